@@ -26,9 +26,7 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	"github.com/minio/cli"
-	json "github.com/minio/colorjson"
 	"github.com/minio/madmin-go"
-	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/console"
 )
 
@@ -181,9 +179,7 @@ func printBandwidth(report madmin.Report, firstPrint bool, bits bool, iec bool)
 		rewindLines = 0
 	}
 	if globalJSON {
-		reportJSON, e := json.MarshalIndent(report, "", "  ")
-		fatalIf(probe.NewError(e), "Unable to marshal to JSON")
-		console.Println(string(reportJSON))
+		printMsg(jsonMessage{report})
 		time.Sleep(1 * time.Second)
 		return
 	}
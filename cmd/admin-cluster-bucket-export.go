@@ -28,7 +28,6 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/minio/cli"
-	json "github.com/minio/colorjson"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/console"
 )
@@ -129,8 +128,6 @@ func mainClusterBucketExport(ctx *cli.Context) error {
 	}{
 		File: downloadPath,
 	}
-	b, e := json.Marshal(v)
-	fatalIf(probe.NewError(e), "Unable to serialize data")
-	console.Println(string(b))
+	printMsg(jsonMessage{v})
 	return nil
 }
@@ -25,7 +25,6 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	"github.com/minio/cli"
-	json "github.com/minio/colorjson"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/console"
 )
@@ -79,9 +78,7 @@ func mainAdminDecommissionStatus(ctx *cli.Context) error {
 		fatalIf(probe.NewError(e).Trace(args...), "Unable to get status per pool")
 
 		if globalJSON {
-			statusJSONBytes, e := json.MarshalIndent(poolStatus, "", "    ")
-			fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
-			console.Println(string(statusJSONBytes))
+			printMsg(jsonMessage{poolStatus})
 			return nil
 		}
 
@@ -118,9 +115,7 @@ func mainAdminDecommissionStatus(ctx *cli.Context) error {
 	fatalIf(probe.NewError(e).Trace(args...), "Unable to get status for all pools")
 
 	if globalJSON {
-		statusJSONBytes, e := json.MarshalIndent(poolStatuses, "", "    ")
-		fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
-		console.Println(string(statusJSONBytes))
+		printMsg(jsonMessage{poolStatuses})
 		return nil
 	}
 
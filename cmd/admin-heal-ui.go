@@ -26,9 +26,7 @@ import (
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/fatih/color"
-	json "github.com/minio/colorjson"
 	"github.com/minio/madmin-go"
-	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/console"
 )
 
@@ -291,9 +289,7 @@ func (ui *uiData) printItemsJSON(s *madmin.HealTaskStatus) (err error) {
 
 	for _, item := range s.Items {
 		h := newHRI(&item)
-		jsonBytes, err := json.MarshalIndent(makeHR(h), "", " ")
-		fatalIf(probe.NewError(err), "Unable to marshal to JSON.")
-		console.Println(string(jsonBytes))
+		printMsg(jsonMessage{makeHR(h)})
 	}
 	return nil
 }
@@ -321,9 +317,7 @@ func (ui *uiData) printStatsJSON(s *madmin.HealTaskStatus) {
 	summary.Size = ui.BytesScanned
 	summary.ElapsedTime = int64(ui.HealDuration.Round(time.Second).Seconds())
 
-	jBytes, err := json.MarshalIndent(summary, "", " ")
-	fatalIf(probe.NewError(err), "Unable to marshal to JSON.")
-	console.Println(string(jBytes))
+	printMsg(jsonMessage{summary})
 }
 
 func (ui *uiData) updateUI(s *madmin.HealTaskStatus) (err error) {
@@ -0,0 +1,133 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var adminIAMApplyCmd = cli.Command{
+	Name:         "apply",
+	Usage:        "converge IAM to a state file",
+	Action:       mainAdminIAMApply,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append([]cli.Flag{adminIAMPruneFlag, adminIAMAllowSecretCommandsFlag}, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] FILE TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Prints the plan of 'mc admin iam plan' and carries it out. Policies are
+  created first, then users, groups and service accounts. Removals with
+  --prune happen last, in reverse order.
+
+  The secret keys of service accounts created without one in FILE are
+  generated by the server and printed once. Secret keys starting with 'cmd:'
+  are commands printing the secret key, they are only run with
+  --allow-secret-commands.
+
+EXAMPLES:
+  1. Converge cluster 'myminio' to iam.yaml.
+     {{.Prompt}} {{.HelpName}} iam.yaml myminio
+
+  2. Converge cluster 'myminio' to iam.yaml, removing unmanaged entities.
+     {{.Prompt}} {{.HelpName}} --prune iam.yaml myminio
+
+  3. Converge cluster 'myminio' to iam.yaml, reading secret keys from 'pass'.
+     {{.Prompt}} {{.HelpName}} --allow-secret-commands iam.yaml myminio
+`,
+}
+
+// iamApplyMessage reports an applied change of an IAM plan.
+type iamApplyMessage struct {
+	Status    string `json:"status"`
+	Action    string `json:"action"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	AccessKey string `json:"accessKey,omitempty"`
+	SecretKey string `json:"secretKey,omitempty"`
+}
+
+func (a iamApplyMessage) String() string {
+	var done string
+	switch a.Action {
+	case iamActionCreate:
+		done = "Created"
+	case iamActionUpdate:
+		done = "Updated"
+	case iamActionReplace:
+		done = "Replaced"
+	case iamActionDelete:
+		done = "Removed"
+	}
+	msg := console.Colorize("IAMApplied", fmt.Sprintf("%s %s `%s`.", done, a.Kind, a.Name))
+	if a.SecretKey != "" {
+		msg += fmt.Sprintf("\n    Access Key: %s\n    Secret Key: %s", a.AccessKey, a.SecretKey)
+	}
+	return msg
+}
+
+func (a iamApplyMessage) JSON() string {
+	a.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(a, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// mainAdminIAMApply is the handle for "mc admin iam apply" command.
+func mainAdminIAMApply(ctx *cli.Context) error {
+	checkAdminIAMPlanSyntax(ctx, "apply")
+	setIAMPlanColors()
+	console.SetColor("IAMApplied", color.New(color.FgGreen))
+
+	aliasedURL := ctx.Args().Get(1)
+	desired, live, changes := planIAMFromContext(ctx)
+	printMsg(newIAMPlanMessage(aliasedURL, changes, iamUnappliedSecrets(desired, live)))
+	if len(changes) == 0 {
+		return nil
+	}
+
+	commands := ctx.Bool("allow-secret-commands")
+	fatalIf(checkIAMSecretCommands(desired, changes, commands), "Unable to apply the plan.")
+
+	client, err := newAdminClient(aliasedURL)
+	fatalIf(err.Trace(aliasedURL), "Unable to initialize admin connection.")
+
+	for _, change := range changes {
+		creds, err := applyIAMChange(globalContext, client, change, desired, live, commands)
+		fatalIf(err.Trace(change.Kind, change.Name), fmt.Sprintf("Unable to %s %s `%s`.", change.Action, change.Kind, change.Name))
+		msg := iamApplyMessage{Action: change.Action, Kind: change.Kind, Name: change.Name}
+		if creds != nil {
+			msg.AccessKey, msg.SecretKey = creds.AccessKey, creds.SecretKey
+		}
+		printMsg(msg)
+	}
+	return nil
+}
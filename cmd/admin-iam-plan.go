@@ -0,0 +1,205 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var adminIAMPlanCmd = cli.Command{
+	Name:         "plan",
+	Usage:        "show the changes converging IAM to a state file",
+	Action:       mainAdminIAMPlan,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append([]cli.Flag{adminIAMPruneFlag}, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] FILE TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  FILE describes the desired policies, users, groups and service accounts in YAML,
+  or in JSON when it has a .json extension:
+
+    policies:
+      logs-rw:
+        file: policies/logs-rw.json   # relative to FILE, or an inline 'document'
+    users:
+      alice:
+        secretKey: "cmd:pass show minio/alice"   # only used to create the user, run with --allow-secret-commands
+        status: enabled
+        policies: [logs-rw]
+    groups:
+      devs:
+        members: [alice]
+        policies: [readonly]
+    serviceAccounts:
+      ci-logs:
+        user: alice
+        policy:
+          file: policies/ci.json
+
+  Entities missing from FILE are left alone, unless --prune is given. Built-in
+  canned policies are never removed. Secret keys can't be read back from the
+  cluster, they are only set when users and service accounts are created: the
+  plan notes the secret keys of existing ones which are not applied.
+
+EXAMPLES:
+  1. Show the changes needed for cluster 'myminio' to match iam.yaml.
+     {{.Prompt}} {{.HelpName}} iam.yaml myminio
+
+  2. Also show the unmanaged entities which would be removed.
+     {{.Prompt}} {{.HelpName}} --prune iam.yaml myminio
+`,
+}
+
+// iamPlanMessage is the Terraform style listing of an IAM plan.
+type iamPlanMessage struct {
+	Status  string      `json:"status"`
+	Target  string      `json:"target"`
+	Changes []iamChange `json:"changes"`
+	Add     int         `json:"add"`
+	Change  int         `json:"change"`
+	Destroy int         `json:"destroy"`
+	Notes   []string    `json:"notes,omitempty"`
+}
+
+func newIAMPlanMessage(target string, changes []iamChange, notes []string) iamPlanMessage {
+	msg := iamPlanMessage{Target: target, Changes: changes, Notes: notes}
+	for _, change := range changes {
+		switch change.Action {
+		case iamActionCreate:
+			msg.Add++
+		case iamActionUpdate:
+			msg.Change++
+		case iamActionReplace:
+			msg.Add++
+			msg.Destroy++
+		case iamActionDelete:
+			msg.Destroy++
+		}
+	}
+	return msg
+}
+
+func (p iamPlanMessage) String() string {
+	var b strings.Builder
+	if len(p.Changes) == 0 {
+		b.WriteString(console.Colorize("IAMUnchanged", "No changes, IAM of `"+p.Target+"` matches the state file."))
+		p.writeNotes(&b)
+		return b.String()
+	}
+	for _, change := range p.Changes {
+		var sign, theme string
+		switch change.Action {
+		case iamActionCreate:
+			sign, theme = "+", "IAMCreate"
+		case iamActionUpdate:
+			sign, theme = "~", "IAMUpdate"
+		case iamActionReplace:
+			sign, theme = "-/+", "IAMReplace"
+		case iamActionDelete:
+			sign, theme = "-", "IAMDelete"
+		}
+		fmt.Fprintf(&b, "%s %s %s\n", console.Colorize(theme, fmt.Sprintf("%3s", sign)), change.Kind, change.Name)
+		for _, field := range change.Fields {
+			if change.Action == iamActionCreate {
+				fmt.Fprintf(&b, "      %s: %s\n", field.Field, field.New)
+				continue
+			}
+			fmt.Fprintf(&b, "      %s: %s -> %s\n", field.Field, iamFieldValue(field.Old), iamFieldValue(field.New))
+		}
+	}
+	fmt.Fprintf(&b, "\nPlan: %d to add, %d to change, %d to destroy.", p.Add, p.Change, p.Destroy)
+	p.writeNotes(&b)
+	return b.String()
+}
+
+func (p iamPlanMessage) writeNotes(b *strings.Builder) {
+	for _, note := range p.Notes {
+		fmt.Fprintf(b, "\n%s %s", console.Colorize("IAMNote", "Note:"), note)
+	}
+}
+
+func iamFieldValue(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func (p iamPlanMessage) JSON() string {
+	p.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(p, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func setIAMPlanColors() {
+	console.SetColor("IAMUnchanged", color.New(color.FgGreen))
+	console.SetColor("IAMCreate", color.New(color.FgGreen, color.Bold))
+	console.SetColor("IAMUpdate", color.New(color.FgYellow, color.Bold))
+	console.SetColor("IAMReplace", color.New(color.FgMagenta, color.Bold))
+	console.SetColor("IAMDelete", color.New(color.FgRed, color.Bold))
+	console.SetColor("IAMNote", color.New(color.FgYellow))
+}
+
+func checkAdminIAMPlanSyntax(ctx *cli.Context, name string) {
+	if len(ctx.Args()) != 2 {
+		cli.ShowCommandHelpAndExit(ctx, name, 1) // last argument is exit code
+	}
+}
+
+// planIAMFromContext loads the state file and the IAM state of the
+// target, returning the plan converging them.
+func planIAMFromContext(ctx *cli.Context) (iamState, iamState, []iamChange) {
+	file, aliasedURL := ctx.Args().Get(0), ctx.Args().Get(1)
+
+	desired, err := loadIAMStateFile(file)
+	fatalIf(err.Trace(file), "Unable to load IAM state file.")
+
+	client, err := newAdminClient(aliasedURL)
+	fatalIf(err.Trace(aliasedURL), "Unable to initialize admin connection.")
+
+	live, err := fetchIAMState(globalContext, client)
+	fatalIf(err.Trace(aliasedURL), "Unable to read IAM state of `"+aliasedURL+"`.")
+
+	return desired, live, planIAM(desired, live, ctx.Bool("prune"))
+}
+
+// mainAdminIAMPlan is the handle for "mc admin iam plan" command.
+func mainAdminIAMPlan(ctx *cli.Context) error {
+	checkAdminIAMPlanSyntax(ctx, "plan")
+	setIAMPlanColors()
+
+	desired, live, changes := planIAMFromContext(ctx)
+	printMsg(newIAMPlanMessage(ctx.Args().Get(1), changes, iamUnappliedSecrets(desired, live)))
+	return nil
+}
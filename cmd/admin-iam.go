@@ -0,0 +1,732 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	iampolicy "github.com/minio/pkg/iam/policy"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var adminIAMSubcommands = []cli.Command{
+	adminIAMPlanCmd,
+	adminIAMApplyCmd,
+}
+
+var adminIAMCmd = cli.Command{
+	Name:            "iam",
+	Usage:           "manage IAM declaratively from a state file",
+	Action:          mainAdminIAM,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	Subcommands:     adminIAMSubcommands,
+	HideHelpCommand: true,
+}
+
+// mainAdminIAM is the handle for "mc admin iam" command.
+func mainAdminIAM(ctx *cli.Context) error {
+	commandNotFound(ctx, adminIAMSubcommands)
+	return nil
+	// Sub-commands like "plan", "apply" have their own main.
+}
+
+var adminIAMPruneFlag = cli.BoolFlag{
+	Name:  "prune",
+	Usage: "remove users, groups, policies and service accounts missing from the state file",
+}
+
+var adminIAMAllowSecretCommandsFlag = cli.BoolFlag{
+	Name:  "allow-secret-commands",
+	Usage: "run the 'cmd:' secret keys of the state file to read them",
+}
+
+// Kinds of IAM entities, in the order they are created.
+const (
+	iamKindPolicy  = "policy"
+	iamKindUser    = "user"
+	iamKindGroup   = "group"
+	iamKindSvcAcct = "svcacct"
+)
+
+var iamKinds = []string{iamKindPolicy, iamKindUser, iamKindGroup, iamKindSvcAcct}
+
+// Actions of an IAM change.
+const (
+	iamActionCreate  = "create"
+	iamActionUpdate  = "update"
+	iamActionReplace = "replace"
+	iamActionDelete  = "delete"
+)
+
+// Canned policies of MinIO are never pruned.
+var iamBuiltinPolicies = map[string]bool{
+	"readwrite":    true,
+	"readonly":     true,
+	"writeonly":    true,
+	"diagnostics":  true,
+	"consoleAdmin": true,
+}
+
+// iamStateFile is the human editable desired IAM state, in YAML or JSON.
+type iamStateFile struct {
+	Policies        map[string]iamPolicySpec         `json:"policies"`
+	Users           map[string]iamUserSpec           `json:"users"`
+	Groups          map[string]iamGroupSpec          `json:"groups"`
+	ServiceAccounts map[string]iamServiceAccountSpec `json:"serviceAccounts"`
+}
+
+// iamPolicySpec is a policy document, inline or in a file relative
+// to the state file.
+type iamPolicySpec struct {
+	File     string          `json:"file,omitempty"`
+	Document json.RawMessage `json:"document,omitempty"`
+}
+
+type iamUserSpec struct {
+	SecretKey string   `json:"secretKey,omitempty"`
+	Status    string   `json:"status,omitempty"`
+	Policies  []string `json:"policies,omitempty"`
+}
+
+type iamGroupSpec struct {
+	Status   string   `json:"status,omitempty"`
+	Members  []string `json:"members,omitempty"`
+	Policies []string `json:"policies,omitempty"`
+}
+
+type iamServiceAccountSpec struct {
+	User      string         `json:"user"`
+	SecretKey string         `json:"secretKey,omitempty"`
+	Status    string         `json:"status,omitempty"`
+	Policy    *iamPolicySpec `json:"policy,omitempty"`
+}
+
+// iamState is the IAM state of a cluster or of a state file,
+// normalized to be compared.
+type iamState struct {
+	Policies        map[string]*iamPolicy
+	Users           map[string]iamUser
+	Groups          map[string]iamGroup
+	ServiceAccounts map[string]iamServiceAccount
+}
+
+type iamPolicy struct {
+	doc    []byte
+	policy *iampolicy.Policy
+}
+
+type iamUser struct {
+	secretKey string
+	status    string
+	policies  []string
+}
+
+type iamGroup struct {
+	status   string
+	members  []string
+	policies []string
+}
+
+// iamServiceAccount has no policy when it inherits the policies
+// of its user.
+type iamServiceAccount struct {
+	user      string
+	secretKey string
+	status    string
+	policy    *iamPolicy
+}
+
+func newIAMState() iamState {
+	return iamState{
+		Policies:        make(map[string]*iamPolicy),
+		Users:           make(map[string]iamUser),
+		Groups:          make(map[string]iamGroup),
+		ServiceAccounts: make(map[string]iamServiceAccount),
+	}
+}
+
+func newIAMPolicy(doc []byte) (*iamPolicy, *probe.Error) {
+	p, e := iampolicy.ParseConfig(bytes.NewReader(doc))
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	var buf bytes.Buffer
+	if e = json.Compact(&buf, doc); e != nil {
+		return nil, probe.NewError(e)
+	}
+	return &iamPolicy{doc: buf.Bytes(), policy: p}, nil
+}
+
+func (p *iamPolicy) equals(q *iamPolicy) bool {
+	if p == nil || q == nil {
+		return p == q
+	}
+	return p.policy.Equals(*q.policy)
+}
+
+func (p *iamPolicy) String() string {
+	if p == nil {
+		return "(inherited)"
+	}
+	return string(p.doc)
+}
+
+// iamStatus normalizes an account or group status.
+func iamStatus(status string) (string, *probe.Error) {
+	switch strings.ToLower(status) {
+	case "", "enabled", "enable":
+		return "enabled", nil
+	case "disabled", "disable":
+		return "disabled", nil
+	}
+	return "", probe.NewError(fmt.Errorf("unknown status %s, valid options are `[enabled, disabled]`", status))
+}
+
+// iamNames returns a sorted copy of names without duplicates.
+func iamNames(names []string) []string {
+	var sorted []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+	uniq := sorted[:0]
+	for i, name := range sorted {
+		if i == 0 || name != sorted[i-1] {
+			uniq = append(uniq, name)
+		}
+	}
+	return uniq
+}
+
+// splitIAMNames splits a comma separated list of policies.
+func splitIAMNames(s string) []string {
+	return iamNames(strings.Split(s, ","))
+}
+
+// yamlToJSON converts decoded YAML to values encoding/json accepts.
+func yamlToJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = yamlToJSON(val)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = yamlToJSON(v[i])
+		}
+	}
+	return v
+}
+
+// loadIAMStateFile reads the desired IAM state from a YAML or JSON file.
+func loadIAMStateFile(path string) (iamState, *probe.Error) {
+	state := newIAMState()
+	buf, e := ioutil.ReadFile(path)
+	if e != nil {
+		return state, probe.NewError(e)
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".json" {
+		var v interface{}
+		if e = yaml.Unmarshal(buf, &v); e != nil {
+			return state, probe.NewError(e)
+		}
+		if buf, e = json.Marshal(yamlToJSON(v)); e != nil {
+			return state, probe.NewError(e)
+		}
+	}
+	var file iamStateFile
+	if e = json.Unmarshal(buf, &file); e != nil {
+		return state, probe.NewError(e)
+	}
+
+	dir := filepath.Dir(path)
+	loadPolicy := func(spec iamPolicySpec) (*iamPolicy, *probe.Error) {
+		doc := []byte(spec.Document)
+		if spec.File != "" {
+			if len(doc) > 0 {
+				return nil, probe.NewError(fmt.Errorf("only one of file and document can be set"))
+			}
+			if !filepath.IsAbs(spec.File) {
+				spec.File = filepath.Join(dir, spec.File)
+			}
+			if doc, e = ioutil.ReadFile(spec.File); e != nil {
+				return nil, probe.NewError(e)
+			}
+		}
+		if len(doc) == 0 {
+			return nil, probe.NewError(fmt.Errorf("policy has no document"))
+		}
+		return newIAMPolicy(doc)
+	}
+
+	for name, spec := range file.Policies {
+		p, err := loadPolicy(spec)
+		if err != nil {
+			return state, err.Trace(iamKindPolicy, name)
+		}
+		state.Policies[name] = p
+	}
+	for name, spec := range file.Users {
+		status, err := iamStatus(spec.Status)
+		if err != nil {
+			return state, err.Trace(iamKindUser, name)
+		}
+		state.Users[name] = iamUser{secretKey: spec.SecretKey, status: status, policies: iamNames(spec.Policies)}
+	}
+	for name, spec := range file.Groups {
+		status, err := iamStatus(spec.Status)
+		if err != nil {
+			return state, err.Trace(iamKindGroup, name)
+		}
+		state.Groups[name] = iamGroup{status: status, members: iamNames(spec.Members), policies: iamNames(spec.Policies)}
+	}
+	for name, spec := range file.ServiceAccounts {
+		status, err := iamStatus(spec.Status)
+		if err != nil {
+			return state, err.Trace(iamKindSvcAcct, name)
+		}
+		if spec.User == "" {
+			return state, probe.NewError(fmt.Errorf("service account has no user")).Trace(iamKindSvcAcct, name)
+		}
+		svc := iamServiceAccount{user: spec.User, secretKey: spec.SecretKey, status: status}
+		if spec.Policy != nil {
+			if svc.policy, err = loadPolicy(*spec.Policy); err != nil {
+				return state, err.Trace(iamKindSvcAcct, name)
+			}
+		}
+		state.ServiceAccounts[name] = svc
+	}
+	return state, nil
+}
+
+// fetchIAMState reads the IAM state of a cluster.
+func fetchIAMState(ctx context.Context, client *madmin.AdminClient) (iamState, *probe.Error) {
+	state := newIAMState()
+
+	policies, e := client.ListCannedPolicies(ctx)
+	if e != nil {
+		return state, probe.NewError(e)
+	}
+	for name, doc := range policies {
+		p, err := newIAMPolicy(doc)
+		if err != nil {
+			return state, err.Trace(iamKindPolicy, name)
+		}
+		state.Policies[name] = p
+	}
+
+	users, e := client.ListUsers(ctx)
+	if e != nil {
+		return state, probe.NewError(e)
+	}
+	for name, info := range users {
+		status, _ := iamStatus(string(info.Status))
+		state.Users[name] = iamUser{status: status, policies: splitIAMNames(info.PolicyName)}
+
+		svcs, e := client.ListServiceAccounts(ctx, name)
+		if e != nil {
+			return state, probe.NewError(e).Trace(iamKindUser, name)
+		}
+		for _, accessKey := range svcs.Accounts {
+			info, e := client.InfoServiceAccount(ctx, accessKey)
+			if e != nil {
+				return state, probe.NewError(e).Trace(iamKindSvcAcct, accessKey)
+			}
+			status, _ := iamStatus(info.AccountStatus)
+			svc := iamServiceAccount{user: info.ParentUser, status: status}
+			if !info.ImpliedPolicy && info.Policy != "" {
+				var err *probe.Error
+				if svc.policy, err = newIAMPolicy([]byte(info.Policy)); err != nil {
+					return state, err.Trace(iamKindSvcAcct, accessKey)
+				}
+			}
+			state.ServiceAccounts[accessKey] = svc
+		}
+	}
+
+	groups, e := client.ListGroups(ctx)
+	if e != nil {
+		return state, probe.NewError(e)
+	}
+	for _, name := range groups {
+		desc, e := client.GetGroupDescription(ctx, name)
+		if e != nil {
+			return state, probe.NewError(e).Trace(iamKindGroup, name)
+		}
+		status, _ := iamStatus(desc.Status)
+		state.Groups[name] = iamGroup{status: status, members: iamNames(desc.Members), policies: splitIAMNames(desc.Policy)}
+	}
+	return state, nil
+}
+
+// iamChange is one step of an IAM plan.
+type iamChange struct {
+	Action string           `json:"action"`
+	Kind   string           `json:"kind"`
+	Name   string           `json:"name"`
+	Fields []iamFieldChange `json:"fields,omitempty"`
+}
+
+type iamFieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// diffField appends a field change when old and new differ.
+func diffField(fields []iamFieldChange, field, old, new string) []iamFieldChange {
+	if old == new {
+		return fields
+	}
+	return append(fields, iamFieldChange{Field: field, Old: old, New: new})
+}
+
+func joinIAMNames(names []string) string {
+	return strings.Join(names, ", ")
+}
+
+func sortedIAMNames(kind string, s iamState) (names []string) {
+	switch kind {
+	case iamKindPolicy:
+		for name := range s.Policies {
+			names = append(names, name)
+		}
+	case iamKindUser:
+		for name := range s.Users {
+			names = append(names, name)
+		}
+	case iamKindGroup:
+		for name := range s.Groups {
+			names = append(names, name)
+		}
+	case iamKindSvcAcct:
+		for name := range s.ServiceAccounts {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diffIAMEntity returns the field changes from the live entity to the
+// desired one, a nil live entity is created.
+func diffIAMEntity(kind, name string, desired, live iamState, exists bool) (fields []iamFieldChange, replace bool) {
+	switch kind {
+	case iamKindPolicy:
+		if !exists || !desired.Policies[name].equals(live.Policies[name]) {
+			old := ""
+			if exists {
+				old = live.Policies[name].String()
+			}
+			fields = diffField(fields, "document", old, desired.Policies[name].String())
+		}
+	case iamKindUser:
+		d, l := desired.Users[name], live.Users[name]
+		fields = diffField(fields, "status", l.status, d.status)
+		fields = diffField(fields, "policies", joinIAMNames(l.policies), joinIAMNames(d.policies))
+	case iamKindGroup:
+		d, l := desired.Groups[name], live.Groups[name]
+		fields = diffField(fields, "status", l.status, d.status)
+		fields = diffField(fields, "members", joinIAMNames(l.members), joinIAMNames(d.members))
+		fields = diffField(fields, "policies", joinIAMNames(l.policies), joinIAMNames(d.policies))
+	case iamKindSvcAcct:
+		d, l := desired.ServiceAccounts[name], live.ServiceAccounts[name]
+		fields = diffField(fields, "user", l.user, d.user)
+		fields = diffField(fields, "status", l.status, d.status)
+		if !exists || !d.policy.equals(l.policy) {
+			old := ""
+			if exists {
+				old = l.policy.String()
+			}
+			fields = diffField(fields, "policy", old, d.policy.String())
+		}
+		// Service accounts can't move to another user.
+		replace = exists && l.user != d.user
+	}
+	return fields, replace
+}
+
+// planIAM returns the changes converging the live IAM state to the
+// desired one. Entities are created and updated in dependency order
+// and deleted in reverse, unmanaged entities are deleted with prune.
+func planIAM(desired, live iamState, prune bool) (changes []iamChange) {
+	exists := func(kind, name string, s iamState) (ok bool) {
+		switch kind {
+		case iamKindPolicy:
+			_, ok = s.Policies[name]
+		case iamKindUser:
+			_, ok = s.Users[name]
+		case iamKindGroup:
+			_, ok = s.Groups[name]
+		case iamKindSvcAcct:
+			_, ok = s.ServiceAccounts[name]
+		}
+		return ok
+	}
+
+	for _, kind := range iamKinds {
+		for _, name := range sortedIAMNames(kind, desired) {
+			found := exists(kind, name, live)
+			fields, replace := diffIAMEntity(kind, name, desired, live, found)
+			switch {
+			case !found:
+				changes = append(changes, iamChange{Action: iamActionCreate, Kind: kind, Name: name, Fields: fields})
+			case replace:
+				changes = append(changes, iamChange{Action: iamActionReplace, Kind: kind, Name: name, Fields: fields})
+			case len(fields) > 0:
+				changes = append(changes, iamChange{Action: iamActionUpdate, Kind: kind, Name: name, Fields: fields})
+			}
+		}
+	}
+
+	if !prune {
+		return changes
+	}
+	for i := len(iamKinds) - 1; i >= 0; i-- {
+		kind := iamKinds[i]
+		for _, name := range sortedIAMNames(kind, live) {
+			if exists(kind, name, desired) || (kind == iamKindPolicy && iamBuiltinPolicies[name]) {
+				continue
+			}
+			// Service accounts are removed along with their user.
+			if kind == iamKindSvcAcct && !exists(iamKindUser, live.ServiceAccounts[name].user, desired) {
+				continue
+			}
+			changes = append(changes, iamChange{Action: iamActionDelete, Kind: kind, Name: name})
+		}
+	}
+	return changes
+}
+
+// iamUnappliedSecrets returns a note for every existing user and
+// service account with a secretKey in the state file: secret keys
+// can't be read back to be compared, they are only set on creation.
+func iamUnappliedSecrets(desired, live iamState) (notes []string) {
+	for _, name := range sortedIAMNames(iamKindUser, desired) {
+		if _, ok := live.Users[name]; ok && desired.Users[name].secretKey != "" {
+			notes = append(notes, fmt.Sprintf("secretKey not applied to existing user `%s`.", name))
+		}
+	}
+	for _, name := range sortedIAMNames(iamKindSvcAcct, desired) {
+		l, ok := live.ServiceAccounts[name]
+		// Replaced service accounts are created again with their secret key.
+		if d := desired.ServiceAccounts[name]; ok && d.secretKey != "" && d.user == l.user {
+			notes = append(notes, fmt.Sprintf("secretKey not applied to existing service account `%s`.", name))
+		}
+	}
+	return notes
+}
+
+// checkIAMSecretCommands returns an error if carrying out changes
+// would run a 'cmd:' secret key while commands are not allowed.
+func checkIAMSecretCommands(desired iamState, changes []iamChange, commands bool) *probe.Error {
+	if commands {
+		return nil
+	}
+	for _, change := range changes {
+		var secretKey string
+		switch {
+		case change.Kind == iamKindUser && change.Action == iamActionCreate:
+			secretKey = desired.Users[change.Name].secretKey
+		case change.Kind == iamKindSvcAcct && (change.Action == iamActionCreate || change.Action == iamActionReplace):
+			secretKey = desired.ServiceAccounts[change.Name].secretKey
+		}
+		if strings.HasPrefix(secretKey, secretCommandPrefix) {
+			return probe.NewError(fmt.Errorf("secretKey of %s %s is a command, it is only run with --allow-secret-commands", change.Kind, change.Name))
+		}
+	}
+	return nil
+}
+
+// applyIAMChange carries out one change of a plan, 'cmd:' secret keys
+// are only run when commands are allowed.
+func applyIAMChange(ctx context.Context, client *madmin.AdminClient, change iamChange, desired, live iamState, commands bool) (*madmin.Credentials, *probe.Error) {
+	switch change.Kind {
+	case iamKindPolicy:
+		if change.Action == iamActionDelete {
+			return nil, probe.NewError(client.RemoveCannedPolicy(ctx, change.Name))
+		}
+		return nil, probe.NewError(client.AddCannedPolicy(ctx, change.Name, desired.Policies[change.Name].doc))
+	case iamKindUser:
+		return nil, applyIAMUser(ctx, client, change, desired.Users[change.Name], commands)
+	case iamKindGroup:
+		return nil, applyIAMGroup(ctx, client, change, desired.Groups[change.Name], live.Groups[change.Name])
+	case iamKindSvcAcct:
+		return applyIAMServiceAccount(ctx, client, change, desired.ServiceAccounts[change.Name], commands)
+	}
+	return nil, errInvalidArgument().Trace(change.Kind)
+}
+
+func applyIAMUser(ctx context.Context, client *madmin.AdminClient, change iamChange, user iamUser, commands bool) *probe.Error {
+	switch change.Action {
+	case iamActionDelete:
+		return probe.NewError(client.RemoveUser(ctx, change.Name))
+	case iamActionCreate:
+		if user.secretKey == "" {
+			return probe.NewError(fmt.Errorf("secretKey is required to create user %s", change.Name))
+		}
+		secretKey, err := resolveSecret(nil, "", user.secretKey, commands, false)
+		if err != nil {
+			return err.Trace(change.Name)
+		}
+		if e := client.SetUser(ctx, change.Name, secretKey, madmin.AccountStatus(user.status)); e != nil {
+			return probe.NewError(e)
+		}
+		if len(user.policies) > 0 {
+			return probe.NewError(client.SetPolicy(ctx, strings.Join(user.policies, ","), change.Name, false))
+		}
+		return nil
+	}
+	for _, field := range change.Fields {
+		var e error
+		switch field.Field {
+		case "status":
+			e = client.SetUserStatus(ctx, change.Name, madmin.AccountStatus(user.status))
+		case "policies":
+			e = client.SetPolicy(ctx, strings.Join(user.policies, ","), change.Name, false)
+		}
+		if e != nil {
+			return probe.NewError(e).Trace(field.Field)
+		}
+	}
+	return nil
+}
+
+func applyIAMGroup(ctx context.Context, client *madmin.AdminClient, change iamChange, group, live iamGroup) *probe.Error {
+	if change.Action == iamActionDelete {
+		if len(live.members) > 0 {
+			if e := client.UpdateGroupMembers(ctx, madmin.GroupAddRemove{Group: change.Name, Members: live.members, IsRemove: true}); e != nil {
+				return probe.NewError(e)
+			}
+		}
+		// Removing no members deletes the empty group.
+		return probe.NewError(client.UpdateGroupMembers(ctx, madmin.GroupAddRemove{Group: change.Name, IsRemove: true}))
+	}
+
+	var added, removed []string
+	for _, m := range group.members {
+		if !containsIAMName(live.members, m) {
+			added = append(added, m)
+		}
+	}
+	for _, m := range live.members {
+		if !containsIAMName(group.members, m) {
+			removed = append(removed, m)
+		}
+	}
+	if len(added) > 0 || change.Action == iamActionCreate {
+		if e := client.UpdateGroupMembers(ctx, madmin.GroupAddRemove{Group: change.Name, Members: added}); e != nil {
+			return probe.NewError(e).Trace("members")
+		}
+	}
+	if len(removed) > 0 {
+		if e := client.UpdateGroupMembers(ctx, madmin.GroupAddRemove{Group: change.Name, Members: removed, IsRemove: true}); e != nil {
+			return probe.NewError(e).Trace("members")
+		}
+	}
+	for _, field := range change.Fields {
+		var e error
+		switch field.Field {
+		case "status":
+			e = client.SetGroupStatus(ctx, change.Name, madmin.GroupStatus(group.status))
+		case "policies":
+			e = client.SetPolicy(ctx, strings.Join(group.policies, ","), change.Name, true)
+		}
+		if e != nil {
+			return probe.NewError(e).Trace(field.Field)
+		}
+	}
+	return nil
+}
+
+func containsIAMName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyIAMServiceAccount returns the credentials of created service
+// accounts whose secret key was generated by the server.
+func applyIAMServiceAccount(ctx context.Context, client *madmin.AdminClient, change iamChange, svc iamServiceAccount, commands bool) (*madmin.Credentials, *probe.Error) {
+	switch change.Action {
+	case iamActionDelete:
+		return nil, probe.NewError(client.DeleteServiceAccount(ctx, change.Name))
+	case iamActionReplace:
+		if e := client.DeleteServiceAccount(ctx, change.Name); e != nil {
+			return nil, probe.NewError(e)
+		}
+		fallthrough
+	case iamActionCreate:
+		secretKey, err := resolveSecret(nil, "", svc.secretKey, commands, false)
+		if err != nil {
+			return nil, err.Trace(change.Name)
+		}
+		opts := madmin.AddServiceAccountReq{TargetUser: svc.user, AccessKey: change.Name, SecretKey: secretKey}
+		if svc.policy != nil {
+			opts.Policy = svc.policy.doc
+		}
+		creds, e := client.AddServiceAccount(ctx, opts)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		if svc.status == "disabled" {
+			if e = client.UpdateServiceAccount(ctx, change.Name, madmin.UpdateServiceAccountReq{NewStatus: svc.status}); e != nil {
+				return nil, probe.NewError(e)
+			}
+		}
+		if svc.secretKey == "" {
+			return &creds, nil
+		}
+		return nil, nil
+	}
+	opts := madmin.UpdateServiceAccountReq{}
+	for _, field := range change.Fields {
+		switch field.Field {
+		case "status":
+			opts.NewStatus = svc.status
+		case "policy":
+			if svc.policy == nil {
+				// An empty statement list makes the service account
+				// inherit the policies of its user again.
+				opts.NewPolicy = json.RawMessage(`{"Version":"2012-10-17","Statement":[]}`)
+			} else {
+				opts.NewPolicy = svc.policy.doc
+			}
+		}
+	}
+	return nil, probe.NewError(client.UpdateServiceAccount(ctx, change.Name, opts))
+}
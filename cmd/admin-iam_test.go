@@ -0,0 +1,153 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testIAMPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::logs/*"]}]}`
+
+const testIAMStateFile = `
+policies:
+  logs-ro:
+    file: logs-ro.json
+  logs-rw:
+    document:
+      Version: "2012-10-17"
+      Statement:
+        - Effect: Allow
+          Action: ["s3:*"]
+          Resource: ["arn:aws:s3:::logs/*"]
+users:
+  alice:
+    secretKey: alice-secret
+    policies: [logs-rw, logs-ro]
+  bob:
+    secretKey: "cmd:pass show minio/bob"
+    status: disabled
+groups:
+  devs:
+    members: [bob, alice]
+    policies: [readonly]
+serviceAccounts:
+  ci-logs:
+    user: alice
+    policy:
+      file: logs-ro.json
+`
+
+func TestAdminIAMPlan(t *testing.T) {
+	dir, e := ioutil.TempDir("", "mc-iam")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	if e = ioutil.WriteFile(filepath.Join(dir, "logs-ro.json"), []byte(testIAMPolicy), 0o600); e != nil {
+		t.Fatal(e)
+	}
+	file := filepath.Join(dir, "iam.yaml")
+	if e = ioutil.WriteFile(file, []byte(testIAMStateFile), 0o600); e != nil {
+		t.Fatal(e)
+	}
+
+	desired, err := loadIAMStateFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users := desired.Users["alice"].policies; !reflect.DeepEqual(users, []string{"logs-ro", "logs-rw"}) {
+		t.Fatalf("unexpected policies %v", users)
+	}
+
+	// The same policy with reordered and unwrapped values is unchanged.
+	logsRO, err := newIAMPolicy([]byte(`{"Statement":[{"Resource":"arn:aws:s3:::logs/*","Action":"s3:GetObject","Effect":"Allow"}],"Version":"2012-10-17"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	readonly, _ := newIAMPolicy([]byte(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::*"]}]}`))
+	live := newIAMState()
+	live.Policies["logs-ro"] = logsRO
+	live.Policies["readonly"] = readonly
+	live.Policies["old"] = readonly
+	live.Users["alice"] = iamUser{status: "enabled", policies: []string{"logs-ro"}}
+	live.Users["carol"] = iamUser{status: "enabled"}
+	live.Groups["devs"] = iamGroup{status: "enabled", members: []string{"alice", "carol"}, policies: []string{"readonly"}}
+	live.ServiceAccounts["ci-logs"] = iamServiceAccount{user: "carol", status: "enabled"}
+	live.ServiceAccounts["alice-old"] = iamServiceAccount{user: "alice", status: "enabled"}
+	live.ServiceAccounts["carol-svc"] = iamServiceAccount{user: "carol", status: "enabled"}
+
+	type step struct{ action, kind, name string }
+	steps := func(changes []iamChange) (s []step) {
+		for _, c := range changes {
+			s = append(s, step{c.Action, c.Kind, c.Name})
+		}
+		return s
+	}
+
+	changes := planIAM(desired, live, false)
+	expected := []step{
+		{iamActionCreate, iamKindPolicy, "logs-rw"},
+		{iamActionUpdate, iamKindUser, "alice"},
+		{iamActionCreate, iamKindUser, "bob"},
+		{iamActionUpdate, iamKindGroup, "devs"},
+		{iamActionReplace, iamKindSvcAcct, "ci-logs"},
+	}
+	if !reflect.DeepEqual(steps(changes), expected) {
+		t.Fatalf("expected %v, got %v", expected, steps(changes))
+	}
+	if fields := changes[3].Fields; len(fields) != 1 || fields[0] != (iamFieldChange{"members", "alice, carol", "alice, bob"}) {
+		t.Fatalf("unexpected group changes %v", fields)
+	}
+	msg := newIAMPlanMessage("myminio", changes, nil)
+	if msg.Add != 3 || msg.Change != 2 || msg.Destroy != 1 {
+		t.Fatalf("unexpected plan summary %+v", msg)
+	}
+
+	// The secret key of existing users is reported as not applied.
+	if notes := iamUnappliedSecrets(desired, live); !reflect.DeepEqual(notes, []string{"secretKey not applied to existing user `alice`."}) {
+		t.Fatalf("unexpected notes %v", notes)
+	}
+
+	// Secret key commands only run when allowed.
+	if checkIAMSecretCommands(desired, changes, false) == nil {
+		t.Fatal("expected the secret key command of bob to be refused")
+	}
+	if err = checkIAMSecretCommands(desired, changes, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pruning removes unmanaged entities in reverse order, except the
+	// built-in policies and service accounts of removed users.
+	changes = planIAM(desired, live, true)
+	expected = append(expected,
+		step{iamActionDelete, iamKindSvcAcct, "alice-old"},
+		step{iamActionDelete, iamKindUser, "carol"},
+		step{iamActionDelete, iamKindPolicy, "old"},
+	)
+	if !reflect.DeepEqual(steps(changes), expected) {
+		t.Fatalf("expected %v, got %v", expected, steps(changes))
+	}
+
+	if len(planIAM(desired, desired, true)) != 0 {
+		t.Fatal("a state should have no changes against itself")
+	}
+}
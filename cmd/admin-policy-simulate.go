@@ -0,0 +1,385 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/bucket/policy"
+	"github.com/minio/pkg/bucket/policy/condition"
+	"github.com/minio/pkg/console"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+var adminPolicySimulateFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "user",
+		Usage: "user or service account whose policies are evaluated",
+	},
+	cli.StringFlag{
+		Name:  "action",
+		Usage: "action to simulate, e.g. s3:GetObject or admin:ServerInfo",
+	},
+	cli.StringFlag{
+		Name:  "resource",
+		Usage: "bucket or BUCKET/OBJECT the action applies to",
+	},
+	cli.StringSliceFlag{
+		Name:  "condition",
+		Usage: "condition value of the request in the form KEY=VALUE, e.g. aws:SourceIp=10.0.0.1",
+	},
+	cli.StringSliceFlag{
+		Name:  "policy",
+		Usage: "policy JSON file evaluated in addition to the policies of the user",
+	},
+}
+
+var adminPolicySimulateCmd = cli.Command{
+	Name:         "simulate",
+	Usage:        "evaluate whether policies allow a request",
+	Action:       mainAdminPolicySimulate,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(adminPolicySimulateFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [TARGET] --action ACTION [--resource BUCKET/OBJECT] [FLAGS]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  With TARGET, the policies attached to --user and to its enabled groups are
+  fetched from the server. The embedded policy of a service account further
+  restricts the policies of its parent user. Without TARGET, only the --policy
+  files are evaluated, to test policies before they are added.
+
+  The decision is printed along with the statement which made it. A request is
+  denied by any matching Deny statement, otherwise allowed by a matching Allow
+  statement and implicitly denied when none matches.
+
+  The username and userid policy variables are set to --user, and CurrentTime
+  and EpochTime to the current time, unless given with --condition.
+
+EXAMPLES:
+  1. Check whether user 'alice' can download 'logs/2022/app.log'.
+     {{.Prompt}} {{.HelpName}} myminio --user alice --action s3:GetObject --resource logs/2022/app.log
+
+  2. Check a service account request coming from a given address.
+     {{.Prompt}} {{.HelpName}} myminio --user svc-ci --action s3:PutObject --resource builds/app.tgz \
+                 --condition aws:SourceIp=10.0.0.12
+
+  3. Test a policy file before adding it to the server.
+     {{.Prompt}} {{.HelpName}} --policy logs-ro.json --action s3:DeleteObject --resource logs/app.log
+`,
+}
+
+// simulatedPolicy is a named policy taking part in a simulation.
+type simulatedPolicy struct {
+	name   string
+	policy *iampolicy.Policy
+}
+
+// policyDecision is the outcome of evaluating policies for a request,
+// with the statement which decided it.
+type policyDecision struct {
+	Allowed   bool            `json:"allowed"`
+	Explicit  bool            `json:"explicit"`
+	Policy    string          `json:"policy,omitempty"`
+	Statement int             `json:"statement,omitempty"`
+	Document  json.RawMessage `json:"document,omitempty"`
+}
+
+func newPolicyDecision(allowed bool, p simulatedPolicy, i int) policyDecision {
+	doc, _ := json.Marshal(p.policy.Statements[i])
+	return policyDecision{Allowed: allowed, Explicit: true, Policy: p.name, Statement: i + 1, Document: doc}
+}
+
+// evaluatePolicies evaluates the statements of all policies like the
+// server does: any matching Deny statement denies the request, then
+// any matching Allow statement allows it.
+func evaluatePolicies(policies []simulatedPolicy, args iampolicy.Args) policyDecision {
+	for _, p := range policies {
+		for i, statement := range p.policy.Statements {
+			if statement.Effect == policy.Deny && !statement.IsAllowed(args) {
+				return newPolicyDecision(false, p, i)
+			}
+		}
+	}
+	for _, p := range policies {
+		for i, statement := range p.policy.Statements {
+			if statement.Effect == policy.Allow && statement.IsAllowed(args) {
+				return newPolicyDecision(true, p, i)
+			}
+		}
+	}
+	return policyDecision{}
+}
+
+// simulationPrincipal holds the policies applying to a user.
+type simulationPrincipal struct {
+	user       string
+	parentUser string
+	groups     []string
+	policies   []simulatedPolicy
+	// Embedded policies of a service account, which must allow the
+	// request along with the policies of its parent user.
+	embedded []simulatedPolicy
+}
+
+// fetchSimulationPrincipal fetches the policies of a user, its groups
+// and, for service accounts, the embedded policy.
+func fetchSimulationPrincipal(ctx context.Context, client *madmin.AdminClient, user string) (simulationPrincipal, *probe.Error) {
+	principal := simulationPrincipal{user: user}
+
+	if info, e := client.InfoServiceAccount(ctx, user); e == nil {
+		principal.parentUser = info.ParentUser
+		if !info.ImpliedPolicy && info.Policy != "" {
+			p, e := iampolicy.ParseConfig(strings.NewReader(info.Policy))
+			if e != nil {
+				return principal, probe.NewError(e).Trace(user)
+			}
+			principal.embedded = []simulatedPolicy{{name: "embedded policy of " + user, policy: p}}
+		}
+		user = info.ParentUser
+	}
+
+	userInfo, e := client.GetUserInfo(ctx, user)
+	if e != nil {
+		return principal, probe.NewError(e).Trace(user)
+	}
+	if userInfo.Status == madmin.AccountDisabled {
+		return principal, probe.NewError(fmt.Errorf("user %s is disabled", user))
+	}
+	names := splitIAMNames(userInfo.PolicyName)
+	for _, group := range userInfo.MemberOf {
+		desc, e := client.GetGroupDescription(ctx, group)
+		if e != nil {
+			return principal, probe.NewError(e).Trace(group)
+		}
+		// Policies of disabled groups don't apply.
+		if desc.Status == string(madmin.GroupDisabled) {
+			continue
+		}
+		principal.groups = append(principal.groups, group)
+		names = append(names, splitIAMNames(desc.Policy)...)
+	}
+
+	for _, name := range iamNames(names) {
+		doc, e := client.InfoCannedPolicy(ctx, name)
+		if e != nil {
+			return principal, probe.NewError(e).Trace(name)
+		}
+		p, e := iampolicy.ParseConfig(bytes.NewReader(doc))
+		if e != nil {
+			return principal, probe.NewError(e).Trace(name)
+		}
+		principal.policies = append(principal.policies, simulatedPolicy{name: name, policy: p})
+	}
+	return principal, nil
+}
+
+// newSimulationArgs returns the request to evaluate. Resources may be
+// given as ARNs, condition keys with or without their aws: prefix.
+func newSimulationArgs(user, action, resource string, conditions []string) (iampolicy.Args, *probe.Error) {
+	args := iampolicy.Args{
+		AccountName:     user,
+		Action:          iampolicy.Action(action),
+		ConditionValues: make(map[string][]string),
+	}
+	if !args.Action.IsValid() {
+		return args, probe.NewError(fmt.Errorf("unknown action %s", action))
+	}
+	resource = strings.TrimPrefix(resource, "arn:aws:s3:::")
+	args.BucketName, args.ObjectName = resource, ""
+	if i := strings.Index(resource, "/"); i >= 0 {
+		args.BucketName, args.ObjectName = resource[:i], resource[i+1:]
+	}
+
+	now := UTCNow()
+	if user != "" {
+		args.ConditionValues["username"] = []string{user}
+		args.ConditionValues["userid"] = []string{user}
+	}
+	args.ConditionValues["CurrentTime"] = []string{now.Format(time.RFC3339)}
+	args.ConditionValues["EpochTime"] = []string{strconv.FormatInt(now.Unix(), 10)}
+
+	given := make(map[string]bool)
+	for _, c := range conditions {
+		kv := strings.SplitN(c, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return args, errInvalidArgument().Trace(c)
+		}
+		key := condition.KeyName(kv[0]).Name()
+		if !given[key] {
+			args.ConditionValues[key] = nil
+			given[key] = true
+		}
+		args.ConditionValues[key] = append(args.ConditionValues[key], kv[1])
+	}
+	return args, nil
+}
+
+// policySimulateMessage is the decision of a simulated request.
+type policySimulateMessage struct {
+	Status     string         `json:"status"`
+	User       string         `json:"user,omitempty"`
+	ParentUser string         `json:"parentUser,omitempty"`
+	Groups     []string       `json:"groups,omitempty"`
+	Action     string         `json:"action"`
+	Resource   string         `json:"resource,omitempty"`
+	Policies   []string       `json:"policies"`
+	Decision   string         `json:"decision"`
+	Reason     policyDecision `json:"reason"`
+}
+
+func (s policySimulateMessage) String() string {
+	var b strings.Builder
+	decision := console.Colorize("SimulateAllowed", "ALLOWED")
+	if s.Decision != "allow" {
+		decision = console.Colorize("SimulateDenied", "DENIED")
+	}
+	fmt.Fprintf(&b, "Decision: %s\n", decision)
+	if s.User != "" {
+		user := s.User
+		if s.ParentUser != "" {
+			user += " (service account of " + s.ParentUser + ")"
+		}
+		fmt.Fprintf(&b, "User: %s\n", user)
+	}
+	if len(s.Groups) > 0 {
+		fmt.Fprintf(&b, "Groups: %s\n", strings.Join(s.Groups, ", "))
+	}
+	fmt.Fprintf(&b, "Action: %s\n", s.Action)
+	if s.Resource != "" {
+		fmt.Fprintf(&b, "Resource: arn:aws:s3:::%s\n", s.Resource)
+	}
+	fmt.Fprintf(&b, "Policies: %s\n", strings.Join(s.Policies, ", "))
+	if !s.Reason.Explicit {
+		b.WriteString("No statement allows the request, it is implicitly denied.")
+		return b.String()
+	}
+	effect := "allowed"
+	if !s.Reason.Allowed {
+		effect = "denied"
+	}
+	fmt.Fprintf(&b, "Explicitly %s by statement #%d of %s:\n", effect, s.Reason.Statement, console.Colorize("SimulatePolicy", s.Reason.Policy))
+	var doc bytes.Buffer
+	json.Indent(&doc, s.Reason.Document, "  ", "  ")
+	b.WriteString("  " + doc.String())
+	return b.String()
+}
+
+func (s policySimulateMessage) JSON() string {
+	s.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// simulatePolicies decides the request for a principal. Requests of
+// service accounts with an embedded policy must be allowed by both.
+func simulatePolicies(principal simulationPrincipal, args iampolicy.Args) policyDecision {
+	decision := evaluatePolicies(principal.policies, args)
+	if !decision.Allowed || len(principal.embedded) == 0 {
+		return decision
+	}
+	if embedded := evaluatePolicies(principal.embedded, args); !embedded.Allowed {
+		return embedded
+	}
+	return decision
+}
+
+func checkAdminPolicySimulateSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) > 1 || ctx.String("action") == "" {
+		cli.ShowCommandHelpAndExit(ctx, "simulate", 1) // last argument is exit code
+	}
+	if len(ctx.Args()) == 1 && ctx.String("user") == "" {
+		fatalIf(errInvalidArgument(), "--user is required to simulate the policies of a server.")
+	}
+	if len(ctx.Args()) == 0 && len(ctx.StringSlice("policy")) == 0 {
+		fatalIf(errInvalidArgument(), "--policy files are required without a server.")
+	}
+}
+
+// mainAdminPolicySimulate is the handler for "mc admin policy simulate" command.
+func mainAdminPolicySimulate(ctx *cli.Context) error {
+	checkAdminPolicySimulateSyntax(ctx)
+
+	console.SetColor("SimulateAllowed", color.New(color.FgGreen, color.Bold))
+	console.SetColor("SimulateDenied", color.New(color.FgRed, color.Bold))
+	console.SetColor("SimulatePolicy", color.New(color.FgBlue))
+
+	user := ctx.String("user")
+	principal := simulationPrincipal{user: user}
+	if aliasedURL := ctx.Args().Get(0); aliasedURL != "" {
+		client, err := newAdminClient(aliasedURL)
+		fatalIf(err, "Unable to initialize admin connection.")
+		principal, err = fetchSimulationPrincipal(globalContext, client, user)
+		fatalIf(err.Trace(aliasedURL, user), "Unable to fetch the policies of `"+user+"`.")
+	}
+	for _, file := range ctx.StringSlice("policy") {
+		buf, e := ioutil.ReadFile(file)
+		fatalIf(probe.NewError(e).Trace(file), "Unable to read the policy document.")
+		p, e := iampolicy.ParseConfig(bytes.NewReader(buf))
+		fatalIf(probe.NewError(e).Trace(file), "Unable to parse the policy document.")
+		principal.policies = append(principal.policies, simulatedPolicy{name: file, policy: p})
+	}
+
+	// Policy variables refer to the parent user of service accounts.
+	name := principal.user
+	if principal.parentUser != "" {
+		name = principal.parentUser
+	}
+	resource := strings.TrimPrefix(ctx.String("resource"), "arn:aws:s3:::")
+	args, err := newSimulationArgs(name, ctx.String("action"), resource, ctx.StringSlice("condition"))
+	fatalIf(err, "Invalid request to simulate.")
+	args.Groups = principal.groups
+
+	msg := policySimulateMessage{
+		User:       principal.user,
+		ParentUser: principal.parentUser,
+		Groups:     principal.groups,
+		Action:     ctx.String("action"),
+		Resource:   resource,
+		Policies:   []string{},
+		Decision:   "deny",
+	}
+	for _, p := range append(principal.policies, principal.embedded...) {
+		msg.Policies = append(msg.Policies, p.name)
+	}
+	msg.Reason = simulatePolicies(principal, args)
+	if msg.Reason.Allowed {
+		msg.Decision = "allow"
+	}
+	printMsg(msg)
+	return nil
+}
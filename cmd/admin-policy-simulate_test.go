@@ -0,0 +1,102 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+func testSimulatedPolicy(t *testing.T, name, doc string) simulatedPolicy {
+	t.Helper()
+	p, e := iampolicy.ParseConfig(strings.NewReader(doc))
+	if e != nil {
+		t.Fatal(e)
+	}
+	return simulatedPolicy{name: name, policy: p}
+}
+
+func TestEvaluatePolicies(t *testing.T) {
+	logsRW := testSimulatedPolicy(t, "logs-rw", `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["s3:*"],"Resource":["arn:aws:s3:::logs/*"]},
+		{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::home/${aws:username}/*"]}]}`)
+	noDelete := testSimulatedPolicy(t, "no-delete", `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Deny","Action":["s3:DeleteObject"],"Resource":["arn:aws:s3:::logs/audit/*"]}]}`)
+	fromLAN := testSimulatedPolicy(t, "from-lan", `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["s3:PutObject"],"Resource":["arn:aws:s3:::logs/*"],
+		 "Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}}]}`)
+
+	testCases := []struct {
+		policies  []simulatedPolicy
+		action    string
+		resource  string
+		condition []string
+		allowed   bool
+		policy    string
+		statement int
+	}{
+		{[]simulatedPolicy{logsRW, noDelete}, "s3:DeleteObject", "logs/app.log", nil, true, "logs-rw", 1},
+		{[]simulatedPolicy{logsRW, noDelete}, "s3:DeleteObject", "arn:aws:s3:::logs/audit/1", nil, false, "no-delete", 1},
+		{[]simulatedPolicy{logsRW}, "s3:GetObject", "home/alice/notes", nil, true, "logs-rw", 2},
+		{[]simulatedPolicy{logsRW}, "s3:GetObject", "home/bob/notes", nil, false, "", 0},
+		{[]simulatedPolicy{fromLAN}, "s3:PutObject", "logs/app.log", []string{"aws:SourceIp=10.1.2.3"}, true, "from-lan", 1},
+		{[]simulatedPolicy{fromLAN}, "s3:PutObject", "logs/app.log", []string{"SourceIp=192.168.1.1"}, false, "", 0},
+	}
+	for i, testCase := range testCases {
+		args, err := newSimulationArgs("alice", testCase.action, strings.TrimPrefix(testCase.resource, "arn:aws:s3:::"), testCase.condition)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		decision := evaluatePolicies(testCase.policies, args)
+		if decision.Allowed != testCase.allowed || decision.Policy != testCase.policy || decision.Statement != testCase.statement {
+			t.Fatalf("Test %d: unexpected decision %+v", i+1, decision)
+		}
+	}
+
+	if _, err := newSimulationArgs("alice", "s3:Fly", "logs", nil); err == nil {
+		t.Fatal("an unknown action was accepted")
+	}
+	if _, err := newSimulationArgs("alice", "s3:GetObject", "logs", []string{"SourceIp"}); err == nil {
+		t.Fatal("a condition without value was accepted")
+	}
+}
+
+func TestSimulateServiceAccount(t *testing.T) {
+	parent := testSimulatedPolicy(t, "readwrite", `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["s3:*"],"Resource":["arn:aws:s3:::*"]}]}`)
+	embedded := testSimulatedPolicy(t, "embedded", `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::logs/*"]}]}`)
+	principal := simulationPrincipal{
+		user:       "svc",
+		parentUser: "alice",
+		policies:   []simulatedPolicy{parent},
+		embedded:   []simulatedPolicy{embedded},
+	}
+
+	args, _ := newSimulationArgs("alice", "s3:GetObject", "logs/app.log", nil)
+	if decision := simulatePolicies(principal, args); !decision.Allowed || decision.Policy != "readwrite" {
+		t.Fatalf("unexpected decision %+v", decision)
+	}
+	// The embedded policy restricts the parent user policies.
+	args, _ = newSimulationArgs("alice", "s3:PutObject", "logs/app.log", nil)
+	if decision := simulatePolicies(principal, args); decision.Allowed || decision.Explicit {
+		t.Fatalf("unexpected decision %+v", decision)
+	}
+}
@@ -27,6 +27,7 @@ var adminPolicySubcommands = []cli.Command{
 	adminPolicySetCmd,
 	adminPolicyUnsetCmd,
 	adminPolicyUpdateCmd,
+	adminPolicySimulateCmd,
 }
 
 var adminPolicyCmd = cli.Command{
@@ -0,0 +1,629 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+	"github.com/olekukonko/tablewriter"
+)
+
+var adminTraceAnalyzeFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "replay",
+		Usage: "print the matching traces like a live trace instead of analyzing them",
+	},
+	cli.IntFlag{
+		Name:  "top",
+		Usage: "number of slowest calls to show",
+		Value: 10,
+	},
+	cli.DurationFlag{
+		Name:  "interval",
+		Usage: "length of the time buckets of the throughput",
+		Value: time.Minute,
+	},
+}
+
+var adminTraceAnalyzeCmd = cli.Command{
+	Name:         "analyze",
+	Usage:        "analyze or replay a recorded trace",
+	Action:       mainAdminTraceAnalyze,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(append(adminTraceAnalyzeFlags, adminTraceFlags...), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] FILE
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Analyze a trace recorded with 'mc admin trace --record'. The latency
+  percentiles, error rates and time to first byte are computed per API, per
+  server and per bucket, along with the slowest calls, the failed calls and
+  the throughput over time.
+
+  The trace filters apply to the recorded traces the same way as to a live
+  trace. All recorded call types are included unless --call or --all is set.
+
+EXAMPLES:
+  1. Analyze a recorded trace.
+     {{.Prompt}} {{.HelpName}} incident.jsonl.zst
+
+  2. Analyze the PutObject calls slower than 1s of a single server, with a throughput per 10s.
+     {{.Prompt}} {{.HelpName}} --funcname s3.PutObject --response-threshold 1s --node node1:9000 \
+                 --interval 10s incident.jsonl.zst
+
+  3. Replay the failed calls of a bucket.
+     {{.Prompt}} {{.HelpName}} --replay -v --errors --path 'photos/*' incident.jsonl.zst
+`,
+}
+
+// traceTTFBBounds are the upper bounds of the time to first byte histogram.
+var traceTTFBBounds = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// traceFilter applies the trace flags to recorded traces, including
+// those applied by the server during a live trace.
+type traceFilter struct {
+	types      madmin.TraceType
+	onlyErrors bool
+	threshold  time.Duration
+	match      matchOpts
+}
+
+func newTraceFilter(ctx *cli.Context) (traceFilter, *probe.Error) {
+	f := traceFilter{types: madmin.TraceAll, match: traceMatchOpts(ctx)}
+	opts, e := tracingOpts(ctx, ctx.StringSlice("call"))
+	if e != nil {
+		return f, probe.NewError(e)
+	}
+	f.onlyErrors, f.threshold = opts.OnlyErrors, opts.Threshold
+	if ctx.Bool("all") || len(ctx.StringSlice("call")) == 0 {
+		return f, nil
+	}
+	f.types = 0
+	for enabled, t := range map[*bool]madmin.TraceType{
+		&opts.S3:           madmin.TraceS3,
+		&opts.Internal:     madmin.TraceInternal,
+		&opts.Storage:      madmin.TraceStorage,
+		&opts.OS:           madmin.TraceOS,
+		&opts.Scanner:      madmin.TraceScanner,
+		&opts.Decommission: madmin.TraceDecommission,
+	} {
+		if *enabled {
+			f.types |= t
+		}
+	}
+	return f, nil
+}
+
+func (f traceFilter) Match(t madmin.TraceInfo) bool {
+	if !f.types.Overlaps(t.TraceType) {
+		return false
+	}
+	if f.onlyErrors && !traceFailed(t) {
+		return false
+	}
+	if traceDuration(t) < f.threshold {
+		return false
+	}
+	return matchTrace(f.match, madmin.ServiceTraceInfo{Trace: t})
+}
+
+// traceFailed returns whether a traced call failed.
+func traceFailed(t madmin.TraceInfo) bool {
+	return t.Error != "" || (t.HTTP != nil && t.HTTP.RespInfo.StatusCode >= http.StatusBadRequest)
+}
+
+// traceDuration returns the duration of a call, traces of older
+// servers only have the latency of HTTP calls.
+func traceDuration(t madmin.TraceInfo) time.Duration {
+	if t.Duration == 0 && t.HTTP != nil {
+		return t.HTTP.CallStats.Latency
+	}
+	return t.Duration
+}
+
+// traceBucket returns the bucket of an S3 call.
+func traceBucket(t madmin.TraceInfo) string {
+	if t.TraceType != madmin.TraceS3 {
+		return ""
+	}
+	return strings.SplitN(strings.TrimPrefix(t.Path, "/"), "/", 2)[0]
+}
+
+// traceLatencyStats are the statistics of a group of calls.
+type traceLatencyStats struct {
+	Name      string        `json:"name"`
+	Calls     int           `json:"calls"`
+	Errors    int           `json:"errors"`
+	ErrorRate float64       `json:"errorRate"`
+	Rx        int64         `json:"rx"`
+	Tx        int64         `json:"tx"`
+	Min       time.Duration `json:"min"`
+	P50       time.Duration `json:"p50"`
+	P90       time.Duration `json:"p90"`
+	P99       time.Duration `json:"p99"`
+	Max       time.Duration `json:"max"`
+	TTFBP50   time.Duration `json:"ttfbP50,omitempty"`
+	TTFBP99   time.Duration `json:"ttfbP99,omitempty"`
+
+	durations []time.Duration
+	ttfbs     []time.Duration
+}
+
+func (s *traceLatencyStats) add(t madmin.TraceInfo) {
+	s.Calls++
+	if traceFailed(t) {
+		s.Errors++
+	}
+	s.durations = append(s.durations, traceDuration(t))
+	if t.HTTP != nil {
+		s.Rx += int64(t.HTTP.CallStats.InputBytes)
+		s.Tx += int64(t.HTTP.CallStats.OutputBytes)
+		if ttfb := t.HTTP.CallStats.TimeToFirstByte; ttfb > 0 {
+			s.ttfbs = append(s.ttfbs, ttfb)
+		}
+	}
+}
+
+func (s *traceLatencyStats) compute() {
+	s.ErrorRate = float64(s.Errors) / float64(s.Calls)
+	sortDurations(s.durations)
+	s.Min, s.Max = s.durations[0], s.durations[len(s.durations)-1]
+	s.P50 = durationPercentile(s.durations, 50)
+	s.P90 = durationPercentile(s.durations, 90)
+	s.P99 = durationPercentile(s.durations, 99)
+	if len(s.ttfbs) > 0 {
+		sortDurations(s.ttfbs)
+		s.TTFBP50 = durationPercentile(s.ttfbs, 50)
+		s.TTFBP99 = durationPercentile(s.ttfbs, 99)
+	}
+}
+
+func sortDurations(d []time.Duration) {
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+}
+
+// durationPercentile returns the nearest rank percentile of sorted durations.
+func durationPercentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// traceErrorStats counts the failures of an API by status code or error.
+type traceErrorStats struct {
+	API        string `json:"api"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Count      int    `json:"count"`
+}
+
+// traceCall summarizes a single call.
+type traceCall struct {
+	Time       time.Time     `json:"time"`
+	Node       string        `json:"node"`
+	API        string        `json:"api"`
+	Path       string        `json:"path"`
+	StatusCode int           `json:"statusCode,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	TTFB       time.Duration `json:"ttfb,omitempty"`
+}
+
+// traceThroughput counts the calls started during an interval.
+type traceThroughput struct {
+	Time   time.Time `json:"time"`
+	Calls  int       `json:"calls"`
+	Errors int       `json:"errors"`
+	Rx     int64     `json:"rx"`
+	Tx     int64     `json:"tx"`
+}
+
+// traceHistogramBucket counts the values up to a bound, the last
+// bucket has no bound.
+type traceHistogramBucket struct {
+	Le    time.Duration `json:"le,omitempty"`
+	Count int           `json:"count"`
+}
+
+// traceAnalysisMessage is the analysis of a recorded trace.
+type traceAnalysisMessage struct {
+	Status     string                 `json:"status"`
+	File       string                 `json:"file"`
+	Truncated  bool                   `json:"truncated,omitempty"`
+	Records    int                    `json:"records"`
+	Matched    int                    `json:"matched"`
+	Start      time.Time              `json:"start"`
+	End        time.Time              `json:"end"`
+	APIs       []*traceLatencyStats   `json:"apis"`
+	Nodes      []*traceLatencyStats   `json:"nodes"`
+	Buckets    []*traceLatencyStats   `json:"buckets"`
+	Errors     []traceErrorStats      `json:"errors"`
+	Slowest    []traceCall            `json:"slowest"`
+	Interval   time.Duration          `json:"interval"`
+	Throughput []traceThroughput      `json:"throughput"`
+	TTFB       []traceHistogramBucket `json:"ttfb"`
+}
+
+// traceAnalyzer aggregates the matching traces.
+type traceAnalyzer struct {
+	top      int
+	interval time.Duration
+
+	matched    int
+	start, end time.Time
+	apis       map[string]*traceLatencyStats
+	nodes      map[string]*traceLatencyStats
+	buckets    map[string]*traceLatencyStats
+	errors     map[traceErrorStats]int
+	slowest    []traceCall
+	throughput map[time.Time]*traceThroughput
+	ttfb       []traceHistogramBucket
+}
+
+func newTraceAnalyzer(top int, interval time.Duration) *traceAnalyzer {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &traceAnalyzer{
+		top:        top,
+		interval:   interval,
+		apis:       make(map[string]*traceLatencyStats),
+		nodes:      make(map[string]*traceLatencyStats),
+		buckets:    make(map[string]*traceLatencyStats),
+		errors:     make(map[traceErrorStats]int),
+		throughput: make(map[time.Time]*traceThroughput),
+		ttfb:       make([]traceHistogramBucket, len(traceTTFBBounds)+1),
+	}
+}
+
+func addTraceStats(groups map[string]*traceLatencyStats, name string, t madmin.TraceInfo) {
+	s, ok := groups[name]
+	if !ok {
+		s = &traceLatencyStats{Name: name}
+		groups[name] = s
+	}
+	s.add(t)
+}
+
+func (a *traceAnalyzer) Add(t madmin.TraceInfo) {
+	a.matched++
+	if a.start.IsZero() || t.Time.Before(a.start) {
+		a.start = t.Time
+	}
+	if end := t.Time.Add(traceDuration(t)); end.After(a.end) {
+		a.end = end
+	}
+
+	addTraceStats(a.apis, t.FuncName, t)
+	addTraceStats(a.nodes, t.NodeName, t)
+	if bucket := traceBucket(t); bucket != "" {
+		addTraceStats(a.buckets, bucket, t)
+	}
+
+	call := traceCall{
+		Time:     t.Time,
+		Node:     t.NodeName,
+		API:      t.FuncName,
+		Path:     t.Path,
+		Error:    t.Error,
+		Duration: traceDuration(t),
+	}
+	if t.HTTP != nil {
+		call.StatusCode = t.HTTP.RespInfo.StatusCode
+		call.TTFB = t.HTTP.CallStats.TimeToFirstByte
+	}
+
+	failed := traceFailed(t)
+	if failed {
+		a.errors[traceErrorStats{API: call.API, StatusCode: call.StatusCode, Error: call.Error}]++
+	}
+
+	// Keep the slowest calls sorted by decreasing duration.
+	i := sort.Search(len(a.slowest), func(i int) bool { return a.slowest[i].Duration < call.Duration })
+	if i < a.top {
+		a.slowest = append(a.slowest, traceCall{})
+		copy(a.slowest[i+1:], a.slowest[i:])
+		a.slowest[i] = call
+		if len(a.slowest) > a.top {
+			a.slowest = a.slowest[:a.top]
+		}
+	}
+
+	slot := t.Time.Truncate(a.interval)
+	tp, ok := a.throughput[slot]
+	if !ok {
+		tp = &traceThroughput{Time: slot}
+		a.throughput[slot] = tp
+	}
+	tp.Calls++
+	if failed {
+		tp.Errors++
+	}
+	if t.HTTP != nil {
+		tp.Rx += int64(t.HTTP.CallStats.InputBytes)
+		tp.Tx += int64(t.HTTP.CallStats.OutputBytes)
+	}
+
+	if call.TTFB > 0 {
+		i := sort.Search(len(traceTTFBBounds), func(i int) bool { return call.TTFB <= traceTTFBBounds[i] })
+		a.ttfb[i].Count++
+	}
+}
+
+// sortedTraceStats returns the statistics of groups by decreasing calls.
+func sortedTraceStats(groups map[string]*traceLatencyStats) []*traceLatencyStats {
+	stats := make([]*traceLatencyStats, 0, len(groups))
+	for _, s := range groups {
+		s.compute()
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Calls != stats[j].Calls {
+			return stats[i].Calls > stats[j].Calls
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+func (a *traceAnalyzer) Result() traceAnalysisMessage {
+	msg := traceAnalysisMessage{
+		Matched:    a.matched,
+		Start:      a.start,
+		End:        a.end,
+		APIs:       sortedTraceStats(a.apis),
+		Nodes:      sortedTraceStats(a.nodes),
+		Buckets:    sortedTraceStats(a.buckets),
+		Errors:     []traceErrorStats{},
+		Slowest:    append([]traceCall{}, a.slowest...),
+		Interval:   a.interval,
+		Throughput: []traceThroughput{},
+		TTFB:       a.ttfb,
+	}
+	for k, count := range a.errors {
+		k.Count = count
+		msg.Errors = append(msg.Errors, k)
+	}
+	sort.Slice(msg.Errors, func(i, j int) bool {
+		if msg.Errors[i].Count != msg.Errors[j].Count {
+			return msg.Errors[i].Count > msg.Errors[j].Count
+		}
+		return msg.Errors[i].API < msg.Errors[j].API
+	})
+	for _, tp := range a.throughput {
+		msg.Throughput = append(msg.Throughput, *tp)
+	}
+	sort.Slice(msg.Throughput, func(i, j int) bool { return msg.Throughput[i].Time.Before(msg.Throughput[j].Time) })
+	for i, bound := range traceTTFBBounds {
+		msg.TTFB[i].Le = bound
+	}
+	return msg
+}
+
+func newTraceTable(s *strings.Builder, header ...string) *tablewriter.Table {
+	table := tablewriter.NewWriter(s)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t") // pad with tabs
+	table.SetNoWhiteSpace(true)
+	table.SetHeader(header)
+	return table
+}
+
+func roundTraceDuration(d time.Duration) string {
+	return d.Round(time.Microsecond).String()
+}
+
+func writeTraceStats(s *strings.Builder, title string, stats []*traceLatencyStats) {
+	if len(stats) == 0 {
+		return
+	}
+	s.WriteString("\n" + console.Colorize("TraceTitle", title) + "\n")
+	table := newTraceTable(s, title[:len(title)-1], "Calls", "Errors", "Min", "P50", "P90", "P99", "Max", "TTFB P50", "TTFB P99", "RX", "TX")
+	for _, st := range stats {
+		errors := strconv.Itoa(st.Errors)
+		if st.Errors > 0 {
+			errors = console.Colorize("TraceError", fmt.Sprintf("%d (%.1f%%)", st.Errors, st.ErrorRate*100))
+		}
+		table.Append([]string{
+			st.Name, strconv.Itoa(st.Calls), errors,
+			roundTraceDuration(st.Min), roundTraceDuration(st.P50), roundTraceDuration(st.P90),
+			roundTraceDuration(st.P99), roundTraceDuration(st.Max),
+			roundTraceDuration(st.TTFBP50), roundTraceDuration(st.TTFBP99),
+			humanize.IBytes(uint64(st.Rx)), humanize.IBytes(uint64(st.Tx)),
+		})
+	}
+	table.Render()
+}
+
+func (t traceAnalysisMessage) String() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "%s: %d of %d recorded calls", console.Colorize("TraceTitle", t.File), t.Matched, t.Records)
+	if t.Matched > 0 {
+		fmt.Fprintf(&s, " from %s to %s", t.Start.Local().Format(traceTimeFormat), t.End.Local().Format(traceTimeFormat))
+	}
+	s.WriteString("\n")
+	if t.Truncated {
+		s.WriteString(console.Colorize("TraceError", "The recording is truncated, it was not closed properly.") + "\n")
+	}
+	if t.Matched == 0 {
+		return strings.TrimSuffix(s.String(), "\n")
+	}
+
+	writeTraceStats(&s, "APIs", t.APIs)
+	writeTraceStats(&s, "Nodes", t.Nodes)
+	writeTraceStats(&s, "Buckets", t.Buckets)
+
+	if len(t.Errors) > 0 {
+		s.WriteString("\n" + console.Colorize("TraceTitle", "Errors") + "\n")
+		table := newTraceTable(&s, "API", "Status", "Error", "Count")
+		for _, e := range t.Errors {
+			status := ""
+			if e.StatusCode != 0 {
+				status = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+			}
+			table.Append([]string{e.API, status, e.Error, strconv.Itoa(e.Count)})
+		}
+		table.Render()
+	}
+
+	s.WriteString("\n" + console.Colorize("TraceTitle", "Slowest calls") + "\n")
+	table := newTraceTable(&s, "Time", "Node", "API", "Path", "Status", "Duration", "TTFB")
+	for _, c := range t.Slowest {
+		status := c.Error
+		if c.StatusCode != 0 {
+			status = strconv.Itoa(c.StatusCode)
+		}
+		table.Append([]string{
+			c.Time.Local().Format(traceTimeFormat), c.Node, c.API, c.Path, status,
+			roundTraceDuration(c.Duration), roundTraceDuration(c.TTFB),
+		})
+	}
+	table.Render()
+
+	fmt.Fprintf(&s, "\n%s\n", console.Colorize("TraceTitle", fmt.Sprintf("Throughput per %s", t.Interval)))
+	table = newTraceTable(&s, "Time", "Calls", "Calls/s", "Errors", "RX/s", "TX/s")
+	seconds := t.Interval.Seconds()
+	for _, tp := range t.Throughput {
+		table.Append([]string{
+			tp.Time.Local().Format(traceTimeFormat), strconv.Itoa(tp.Calls),
+			fmt.Sprintf("%.1f", float64(tp.Calls)/seconds), strconv.Itoa(tp.Errors),
+			humanize.IBytes(uint64(float64(tp.Rx) / seconds)), humanize.IBytes(uint64(float64(tp.Tx) / seconds)),
+		})
+	}
+	table.Render()
+
+	var ttfbCalls int
+	for _, b := range t.TTFB {
+		ttfbCalls += b.Count
+	}
+	if ttfbCalls > 0 {
+		s.WriteString("\n" + console.Colorize("TraceTitle", "Time to first byte") + "\n")
+		table = newTraceTable(&s, "TTFB", "Calls", "")
+		for i, b := range t.TTFB {
+			le := "> " + traceTTFBBounds[len(traceTTFBBounds)-1].String()
+			if i < len(traceTTFBBounds) {
+				le = "<= " + b.Le.String()
+			}
+			table.Append([]string{le, strconv.Itoa(b.Count), strings.Repeat("▇", b.Count*40/ttfbCalls)})
+		}
+		table.Render()
+	}
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+func (t traceAnalysisMessage) JSON() string {
+	t.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(t, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func checkAdminTraceAnalyzeSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "analyze", 1) // last argument is exit code
+	}
+}
+
+// mainAdminTraceAnalyze is the handler for "mc admin trace analyze" command.
+func mainAdminTraceAnalyze(ctx *cli.Context) error {
+	checkAdminTraceAnalyzeSyntax(ctx)
+
+	file := ctx.Args().Get(0)
+	filter, err := newTraceFilter(ctx)
+	fatalIf(err, "Invalid trace filters.")
+
+	setTraceColors()
+	console.SetColor("TraceTitle", color.New(color.Bold, color.FgCyan))
+	console.SetColor("TraceError", color.New(color.FgRed))
+
+	reader, err := newTraceRecordReader(file)
+	fatalIf(err.Trace(file), "Unable to open the trace recording.")
+	defer reader.Close()
+
+	replay := ctx.Bool("replay")
+	analyzer := newTraceAnalyzer(ctx.Int("top"), ctx.Duration("interval"))
+	records := 0
+	for {
+		t, err := reader.Next()
+		if err != nil {
+			if err.ToGoError() == io.EOF {
+				break
+			}
+			fatalIf(err.Trace(file), "Unable to read the trace recording.")
+		}
+		records++
+		if !filter.Match(t) {
+			continue
+		}
+		if replay {
+			printTrace(ctx.Bool("verbose"), madmin.ServiceTraceInfo{Trace: t})
+			continue
+		}
+		analyzer.Add(t)
+	}
+	if replay {
+		if reader.Truncated {
+			errorIf(errDummy().Trace(file), "The recording is truncated, it was not closed properly.")
+		}
+		return nil
+	}
+
+	msg := analyzer.Result()
+	msg.File, msg.Records, msg.Truncated = file, records, reader.Truncated
+	printMsg(msg)
+	return nil
+}
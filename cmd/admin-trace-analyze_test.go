@@ -0,0 +1,197 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go"
+)
+
+func testTraces() []madmin.TraceInfo {
+	start := time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC)
+	s3 := func(offset time.Duration, node, api, path string, status int, d, ttfb time.Duration) madmin.TraceInfo {
+		return madmin.TraceInfo{
+			TraceType: madmin.TraceS3,
+			NodeName:  node,
+			FuncName:  api,
+			Time:      start.Add(offset),
+			Path:      path,
+			Duration:  d,
+			HTTP: &madmin.TraceHTTPStats{
+				RespInfo:  madmin.TraceResponseInfo{StatusCode: status},
+				CallStats: madmin.TraceCallStats{InputBytes: 100, OutputBytes: 1000, TimeToFirstByte: ttfb},
+			},
+		}
+	}
+	return []madmin.TraceInfo{
+		s3(0, "node1:9000", "s3.GetObject", "/photos/a.jpg", 200, 10*time.Millisecond, 2*time.Millisecond),
+		s3(time.Second, "node1:9000", "s3.GetObject", "/photos/b.jpg", 200, 20*time.Millisecond, 3*time.Millisecond),
+		s3(2*time.Second, "node2:9000", "s3.GetObject", "/photos/c.jpg", 404, 5*time.Millisecond, 0),
+		s3(70*time.Second, "node2:9000", "s3.PutObject", "/logs/app.log", 503, 2*time.Second, 0),
+		s3(75*time.Second, "node1:9000", "s3.ListBuckets", "/", 200, 30*time.Millisecond, 20*time.Millisecond),
+		{
+			TraceType: madmin.TraceStorage,
+			NodeName:  "node1:9000",
+			FuncName:  "storage.ReadAll",
+			Time:      start.Add(3 * time.Second),
+			Path:      "/mnt/disk1/photos/a.jpg/xl.meta",
+			Duration:  time.Millisecond,
+			Error:     "file not found",
+		},
+	}
+}
+
+func TestTraceRecording(t *testing.T) {
+	dir, e := ioutil.TempDir("", "mc-trace")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"trace.jsonl", "trace.jsonl.gz", "trace.jsonl.zst"} {
+		file := filepath.Join(dir, name)
+		recorder, err := newTraceRecorder(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, trace := range testTraces() {
+			if err = recorder.Record(trace); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = recorder.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		reader, err := newTraceRecordReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var traces []madmin.TraceInfo
+		for {
+			trace, err := reader.Next()
+			if err != nil {
+				if err.ToGoError() != io.EOF {
+					t.Fatalf("%s: %v", name, err)
+				}
+				break
+			}
+			traces = append(traces, trace)
+		}
+		reader.Close()
+		if len(traces) != len(testTraces()) || reader.Truncated {
+			t.Fatalf("%s: read %d traces, truncated %v", name, len(traces), reader.Truncated)
+		}
+		if traces[3].Duration != 2*time.Second || traces[3].HTTP.RespInfo.StatusCode != 503 {
+			t.Fatalf("%s: unexpected trace %+v", name, traces[3])
+		}
+	}
+}
+
+func TestTraceRecordingTruncated(t *testing.T) {
+	dir, e := ioutil.TempDir("", "mc-trace")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+
+	// A recording interrupted after a flush keeps the flushed records.
+	file := filepath.Join(dir, "trace.jsonl.zst")
+	recorder, err := newTraceRecorder(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	traces := testTraces()
+	recorder.Record(traces[0])
+	recorder.Record(traces[1])
+	recorder.mu.Lock()
+	recorder.flush()
+	recorder.mu.Unlock()
+	buf, e := ioutil.ReadFile(file)
+	if e != nil {
+		t.Fatal(e)
+	}
+	recorder.Close()
+	if e = ioutil.WriteFile(file, buf, 0o600); e != nil {
+		t.Fatal(e)
+	}
+
+	reader, err := newTraceRecordReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	n := 0
+	for {
+		if _, err = reader.Next(); err != nil {
+			break
+		}
+		n++
+	}
+	if err.ToGoError() != io.EOF || n != 2 {
+		t.Fatalf("read %d traces: %v", n, err)
+	}
+}
+
+func TestTraceAnalyzer(t *testing.T) {
+	analyzer := newTraceAnalyzer(2, time.Minute)
+	filter := traceFilter{types: madmin.TraceAll}
+	for _, trace := range testTraces() {
+		if filter.Match(trace) {
+			analyzer.Add(trace)
+		}
+	}
+	msg := analyzer.Result()
+	if msg.Matched != 6 || len(msg.APIs) != 4 || len(msg.Nodes) != 2 || len(msg.Buckets) != 2 {
+		t.Fatalf("unexpected analysis %+v", msg)
+	}
+	get := msg.APIs[0]
+	if get.Name != "s3.GetObject" || get.Calls != 3 || get.Errors != 1 || get.Min != 5*time.Millisecond ||
+		get.P50 != 10*time.Millisecond || get.Max != 20*time.Millisecond || get.TTFBP99 != 3*time.Millisecond || get.Tx != 3000 {
+		t.Fatalf("unexpected GetObject stats %+v", get)
+	}
+	if msg.Buckets[0].Name != "photos" || msg.Buckets[0].Calls != 3 {
+		t.Fatalf("unexpected bucket stats %+v", msg.Buckets[0])
+	}
+	if len(msg.Errors) != 3 {
+		t.Fatalf("unexpected errors %+v", msg.Errors)
+	}
+	if len(msg.Slowest) != 2 || msg.Slowest[0].API != "s3.PutObject" || msg.Slowest[1].API != "s3.ListBuckets" {
+		t.Fatalf("unexpected slowest calls %+v", msg.Slowest)
+	}
+	if len(msg.Throughput) != 2 || msg.Throughput[0].Calls != 4 || msg.Throughput[1].Errors != 1 {
+		t.Fatalf("unexpected throughput %+v", msg.Throughput)
+	}
+	// 2ms and 3ms are within 5ms, 20ms within 50ms.
+	if msg.TTFB[1].Count != 2 || msg.TTFB[3].Count != 1 {
+		t.Fatalf("unexpected ttfb histogram %+v", msg.TTFB)
+	}
+
+	filter = traceFilter{types: madmin.TraceS3, onlyErrors: true, threshold: time.Second}
+	for i, trace := range testTraces() {
+		if filter.Match(trace) != (i == 3) {
+			t.Fatalf("trace %d: unexpected filter result", i)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+)
+
+// Recorded traces are flushed at this interval, so that a recording
+// stays readable up to the last few records when mc is interrupted.
+const traceRecordFlushInterval = time.Second
+
+// traceFlushWriter is a writer whose buffered data can be flushed.
+type traceFlushWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// traceRecorder writes raw trace records as JSON lines, zstd or gzip
+// compressed depending on the file extension.
+type traceRecorder struct {
+	mu   sync.Mutex
+	f    *os.File
+	bw   *bufio.Writer
+	cw   traceFlushWriter
+	enc  *json.Encoder
+	done chan struct{}
+}
+
+func newTraceRecorder(path string) (*traceRecorder, *probe.Error) {
+	f, e := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	r := &traceRecorder{f: f, bw: bufio.NewWriter(f), done: make(chan struct{})}
+	var w io.Writer = r.bw
+	switch {
+	case strings.HasSuffix(path, ".zst"):
+		zw, e := zstd.NewWriter(r.bw)
+		if e != nil {
+			f.Close()
+			return nil, probe.NewError(e)
+		}
+		r.cw, w = zw, zw
+	case strings.HasSuffix(path, ".gz"):
+		gw := gzip.NewWriter(r.bw)
+		r.cw, w = gw, gw
+	}
+	r.enc = json.NewEncoder(w)
+	go r.flusher()
+	return r, nil
+}
+
+func (r *traceRecorder) flusher() {
+	ticker := time.NewTicker(traceRecordFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			r.flush()
+			r.mu.Unlock()
+		}
+	}
+}
+
+func (r *traceRecorder) flush() error {
+	if r.cw != nil {
+		if e := r.cw.Flush(); e != nil {
+			return e
+		}
+	}
+	return r.bw.Flush()
+}
+
+// Record writes a trace record.
+func (r *traceRecorder) Record(t madmin.TraceInfo) *probe.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return probe.NewError(r.enc.Encode(t))
+}
+
+// Close flushes the remaining records and closes the file.
+func (r *traceRecorder) Close() *probe.Error {
+	close(r.done)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cw != nil {
+		if e := r.cw.Close(); e != nil {
+			r.f.Close()
+			return probe.NewError(e)
+		}
+	}
+	if e := r.bw.Flush(); e != nil {
+		r.f.Close()
+		return probe.NewError(e)
+	}
+	return probe.NewError(r.f.Close())
+}
+
+// traceRecordReader reads back the records written by a traceRecorder.
+type traceRecordReader struct {
+	f   *os.File
+	zr  *zstd.Decoder
+	gr  *gzip.Reader
+	dec *json.Decoder
+
+	// Truncated is set when the recording ended without being
+	// closed, the records up to its last flush are returned.
+	Truncated bool
+}
+
+func newTraceRecordReader(path string) (*traceRecordReader, *probe.Error) {
+	f, e := os.Open(path)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	r := &traceRecordReader{f: f}
+	var rd io.Reader = bufio.NewReader(f)
+	switch {
+	case strings.HasSuffix(path, ".zst"):
+		if r.zr, e = zstd.NewReader(rd); e != nil {
+			f.Close()
+			return nil, probe.NewError(e)
+		}
+		rd = r.zr
+	case strings.HasSuffix(path, ".gz"):
+		if r.gr, e = gzip.NewReader(rd); e != nil {
+			f.Close()
+			return nil, probe.NewError(e)
+		}
+		rd = r.gr
+	}
+	r.dec = json.NewDecoder(rd)
+	return r, nil
+}
+
+// Next returns the next record, io.EOF once all records are read.
+func (r *traceRecordReader) Next() (t madmin.TraceInfo, err *probe.Error) {
+	e := r.dec.Decode(&t)
+	if errors.Is(e, io.ErrUnexpectedEOF) {
+		r.Truncated = true
+		e = io.EOF
+	}
+	if e != nil {
+		return t, probe.NewError(e)
+	}
+	return t, nil
+}
+
+func (r *traceRecordReader) Close() {
+	if r.zr != nil {
+		r.zr.Close()
+	}
+	if r.gr != nil {
+		r.gr.Close()
+	}
+	r.f.Close()
+}
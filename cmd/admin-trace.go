@@ -79,19 +79,26 @@ var adminTraceFlags = []cli.Flag{
 	},
 }
 
+var adminTraceRecordFlag = cli.StringFlag{
+	Name:  "record",
+	Usage: "also record all received traces to a file, compressed when it ends with .zst or .gz",
+}
+
 var adminTraceCmd = cli.Command{
 	Name:            "trace",
 	Usage:           "show http trace for MinIO server",
 	Action:          mainAdminTrace,
 	OnUsageError:    onUsageError,
 	Before:          setGlobalsFromContext,
-	Flags:           append(adminTraceFlags, globalFlags...),
+	Flags:           append(append(adminTraceFlags, adminTraceRecordFlag), globalFlags...),
+	Subcommands:     []cli.Command{adminTraceAnalyzeCmd},
 	HideHelpCommand: true,
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
 USAGE:
   {{.HelpName}} [FLAGS] TARGET
+  {{.HelpName}} analyze [FLAGS] FILE
 
 FLAGS:
   {{range .VisibleFlags}}{{.}}
@@ -111,6 +118,9 @@ EXAMPLES:
 
   5. Show console trace for requests with '404' and '503' status code
     {{.Prompt}} {{.HelpName}} --status-code 404 --status-code 503 myminio
+
+  6. Record all S3 calls during an incident, to analyze them later with 'analyze'
+    {{.Prompt}} {{.HelpName}} --record incident.jsonl.zst myminio
 `,
 }
 
@@ -120,7 +130,7 @@ var colors = []color.Attribute{color.FgCyan, color.FgWhite, color.FgYellow, colo
 
 func checkAdminTraceSyntax(ctx *cli.Context) {
 	if len(ctx.Args()) != 1 {
-		cli.ShowCommandHelpAndExit(ctx, "trace", 1) // last argument is exit code
+		cli.ShowAppHelpAndExit(ctx, 1) // last argument is exit code
 	}
 }
 
@@ -265,14 +275,7 @@ func tracingOpts(ctx *cli.Context, apis []string) (opts madmin.ServiceTraceOpts,
 	return
 }
 
-// mainAdminTrace - the entry function of trace command
-func mainAdminTrace(ctx *cli.Context) error {
-	// Check for command syntax
-	checkAdminTraceSyntax(ctx)
-
-	verbose := ctx.Bool("verbose")
-	aliasedURL := ctx.Args().Get(0)
-
+func setTraceColors() {
 	console.SetColor("Stat", color.New(color.FgYellow))
 
 	console.SetColor("Request", color.New(color.FgCyan))
@@ -291,6 +294,28 @@ func mainAdminTrace(ctx *cli.Context) error {
 	for _, c := range colors {
 		console.SetColor(fmt.Sprintf("Node%d", c), color.New(c))
 	}
+}
+
+// traceMatchOpts returns the client side filters of the trace flags.
+func traceMatchOpts(ctx *cli.Context) matchOpts {
+	return matchOpts{
+		statusCodes: ctx.IntSlice("status-code"),
+		methods:     ctx.StringSlice("method"),
+		funcNames:   ctx.StringSlice("funcname"),
+		apiPaths:    ctx.StringSlice("path"),
+		nodes:       ctx.StringSlice("node"),
+	}
+}
+
+// mainAdminTrace - the entry function of trace command
+func mainAdminTrace(ctx *cli.Context) error {
+	// Check for command syntax
+	checkAdminTraceSyntax(ctx)
+
+	verbose := ctx.Bool("verbose")
+	aliasedURL := ctx.Args().Get(0)
+
+	setTraceColors()
 	// Create a new MinIO Admin Client
 	client, err := newAdminClient(aliasedURL)
 	if err != nil {
@@ -304,24 +329,46 @@ func mainAdminTrace(ctx *cli.Context) error {
 	opts, e := tracingOpts(ctx, ctx.StringSlice("call"))
 	fatalIf(probe.NewError(e), "Unable to start tracing")
 
-	mopts := matchOpts{
-		statusCodes: ctx.IntSlice("status-code"),
-		methods:     ctx.StringSlice("method"),
-		funcNames:   ctx.StringSlice("funcname"),
-		apiPaths:    ctx.StringSlice("path"),
-		nodes:       ctx.StringSlice("node"),
+	mopts := traceMatchOpts(ctx)
+
+	var recorder *traceRecorder
+	file := ctx.String("record")
+	if file != "" {
+		recorder, err = newTraceRecorder(file)
+		fatalIf(err.Trace(file), "Unable to create the trace recording.")
 	}
 
-	// Start listening on all trace activity.
+	// Start listening on all trace activity. Errors stop the loop,
+	// they are reported once the recording is complete.
+	var traceErr *probe.Error
+	var traceErrMsg string
 	traceCh := client.ServiceTrace(ctxt, opts)
 	for traceInfo := range traceCh {
 		if traceInfo.Err != nil {
-			fatalIf(probe.NewError(traceInfo.Err), "Unable to listen to http trace")
+			traceErr, traceErrMsg = probe.NewError(traceInfo.Err), "Unable to listen to http trace"
+			break
+		}
+		if recorder != nil {
+			if err = recorder.Record(traceInfo.Trace); err != nil {
+				traceErr, traceErrMsg = err.Trace(file), "Unable to record the trace."
+				break
+			}
 		}
 		if matchTrace(mopts, traceInfo) {
 			printTrace(verbose, traceInfo)
 		}
 	}
+	cancel()
+
+	if recorder != nil {
+		err = recorder.Close()
+		if traceErr == nil {
+			fatalIf(err.Trace(file), "Unable to save the trace recording.")
+		} else {
+			errorIf(err.Trace(file), "Unable to save the trace recording.")
+		}
+	}
+	fatalIf(traceErr, traceErrMsg)
 	return nil
 }
 
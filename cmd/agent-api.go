@@ -0,0 +1,164 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+// The control API of the agent, served on a unix socket only its
+// user can connect to:
+//
+//	GET  /v1/jobs                 status of all jobs
+//	GET  /v1/jobs/NAME            status of a job
+//	POST /v1/jobs/NAME/trigger    run a job now
+//	POST /v1/jobs/NAME/pause      stop a job until resumed
+//	POST /v1/jobs/NAME/resume     resume a paused job
+const agentJobsPath = "/v1/jobs"
+
+// agentAPIError is the body of the control API error responses.
+type agentAPIError struct {
+	Error string `json:"error"`
+}
+
+// agentAPIHandler serves the control API for jobs.
+type agentAPIHandler struct {
+	jobs []*agentJob
+}
+
+func (h agentAPIHandler) job(name string) *agentJob {
+	for _, j := range h.jobs {
+		if j.config.Name == name {
+			return j
+		}
+	}
+	return nil
+}
+
+func (h agentAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reply := func(status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	fail := func(status int, e error) {
+		reply(status, agentAPIError{Error: e.Error()})
+	}
+
+	// Browsers set Origin on cross-site requests, the API is only
+	// meant for mc.
+	if r.Header.Get("Origin") != "" {
+		fail(http.StatusForbidden, errors.New("cross-origin requests are not allowed"))
+		return
+	}
+
+	elems := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, agentJobsPath), "/"), "/")
+	if r.URL.Path != agentJobsPath && !strings.HasPrefix(r.URL.Path, agentJobsPath+"/") {
+		fail(http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	if elems[0] == "" {
+		if r.Method != http.MethodGet {
+			fail(http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		statuses := make([]agentJobStatus, 0, len(h.jobs))
+		for _, j := range h.jobs {
+			statuses = append(statuses, j.Status())
+		}
+		reply(http.StatusOK, statuses)
+		return
+	}
+
+	j := h.job(elems[0])
+	if j == nil || len(elems) > 2 {
+		fail(http.StatusNotFound, fmt.Errorf("job %s not found", elems[0]))
+		return
+	}
+	if len(elems) == 1 {
+		if r.Method != http.MethodGet {
+			fail(http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		reply(http.StatusOK, j.Status())
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		fail(http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	switch elems[1] {
+	case "trigger":
+		if e := j.Trigger(); e != nil {
+			fail(http.StatusConflict, e)
+			return
+		}
+	case "pause":
+		j.Pause()
+	case "resume":
+		j.Resume()
+	default:
+		fail(http.StatusNotFound, fmt.Errorf("unknown action %s", elems[1]))
+		return
+	}
+	reply(http.StatusOK, j.Status())
+}
+
+// agentRequest calls the control API of the agent listening on socket
+// and decodes the response into v.
+func agentRequest(ctx context.Context, socket, method, path string, v interface{}) *probe.Error {
+	req, e := http.NewRequestWithContext(ctx, method, "http://agent"+path, nil)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	resp, e := client.Do(req)
+	if e != nil {
+		return probe.NewError(e).Trace(socket)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr agentAPIError
+		if e = json.NewDecoder(resp.Body).Decode(&apiErr); e != nil || apiErr.Error == "" {
+			apiErr.Error = resp.Status
+		}
+		return probe.NewError(errors.New(apiErr.Error)).Trace(path)
+	}
+	if e = json.NewDecoder(resp.Body).Decode(v); e != nil {
+		return probe.NewError(e).Trace(path)
+	}
+	return nil
+}
@@ -0,0 +1,430 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// agentJobsFile is the YAML file listing the jobs run by the agent.
+type agentJobsFile struct {
+	Jobs []agentJobConfig `yaml:"jobs"`
+}
+
+// agentJobConfig defines a mirror job. Flags holds any 'mc mirror' flag
+// by its name, e.g. remove: true or exclude: ["*.tmp"].
+type agentJobConfig struct {
+	Name      string                 `yaml:"name"`
+	Source    string                 `yaml:"source"`
+	Target    string                 `yaml:"target"`
+	Watch     bool                   `yaml:"watch"`
+	Schedule  string                 `yaml:"schedule"`
+	Workers   int                    `yaml:"workers"`
+	Bandwidth string                 `yaml:"bandwidth"`
+	Flags     map[string]interface{} `yaml:"flags"`
+}
+
+var agentJobNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Flags of the process rather than of a job, or set by a job field.
+var agentReservedFlags = map[string]string{
+	"watch":              "use the watch field instead",
+	"w":                  "use the watch field instead",
+	"multi-master":       "use the watch field instead",
+	"active-active":      "use the watch field instead",
+	"jobs":               "not allowed in a job",
+	"monitoring-address": "set it on the command line",
+}
+
+// agentJobFlags are the flags a job may set.
+var agentJobFlags = append(append(append(append([]cli.Flag{}, mirrorFlags...), deleteGuardFlags...), parallelFlags...), ioFlags...)
+
+// parseAgentJobs reads and validates a jobs file.
+func parseAgentJobs(r io.Reader) ([]agentJobConfig, error) {
+	data, e := ioutil.ReadAll(r)
+	if e != nil {
+		return nil, e
+	}
+	var file agentJobsFile
+	if e = yaml.UnmarshalStrict(data, &file); e != nil {
+		return nil, e
+	}
+	if len(file.Jobs) == 0 {
+		return nil, errors.New("no jobs defined")
+	}
+	names := make(map[string]bool)
+	for i, job := range file.Jobs {
+		switch {
+		case !agentJobNameRegexp.MatchString(job.Name):
+			return nil, fmt.Errorf("job %d: invalid name %q", i+1, job.Name)
+		case names[job.Name]:
+			return nil, fmt.Errorf("job %s: duplicate name", job.Name)
+		case job.Source == "" || job.Target == "":
+			return nil, fmt.Errorf("job %s: source and target are required", job.Name)
+		case job.Watch && job.Schedule != "":
+			return nil, fmt.Errorf("job %s: watch and schedule are mutually exclusive", job.Name)
+		case job.Workers < 0:
+			return nil, fmt.Errorf("job %s: workers cannot be negative", job.Name)
+		}
+		names[job.Name] = true
+		if job.Schedule != "" {
+			if _, e = parseAgentSchedule(job.Schedule); e != nil {
+				return nil, fmt.Errorf("job %s: invalid schedule: %w", job.Name, e)
+			}
+		}
+		if _, e = job.flagSet(); e != nil {
+			return nil, fmt.Errorf("job %s: %w", job.Name, e)
+		}
+	}
+	return file.Jobs, nil
+}
+
+// args returns the command line flags equivalent to the job.
+func (c agentJobConfig) args() ([]string, error) {
+	var args []string
+	if c.Watch {
+		args = append(args, "--watch")
+	}
+	if c.Workers > 0 {
+		args = append(args, "--max-workers="+strconv.Itoa(c.Workers))
+	}
+	if c.Bandwidth != "" {
+		args = append(args, "--limit-bandwidth="+c.Bandwidth)
+	}
+	names := make([]string, 0, len(c.Flags))
+	for name := range c.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if reason, ok := agentReservedFlags[name]; ok {
+			return nil, fmt.Errorf("flag %q: %s", name, reason)
+		}
+		switch value := c.Flags[name].(type) {
+		case []interface{}:
+			for _, v := range value {
+				args = append(args, fmt.Sprintf("--%s=%v", name, v))
+			}
+		case nil:
+			args = append(args, "--"+name)
+		default:
+			args = append(args, fmt.Sprintf("--%s=%v", name, value))
+		}
+	}
+	return args, nil
+}
+
+// flagSet parses the flags of the job.
+func (c agentJobConfig) flagSet() (*flag.FlagSet, error) {
+	args, e := c.args()
+	if e != nil {
+		return nil, e
+	}
+	set := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	set.SetOutput(ioutil.Discard)
+	for _, f := range agentJobFlags {
+		f.Apply(set)
+	}
+	if e = set.Parse(args); e != nil {
+		return nil, e
+	}
+	return set, nil
+}
+
+// agentJob runs a mirror job continuously, on a schedule or on demand.
+type agentJob struct {
+	config   agentJobConfig
+	schedule agentSchedule
+	cliCtx   *cli.Context
+	encKeyDB map[string][]prefixSSEPair
+	metrics  *mirrorMetrics
+
+	// Wakes up the job to run it, or to check its state.
+	triggerCh chan struct{}
+	wakeCh    chan struct{}
+
+	mu         sync.Mutex
+	paused     bool
+	running    bool
+	cancelRun  context.CancelFunc
+	runs       int
+	failures   int
+	lastStart  time.Time
+	lastEnd    time.Time
+	lastResult string
+	nextRun    time.Time
+}
+
+// agentJobStatus is the state of a job reported by the control API.
+type agentJobStatus struct {
+	Name       string     `json:"name"`
+	Source     string     `json:"source"`
+	Target     string     `json:"target"`
+	Mode       string     `json:"mode"`
+	Schedule   string     `json:"schedule,omitempty"`
+	State      string     `json:"state"`
+	Runs       int        `json:"runs"`
+	Failures   int        `json:"failures"`
+	LastStart  *time.Time `json:"lastStart,omitempty"`
+	LastEnd    *time.Time `json:"lastEnd,omitempty"`
+	LastResult string     `json:"lastResult,omitempty"`
+	NextRun    *time.Time `json:"nextRun,omitempty"`
+}
+
+// newAgentJob prepares a job, its local paths are relative to dir.
+func newAgentJob(parent *cli.Context, config agentJobConfig, dir string) (*agentJob, *probe.Error) {
+	set, e := config.flagSet()
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	cliCtx := cli.NewContext(parent.App, set, parent)
+	encKeyDB, err := getEncKeys(cliCtx)
+	if err != nil {
+		return nil, err.Trace(config.Name)
+	}
+	if _, err = parseLimitBandwidth(cliCtx); err != nil {
+		return nil, err.Trace(config.Name)
+	}
+	parseParallelOptions(cliCtx)
+
+	for _, url := range []*string{&config.Source, &config.Target} {
+		if _, err = newClient(*url); err != nil {
+			return nil, err.Trace(*url)
+		}
+		if alias, _, _ := mustExpandAlias(*url); alias == "" && !filepath.IsAbs(*url) {
+			*url = filepath.Join(dir, *url)
+		}
+	}
+
+	j := &agentJob{
+		config:    config,
+		cliCtx:    cliCtx,
+		encKeyDB:  encKeyDB,
+		metrics:   newMirrorMetrics(config.Name),
+		triggerCh: make(chan struct{}, 1),
+		wakeCh:    make(chan struct{}, 1),
+	}
+	if config.Schedule != "" {
+		j.schedule, e = parseAgentSchedule(config.Schedule)
+		if e != nil {
+			return nil, probe.NewError(e).Trace(config.Schedule)
+		}
+	}
+	return j, nil
+}
+
+func (j *agentJob) mode() string {
+	switch {
+	case j.config.Watch:
+		return "watch"
+	case j.config.Schedule != "":
+		return "schedule"
+	}
+	return "once"
+}
+
+// Status returns the current state of the job.
+func (j *agentJob) Status() agentJobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	timeOrNil := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+	st := agentJobStatus{
+		Name:       j.config.Name,
+		Source:     j.config.Source,
+		Target:     j.config.Target,
+		Mode:       j.mode(),
+		Schedule:   j.config.Schedule,
+		Runs:       j.runs,
+		Failures:   j.failures,
+		LastStart:  timeOrNil(j.lastStart),
+		LastEnd:    timeOrNil(j.lastEnd),
+		LastResult: j.lastResult,
+		NextRun:    timeOrNil(j.nextRun),
+	}
+	switch {
+	case j.paused:
+		st.State = "paused"
+	case j.running:
+		st.State = "running"
+	case !j.nextRun.IsZero():
+		st.State = "scheduled"
+	default:
+		st.State = "idle"
+	}
+	return st
+}
+
+var (
+	errAgentJobRunning = errors.New("job is already running")
+	errAgentJobPaused  = errors.New("job is paused")
+)
+
+// Trigger runs the job now.
+func (j *agentJob) Trigger() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch {
+	case j.paused:
+		return errAgentJobPaused
+	case j.running:
+		return errAgentJobRunning
+	}
+	select {
+	case j.triggerCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Pause stops the current run, if any, and the next ones until resumed.
+func (j *agentJob) Pause() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.paused = true
+	if j.cancelRun != nil {
+		j.cancelRun()
+	}
+	j.wake()
+}
+
+// Resume lets a paused job run again.
+func (j *agentJob) Resume() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.paused = false
+	j.wake()
+}
+
+func (j *agentJob) wake() {
+	select {
+	case j.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// next returns when the job should run next, nil to wait for a trigger.
+func (j *agentJob) next(first bool, r *rand.Rand) <-chan time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.nextRun = time.Time{}
+	switch {
+	case j.paused:
+		return nil
+	case j.config.Watch:
+		if first {
+			return time.After(0)
+		}
+		// Restart a watch like 'mc mirror --watch' does.
+		return time.After(time.Duration(r.Float64() * float64(2*time.Second)))
+	case j.schedule != nil:
+		now := time.Now()
+		j.nextRun = j.schedule.Next(now)
+		if j.nextRun.IsZero() {
+			return nil
+		}
+		return time.After(j.nextRun.Sub(now))
+	case first:
+		return time.After(0)
+	}
+	return nil
+}
+
+// run runs the job until ctx is canceled.
+func (j *agentJob) run(ctx context.Context, runMirrorFn func(ctx context.Context, j *agentJob) bool) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	ran := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.wakeCh:
+			// Paused or resumed, check again when to run.
+			continue
+		case <-j.triggerCh:
+		case <-j.next(!ran, r):
+		}
+		if j.runOnce(ctx, runMirrorFn) {
+			ran = true
+		}
+	}
+}
+
+// runOnce runs the job unless it is paused, it returns whether it ran.
+func (j *agentJob) runOnce(ctx context.Context, runMirrorFn func(ctx context.Context, j *agentJob) bool) bool {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	j.mu.Lock()
+	if j.paused {
+		j.mu.Unlock()
+		return false
+	}
+	j.running = true
+	j.cancelRun = cancel
+	j.runs++
+	j.lastStart = time.Now()
+	j.nextRun = time.Time{}
+	j.mu.Unlock()
+
+	errorDetected := runMirrorFn(runCtx, j)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.running = false
+	j.cancelRun = nil
+	j.lastEnd = time.Now()
+	switch {
+	case runCtx.Err() != nil:
+		j.lastResult = "canceled"
+	case errorDetected:
+		j.lastResult = "failed"
+		j.failures++
+	default:
+		j.lastResult = "success"
+	}
+	if j.config.Watch && runCtx.Err() == nil {
+		j.metrics.restarts.Inc()
+	}
+	return true
+}
+
+// runAgentJob mirrors the source of a job to its target.
+func runAgentJob(ctx context.Context, j *agentJob) bool {
+	return runMirror(ctx, nil, j.config.Source, j.config.Target, j.cliCtx, j.encKeyDB, j.metrics)
+}
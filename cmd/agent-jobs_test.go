@@ -0,0 +1,232 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAgentJobs(t *testing.T) {
+	jobs, e := parseAgentJobs(strings.NewReader(`
+jobs:
+  - name: photos
+    source: photos
+    target: s3/photos
+    watch: true
+    workers: 4
+    bandwidth: 10MiB
+    flags:
+      remove: true
+      exclude: ["*.tmp", ".cache/*"]
+      debounce: 2s
+  - name: db-dumps
+    source: /var/backups/db
+    target: s3/db
+    schedule: "30 2 * * *"
+`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(jobs))
+	}
+	args, e := jobs[0].args()
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := "--watch --max-workers=4 --limit-bandwidth=10MiB --debounce=2s --exclude=*.tmp --exclude=.cache/* --remove=true"
+	if got := strings.Join(args, " "); got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+	set, e := jobs[0].flagSet()
+	if e != nil {
+		t.Fatal(e)
+	}
+	if set.Lookup("max-workers").Value.String() != "4" || set.Lookup("exclude").Value.String() != `[*.tmp .cache/*]` {
+		t.Fatalf("unexpected flags %v %v", set.Lookup("max-workers").Value, set.Lookup("exclude").Value)
+	}
+
+	testCases := []string{
+		"jobs: []",
+		"jobs:\n  - {name: a, source: x}",
+		"jobs:\n  - {name: a/b, source: x, target: y}",
+		"jobs:\n  - {name: a, source: x, target: y}\n  - {name: a, source: x, target: z}",
+		"jobs:\n  - {name: a, source: x, target: y, watch: true, schedule: '@daily'}",
+		"jobs:\n  - {name: a, source: x, target: y, schedule: '61 * * * *'}",
+		"jobs:\n  - {name: a, source: x, target: y, unknown: 1}",
+		"jobs:\n  - {name: a, source: x, target: y, flags: {unknown: 1}}",
+		"jobs:\n  - {name: a, source: x, target: y, flags: {active-active: true}}",
+		"jobs:\n  - {name: a, source: x, target: y, flags: {max-workers: many}}",
+	}
+	for i, tc := range testCases {
+		if _, e := parseAgentJobs(strings.NewReader(tc)); e == nil {
+			t.Errorf("Test %d: expected an error", i+1)
+		}
+	}
+}
+
+func newTestAgentJob(config agentJobConfig) *agentJob {
+	j := &agentJob{
+		config:    config,
+		metrics:   newMirrorMetrics(config.Name),
+		triggerCh: make(chan struct{}, 1),
+		wakeCh:    make(chan struct{}, 1),
+	}
+	if config.Schedule != "" {
+		j.schedule, _ = parseAgentSchedule(config.Schedule)
+	}
+	return j
+}
+
+func TestAgentJobRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := make(chan struct{}, 10)
+	block := make(chan struct{})
+	fakeMirror := func(ctx context.Context, j *agentJob) bool {
+		runs <- struct{}{}
+		select {
+		case <-ctx.Done():
+		case <-block:
+		}
+		return false
+	}
+	waitRun := func() {
+		select {
+		case <-runs:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the job didn't run")
+		}
+	}
+	waitStatus := func(j *agentJob, ok func(st agentJobStatus) bool) {
+		deadline := time.Now().Add(5 * time.Second)
+		for !ok(j.Status()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("unexpected status %+v", j.Status())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitState := func(j *agentJob, state string) {
+		waitStatus(j, func(st agentJobStatus) bool { return st.State == state })
+	}
+
+	// A job without schedule runs at start, then when triggered.
+	j := newTestAgentJob(agentJobConfig{Name: "once"})
+	go j.run(ctx, fakeMirror)
+	waitRun()
+	if e := j.Trigger(); e != errAgentJobRunning {
+		t.Fatalf("expected %v, got %v", errAgentJobRunning, e)
+	}
+	block <- struct{}{}
+	waitState(j, "idle")
+	if st := j.Status(); st.Runs != 1 || st.LastResult != "success" || st.NextRun != nil {
+		t.Fatalf("unexpected status %+v", st)
+	}
+	if e := j.Trigger(); e != nil {
+		t.Fatal(e)
+	}
+	waitRun()
+
+	// Pausing cancels the current run.
+	j.Pause()
+	waitStatus(j, func(st agentJobStatus) bool {
+		return st.State == "paused" && st.Runs == 2 && st.LastResult == "canceled"
+	})
+	if e := j.Trigger(); e != errAgentJobPaused {
+		t.Fatalf("expected %v, got %v", errAgentJobPaused, e)
+	}
+	j.Resume()
+	waitState(j, "idle")
+
+	// A scheduled job waits for its next run.
+	j = newTestAgentJob(agentJobConfig{Name: "daily", Schedule: "@daily"})
+	go j.run(ctx, fakeMirror)
+	waitState(j, "scheduled")
+	if st := j.Status(); st.NextRun == nil || !st.NextRun.After(time.Now()) || st.Runs != 0 {
+		t.Fatalf("unexpected status %+v", st)
+	}
+	j.Trigger()
+	waitRun()
+	close(block)
+	waitState(j, "scheduled")
+}
+
+func TestAgentAPI(t *testing.T) {
+	j := newTestAgentJob(agentJobConfig{Name: "photos", Source: "/photos", Target: "s3/photos", Watch: true})
+	dir, e := ioutil.TempDir("", "mc-agent")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	address := filepath.Join(dir, "agent.sock")
+	l, err := listenConfigAgent(address)
+	if err != nil {
+		t.Skip("unix sockets are not supported:", err)
+	}
+	server := httptest.NewUnstartedServer(agentAPIHandler{jobs: []*agentJob{j}})
+	server.Listener.Close()
+	server.Listener = l
+	server.Start()
+	defer server.Close()
+	ctx := context.Background()
+
+	var statuses []agentJobStatus
+	if err := agentRequest(ctx, address, http.MethodGet, agentJobsPath, &statuses); err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0].Name != "photos" || statuses[0].Mode != "watch" || statuses[0].State != "idle" {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+
+	var st agentJobStatus
+	if err := agentRequest(ctx, address, http.MethodPost, agentJobsPath+"/photos/pause", &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.State != "paused" {
+		t.Fatalf("expected the job to be paused, got %+v", st)
+	}
+	err = agentRequest(ctx, address, http.MethodPost, agentJobsPath+"/photos/trigger", &st)
+	if err == nil || err.ToGoError().Error() != errAgentJobPaused.Error() {
+		t.Fatalf("expected %v, got %v", errAgentJobPaused, err)
+	}
+	if err = agentRequest(ctx, address, http.MethodGet, agentJobsPath+"/other", &st); err == nil {
+		t.Fatal("expected an error for an unknown job")
+	}
+	if err = agentRequest(ctx, address, http.MethodGet, agentJobsPath+"/photos/pause", &st); err == nil {
+		t.Fatal("expected an error for a GET action")
+	}
+
+	// Cross-site requests of browsers are refused.
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, agentJobsPath+"/photos/resume", nil)
+	req.Header.Set("Origin", "https://example.com")
+	agentAPIHandler{jobs: []*agentJob{j}}.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || j.Status().State != "paused" {
+		t.Fatalf("expected a cross-origin request to be refused, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+	"github.com/minio/pkg/env"
+)
+
+// defaultAgentSocket is the unix socket in the config directory where
+// the agent serves its control API.
+const defaultAgentSocket = "agent.sock"
+
+var agentFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "socket",
+		Usage: "unix socket of the agent control API, defaults to MC_AGENT_SOCKET or " + defaultAgentSocket + " in the config directory",
+	},
+}
+
+var agentSubcommands = []cli.Command{
+	agentRunCmd,
+	agentStatusCmd,
+	agentTriggerCmd,
+	agentPauseCmd,
+	agentResumeCmd,
+}
+
+var agentCmd = cli.Command{
+	Name:            "agent",
+	Usage:           "run and control scheduled mirror jobs",
+	HideHelpCommand: true,
+	Action:          mainAgent,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	Subcommands:     agentSubcommands,
+}
+
+// mainAgent is the handle for "mc agent" command.
+func mainAgent(ctx *cli.Context) error {
+	commandNotFound(ctx, agentSubcommands)
+	return nil
+	// Sub-commands like "run", "status" have their own main.
+}
+
+// agentSocket returns the unix socket of the agent control API.
+func agentSocket(cliCtx *cli.Context) string {
+	if socket := cliCtx.String("socket"); socket != "" {
+		return socket
+	}
+	return env.Get("MC_AGENT_SOCKET", filepath.Join(mustGetMcConfigDir(), defaultAgentSocket))
+}
+
+// agentControlMessage container for agent job control messages
+type agentControlMessage struct {
+	Status string         `json:"status"`
+	Action string         `json:"action"`
+	Job    agentJobStatus `json:"job"`
+}
+
+// String colorized agent job control message
+func (m agentControlMessage) String() string {
+	return console.Colorize("AgentJob", fmt.Sprintf("Job `%s` %s.", m.Job.Name, m.Action))
+}
+
+// JSON jsonified agent job control message
+func (m agentControlMessage) JSON() string {
+	m.Status = "success"
+	controlJSONBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(controlJSONBytes)
+}
+
+// mainAgentControl applies an action of the control API to the job
+// given as argument, past is the action in the past tense.
+func mainAgentControl(cliCtx *cli.Context, action, past string) error {
+	if len(cliCtx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(cliCtx, action, 1) // last argument is exit code
+	}
+	ctx, cancelAgentControl := context.WithCancel(globalContext)
+	defer cancelAgentControl()
+
+	console.SetColor("AgentJob", color.New(color.Bold))
+
+	name := cliCtx.Args().Get(0)
+	var st agentJobStatus
+	err := agentRequest(ctx, agentSocket(cliCtx), http.MethodPost, agentJobsPath+"/"+name+"/"+action, &st)
+	fatalIf(err, "Unable to "+action+" job `"+name+"`.")
+	printMsg(agentControlMessage{Action: past, Job: st})
+	return nil
+}
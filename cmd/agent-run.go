@@ -0,0 +1,155 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var agentRunFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "monitoring-address",
+		Usage: "if specified, a new prometheus endpoint will be created to report mirroring activity per job. (eg: localhost:8081)",
+	},
+}
+
+var agentRunCmd = cli.Command{
+	Name:         "run",
+	Usage:        "run the mirror jobs defined in a file",
+	Action:       mainAgentRun,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(append(agentRunFlags, agentFlags...), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] FILE
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+JOBS FILE:
+  A YAML file with a list of jobs, each job has the following fields:
+    name       unique name of the job
+    source     source of the mirror, local paths are relative to the file
+    target     target of the mirror
+    watch      mirror continuously, like 'mc mirror --watch'
+    schedule   when to mirror, a cron expression such as "*/15 * * * *",
+               @hourly, @daily, @weekly, @monthly or "@every 30m"
+    workers    maximum number of concurrent transfers
+    bandwidth  maximum transfer rate per second, e.g. 10MiB
+    flags      any other 'mc mirror' flag by name, e.g. "remove: true"
+  A job without watch or schedule runs once at start and on demand.
+
+CONTROL API:
+  The agent serves the status of its jobs and lets them be triggered, paused and
+  resumed on the unix socket --socket, see 'mc agent status'. Only the user running
+  the agent can connect to it.
+
+EXAMPLES:
+  1. Run the jobs of jobs.yaml, exposing prometheus metrics labeled with the job names.
+     {{.Prompt}} {{.HelpName}} --monitoring-address localhost:8081 jobs.yaml
+
+     jobs.yaml:
+       jobs:
+         - name: photos
+           source: /home/user/photos
+           target: s3/photos
+           watch: true
+           workers: 4
+           bandwidth: 20MiB
+           flags:
+             remove: true
+             exclude: ["*.tmp", ".cache/*"]
+         - name: db-dumps
+           source: /var/backups/db
+           target: s3/db-dumps
+           schedule: "30 2 * * *"
+           flags:
+             storage-class: GLACIER
+`,
+}
+
+func checkAgentRunSyntax(cliCtx *cli.Context) {
+	if len(cliCtx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(cliCtx, "run", 1) // last argument is exit code
+	}
+}
+
+// mainAgentRun is the handle for "mc agent run" command.
+func mainAgentRun(cliCtx *cli.Context) error {
+	checkAgentRunSyntax(cliCtx)
+	console.SetColor("Mirror", color.New(color.FgGreen, color.Bold))
+
+	ctx, cancelAgent := context.WithCancel(globalContext)
+	defer cancelAgent()
+
+	return runAgent(ctx, cliCtx, cliCtx.Args().Get(0), agentSocket(cliCtx))
+}
+
+// runAgent runs the jobs of a file until ctx is canceled, it is shared
+// by 'mc agent run' and 'mc mirror --jobs'.
+func runAgent(ctx context.Context, cliCtx *cli.Context, jobsFile, socket string) error {
+	f, e := os.Open(jobsFile)
+	fatalIf(probe.NewError(e), "Unable to open the jobs file.")
+	configs, e := parseAgentJobs(f)
+	f.Close()
+	fatalIf(probe.NewError(e).Trace(jobsFile), "Unable to parse the jobs file.")
+
+	dir, e := filepath.Abs(filepath.Dir(jobsFile))
+	fatalIf(probe.NewError(e), "Unable to resolve the jobs file directory.")
+
+	var jobs []*agentJob
+	for _, config := range configs {
+		j, err := newAgentJob(cliCtx, config, dir)
+		fatalIf(err, "Unable to initialize job `"+config.Name+"`.")
+		jobs = append(jobs, j)
+	}
+
+	// The jobs run concurrently, progress bars would garble each other.
+	globalQuiet = true
+
+	listener, err := listenConfigAgent(socket)
+	fatalIf(err.Trace(socket), "Unable to listen on the control socket `"+socket+"`.")
+	server := &http.Server{Handler: agentAPIHandler{jobs: jobs}}
+	go server.Serve(listener)
+	defer server.Close()
+
+	startMirrorMonitoring(cliCtx.String("monitoring-address"))
+
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j *agentJob) {
+			defer wg.Done()
+			j.run(ctx, runAgentJob)
+		}(j)
+	}
+	wg.Wait()
+	return nil
+}
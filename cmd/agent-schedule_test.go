@@ -0,0 +1,63 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgentSchedule(t *testing.T) {
+	// Saturday.
+	now := time.Date(2022, 1, 1, 10, 30, 15, 0, time.UTC)
+	testCases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2022, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2022, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"5/15 * * * *", time.Date(2022, 1, 1, 10, 35, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2022, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2022, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2022, 1, 2, 2, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2022, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * mon-fri", time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 mar *", time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day matches when both are restricted.
+		{"0 0 15 * 1", time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+		{"@every 90m", now.Add(90 * time.Minute)},
+	}
+	for i, tc := range testCases {
+		s, e := parseAgentSchedule(tc.spec)
+		if e != nil {
+			t.Fatalf("Test %d: unexpected error %v", i+1, e)
+		}
+		if got := s.Next(now); !got.Equal(tc.expected) {
+			t.Errorf("Test %d: %q expected %v, got %v", i+1, tc.spec, tc.expected, got)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "@every 1ms", "@every x"} {
+		if _, e := parseAgentSchedule(spec); e == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var agentStatusCmd = cli.Command{
+	Name:         "status",
+	Usage:        "show the status of the agent jobs",
+	Action:       mainAgentStatus,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(agentFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] [JOB]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Show the status of all the jobs of the local agent.
+     {{.Prompt}} {{.HelpName}}
+
+  2. Show the status of the job 'photos' of an agent listening on another socket.
+     {{.Prompt}} {{.HelpName}} --socket /run/user/1000/mc-agent.sock photos
+`,
+}
+
+// agentStatusMessage container for agent job status messages
+type agentStatusMessage struct {
+	Status string `json:"status"`
+	agentJobStatus
+}
+
+// String colorized agent job status message
+func (m agentStatusMessage) String() string {
+	theme := "AgentIdle"
+	switch m.State {
+	case "running":
+		theme = "AgentRunning"
+	case "paused":
+		theme = "AgentPaused"
+	}
+	mode := m.Mode
+	if m.Schedule != "" {
+		mode = fmt.Sprintf("%s %q", m.Mode, m.Schedule)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", console.Colorize("AgentJob", fmt.Sprintf("%-20s", m.Name)),
+		console.Colorize(theme, fmt.Sprintf("%-10s", m.State)), mode)
+	if m.LastEnd != nil {
+		result := m.LastResult
+		if result == "failed" {
+			result = console.Colorize("AgentFailed", result)
+		}
+		fmt.Fprintf(&b, ", last run %s %s ago", result, timeDurationToHumanizedDuration(time.Since(*m.LastEnd)).StringShort())
+	}
+	if m.NextRun != nil {
+		fmt.Fprintf(&b, ", next run in %s", timeDurationToHumanizedDuration(time.Until(*m.NextRun)).StringShort())
+	}
+	fmt.Fprintf(&b, " (%d runs, %d failures)", m.Runs, m.Failures)
+	return b.String()
+}
+
+// JSON jsonified agent job status message
+func (m agentStatusMessage) JSON() string {
+	m.Status = "success"
+	statusJSONBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(statusJSONBytes)
+}
+
+func checkAgentStatusSyntax(cliCtx *cli.Context) {
+	if len(cliCtx.Args()) > 1 {
+		cli.ShowCommandHelpAndExit(cliCtx, "status", 1) // last argument is exit code
+	}
+}
+
+// mainAgentStatus is the handle for "mc agent status" command.
+func mainAgentStatus(cliCtx *cli.Context) error {
+	checkAgentStatusSyntax(cliCtx)
+	ctx, cancelAgentStatus := context.WithCancel(globalContext)
+	defer cancelAgentStatus()
+
+	console.SetColor("AgentJob", color.New(color.Bold))
+	console.SetColor("AgentRunning", color.New(color.FgGreen, color.Bold))
+	console.SetColor("AgentPaused", color.New(color.FgYellow, color.Bold))
+	console.SetColor("AgentIdle", color.New(color.FgCyan))
+	console.SetColor("AgentFailed", color.New(color.FgRed, color.Bold))
+
+	socket := agentSocket(cliCtx)
+	var statuses []agentJobStatus
+	if name := cliCtx.Args().Get(0); name != "" {
+		var st agentJobStatus
+		err := agentRequest(ctx, socket, http.MethodGet, agentJobsPath+"/"+name, &st)
+		fatalIf(err, "Unable to get the status of job `"+name+"`.")
+		statuses = append(statuses, st)
+	} else {
+		err := agentRequest(ctx, socket, http.MethodGet, agentJobsPath, &statuses)
+		fatalIf(err, "Unable to get the status of the agent at `"+socket+"`.")
+	}
+	for _, st := range statuses {
+		printMsg(agentStatusMessage{agentJobStatus: st})
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/console"
+)
+
+var aliasDecryptCmd = cli.Command{
+	Name:            "decrypt",
+	Usage:           "store the credentials in configuration file in plain text again",
+	Action:          mainAliasDecrypt,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	OnUsageError:    onUsageError,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}}
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Remove the encryption of the configuration file.
+     {{.Prompt}} {{.HelpName}}
+     Enter passphrase of '/home/user/.mc/config.json':
+`,
+}
+
+func mainAliasDecrypt(ctx *cli.Context) error {
+	if len(ctx.Args()) != 0 {
+		cli.ShowCommandHelpAndExit(ctx, "decrypt", 1) // last argument is exit code
+	}
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
+	if conf.Encryption == nil {
+		fatalIf(errInvalidArgument(), "Config `"+mustGetMcConfigPath()+"` is not encrypted.")
+	}
+
+	key, err := unlockConfig(conf.Encryption, true)
+	fatalIf(err, "Unable to unlock config `"+mustGetMcConfigPath()+"`.")
+	fatalIf(openConfigSecrets(conf, key), "Unable to decrypt config `"+mustGetMcConfigPath()+"`.")
+	conf.Encryption = nil
+	fatalIf(saveMcConfig(conf), "Unable to save config `"+mustGetMcConfigPath()+"`.")
+
+	printMsg(aliasSecretsMessage{op: "decrypt", Config: mustGetMcConfigPath(), Aliases: len(conf.Aliases)})
+	return nil
+}
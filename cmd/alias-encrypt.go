@@ -0,0 +1,121 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var aliasEncryptCmd = cli.Command{
+	Name:            "encrypt",
+	Usage:           "encrypt the credentials stored in configuration file",
+	Action:          mainAliasEncrypt,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	OnUsageError:    onUsageError,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}}
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Access keys, secret keys and session tokens of all aliases are encrypted with
+  AES-GCM, using a key derived from a passphrase with Argon2id. Credentials stored
+  as 'cmd:' references are kept as they are. An encrypted configuration is
+  encrypted again with a new passphrase.
+
+  Commands reading credentials prompt for the passphrase, unless the configuration
+  is unlocked for the shell with 'mc alias unlock'.
+
+EXAMPLES:
+  1. Encrypt the credentials of all aliases.
+     {{.Prompt}} {{.HelpName}}
+     Enter new passphrase:
+     Confirm passphrase:
+
+  2. Unlock the configuration for the current shell.
+     {{.Prompt}} eval $(mc alias unlock)
+`,
+}
+
+// aliasSecretsMessage reports a change of the credentials encryption.
+type aliasSecretsMessage struct {
+	op      string
+	Status  string `json:"status"`
+	Config  string `json:"config"`
+	Aliases int    `json:"aliases"`
+}
+
+func (a aliasSecretsMessage) String() string {
+	switch a.op {
+	case "encrypt":
+		return console.Colorize("AliasMessage", fmt.Sprintf("Encrypted credentials of %d aliases in `%s`.", a.Aliases, a.Config))
+	default:
+		return console.Colorize("AliasMessage", fmt.Sprintf("Decrypted credentials of %d aliases in `%s`.", a.Aliases, a.Config))
+	}
+}
+
+func (a aliasSecretsMessage) JSON() string {
+	a.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(a, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func mainAliasEncrypt(ctx *cli.Context) error {
+	if len(ctx.Args()) != 0 {
+		cli.ShowCommandHelpAndExit(ctx, "encrypt", 1) // last argument is exit code
+	}
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
+
+	// Encrypt again with a new passphrase.
+	if conf.Encryption != nil {
+		key, err := unlockConfig(conf.Encryption, true)
+		fatalIf(err, "Unable to unlock config `"+mustGetMcConfigPath()+"`.")
+		fatalIf(openConfigSecrets(conf, key), "Unable to decrypt config `"+mustGetMcConfigPath()+"`.")
+	}
+
+	passphrase, err := readPassphrase("Enter new passphrase: ", true)
+	fatalIf(err, "Unable to read passphrase.")
+	enc, key, err := newConfigEncryption(passphrase)
+	fatalIf(err, "Unable to derive a key from the passphrase.")
+
+	for alias, aliasCfg := range conf.Aliases {
+		fatalIf(sealAliasSecrets(key, alias, &aliasCfg), "Unable to encrypt the credentials of `"+alias+"`.")
+		conf.Aliases[alias] = aliasCfg
+	}
+	conf.Encryption = enc
+	fatalIf(saveMcConfig(conf), "Unable to save config `"+mustGetMcConfigPath()+"`.")
+
+	printMsg(aliasSecretsMessage{op: "encrypt", Config: mustGetMcConfigPath(), Aliases: len(conf.Aliases)})
+	return nil
+}
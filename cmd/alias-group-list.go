@@ -0,0 +1,89 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/console"
+)
+
+var aliasGroupListCmd = cli.Command{
+	Name:            "list",
+	ShortName:       "ls",
+	Usage:           "list groups of aliases",
+	Action:          mainAliasGroupList,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	OnUsageError:    onUsageError,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [GROUP]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. List all groups.
+     {{.Prompt}} {{.HelpName}}
+
+  2. List the aliases of the "prod" group.
+     {{.Prompt}} {{.HelpName}} prod
+`,
+}
+
+// checkAliasGroupListSyntax - verifies input arguments to 'alias group list'.
+func checkAliasGroupListSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) > 1 {
+		cli.ShowCommandHelpAndExit(ctx, "list", 1) // last argument is exit code
+	}
+}
+
+// mainAliasGroupList is the handle for "mc alias group list" command.
+func mainAliasGroupList(ctx *cli.Context) error {
+	checkAliasGroupListSyntax(ctx)
+
+	console.SetColor("AliasGroup", color.New(color.FgCyan, color.Bold))
+	console.SetColor("AliasGroupMembers", color.New(color.FgWhite))
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config version `"+globalMCConfigVersion+"`.")
+
+	var groups []string
+	if group := ctx.Args().First(); group != "" {
+		if _, ok := conf.Groups[group]; !ok {
+			fatalIf(errInvalidArgument().Trace(group), "No such group `"+group+"` found.")
+		}
+		groups = append(groups, group)
+	} else {
+		for group := range conf.Groups {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+	}
+
+	for _, group := range groups {
+		printMsg(aliasGroupMessage{op: "list", Group: group, Aliases: conf.Groups[group]})
+	}
+	return nil
+}
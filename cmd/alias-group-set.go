@@ -0,0 +1,104 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/console"
+)
+
+var aliasGroupSetCmd = cli.Command{
+	Name:            "set",
+	Usage:           "set the aliases of a group",
+	Action:          mainAliasGroupSet,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	OnUsageError:    onUsageError,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} GROUP ALIAS [ALIAS...]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Groups name a set of aliases, commands run on all of them with '--aliases GROUP'.
+  Setting an existing group replaces its aliases.
+
+EXAMPLES:
+  1. Group the production clusters.
+     {{.Prompt}} {{.HelpName}} prod prod-us-east prod-us-west prod-eu
+
+  2. Check that all production clusters are ready.
+     {{.Prompt}} mc ready --aliases prod
+`,
+}
+
+// checkAliasGroupSetSyntax - verifies input arguments to 'alias group set'.
+func checkAliasGroupSetSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) < 2 {
+		cli.ShowCommandHelpAndExit(ctx, "set", 1) // last argument is exit code
+	}
+	checkAliasGroupName(ctx.Args().First())
+}
+
+// mainAliasGroupSet is the handle for "mc alias group set" command.
+func mainAliasGroupSet(ctx *cli.Context) error {
+	checkAliasGroupSetSyntax(ctx)
+
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	group, aliases := ctx.Args().First(), ctx.Args().Tail()
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config version `"+globalMCConfigVersion+"`.")
+
+	if _, ok := conf.Aliases[group]; ok {
+		fatalIf(errInvalidArgument().Trace(group), "Group `"+group+"` has the name of an alias.")
+	}
+	members := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		alias = cleanAlias(alias)
+		if _, ok := conf.Aliases[alias]; !ok {
+			fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
+		}
+		members[alias] = true
+	}
+	aliases = aliases[:0]
+	for alias := range members {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	if conf.Groups == nil {
+		conf.Groups = make(map[string][]string)
+	}
+	conf.Groups[group] = aliases
+
+	err = saveMcConfig(conf)
+	fatalIf(err.Trace(group), "Unable to save the group in config version `"+globalMCConfigVersion+"`.")
+
+	printMsg(aliasGroupMessage{op: "set", Group: group, Aliases: aliases})
+	return nil
+}
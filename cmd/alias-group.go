@@ -0,0 +1,84 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var aliasGroupSubcommands = []cli.Command{
+	aliasGroupSetCmd,
+	aliasGroupRemoveCmd,
+	aliasGroupListCmd,
+}
+
+var aliasGroupCmd = cli.Command{
+	Name:            "group",
+	Usage:           "manage groups of aliases to run commands on with --aliases",
+	Action:          mainAliasGroup,
+	Before:          setGlobalsFromContext,
+	HideHelpCommand: true,
+	Flags:           globalFlags,
+	Subcommands:     aliasGroupSubcommands,
+}
+
+// mainAliasGroup is the handle for "mc alias group" command.
+func mainAliasGroup(ctx *cli.Context) error {
+	commandNotFound(ctx, aliasGroupSubcommands)
+	return nil
+	// Sub-commands like set, remove and list have their own main.
+}
+
+// aliasGroupMessage container for alias group messages.
+type aliasGroupMessage struct {
+	op      string
+	Status  string   `json:"status"`
+	Group   string   `json:"group"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+func (g aliasGroupMessage) String() string {
+	switch g.op {
+	case "list":
+		return console.Colorize("AliasGroup", g.Group+":") + " " + console.Colorize("AliasGroupMembers", strings.Join(g.Aliases, ", "))
+	case "remove":
+		return console.Colorize("AliasMessage", "Removed group `"+g.Group+"` successfully.")
+	default:
+		return console.Colorize("AliasMessage", "Set group `"+g.Group+"` successfully.")
+	}
+}
+
+func (g aliasGroupMessage) JSON() string {
+	g.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(g, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+
+	return string(jsonMessageBytes)
+}
+
+// checkAliasGroupName verifies that a group name is valid.
+func checkAliasGroupName(group string) {
+	if !isValidAlias(group) {
+		fatalIf(errInvalidArgument().Trace(group), "Invalid group name `"+group+"`.")
+	}
+}
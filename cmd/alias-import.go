@@ -116,6 +116,9 @@ func importAlias(alias string, aliasCfgV10 aliasConfigV10) aliasMessage {
 	mcCfgV10, err := loadMcConfig()
 	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
 
+	err = storeAliasSecrets(mcCfgV10, alias, &aliasCfgV10)
+	fatalIf(err.Trace(alias), "Unable to encrypt the credentials of `"+alias+"`.")
+
 	// Add new host.
 	mcCfgV10.Aliases[alias] = aliasCfgV10
 	fatalIf(saveMcConfig(mcCfgV10).Trace(alias), "Unable to import credentials to `"+mustGetMcConfigPath()+"`.")
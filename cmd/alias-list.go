@@ -26,6 +26,13 @@ import (
 	"github.com/minio/pkg/console"
 )
 
+var aliasListFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "show-secrets",
+		Usage: "show secret keys instead of redacting them, decrypting them if needed",
+	},
+}
+
 var aliasListCmd = cli.Command{
 	Name:      "list",
 	ShortName: "ls",
@@ -34,7 +41,7 @@ var aliasListCmd = cli.Command{
 		return mainAliasList(ctx, false)
 	},
 	Before:          setGlobalsFromContext,
-	Flags:           globalFlags,
+	Flags:           append(aliasListFlags, globalFlags...),
 	OnUsageError:    onUsageError,
 	HideHelpCommand: true,
 	CustomHelpTemplate: `NAME:
@@ -52,6 +59,9 @@ EXAMPLES:
 
   2. List a specific alias.
      {{.Prompt}} {{.HelpName}} s3
+
+  3. List a specific alias with its secret key.
+     {{.Prompt}} {{.HelpName}} --show-secrets s3
 `,
 }
 
@@ -75,10 +85,11 @@ func mainAliasList(ctx *cli.Context, deprecated bool) error {
 	console.SetColor("SecretKey", color.New(color.FgCyan))
 	console.SetColor("API", color.New(color.FgBlue))
 	console.SetColor("Path", color.New(color.FgCyan))
+	console.SetColor("Transport", color.New(color.FgCyan))
 
 	alias := cleanAlias(ctx.Args().Get(0))
 
-	aliasesMsgs := listAliases(alias, deprecated) // List all configured hosts.
+	aliasesMsgs := listAliases(alias, deprecated, ctx.Bool("show-secrets")) // List all configured hosts.
 	for i := range aliasesMsgs {
 		aliasesMsgs[i].op = "list"
 	}
@@ -116,53 +127,60 @@ func (d byAlias) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
 func (d byAlias) Less(i, j int) bool { return d[i].Alias < d[j].Alias }
 
 // listAliases - list one or all aliases
-func listAliases(alias string, deprecated bool) (aliases []aliasMessage) {
+func listAliases(alias string, deprecated, showSecrets bool) (aliases []aliasMessage) {
 	conf, err := loadMcConfig()
 	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config version `"+globalMCConfigVersion+"`.")
 
 	// If specific alias is requested, look for it and print.
 	if alias != "" {
 		if v, ok := conf.Aliases[alias]; ok {
-			aliasMsg := aliasMessage{
-				prettyPrint: false,
-				Alias:       alias,
-				URL:         v.URL,
-				AccessKey:   v.AccessKey,
-				SecretKey:   v.SecretKey,
-				API:         v.API,
-			}
-
-			if deprecated {
-				aliasMsg.Lookup = v.Path
-			} else {
-				aliasMsg.Path = v.Path
-			}
-
+			aliasMsg := newAliasListMessage(conf, alias, v, deprecated, showSecrets)
+			aliasMsg.prettyPrint = false
 			return []aliasMessage{aliasMsg}
 		}
 		fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
 	}
 
 	for k, v := range conf.Aliases {
-		aliasMsg := aliasMessage{
-			prettyPrint: true,
-			Alias:       k,
-			URL:         v.URL,
-			AccessKey:   v.AccessKey,
-			SecretKey:   v.SecretKey,
-			API:         v.API,
-		}
-
-		if deprecated {
-			aliasMsg.Lookup = v.Path
-		} else {
-			aliasMsg.Path = v.Path
-		}
-
-		aliases = append(aliases, aliasMsg)
+		aliases = append(aliases, newAliasListMessage(conf, k, v, deprecated, showSecrets))
 	}
 
 	// Sort by alias names lexically.
 	sort.Sort(byAlias(aliases))
 	return
 }
+
+// newAliasListMessage returns the listing of an alias, secrets are
+// redacted unless showSecrets is set. Encrypted access keys are shown
+// when the config is already unlocked.
+func newAliasListMessage(conf *configV10, alias string, v aliasConfigV10, deprecated, showSecrets bool) aliasMessage {
+	if showSecrets {
+		err := resolveAliasSecrets(conf.Encryption, alias, &v, true)
+		fatalIf(err.Trace(alias), "Unable to read the credentials of alias `"+alias+"`.")
+	} else {
+		if conf.Encryption != nil && isSealedSecret(v.AccessKey) {
+			accessKey, err := resolveSecret(conf.Encryption, alias+".accessKey", v.AccessKey, false, false)
+			if err != nil {
+				accessKey = redactSecret(&v, v.AccessKey)
+			}
+			v.AccessKey = accessKey
+		}
+		v.SecretKey = redactSecret(&v, v.SecretKey)
+	}
+
+	aliasMsg := aliasMessage{
+		prettyPrint: true,
+		Alias:       alias,
+		URL:         v.URL,
+		AccessKey:   v.AccessKey,
+		SecretKey:   v.SecretKey,
+		API:         v.API,
+		Transport:   v.Transport,
+	}
+	if deprecated {
+		aliasMsg.Lookup = v.Path
+	} else {
+		aliasMsg.Path = v.Path
+	}
+	return aliasMsg
+}
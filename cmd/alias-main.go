@@ -42,6 +42,11 @@ var aliasSubcommands = []cli.Command{
 	aliasListCmd,
 	aliasRemoveCmd,
 	aliasImportCmd,
+	aliasEncryptCmd,
+	aliasDecryptCmd,
+	aliasUnlockCmd,
+	aliasGroupCmd,
+	aliasProtectCmd,
 }
 
 var aliasCmd = cli.Command{
@@ -73,7 +78,8 @@ type aliasMessage struct {
 	API         string `json:"api,omitempty"`
 	Path        string `json:"path,omitempty"`
 	// Deprecated field, replaced by Path
-	Lookup string `json:"lookup,omitempty"`
+	Lookup    string             `json:"lookup,omitempty"`
+	Transport *aliasTransportV10 `json:"transport,omitempty"`
 }
 
 // Print the config information of one alias, when prettyPrint flag
@@ -83,20 +89,25 @@ func (h aliasMessage) String() string {
 	switch h.op {
 	case "list":
 		// Create a new pretty table with cols configuration
-		t := newPrettyRecord(2,
-			Row{"Alias", "Alias"},
-			Row{"URL", "URL"},
-			Row{"AccessKey", "AccessKey"},
-			Row{"SecretKey", "SecretKey"},
-			Row{"API", "API"},
-			Row{"Path", "Path"},
-		)
+		rows := []Row{
+			{"Alias", "Alias"},
+			{"URL", "URL"},
+			{"AccessKey", "AccessKey"},
+			{"SecretKey", "SecretKey"},
+			{"API", "API"},
+			{"Path", "Path"},
+		}
 		// Handle deprecated lookup
 		path := h.Path
 		if path == "" {
 			path = h.Lookup
 		}
-		return t.buildRecord(h.Alias, h.URL, h.AccessKey, h.SecretKey, h.API, path)
+		contents := []string{h.Alias, h.URL, h.AccessKey, h.SecretKey, h.API, path}
+		if h.Transport != nil {
+			rows = append(rows, Row{"Transport", "Transport"})
+			contents = append(contents, h.Transport.String())
+		}
+		return newPrettyRecord(2, rows...).buildRecord(contents...)
 	case "remove":
 		return console.Colorize("AliasMessage", "Removed `"+h.Alias+"` successfully.")
 	case "add": // add is deprecated
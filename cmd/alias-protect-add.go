@@ -0,0 +1,99 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/console"
+)
+
+var aliasProtectAddCmd = cli.Command{
+	Name:            "add",
+	Usage:           "protect prefixes from removal",
+	Action:          mainAliasProtectAdd,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	OnUsageError:    onUsageError,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} PREFIX [PREFIX...]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  'rm' and 'mirror --remove' refuse to remove objects under a protected prefix, or
+  the buckets containing them. A prefix is an aliased path or a local path, and
+  protects the objects and folders under it, not the objects it is a prefix of.
+
+EXAMPLES:
+  1. Protect the finance bucket of the production cluster.
+     {{.Prompt}} {{.HelpName}} prod/finance
+
+  2. Protect the yearly reports and a local archive.
+     {{.Prompt}} {{.HelpName}} prod/reports/yearly/ /srv/archive
+`,
+}
+
+// checkAliasProtectSyntax - verifies input arguments to 'alias protect add|remove'.
+func checkAliasProtectSyntax(ctx *cli.Context, cmd string) {
+	if len(ctx.Args()) < 1 {
+		cli.ShowCommandHelpAndExit(ctx, cmd, 1) // last argument is exit code
+	}
+}
+
+// mainAliasProtectAdd is the handle for "mc alias protect add" command.
+func mainAliasProtectAdd(ctx *cli.Context) error {
+	checkAliasProtectSyntax(ctx, "add")
+
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config version `"+globalMCConfigVersion+"`.")
+
+	var added []string
+	for _, arg := range ctx.Args() {
+		prefix := normalizeProtectedPath(arg)
+		found := false
+		for _, p := range conf.Protected {
+			if p == prefix {
+				found = true
+				break
+			}
+		}
+		if !found {
+			conf.Protected = append(conf.Protected, prefix)
+		}
+		added = append(added, prefix)
+	}
+	sort.Strings(conf.Protected)
+
+	err = saveMcConfig(conf)
+	fatalIf(err.Trace(added...), "Unable to save the protected prefixes in config version `"+globalMCConfigVersion+"`.")
+
+	for _, prefix := range added {
+		printMsg(aliasProtectMessage{op: "add", Prefix: prefix})
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/console"
+)
+
+var aliasProtectRemoveCmd = cli.Command{
+	Name:            "remove",
+	ShortName:       "rm",
+	Usage:           "remove the protection of prefixes",
+	Action:          mainAliasProtectRemove,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	OnUsageError:    onUsageError,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} PREFIX [PREFIX...]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Allow the removal of objects in the finance bucket again.
+     {{.Prompt}} {{.HelpName}} prod/finance
+`,
+}
+
+// mainAliasProtectRemove is the handle for "mc alias protect remove" command.
+func mainAliasProtectRemove(ctx *cli.Context) error {
+	checkAliasProtectSyntax(ctx, "remove")
+
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config version `"+globalMCConfigVersion+"`.")
+
+	var removed []string
+	for _, arg := range ctx.Args() {
+		prefix := normalizeProtectedPath(arg)
+		found := false
+		for i, p := range conf.Protected {
+			if p == prefix {
+				conf.Protected = append(conf.Protected[:i], conf.Protected[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			fatalIf(errInvalidArgument().Trace(arg), "No such protected prefix `"+prefix+"` found.")
+		}
+		removed = append(removed, prefix)
+	}
+
+	err = saveMcConfig(conf)
+	fatalIf(err.Trace(removed...), "Unable to save the protected prefixes in config version `"+globalMCConfigVersion+"`.")
+
+	for _, prefix := range removed {
+		printMsg(aliasProtectMessage{op: "remove", Prefix: prefix})
+	}
+	return nil
+}
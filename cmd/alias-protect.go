@@ -0,0 +1,74 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var aliasProtectSubcommands = []cli.Command{
+	aliasProtectAddCmd,
+	aliasProtectRemoveCmd,
+	aliasProtectListCmd,
+}
+
+var aliasProtectCmd = cli.Command{
+	Name:            "protect",
+	Usage:           "manage prefixes protected from removal by rm and mirror --remove",
+	Action:          mainAliasProtect,
+	Before:          setGlobalsFromContext,
+	HideHelpCommand: true,
+	Flags:           globalFlags,
+	Subcommands:     aliasProtectSubcommands,
+}
+
+// mainAliasProtect is the handle for "mc alias protect" command.
+func mainAliasProtect(ctx *cli.Context) error {
+	commandNotFound(ctx, aliasProtectSubcommands)
+	return nil
+	// Sub-commands like add, remove and list have their own main.
+}
+
+// aliasProtectMessage container for protected prefix messages.
+type aliasProtectMessage struct {
+	op     string
+	Status string `json:"status"`
+	Prefix string `json:"prefix"`
+}
+
+func (p aliasProtectMessage) String() string {
+	switch p.op {
+	case "list":
+		return console.Colorize("AliasProtected", p.Prefix)
+	case "remove":
+		return console.Colorize("AliasMessage", "`"+p.Prefix+"` is no longer protected.")
+	default:
+		return console.Colorize("AliasMessage", "`"+p.Prefix+"` is now protected from removal.")
+	}
+}
+
+func (p aliasProtectMessage) JSON() string {
+	p.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(p, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+
+	return string(jsonMessageBytes)
+}
@@ -83,6 +83,12 @@ func mainAliasRemove(ctx *cli.Context, deprecated bool) error {
 // aliasMustExist confirms that a given alias is present in Aliases array, returns error if not found
 
 func aliasMustExist(alias string) {
+	// Stored aliases exist without unlocking their credentials.
+	if conf, err := loadMcConfig(); err == nil {
+		if _, ok := conf.Aliases[alias]; ok {
+			return
+		}
+	}
 	hostConfig := mustGetHostConfig(alias)
 	if hostConfig == nil {
 		fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
@@ -97,8 +103,17 @@ func removeAlias(alias string) aliasMessage {
 	// check if alias is valid
 	aliasMustExist(alias)
 
-	// Remove the alias from the config.
+	// Remove the alias from the config and its groups.
 	delete(conf.Aliases, alias)
+	for group, aliases := range conf.Groups {
+		members := aliases[:0]
+		for _, member := range aliases {
+			if member != alias {
+				members = append(members, member)
+			}
+		}
+		conf.Groups[group] = members
+	}
 
 	err = saveMcConfig(conf)
 	fatalIf(err.Trace(alias), "Unable to save the delete alias in config version `"+globalMCConfigVersion+"`.")
@@ -0,0 +1,433 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/env"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/term"
+)
+
+// Alias secrets are stored either in plain text, encrypted with a key
+// derived from a passphrase, or as a command printing the secret. Only
+// aliases with secretCommands set run commands, a plain text secret
+// which happens to start with 'cmd:' is never executed. Likewise a
+// secret is only encrypted if it parses as a whole as a versioned
+// envelope, anything else is plain text.
+const (
+	secretEncryptedPrefix = "enc:v1:"
+	secretCommandPrefix   = "cmd:"
+
+	// AES-GCM nonce and tag added to every encrypted secret.
+	sealedSecretOverhead = 12 + 16
+
+	// MC_CONFIG_KEY holds the unlocked config key, MC_CONFIG_AGENT
+	// the socket of an agent serving it.
+	mcEnvConfigKey   = "MC_CONFIG_KEY"
+	mcEnvConfigAgent = "MC_CONFIG_AGENT"
+
+	configKDFArgon2id = "argon2id"
+	configKeyCheck    = "mc-config-key"
+)
+
+// configEncryptionV10 describes how the secrets of a config
+// are encrypted. The check value verifies a derived key.
+type configEncryptionV10 struct {
+	KDF     string `json:"kdf"`
+	Salt    string `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+	Check   string `json:"check"`
+}
+
+// newConfigEncryption returns the encryption parameters of a new
+// passphrase and the key derived from it.
+func newConfigEncryption(passphrase []byte) (*configEncryptionV10, []byte, *probe.Error) {
+	salt := make([]byte, 32)
+	if _, e := rand.Read(salt); e != nil {
+		return nil, nil, probe.NewError(e)
+	}
+	enc := &configEncryptionV10{
+		KDF:     configKDFArgon2id,
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Time:    1,
+		Memory:  64 * 1024,
+		Threads: 4,
+	}
+	key, err := enc.deriveKey(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	if enc.Check, err = sealSecret(key, configKeyCheck, configKeyCheck); err != nil {
+		return nil, nil, err
+	}
+	return enc, key, nil
+}
+
+// deriveKey derives the config key from the passphrase.
+func (enc *configEncryptionV10) deriveKey(passphrase []byte) ([]byte, *probe.Error) {
+	if enc.KDF != configKDFArgon2id {
+		return nil, probe.NewError(fmt.Errorf("unsupported key derivation function %s", enc.KDF))
+	}
+	salt, e := base64.StdEncoding.DecodeString(enc.Salt)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return argon2.IDKey(passphrase, salt, enc.Time, enc.Memory, enc.Threads, 32), nil
+}
+
+// verifyKey checks that key is the key of this config.
+func (enc *configEncryptionV10) verifyKey(key []byte) *probe.Error {
+	check, err := openSecret(key, configKeyCheck, enc.Check)
+	if err != nil || check != configKeyCheck {
+		return probe.NewError(errors.New("wrong passphrase or config key"))
+	}
+	return nil
+}
+
+func newConfigAEAD(key []byte) (cipher.AEAD, *probe.Error) {
+	block, e := aes.NewCipher(key)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	aead, e := cipher.NewGCM(block)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return aead, nil
+}
+
+// sealSecret encrypts a secret with AES-GCM, binding it to where
+// it is stored with the additional data.
+func sealSecret(key []byte, aad, secret string) (string, *probe.Error) {
+	aead, err := newConfigAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, e := rand.Read(nonce); e != nil {
+		return "", probe.NewError(e)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), []byte(aad))
+	return secretEncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openSecret decrypts a secret sealed by sealSecret.
+func openSecret(key []byte, aad, sealed string) (string, *probe.Error) {
+	aead, err := newConfigAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	buf, ok := parseSealedSecret(sealed)
+	if !ok {
+		return "", probe.NewError(errors.New("malformed encrypted secret"))
+	}
+	secret, e := aead.Open(nil, buf[:aead.NonceSize()], buf[aead.NonceSize():], []byte(aad))
+	if e != nil {
+		return "", probe.NewError(e)
+	}
+	return string(secret), nil
+}
+
+// parseSealedSecret returns the nonce and ciphertext of a secret
+// sealed by sealSecret, false if the secret is not one.
+func parseSealedSecret(secret string) ([]byte, bool) {
+	if !strings.HasPrefix(secret, secretEncryptedPrefix) {
+		return nil, false
+	}
+	buf, e := base64.StdEncoding.Strict().DecodeString(strings.TrimPrefix(secret, secretEncryptedPrefix))
+	if e != nil || len(buf) < sealedSecretOverhead {
+		return nil, false
+	}
+	return buf, true
+}
+
+// isSealedSecret returns true if a stored secret is encrypted.
+func isSealedSecret(secret string) bool {
+	_, ok := parseSealedSecret(secret)
+	return ok
+}
+
+var configKey struct {
+	sync.Mutex
+	key []byte
+}
+
+// unlockConfig returns the key of an encrypted config. It is taken
+// from MC_CONFIG_KEY, then from the agent at MC_CONFIG_AGENT. With
+// prompt set, the passphrase is read from the terminal as a last resort.
+func unlockConfig(enc *configEncryptionV10, prompt bool) ([]byte, *probe.Error) {
+	configKey.Lock()
+	defer configKey.Unlock()
+	if configKey.key != nil {
+		return configKey.key, nil
+	}
+
+	var key []byte
+	switch {
+	case env.IsSet(mcEnvConfigKey):
+		var e error
+		if key, e = hex.DecodeString(env.Get(mcEnvConfigKey, "")); e != nil {
+			return nil, probe.NewError(e).Trace(mcEnvConfigKey)
+		}
+	case env.IsSet(mcEnvConfigAgent):
+		var err *probe.Error
+		if key, err = fetchAgentKey(env.Get(mcEnvConfigAgent, "")); err != nil {
+			return nil, err.Trace(mcEnvConfigAgent)
+		}
+	case prompt && term.IsTerminal(int(os.Stdin.Fd())):
+		passphrase, err := readPassphrase("Enter passphrase of `"+mustGetMcConfigPath()+"`: ", false)
+		if err != nil {
+			return nil, err
+		}
+		if key, err = enc.deriveKey(passphrase); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, probe.NewError(fmt.Errorf("config is encrypted, unlock it with `eval $(mc alias unlock)`"))
+	}
+	if err := enc.verifyKey(key); err != nil {
+		return nil, err
+	}
+	configKey.key = key
+	return key, nil
+}
+
+// readPassphrase reads a passphrase from the terminal, or a line of
+// standard input when it is not a terminal. Prompts go to standard
+// error, standard output may be evaluated by the shell.
+func readPassphrase(prompt string, confirm bool) ([]byte, *probe.Error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, e := bufio.NewReader(os.Stdin).ReadString('\n')
+		if e != nil && line == "" {
+			return nil, probe.NewError(e)
+		}
+		if line = strings.TrimRight(line, "\r\n"); line == "" {
+			return nil, probe.NewError(errors.New("passphrase cannot be empty"))
+		}
+		return []byte(line), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, e := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	if len(passphrase) == 0 {
+		return nil, probe.NewError(errors.New("passphrase cannot be empty"))
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		again, e := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, probe.NewError(errors.New("passphrases do not match"))
+		}
+	}
+	return passphrase, nil
+}
+
+// fetchAgentKey reads the config key served by 'mc alias unlock --agent'.
+func fetchAgentKey(socket string) ([]byte, *probe.Error) {
+	conn, e := net.DialTimeout("unix", socket, 5*time.Second)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	buf, e := ioutil.ReadAll(conn)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	key, e := hex.DecodeString(strings.TrimSpace(string(buf)))
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return key, nil
+}
+
+var secretCommands struct {
+	sync.Mutex
+	cache map[string]string
+}
+
+// runSecretCommand runs the command of a 'cmd:' secret reference
+// with the shell and returns its output. Outputs are cached for the
+// lifetime of the process.
+func runSecretCommand(ref string) (string, *probe.Error) {
+	secretCommands.Lock()
+	defer secretCommands.Unlock()
+	if secret, ok := secretCommands.cache[ref]; ok {
+		return secret, nil
+	}
+
+	command := strings.TrimSpace(strings.TrimPrefix(ref, secretCommandPrefix))
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(globalContext, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(globalContext, "sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, e := cmd.Output()
+	if e != nil {
+		return "", probe.NewError(fmt.Errorf("%s: %v", command, e))
+	}
+	secret := strings.TrimRight(string(out), "\r\n")
+	if secret == "" {
+		return "", probe.NewError(fmt.Errorf("%s: printed no secret", command))
+	}
+	if secretCommands.cache == nil {
+		secretCommands.cache = make(map[string]string)
+	}
+	secretCommands.cache[ref] = secret
+	return secret, nil
+}
+
+// aliasSecretFields returns the secret fields of an alias config,
+// keyed by the name used as additional data of their encryption.
+func aliasSecretFields(alias string, aliasCfg *aliasConfigV10) map[string]*string {
+	return map[string]*string{
+		alias + ".accessKey":    &aliasCfg.AccessKey,
+		alias + ".secretKey":    &aliasCfg.SecretKey,
+		alias + ".sessionToken": &aliasCfg.SessionToken,
+	}
+}
+
+// resolveSecret returns the plain text of a stored secret, 'cmd:'
+// references are only run when commands are allowed. Secrets of a
+// config without encryption are always plain text.
+func resolveSecret(enc *configEncryptionV10, aad, secret string, commands, prompt bool) (string, *probe.Error) {
+	switch {
+	case commands && strings.HasPrefix(secret, secretCommandPrefix):
+		return runSecretCommand(secret)
+	case enc != nil && isSealedSecret(secret):
+		key, err := unlockConfig(enc, prompt)
+		if err != nil {
+			return "", err
+		}
+		return openSecret(key, aad, secret)
+	}
+	return secret, nil
+}
+
+// resolveAliasSecrets replaces the encrypted secrets and secret
+// references of an alias config with their plain text.
+func resolveAliasSecrets(enc *configEncryptionV10, alias string, aliasCfg *aliasConfigV10, prompt bool) *probe.Error {
+	for aad, field := range aliasSecretFields(alias, aliasCfg) {
+		secret, err := resolveSecret(enc, aad, *field, aliasCfg.SecretCommands, prompt)
+		if err != nil {
+			return errSecretUnavailable(alias, err.ToGoError())
+		}
+		*field = secret
+	}
+	return nil
+}
+
+// sealAliasSecrets encrypts the plain text secrets of an alias
+// config, secret references are stored as they are.
+func sealAliasSecrets(key []byte, alias string, aliasCfg *aliasConfigV10) *probe.Error {
+	for aad, field := range aliasSecretFields(alias, aliasCfg) {
+		if *field == "" || isSecretCommand(aliasCfg, *field) || isSealedSecret(*field) {
+			continue
+		}
+		sealed, err := sealSecret(key, aad, *field)
+		if err != nil {
+			return err.Trace(alias)
+		}
+		*field = sealed
+	}
+	return nil
+}
+
+// storeAliasSecrets prepares the secrets of an alias config to be
+// saved in config, they are encrypted when the config is.
+func storeAliasSecrets(config *configV10, alias string, aliasCfg *aliasConfigV10) *probe.Error {
+	if config.Encryption == nil {
+		return nil
+	}
+	key, err := unlockConfig(config.Encryption, true)
+	if err != nil {
+		return err.Trace(alias)
+	}
+	return sealAliasSecrets(key, alias, aliasCfg)
+}
+
+// isSecretCommand returns true if the stored secret of the alias
+// is a 'cmd:' reference.
+func isSecretCommand(aliasCfg *aliasConfigV10, secret string) bool {
+	return aliasCfg.SecretCommands && strings.HasPrefix(secret, secretCommandPrefix)
+}
+
+// redactSecret hides a stored secret of the alias, references are
+// shown as they don't contain the secret itself.
+func redactSecret(aliasCfg *aliasConfigV10, secret string) string {
+	switch {
+	case secret == "":
+		return ""
+	case isSecretCommand(aliasCfg, secret):
+		return secret
+	case isSealedSecret(secret):
+		return "ENCRYPTED"
+	}
+	return "REDACTED"
+}
+
+// openConfigSecrets decrypts the encrypted secrets of all aliases
+// in config, secret references are kept.
+func openConfigSecrets(config *configV10, key []byte) *probe.Error {
+	for alias, aliasCfg := range config.Aliases {
+		for aad, field := range aliasSecretFields(alias, &aliasCfg) {
+			if !isSealedSecret(*field) {
+				continue
+			}
+			secret, err := openSecret(key, aad, *field)
+			if err != nil {
+				return err.Trace(alias)
+			}
+			*field = secret
+		}
+		config.Aliases[alias] = aliasCfg
+	}
+	return nil
+}
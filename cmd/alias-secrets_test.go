@@ -0,0 +1,183 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestConfigEncryption(t *testing.T) {
+	enc, key, err := newConfigEncryption([]byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = enc.verifyKey(key); err != nil {
+		t.Fatal(err)
+	}
+	derived, err := enc.deriveKey([]byte("correct horse"))
+	if err != nil || !bytes.Equal(derived, key) {
+		t.Fatalf("the passphrase derived another key: %v", err)
+	}
+	wrong, _ := enc.deriveKey([]byte("battery staple"))
+	if enc.verifyKey(wrong) == nil {
+		t.Fatal("a wrong passphrase was accepted")
+	}
+
+	sealed, err := sealSecret(key, "play.secretKey", "zuf+tfteSlswRu7BJ86wekitnifILbZam1KYY3TG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, secretEncryptedPrefix) || strings.Contains(sealed, "zuf+") {
+		t.Fatalf("unexpected sealed secret %s", sealed)
+	}
+	secret, err := openSecret(key, "play.secretKey", sealed)
+	if err != nil || secret != "zuf+tfteSlswRu7BJ86wekitnifILbZam1KYY3TG" {
+		t.Fatalf("unexpected secret %q: %v", secret, err)
+	}
+	// Secrets are bound to the alias field they were sealed for.
+	if _, err = openSecret(key, "play.accessKey", sealed); err == nil {
+		t.Fatal("secret was opened for another field")
+	}
+}
+
+func TestResolveAliasSecrets(t *testing.T) {
+	enc, key, err := newConfigEncryption([]byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configKey.key = nil
+	os.Setenv(mcEnvConfigKey, hex.EncodeToString(key))
+	defer func() {
+		os.Unsetenv(mcEnvConfigKey)
+		configKey.key = nil
+	}()
+
+	stored := aliasConfigV10{
+		AccessKey: "Q3AM3UQ867SPQQA43P2F",
+		SecretKey: "zuf+tfteSlswRu7BJ86wekitnifILbZam1KYY3TG",
+	}
+	if runtime.GOOS != "windows" {
+		stored.SessionToken = "cmd:printf token"
+		stored.SecretCommands = true
+	}
+	if err = sealAliasSecrets(key, "play", &stored); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stored.AccessKey, secretEncryptedPrefix) || !strings.HasPrefix(stored.SecretKey, secretEncryptedPrefix) {
+		t.Fatalf("plain text secrets were not sealed: %+v", stored)
+	}
+	if redactSecret(&stored, stored.SecretKey) != "ENCRYPTED" || redactSecret(&stored, "plain") != "REDACTED" {
+		t.Fatal("secrets were not redacted")
+	}
+
+	resolved := stored
+	if err = resolveAliasSecrets(enc, "play", &resolved, false); err != nil {
+		t.Fatal(err)
+	}
+	if resolved.AccessKey != "Q3AM3UQ867SPQQA43P2F" || resolved.SecretKey != "zuf+tfteSlswRu7BJ86wekitnifILbZam1KYY3TG" {
+		t.Fatalf("unexpected resolved secrets %+v", resolved)
+	}
+	if runtime.GOOS != "windows" && resolved.SessionToken != "token" {
+		t.Fatalf("secret command was not resolved: %q", resolved.SessionToken)
+	}
+
+	// Sealed secrets of one alias can't be used by another.
+	moved := stored
+	err = resolveAliasSecrets(enc, "other", &moved, false)
+	if _, ok := err.ToGoError().(secretUnavailableErr); !ok {
+		t.Fatalf("expected secrets to be unavailable, got %v", err)
+	}
+	if _, ok := errNoMatchingHost("other").ToGoError().(secretUnavailableErr); ok {
+		t.Fatal("unrelated errors must not match secretUnavailableErr")
+	}
+
+	// Secrets starting with 'cmd:' are plain text unless the alias
+	// allows secret commands.
+	plain := aliasConfigV10{SecretKey: "cmd:touch /nonexistent/mc-secret"}
+	if err = resolveAliasSecrets(nil, "plain", &plain, false); err != nil || plain.SecretKey != "cmd:touch /nonexistent/mc-secret" {
+		t.Fatalf("plain text secret was not kept as is: %q, %v", plain.SecretKey, err)
+	}
+	if redactSecret(&plain, plain.SecretKey) != "REDACTED" {
+		t.Fatal("plain text secret starting with cmd: was not redacted")
+	}
+	if err = sealAliasSecrets(key, "plain", &plain); err != nil || !strings.HasPrefix(plain.SecretKey, secretEncryptedPrefix) {
+		t.Fatalf("plain text secret was not sealed: %q, %v", plain.SecretKey, err)
+	}
+
+	// Secrets which only look encrypted are plain text.
+	for _, secret := range []string{"enc:hunter2", secretEncryptedPrefix + "aGVsbG8=", secretEncryptedPrefix + "not base64!"} {
+		if isSealedSecret(secret) {
+			t.Fatalf("%q must not be treated as encrypted", secret)
+		}
+		lookalike := aliasConfigV10{SecretKey: secret}
+		if err = resolveAliasSecrets(enc, "lookalike", &lookalike, false); err != nil || lookalike.SecretKey != secret {
+			t.Fatalf("plain text secret was not kept as is: %q, %v", lookalike.SecretKey, err)
+		}
+		if err = sealAliasSecrets(key, "lookalike", &lookalike); err != nil || !isSealedSecret(lookalike.SecretKey) {
+			t.Fatalf("plain text secret was not sealed: %q, %v", lookalike.SecretKey, err)
+		}
+	}
+	if unencrypted := (aliasConfigV10{SecretKey: stored.SecretKey}); resolveAliasSecrets(nil, "play", &unencrypted, false) != nil || unencrypted.SecretKey != stored.SecretKey {
+		t.Fatal("secrets of a config without encryption must be plain text")
+	}
+
+	config := &configV10{Encryption: enc, Aliases: map[string]aliasConfigV10{"play": stored}}
+	if err = openConfigSecrets(config, key); err != nil {
+		t.Fatal(err)
+	}
+	if opened := config.Aliases["play"]; opened.SecretKey != resolved.SecretKey || opened.SessionToken != stored.SessionToken {
+		t.Fatalf("unexpected decrypted config %+v", opened)
+	}
+}
+
+func TestConfigAgent(t *testing.T) {
+	dir, e := ioutil.TempDir("", "mc-agent")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "agent.sock")
+	l, err := listenConfigAgent(socket)
+	if err != nil {
+		t.Skip("unix sockets are not supported:", err)
+	}
+	defer l.Close()
+	if fi, e := os.Stat(socket); e != nil {
+		t.Fatal(e)
+	} else if runtime.GOOS != "windows" && fi.Mode().Perm() != 0o600 {
+		t.Fatalf("expected socket permissions 0600, got %v", fi.Mode().Perm())
+	}
+	key := bytes.Repeat([]byte{0xab}, 32)
+	go serveConfigAgent(l, key)
+
+	fetched, err := fetchAgentKey(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fetched, key) {
+		t.Fatalf("expected %x, got %x", key, fetched)
+	}
+}
@@ -62,7 +62,7 @@ var aliasSetCmd = cli.Command{
 	},
 	OnUsageError:    onUsageError,
 	Before:          setGlobalsFromContext,
-	Flags:           append(aliasSetFlags, globalFlags...),
+	Flags:           append(append(aliasSetFlags, aliasTransportFlags...), globalFlags...),
 	HideHelpCommand: true,
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
@@ -97,6 +97,15 @@ EXAMPLES:
      {{.Prompt}} echo -e "BKIKJAA5BMMU2RHO6IBB\nV8f1CwQqAcwo80UEIJEjc5gVQUSSx5ohQ9GSrr12" | \
                  {{.HelpName}} mys3 https://s3.amazonaws.com --api "s3v4" --path "off"
      {{.EnableHistory}}
+  6. Add MinIO service under "myminio" alias, authenticating with a client certificate and
+     trusting the private CA of the service.
+     {{.Prompt}} {{.HelpName}} myminio https://minio.internal:9000 minio minio123 \
+                 --client-cert client.crt --client-key client.key --ca-bundle ca.pem
+  7. Add MinIO service under "myminio" alias, reached through a proxy with an extra header.
+     {{.Prompt}} {{.HelpName}} myminio https://minio.internal:9000 minio minio123 \
+                 --proxy http://proxy.corp:3128 --header "X-Tenant: blue"
+  8. Add MinIO service under "myminio" alias, listening on a unix domain socket.
+     {{.Prompt}} {{.HelpName}} myminio http://localhost minio minio123 --unix-socket /run/minio.sock
 `,
 }
 
@@ -161,6 +170,9 @@ func setAlias(alias string, aliasCfgV10 aliasConfigV10) aliasMessage {
 	mcCfgV10, err := loadMcConfig()
 	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
 
+	err = storeAliasSecrets(mcCfgV10, alias, &aliasCfgV10)
+	fatalIf(err.Trace(alias), "Unable to encrypt the credentials of `"+alias+"`.")
+
 	// Add new host.
 	mcCfgV10.Aliases[alias] = aliasCfgV10
 
@@ -174,12 +186,13 @@ func setAlias(alias string, aliasCfgV10 aliasConfigV10) aliasMessage {
 		SecretKey: aliasCfgV10.SecretKey,
 		API:       aliasCfgV10.API,
 		Path:      aliasCfgV10.Path,
+		Transport: aliasCfgV10.Transport,
 	}
 }
 
 // probeS3Signature - auto probe S3 server signature: issue a Stat call
 // using v4 signature then v2 in case of failure.
-func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, peerCert *x509.Certificate) (string, *probe.Error) {
+func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, transport *aliasTransportV10, peerCert *x509.Certificate) (string, *probe.Error) {
 	probeBucketName := randString(60, rand.NewSource(time.Now().UnixNano()), "probe-bucket-sign-")
 	// Test s3 connection for API auto probe
 	s3Config := &Config{
@@ -191,6 +204,7 @@ func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, pee
 		Debug:             globalDebug,
 		ConnReadDeadline:  globalConnReadDeadline,
 		ConnWriteDeadline: globalConnWriteDeadline,
+		TransportProfile:  transport,
 	}
 	if peerCert != nil {
 		configurePeerCertificate(s3Config, peerCert)
@@ -234,12 +248,13 @@ func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, pee
 
 // BuildS3Config constructs an S3 Config and does
 // signature auto-probe when needed.
-func BuildS3Config(ctx context.Context, url, alias, accessKey, secretKey, api, path string, peerCert *x509.Certificate) (*Config, *probe.Error) {
+func BuildS3Config(ctx context.Context, url, alias, accessKey, secretKey, api, path string, transport *aliasTransportV10, peerCert *x509.Certificate) (*Config, *probe.Error) {
 	s3Config := NewS3Config(url, &aliasConfigV10{
 		AccessKey: accessKey,
 		SecretKey: secretKey,
 		URL:       url,
 		Path:      path,
+		Transport: transport,
 	})
 
 	if peerCert != nil {
@@ -253,7 +268,7 @@ func BuildS3Config(ctx context.Context, url, alias, accessKey, secretKey, api, p
 		return s3Config, nil
 	}
 	// Probe S3 signature version
-	api, err := probeS3Signature(ctx, accessKey, secretKey, url, peerCert)
+	api, err := probeS3Signature(ctx, accessKey, secretKey, url, transport, peerCert)
 	if err != nil {
 		return nil, err.Trace(url, accessKey, secretKey, api, path)
 	}
@@ -327,26 +342,45 @@ func mainAliasSet(cli *cli.Context, deprecated bool) error {
 		}
 	}
 
-	accessKey, secretKey := fetchAliasKeys(args)
+	// Credentials given as 'cmd:' references are stored as such,
+	// their output is only used to verify them. The alias is marked
+	// so that only these references are run later on.
+	storedAccessKey, storedSecretKey := fetchAliasKeys(args)
+	secretCommands := strings.HasPrefix(storedAccessKey, secretCommandPrefix) || strings.HasPrefix(storedSecretKey, secretCommandPrefix)
+	accessKey, err := resolveSecret(nil, "", storedAccessKey, true, false)
+	fatalIf(err.Trace(alias), "Unable to resolve the access key.")
+	secretKey, err := resolveSecret(nil, "", storedSecretKey, true, false)
+	fatalIf(err.Trace(alias), "Unable to resolve the secret key.")
 	checkAliasSetSyntax(cli, accessKey, secretKey, deprecated)
 
+	var transport *aliasTransportV10
+	if !deprecated {
+		transport, err = newAliasTransport(cli)
+		fatalIf(err.Trace(cli.Args()...), "Invalid transport settings.")
+	}
+
 	ctx, cancelAliasAdd := context.WithCancel(globalContext)
 	defer cancelAliasAdd()
 
-	if !globalInsecure && !globalJSON && term.IsTerminal(int(os.Stdout.Fd())) {
+	// Endpoints with a transport profile are reached differently than
+	// the certificate probe would, their CAs are set with --ca-bundle.
+	if !globalInsecure && !globalJSON && transport == nil && term.IsTerminal(int(os.Stdout.Fd())) {
 		peerCert, err = promptTrustSelfSignedCert(ctx, url, alias)
 		fatalIf(err.Trace(cli.Args()...), "Unable to initialize new alias from the provided credentials.")
 	}
 
-	s3Config, err := BuildS3Config(ctx, url, alias, accessKey, secretKey, api, path, peerCert)
+	s3Config, err := BuildS3Config(ctx, url, alias, accessKey, secretKey, api, path, transport, peerCert)
 	fatalIf(err.Trace(cli.Args()...), "Unable to initialize new alias from the provided credentials.")
 
 	msg := setAlias(alias, aliasConfigV10{
 		URL:       s3Config.HostURL,
-		AccessKey: s3Config.AccessKey,
-		SecretKey: s3Config.SecretKey,
+		AccessKey: storedAccessKey,
+		SecretKey: storedSecretKey,
 		API:       s3Config.Signature,
 		Path:      path,
+		Transport: transport,
+
+		SecretCommands: secretCommands,
 	}) // Add an alias with specified credentials.
 
 	msg.op = "set"
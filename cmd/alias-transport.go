@@ -0,0 +1,398 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/certs"
+)
+
+// Default timeouts of connections to an alias endpoint.
+const (
+	defaultDialTimeout         = 10 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+var aliasTransportFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "client-cert",
+		Usage: "client certificate file for mutual TLS",
+	},
+	cli.StringFlag{
+		Name:  "client-key",
+		Usage: "private key file of the client certificate",
+	},
+	cli.StringFlag{
+		Name:  "ca-bundle",
+		Usage: "file of PEM encoded CAs trusted for this alias only",
+	},
+	cli.StringFlag{
+		Name:  "proxy",
+		Usage: "proxy URL for this alias, overrides HTTP(S)_PROXY environment variables",
+	},
+	cli.DurationFlag{
+		Name:  "dial-timeout",
+		Usage: "timeout to establish a connection",
+	},
+	cli.DurationFlag{
+		Name:  "tls-handshake-timeout",
+		Usage: "timeout to complete a TLS handshake",
+	},
+	cli.DurationFlag{
+		Name:  "response-header-timeout",
+		Usage: "timeout to receive response headers after sending a request",
+	},
+	cli.StringSliceFlag{
+		Name:  "resolve",
+		Usage: "connect to ADDR instead of HOST:PORT, in the form HOST:PORT:ADDR",
+	},
+	cli.StringFlag{
+		Name:  "unix-socket",
+		Usage: "connect through a unix domain socket instead of TCP",
+	},
+	cli.StringSliceFlag{
+		Name:  "header",
+		Usage: "extra static header sent with every request, in the form 'KEY: VALUE'",
+	},
+}
+
+// aliasTransportV10 is the optional transport profile of an alias,
+// it overrides how connections to the alias endpoint are made.
+type aliasTransportV10 struct {
+	ClientCert            string            `json:"clientCert,omitempty"`
+	ClientKey             string            `json:"clientKey,omitempty"`
+	CABundle              string            `json:"caBundle,omitempty"`
+	Proxy                 string            `json:"proxy,omitempty"`
+	DialTimeout           string            `json:"dialTimeout,omitempty"`
+	TLSHandshakeTimeout   string            `json:"tlsHandshakeTimeout,omitempty"`
+	ResponseHeaderTimeout string            `json:"responseHeaderTimeout,omitempty"`
+	Resolve               map[string]string `json:"resolve,omitempty"`
+	UnixSocket            string            `json:"unixSocket,omitempty"`
+	Headers               map[string]string `json:"headers,omitempty"`
+}
+
+// newAliasTransport returns the transport profile set by the
+// 'alias set' flags, nil if none of them was given.
+func newAliasTransport(ctx *cli.Context) (*aliasTransportV10, *probe.Error) {
+	t := &aliasTransportV10{}
+	for _, flag := range []struct {
+		name string
+		path *string
+	}{
+		{"client-cert", &t.ClientCert},
+		{"client-key", &t.ClientKey},
+		{"ca-bundle", &t.CABundle},
+		{"unix-socket", &t.UnixSocket},
+	} {
+		if p := ctx.String(flag.name); p != "" {
+			abs, e := filepath.Abs(p)
+			if e != nil {
+				return nil, probe.NewError(e).Trace(p)
+			}
+			*flag.path = abs
+		}
+	}
+	t.Proxy = ctx.String("proxy")
+	for _, flag := range []struct {
+		name string
+		d    *string
+	}{
+		{"dial-timeout", &t.DialTimeout},
+		{"tls-handshake-timeout", &t.TLSHandshakeTimeout},
+		{"response-header-timeout", &t.ResponseHeaderTimeout},
+	} {
+		if ctx.IsSet(flag.name) {
+			*flag.d = ctx.Duration(flag.name).String()
+		}
+	}
+	for _, resolve := range ctx.StringSlice("resolve") {
+		hostPort, addr, err := parseResolve(resolve)
+		if err != nil {
+			return nil, err
+		}
+		if t.Resolve == nil {
+			t.Resolve = make(map[string]string)
+		}
+		t.Resolve[hostPort] = addr
+	}
+	for _, header := range ctx.StringSlice("header") {
+		key, value, err := parseStaticHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if t.Headers == nil {
+			t.Headers = make(map[string]string)
+		}
+		t.Headers[key] = value
+	}
+
+	if t.isEmpty() {
+		return nil, nil
+	}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseResolve parses a host override of the form HOST:PORT:ADDR.
+func parseResolve(s string) (hostPort, addr string, err *probe.Error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", errInvalidArgument().Trace(s)
+	}
+	addr = strings.TrimSuffix(strings.TrimPrefix(parts[2], "["), "]")
+	if net.ParseIP(addr) == nil {
+		return "", "", probe.NewError(fmt.Errorf("%s is not an IP address", addr)).Trace(s)
+	}
+	return net.JoinHostPort(parts[0], parts[1]), addr, nil
+}
+
+// parseStaticHeader parses a header of the form 'KEY: VALUE'.
+func parseStaticHeader(s string) (key, value string, err *probe.Error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return "", "", errInvalidArgument().Trace(s)
+	}
+	key = http.CanonicalHeaderKey(strings.TrimSpace(parts[0]))
+	if key == "Host" || key == "Authorization" || strings.HasPrefix(key, "X-Amz-") {
+		return "", "", probe.NewError(fmt.Errorf("header %s is reserved for request signing", key)).Trace(s)
+	}
+	return key, strings.TrimSpace(parts[1]), nil
+}
+
+func (t *aliasTransportV10) isEmpty() bool {
+	return t == nil || (t.ClientCert == "" && t.ClientKey == "" && t.CABundle == "" &&
+		t.Proxy == "" && t.DialTimeout == "" && t.TLSHandshakeTimeout == "" &&
+		t.ResponseHeaderTimeout == "" && len(t.Resolve) == 0 && t.UnixSocket == "" &&
+		len(t.Headers) == 0)
+}
+
+// validate verifies that the transport profile can be applied.
+func (t *aliasTransportV10) validate() *probe.Error {
+	if (t.ClientCert == "") != (t.ClientKey == "") {
+		return probe.NewError(errors.New("client certificate and key must be set together"))
+	}
+	if _, err := t.proxyFunc(); err != nil {
+		return err
+	}
+	for _, d := range []string{t.DialTimeout, t.TLSHandshakeTimeout, t.ResponseHeaderTimeout} {
+		if _, err := parseTransportTimeout(d, 0); err != nil {
+			return err
+		}
+	}
+	return t.applyTLS(&tls.Config{})
+}
+
+// hash returns a key unique to the transport profile, to tell
+// apart cached clients of the same endpoint and credentials.
+func (t *aliasTransportV10) hash() string {
+	if t.isEmpty() {
+		return ""
+	}
+	buf, e := json.Marshal(t)
+	if e != nil {
+		return ""
+	}
+	return string(buf)
+}
+
+func parseTransportTimeout(s string, def time.Duration) (time.Duration, *probe.Error) {
+	if s == "" {
+		return def, nil
+	}
+	d, e := time.ParseDuration(s)
+	if e != nil {
+		return 0, probe.NewError(e).Trace(s)
+	}
+	if d < 0 {
+		return 0, errInvalidArgument().Trace(s)
+	}
+	return d, nil
+}
+
+// dialTimeout returns the dial timeout of the profile, t may be nil.
+func (t *aliasTransportV10) dialTimeout() time.Duration {
+	if t == nil {
+		return defaultDialTimeout
+	}
+	d, err := parseTransportTimeout(t.DialTimeout, defaultDialTimeout)
+	if err != nil {
+		return defaultDialTimeout
+	}
+	return d
+}
+
+// dialAddress returns where a connection to addr is made, t may be nil.
+func (t *aliasTransportV10) dialAddress(network, addr string) (string, string) {
+	if t == nil {
+		return network, addr
+	}
+	if t.UnixSocket != "" {
+		return "unix", t.UnixSocket
+	}
+	if ip, ok := t.Resolve[addr]; ok {
+		if _, port, e := net.SplitHostPort(addr); e == nil {
+			return network, net.JoinHostPort(ip, port)
+		}
+	}
+	return network, addr
+}
+
+// proxyFunc returns the proxy selection of the profile, nil when
+// the proxy is taken from the environment.
+func (t *aliasTransportV10) proxyFunc() (func(*http.Request) (*url.URL, error), *probe.Error) {
+	if t.Proxy == "" {
+		return nil, nil
+	}
+	u, e := url.Parse(t.Proxy)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(t.Proxy)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, probe.NewError(fmt.Errorf("proxy %s must be an absolute URL", t.Proxy))
+	}
+	return http.ProxyURL(u), nil
+}
+
+// applyTLS adds the client certificate and the CA bundle of the
+// profile to the TLS configuration.
+func (t *aliasTransportV10) applyTLS(tlsConfig *tls.Config) *probe.Error {
+	if t.ClientCert != "" {
+		cert, e := tls.LoadX509KeyPair(t.ClientCert, t.ClientKey)
+		if e != nil {
+			return probe.NewError(e).Trace(t.ClientCert, t.ClientKey)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if t.CABundle != "" {
+		pem, e := ioutil.ReadFile(t.CABundle)
+		if e != nil {
+			return probe.NewError(e).Trace(t.CABundle)
+		}
+		// Start from a copy of the global CAs, the bundle is
+		// trusted for this alias only.
+		rootCAs, e := certs.GetRootCAs(mustGetCAsDir())
+		if e != nil {
+			return probe.NewError(e)
+		}
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return probe.NewError(fmt.Errorf("no certificates found in %s", t.CABundle))
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	return nil
+}
+
+// apply configures the transport with the profile, t may be nil.
+// The dialer is expected to be set up with newCustomDialContext.
+func (t *aliasTransportV10) apply(tr *http.Transport) *probe.Error {
+	if t == nil {
+		return nil
+	}
+	proxy, err := t.proxyFunc()
+	if err != nil {
+		return err
+	}
+	if proxy != nil {
+		tr.Proxy = proxy
+	}
+	if tr.TLSHandshakeTimeout, err = parseTransportTimeout(t.TLSHandshakeTimeout, tr.TLSHandshakeTimeout); err != nil {
+		return err
+	}
+	if tr.ResponseHeaderTimeout, err = parseTransportTimeout(t.ResponseHeaderTimeout, tr.ResponseHeaderTimeout); err != nil {
+		return err
+	}
+	if tr.TLSClientConfig != nil {
+		return t.applyTLS(tr.TLSClientConfig)
+	}
+	return nil
+}
+
+// wrap adds the static headers of the profile to the requests
+// sent through transport, t may be nil.
+func (t *aliasTransportV10) wrap(transport http.RoundTripper) http.RoundTripper {
+	if t == nil || len(t.Headers) == 0 {
+		return transport
+	}
+	return headerTransport{RoundTripper: transport, headers: t.Headers}
+}
+
+// String lists the settings of the profile on one line.
+func (t *aliasTransportV10) String() string {
+	if t.isEmpty() {
+		return ""
+	}
+	var settings []string
+	add := func(name, value string) {
+		if value != "" {
+			settings = append(settings, name+"="+value)
+		}
+	}
+	add("client-cert", t.ClientCert)
+	add("ca-bundle", t.CABundle)
+	add("proxy", t.Proxy)
+	add("dial-timeout", t.DialTimeout)
+	add("tls-handshake-timeout", t.TLSHandshakeTimeout)
+	add("response-header-timeout", t.ResponseHeaderTimeout)
+	add("unix-socket", t.UnixSocket)
+	for _, k := range sortedKeys(t.Resolve) {
+		add("resolve", k+":"+t.Resolve[k])
+	}
+	for _, k := range sortedKeys(t.Headers) {
+		add("header", k)
+	}
+	return strings.Join(settings, " ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// headerTransport sets static headers on every request.
+type headerTransport struct {
+	http.RoundTripper
+	headers map[string]string
+}
+
+func (h headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	return h.RoundTripper.RoundTrip(req)
+}
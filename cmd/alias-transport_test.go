@@ -0,0 +1,159 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseResolve(t *testing.T) {
+	testCases := []struct {
+		resolve  string
+		hostPort string
+		addr     string
+		ok       bool
+	}{
+		{"minio.local:9000:10.0.0.1", "minio.local:9000", "10.0.0.1", true},
+		{"minio.local:443:[::1]", "minio.local:443", "::1", true},
+		{"minio.local:443:::1", "minio.local:443", "::1", true},
+		{"minio.local:9000", "", "", false},
+		{"minio.local:9000:minio.other", "", "", false},
+	}
+	for i, testCase := range testCases {
+		hostPort, addr, err := parseResolve(testCase.resolve)
+		if (err == nil) != testCase.ok {
+			t.Fatalf("Test %d: unexpected error %v", i+1, err)
+		}
+		if hostPort != testCase.hostPort || addr != testCase.addr {
+			t.Errorf("Test %d: expected %s -> %s, got %s -> %s", i+1, testCase.hostPort, testCase.addr, hostPort, addr)
+		}
+	}
+}
+
+func TestParseStaticHeader(t *testing.T) {
+	key, value, err := parseStaticHeader("x-tenant:  blue ")
+	if err != nil || key != "X-Tenant" || value != "blue" {
+		t.Fatalf("unexpected header %q: %q (%v)", key, value, err)
+	}
+	for _, header := range []string{"X-Tenant", "Host: example.com", "x-amz-date: now", ": value"} {
+		if _, _, err := parseStaticHeader(header); err == nil {
+			t.Errorf("expected %q to be rejected", header)
+		}
+	}
+}
+
+// transportTestStat stats a bucket through a client configured with
+// the transport profile and returns the headers the server received.
+func transportTestStat(t *testing.T, hostURL string, profile *aliasTransportV10, headers chan http.Header) (received []http.Header) {
+	conf := new(Config)
+	conf.HostURL = hostURL
+	conf.AccessKey = "WLGDGYAQYIGI833EV05A"
+	conf.SecretKey = "BYvgJM101sHngl2uzjXS/OBF/aMxAN06JrJ3qJlF"
+	conf.Signature = "S3v4"
+	conf.TransportProfile = profile
+	clnt, err := S3New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clnt.Stat(context.Background(), StatOptions{})
+	for {
+		select {
+		case h := <-headers:
+			received = append(received, h)
+		default:
+			return received
+		}
+	}
+}
+
+func TestAliasTransportDial(t *testing.T) {
+	headers := make(chan http.Header, 10)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Host overrides connect to the resolved address.
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	received := transportTestStat(t, "http://minio.invalid:"+port+"/bucket", &aliasTransportV10{
+		Resolve: map[string]string{"minio.invalid:" + port: "127.0.0.1"},
+		Headers: map[string]string{"X-Tenant": "blue"},
+	}, headers)
+	if len(received) == 0 {
+		t.Fatal("request did not reach the resolved address")
+	}
+	for _, h := range received {
+		if h.Get("X-Tenant") != "blue" {
+			t.Errorf("static header was not sent, got %v", h)
+		}
+	}
+
+	// Unix sockets replace the endpoint address.
+	dir, e := ioutil.TempDir("", "mc-transport")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "minio.sock")
+	l, e := net.Listen("unix", socket)
+	if e != nil {
+		t.Skip("unix sockets are not supported:", e)
+	}
+	unixServer := httptest.NewUnstartedServer(handler)
+	unixServer.Listener.Close()
+	unixServer.Listener = l
+	unixServer.Start()
+	defer unixServer.Close()
+
+	received = transportTestStat(t, "http://localhost/bucket", &aliasTransportV10{UnixSocket: socket}, headers)
+	if len(received) == 0 {
+		t.Fatal("request did not reach the unix socket")
+	}
+	for _, h := range received {
+		if h.Get("X-Tenant") != "" {
+			t.Errorf("unexpected header from another profile, got %v", h)
+		}
+	}
+}
+
+func TestAliasTransportValidate(t *testing.T) {
+	testCases := []struct {
+		profile aliasTransportV10
+		ok      bool
+	}{
+		{aliasTransportV10{Proxy: "http://proxy:3128", DialTimeout: "5s"}, true},
+		{aliasTransportV10{Proxy: "proxy:3128"}, false},
+		{aliasTransportV10{ResponseHeaderTimeout: "soon"}, false},
+		{aliasTransportV10{ClientCert: "client.crt"}, false},
+		{aliasTransportV10{CABundle: "/nonexistent/ca.pem"}, false},
+	}
+	for i, testCase := range testCases {
+		if err := testCase.profile.validate(); (err == nil) != testCase.ok {
+			t.Errorf("Test %d: unexpected result %v", i+1, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/hex"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var aliasUnlockFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "agent",
+		Usage: "serve the key on this unix socket until interrupted, instead of printing it",
+	},
+}
+
+var aliasUnlockCmd = cli.Command{
+	Name:            "unlock",
+	Usage:           "unlock encrypted credentials for the current shell",
+	Action:          mainAliasUnlock,
+	Before:          setGlobalsFromContext,
+	Flags:           append(aliasUnlockFlags, globalFlags...),
+	HideHelpCommand: true,
+	OnUsageError:    onUsageError,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Prompts for the passphrase of an encrypted configuration and prints the shell
+  command exporting the derived key as MC_CONFIG_KEY. Unset it to lock the
+  configuration again.
+
+  With --agent the key is kept in memory and served on a unix socket readable
+  by the current user only. Commands find the agent through MC_CONFIG_AGENT.
+
+EXAMPLES:
+  1. Unlock the configuration for the current shell.
+     {{.Prompt}} eval $({{.HelpName}})
+
+  2. Run an agent serving the key, and use it from other shells.
+     {{.Prompt}} {{.HelpName}} --agent ~/.mc/agent.sock &
+     {{.Prompt}} export MC_CONFIG_AGENT=~/.mc/agent.sock
+`,
+}
+
+// aliasUnlockMessage is the shell command exporting the config key
+// or the agent socket.
+type aliasUnlockMessage struct {
+	Status string `json:"status"`
+	Key    string `json:"key,omitempty"`
+	Agent  string `json:"agent,omitempty"`
+}
+
+func (u aliasUnlockMessage) String() string {
+	if u.Agent != "" {
+		return "export " + mcEnvConfigAgent + "=" + u.Agent
+	}
+	return "export " + mcEnvConfigKey + "=" + u.Key
+}
+
+func (u aliasUnlockMessage) JSON() string {
+	u.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(u, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func mainAliasUnlock(ctx *cli.Context) error {
+	if len(ctx.Args()) != 0 {
+		cli.ShowCommandHelpAndExit(ctx, "unlock", 1) // last argument is exit code
+	}
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
+	if conf.Encryption == nil {
+		fatalIf(errInvalidArgument(), "Config `"+mustGetMcConfigPath()+"` is not encrypted.")
+	}
+
+	passphrase, err := readPassphrase("Enter passphrase of `"+mustGetMcConfigPath()+"`: ", false)
+	fatalIf(err, "Unable to read passphrase.")
+	key, err := conf.Encryption.deriveKey(passphrase)
+	fatalIf(err, "Unable to derive a key from the passphrase.")
+	fatalIf(conf.Encryption.verifyKey(key), "Unable to unlock config `"+mustGetMcConfigPath()+"`.")
+
+	socket := ctx.String("agent")
+	if socket == "" {
+		printMsg(aliasUnlockMessage{Key: hex.EncodeToString(key)})
+		return nil
+	}
+
+	socket, e := filepath.Abs(socket)
+	fatalIf(probe.NewError(e), "Unable to resolve the agent socket path.")
+	l, err := listenConfigAgent(socket)
+	fatalIf(err.Trace(socket), "Unable to start the agent.")
+	printMsg(aliasUnlockMessage{Agent: socket})
+	serveConfigAgent(l, key)
+	return nil
+}
+
+// listenConfigAgent listens on a unix socket only the current user
+// can connect to, replacing the socket of a previous agent.
+func listenConfigAgent(socket string) (net.Listener, *probe.Error) {
+	if fi, e := os.Lstat(socket); e == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(socket)
+	}
+	l, e := listenPrivateUnix(socket)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return l, nil
+}
+
+// serveConfigAgent writes the key to every connection until the
+// agent is interrupted.
+func serveConfigAgent(l net.Listener, key []byte) {
+	go func() {
+		<-globalContext.Done()
+		l.Close()
+	}()
+	for {
+		conn, e := l.Accept()
+		if e != nil {
+			return
+		}
+		conn.Write([]byte(hex.EncodeToString(key) + "\n"))
+		conn.Close()
+	}
+}
@@ -246,6 +246,7 @@ var completeCmds = map[string]complete.Predictor{
 	"/anonymous": complete.PredictOr(s3Completer, fsCompleter),
 	"/tree":      complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
 	"/du":        complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
+	"/browse":    complete.PredictOr(s3Completer, fsCompleter),
 
 	"/retention/set":   s3Completer,
 	"/retention/clear": s3Completer,
@@ -258,6 +259,12 @@ var completeCmds = map[string]complete.Predictor{
 	"/sql": s3Completer,
 	"/mb":  aliasCompleter,
 
+	"/agent/run":     nil,
+	"/agent/status":  nil,
+	"/agent/trigger": nil,
+	"/agent/pause":   nil,
+	"/agent/resume":  nil,
+
 	"/event/add":    s3Complete{deepLevel: 2},
 	"/event/list":   s3Complete{deepLevel: 2},
 	"/event/remove": s3Complete{deepLevel: 2},
@@ -278,10 +285,17 @@ var completeCmds = map[string]complete.Predictor{
 	"/replicate/resync/start":  s3Complete{deepLevel: 3},
 	"/replicate/resync/status": s3Complete{deepLevel: 3},
 
+	"/index/build":  s3Completer,
+	"/index/update": fsCompleter,
+
 	"/tag/list":   s3Completer,
 	"/tag/remove": s3Completer,
 	"/tag/set":    s3Completer,
 
+	"/meta/set": s3Completer,
+	"/meta/rm":  s3Completer,
+	"/meta/ls":  s3Completer,
+
 	"/version/info":    s3Complete{deepLevel: 2},
 	"/version/enable":  s3Complete{deepLevel: 2},
 	"/version/suspend": s3Complete{deepLevel: 2},
@@ -295,16 +309,27 @@ var completeCmds = map[string]complete.Predictor{
 	"/share/list":     nil,
 	"/share/upload":   s3Completer,
 
-	"/ilm/ls":      s3Complete{deepLevel: 2},
-	"/ilm/add":     s3Complete{deepLevel: 2},
-	"/ilm/edit":    s3Complete{deepLevel: 2},
-	"/ilm/rm":      s3Complete{deepLevel: 2},
-	"/ilm/export":  s3Complete{deepLevel: 2},
-	"/ilm/import":  s3Complete{deepLevel: 2},
-	"/ilm/restore": s3Completer,
+	"/ilm/ls":       s3Complete{deepLevel: 2},
+	"/ilm/add":      s3Complete{deepLevel: 2},
+	"/ilm/edit":     s3Complete{deepLevel: 2},
+	"/ilm/rm":       s3Complete{deepLevel: 2},
+	"/ilm/export":   s3Complete{deepLevel: 2},
+	"/ilm/import":   s3Complete{deepLevel: 2},
+	"/ilm/restore":  s3Completer,
+	"/ilm/simulate": s3Completer,
 
 	"/undo": s3Completer,
 
+	"/trash/ls":      s3Completer,
+	"/trash/restore": s3Completer,
+	"/trash/empty":   s3Completer,
+
+	"/bench/put":    s3Complete{deepLevel: 2},
+	"/bench/get":    s3Complete{deepLevel: 2},
+	"/bench/mixed":  s3Complete{deepLevel: 2},
+	"/bench/list":   s3Complete{deepLevel: 2},
+	"/bench/delete": s3Complete{deepLevel: 2},
+
 	// Admin API commands MinIO only.
 	"/admin/heal": s3Completer,
 
@@ -325,13 +350,14 @@ var completeCmds = map[string]complete.Predictor{
 	"/admin/decommission/status": aliasCompleter,
 	"/admin/decommission/cancel": aliasCompleter,
 
-	"/admin/trace":     aliasCompleter,
-	"/admin/speedtest": aliasCompleter,
-	"/admin/console":   aliasCompleter,
-	"/admin/update":    aliasCompleter,
-	"/admin/inspect":   s3Completer,
-	"/admin/top/locks": aliasCompleter,
-	"/admin/top/api":   aliasCompleter,
+	"/admin/trace":         aliasCompleter,
+	"/admin/trace/analyze": fsCompleter,
+	"/admin/speedtest":     aliasCompleter,
+	"/admin/console":       aliasCompleter,
+	"/admin/update":        aliasCompleter,
+	"/admin/inspect":       s3Completer,
+	"/admin/top/locks":     aliasCompleter,
+	"/admin/top/api":       aliasCompleter,
 
 	"/admin/scanner/info":  aliasCompleter,
 	"/admin/scanner/trace": aliasCompleter,
@@ -352,13 +378,14 @@ var completeCmds = map[string]complete.Predictor{
 	"/admin/idp/ls":   aliasCompleter,
 	"/admin/idp/rm":   aliasCompleter,
 
-	"/admin/policy/info":   aliasCompleter,
-	"/admin/policy/set":    aliasCompleter,
-	"/admin/policy/unset":  aliasCompleter,
-	"/admin/policy/update": aliasCompleter,
-	"/admin/policy/add":    aliasCompleter,
-	"/admin/policy/list":   aliasCompleter,
-	"/admin/policy/remove": aliasCompleter,
+	"/admin/policy/info":     aliasCompleter,
+	"/admin/policy/set":      aliasCompleter,
+	"/admin/policy/unset":    aliasCompleter,
+	"/admin/policy/update":   aliasCompleter,
+	"/admin/policy/add":      aliasCompleter,
+	"/admin/policy/list":     aliasCompleter,
+	"/admin/policy/remove":   aliasCompleter,
+	"/admin/policy/simulate": aliasCompleter,
 
 	"/admin/user/add":     aliasCompleter,
 	"/admin/user/disable": aliasCompleter,
@@ -417,25 +444,41 @@ var completeCmds = map[string]complete.Predictor{
 	"/admin/cluster/iam/export":    aliasCompleter,
 	"/admin/cluster/iam/import":    aliasCompleter,
 
-	"/alias/set":    nil,
-	"/alias/list":   aliasCompleter,
-	"/alias/remove": aliasCompleter,
-	"/alias/import": nil,
-
-	"/support/callhome":     aliasCompleter,
-	"/support/logs/enable":  aliasCompleter,
-	"/support/logs/disable": aliasCompleter,
-	"/support/logs/status":  aliasCompleter,
-	"/support/logs/show":    aliasCompleter,
-	"/support/register":     aliasCompleter,
-	"/support/diag":         aliasCompleter,
-	"/support/profile":      aliasCompleter,
-	"/support/inspect":      aliasCompleter,
-	"/support/perf":         aliasCompleter,
-	"/support/metrics":      aliasCompleter,
-	"/support/status":       aliasCompleter,
-	"/support/top/locks":    aliasCompleter,
-	"/support/top/api":      aliasCompleter,
+	"/admin/iam/plan":  aliasCompleter,
+	"/admin/iam/apply": aliasCompleter,
+
+	"/alias/set":     nil,
+	"/alias/list":    aliasCompleter,
+	"/alias/remove":  aliasCompleter,
+	"/alias/import":  nil,
+	"/alias/encrypt": nil,
+	"/alias/decrypt": nil,
+	"/alias/unlock":  nil,
+
+	"/alias/group/set":    aliasCompleter,
+	"/alias/group/remove": nil,
+	"/alias/group/list":   nil,
+
+	"/alias/protect/add":    nil,
+	"/alias/protect/remove": nil,
+	"/alias/protect/list":   nil,
+
+	"/support/callhome":            aliasCompleter,
+	"/support/logs/enable":         aliasCompleter,
+	"/support/logs/disable":        aliasCompleter,
+	"/support/logs/status":         aliasCompleter,
+	"/support/logs/show":           aliasCompleter,
+	"/support/register":            aliasCompleter,
+	"/support/diag":                aliasCompleter,
+	"/support/diag/analyze":        fsCompleter,
+	"/support/profile":             aliasCompleter,
+	"/support/inspect":             aliasCompleter,
+	"/support/inspect/reconstruct": fsCompleter,
+	"/support/perf":                aliasCompleter,
+	"/support/metrics":             aliasCompleter,
+	"/support/status":              aliasCompleter,
+	"/support/top/locks":           aliasCompleter,
+	"/support/top/api":             aliasCompleter,
 
 	"/license/register": aliasCompleter,
 	"/license/info":     aliasCompleter,
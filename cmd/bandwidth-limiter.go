@@ -0,0 +1,56 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// bandwidthLimiter is a progress hook blocking the transfers reporting
+// through it to keep them under a rate in bytes per second. Up to a
+// second of unused bandwidth can be used in a burst.
+type bandwidthLimiter struct {
+	hook io.Reader
+	rate float64
+
+	mu sync.Mutex
+	// The time at which the bytes reported so far are within the rate.
+	next time.Time
+}
+
+func newBandwidthLimiter(hook io.Reader, rate uint64) *bandwidthLimiter {
+	return &bandwidthLimiter{hook: hook, rate: float64(rate)}
+}
+
+func (l *bandwidthLimiter) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	now := time.Now()
+	if burst := now.Add(-time.Second); l.next.Before(burst) {
+		l.next = burst
+	}
+	l.next = l.next.Add(time.Duration(float64(len(p)) / l.rate * float64(time.Second)))
+	wait := l.next.Sub(now)
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	return l.hook.Read(p)
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/minio/cli"
+)
+
+var benchDeleteCmd = cli.Command{
+	Name:         "delete",
+	Usage:        "benchmark the removal of objects",
+	Action:       mainBenchDelete,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(append(benchFlags, benchObjectsFlag), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+  The benchmark stops when all the objects uploaded beforehand are removed.
+
+EXAMPLES:
+  1. Remove 10000 small objects uploaded beforehand from the bucket 'mybucket' with 32 concurrent removals.
+     {{.Prompt}} {{.HelpName}} --objects 10000 --size 4KiB --concurrent 32 myminio/mybucket
+`,
+}
+
+// benchDeleteObject removes an object.
+func benchDeleteObject(ctx context.Context, opts *benchOptions, key string) benchSample {
+	clnt, err := newClient(opts.targetURL + key)
+	if err != nil {
+		return newBenchSample("delete", time.Now(), 0, err)
+	}
+	contentCh := make(chan *ClientContent, 1)
+	contentCh <- &ClientContent{URL: clnt.GetURL()}
+	close(contentCh)
+
+	start := time.Now()
+	for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+		if result.Err != nil {
+			err = result.Err
+		}
+	}
+	return newBenchSample("delete", start, 0, err)
+}
+
+// mainBenchDelete is the handle for "mc bench delete" command.
+func mainBenchDelete(cliCtx *cli.Context) error {
+	return doBench(cliCtx, "delete", true, func(opts *benchOptions, keys *benchKeys) benchOperation {
+		return func(ctx context.Context, w *benchWorker) benchSample {
+			key, ok := keys.take(w.rand)
+			if !ok {
+				return benchSample{err: errBenchDone}
+			}
+			return benchDeleteObject(ctx, opts, key)
+		}
+	})
+}
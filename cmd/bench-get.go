@@ -0,0 +1,97 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var benchGetCmd = cli.Command{
+	Name:         "get",
+	Usage:        "benchmark the download of objects",
+	Action:       mainBenchGet,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(append(benchFlags, benchObjectsFlag), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Download 1MiB objects from the bucket 'mybucket' with 16 concurrent downloads for one minute.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket
+
+  2. Download 64MiB objects out of 100 objects uploaded beforehand, with 32 concurrent downloads.
+     {{.Prompt}} {{.HelpName}} --size 64MiB --objects 100 --concurrent 32 s3/mybucket
+
+  3. Keep the objects uploaded under the prefix 'dataset' and download them again in a later run.
+     {{.Prompt}} {{.HelpName}} --prefix dataset --keep s3/mybucket
+     {{.Prompt}} {{.HelpName}} --prefix dataset --objects 0 s3/mybucket
+`,
+}
+
+// benchGetObject downloads an object, the time to first byte is the time
+// to read the first byte of its content.
+func benchGetObject(ctx context.Context, opts *benchOptions, key string) benchSample {
+	clnt, err := newClient(opts.targetURL + key)
+	if err != nil {
+		return newBenchSample("get", time.Now(), 0, err)
+	}
+	start := time.Now()
+	reader, err := clnt.Get(ctx, GetOptions{})
+	if err != nil {
+		return newBenchSample("get", start, 0, err)
+	}
+	defer reader.Close()
+
+	var first [1]byte
+	_, e := io.ReadFull(reader, first[:])
+	ttfb := time.Since(start)
+	n := int64(1)
+	if e == nil {
+		var m int64
+		m, e = io.Copy(io.Discard, reader)
+		n += m
+	} else if e == io.EOF {
+		// Empty object.
+		n, e = 0, nil
+	}
+	s := newBenchSample("get", start, n, probe.NewError(e))
+	s.ttfb = ttfb
+	return s
+}
+
+// mainBenchGet is the handle for "mc bench get" command.
+func mainBenchGet(cliCtx *cli.Context) error {
+	return doBench(cliCtx, "get", true, func(opts *benchOptions, keys *benchKeys) benchOperation {
+		return func(ctx context.Context, w *benchWorker) benchSample {
+			key, _ := keys.random(w.rand)
+			return benchGetObject(ctx, opts, key)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/minio/cli"
+)
+
+var benchListCmd = cli.Command{
+	Name:         "list",
+	Usage:        "benchmark the listing of objects",
+	Action:       mainBenchList,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(append(benchFlags, benchObjectsFlag), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+  Each operation lists all the objects uploaded beforehand, the time to first byte
+  is the time to receive the first object.
+
+EXAMPLES:
+  1. List 5000 objects uploaded beforehand to the bucket 'mybucket' with 8 concurrent listings.
+     {{.Prompt}} {{.HelpName}} --objects 5000 --size 1KiB --concurrent 8 myminio/mybucket
+
+  2. List objects each stored under its own prefix.
+     {{.Prompt}} {{.HelpName}} --objects 5000 --size 1KiB --key-pattern "{n}/obj" myminio/mybucket
+`,
+}
+
+// benchListObjects lists all the objects of the benchmark.
+func benchListObjects(ctx context.Context, opts *benchOptions) benchSample {
+	clnt, err := newClient(opts.targetURL)
+	if err != nil {
+		return newBenchSample("list", time.Now(), 0, err)
+	}
+	start := time.Now()
+	var ttfb time.Duration
+	for content := range clnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirNone}) {
+		if ttfb == 0 {
+			ttfb = time.Since(start)
+		}
+		if content.Err != nil {
+			err = content.Err
+		}
+	}
+	s := newBenchSample("list", start, 0, err)
+	s.ttfb = ttfb
+	return s
+}
+
+// mainBenchList is the handle for "mc bench list" command.
+func mainBenchList(cliCtx *cli.Context) error {
+	return doBench(cliCtx, "list", true, func(opts *benchOptions, _ *benchKeys) benchOperation {
+		return func(ctx context.Context, _ *benchWorker) benchSample {
+			return benchListObjects(ctx, opts)
+		}
+	})
+}
@@ -0,0 +1,937 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var benchSubcommands = []cli.Command{
+	benchPutCmd,
+	benchGetCmd,
+	benchMixedCmd,
+	benchListCmd,
+	benchDeleteCmd,
+}
+
+var benchCmd = cli.Command{
+	Name:            "bench",
+	Usage:           "benchmark an S3 endpoint from this host",
+	HideHelpCommand: true,
+	Action:          mainBench,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	Subcommands:     benchSubcommands,
+}
+
+// mainBench is the handle for "mc bench" command.
+func mainBench(ctx *cli.Context) error {
+	commandNotFound(ctx, benchSubcommands)
+	return nil
+	// Sub-commands like "put", "get" have their own main.
+}
+
+// benchFlags are the flags common to all the benchmarks.
+var benchFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "duration",
+		Value: "1m",
+		Usage: "run the benchmark for this duration",
+	},
+	cli.IntFlag{
+		Name:  "concurrent, c",
+		Value: 16,
+		Usage: "number of concurrent operations",
+	},
+	cli.StringFlag{
+		Name:  "size",
+		Value: "1MiB",
+		Usage: "size of the objects: SIZE, MIN-MAX for a uniform distribution or SIZE:WEIGHT,... e.g. 4KiB:80,1MiB:20",
+	},
+	cli.StringFlag{
+		Name:  "prefix",
+		Usage: "prefix of the benchmark objects under TARGET, it must be empty unless --objects is 0 (default: a new prefix per run)",
+	},
+	cli.StringFlag{
+		Name:  "key-pattern",
+		Value: "obj-{n}",
+		Usage: "name of the objects, {n} is replaced by a sequence number and {rand} by random characters",
+	},
+	cli.StringFlag{
+		Name:  "interval",
+		Value: "5s",
+		Usage: "report the throughput and the latencies per interval",
+	},
+	cli.BoolFlag{
+		Name:  "keep",
+		Usage: "do not remove the objects created by the benchmark",
+	},
+	cli.StringFlag{
+		Name:  "save",
+		Usage: "save the results as JSON in FILE",
+	},
+	cli.StringFlag{
+		Name:  "compare",
+		Usage: "compare the results with the ones saved by a previous run with --save in FILE",
+	},
+}
+
+// benchObjectsFlag is the number of objects uploaded before benchmarking
+// the operations on existing objects.
+var benchObjectsFlag = cli.IntFlag{
+	Name:  "objects",
+	Value: 1000,
+	Usage: "number of objects uploaded before the benchmark, 0 to use the objects kept under --prefix by a previous run",
+}
+
+// errBenchDone stops a worker when there is nothing left to benchmark,
+// e.g. all the objects are removed.
+var errBenchDone = errors.New("no more objects")
+
+// benchSizes is the distribution of the object sizes.
+type benchSizes struct {
+	spec    string
+	sizes   []int64
+	weights []int
+	total   int
+	// min and max bound a uniform distribution when sizes is empty.
+	min, max int64
+}
+
+// parseBenchSizes parses the value of --size: a size, a range of sizes
+// MIN-MAX or a list of sizes with optional weights SIZE:WEIGHT,...
+func parseBenchSizes(s string) (benchSizes, error) {
+	b := benchSizes{spec: s}
+	if r := strings.SplitN(s, "-", 2); len(r) == 2 && !strings.Contains(s, ",") {
+		lo, e := humanize.ParseBytes(r[0])
+		if e != nil {
+			return b, fmt.Errorf("invalid size `%s`", r[0])
+		}
+		hi, e := humanize.ParseBytes(r[1])
+		if e != nil {
+			return b, fmt.Errorf("invalid size `%s`", r[1])
+		}
+		if lo > hi {
+			return b, fmt.Errorf("invalid range `%s`, %s is larger than %s", s, r[0], r[1])
+		}
+		b.min, b.max = int64(lo), int64(hi)
+		return b, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		kv := strings.SplitN(entry, ":", 2)
+		size, e := humanize.ParseBytes(kv[0])
+		if e != nil {
+			return b, fmt.Errorf("invalid size `%s`", kv[0])
+		}
+		weight := 1
+		if len(kv) == 2 {
+			if weight, e = strconv.Atoi(kv[1]); e != nil || weight <= 0 {
+				return b, fmt.Errorf("invalid weight `%s`, expected a positive number", kv[1])
+			}
+		}
+		b.sizes = append(b.sizes, int64(size))
+		b.weights = append(b.weights, weight)
+		b.total += weight
+	}
+	return b, nil
+}
+
+// pick returns the size of a new object.
+func (b benchSizes) pick(r *rand.Rand) int64 {
+	if len(b.sizes) == 0 {
+		return b.min + r.Int63n(b.max-b.min+1)
+	}
+	n := r.Intn(b.total)
+	for i, w := range b.weights {
+		if n < w {
+			return b.sizes[i]
+		}
+		n -= w
+	}
+	return b.sizes[len(b.sizes)-1]
+}
+
+// checkBenchKeyPattern verifies that the pattern names the objects uniquely.
+func checkBenchKeyPattern(pattern string) error {
+	if !strings.Contains(pattern, "{n}") && !strings.Contains(pattern, "{rand}") {
+		return fmt.Errorf("invalid key pattern `%s`, it must contain {n} or {rand}", pattern)
+	}
+	return nil
+}
+
+const benchRandChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// benchKey returns the name of the object n from the pattern.
+func benchKey(pattern string, n int64, r *rand.Rand) string {
+	key := strings.ReplaceAll(pattern, "{n}", strconv.FormatInt(n, 10))
+	for strings.Contains(key, "{rand}") {
+		b := make([]byte, 12)
+		for i := range b {
+			b[i] = benchRandChars[r.Intn(len(benchRandChars))]
+		}
+		key = strings.Replace(key, "{rand}", string(b), 1)
+	}
+	return key
+}
+
+// benchData is an endless reader of random data, the content of the
+// uploaded objects is a section of it.
+type benchData []byte
+
+// ReadAt reads the data repeating at offset off.
+func (d benchData) ReadAt(p []byte, off int64) (int, error) {
+	for n := 0; n < len(p); {
+		m := copy(p[n:], d[(off+int64(n))%int64(len(d)):])
+		n += m
+	}
+	return len(p), nil
+}
+
+// newBenchData returns the data of the uploaded objects.
+func newBenchData() benchData {
+	d := make(benchData, 1<<20)
+	rand.New(rand.NewSource(time.Now().UnixNano())).Read(d)
+	return d
+}
+
+// benchOptions are the options of a benchmark.
+type benchOptions struct {
+	// targetURL is the aliased URL of the benchmark objects, ending with a separator.
+	targetURL  string
+	duration   time.Duration
+	interval   time.Duration
+	concurrent int
+	sizes      benchSizes
+	keyPattern string
+	objects    int
+	keep       bool
+	save       string
+	previous   *benchResult
+	data       benchData
+
+	// seq numbers the objects created.
+	seq int64
+	// created are the objects uploaded by this run, the only ones
+	// removed afterwards.
+	created benchKeys
+	// checkPrefix is set when --prefix must not hold objects yet.
+	checkPrefix bool
+}
+
+// parseBenchOptions validates the arguments of a benchmark.
+func parseBenchOptions(cliCtx *cli.Context) *benchOptions {
+	if len(cliCtx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(cliCtx, cliCtx.Command.Name, 1) // last argument is exit code
+	}
+	aliasedURL := cliCtx.Args().Get(0)
+	alias, p := url2Alias(aliasedURL)
+	if bucket, _ := splitBucketKey(p); alias == "" || bucket == "" {
+		fatalIf(errInvalidArgument().Trace(aliasedURL), "The benchmark requires a bucket on object storage, e.g. 'myminio/mybucket'.")
+	}
+
+	opts := &benchOptions{
+		concurrent: cliCtx.Int("concurrent"),
+		keyPattern: cliCtx.String("key-pattern"),
+		objects:    cliCtx.Int("objects"),
+		keep:       cliCtx.Bool("keep"),
+		save:       cliCtx.String("save"),
+		data:       newBenchData(),
+	}
+	var e error
+	opts.duration, e = time.ParseDuration(cliCtx.String("duration"))
+	if e != nil || opts.duration <= 0 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.String("duration")), "Unable to parse --duration.")
+	}
+	opts.interval, e = time.ParseDuration(cliCtx.String("interval"))
+	if e != nil || opts.interval <= 0 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.String("interval")), "Unable to parse --interval.")
+	}
+	if opts.concurrent <= 0 {
+		fatalIf(errInvalidArgument().Trace(strconv.Itoa(opts.concurrent)), "--concurrent must be a positive number.")
+	}
+	if opts.objects < 0 {
+		fatalIf(errInvalidArgument().Trace(strconv.Itoa(opts.objects)), "--objects must not be negative.")
+	}
+	if cliCtx.IsSet("objects") && opts.objects == 0 {
+		if !cliCtx.IsSet("prefix") {
+			fatalIf(errInvalidArgument(), "--objects 0 requires the --prefix of the objects of a previous run.")
+		}
+		// The objects of the previous run are not removed.
+		opts.keep = true
+	}
+	opts.sizes, e = parseBenchSizes(cliCtx.String("size"))
+	fatalIf(probe.NewError(e), "Unable to parse --size.")
+	fatalIf(probe.NewError(checkBenchKeyPattern(opts.keyPattern)), "Unable to parse --key-pattern.")
+
+	prefix := cliCtx.String("prefix")
+	opts.checkPrefix = prefix != "" && !(cliCtx.IsSet("objects") && opts.objects == 0)
+	if prefix == "" {
+		prefix = fmt.Sprintf("mc-bench-%s-%04x", UTCNow().Format(backupStampFormat), rand.Intn(1<<16))
+	}
+	opts.targetURL = strings.TrimSuffix(urlJoinPath(aliasedURL, prefix), "/") + "/"
+
+	if file := cliCtx.String("compare"); file != "" {
+		previous, err := loadBenchResult(file)
+		fatalIf(err.Trace(file), "Unable to load the results to compare with.")
+		opts.previous = previous
+	}
+	return opts
+}
+
+// nextKey returns the name of a new object.
+func (o *benchOptions) nextKey(w *benchWorker) string {
+	return benchKey(o.keyPattern, atomic.AddInt64(&o.seq, 1), w.rand)
+}
+
+// benchWorker runs the operations of a benchmark one after another.
+type benchWorker struct {
+	rand *rand.Rand
+}
+
+// benchOperation runs one operation of a benchmark.
+type benchOperation func(ctx context.Context, w *benchWorker) benchSample
+
+// benchSample is the outcome of an operation.
+type benchSample struct {
+	op    string
+	end   time.Time
+	dur   time.Duration
+	ttfb  time.Duration
+	bytes int64
+	err   error
+}
+
+// newBenchSample returns the sample of an operation started at start.
+func newBenchSample(op string, start time.Time, bytes int64, err *probe.Error) benchSample {
+	s := benchSample{op: op, end: time.Now(), bytes: bytes}
+	s.dur = s.end.Sub(start)
+	if err != nil {
+		s.err = err.ToGoError()
+	}
+	return s
+}
+
+// benchKeys are the objects available to the operations on existing objects.
+type benchKeys struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+// add makes an object available.
+func (k *benchKeys) add(key string) {
+	k.mu.Lock()
+	k.keys = append(k.keys, key)
+	k.mu.Unlock()
+}
+
+// random returns an available object.
+func (k *benchKeys) random(r *rand.Rand) (string, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.keys) == 0 {
+		return "", false
+	}
+	return k.keys[r.Intn(len(k.keys))], true
+}
+
+// take returns an available object which is no longer available.
+func (k *benchKeys) take(r *rand.Rand) (string, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.keys) == 0 {
+		return "", false
+	}
+	i := r.Intn(len(k.keys))
+	key := k.keys[i]
+	k.keys[i] = k.keys[len(k.keys)-1]
+	k.keys = k.keys[:len(k.keys)-1]
+	return key, true
+}
+
+// benchPutObject uploads a new object, made available in keys if not nil.
+func benchPutObject(ctx context.Context, opts *benchOptions, keys *benchKeys, w *benchWorker) benchSample {
+	key := opts.nextKey(w)
+	size := opts.sizes.pick(w.rand)
+	clnt, err := newClient(opts.targetURL + key)
+	if err != nil {
+		return newBenchSample("put", time.Now(), 0, err)
+	}
+	// Failed uploads may still have created the object.
+	if !opts.keep {
+		opts.created.add(key)
+	}
+	start := time.Now()
+	n, err := clnt.Put(ctx, io.NewSectionReader(opts.data, 0, size), size, nil, PutOptions{})
+	if err == nil && keys != nil {
+		keys.add(key)
+	}
+	return newBenchSample("put", start, n, err)
+}
+
+// prepareBenchObjects uploads the objects of the benchmarks on existing objects.
+func prepareBenchObjects(ctx context.Context, opts *benchOptions) (*benchKeys, *probe.Error) {
+	if !globalJSON && !globalQuiet {
+		console.Infof("Uploading %d objects to `%s`...\n", opts.objects, opts.targetURL)
+	}
+	keys := &benchKeys{}
+	remaining := int64(opts.objects)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr *probe.Error
+	for i := 0; i < opts.concurrent; i++ {
+		wg.Add(1)
+		go func(w *benchWorker) {
+			defer wg.Done()
+			for atomic.AddInt64(&remaining, -1) >= 0 && ctx.Err() == nil {
+				if s := benchPutObject(ctx, opts, keys, w); s.err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = probe.NewError(s.err)
+					}
+					mu.Unlock()
+					return
+				}
+			}
+		}(newBenchWorker(i))
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr.Trace(opts.targetURL)
+	}
+	return keys, nil
+}
+
+// newBenchWorker returns the worker i.
+func newBenchWorker(i int) *benchWorker {
+	return &benchWorker{rand: rand.New(rand.NewSource(time.Now().UnixNano() + int64(i)))}
+}
+
+// runBench runs the operation with concurrent workers for the duration
+// of the benchmark, or until there is nothing left to benchmark.
+func runBench(ctx context.Context, opts *benchOptions, name string, op benchOperation) *benchResult {
+	runCtx, cancel := context.WithTimeout(ctx, opts.duration)
+	defer cancel()
+
+	start := time.Now()
+	samples := make(chan benchSample, opts.concurrent)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrent; i++ {
+		wg.Add(1)
+		go func(w *benchWorker) {
+			defer wg.Done()
+			for runCtx.Err() == nil {
+				s := op(runCtx, w)
+				if s.err == errBenchDone || runCtx.Err() != nil {
+					// Operations interrupted by the end of the run are not counted.
+					return
+				}
+				samples <- s
+			}
+		}(newBenchWorker(i))
+	}
+	go func() {
+		wg.Wait()
+		close(samples)
+	}()
+
+	c := newBenchCollector(start, opts.interval)
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case s, ok := <-samples:
+			if !ok {
+				return c.result(name, opts, time.Now())
+			}
+			c.add(s)
+		case now := <-ticker.C:
+			if !globalJSON && !globalQuiet {
+				c.printIntervals(now)
+			}
+		}
+	}
+}
+
+// benchOpStats are the statistics of an operation.
+type benchOpStats struct {
+	Op          string        `json:"op"`
+	Ops         int64         `json:"ops"`
+	Errors      int64         `json:"errors"`
+	Bytes       int64         `json:"bytes"`
+	OpsPerSec   float64       `json:"opsPerSec"`
+	BytesPerSec float64       `json:"bytesPerSec"`
+	Min         time.Duration `json:"min"`
+	P50         time.Duration `json:"p50"`
+	P90         time.Duration `json:"p90"`
+	P99         time.Duration `json:"p99"`
+	Max         time.Duration `json:"max"`
+	TTFBP50     time.Duration `json:"ttfbP50,omitempty"`
+	TTFBP90     time.Duration `json:"ttfbP90,omitempty"`
+	TTFBP99     time.Duration `json:"ttfbP99,omitempty"`
+	LastError   string        `json:"lastError,omitempty"`
+
+	durations []time.Duration
+	ttfbs     []time.Duration
+}
+
+func (s *benchOpStats) add(smp benchSample) {
+	if smp.err != nil {
+		s.Errors++
+		s.LastError = smp.err.Error()
+		return
+	}
+	s.Ops++
+	s.Bytes += smp.bytes
+	s.durations = append(s.durations, smp.dur)
+	if smp.ttfb > 0 {
+		s.ttfbs = append(s.ttfbs, smp.ttfb)
+	}
+}
+
+func (s *benchOpStats) compute(elapsed time.Duration) {
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		s.OpsPerSec = float64(s.Ops) / seconds
+		s.BytesPerSec = float64(s.Bytes) / seconds
+	}
+	if len(s.durations) > 0 {
+		sortDurations(s.durations)
+		s.Min, s.Max = s.durations[0], s.durations[len(s.durations)-1]
+		s.P50 = durationPercentile(s.durations, 50)
+		s.P90 = durationPercentile(s.durations, 90)
+		s.P99 = durationPercentile(s.durations, 99)
+	}
+	if len(s.ttfbs) > 0 {
+		sortDurations(s.ttfbs)
+		s.TTFBP50 = durationPercentile(s.ttfbs, 50)
+		s.TTFBP90 = durationPercentile(s.ttfbs, 90)
+		s.TTFBP99 = durationPercentile(s.ttfbs, 99)
+	}
+}
+
+// benchInterval are the statistics of all the operations completed
+// during an interval of the benchmark.
+type benchInterval struct {
+	Start       time.Time     `json:"start"`
+	Ops         int64         `json:"ops"`
+	Errors      int64         `json:"errors"`
+	OpsPerSec   float64       `json:"opsPerSec"`
+	BytesPerSec float64       `json:"bytesPerSec"`
+	P50         time.Duration `json:"p50"`
+	P99         time.Duration `json:"p99"`
+	TTFBP50     time.Duration `json:"ttfbP50,omitempty"`
+
+	stats benchOpStats
+}
+
+func (i *benchInterval) compute(elapsed time.Duration) {
+	i.stats.compute(elapsed)
+	i.Ops, i.Errors = i.stats.Ops, i.stats.Errors
+	i.OpsPerSec, i.BytesPerSec = i.stats.OpsPerSec, i.stats.BytesPerSec
+	i.P50, i.P99, i.TTFBP50 = i.stats.P50, i.stats.P99, i.stats.TTFBP50
+}
+
+// benchCollector aggregates the samples of a benchmark.
+type benchCollector struct {
+	start     time.Time
+	interval  time.Duration
+	ops       map[string]*benchOpStats
+	intervals []*benchInterval
+	printed   int
+}
+
+func newBenchCollector(start time.Time, interval time.Duration) *benchCollector {
+	return &benchCollector{
+		start:    start,
+		interval: interval,
+		ops:      make(map[string]*benchOpStats),
+	}
+}
+
+func (c *benchCollector) add(s benchSample) {
+	st, ok := c.ops[s.op]
+	if !ok {
+		st = &benchOpStats{Op: s.op}
+		c.ops[s.op] = st
+	}
+	st.add(s)
+
+	idx := int(s.end.Sub(c.start) / c.interval)
+	if idx < 0 {
+		idx = 0
+	}
+	for len(c.intervals) <= idx {
+		c.intervals = append(c.intervals, &benchInterval{Start: c.start.Add(time.Duration(len(c.intervals)) * c.interval)})
+	}
+	c.intervals[idx].stats.add(s)
+}
+
+// printIntervals prints the intervals completed before now.
+func (c *benchCollector) printIntervals(now time.Time) {
+	for ; c.printed < len(c.intervals); c.printed++ {
+		i := c.intervals[c.printed]
+		if i.Start.Add(c.interval).After(now) {
+			return
+		}
+		i.compute(c.interval)
+		printMsg(benchIntervalMessage{i})
+	}
+}
+
+// result returns the results of the benchmark ended at end.
+func (c *benchCollector) result(name string, opts *benchOptions, end time.Time) *benchResult {
+	r := &benchResult{
+		Benchmark:  name,
+		Target:     opts.targetURL,
+		Start:      c.start,
+		Duration:   end.Sub(c.start),
+		Concurrent: opts.concurrent,
+		Size:       opts.sizes.spec,
+		Intervals:  c.intervals,
+	}
+	for _, st := range c.ops {
+		st.compute(r.Duration)
+		r.Operations = append(r.Operations, st)
+	}
+	sort.Slice(r.Operations, func(i, j int) bool { return r.Operations[i].Op < r.Operations[j].Op })
+	for _, i := range c.intervals {
+		elapsed := c.interval
+		if last := end.Sub(i.Start); last < elapsed {
+			elapsed = last
+		}
+		i.compute(elapsed)
+	}
+	if r.Intervals == nil {
+		r.Intervals = []*benchInterval{}
+	}
+	return r
+}
+
+// benchIntervalMessage reports an interval while the benchmark runs.
+type benchIntervalMessage struct {
+	*benchInterval
+}
+
+func (m benchIntervalMessage) String() string {
+	msg := fmt.Sprintf("%s %8.1f ops/s %10s/s  p50 %-10s p99 %-10s", console.Colorize("BenchTime", m.Start.Local().Format("15:04:05")),
+		m.OpsPerSec, humanize.IBytes(uint64(m.BytesPerSec)), roundTraceDuration(m.P50), roundTraceDuration(m.P99))
+	if m.TTFBP50 > 0 {
+		msg += " ttfb p50 " + roundTraceDuration(m.TTFBP50)
+	}
+	if m.Errors > 0 {
+		msg += console.Colorize("BenchError", fmt.Sprintf(" %d errors", m.Errors))
+	}
+	return msg
+}
+
+func (m benchIntervalMessage) JSON() string {
+	msgBytes, e := json.MarshalIndent(m.benchInterval, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// benchComparison is the relative change in percent of the statistics
+// of an operation since a previous run.
+type benchComparison struct {
+	Op          string  `json:"op"`
+	OpsPerSec   float64 `json:"opsPerSec"`
+	BytesPerSec float64 `json:"bytesPerSec"`
+	P50         float64 `json:"p50"`
+	P99         float64 `json:"p99"`
+}
+
+// percentChange returns the change from before to after in percent.
+func percentChange(before, after float64) float64 {
+	if before == 0 {
+		return 0
+	}
+	return (after - before) * 100 / before
+}
+
+// compareBenchResults compares the operations of two runs.
+func compareBenchResults(before, after *benchResult) []benchComparison {
+	var comparisons []benchComparison
+	for _, a := range after.Operations {
+		for _, b := range before.Operations {
+			if a.Op != b.Op {
+				continue
+			}
+			comparisons = append(comparisons, benchComparison{
+				Op:          a.Op,
+				OpsPerSec:   percentChange(b.OpsPerSec, a.OpsPerSec),
+				BytesPerSec: percentChange(b.BytesPerSec, a.BytesPerSec),
+				P50:         percentChange(float64(b.P50), float64(a.P50)),
+				P99:         percentChange(float64(b.P99), float64(a.P99)),
+			})
+		}
+	}
+	return comparisons
+}
+
+// benchResult are the results of a benchmark.
+type benchResult struct {
+	Status     string            `json:"status"`
+	Benchmark  string            `json:"benchmark"`
+	Target     string            `json:"target"`
+	Start      time.Time         `json:"start"`
+	Duration   time.Duration     `json:"duration"`
+	Concurrent int               `json:"concurrent"`
+	Size       string            `json:"size"`
+	Operations []*benchOpStats   `json:"operations"`
+	Intervals  []*benchInterval  `json:"intervals"`
+	ComparedTo *time.Time        `json:"comparedTo,omitempty"`
+	Comparison []benchComparison `json:"comparison,omitempty"`
+}
+
+// loadBenchResult reads the results saved by a previous run.
+func loadBenchResult(file string) (*benchResult, *probe.Error) {
+	data, e := os.ReadFile(file)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	r := &benchResult{}
+	if e = json.Unmarshal(data, r); e != nil {
+		return nil, probe.NewError(e)
+	}
+	return r, nil
+}
+
+// saveBenchResult writes the results to file.
+func saveBenchResult(file string, r *benchResult) *probe.Error {
+	r.Status = "success"
+	data, e := json.MarshalIndent(r, "", " ")
+	if e != nil {
+		return probe.NewError(e)
+	}
+	return probe.NewError(os.WriteFile(file, append(data, '\n'), 0o644))
+}
+
+// formatChange formats a relative change, colorized if better is true
+// for an improvement.
+func formatChange(change float64, better bool) string {
+	s := fmt.Sprintf("%+.1f%%", change)
+	switch {
+	case change == 0:
+		return s
+	case better:
+		return console.Colorize("BenchBetter", s)
+	default:
+		return console.Colorize("BenchWorse", s)
+	}
+}
+
+func (r benchResult) String() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "%s: %s, %d concurrent, size %s, %s\n", console.Colorize("BenchTitle", strings.ToUpper(r.Benchmark)),
+		r.Target, r.Concurrent, r.Size, r.Duration.Round(time.Millisecond))
+
+	table := newTraceTable(&s, "Op", "Ops", "Ops/s", "Throughput", "Errors", "Min", "P50", "P90", "P99", "Max", "TTFB P50", "TTFB P90", "TTFB P99")
+	for _, op := range r.Operations {
+		errors := strconv.FormatInt(op.Errors, 10)
+		if op.Errors > 0 {
+			errors = console.Colorize("BenchError", errors)
+		}
+		table.Append([]string{
+			op.Op, strconv.FormatInt(op.Ops, 10), fmt.Sprintf("%.1f", op.OpsPerSec), humanize.IBytes(uint64(op.BytesPerSec)) + "/s", errors,
+			roundTraceDuration(op.Min), roundTraceDuration(op.P50), roundTraceDuration(op.P90), roundTraceDuration(op.P99), roundTraceDuration(op.Max),
+			roundTraceDuration(op.TTFBP50), roundTraceDuration(op.TTFBP90), roundTraceDuration(op.TTFBP99),
+		})
+	}
+	table.Render()
+	for _, op := range r.Operations {
+		if op.LastError != "" {
+			fmt.Fprintf(&s, "%s %s: %s\n", console.Colorize("BenchError", "Last error of"), op.Op, op.LastError)
+		}
+	}
+
+	if r.ComparedTo != nil {
+		fmt.Fprintf(&s, "\n%s\n", console.Colorize("BenchTitle", "Compared to the run of "+r.ComparedTo.Local().Format(printDate)))
+		table = newTraceTable(&s, "Op", "Ops/s", "Throughput", "P50", "P99")
+		for _, c := range r.Comparison {
+			table.Append([]string{
+				c.Op, formatChange(c.OpsPerSec, c.OpsPerSec > 0), formatChange(c.BytesPerSec, c.BytesPerSec > 0),
+				formatChange(c.P50, c.P50 < 0), formatChange(c.P99, c.P99 < 0),
+			})
+		}
+		table.Render()
+	}
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+func (r benchResult) JSON() string {
+	r.Status = "success"
+	msgBytes, e := json.MarshalIndent(r, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// removeBenchObjects removes the objects created by the benchmark,
+// other objects under the prefix are left untouched.
+func removeBenchObjects(ctx context.Context, opts *benchOptions) *probe.Error {
+	clnt, err := newClient(opts.targetURL)
+	if err != nil {
+		return err.Trace(opts.targetURL)
+	}
+	opts.created.mu.Lock()
+	keys := opts.created.keys
+	opts.created.mu.Unlock()
+
+	contentCh := make(chan *ClientContent)
+	go func() {
+		defer close(contentCh)
+		for _, key := range keys {
+			select {
+			case contentCh <- &ClientContent{URL: *newClientURL(urlJoinPath(clnt.GetURL().String(), key))}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var rErr *probe.Error
+	for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+		if result.Err != nil && rErr == nil {
+			rErr = result.Err.Trace(opts.targetURL)
+		}
+	}
+	return rErr
+}
+
+// checkBenchPrefix refuses a --prefix already holding objects, they
+// could be overwritten by the benchmark.
+func checkBenchPrefix(ctx context.Context, opts *benchOptions) *probe.Error {
+	clnt, err := newClient(opts.targetURL)
+	if err != nil {
+		return err.Trace(opts.targetURL)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for content := range clnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirNone}) {
+		if content.Err != nil {
+			return content.Err.Trace(opts.targetURL)
+		}
+		return probe.NewError(fmt.Errorf("`%s` already holds objects, use an empty prefix or --objects 0 to benchmark them", opts.targetURL))
+	}
+	return nil
+}
+
+// listBenchObjects returns the objects kept under the prefix by a previous run.
+func listBenchObjects(ctx context.Context, opts *benchOptions) (*benchKeys, *probe.Error) {
+	clnt, err := newClient(opts.targetURL)
+	if err != nil {
+		return nil, err.Trace(opts.targetURL)
+	}
+	keys := &benchKeys{}
+	prefix := clnt.GetURL().Path
+	for content := range clnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirNone}) {
+		if content.Err != nil {
+			return nil, content.Err.Trace(opts.targetURL)
+		}
+		keys.add(strings.TrimPrefix(content.URL.Path, prefix))
+	}
+	if len(keys.keys) == 0 {
+		return nil, probe.NewError(fmt.Errorf("no object found under `%s`", opts.targetURL))
+	}
+	return keys, nil
+}
+
+// benchKeysFor returns the objects of a benchmark on existing objects,
+// the ones kept under --prefix by a previous run if --objects is 0.
+func benchKeysFor(ctx context.Context, opts *benchOptions) *benchKeys {
+	if opts.objects == 0 {
+		keys, err := listBenchObjects(ctx, opts)
+		fatalIf(err, "Unable to list the benchmark objects.")
+		return keys
+	}
+	keys, err := prepareBenchObjects(ctx, opts)
+	if err != nil {
+		if !opts.keep {
+			errorIf(removeBenchObjects(context.Background(), opts), "Unable to remove the benchmark objects.")
+		}
+		fatalIf(err, "Unable to upload the benchmark objects.")
+	}
+	return keys
+}
+
+// doBench runs the benchmark of the operation returned by newOp, with
+// the objects uploaded beforehand if prepare is true.
+func doBench(cliCtx *cli.Context, name string, prepare bool, newOp func(opts *benchOptions, keys *benchKeys) benchOperation) error {
+	ctx, cancelBench := context.WithCancel(globalContext)
+	defer cancelBench()
+
+	console.SetColor("BenchTitle", color.New(color.Bold))
+	console.SetColor("BenchTime", color.New(color.FgGreen))
+	console.SetColor("BenchError", color.New(color.FgRed, color.Bold))
+	console.SetColor("BenchBetter", color.New(color.FgGreen))
+	console.SetColor("BenchWorse", color.New(color.FgRed))
+
+	opts := parseBenchOptions(cliCtx)
+	bucketURL := opts.targetURL
+	if alias, p := url2Alias(opts.targetURL); alias != "" {
+		bucket, _ := splitBucketKey(p)
+		bucketURL = alias + "/" + bucket
+	}
+	clnt, err := newClient(bucketURL)
+	fatalIf(err.Trace(bucketURL), "Unable to initialize target `"+bucketURL+"`.")
+	_, err = clnt.Stat(ctx, StatOptions{})
+	fatalIf(err.Trace(bucketURL), "Unable to access the bucket `"+bucketURL+"`.")
+	if opts.checkPrefix {
+		fatalIf(checkBenchPrefix(ctx, opts), "Unable to use --prefix.")
+	}
+
+	// An interrupted benchmark still removes its objects, the exit is
+	// held until they are removed and they are removed on a context
+	// which is not canceled by the interruption.
+	releaseExit := holdExit()
+	defer releaseExit()
+
+	keys := &benchKeys{}
+	if prepare {
+		keys = benchKeysFor(ctx, opts)
+	}
+	result := runBench(ctx, opts, name, newOp(opts, keys))
+	if !opts.keep {
+		errorIf(removeBenchObjects(context.Background(), opts), "Unable to remove the benchmark objects.")
+	}
+	releaseExit()
+	if opts.previous != nil {
+		result.ComparedTo = &opts.previous.Start
+		result.Comparison = compareBenchResults(opts.previous, result)
+	}
+	if opts.save != "" {
+		fatalIf(saveBenchResult(opts.save, result).Trace(opts.save), "Unable to save the results.")
+	}
+	printMsg(result)
+	return nil
+}
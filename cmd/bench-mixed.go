@@ -0,0 +1,153 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var benchMixedCmd = cli.Command{
+	Name:         "mixed",
+	Usage:        "benchmark a mix of uploads, downloads, stats and removals",
+	Action:       mainBenchMixed,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags: append(append(benchFlags, benchObjectsFlag, cli.StringFlag{
+		Name:  "mix",
+		Value: "get:45,stat:30,put:15,delete:10",
+		Usage: "weights of the operations among get, stat, put and delete",
+	}), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Run a mix of 45% downloads, 30% stats, 15% uploads and 10% removals on the bucket 'mybucket'.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket
+
+  2. Run a read heavy mix on small objects for 10 minutes.
+     {{.Prompt}} {{.HelpName}} --mix get:90,put:10 --size 4KiB-64KiB --duration 10m myminio/mybucket
+`,
+}
+
+// benchMixOps are the operations of a mixed benchmark.
+var benchMixOps = []string{"get", "stat", "put", "delete"}
+
+// benchMix are the weights of the operations of a mixed benchmark.
+type benchMix struct {
+	ops     []string
+	weights []int
+	total   int
+}
+
+// parseBenchMix parses the value of --mix, OP:WEIGHT,...
+func parseBenchMix(s string) (benchMix, error) {
+	var m benchMix
+	for _, entry := range strings.Split(s, ",") {
+		kv := strings.SplitN(entry, ":", 2)
+		if len(kv) != 2 {
+			return m, fmt.Errorf("invalid entry `%s`, expected OP:WEIGHT", entry)
+		}
+		op := strings.ToLower(strings.TrimSpace(kv[0]))
+		known := false
+		for _, o := range benchMixOps {
+			known = known || o == op
+		}
+		if !known {
+			return m, fmt.Errorf("unknown operation `%s`, expected one of %s", kv[0], strings.Join(benchMixOps, ", "))
+		}
+		weight, e := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if e != nil || weight < 0 {
+			return m, fmt.Errorf("invalid weight `%s`, expected a positive number", kv[1])
+		}
+		m.ops = append(m.ops, op)
+		m.weights = append(m.weights, weight)
+		m.total += weight
+	}
+	if m.total == 0 {
+		return m, fmt.Errorf("invalid mix `%s`, at least one operation must have a weight", s)
+	}
+	return m, nil
+}
+
+// pick returns the next operation.
+func (m benchMix) pick(r *rand.Rand) string {
+	n := r.Intn(m.total)
+	for i, w := range m.weights {
+		if n < w {
+			return m.ops[i]
+		}
+		n -= w
+	}
+	return m.ops[len(m.ops)-1]
+}
+
+// benchStatObject fetches the metadata of an object.
+func benchStatObject(ctx context.Context, opts *benchOptions, key string) benchSample {
+	clnt, err := newClient(opts.targetURL + key)
+	if err != nil {
+		return newBenchSample("stat", time.Now(), 0, err)
+	}
+	start := time.Now()
+	_, err = clnt.Stat(ctx, StatOptions{})
+	return newBenchSample("stat", start, 0, err)
+}
+
+// mainBenchMixed is the handle for "mc bench mixed" command.
+func mainBenchMixed(cliCtx *cli.Context) error {
+	mix, e := parseBenchMix(cliCtx.String("mix"))
+	fatalIf(probe.NewError(e), "Unable to parse --mix.")
+
+	return doBench(cliCtx, "mixed", true, func(opts *benchOptions, keys *benchKeys) benchOperation {
+		return func(ctx context.Context, w *benchWorker) benchSample {
+			op := mix.pick(w.rand)
+			var key string
+			var ok bool
+			switch op {
+			case "delete":
+				key, ok = keys.take(w.rand)
+			case "get", "stat":
+				key, ok = keys.random(w.rand)
+			}
+			if !ok {
+				// Upload, also when there is no object left to get or remove.
+				return benchPutObject(ctx, opts, keys, w)
+			}
+			switch op {
+			case "delete":
+				return benchDeleteObject(ctx, opts, key)
+			case "get":
+				return benchGetObject(ctx, opts, key)
+			}
+			return benchStatObject(ctx, opts, key)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/minio/cli"
+)
+
+var benchPutCmd = cli.Command{
+	Name:         "put",
+	Usage:        "benchmark the upload of objects",
+	Action:       mainBenchPut,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(benchFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Upload 1MiB objects to the bucket 'mybucket' with 16 concurrent uploads for one minute.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket
+
+  2. Upload objects of 4KiB to 1MiB with 64 concurrent uploads for 5 minutes, reporting every 10 seconds.
+     {{.Prompt}} {{.HelpName}} --size 4KiB-1MiB --concurrent 64 --duration 5m --interval 10s s3/mybucket
+
+  3. Upload mostly small objects named after random characters, and save the results.
+     {{.Prompt}} {{.HelpName}} --size 4KiB:90,16MiB:10 --key-pattern "{rand}/{n}" --save put.json s3/mybucket
+
+  4. Compare the upload to the results saved by a previous run.
+     {{.Prompt}} {{.HelpName}} --compare put.json s3/mybucket
+`,
+}
+
+// mainBenchPut is the handle for "mc bench put" command.
+func mainBenchPut(cliCtx *cli.Context) error {
+	return doBench(cliCtx, "put", false, func(opts *benchOptions, _ *benchKeys) benchOperation {
+		return func(ctx context.Context, w *benchWorker) benchSample {
+			return benchPutObject(ctx, opts, nil, w)
+		}
+	})
+}
@@ -0,0 +1,284 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestParseBenchSizes(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		ok       bool
+		min, max int64
+	}{
+		{"1MiB", true, 1 << 20, 1 << 20},
+		{"4KiB-1MiB", true, 4 << 10, 1 << 20},
+		{"4KiB:80,1MiB:20", true, 4 << 10, 1 << 20},
+		{"1KiB,2KiB,3KiB", true, 1 << 10, 3 << 10},
+		{"1MiB-4KiB", false, 0, 0},
+		{"big", false, 0, 0},
+		{"4KiB:0", false, 0, 0},
+		{"4KiB:x", false, 0, 0},
+	}
+	r := rand.New(rand.NewSource(1))
+	for i, tc := range testCases {
+		b, e := parseBenchSizes(tc.spec)
+		if (e == nil) != tc.ok {
+			t.Fatalf("Test %d: expected ok=%v, got %v", i+1, tc.ok, e)
+		}
+		if e != nil {
+			continue
+		}
+		for n := 0; n < 1000; n++ {
+			if size := b.pick(r); size < tc.min || size > tc.max {
+				t.Fatalf("Test %d: size %d out of [%d, %d]", i+1, size, tc.min, tc.max)
+			}
+		}
+	}
+}
+
+func TestBenchSizesWeights(t *testing.T) {
+	b, e := parseBenchSizes("1KiB:9,1MiB:1")
+	if e != nil {
+		t.Fatal(e)
+	}
+	r := rand.New(rand.NewSource(1))
+	small := 0
+	for n := 0; n < 10000; n++ {
+		if b.pick(r) == 1<<10 {
+			small++
+		}
+	}
+	if small < 8500 || small > 9500 {
+		t.Fatalf("expected about 9000 small objects out of 10000, got %d", small)
+	}
+}
+
+func TestBenchKey(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	if key := benchKey("obj-{n}", 42, r); key != "obj-42" {
+		t.Fatalf("expected obj-42, got %s", key)
+	}
+	key := benchKey("{rand}/{rand}-{n}", 7, r)
+	parts := strings.Split(key, "/")
+	if len(parts) != 2 || len(parts[0]) != 12 || !strings.HasSuffix(parts[1], "-7") || parts[0] == parts[1][:12] {
+		t.Fatalf("unexpected key %s", key)
+	}
+	if checkBenchKeyPattern("fixed-name") == nil {
+		t.Fatal("expected an error for a pattern without {n} or {rand}")
+	}
+	for _, pattern := range []string{"{n}", "a/{rand}"} {
+		if e := checkBenchKeyPattern(pattern); e != nil {
+			t.Fatalf("%s: %v", pattern, e)
+		}
+	}
+}
+
+func TestParseBenchMix(t *testing.T) {
+	m, e := parseBenchMix("get:45,stat:30,put:15,delete:10")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if m.total != 100 || len(m.ops) != 4 {
+		t.Fatalf("unexpected mix %+v", m)
+	}
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 100; n++ {
+		if op := m.pick(r); op != "get" && op != "stat" && op != "put" && op != "delete" {
+			t.Fatalf("unexpected operation %s", op)
+		}
+	}
+	if m, e = parseBenchMix("get:1,put:0"); e != nil || m.pick(r) != "get" {
+		t.Fatalf("expected only get, got %v", e)
+	}
+	for _, spec := range []string{"get", "list:1", "get:-1", "get:0,put:0"} {
+		if _, e := parseBenchMix(spec); e == nil {
+			t.Fatalf("%s: expected an error", spec)
+		}
+	}
+}
+
+func TestBenchKeys(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	keys := &benchKeys{}
+	if _, ok := keys.random(r); ok {
+		t.Fatal("expected no key")
+	}
+	keys.add("a")
+	keys.add("b")
+	if key, ok := keys.random(r); !ok || (key != "a" && key != "b") {
+		t.Fatalf("unexpected key %s", key)
+	}
+	taken := map[string]bool{}
+	for {
+		key, ok := keys.take(r)
+		if !ok {
+			break
+		}
+		taken[key] = true
+	}
+	if len(taken) != 2 || !taken["a"] || !taken["b"] {
+		t.Fatalf("unexpected keys taken %v", taken)
+	}
+}
+
+func TestBenchData(t *testing.T) {
+	d := benchData("0123456789")
+	got, e := io.ReadAll(io.NewSectionReader(d, 0, 25))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if string(got) != "0123456789012345678901234" {
+		t.Fatalf("unexpected data %q", got)
+	}
+}
+
+func TestRunBench(t *testing.T) {
+	defer func(quiet bool) { globalQuiet = quiet }(globalQuiet)
+	globalQuiet = true
+
+	opts := &benchOptions{
+		targetURL:  "myminio/bucket/prefix/",
+		duration:   time.Minute,
+		interval:   time.Second,
+		concurrent: 4,
+	}
+	var ops int64
+	result := runBench(context.Background(), opts, "delete", func(ctx context.Context, w *benchWorker) benchSample {
+		n := atomic.AddInt64(&ops, 1)
+		if n > 100 {
+			return benchSample{err: errBenchDone}
+		}
+		s := benchSample{op: "delete", end: time.Now(), dur: time.Duration(n) * time.Millisecond}
+		if n%10 == 0 {
+			s.err = errors.New("failed")
+		}
+		return s
+	})
+	if len(result.Operations) != 1 {
+		t.Fatalf("expected one operation, got %d", len(result.Operations))
+	}
+	st := result.Operations[0]
+	if st.Ops != 90 || st.Errors != 10 || st.LastError != "failed" {
+		t.Fatalf("expected 90 operations and 10 errors, got %+v", st)
+	}
+	if st.Min != time.Millisecond || st.Max != 99*time.Millisecond || st.P50 > st.P90 || st.P90 > st.P99 {
+		t.Fatalf("unexpected latencies %+v", st)
+	}
+	if len(result.Intervals) == 0 || result.Intervals[0].Ops == 0 {
+		t.Fatalf("unexpected intervals %+v", result.Intervals)
+	}
+	if result.Duration >= opts.duration {
+		t.Fatalf("expected the run to stop when there is nothing left, took %s", result.Duration)
+	}
+}
+
+func TestRemoveBenchObjects(t *testing.T) {
+	defer func(load func() (*configV10, *probe.Error)) { loadMcConfig = load }(loadMcConfig)
+	loadMcConfig = func() (*configV10, *probe.Error) { return newMcConfig(), nil }
+
+	dir, e := ioutil.TempDir("", "mc-bench")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"obj-1", "obj-2", "data.csv"} {
+		if e = ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	// Only the objects created by the run are removed.
+	opts := &benchOptions{targetURL: dir + string(filepath.Separator)}
+	opts.created.add("obj-1")
+	opts.created.add("obj-2")
+	if err := removeBenchObjects(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	entries, e := ioutil.ReadDir(dir)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(entries) != 1 || entries[0].Name() != "data.csv" {
+		t.Fatalf("expected only data.csv to be left, got %v", entries)
+	}
+
+	if err := checkBenchPrefix(context.Background(), opts); err == nil {
+		t.Fatal("expected a prefix holding objects to be refused")
+	}
+	opts.targetURL = filepath.Join(dir, "empty") + string(filepath.Separator)
+	if err := checkBenchPrefix(context.Background(), opts); err != nil {
+		t.Fatalf("expected an empty prefix to be accepted, got %v", err)
+	}
+}
+
+func TestCompareBenchResults(t *testing.T) {
+	before := &benchResult{Operations: []*benchOpStats{
+		{Op: "get", OpsPerSec: 100, BytesPerSec: 1000, P50: 10 * time.Millisecond, P99: 100 * time.Millisecond},
+		{Op: "put", OpsPerSec: 10},
+	}}
+	after := &benchResult{Operations: []*benchOpStats{
+		{Op: "get", OpsPerSec: 150, BytesPerSec: 500, P50: 5 * time.Millisecond, P99: 100 * time.Millisecond},
+		{Op: "stat", OpsPerSec: 10},
+	}}
+	c := compareBenchResults(before, after)
+	if len(c) != 1 {
+		t.Fatalf("expected one comparison, got %d", len(c))
+	}
+	if c[0].Op != "get" || c[0].OpsPerSec != 50 || c[0].BytesPerSec != -50 || c[0].P50 != -50 || c[0].P99 != 0 {
+		t.Fatalf("unexpected comparison %+v", c[0])
+	}
+	if percentChange(0, 10) != 0 {
+		t.Fatal("expected no change without a previous value")
+	}
+}
+
+func TestSaveBenchResult(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bench.json")
+	r := &benchResult{
+		Benchmark:  "put",
+		Start:      time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+		Duration:   time.Minute,
+		Concurrent: 16,
+		Operations: []*benchOpStats{{Op: "put", Ops: 10, P99: time.Second}},
+	}
+	if err := saveBenchResult(file, r); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadBenchResult(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Benchmark != "put" || !got.Start.Equal(r.Start) || got.Duration != time.Minute ||
+		len(got.Operations) != 1 || got.Operations[0].P99 != time.Second {
+		t.Fatalf("unexpected results %+v", got)
+	}
+}
@@ -0,0 +1,422 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"golang.org/x/term"
+)
+
+var browseCmd = cli.Command{
+	Name:         "browse",
+	Usage:        "browse aliases, buckets and objects interactively",
+	Action:       mainBrowse,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(ioFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] [TARGET]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+KEYS:
+  up/down, k/j       move the cursor
+  enter, right, l    open a bucket or prefix
+  backspace, left, h go back to the parent
+  space              select or unselect the item under the cursor
+  a                  select or unselect all items
+  c                  copy the selected items to another location
+  d                  download the selected items to a local folder
+  x, delete          remove the selected items, after confirmation
+  s                  generate shareable URLs of the selected objects
+  r                  refresh the listing
+  q, ctrl+c          quit
+
+  Details of the object under the cursor, such as its versions, tags and
+  retention are shown in the side pane.
+
+EXAMPLES:
+  1. Browse all configured aliases.
+     {{.Prompt}} {{.HelpName}}
+
+  2. Browse the "photos" bucket.
+     {{.Prompt}} {{.HelpName}} myminio/photos
+`,
+}
+
+const (
+	// Number of entries read from a listing at once.
+	browseBatchSize = 500
+
+	// Expiry of URLs generated with 's'.
+	browseShareExpiry = 7 * 24 * time.Hour
+)
+
+// browseEntry is an alias, bucket, prefix or object of a listing.
+type browseEntry struct {
+	name    string
+	url     string
+	isDir   bool
+	content *ClientContent
+}
+
+// browseListMsg carries the next batch of entries of a listing.
+type browseListMsg struct {
+	url     string
+	entries []browseEntry
+	ch      <-chan *ClientContent
+	done    bool
+	err     *probe.Error
+}
+
+// browseDetailsMsg carries the details of the entry at url.
+type browseDetailsMsg struct {
+	url     string
+	details string
+}
+
+// browseActionMsg carries the result of copy, download, remove or share.
+type browseActionMsg struct {
+	status  string
+	details string
+	refresh bool
+}
+
+// browseAliases returns all configured aliases, sorted.
+func browseAliases() browseListMsg {
+	conf, err := loadMcConfig()
+	if err != nil {
+		return browseListMsg{done: true, err: err}
+	}
+	var entries []browseEntry
+	for alias := range conf.Aliases {
+		entries = append(entries, browseEntry{name: alias + "/", url: alias + "/", isDir: true})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	return browseListMsg{entries: entries, done: true}
+}
+
+// browseList starts a non recursive listing of url and returns
+// its first batch of entries.
+func browseList(ctx context.Context, url string) tea.Cmd {
+	return func() tea.Msg {
+		clnt, err := newClient(url)
+		if err != nil {
+			return browseListMsg{url: url, done: true, err: err.Trace(url)}
+		}
+		ch := clnt.List(ctx, ListOptions{ShowDir: DirNone})
+		return browseNextBatch(url, ch)()
+	}
+}
+
+// browseNextBatch reads the next batch of entries of a listing.
+func browseNextBatch(url string, ch <-chan *ClientContent) tea.Cmd {
+	return func() tea.Msg {
+		msg := browseListMsg{url: url, ch: ch}
+		for len(msg.entries) < browseBatchSize {
+			content, ok := <-ch
+			if !ok {
+				msg.done = true
+				break
+			}
+			if content.Err != nil {
+				msg.err = content.Err.Trace(url)
+				continue
+			}
+			msg.entries = append(msg.entries, newBrowseEntry(url, content))
+		}
+		return msg
+	}
+}
+
+func newBrowseEntry(parentURL string, content *ClientContent) browseEntry {
+	separator := string(content.URL.Separator)
+	name := strings.TrimSuffix(content.URL.Path, separator)
+	name = name[strings.LastIndex(name, separator)+1:]
+	isDir := content.Type.IsDir()
+	if isDir {
+		name += separator
+	}
+	if !strings.HasSuffix(parentURL, separator) {
+		parentURL += separator
+	}
+	return browseEntry{name: name, url: parentURL + name, isDir: isDir, content: content}
+}
+
+// browseDetails collects the stat details, versions, tags and
+// retention of an object.
+func browseDetails(ctx context.Context, entry browseEntry, encKeyDB map[string][]prefixSSEPair) tea.Cmd {
+	return func() tea.Msg {
+		var b strings.Builder
+		field := func(name string, value interface{}) {
+			fmt.Fprintf(&b, "%-12s: %v\n", name, value)
+		}
+
+		field("Name", entry.name)
+		if entry.content == nil || entry.isDir {
+			if entry.content != nil && !entry.content.Time.IsZero() {
+				field("Date", entry.content.Time.Local().Format(printDate))
+			}
+			return browseDetailsMsg{url: entry.url, details: b.String()}
+		}
+
+		clnt, st, err := url2Stat(ctx, entry.url, "", false, encKeyDB, time.Time{}, false)
+		if err != nil {
+			field("Error", err.ToGoError())
+			return browseDetailsMsg{url: entry.url, details: b.String()}
+		}
+		field("Date", st.Time.Local().Format(printDate))
+		field("Size", humanize.IBytes(uint64(st.Size)))
+		field("ETag", st.ETag)
+		if st.VersionID != "" {
+			field("VersionID", st.VersionID)
+		}
+		if st.StorageClass != "" {
+			field("Class", st.StorageClass)
+		}
+		if contentType, ok := st.Metadata["Content-Type"]; ok {
+			field("Type", contentType)
+		}
+
+		if mode, until, err := clnt.GetObjectRetention(ctx, st.VersionID); err == nil && mode != "" {
+			field("Retention", fmt.Sprintf("%s until %s", mode, until.Local().Format(printDate)))
+		}
+		if hold, err := clnt.GetObjectLegalHold(ctx, st.VersionID); err == nil && hold != "" {
+			field("LegalHold", hold)
+		}
+
+		if tags, err := clnt.GetTags(ctx, st.VersionID); err == nil && len(tags) > 0 {
+			b.WriteString("\nTags:\n")
+			keys := make([]string, 0, len(tags))
+			for k := range tags {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(&b, "  %s: %s\n", k, tags[k])
+			}
+		}
+
+		var versions []string
+		for content := range clnt.List(ctx, ListOptions{WithOlderVersions: true, WithDeleteMarkers: true}) {
+			if content.Err != nil || content.URL.Path != clnt.GetURL().Path {
+				continue
+			}
+			version := fmt.Sprintf("  %s %9s %s", content.Time.Local().Format(printDate),
+				humanize.IBytes(uint64(content.Size)), content.VersionID)
+			if content.IsDeleteMarker {
+				version += " (delete marker)"
+			}
+			versions = append(versions, version)
+		}
+		if len(versions) > 1 || len(versions) == 1 && st.VersionID != "" {
+			b.WriteString("\nVersions:\n")
+			b.WriteString(strings.Join(versions, "\n"))
+			b.WriteString("\n")
+		}
+		return browseDetailsMsg{url: entry.url, details: b.String()}
+	}
+}
+
+// browseContents calls fn for the object of entry, or for all objects
+// under the prefix if entry is a bucket or a prefix. name is the path
+// of the object relative to the listing entry belongs to.
+func browseContents(ctx context.Context, entry browseEntry, fn func(content *ClientContent, name string) *probe.Error) *probe.Error {
+	clnt, err := newClient(entry.url)
+	if err != nil {
+		return err.Trace(entry.url)
+	}
+	if !entry.isDir {
+		return fn(entry.content, entry.name)
+	}
+	dirPath := clnt.GetURL().Path
+	separator := string(clnt.GetURL().Separator)
+	if !strings.HasSuffix(dirPath, separator) {
+		dirPath += separator
+	}
+	for content := range clnt.List(ctx, ListOptions{Recursive: true}) {
+		if content.Err != nil {
+			return content.Err.Trace(entry.url)
+		}
+		if content.Type.IsDir() {
+			continue
+		}
+		if err = fn(content, entry.name+strings.TrimPrefix(content.URL.Path, dirPath)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// browseCopy copies the entries under the target folder.
+func browseCopy(ctx context.Context, entries []browseEntry, target string, encKeyDB map[string][]prefixSSEPair) tea.Cmd {
+	return func() tea.Msg {
+		if !strings.HasSuffix(target, "/") && !strings.HasSuffix(target, string(os.PathSeparator)) {
+			target += "/"
+		}
+		var copied int
+		var size int64
+		for _, entry := range entries {
+			sourceAlias, _, _ := mustExpandAlias(entry.url)
+			err := browseContents(ctx, entry, func(content *ClientContent, name string) *probe.Error {
+				targetAlias, targetURL, _ := mustExpandAlias(target + name)
+				urls := uploadSourceToTargetURL(ctx, makeCopyContentTypeA(sourceAlias, content, targetAlias, targetURL, encKeyDB), nil, encKeyDB, false, false)
+				if urls.Error != nil {
+					return urls.Error.Trace(entry.url, target)
+				}
+				copied++
+				size += content.Size
+				return nil
+			})
+			if err != nil {
+				return browseActionMsg{status: fmt.Sprintf("Copied %d objects, failed on `%s`: %v", copied, entry.url, err.ToGoError())}
+			}
+		}
+		return browseActionMsg{status: fmt.Sprintf("Copied %d objects (%s) to `%s`.", copied, humanize.IBytes(uint64(size)), target)}
+	}
+}
+
+// browseRemove removes the entries, all objects under prefixes.
+func browseRemove(ctx context.Context, entries []browseEntry) tea.Cmd {
+	return func() tea.Msg {
+		var removed int
+		for _, entry := range entries {
+			if entry.content == nil || isBucketEntry(entry) {
+				return browseActionMsg{status: fmt.Sprintf("Removed %d objects, `%s` is not an object or a prefix.", removed, entry.url), refresh: removed > 0}
+			}
+			clnt, err := newClient(entry.url)
+			if err != nil {
+				return browseActionMsg{status: fmt.Sprintf("Removed %d objects, failed on `%s`: %v", removed, entry.url, err.ToGoError()), refresh: removed > 0}
+			}
+			n, err := browseRemoveEntry(ctx, clnt, entry)
+			removed += n
+			if err != nil {
+				return browseActionMsg{status: fmt.Sprintf("Removed %d objects, failed on `%s`: %v", removed, entry.url, err.ToGoError()), refresh: true}
+			}
+		}
+		return browseActionMsg{status: fmt.Sprintf("Removed %d objects.", removed), refresh: true}
+	}
+}
+
+// browseRemoveEntry removes the objects of a single entry. On the first
+// failure the listing is canceled and the pending results are drained
+// before returning, so no goroutine is left behind.
+func browseRemoveEntry(ctx context.Context, clnt Client, entry browseEntry) (removed int, err *probe.Error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	contentCh := make(chan *ClientContent)
+	go func() {
+		defer close(contentCh)
+		browseContents(ctx, entry, func(content *ClientContent, _ string) *probe.Error {
+			select {
+			case contentCh <- &ClientContent{URL: content.URL}:
+				return nil
+			case <-ctx.Done():
+				return probe.NewError(ctx.Err())
+			}
+		})
+	}()
+	for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+		if result.Err != nil {
+			if err == nil {
+				err = result.Err
+				cancel()
+			}
+			continue
+		}
+		removed++
+	}
+	return removed, err
+}
+
+// isBucketEntry returns true if the entry is a bucket of an alias.
+func isBucketEntry(entry browseEntry) bool {
+	_, object := url2BucketAndObject(&entry.content.URL)
+	return entry.content.URL.Type == objectStorage && object == ""
+}
+
+// browseShare generates shareable URLs for the entries.
+func browseShare(ctx context.Context, entries []browseEntry) tea.Cmd {
+	return func() tea.Msg {
+		var b strings.Builder
+		var shared int
+		for _, entry := range entries {
+			alias, _, _ := mustExpandAlias(entry.url)
+			err := browseContents(ctx, entry, func(content *ClientContent, name string) *probe.Error {
+				clnt, err := newClientFromAlias(alias, content.URL.String())
+				if err != nil {
+					return err.Trace(entry.url)
+				}
+				shareURL, err := clnt.ShareDownload(ctx, content.VersionID, browseShareExpiry)
+				if err != nil {
+					return err.Trace(entry.url)
+				}
+				fmt.Fprintf(&b, "%s\n  %s\n", name, shareURL)
+				shared++
+				return nil
+			})
+			if err != nil {
+				return browseActionMsg{status: fmt.Sprintf("Shared %d objects, failed on `%s`: %v", shared, entry.url, err.ToGoError()), details: b.String()}
+			}
+		}
+		return browseActionMsg{
+			status:  fmt.Sprintf("Shared %d objects, URLs expire in %s.", shared, timeDurationToHumanizedDuration(browseShareExpiry)),
+			details: b.String(),
+		}
+	}
+}
+
+// mainBrowse is the handle for "mc browse" command.
+func mainBrowse(cliCtx *cli.Context) error {
+	ctx, cancelBrowse := context.WithCancel(globalContext)
+	defer cancelBrowse()
+
+	if len(cliCtx.Args()) > 1 {
+		cli.ShowCommandHelpAndExit(cliCtx, "browse", 1) // last argument is exit code
+	}
+	if globalJSON || !term.IsTerminal(int(os.Stdout.Fd())) {
+		fatalIf(errInvalidArgument(), "`mc browse` needs an interactive terminal.")
+	}
+
+	// Parse encryption keys per command.
+	encKeyDB, err := getEncKeys(cliCtx)
+	fatalIf(err, "Unable to parse encryption keys.")
+
+	ui := tea.NewProgram(newBrowseUI(ctx, cliCtx.Args().Get(0), encKeyDB), tea.WithAltScreen())
+	if e := ui.Start(); e != nil {
+		fatalIf(probe.NewError(e), "Unable to start the object browser.")
+	}
+	return nil
+}
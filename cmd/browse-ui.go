@@ -0,0 +1,478 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+)
+
+// Delay before loading the details of the entry under the cursor,
+// to avoid a stat for every entry scrolled through.
+const browseDetailsDelay = 200 * time.Millisecond
+
+var (
+	browseTitleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#ffffff")).Background(lipgloss.Color("#c72c48"))
+	browseCursorStyle   = lipgloss.NewStyle().Reverse(true)
+	browseSelectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	browseDirStyle      = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	browseDetailsStyle  = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, false, false, true).PaddingLeft(1)
+	browseStatusStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+type browseMode int
+
+const (
+	browseNormal browseMode = iota
+	browsePrompt
+	browseConfirm
+)
+
+type browseAction int
+
+const (
+	browseCopyAction browseAction = iota
+	browseDownloadAction
+	browseRemoveAction
+)
+
+// browseDetailsTickMsg loads the details of url if the cursor is
+// still on it.
+type browseDetailsTickMsg string
+
+// browseLocation is a parent listing to go back to.
+type browseLocation struct {
+	url    string
+	cursor int
+}
+
+type browseUI struct {
+	ctx      context.Context
+	encKeyDB map[string][]prefixSSEPair
+
+	url        string
+	parents    []browseLocation
+	entries    []browseEntry
+	listCh     <-chan *ClientContent
+	listCancel context.CancelFunc
+	listDone   bool
+	loading    bool
+	restore    int
+
+	cursor   int
+	offset   int
+	selected map[string]bool
+
+	details    string
+	detailsURL string
+
+	mode    browseMode
+	action  browseAction
+	targets []browseEntry
+	input   textinput.Model
+	status  string
+	busy    bool
+
+	width  int
+	height int
+}
+
+func newBrowseUI(ctx context.Context, url string, encKeyDB map[string][]prefixSSEPair) *browseUI {
+	input := textinput.New()
+	input.CharLimit = 1024
+	return &browseUI{
+		ctx:      ctx,
+		encKeyDB: encKeyDB,
+		url:      url,
+		selected: make(map[string]bool),
+		input:    input,
+		width:    80,
+		height:   24,
+	}
+}
+
+func (m *browseUI) Init() tea.Cmd {
+	return m.open(m.url, 0)
+}
+
+// open starts listing url, restoring the cursor once loaded.
+func (m *browseUI) open(url string, cursor int) tea.Cmd {
+	m.stopListing()
+	m.url = url
+	m.entries = nil
+	m.cursor, m.offset, m.restore = 0, 0, cursor
+	m.selected = make(map[string]bool)
+	m.details, m.detailsURL = "", ""
+	m.loading, m.listDone = true, false
+	if url == "" {
+		return func() tea.Msg { return browseAliases() }
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.listCancel = cancel
+	return browseList(ctx, url)
+}
+
+// stopListing cancels the current listing, draining it so that
+// the listing go-routine can exit.
+func (m *browseUI) stopListing() {
+	if m.listCancel != nil {
+		m.listCancel()
+		m.listCancel = nil
+	}
+	if m.listCh != nil && !m.listDone {
+		go func(ch <-chan *ClientContent) {
+			for range ch {
+			}
+		}(m.listCh)
+	}
+	m.listCh = nil
+}
+
+func (m *browseUI) rows() int {
+	if rows := m.height - 3; rows > 1 {
+		return rows
+	}
+	return 1
+}
+
+// loadMore reads the next batch of the listing once the cursor
+// gets close to the end of the loaded entries.
+func (m *browseUI) loadMore() tea.Cmd {
+	if m.loading || m.listDone || m.listCh == nil || m.cursor < len(m.entries)-2*m.rows() {
+		return nil
+	}
+	m.loading = true
+	return browseNextBatch(m.url, m.listCh)
+}
+
+func (m *browseUI) moveCursor(n int) tea.Cmd {
+	m.cursor += n
+	if m.cursor >= len(m.entries) {
+		m.cursor = len(m.entries) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.rows() {
+		m.offset = m.cursor - m.rows() + 1
+	}
+	return tea.Batch(m.loadMore(), m.scheduleDetails())
+}
+
+func (m *browseUI) scheduleDetails() tea.Cmd {
+	if len(m.entries) == 0 {
+		return nil
+	}
+	url := m.entries[m.cursor].url
+	if url == m.detailsURL {
+		return nil
+	}
+	m.detailsURL = url
+	m.details = ""
+	return tea.Tick(browseDetailsDelay, func(time.Time) tea.Msg {
+		return browseDetailsTickMsg(url)
+	})
+}
+
+// selection returns the selected entries, or the entry under the
+// cursor if none is selected.
+func (m *browseUI) selection() []browseEntry {
+	var entries []browseEntry
+	for _, entry := range m.entries {
+		if m.selected[entry.url] {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 && len(m.entries) > 0 {
+		entries = append(entries, m.entries[m.cursor])
+	}
+	return entries
+}
+
+func (m *browseUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, m.moveCursor(0)
+	case browseListMsg:
+		if msg.url != m.url {
+			// Stale batch of a listing we navigated away from.
+			return m, nil
+		}
+		m.loading = false
+		m.listCh, m.listDone = msg.ch, msg.done
+		m.entries = append(m.entries, msg.entries...)
+		if msg.err != nil {
+			m.status = "Unable to list `" + msg.url + "`: " + msg.err.ToGoError().Error()
+		}
+		if m.restore > 0 {
+			m.cursor, m.restore = m.restore, 0
+		}
+		return m, m.moveCursor(0)
+	case browseDetailsTickMsg:
+		if string(msg) != m.detailsURL || len(m.entries) == 0 {
+			return m, nil
+		}
+		return m, browseDetails(m.ctx, m.entries[m.cursor], m.encKeyDB)
+	case browseDetailsMsg:
+		if msg.url == m.detailsURL {
+			m.details = msg.details
+		}
+		return m, nil
+	case browseActionMsg:
+		m.busy = false
+		m.status = msg.status
+		if msg.refresh {
+			return m, m.open(m.url, m.cursor)
+		}
+		if msg.details != "" {
+			m.details = msg.details
+		}
+		return m, nil
+	case tea.KeyMsg:
+		switch m.mode {
+		case browsePrompt:
+			return m.updatePrompt(msg)
+		case browseConfirm:
+			return m.updateConfirm(msg)
+		}
+		return m.updateNormal(msg)
+	}
+	return m, nil
+}
+
+func (m *browseUI) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.busy {
+		// The result of the last action is shown until the next key.
+		m.status = ""
+	}
+	switch msg.String() {
+	case "ctrl+c", "q":
+		m.stopListing()
+		return m, tea.Quit
+	case "up", "k":
+		return m, m.moveCursor(-1)
+	case "down", "j":
+		return m, m.moveCursor(1)
+	case "pgup":
+		return m, m.moveCursor(-m.rows())
+	case "pgdown":
+		return m, m.moveCursor(m.rows())
+	case "home", "g":
+		return m, m.moveCursor(-len(m.entries))
+	case "end", "G":
+		return m, m.moveCursor(len(m.entries))
+	case "enter", "right", "l":
+		if len(m.entries) == 0 || !m.entries[m.cursor].isDir {
+			return m, nil
+		}
+		m.parents = append(m.parents, browseLocation{url: m.url, cursor: m.cursor})
+		return m, m.open(m.entries[m.cursor].url, 0)
+	case "backspace", "left", "h":
+		if len(m.parents) == 0 {
+			return m, nil
+		}
+		parent := m.parents[len(m.parents)-1]
+		m.parents = m.parents[:len(m.parents)-1]
+		return m, m.open(parent.url, parent.cursor)
+	case " ":
+		if len(m.entries) > 0 && m.url != "" {
+			url := m.entries[m.cursor].url
+			if m.selected[url] {
+				delete(m.selected, url)
+			} else {
+				m.selected[url] = true
+			}
+		}
+		return m, m.moveCursor(1)
+	case "a":
+		if m.url == "" {
+			return m, nil
+		}
+		if len(m.selected) == len(m.entries) {
+			m.selected = make(map[string]bool)
+		} else {
+			for _, entry := range m.entries {
+				m.selected[entry.url] = true
+			}
+		}
+		return m, nil
+	case "r":
+		return m, m.open(m.url, m.cursor)
+	}
+
+	if m.busy || m.url == "" || len(m.entries) == 0 {
+		return m, nil
+	}
+	switch msg.String() {
+	case "c":
+		return m, m.prompt(browseCopyAction, "Copy to: ", "")
+	case "d":
+		return m, m.prompt(browseDownloadAction, "Download to: ", "./")
+	case "x", "delete":
+		m.targets = m.selection()
+		m.action = browseRemoveAction
+		m.mode = browseConfirm
+		m.status = fmt.Sprintf("Remove %d item(s), including all objects under prefixes? [y/N]", len(m.targets))
+	case "s":
+		m.busy = true
+		m.status = "Generating shareable URLs..."
+		return m, browseShare(m.ctx, m.selection())
+	}
+	return m, nil
+}
+
+func (m *browseUI) prompt(action browseAction, prompt, value string) tea.Cmd {
+	m.targets = m.selection()
+	m.action = action
+	m.mode = browsePrompt
+	m.input.Prompt = prompt
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+func (m *browseUI) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = browseNormal
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		m.mode = browseNormal
+		m.input.Blur()
+		target := strings.TrimSpace(m.input.Value())
+		if target == "" {
+			return m, nil
+		}
+		m.busy = true
+		verb := "Copying"
+		if m.action == browseDownloadAction {
+			verb = "Downloading"
+		}
+		m.status = fmt.Sprintf("%s %d item(s) to `%s`...", verb, len(m.targets), target)
+		return m, browseCopy(m.ctx, m.targets, target, m.encKeyDB)
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *browseUI) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = browseNormal
+	if msg.String() != "y" && msg.String() != "Y" {
+		m.status = "Canceled."
+		return m, nil
+	}
+	m.busy = true
+	m.status = fmt.Sprintf("Removing %d item(s)...", len(m.targets))
+	return m, browseRemove(m.ctx, m.targets)
+}
+
+// browseTruncate cuts s to at most n runes.
+func browseTruncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n == 1 {
+		return "…"
+	}
+	return string(r[:n-1]) + "…"
+}
+
+func (m *browseUI) View() string {
+	listWidth := m.width * 55 / 100
+	detailsWidth := m.width - listWidth - 2
+	rows := m.rows()
+
+	location := m.url
+	if location == "" {
+		location = "aliases"
+	}
+	title := browseTitleStyle.Render(browseTruncate(" mc browse: "+location+" ", m.width))
+
+	var list strings.Builder
+	for i := m.offset; i < len(m.entries) && i < m.offset+rows; i++ {
+		entry := m.entries[i]
+		mark := "  "
+		if m.selected[entry.url] {
+			mark = "* "
+		}
+		info := ""
+		if entry.content != nil && !entry.isDir {
+			info = fmt.Sprintf(" %9s", humanize.IBytes(uint64(entry.content.Size)))
+		}
+		nameWidth := listWidth - len(mark) - len(info)
+		line := mark + fmt.Sprintf("%-*s", nameWidth, browseTruncate(entry.name, nameWidth)) + info
+		switch {
+		case i == m.cursor:
+			line = browseCursorStyle.Render(line)
+		case m.selected[entry.url]:
+			line = browseSelectedStyle.Render(line)
+		case entry.isDir:
+			line = browseDirStyle.Render(line)
+		}
+		list.WriteString(line)
+		list.WriteString("\n")
+	}
+	if len(m.entries) == 0 && !m.loading {
+		list.WriteString("  (empty)\n")
+	}
+
+	var details []string
+	for _, line := range strings.Split(m.details, "\n") {
+		details = append(details, browseTruncate(line, detailsWidth))
+		if len(details) == rows {
+			break
+		}
+	}
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(listWidth).Height(rows).Render(strings.TrimSuffix(list.String(), "\n")),
+		browseDetailsStyle.Width(detailsWidth).Height(rows).Render(strings.Join(details, "\n")),
+	)
+
+	var footer string
+	switch {
+	case m.mode == browsePrompt:
+		footer = m.input.View()
+	case m.status != "":
+		footer = browseStatusStyle.Render(browseTruncate(m.status, m.width))
+	default:
+		help := "↑/↓ move • ⏎ open • ⌫ back • space select • c copy • d download • x remove • s share • r refresh • q quit"
+		if m.loading {
+			help = "loading... • " + help
+		}
+		footer = browseStatusStyle.Render(browseTruncate(help, m.width))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, title, body, footer)
+}
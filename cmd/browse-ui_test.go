@@ -0,0 +1,95 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNewBrowseEntry(t *testing.T) {
+	testCases := []struct {
+		parent string
+		path   string
+		dir    bool
+		name   string
+		url    string
+	}{
+		{"play", "/bucket", true, "bucket/", "play/bucket/"},
+		{"play/bucket/", "/bucket/photos/", true, "photos/", "play/bucket/photos/"},
+		{"play/bucket/photos", "/bucket/photos/1.jpg", false, "1.jpg", "play/bucket/photos/1.jpg"},
+	}
+	for i, testCase := range testCases {
+		content := &ClientContent{URL: *newClientURL("https://play.min.io" + testCase.path), Type: os.FileMode(0o664)}
+		if testCase.dir {
+			content.Type = os.ModeDir
+		}
+		entry := newBrowseEntry(testCase.parent, content)
+		if entry.name != testCase.name || entry.url != testCase.url || entry.isDir != testCase.dir {
+			t.Errorf("Test %d: unexpected entry %+v", i+1, entry)
+		}
+	}
+}
+
+func TestBrowseUINavigation(t *testing.T) {
+	m := newBrowseUI(context.Background(), "play/bucket/", nil)
+	m.Init()
+	m.height = 5 // two rows of entries
+
+	var entries []browseEntry
+	for _, name := range []string{"a/", "b", "c", "d"} {
+		entries = append(entries, browseEntry{name: name, url: "play/bucket/" + name, isDir: name == "a/"})
+	}
+	m.Update(browseListMsg{url: "play/bucket/", entries: entries, done: true})
+
+	keys := func(keys ...string) {
+		for _, key := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			if key == "down" {
+				msg = tea.KeyMsg{Type: tea.KeyDown}
+			}
+			m.Update(msg)
+		}
+	}
+
+	keys("down", "down", "down", "down")
+	if m.cursor != 3 || m.offset != 2 {
+		t.Fatalf("expected cursor 3 at offset 2, got %d at %d", m.cursor, m.offset)
+	}
+
+	keys("g", "j", " ", "j", " ")
+	selection := m.selection()
+	if len(selection) != 2 || selection[0].name != "b" || selection[1].name != "d" {
+		t.Fatalf("unexpected selection %+v", selection)
+	}
+
+	// Stale batches of a previous listing are ignored.
+	m.Update(browseListMsg{url: "play/other/", entries: entries})
+	if len(m.entries) != len(entries) {
+		t.Fatalf("stale listing was appended")
+	}
+
+	keys("g", "l")
+	if m.url != "play/bucket/a/" || len(m.parents) != 1 || len(m.entries) != 0 {
+		t.Fatalf("expected to open play/bucket/a/, at %s", m.url)
+	}
+	m.stopListing()
+}
@@ -215,6 +215,10 @@ func catURL(ctx context.Context, sourceURL string, encKeyDB map[string][]prefixS
 	default:
 		versionID := o.versionID
 		var err *probe.Error
+		// Objects compressed by mc are decompressed, the offsets apply
+		// to their uncompressed content.
+		var codec string
+		uncompressedSize := int64(-1)
 		// Try to stat the object, the purpose is to:
 		// 1. extract the size of S3 object so we can check if the size of the
 		// downloaded object is equal to the original one. FS files
@@ -225,8 +229,17 @@ func catURL(ctx context.Context, sourceURL string, encKeyDB map[string][]prefixS
 			if o.versionID == "" {
 				versionID = content.VersionID
 			}
-			if o.tailO > 0 && content.Size > 0 {
-				o.startO = content.Size - o.tailO
+			contentSize := content.Size
+			if client.GetURL().Type == objectStorage {
+				codec, uncompressedSize = compressionOf(content.UserMetadata, content.Metadata)
+				contentSize = logicalSize(content)
+				if codec != "" && uncompressedSize < 0 && o.tailO > 0 {
+					err := probe.NewError(fmt.Errorf("uncompressed size of the object is unknown, --tail is not supported"))
+					return err.Trace(sourceURL)
+				}
+			}
+			if o.tailO > 0 && contentSize > 0 {
+				o.startO = contentSize - o.tailO
 				if o.startO < 0 {
 					// Return all.
 					o.startO = 0
@@ -234,16 +247,23 @@ func catURL(ctx context.Context, sourceURL string, encKeyDB map[string][]prefixS
 			}
 
 			if client.GetURL().Type == objectStorage {
-				size = content.Size - o.startO
+				size = contentSize - o.startO
 				if size < 0 {
-					err := probe.NewError(fmt.Errorf("specified offset (%d) bigger than file (%d)", o.startO, content.Size))
+					err := probe.NewError(fmt.Errorf("specified offset (%d) bigger than file (%d)", o.startO, contentSize))
 					return err.Trace(sourceURL)
 				}
+				if codec != "" && uncompressedSize < 0 {
+					// Uncompressed size not recorded, e.g. by 'mc pipe'.
+					size = -1
+				}
 			}
 		} else {
 			return err.Trace(sourceURL)
 		}
 		gopts := GetOptions{VersionID: versionID, Zip: o.isZip, RangeStart: o.startO}
+		if codec != "" {
+			gopts.RangeStart = 0
+		}
 		if reader, err = getSourceStreamFromURL(ctx, sourceURL, encKeyDB, getSourceOpts{
 			GetOptions: gopts,
 			fetchStat:  false,
@@ -251,6 +271,15 @@ func catURL(ctx context.Context, sourceURL string, encKeyDB map[string][]prefixS
 		}); err != nil {
 			return err.Trace(sourceURL)
 		}
+		if codec != "" {
+			if reader, err = newDecompressReader(reader, codec); err != nil {
+				return err.Trace(sourceURL)
+			}
+			if _, e := io.CopyN(io.Discard, reader, o.startO); e != nil {
+				reader.Close()
+				return probe.NewError(e).Trace(sourceURL)
+			}
+		}
 		defer reader.Close()
 	}
 	return catOut(reader, size).Trace(sourceURL)
@@ -21,7 +21,6 @@ import (
 	"crypto/tls"
 	"fmt"
 	"hash/fnv"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -60,6 +59,7 @@ func NewAdminFactory() func(config *Config) (*madmin.AdminClient, *probe.Error)
 		// Generate a hash out of s3Conf.
 		confHash := fnv.New32a()
 		confHash.Write([]byte(hostName + config.AccessKey + config.SecretKey))
+		confHash.Write([]byte(config.TransportProfile.hash()))
 		confSum := confHash.Sum32()
 
 		// Lookup previous cache by hash.
@@ -93,12 +93,9 @@ func NewAdminFactory() func(config *Config) (*madmin.AdminClient, *probe.Error)
 				tlsConfig.InsecureSkipVerify = true
 			}
 
-			var transport http.RoundTripper = &http.Transport{
-				Proxy: ieproxy.GetProxyFunc(),
-				DialContext: (&net.Dialer{
-					Timeout:   10 * time.Second,
-					KeepAlive: 15 * time.Second,
-				}).DialContext,
+			tr := &http.Transport{
+				Proxy:                 ieproxy.GetProxyFunc(),
+				DialContext:           newCustomDialContext(config),
 				MaxIdleConnsPerHost:   256,
 				IdleConnTimeout:       90 * time.Second,
 				TLSHandshakeTimeout:   10 * time.Second,
@@ -106,7 +103,10 @@ func NewAdminFactory() func(config *Config) (*madmin.AdminClient, *probe.Error)
 				TLSClientConfig:       tlsConfig,
 				DisableCompression:    true,
 			}
-			transport = gzhttp.Transport(transport)
+			if err := config.TransportProfile.apply(tr); err != nil {
+				return nil, err.Trace(config.HostURL)
+			}
+			transport := config.TransportProfile.wrap(gzhttp.Transport(tr))
 
 			if config.Debug {
 				transport = httptracer.GetNewTraceTransport(newTraceV4(), transport)
@@ -199,12 +199,9 @@ func newAnonymousClient(aliasedURL string) (*madmin.AnonymousClient, *probe.Erro
 		tlsConfig.InsecureSkipVerify = true
 	}
 	// Set custom transport
-	var transport http.RoundTripper = &http.Transport{
-		Proxy: ieproxy.GetProxyFunc(),
-		DialContext: (&net.Dialer{
-			Timeout:   10 * time.Second,
-			KeepAlive: 15 * time.Second,
-		}).DialContext,
+	tr := &http.Transport{
+		Proxy:                 ieproxy.GetProxyFunc(),
+		DialContext:           newCustomDialContext(&Config{TransportProfile: aliasCfg.Transport}),
 		MaxIdleConnsPerHost:   256,
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
@@ -218,6 +215,10 @@ func newAnonymousClient(aliasedURL string) (*madmin.AnonymousClient, *probe.Erro
 		//    https://golang.org/src/net/http/transport.go?h=roundTrip#L1843
 		DisableCompression: true,
 	}
+	if err := aliasCfg.Transport.apply(tr); err != nil {
+		return nil, err.Trace(urlStrFull)
+	}
+	transport := aliasCfg.Transport.wrap(tr)
 	if globalDebug {
 		transport = httptracer.GetNewTraceTransport(newTraceV4(), transport)
 	}
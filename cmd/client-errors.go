@@ -207,3 +207,13 @@ type SameFile struct {
 func (e SameFile) Error() string {
 	return fmt.Sprintf("'%s' and '%s' are the same file", e.Source, e.Destination)
 }
+
+// ChecksumMismatch - downloaded content does not match the source checksum.
+type ChecksumMismatch struct {
+	Expected string
+	Computed string
+}
+
+func (e ChecksumMismatch) Error() string {
+	return fmt.Sprintf("Checksum mismatch. Expected `%s`, but computed `%s`.", e.Expected, e.Computed)
+}
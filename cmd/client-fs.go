@@ -132,12 +132,9 @@ func (f *fsClient) GetURL() ClientURL {
 	return *f.PathURL
 }
 
-// Select replies a stream of query results.
+// Select replies a stream of query results, evaluated locally.
 func (f *fsClient) Select(ctx context.Context, expression string, sse encrypt.ServerSide, opts SelectObjectOpts) (io.ReadCloser, *probe.Error) {
-	return nil, probe.NewError(APINotImplemented{
-		API:     "Select",
-		APIType: "filesystem",
-	})
+	return selectLocal(ctx, f, expression, sse, opts)
 }
 
 // Watches for all fs events on an input path.
@@ -212,16 +209,18 @@ func (f *fsClient) Watch(ctx context.Context, options WatchOptions) (*WatchObjec
 					continue
 				}
 				eventChan <- []EventInfo{{
-					Time: UTCNow().Format(timeFormatFS),
-					Size: i.Size(),
-					Path: event.Path(),
-					Type: notification.ObjectCreatedPut,
+					Time:         UTCNow().Format(timeFormatFS),
+					Size:         i.Size(),
+					Path:         event.Path(),
+					Type:         notification.ObjectCreatedPut,
+					RenameCookie: renameCookie(event),
 				}}
 			} else if IsDeleteEvent(event.Event()) {
 				eventChan <- []EventInfo{{
-					Time: UTCNow().Format(timeFormatFS),
-					Path: event.Path(),
-					Type: notification.ObjectRemovedDelete,
+					Time:         UTCNow().Format(timeFormatFS),
+					Path:         event.Path(),
+					Type:         notification.ObjectRemovedDelete,
+					RenameCookie: renameCookie(event),
 				}}
 			} else if IsGetEvent(event.Event()) {
 				eventChan <- []EventInfo{{
@@ -442,6 +441,12 @@ func (f *fsClient) Get(ctx context.Context, opts GetOptions) (io.ReadCloser, *pr
 			return nil, err.Trace(f.PathURL.Path)
 		}
 	}
+	if opts.RangeEnd != 0 {
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(fileData, opts.RangeEnd-opts.RangeStart+1), fileData}, nil
+	}
 
 	return fileData, nil
 }
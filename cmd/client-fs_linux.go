@@ -27,6 +27,7 @@ import (
 
 	"github.com/pkg/xattr"
 	"github.com/rjeczalik/notify"
+	"golang.org/x/sys/unix"
 )
 
 var (
@@ -107,3 +108,15 @@ func getAllXattrs(path string) (map[string]string, error) {
 	}
 	return xMetadata, nil
 }
+
+// renameCookie returns the cookie pairing the two events of a rename,
+// zero for other events.
+func renameCookie(event notify.EventInfo) uint32 {
+	if event.Event()&(notify.InMovedFrom|notify.InMovedTo) == 0 {
+		return 0
+	}
+	if sys, ok := event.Sys().(*unix.InotifyEvent); ok {
+		return sys.Cookie
+	}
+	return 0
+}
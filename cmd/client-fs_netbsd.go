@@ -84,3 +84,9 @@ func getAllXattrs(path string) (map[string]string, error) {
 	}
 	return xMetadata, nil
 }
+
+// renameCookie returns the cookie pairing the two events of a rename,
+// they can't be paired on this platform.
+func renameCookie(event notify.EventInfo) uint32 {
+	return 0
+}
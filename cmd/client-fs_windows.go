@@ -59,3 +59,9 @@ func IsDeleteEvent(event notify.Event) bool {
 func getAllXattrs(path string) (map[string]string, error) {
 	return nil, nil
 }
+
+// renameCookie returns the cookie pairing the two events of a rename,
+// they can't be paired on this platform.
+func renameCookie(event notify.EventInfo) uint32 {
+	return 0
+}
@@ -34,6 +34,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -60,6 +61,7 @@ type S3Client struct {
 	sync.Mutex
 	targetURL    *ClientURL
 	api          *minio.Client
+	transport    http.RoundTripper
 	virtualStyle bool
 }
 
@@ -95,10 +97,11 @@ type dialContext func(ctx context.Context, network, addr string) (net.Conn, erro
 func newCustomDialContext(c *Config) dialContext {
 	return func(ctx context.Context, network, addr string) (net.Conn, error) {
 		dialer := &net.Dialer{
-			Timeout:   10 * time.Second,
+			Timeout:   c.TransportProfile.dialTimeout(),
 			KeepAlive: 15 * time.Second,
 		}
 
+		network, addr = c.TransportProfile.dialAddress(network, addr)
 		conn, err := dialer.DialContext(ctx, network, addr)
 		if err != nil {
 			return nil, err
@@ -117,6 +120,7 @@ var timeSentinel = time.Unix(0, 0).UTC()
 // newFactory encloses New function with client cache.
 func newFactory() func(config *Config) (Client, *probe.Error) {
 	clientCache := make(map[uint32]*minio.Client)
+	transportCache := make(map[uint32]http.RoundTripper)
 	var mutex sync.Mutex
 
 	// Return New function.
@@ -148,6 +152,7 @@ func newFactory() func(config *Config) (Client, *probe.Error) {
 		// Generate a hash out of s3Conf.
 		confHash := fnv.New32a()
 		confHash.Write([]byte(hostName + config.AccessKey + config.SecretKey + config.SessionToken))
+		confHash.Write([]byte(config.TransportProfile.hash()))
 		confSum := confHash.Sum32()
 
 		// Lookup previous cache by hash.
@@ -208,8 +213,12 @@ func newFactory() func(config *Config) (Client, *probe.Error) {
 					// 	return nil, probe.NewError(e)
 					// }
 				}
+				if err := config.TransportProfile.apply(tr); err != nil {
+					return nil, err.Trace(config.HostURL)
+				}
 				transport = tr
 			}
+			transport = config.TransportProfile.wrap(transport)
 
 			if config.Debug {
 				if strings.EqualFold(config.Signature, "S3v4") {
@@ -245,10 +254,12 @@ func newFactory() func(config *Config) (Client, *probe.Error) {
 
 			// Cache the new MinIO Client with hash of config as key.
 			clientCache[confSum] = api
+			transportCache[confSum] = transport
 		}
 
 		// Store the new api object.
 		s3Clnt.api = api
+		s3Clnt.transport = transportCache[confSum]
 
 		return s3Clnt, nil
 	}
@@ -852,8 +863,8 @@ func (c *S3Client) Get(ctx context.Context, opts GetOptions) (io.ReadCloser, *pr
 	if opts.Zip {
 		o.Set("x-minio-extract", "true")
 	}
-	if opts.RangeStart != 0 {
-		err := o.SetRange(opts.RangeStart, 0)
+	if opts.RangeStart != 0 || opts.RangeEnd != 0 {
+		err := o.SetRange(opts.RangeStart, opts.RangeEnd)
 		if err != nil {
 			return nil, probe.NewError(err)
 		}
@@ -938,6 +949,11 @@ func (c *S3Client) Copy(ctx context.Context, source string, opts CopyOptions, pr
 	destOpts.UserMetadata = metadata
 	destOpts.ReplaceMetadata = len(metadata) > 0
 
+	if opts.tags != nil {
+		destOpts.UserTags = opts.tags
+		destOpts.ReplaceTags = true
+	}
+
 	var e error
 	if opts.disableMultipart || opts.size < 64*1024*1024 {
 		_, e = c.api.CopyObject(ctx, destOpts, srcOpts)
@@ -1501,7 +1517,7 @@ func (c *S3Client) SetAccess(ctx context.Context, bucketPolicy string, isJSON bo
 }
 
 // listObjectWrapper - select ObjectList mode depending on arguments
-func (c *S3Client) listObjectWrapper(ctx context.Context, bucket, object string, isRecursive bool, timeRef time.Time, withVersions, withDeleteMarkers bool, metadata bool, maxKeys int, zip bool) <-chan minio.ObjectInfo {
+func (c *S3Client) listObjectWrapper(ctx context.Context, bucket, object string, isRecursive bool, timeRef time.Time, withVersions, withDeleteMarkers bool, metadata bool, maxKeys int, startAfter string, zip bool) <-chan minio.ObjectInfo {
 	if !timeRef.IsZero() || withVersions {
 		return c.listVersions(ctx, bucket, object, isRecursive, timeRef, withVersions, withDeleteMarkers)
 	}
@@ -1509,9 +1525,9 @@ func (c *S3Client) listObjectWrapper(ctx context.Context, bucket, object string,
 	if isGoogle(c.targetURL.Host) {
 		// Google Cloud S3 layer doesn't implement ListObjectsV2 implementation
 		// https://github.com/minio/mc/issues/3073
-		return c.api.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: object, Recursive: isRecursive, UseV1: true, MaxKeys: maxKeys})
+		return c.api.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: object, Recursive: isRecursive, UseV1: true, MaxKeys: maxKeys, StartAfter: startAfter})
 	}
-	opts := minio.ListObjectsOptions{Prefix: object, Recursive: isRecursive, WithMetadata: metadata, MaxKeys: maxKeys}
+	opts := minio.ListObjectsOptions{Prefix: object, Recursive: isRecursive, WithMetadata: metadata, MaxKeys: maxKeys, StartAfter: startAfter}
 	if zip {
 		// If prefix ends with .zip, add a slash.
 		if strings.HasSuffix(object, ".zip") {
@@ -1624,7 +1640,7 @@ func (c *S3Client) Stat(ctx context.Context, opts StatOptions) (*ClientContent,
 
 	nonRecursive := false
 	maxKeys := 1
-	for objectStat := range c.listObjectWrapper(ctx, bucket, path, nonRecursive, opts.timeRef, false, false, false, maxKeys, opts.isZip) {
+	for objectStat := range c.listObjectWrapper(ctx, bucket, path, nonRecursive, opts.timeRef, false, false, false, maxKeys, "", opts.isZip) {
 		if objectStat.Err != nil {
 			return nil, probe.NewError(objectStat.Err)
 		}
@@ -1675,6 +1691,49 @@ func (c *S3Client) getObjectStat(ctx context.Context, bucket, object string, opt
 	return objectMetadata, nil
 }
 
+// getPartSize returns the size of a single part of a multipart object.
+func (c *S3Client) getPartSize(ctx context.Context, versionID string, sse encrypt.ServerSide, partNumber int) (int64, *probe.Error) {
+	bucket, object := c.url2BucketAndObject()
+	// StatObject does not send the part number, issue a presigned
+	// HEAD request on that part and read its content length instead.
+	params := make(url.Values)
+	params.Set("partNumber", strconv.Itoa(partNumber))
+	if versionID != "" {
+		params.Set("versionId", versionID)
+	}
+	u, e := c.api.Presign(ctx, http.MethodHead, bucket, object, 5*time.Minute, params)
+	if e != nil {
+		return 0, probe.NewError(e)
+	}
+	req, e := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if e != nil {
+		return 0, probe.NewError(e)
+	}
+	if sse != nil {
+		sse.Marshal(req.Header)
+	}
+	resp, e := (&http.Client{Transport: c.transport}).Do(req)
+	if e != nil {
+		return 0, probe.NewError(e)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		code := http.StatusText(resp.StatusCode)
+		if resp.StatusCode == http.StatusNotFound {
+			code = "NoSuchKey"
+		}
+		return 0, probe.NewError(minio.ErrorResponse{
+			StatusCode: resp.StatusCode,
+			Code:       code,
+			Message:    "Unable to read the size of part " + strconv.Itoa(partNumber),
+			BucketName: bucket,
+			Key:        object,
+			RequestID:  resp.Header.Get("x-amz-request-id"),
+		})
+	}
+	return resp.ContentLength, nil
+}
+
 func isAmazon(host string) bool {
 	return s3utils.IsAmazonEndpoint(url.URL{Host: host})
 }
@@ -2100,6 +2159,7 @@ func (c *S3Client) objectInfo2ClientContent(bucket string, entry minio.ObjectInf
 	content.IsDeleteMarker = entry.IsDeleteMarker
 	content.IsLatest = entry.IsLatest
 	content.Restore = entry.Restore
+	content.Tags = entry.UserTags
 	content.Metadata = map[string]string{}
 	content.UserMetadata = map[string]string{}
 	content.ReplicationStatus = entry.ReplicationStatus
@@ -2180,7 +2240,7 @@ func (c *S3Client) listInRoutine(ctx context.Context, contentCh chan *ClientCont
 		contentCh <- content
 	default:
 		isRecursive := false
-		for object := range c.listObjectWrapper(ctx, b, o, isRecursive, time.Time{}, false, false, opts.WithMetadata, -1, opts.ListZip) {
+		for object := range c.listObjectWrapper(ctx, b, o, isRecursive, time.Time{}, false, false, opts.WithMetadata, -1, opts.StartAfter, opts.ListZip) {
 			if object.Err != nil {
 				contentCh <- &ClientContent{
 					Err: probe.NewError(object.Err),
@@ -2239,7 +2299,7 @@ func (c *S3Client) listRecursiveInRoutine(ctx context.Context, contentCh chan *C
 			}
 
 			isRecursive := true
-			for object := range c.listObjectWrapper(ctx, bucket.Name, o, isRecursive, time.Time{}, false, false, opts.WithMetadata, -1, opts.ListZip) {
+			for object := range c.listObjectWrapper(ctx, bucket.Name, o, isRecursive, time.Time{}, false, false, opts.WithMetadata, -1, opts.StartAfter, opts.ListZip) {
 				if object.Err != nil {
 					contentCh <- &ClientContent{
 						Err: probe.NewError(object.Err),
@@ -2255,7 +2315,7 @@ func (c *S3Client) listRecursiveInRoutine(ctx context.Context, contentCh chan *C
 		}
 	default:
 		isRecursive := true
-		for object := range c.listObjectWrapper(ctx, b, o, isRecursive, time.Time{}, false, false, opts.WithMetadata, -1, opts.ListZip) {
+		for object := range c.listObjectWrapper(ctx, b, o, isRecursive, time.Time{}, false, false, opts.WithMetadata, -1, opts.StartAfter, opts.ListZip) {
 			if object.Err != nil {
 				contentCh <- &ClientContent{
 					Err: probe.NewError(object.Err),
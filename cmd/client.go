@@ -49,6 +49,9 @@ type GetOptions struct {
 	VersionID  string
 	Zip        bool
 	RangeStart int64
+	// RangeEnd is the inclusive offset of the last byte to read,
+	// zero reads until the end of the object.
+	RangeEnd int64
 }
 
 // PutOptions holds options for PUT operation
@@ -83,6 +86,7 @@ type ListOptions struct {
 	TimeRef           time.Time
 	ShowDir           DirOpt
 	Count             int
+	StartAfter        string
 }
 
 // CopyOptions holds options for copying operation
@@ -94,6 +98,8 @@ type CopyOptions struct {
 	disableMultipart bool
 	isPreserve       bool
 	storageClass     string
+	// tags replace the tags of the source object when not nil.
+	tags map[string]string
 }
 
 // Client - client interface
@@ -187,6 +193,7 @@ type ClientContent struct {
 	UserMetadata map[string]string
 	ETag         string
 	Expires      time.Time
+	Tags         map[string]string
 
 	Expiration       time.Time
 	ExpirationRuleID string
@@ -222,6 +229,7 @@ type Config struct {
 	ConnReadDeadline  time.Duration
 	ConnWriteDeadline time.Duration
 	Transport         *http.Transport
+	TransportProfile  *aliasTransportV10
 }
 
 // SelectObjectOpts - opts entered for select API
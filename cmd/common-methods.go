@@ -35,6 +35,7 @@ import (
 
 	"github.com/dustin/go-humanize"
 	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/hookreader"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/encrypt"
@@ -481,8 +482,10 @@ func uploadSourceToTargetURL(ctx context.Context, urls URLs, progress io.Reader,
 		metadata[http.CanonicalHeaderKey(k)] = v
 	}
 
-	// Optimize for server side copy if the host is same.
-	if sourceAlias == targetAlias && !isZip {
+	// Optimize for server side copy if the host is same, unless the
+	// object must be compressed on the way.
+	srcCodec, _ := compressionOf(urls.SourceContent.UserMetadata, urls.SourceContent.Metadata)
+	if sourceAlias == targetAlias && !isZip && (urls.Compress == "" || srcCodec != "") {
 		// preserve new metadata and save existing ones.
 		if preserve {
 			currentMetadata, err := getAllMetadata(ctx, sourceAlias, sourceURL.String(), srcSSE, urls)
@@ -548,6 +551,15 @@ func uploadSourceToTargetURL(ctx context.Context, urls URLs, progress io.Reader,
 			return urls.WithError(err.Trace(sourceURL.String()))
 		}
 
+		downloadOpts, err := getParallelDownloadOpts()
+		if err != nil {
+			return urls.WithError(err.Trace(sourceURL.String()))
+		}
+		if isParallelDownload(urls, downloadOpts, isZip) {
+			err = parallelDownloadSourceToTargetURL(ctx, urls, srcSSE, preserve, progress, downloadOpts)
+			return urls.WithError(err)
+		}
+
 		var reader io.ReadCloser
 		// Proceed with regular stream copy.
 		reader, metadata, err = getSourceStream(ctx, sourceAlias, sourceURL.String(), getSourceOpts{
@@ -599,13 +611,40 @@ func uploadSourceToTargetURL(ctx context.Context, urls URLs, progress io.Reader,
 			multipartThreads: uint(multipartThreads),
 		}
 
-		if isReadAt(reader) {
-			_, err = putTargetStream(ctx, targetAlias, targetURL.String(), mode, until,
-				legalHold, reader, length, progress, putOpts)
-		} else {
-			_, err = putTargetStream(ctx, targetAlias, targetURL.String(), mode, until,
-				legalHold, io.LimitReader(reader, length), length, progress, putOpts)
+		var source io.Reader = reader
+		if !isReadAt(reader) {
+			source = io.LimitReader(reader, length)
+		}
+		codec, uncompressedSize := compressionOf(metadata)
+		switch {
+		case codec != "" && targetAlias == "":
+			// Decompress objects downloaded to a local path, the
+			// progress follows the object downloaded.
+			var rc io.ReadCloser
+			rc, err = newDecompressReader(io.NopCloser(hookreader.NewHook(source, progress)), codec)
+			if err != nil {
+				return urls.WithError(err.Trace(sourceURL.String()))
+			}
+			defer rc.Close()
+			dropCompressMetadata(putOpts.metadata)
+			source, length, progress = rc, uncompressedSize, nil
+		case codec == "" && urls.Compress != "" && targetAlias != "":
+			// Compress the object uploaded, the progress follows the
+			// source read.
+			var rc io.ReadCloser
+			rc, err = newCompressReader(hookreader.NewHook(source, progress), urls.Compress)
+			if err != nil {
+				return urls.WithError(err.Trace(sourceURL.String()))
+			}
+			defer rc.Close()
+			setCompressMetadata(putOpts.metadata, urls.Compress, length)
+			if putOpts.multipartSize == 0 {
+				putOpts.multipartSize = compressPartSize(length)
+			}
+			source, length, progress = rc, -1, nil
 		}
+		_, err = putTargetStream(ctx, targetAlias, targetURL.String(), mode, until,
+			legalHold, source, length, progress, putOpts)
 	}
 	if err != nil {
 		return urls.WithError(err.Trace(sourceURL.String()))
@@ -0,0 +1,240 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+// Objects compressed by mc carry the codec and their uncompressed size
+// in their user metadata, their content is decompressed when they are
+// downloaded to a local path.
+const (
+	compressMetaCodec = "X-Amz-Meta-Mc-Compression"
+	compressMetaSize  = "X-Amz-Meta-Mc-Uncompressed-Size"
+)
+
+// compressCodecs are the codecs of --compress.
+var compressCodecs = []string{"zstd", "gzip", "s2"}
+
+// compressFlag compresses the uploaded objects client-side.
+var compressFlag = cli.StringFlag{
+	Name:  "compress",
+	Usage: "compress objects uploaded to object storage with CODEC: " + strings.Join(compressCodecs, ", "),
+}
+
+// logicalSizeFlag shows the uncompressed size of compressed objects.
+var logicalSizeFlag = cli.BoolFlag{
+	Name:  "logical-size",
+	Usage: "show the uncompressed size of the objects compressed with --compress",
+}
+
+// checkCompressCodec verifies the codec of --compress.
+func checkCompressCodec(codec string) *probe.Error {
+	for _, c := range compressCodecs {
+		if codec == c {
+			return nil
+		}
+	}
+	return errInvalidArgument().Trace(codec, "expected one of "+strings.Join(compressCodecs, ", "))
+}
+
+// checkCompressTarget verifies that the objects uploaded to the aliased
+// target url can be compressed with codec, if any.
+func checkCompressTarget(codec, targetURL string) *probe.Error {
+	if codec == "" {
+		return nil
+	}
+	if err := checkCompressCodec(codec); err != nil {
+		return err
+	}
+	if alias, _ := url2Alias(targetURL); alias == "" {
+		return errInvalidArgument().Trace(targetURL, "--compress requires a target on object storage")
+	}
+	return nil
+}
+
+// compressionOf returns the codec and the uncompressed size of an object
+// compressed by mc from its metadata, the codec is empty if the object
+// is not compressed and the size is -1 if unknown.
+func compressionOf(metadata ...map[string]string) (codec string, size int64) {
+	size = -1
+	for _, m := range metadata {
+		for k, v := range m {
+			switch {
+			case isMetaKey(k, compressMetaCodec):
+				codec = v
+			case isMetaKey(k, compressMetaSize):
+				if n, e := strconv.ParseInt(v, 10, 64); e == nil && n >= 0 {
+					size = n
+				}
+			}
+		}
+	}
+	return codec, size
+}
+
+// isMetaKey returns true if k is the user metadata key, with or without
+// its X-Amz-Meta- prefix.
+func isMetaKey(k, key string) bool {
+	return strings.EqualFold(k, key) || strings.EqualFold(k, strings.TrimPrefix(key, "X-Amz-Meta-"))
+}
+
+// logicalSize returns the uncompressed size of an object if it is known,
+// its size otherwise.
+func logicalSize(content *ClientContent) int64 {
+	if codec, size := compressionOf(content.UserMetadata, content.Metadata); codec != "" && size >= 0 {
+		return size
+	}
+	return content.Size
+}
+
+// setCompressMetadata records the compression of an object in its metadata.
+func setCompressMetadata(metadata map[string]string, codec string, size int64) {
+	metadata[compressMetaCodec] = codec
+	if size >= 0 {
+		metadata[compressMetaSize] = strconv.FormatInt(size, 10)
+	}
+}
+
+// dropCompressMetadata removes the compression of an object from its metadata.
+func dropCompressMetadata(metadata map[string]string) {
+	for k := range metadata {
+		if isMetaKey(k, compressMetaCodec) || isMetaKey(k, compressMetaSize) {
+			delete(metadata, k)
+		}
+	}
+}
+
+// compressPartSize returns the multipart size of the upload of size bytes
+// compressed, whose final size is not known: large enough for the object
+// to fit in the parts allowed even if it does not compress.
+func compressPartSize(size int64) uint64 {
+	const minPartSize, maxParts = 16 << 20, 9000
+	if size < 0 {
+		return 64 << 20
+	}
+	if partSize := uint64(size/maxParts + 1); partSize > minPartSize {
+		return partSize
+	}
+	return minPartSize
+}
+
+// newCompressWriter returns a writer compressing to w with codec.
+func newCompressWriter(w io.Writer, codec string) (io.WriteCloser, *probe.Error) {
+	switch codec {
+	case "zstd":
+		zw, e := zstd.NewWriter(w)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		return zw, nil
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "s2":
+		return s2.NewWriter(w), nil
+	}
+	return nil, checkCompressCodec(codec)
+}
+
+// newCompressReader returns the content of r compressed with codec.
+func newCompressReader(r io.Reader, codec string) (io.ReadCloser, *probe.Error) {
+	pr, pw := io.Pipe()
+	w, err := newCompressWriter(pw, codec)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		_, e := io.Copy(w, r)
+		if ce := w.Close(); e == nil {
+			e = ce
+		}
+		pw.CloseWithError(e)
+	}()
+	return pr, nil
+}
+
+// decompressReader decompresses a source closed along with it.
+type decompressReader struct {
+	io.ReadCloser
+	source io.Closer
+}
+
+// Close closes the decompressor and its source.
+func (d decompressReader) Close() error {
+	d.ReadCloser.Close()
+	return d.source.Close()
+}
+
+// newDecompressReader returns the content of source decompressed with codec.
+func newDecompressReader(source io.ReadCloser, codec string) (io.ReadCloser, *probe.Error) {
+	var rc io.ReadCloser
+	switch codec {
+	case "zstd":
+		zr, e := zstd.NewReader(source)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		rc = zr.IOReadCloser()
+	case "gzip":
+		gr, e := gzip.NewReader(source)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		rc = gr
+	case "s2":
+		rc = io.NopCloser(s2.NewReader(source))
+	default:
+		return nil, errInvalidArgument().Trace(codec, "unknown compression of the object")
+	}
+	return decompressReader{ReadCloser: rc, source: source}, nil
+}
+
+// decompressFile decompresses the file src compressed with codec into dst.
+func decompressFile(src, dst, codec string) *probe.Error {
+	f, e := os.Open(src)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	rc, err := newDecompressReader(f, codec)
+	if err != nil {
+		f.Close()
+		return err.Trace(src)
+	}
+	defer rc.Close()
+
+	w, e := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o666)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if _, e = io.Copy(w, rc); e != nil {
+		w.Close()
+		os.Remove(dst)
+		return probe.NewError(e).Trace(src, dst)
+	}
+	return probe.NewError(w.Close())
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressionOf(t *testing.T) {
+	testCases := []struct {
+		metadata []map[string]string
+		codec    string
+		size     int64
+	}{
+		{nil, "", -1},
+		{[]map[string]string{{"Content-Type": "text/plain"}}, "", -1},
+		{[]map[string]string{{compressMetaCodec: "zstd", compressMetaSize: "1024"}}, "zstd", 1024},
+		// User metadata of a HEAD request, without the prefix.
+		{[]map[string]string{{"Mc-Compression": "s2", "Mc-Uncompressed-Size": "10"}}, "s2", 10},
+		{[]map[string]string{{"x-amz-meta-mc-compression": "gzip"}}, "gzip", -1},
+		{[]map[string]string{{compressMetaCodec: "zstd", compressMetaSize: "-4"}}, "zstd", -1},
+		{[]map[string]string{{"Content-Type": "text/plain"}, {compressMetaCodec: "gzip", compressMetaSize: "7"}}, "gzip", 7},
+	}
+	for i, tc := range testCases {
+		codec, size := compressionOf(tc.metadata...)
+		if codec != tc.codec || size != tc.size {
+			t.Fatalf("Test %d: expected (%q, %d), got (%q, %d)", i+1, tc.codec, tc.size, codec, size)
+		}
+	}
+}
+
+func TestCompressMetadata(t *testing.T) {
+	metadata := map[string]string{"Content-Type": "text/plain"}
+	setCompressMetadata(metadata, "zstd", 42)
+	if codec, size := compressionOf(metadata); codec != "zstd" || size != 42 {
+		t.Fatalf("expected (zstd, 42), got (%q, %d)", codec, size)
+	}
+	dropCompressMetadata(metadata)
+	if len(metadata) != 1 || metadata["Content-Type"] != "text/plain" {
+		t.Fatalf("unexpected metadata %v", metadata)
+	}
+
+	setCompressMetadata(metadata, "s2", -1)
+	if _, ok := metadata[compressMetaSize]; ok {
+		t.Fatalf("unexpected uncompressed size in %v", metadata)
+	}
+}
+
+func TestLogicalSize(t *testing.T) {
+	testCases := []struct {
+		content *ClientContent
+		size    int64
+	}{
+		{&ClientContent{Size: 10}, 10},
+		{&ClientContent{Size: 10, UserMetadata: map[string]string{compressMetaCodec: "zstd", compressMetaSize: "100"}}, 100},
+		{&ClientContent{Size: 10, Metadata: map[string]string{compressMetaCodec: "zstd"}}, 10},
+		{&ClientContent{Size: 10, UserMetadata: map[string]string{compressMetaSize: "100"}}, 10},
+	}
+	for i, tc := range testCases {
+		if size := logicalSize(tc.content); size != tc.size {
+			t.Fatalf("Test %d: expected %d, got %d", i+1, tc.size, size)
+		}
+	}
+}
+
+func TestCompressPartSize(t *testing.T) {
+	testCases := []struct {
+		size     int64
+		partSize uint64
+	}{
+		{-1, 64 << 20},
+		{0, 16 << 20},
+		{1 << 30, 16 << 20},
+		{1 << 40, 1<<40/9000 + 1},
+	}
+	for i, tc := range testCases {
+		if partSize := compressPartSize(tc.size); partSize != tc.partSize {
+			t.Fatalf("Test %d: expected %d, got %d", i+1, tc.partSize, partSize)
+		}
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("mc compresses this line\n"), 4096)
+	for _, codec := range compressCodecs {
+		compressed, err := newCompressReader(bytes.NewReader(data), codec)
+		if err != nil {
+			t.Fatalf("%s: %v", codec, err)
+		}
+		stored, e := io.ReadAll(compressed)
+		if e != nil {
+			t.Fatalf("%s: %v", codec, e)
+		}
+		if len(stored) >= len(data) {
+			t.Fatalf("%s: expected less than %d bytes, got %d", codec, len(data), len(stored))
+		}
+
+		rc, err := newDecompressReader(io.NopCloser(bytes.NewReader(stored)), codec)
+		if err != nil {
+			t.Fatalf("%s: %v", codec, err)
+		}
+		got, e := io.ReadAll(rc)
+		rc.Close()
+		if e != nil {
+			t.Fatalf("%s: %v", codec, e)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%s: decompressed content differs", codec)
+		}
+	}
+
+	if _, err := newCompressReader(bytes.NewReader(data), "lz4"); err == nil {
+		t.Fatal("expected an error for an unknown codec")
+	}
+	if _, err := newDecompressReader(io.NopCloser(bytes.NewReader(data)), "lz4"); err == nil {
+		t.Fatal("expected an error for an unknown codec")
+	}
+}
+
+func TestDecompressFile(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("hello, compressed world\n")
+
+	compressed, err := newCompressReader(bytes.NewReader(data), "gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, e := io.ReadAll(compressed)
+	if e != nil {
+		t.Fatal(e)
+	}
+	src, dst := filepath.Join(dir, "part"), filepath.Join(dir, "object")
+	if e = os.WriteFile(src, stored, 0o600); e != nil {
+		t.Fatal(e)
+	}
+	if err = decompressFile(src, dst, "gzip"); err != nil {
+		t.Fatal(err)
+	}
+	got, e := os.ReadFile(dst)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("expected %q, got %q", data, got)
+	}
+}
+
+func TestCheckCompressTarget(t *testing.T) {
+	if err := checkCompressTarget("", "/tmp/local"); err != nil {
+		t.Fatalf("expected no error without codec, got %v", err)
+	}
+	if err := checkCompressTarget("zstd", "/tmp/local"); err == nil {
+		t.Fatal("expected an error for a local target")
+	}
+	if err := checkCompressCodec("lz4"); err == nil {
+		t.Fatal("expected an error for an unknown codec")
+	}
+	for _, codec := range compressCodecs {
+		if err := checkCompressCodec(codec); err != nil {
+			t.Fatalf("%s: %v", codec, err)
+		}
+	}
+}
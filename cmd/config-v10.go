@@ -46,12 +46,20 @@ type aliasConfigV10 struct {
 	Path         string `json:"path"`
 	License      string `json:"license,omitempty"`
 	APIKey       string `json:"apiKey,omitempty"`
+
+	// Secrets starting with 'cmd:' are only run as commands when set.
+	SecretCommands bool `json:"secretCommands,omitempty"`
+
+	Transport *aliasTransportV10 `json:"transport,omitempty"`
 }
 
 // configV10 config version.
 type configV10 struct {
-	Version string                    `json:"version"`
-	Aliases map[string]aliasConfigV10 `json:"aliases"`
+	Version    string                    `json:"version"`
+	Encryption *configEncryptionV10      `json:"encryption,omitempty"`
+	Aliases    map[string]aliasConfigV10 `json:"aliases"`
+	Groups     map[string][]string       `json:"groups,omitempty"`
+	Protected  []string                  `json:"protected,omitempty"`
 }
 
 // newConfigV10 - new config version.
@@ -185,6 +185,9 @@ func getAliasConfig(alias string) (*aliasConfigV10, *probe.Error) {
 	// if host is exact return quickly.
 	if _, ok := mcCfg.Aliases[alias]; ok {
 		hostCfg := mcCfg.Aliases[alias]
+		if err = resolveAliasSecrets(mcCfg.Encryption, alias, &hostCfg, true); err != nil {
+			return nil, err.Trace(alias)
+		}
 		return &hostCfg, nil
 	}
 
@@ -194,7 +197,12 @@ func getAliasConfig(alias string) (*aliasConfigV10, *probe.Error) {
 
 // mustGetHostConfig retrieves host specific configuration such as access keys, signature type.
 func mustGetHostConfig(alias string) *aliasConfigV10 {
-	aliasCfg, _ := getAliasConfig(alias)
+	aliasCfg, err := getAliasConfig(alias)
+	if err != nil {
+		if _, ok := err.ToGoError().(secretUnavailableErr); ok {
+			fatalIf(err.Trace(alias), "Unable to read the credentials of alias `"+alias+"`.")
+		}
+	}
 	// If alias is not found,
 	// look for it in the environment variable.
 	if aliasCfg == nil {
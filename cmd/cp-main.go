@@ -106,6 +106,7 @@ var (
 			Name:  "zip",
 			Usage: "Extract from remote zip file (MinIO server source only)",
 		},
+		compressFlag,
 	}
 )
 
@@ -125,7 +126,7 @@ var cpCmd = cli.Command{
 	Action:       mainCopy,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(cpFlags, ioFlags...), globalFlags...),
+	Flags:        append(append(append(cpFlags, parallelFlags...), ioFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -136,8 +137,10 @@ FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
 ENVIRONMENT VARIABLES:
-  MC_ENCRYPT:      list of comma delimited prefixes
-  MC_ENCRYPT_KEY:  list of comma delimited prefix=secret values
+  MC_ENCRYPT:                     list of comma delimited prefixes
+  MC_ENCRYPT_KEY:                 list of comma delimited prefix=secret values
+  MC_DOWNLOAD_MULTIPART_SIZE:     part size of parallel downloads to local filesystem, defaults to 64MiB
+  MC_DOWNLOAD_MULTIPART_THREADS:  number of concurrent ranged GETs per download, 1 disables, defaults to 4
 
 EXAMPLES:
   01. Copy a list of objects from local file system to Amazon S3 cloud storage.
@@ -201,6 +204,9 @@ EXAMPLES:
   20. Set tags to the uploaded objects
       {{.Prompt}} {{.HelpName}} -r --tags "category=prod&type=backup" ./data/ play/another-bucket/
 
+  21. Copy logs compressed with zstd, they are decompressed when copied back to a local path.
+      {{.Prompt}} {{.HelpName}} -r --compress zstd /var/log/app/ play/logs/app/
+
 `,
 }
 
@@ -212,6 +218,7 @@ type copyMessage struct {
 	Size       int64  `json:"size"`
 	TotalCount int64  `json:"totalCount"`
 	TotalSize  int64  `json:"totalSize"`
+	Workers    int    `json:"workers,omitempty"`
 }
 
 // String colorized copy message
@@ -243,7 +250,7 @@ type ProgressReader interface {
 }
 
 // doCopy - Copy a single file from source to destination
-func doCopy(ctx context.Context, cpURLs URLs, pg ProgressReader, encKeyDB map[string][]prefixSSEPair, isMvCmd bool, preserve, isZip bool) URLs {
+func doCopy(ctx context.Context, cpURLs URLs, pg ProgressReader, encKeyDB map[string][]prefixSSEPair, isMvCmd bool, preserve, isZip bool, workers int) URLs {
 	if cpURLs.Error != nil {
 		cpURLs.Error = cpURLs.Error.Trace()
 		return cpURLs
@@ -266,6 +273,7 @@ func doCopy(ctx context.Context, cpURLs URLs, pg ProgressReader, encKeyDB map[st
 			Size:       length,
 			TotalCount: cpURLs.TotalCount,
 			TotalSize:  cpURLs.TotalSize,
+			Workers:    workers,
 		})
 	}
 
@@ -481,7 +489,10 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 	quitCh := make(chan struct{})
 	statusCh := make(chan URLs)
 
-	parallel := newParallelManager(statusCh)
+	parallel := newParallelManager(statusCh, parseParallelOptions(cli))
+	if progressReader, ok := pg.(*progressBar); ok {
+		parallel.setResizeHook(progressReader.SetWorkers)
+	}
 
 	go func() {
 		gracefulStop := func() {
@@ -544,6 +555,7 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 
 				cpURLs.MD5 = cli.Bool("md5") || withLock
 				cpURLs.DisableMultipart = cli.Bool("disable-multipart")
+				cpURLs.Compress = cli.String("compress")
 
 				// Verify if previously copied, notify progress bar.
 				if isCopied != nil && isCopied(cpURLs.SourceContent.URL.String()) {
@@ -551,9 +563,9 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 						return doCopyFake(ctx, cpURLs, pg)
 					}, 0)
 				} else {
-					parallel.queueTask(func() URLs {
-						return doCopy(ctx, cpURLs, pg, encKeyDB, isMvCmd, preserve, isZip)
-					}, cpURLs.SourceContent.Size)
+					parallel.queueCopyTask(func() URLs {
+						return doCopy(ctx, cpURLs, pg, encKeyDB, isMvCmd, preserve, isZip, parallel.workers())
+					}, cpURLs)
 				}
 			}
 		}
@@ -702,6 +714,7 @@ func mainCopy(cliCtx *cli.Context) error {
 			session.Header.CommandStringFlags["newer-than"] = newerThan
 			session.Header.CommandStringFlags["storage-class"] = storageClass
 			session.Header.CommandStringFlags["tags"] = tags
+			session.Header.CommandStringFlags["compress"] = cliCtx.String("compress")
 			session.Header.CommandStringFlags[rmFlag] = retentionMode
 			session.Header.CommandStringFlags[rdFlag] = retentionDuration
 			session.Header.CommandStringFlags[lhFlag] = legalHold
@@ -57,6 +57,13 @@ func checkCopySyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[stri
 		fatalIf(errDummy().Trace(cliCtx.Args()...), "--zip and --rewind cannot be used together")
 	}
 
+	if codec := cliCtx.String("compress"); codec != "" {
+		fatalIf(checkCompressTarget(codec, tgtURL), "Unable to compress the objects copied to `"+tgtURL+"`.")
+		if cliCtx.Bool("disable-multipart") {
+			fatalIf(errDummy().Trace(cliCtx.Args()...), "--compress and --disable-multipart cannot be used together")
+		}
+	}
+
 	// Verify if source(s) exists.
 	for _, srcURL := range srcURLs {
 		var err *probe.Error
@@ -0,0 +1,347 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+// deleteGuardFlags are the flags of the commands removing objects in bulk.
+var deleteGuardFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "max-delete",
+		Usage: "abort without removing anything if more than N objects, or P% of the objects, would be removed",
+	},
+	cli.StringFlag{
+		Name:  "backup-dir",
+		Usage: "copy objects server-side under this prefix before removing them, e.g. alias/trash/",
+	},
+}
+
+// backupStampFormat names the directory of each run under --backup-dir.
+const backupStampFormat = "20060102T150405Z"
+
+// deleteGuard enforces the deletion safeguards: the --max-delete
+// threshold, the protected prefixes of the config and --backup-dir.
+// A nil guard allows everything.
+type deleteGuard struct {
+	// maxCount is the maximum number of removals, -1 if unlimited.
+	maxCount int64
+	// maxPercent is the maximum percentage of objects removed, 0 if unlimited.
+	maxPercent float64
+	protected  []string
+	backupDir  string
+
+	// targetObjects counts the objects found in the target.
+	targetObjects int64
+}
+
+// parseMaxDelete parses the value of --max-delete, either a number of
+// objects or a percentage of the objects.
+func parseMaxDelete(s string) (maxCount int64, maxPercent float64, e error) {
+	maxCount = -1
+	if s == "" {
+		return maxCount, 0, nil
+	}
+	if p := strings.TrimSuffix(s, "%"); p != s {
+		maxPercent, e = strconv.ParseFloat(p, 64)
+		if e != nil || maxPercent <= 0 || maxPercent > 100 {
+			return maxCount, 0, fmt.Errorf("invalid percentage `%s`, expected a value in (0, 100]", s)
+		}
+		return maxCount, maxPercent, nil
+	}
+	maxCount, e = strconv.ParseInt(s, 10, 64)
+	if e != nil || maxCount < 0 {
+		return -1, 0, fmt.Errorf("invalid value `%s`, expected a number of objects or a percentage", s)
+	}
+	return maxCount, 0, nil
+}
+
+// protectedPath returns the form under which a path is protected and
+// matched: the cleaned aliased path, or the absolute path for local paths.
+func protectedPath(alias, p string) string {
+	if alias == "" {
+		if abs, e := filepath.Abs(p); e == nil {
+			p = abs
+		}
+		return path.Clean(filepath.ToSlash(p))
+	}
+	return path.Clean(path.Join(alias, filepath.ToSlash(p)))
+}
+
+// normalizeProtectedPath returns the form of an aliased or local path
+// given by the user, as stored in the protected prefixes of the config.
+func normalizeProtectedPath(aliasedPath string) string {
+	alias, _, _ := mustExpandAlias(aliasedPath)
+	if alias == "" {
+		return protectedPath("", aliasedPath)
+	}
+	return protectedPath(alias, strings.TrimPrefix(filepath.ToSlash(aliasedPath), alias))
+}
+
+// newDeleteGuard returns the guard for the deletion flags of the command
+// and the protected prefixes of the config.
+func newDeleteGuard(cliCtx *cli.Context) (*deleteGuard, *probe.Error) {
+	g := &deleteGuard{}
+	var e error
+	g.maxCount, g.maxPercent, e = parseMaxDelete(cliCtx.String("max-delete"))
+	if e != nil {
+		return nil, probe.NewError(e).Trace(cliCtx.String("max-delete"))
+	}
+	if conf, err := loadMcConfig(); err == nil {
+		g.protected = conf.Protected
+	}
+	if backupDir := cliCtx.String("backup-dir"); backupDir != "" {
+		g.backupDir = path.Join(filepath.ToSlash(backupDir), UTCNow().Format(backupStampFormat))
+	}
+	return g, nil
+}
+
+// hasLimit returns true if the number of removals is limited.
+func (g *deleteGuard) hasLimit() bool {
+	return g != nil && (g.maxCount >= 0 || g.maxPercent > 0)
+}
+
+// limit returns the limit in a readable form.
+func (g *deleteGuard) limit() string {
+	if g.maxPercent > 0 {
+		return strconv.FormatFloat(g.maxPercent, 'f', -1, 64) + "%"
+	}
+	return strconv.FormatInt(g.maxCount, 10)
+}
+
+// exceeded returns true if removing deletes out of total objects
+// is above the limit.
+func (g *deleteGuard) exceeded(deletes, total int64) bool {
+	if !g.hasLimit() {
+		return false
+	}
+	if g.maxCount >= 0 && deletes > g.maxCount {
+		return true
+	}
+	if g.maxPercent > 0 && deletes > 0 {
+		if total < deletes {
+			total = deletes
+		}
+		return float64(deletes)*100 > g.maxPercent*float64(total)
+	}
+	return false
+}
+
+// countTarget records an object found in the target.
+func (g *deleteGuard) countTarget() {
+	if g != nil {
+		atomic.AddInt64(&g.targetObjects, 1)
+	}
+}
+
+// targetTotal returns the number of objects found in the target.
+func (g *deleteGuard) targetTotal() int64 {
+	if g == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&g.targetObjects)
+}
+
+// isProtected returns true if the object at path p of alias is under a
+// protected prefix.
+func (g *deleteGuard) isProtected(alias, p string) bool {
+	if g == nil || len(g.protected) == 0 {
+		return false
+	}
+	p = protectedPath(alias, p)
+	for _, prefix := range g.protected {
+		if p == prefix || strings.HasPrefix(p, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsProtected returns true if removing everything under path p of
+// alias, like a whole bucket, would remove a protected object.
+func (g *deleteGuard) overlapsProtected(alias, p string) bool {
+	if g == nil {
+		return false
+	}
+	if g.isProtected(alias, p) {
+		return true
+	}
+	p = protectedPath(alias, p)
+	for _, prefix := range g.protected {
+		if strings.HasPrefix(prefix, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRemoveBucket returns an error if the bucket at path p of alias
+// must not be removed: its objects are neither counted against the limit
+// nor backed up, and it may contain protected objects.
+func (g *deleteGuard) checkRemoveBucket(alias, p string) *probe.Error {
+	if g == nil {
+		return nil
+	}
+	if g.overlapsProtected(alias, p) {
+		return errDeleteProtected(protectedPath(alias, p))
+	}
+	if g.hasLimit() || g.backupDir != "" {
+		return errDeleteBucketGuarded(protectedPath(alias, p))
+	}
+	return nil
+}
+
+// checkBackupDir verifies that objects removed under the aliased target
+// can be copied server-side to the backup directory.
+func (g *deleteGuard) checkBackupDir(target string) *probe.Error {
+	if g == nil || g.backupDir == "" {
+		return nil
+	}
+	backupAlias, _, _ := mustExpandAlias(g.backupDir)
+	targetAlias, _, _ := mustExpandAlias(target)
+	if backupAlias != targetAlias {
+		return errInvalidBackupDir(g.backupDir, "it must be on the same alias as `"+target+"` for a server-side copy")
+	}
+	backupDir, target := normalizeProtectedPath(g.backupDir), normalizeProtectedPath(target)
+	if backupDir == target || strings.HasPrefix(backupDir, strings.TrimSuffix(target, "/")+"/") {
+		return errInvalidBackupDir(g.backupDir, "it is inside `"+target+"`")
+	}
+	return nil
+}
+
+// backup copies the object server-side under the backup directory,
+// keeping its path: alias/bucket/key is copied to BACKUP-DIR/STAMP/bucket/key.
+func (g *deleteGuard) backup(ctx context.Context, alias string, content *ClientContent, encKeyDB map[string][]prefixSSEPair) *probe.Error {
+	if g == nil || g.backupDir == "" || content.IsDeleteMarker || content.Type.IsDir() {
+		return nil
+	}
+	backupAlias, _, _ := mustExpandAlias(g.backupDir)
+	if backupAlias != alias {
+		return errInvalidBackupDir(g.backupDir, "it must be on the same alias as the removed objects for a server-side copy")
+	}
+	srcPath := filepath.ToSlash(content.URL.Path)
+	backupPath := path.Join(g.backupDir, strings.TrimPrefix(srcPath, "/"))
+	clnt, err := newClient(backupPath)
+	if err != nil {
+		return err.Trace(backupPath)
+	}
+	opts := CopyOptions{
+		versionID: content.VersionID,
+		size:      content.Size,
+		srcSSE:    getSSE(path.Join(alias, srcPath), encKeyDB[alias]),
+		tgtSSE:    getSSE(backupPath, encKeyDB[alias]),
+	}
+	return clnt.Copy(ctx, content.URL.Path, opts, nil).Trace(path.Join(alias, srcPath), backupPath)
+}
+
+// deleteCandidates collects the objects a run would remove, to check
+// them against the limit before removing anything.
+type deleteCandidates struct {
+	mu    sync.Mutex
+	items []deleteAbortedMessage
+	keys  map[deleteAbortedMessage]struct{}
+	total int64
+}
+
+func candidateKey(alias string, content *ClientContent) deleteAbortedMessage {
+	return deleteAbortedMessage{
+		Key:       path.Join(alias, filepath.ToSlash(content.URL.Path)),
+		VersionID: content.VersionID,
+	}
+}
+
+// add records an object that would be removed.
+func (c *deleteCandidates) add(alias string, content *ClientContent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := candidateKey(alias, content)
+	if c.keys == nil {
+		c.keys = make(map[deleteAbortedMessage]struct{})
+	}
+	c.keys[key] = struct{}{}
+	c.items = append(c.items, key)
+}
+
+// contains returns true if the object was recorded by add, objects
+// created after the candidates were collected are not. A nil
+// deleteCandidates contains every object.
+func (c *deleteCandidates) contains(alias string, content *ClientContent) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.keys[candidateKey(alias, content)]
+	return ok
+}
+
+// count records an object found in the target.
+func (c *deleteCandidates) count() {
+	if c != nil {
+		atomic.AddInt64(&c.total, 1)
+	}
+}
+
+// abort reports the objects that would have been removed and returns
+// the error of the aborted run.
+func (c *deleteCandidates) abort(g *deleteGuard) *probe.Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, msg := range c.items {
+		printMsg(msg)
+	}
+	return errDeleteLimitExceeded(int64(len(c.items)), atomic.LoadInt64(&c.total), g.limit())
+}
+
+// deleteAbortedMessage reports an object that would have been removed
+// by a run aborted by --max-delete.
+type deleteAbortedMessage struct {
+	Status    string `json:"status"`
+	Key       string `json:"key"`
+	VersionID string `json:"versionID,omitempty"`
+}
+
+// String colorized message of an object that would have been removed.
+func (m deleteAbortedMessage) String() string {
+	msg := "Would remove " + console.Colorize("DeleteAborted", fmt.Sprintf("`%s`", m.Key))
+	if m.VersionID != "" {
+		msg += fmt.Sprintf(" (versionId=%s)", m.VersionID)
+	}
+	return msg + "."
+}
+
+// JSON jsonified message of an object that would have been removed.
+func (m deleteAbortedMessage) JSON() string {
+	m.Status = "aborted"
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
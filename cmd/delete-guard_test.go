@@ -0,0 +1,177 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestParseMaxDelete(t *testing.T) {
+	testCases := []struct {
+		value      string
+		maxCount   int64
+		maxPercent float64
+		ok         bool
+	}{
+		{"", -1, 0, true},
+		{"0", 0, 0, true},
+		{"100", 100, 0, true},
+		{"5%", -1, 5, true},
+		{"0.5%", -1, 0.5, true},
+		{"100%", -1, 100, true},
+		{"-1", -1, 0, false},
+		{"0%", -1, 0, false},
+		{"101%", -1, 0, false},
+		{"many", -1, 0, false},
+		{"5 %", -1, 0, false},
+	}
+	for i, tc := range testCases {
+		maxCount, maxPercent, e := parseMaxDelete(tc.value)
+		if (e == nil) != tc.ok {
+			t.Fatalf("Test %d: %q unexpected error %v", i+1, tc.value, e)
+		}
+		if tc.ok && (maxCount != tc.maxCount || maxPercent != tc.maxPercent) {
+			t.Errorf("Test %d: %q expected %d %v, got %d %v", i+1, tc.value, tc.maxCount, tc.maxPercent, maxCount, maxPercent)
+		}
+	}
+}
+
+func TestDeleteGuardExceeded(t *testing.T) {
+	var unlimited *deleteGuard
+	if unlimited.hasLimit() || unlimited.exceeded(1000, 1000) {
+		t.Fatal("a nil guard must not limit removals")
+	}
+	testCases := []struct {
+		guard          deleteGuard
+		deletes, total int64
+		expected       bool
+	}{
+		{deleteGuard{maxCount: -1}, 1000, 1000, false},
+		{deleteGuard{maxCount: 0}, 0, 10, false},
+		{deleteGuard{maxCount: 0}, 1, 10, true},
+		{deleteGuard{maxCount: 10}, 10, 10, false},
+		{deleteGuard{maxCount: 10}, 11, 100, true},
+		{deleteGuard{maxCount: -1, maxPercent: 10}, 10, 100, false},
+		{deleteGuard{maxCount: -1, maxPercent: 10}, 11, 100, true},
+		{deleteGuard{maxCount: -1, maxPercent: 10}, 0, 0, false},
+		// The objects removed are at least the objects found.
+		{deleteGuard{maxCount: -1, maxPercent: 50}, 3, 0, true},
+	}
+	for i, tc := range testCases {
+		if got := tc.guard.exceeded(tc.deletes, tc.total); got != tc.expected {
+			t.Errorf("Test %d: expected %v, got %v", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestDeleteGuardProtected(t *testing.T) {
+	g := &deleteGuard{maxCount: -1, protected: []string{"prod/finance", "prod/reports/yearly", "/srv/archive"}}
+	testCases := []struct {
+		alias, path         string
+		protected, overlaps bool
+	}{
+		{"prod", "/finance", true, true},
+		{"prod", "/finance/2022/q1.csv", true, true},
+		{"prod", "/finance-old/q1.csv", false, false},
+		{"prod", "/reports/yearly/2021.pdf", true, true},
+		{"prod", "/reports/monthly/2021-01.pdf", false, false},
+		{"prod", "/reports", false, true},
+		{"staging", "/finance/q1.csv", false, false},
+		{"", "/srv/archive/2020.tar", true, true},
+		{"", "/srv/archived", false, false},
+		{"", "/srv", false, true},
+	}
+	for i, tc := range testCases {
+		if got := g.isProtected(tc.alias, tc.path); got != tc.protected {
+			t.Errorf("Test %d: %s%s expected protected %v, got %v", i+1, tc.alias, tc.path, tc.protected, got)
+		}
+		if got := g.overlapsProtected(tc.alias, tc.path); got != tc.overlaps {
+			t.Errorf("Test %d: %s%s expected overlaps %v, got %v", i+1, tc.alias, tc.path, tc.overlaps, got)
+		}
+	}
+	if err := g.checkRemoveBucket("prod", "/finance"); err == nil {
+		t.Error("expected the removal of a protected bucket to be refused")
+	}
+	if err := g.checkRemoveBucket("prod", "/photos"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	g.maxCount = 10
+	if err := g.checkRemoveBucket("prod", "/photos"); err == nil {
+		t.Error("expected the removal of a bucket to be refused with --max-delete")
+	}
+}
+
+func TestDeleteCandidatesTotal(t *testing.T) {
+	dir, e := ioutil.TempDir("", "mc-rm")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+
+	old := time.Now().Add(-48 * time.Hour)
+	for i, name := range []string{"a", "b", "c", "d"} {
+		file := filepath.Join(dir, name)
+		if e = ioutil.WriteFile(file, []byte(name), 0o644); e != nil {
+			t.Fatal(e)
+		}
+		if i%2 == 0 {
+			if e = os.Chtimes(file, old, old); e != nil {
+				t.Fatal(e)
+			}
+		}
+	}
+
+	defer func(load func() (*configV10, *probe.Error)) { loadMcConfig = load }(loadMcConfig)
+	loadMcConfig = func() (*configV10, *probe.Error) { return newMcConfig(), nil }
+
+	// Objects filtered out by their age count in the total.
+	candidates := &deleteCandidates{}
+	listAndRemove(dir, removeOpts{isRecursive: true, isFake: true, olderThan: "1d", candidates: candidates})
+	if len(candidates.items) != 2 || candidates.total != 4 {
+		t.Fatalf("expected 2 of 4 objects to be removed, got %d of %d", len(candidates.items), candidates.total)
+	}
+	if (&deleteGuard{maxCount: -1, maxPercent: 60}).exceeded(int64(len(candidates.items)), candidates.total) {
+		t.Fatal("removing 2 of 4 objects must not exceed 60%")
+	}
+
+	// Objects created after the dry run are not removed.
+	file := filepath.Join(dir, "e")
+	if e = ioutil.WriteFile(file, []byte("e"), 0o644); e != nil {
+		t.Fatal(e)
+	}
+	if e = os.Chtimes(file, old, old); e != nil {
+		t.Fatal(e)
+	}
+	listAndRemove(dir, removeOpts{isRecursive: true, olderThan: "1d", approved: candidates})
+	for _, name := range []string{"a", "c"} {
+		if _, e = os.Stat(filepath.Join(dir, name)); !os.IsNotExist(e) {
+			t.Errorf("expected %s to be removed", name)
+		}
+	}
+	for _, name := range []string{"b", "d", "e"} {
+		if _, e = os.Stat(filepath.Join(dir, name)); e != nil {
+			t.Errorf("expected %s to be kept, got %v", name, e)
+		}
+	}
+}
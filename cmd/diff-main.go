@@ -32,7 +32,9 @@ import (
 
 // diff specific flags.
 var (
-	diffFlags = []cli.Flag{}
+	diffFlags = []cli.Flag{
+		indexFlag,
+	}
 )
 
 // Compute differences in object name, size, and date between two buckets.
@@ -66,6 +68,9 @@ EXAMPLES:
 
   2. Compare two folders on a local filesystem.
      {{.Prompt}} {{.HelpName}} ~/Photos /Media/Backup/Photos
+
+  3. Compare a local folder with a local index of a bucket, without listing the bucket.
+     {{.Prompt}} {{.HelpName}} --index idx/ ~/Photos s3/mybucket/Photos
 `,
 }
 
@@ -114,7 +119,7 @@ func (d diffMessage) JSON() string {
 	return string(diffJSONBytes)
 }
 
-func checkDiffSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[string][]prefixSSEPair) {
+func checkDiffSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[string][]prefixSSEPair, indexes indexSet) {
 	if len(cliCtx.Args()) != 2 {
 		cli.ShowCommandHelpAndExit(cliCtx, "diff", 1) // last argument is exit code
 	}
@@ -127,36 +132,41 @@ func checkDiffSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[stri
 	firstURL := URLs[0]
 	secondURL := URLs[1]
 
-	// Diff only works between two directories, verify them below.
+	// Diff only works between two directories, verify them below,
+	// indexed URLs are answered offline and not verified.
 
 	// Verify if firstURL is accessible.
-	_, firstContent, err := url2Stat(ctx, firstURL, "", false, encKeyDB, time.Time{}, false)
-	if err != nil {
-		fatalIf(err.Trace(firstURL), fmt.Sprintf("Unable to stat '%s'.", firstURL))
-	}
+	if !indexes.covers(firstURL) {
+		_, firstContent, err := url2Stat(ctx, firstURL, "", false, encKeyDB, time.Time{}, false)
+		if err != nil {
+			fatalIf(err.Trace(firstURL), fmt.Sprintf("Unable to stat '%s'.", firstURL))
+		}
 
-	// Verify if its a directory.
-	if !firstContent.Type.IsDir() {
-		fatalIf(errInvalidArgument().Trace(firstURL), fmt.Sprintf("`%s` is not a folder.", firstURL))
+		// Verify if its a directory.
+		if !firstContent.Type.IsDir() {
+			fatalIf(errInvalidArgument().Trace(firstURL), fmt.Sprintf("`%s` is not a folder.", firstURL))
+		}
 	}
 
 	// Verify if secondURL is accessible.
-	_, secondContent, err := url2Stat(ctx, secondURL, "", false, encKeyDB, time.Time{}, false)
-	if err != nil {
-		// Destination doesn't exist is okay.
-		if _, ok := err.ToGoError().(ObjectMissing); !ok {
-			fatalIf(err.Trace(secondURL), fmt.Sprintf("Unable to stat '%s'.", secondURL))
+	if !indexes.covers(secondURL) {
+		_, secondContent, err := url2Stat(ctx, secondURL, "", false, encKeyDB, time.Time{}, false)
+		if err != nil {
+			// Destination doesn't exist is okay.
+			if _, ok := err.ToGoError().(ObjectMissing); !ok {
+				fatalIf(err.Trace(secondURL), fmt.Sprintf("Unable to stat '%s'.", secondURL))
+			}
 		}
-	}
 
-	// Verify if its a directory.
-	if err == nil && !secondContent.Type.IsDir() {
-		fatalIf(errInvalidArgument().Trace(secondURL), fmt.Sprintf("`%s` is not a folder.", secondURL))
+		// Verify if its a directory.
+		if err == nil && !secondContent.Type.IsDir() {
+			fatalIf(errInvalidArgument().Trace(secondURL), fmt.Sprintf("`%s` is not a folder.", secondURL))
+		}
 	}
 }
 
 // doDiffMain runs the diff.
-func doDiffMain(ctx context.Context, firstURL, secondURL string) error {
+func doDiffMain(ctx context.Context, firstURL, secondURL string, indexes indexSet) error {
 	// Source and targets are always directories
 	sourceSeparator := string(newClientURL(firstURL).Separator)
 	if !strings.HasSuffix(firstURL, sourceSeparator) {
@@ -183,8 +193,18 @@ func doDiffMain(ctx context.Context, firstURL, secondURL string) error {
 			fmt.Sprintf("Failed to diff '%s' and '%s'", firstURL, secondURL))
 	}
 
+	// Indexes hold no metadata, only compare it on live listings.
+	isMetadata := true
+	if len(indexes) > 0 {
+		firstClient = indexes.wrap(firstClient)
+		secondClient = indexes.wrap(secondClient)
+		_, firstIndexed := firstClient.(*indexClient)
+		_, secondIndexed := secondClient.(*indexClient)
+		isMetadata = !firstIndexed && !secondIndexed
+	}
+
 	// Diff first and second urls.
-	for diffMsg := range objectDifference(ctx, firstClient, secondClient, true) {
+	for diffMsg := range objectDifference(ctx, firstClient, secondClient, isMetadata) {
 		if diffMsg.Error != nil {
 			errorIf(diffMsg.Error, "Unable to calculate objects difference.")
 			// Ignore error and proceed to next object.
@@ -205,8 +225,11 @@ func mainDiff(cliCtx *cli.Context) error {
 	encKeyDB, err := getEncKeys(cliCtx)
 	fatalIf(err, "Unable to parse encryption keys.")
 
+	indexes, err := loadIndexes(cliCtx.StringSlice("index"))
+	fatalIf(err, "Unable to load index.")
+
 	// check 'diff' cli arguments.
-	checkDiffSyntax(ctx, cliCtx, encKeyDB)
+	checkDiffSyntax(ctx, cliCtx, encKeyDB, indexes)
 
 	// Additional command specific theme customization.
 	console.SetColor("DiffMessage", color.New(color.FgGreen, color.Bold))
@@ -221,5 +244,5 @@ func mainDiff(cliCtx *cli.Context) error {
 	firstURL := URLs.Get(0)
 	secondURL := URLs.Get(1)
 
-	return doDiffMain(ctx, firstURL, secondURL)
+	return doDiffMain(ctx, firstURL, secondURL, indexes)
 }
@@ -162,17 +162,19 @@ func metadataEqual(m1, m2 map[string]string) bool {
 }
 
 func objectDifference(ctx context.Context, sourceClnt, targetClnt Client, isMetadata bool) (diffCh chan diffMessage) {
-	return difference(ctx, sourceClnt, targetClnt, isMetadata, true, false, DirNone)
+	return difference(ctx, sourceClnt, targetClnt, isMetadata, true, false, DirNone, false)
 }
 
 func dirDifference(ctx context.Context, sourceClnt, targetClnt Client) (diffCh chan diffMessage) {
-	return difference(ctx, sourceClnt, targetClnt, false, false, true, DirFirst)
+	return difference(ctx, sourceClnt, targetClnt, false, false, true, DirFirst, false)
 }
 
-func differenceInternal(ctx context.Context, sourceClnt, targetClnt Client, isMetadata bool, isRecursive, returnSimilar bool, dirOpt DirOpt, diffCh chan<- diffMessage) *probe.Error {
-	// Set default values for listing.
-	srcCh := sourceClnt.List(ctx, ListOptions{Recursive: isRecursive, WithMetadata: isMetadata, ShowDir: dirOpt})
-	tgtCh := targetClnt.List(ctx, ListOptions{Recursive: isRecursive, WithMetadata: isMetadata, ShowDir: dirOpt})
+func differenceInternal(ctx context.Context, sourceClnt, targetClnt Client, isMetadata bool, isRecursive, returnSimilar bool, dirOpt DirOpt, withLogicalSize bool, diffCh chan<- diffMessage) *probe.Error {
+	// Set default values for listing, the uncompressed size of the
+	// objects compressed by mc is in their metadata.
+	withMetadata := isMetadata || withLogicalSize
+	srcCh := sourceClnt.List(ctx, ListOptions{Recursive: isRecursive, WithMetadata: withMetadata, ShowDir: dirOpt})
+	tgtCh := targetClnt.List(ctx, ListOptions{Recursive: isRecursive, WithMetadata: withMetadata, ShowDir: dirOpt})
 
 	srcCtnt, srcOk := <-srcCh
 	tgtCtnt, tgtOk := <-tgtCh
@@ -254,7 +256,7 @@ func differenceInternal(ctx context.Context, sourceClnt, targetClnt Client, isMe
 		}
 		if normalizedExpected == normalizedCurrent {
 			srcType, tgtType := srcCtnt.Type, tgtCtnt.Type
-			srcSize, tgtSize := srcCtnt.Size, tgtCtnt.Size
+			srcSize, tgtSize := logicalSize(srcCtnt), logicalSize(tgtCtnt)
 			if srcType.IsRegular() && !tgtType.IsRegular() ||
 				!srcType.IsRegular() && tgtType.IsRegular() {
 				// Type differs. Source is never a directory.
@@ -326,14 +328,15 @@ func differenceInternal(ctx context.Context, sourceClnt, targetClnt Client, isMe
 }
 
 // objectDifference function finds the difference between all objects
-// recursively in sorted order from source and target.
-func difference(ctx context.Context, sourceClnt, targetClnt Client, isMetadata bool, isRecursive, returnSimilar bool, dirOpt DirOpt) (diffCh chan diffMessage) {
+// recursively in sorted order from source and target. withLogicalSize
+// compares the uncompressed size of the objects compressed by mc.
+func difference(ctx context.Context, sourceClnt, targetClnt Client, isMetadata bool, isRecursive, returnSimilar bool, dirOpt DirOpt, withLogicalSize bool) (diffCh chan diffMessage) {
 	diffCh = make(chan diffMessage, 10000)
 
 	go func() {
 		defer close(diffCh)
 
-		err := differenceInternal(ctx, sourceClnt, targetClnt, isMetadata, isRecursive, returnSimilar, dirOpt, diffCh)
+		err := differenceInternal(ctx, sourceClnt, targetClnt, isMetadata, isRecursive, returnSimilar, dirOpt, withLogicalSize, diffCh)
 		if err != nil {
 			// handle this specifically for filesystem related errors.
 			switch v := err.ToGoError().(type) {
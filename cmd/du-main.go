@@ -51,6 +51,7 @@ var (
 			Name:  "versions",
 			Usage: "include all object versions",
 		},
+		logicalSizeFlag,
 	}
 )
 
@@ -61,7 +62,7 @@ var duCmd = cli.Command{
 	Action:       mainDu,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(duFlags, ioFlags...), globalFlags...),
+	Flags:        append(append(append(duFlags, indexFlag), ioFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -86,6 +87,12 @@ EXAMPLES:
 
   4. Summarize disk usage of 'jazz-songs' bucket with all objects versions
      {{.Prompt}} {{.HelpName}} --versions s3/jazz-songs/
+
+  5. Summarize disk usage of 'jazz-songs' bucket from a local index, without listing the bucket
+     {{.Prompt}} {{.HelpName}} --index idx/ s3/jazz-songs/
+
+  6. Summarize disk usage of 'jazz-songs' bucket counting the uncompressed size of the objects uploaded with --compress
+     {{.Prompt}} {{.HelpName}} --logical-size s3/jazz-songs/
 `,
 }
 
@@ -120,7 +127,7 @@ func (r duMessage) JSON() string {
 	return string(msgBytes)
 }
 
-func du(ctx context.Context, urlStr string, timeRef time.Time, withVersions bool, depth int, encKeyDB map[string][]prefixSSEPair) (sz, objs int64, err error) {
+func du(ctx context.Context, urlStr string, timeRef time.Time, withVersions, withLogicalSize bool, depth int, encKeyDB map[string][]prefixSSEPair, indexes indexSet) (sz, objs int64, err error) {
 	targetAlias, targetURL, _ := mustExpandAlias(urlStr)
 	if !strings.HasSuffix(targetURL, "/") {
 		targetURL += "/"
@@ -131,6 +138,28 @@ func du(ctx context.Context, urlStr string, timeRef time.Time, withVersions bool
 		errorIf(pErr.Trace(urlStr), "Failed to summarize disk usage `"+urlStr+"`.")
 		return 0, 0, exitStatus(globalErrorExitStatus) // End of journey.
 	}
+	clnt = indexes.wrap(clnt)
+	if _, ok := clnt.(*indexClient); ok {
+		u, e := url.Parse(targetURL)
+		if e != nil {
+			panic(e)
+		}
+		opts := ListOptions{TimeRef: timeRef, WithOlderVersions: withVersions}
+		size, objects, pErr := duIndex(ctx, clnt, opts, withLogicalSize, depth, func(folder string, size, objects int64) {
+			printMsg(duMessage{
+				Prefix:     strings.Trim(u.Path+folder, "/"),
+				Size:       size,
+				Objects:    objects,
+				Status:     "success",
+				IsVersions: withVersions,
+			})
+		})
+		if pErr != nil {
+			errorIf(pErr.Trace(urlStr), "Failed to find disk usage of `"+urlStr+"` recursively.")
+			return 0, 0, exitStatus(globalErrorExitStatus)
+		}
+		return size, objects, nil
+	}
 
 	// No disk usage details below this level,
 	// just do a recursive listing
@@ -141,6 +170,7 @@ func du(ctx context.Context, urlStr string, timeRef time.Time, withVersions bool
 		WithOlderVersions: withVersions,
 		Recursive:         recursive,
 		ShowDir:           DirFirst,
+		WithMetadata:      withLogicalSize,
 	})
 	size := int64(0)
 	objects := int64(0)
@@ -171,13 +201,16 @@ func du(ctx context.Context, urlStr string, timeRef time.Time, withVersions bool
 			if targetAlias != "" {
 				subDirAlias = targetAlias + "/" + content.URL.Path
 			}
-			used, n, err := du(ctx, subDirAlias, timeRef, withVersions, depth, encKeyDB)
+			used, n, err := du(ctx, subDirAlias, timeRef, withVersions, withLogicalSize, depth, encKeyDB, indexes)
 			if err != nil {
 				return 0, 0, err
 			}
 			size += used
 			objects += n
 		} else {
+			if withLogicalSize {
+				content.Size = logicalSize(content)
+			}
 			size += content.Size
 			if !content.IsDeleteMarker {
 				objects++
@@ -203,6 +236,73 @@ func du(ctx context.Context, urlStr string, timeRef time.Time, withVersions bool
 	return size, objects, nil
 }
 
+// duIndex summarizes the disk usage of a client listing from an index
+// in a single recursive listing instead of one per folder. Keys are
+// sorted, so the folders are totaled on a stack as the listing enters
+// and leaves them: report is called for each folder above depth, with
+// its path relative to the client URL, in the order du prints them.
+func duIndex(ctx context.Context, clnt Client, opts ListOptions, withLogicalSize bool, depth int, report func(folder string, size, objects int64)) (sz, objs int64, err *probe.Error) {
+	targetURL := clnt.GetURL()
+	_, prefix := url2BucketAndObject(&targetURL)
+	separator := string(targetURL.Separator)
+
+	type duFolder struct {
+		prefix        string
+		level         int
+		size, objects int64
+	}
+	folders := []*duFolder{{prefix: prefix}}
+	leave := func() {
+		f := folders[len(folders)-1]
+		folders = folders[:len(folders)-1]
+		if len(folders) > 0 {
+			folders[len(folders)-1].size += f.size
+			folders[len(folders)-1].objects += f.objects
+		}
+		if depth < 0 || f.level < depth {
+			report(f.prefix[len(prefix):], f.size, f.objects)
+		}
+	}
+
+	opts.Recursive = true
+	for content := range clnt.List(ctx, opts) {
+		if content.Err != nil {
+			return 0, 0, content.Err
+		}
+		_, key := url2BucketAndObject(&content.URL)
+		for !strings.HasPrefix(key, folders[len(folders)-1].prefix) {
+			leave()
+		}
+		// Enter the folders of the key down to depth.
+		for f := folders[len(folders)-1]; depth <= 0 || f.level < depth-1; f = folders[len(folders)-1] {
+			i := strings.Index(key[len(f.prefix):], separator)
+			if i < 0 {
+				break
+			}
+			folders = append(folders, &duFolder{prefix: key[:len(f.prefix)+i+1], level: f.level + 1})
+		}
+		f := folders[len(folders)-1]
+		if key == f.prefix {
+			// The folder object itself, not counted like by listings.
+			continue
+		}
+		size := content.Size
+		if withLogicalSize {
+			size = logicalSize(content)
+		}
+		f.size += size
+		if !content.IsDeleteMarker {
+			f.objects++
+		}
+	}
+	for len(folders) > 1 {
+		leave()
+	}
+	sz, objs = folders[0].size, folders[0].objects
+	leave()
+	return sz, objs, nil
+}
+
 // main for du command.
 func mainDu(cliCtx *cli.Context) error {
 	if !cliCtx.Args().Present() {
@@ -237,13 +337,19 @@ func mainDu(cliCtx *cli.Context) error {
 	withVersions := cliCtx.Bool("versions")
 	timeRef := parseRewindFlag(cliCtx.String("rewind"))
 
+	indexes, err := loadIndexes(cliCtx.StringSlice("index"))
+	fatalIf(err, "Unable to load index.")
+
 	var duErr error
 	for _, urlStr := range cliCtx.Args() {
-		if !isAliasURLDir(ctx, urlStr, nil, time.Time{}) {
+		if cliCtx.Bool("logical-size") && !indexes.logicalSizes(urlStr) {
+			fatalIf(errInvalidArgument().Trace(urlStr), "The index of `"+urlStr+"` has no logical size, build it again without --versions to use --logical-size.")
+		}
+		if !indexes.covers(urlStr) && !isAliasURLDir(ctx, urlStr, nil, time.Time{}) {
 			fatalIf(errInvalidArgument().Trace(urlStr), fmt.Sprintf("Source `%s` is not a folder. Only folders are supported by 'du' command.", urlStr))
 		}
 
-		if _, _, err := du(ctx, urlStr, timeRef, withVersions, depth, encKeyDB); duErr == nil {
+		if _, _, err := du(ctx, urlStr, timeRef, withVersions, cliCtx.Bool("logical-size"), depth, encKeyDB, indexes); duErr == nil {
 			duErr = err
 		}
 	}
@@ -54,7 +54,8 @@ func fatalIf(err *probe.Error, msg string, data ...interface{}) {
 }
 
 func fatal(err *probe.Error, msg string, data ...interface{}) {
-	if globalJSON {
+	flushOutputFormat()
+	if globalJSON && globalFormat.printsJSON() {
 		errorMsg := errorMessage{
 			Message: msg,
 			Type:    "fatal",
@@ -131,7 +132,7 @@ func errorIf(err *probe.Error, msg string, data ...interface{}) {
 	if err == nil {
 		return
 	}
-	if globalJSON {
+	if globalJSON && globalFormat.printsJSON() {
 		errorMsg := errorMessage{
 			Message: fmt.Sprintf(msg, data...),
 			Type:    "error",
@@ -89,7 +89,7 @@ var findCmd = cli.Command{
 	Action:       mainFind,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(findFlags, globalFlags...),
+	Flags:        append(append(findFlags, indexFlag), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -154,11 +154,14 @@ EXAMPLES:
 
   10. List all objects up to 3 levels sub-directory deep under "s3/bucket".
       {{.Prompt}} {{.HelpName}} s3/bucket --maxdepth 3
+
+  11. Find all objects larger than 1 GB under "s3/bucket" from a local index, without listing the bucket.
+      {{.Prompt}} {{.HelpName}} s3/bucket --larger 1GB --index idx/
 `,
 }
 
 // checkFindSyntax - validate the passed arguments
-func checkFindSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[string][]prefixSSEPair) {
+func checkFindSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[string][]prefixSSEPair, indexes indexSet) {
 	args := cliCtx.Args()
 	if !args.Present() {
 		args = []string{"./"} // No args just default to present directory.
@@ -174,6 +177,9 @@ func checkFindSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[stri
 
 	// Extract input URLs and validate.
 	for _, url := range args {
+		if indexes.covers(url) {
+			continue
+		}
 		_, _, err := url2Stat(ctx, url, "", false, encKeyDB, time.Time{}, false)
 		if err != nil {
 			// Bucket name empty is a valid error for 'find myminio' unless we are using watch, treat it as such.
@@ -223,7 +229,10 @@ func mainFind(cliCtx *cli.Context) error {
 	encKeyDB, err := getEncKeys(cliCtx)
 	fatalIf(err, "Unable to parse encryption keys.")
 
-	checkFindSyntax(ctx, cliCtx, encKeyDB)
+	indexes, err := loadIndexes(cliCtx.StringSlice("index"))
+	fatalIf(err, "Unable to load index.")
+
+	checkFindSyntax(ctx, cliCtx, encKeyDB, indexes)
 
 	args := cliCtx.Args()
 	if !args.Present() {
@@ -234,6 +243,7 @@ func mainFind(cliCtx *cli.Context) error {
 
 	clnt, err := newClient(args[0])
 	fatalIf(err.Trace(args...), "Unable to initialize `"+args[0]+"`.")
+	clnt = indexes.wrap(clnt)
 
 	var olderThan, newerThan string
 
@@ -42,6 +42,18 @@ var globalFlags = []cli.Flag{
 		Name:  "json",
 		Usage: "enable JSON lines formatted output",
 	},
+	cli.StringFlag{
+		Name:  "format",
+		Usage: "format the output from its JSON form: table, csv, tsv, jsonl or template=TEMPLATE",
+	},
+	cli.StringFlag{
+		Name:  "columns",
+		Usage: "comma separated list of the JSON fields printed by --format, e.g. key,size",
+	},
+	cli.BoolFlag{
+		Name:  "no-header",
+		Usage: "do not print the header of --format table, csv and tsv",
+	},
 	cli.BoolFlag{
 		Name:  "debug",
 		Usage: "enable debug output",
@@ -50,6 +62,18 @@ var globalFlags = []cli.Flag{
 		Name:  "insecure",
 		Usage: "disable SSL certificate verification",
 	},
+	cli.StringFlag{
+		Name:  "aliases",
+		Usage: "run the command concurrently on a comma separated list of aliases or alias groups",
+	},
+	cli.StringFlag{
+		Name:  "alias-glob",
+		Usage: "run the command concurrently on all aliases matching a pattern",
+	},
+	cli.DurationFlag{
+		Name:  "alias-timeout",
+		Usage: "maximum duration of the command on each alias with --aliases or --alias-glob",
+	},
 	cli.DurationFlag{
 		Name:   "conn-read-deadline",
 		Usage:  "custom connection READ deadline",
@@ -71,3 +95,25 @@ var ioFlags = []cli.Flag{
 		Usage: "encrypt/decrypt objects (using server-side encryption with customer provided keys)",
 	},
 }
+
+// Flag to answer listings of find, du and diff from local indexes.
+var indexFlag = cli.StringSliceFlag{
+	Name:  "index",
+	Usage: "answer listings from a local index built with 'mc index build'",
+}
+
+// Flags to control the concurrency and memory usage of cp, mv and mirror.
+var parallelFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "max-workers",
+		Usage: "maximum number of concurrent workers, adapted to throughput and throttling in between (default: 128)",
+	},
+	cli.IntFlag{
+		Name:  "min-workers",
+		Usage: "minimum number of concurrent workers kept when backing off (default: 1)",
+	},
+	cli.StringFlag{
+		Name:  "max-memory",
+		Usage: "maximum memory budgeted for in-flight uploads (e.g. 2GiB), defaults to half of the cgroup or available memory",
+	},
+}
@@ -0,0 +1,434 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	gojson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+	"github.com/olekukonko/tablewriter"
+)
+
+//   Fleet mode
+//
+//   ----
+//   With --aliases or --alias-glob, a command runs once per alias in
+//   separate mc processes, so that a failing alias can't bring down
+//   the others. Their output is collected and printed per alias,
+//   followed by a summary of the aliases which failed.
+//   ----
+//
+
+// Maximum number of aliases a command runs on at the same time.
+const fleetMaxParallel = 16
+
+// Flags which are not copied from the command line to the commands
+// run for each alias, they select the aliases or are set from the
+// global state which may come from flags of parent commands.
+var fleetFlagNames = map[string]bool{
+	"aliases":       true,
+	"alias-glob":    true,
+	"alias-timeout": true,
+	"config-dir":    true,
+	"json":          true,
+	"quiet":         true,
+	"no-color":      true,
+	"insecure":      true,
+	"debug":         true,
+}
+
+// Fleet results of an alias.
+const (
+	fleetStatusSuccess = "success"
+	fleetStatusError   = "error"
+	fleetStatusTimeout = "timeout"
+)
+
+// fleetMessage is the result of a command on an alias, in JSON mode
+// a message is printed for each record output by the command.
+type fleetMessage struct {
+	Status   string            `json:"status"`
+	Alias    string            `json:"alias"`
+	Duration string            `json:"duration"`
+	Result   gojson.RawMessage `json:"result,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	output   []byte
+	records  []gojson.RawMessage
+}
+
+func (m fleetMessage) String() string {
+	var b strings.Builder
+	status := console.Colorize("FleetSuccess", m.Status)
+	if m.Status != fleetStatusSuccess {
+		status = console.Colorize("FleetError", m.Status)
+	}
+	fmt.Fprintf(&b, "%s %s (%s, %s)\n", console.Colorize("FleetAlias", "──"), console.Colorize("FleetAlias", m.Alias), status, m.Duration)
+	for _, line := range strings.Split(strings.TrimRight(string(m.output), "\n"), "\n") {
+		if line != "" {
+			b.WriteString("   " + line + "\n")
+		}
+	}
+	if m.Error != "" {
+		b.WriteString("   " + console.Colorize("FleetError", m.Error) + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (m fleetMessage) JSON() string {
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// fleetSummaryMessage reports the aliases a command failed on.
+type fleetSummaryMessage struct {
+	Status    string         `json:"status"`
+	Total     int            `json:"total"`
+	Succeeded int            `json:"succeeded"`
+	Failed    []fleetMessage `json:"failed,omitempty"`
+}
+
+func (m fleetSummaryMessage) String() string {
+	var s strings.Builder
+	if len(m.Failed) == 0 {
+		return console.Colorize("FleetSuccess", fmt.Sprintf("Succeeded on all %d aliases.", m.Total))
+	}
+	fmt.Fprintf(&s, "%s\n", console.Colorize("FleetError", fmt.Sprintf("Failed on %d of %d aliases:", len(m.Failed), m.Total)))
+	table := tablewriter.NewWriter(&s)
+	table.SetAutoWrapText(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t") // pad with tabs
+	table.SetNoWhiteSpace(true)
+	table.SetHeader([]string{"Alias", "Status", "Duration", "Error"})
+	for _, f := range m.Failed {
+		table.Append([]string{f.Alias, f.Status, f.Duration, f.Error})
+	}
+	table.Render()
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+func (m fleetSummaryMessage) JSON() string {
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// resolveFleetAliases returns the aliases named by a comma separated
+// list of aliases and groups, and those matching a glob pattern.
+func resolveFleetAliases(conf *configV10, names, glob string) ([]string, *probe.Error) {
+	found := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+		case conf.Groups[name] != nil:
+			for _, alias := range conf.Groups[name] {
+				if _, ok := conf.Aliases[alias]; !ok {
+					return nil, errInvalidAliasedURL(alias).Trace(name)
+				}
+				found[alias] = true
+			}
+		default:
+			if _, ok := conf.Aliases[name]; !ok {
+				return nil, errInvalidAliasedURL(name).Trace(names)
+			}
+			found[name] = true
+		}
+	}
+	if glob != "" {
+		matched := false
+		for alias := range conf.Aliases {
+			ok, e := path.Match(glob, alias)
+			if e != nil {
+				return nil, probe.NewError(e).Trace(glob)
+			}
+			if ok {
+				found[alias], matched = true, true
+			}
+		}
+		if !matched {
+			return nil, probe.NewError(fmt.Errorf("no alias matches `%s`", glob))
+		}
+	}
+	aliases := make([]string, 0, len(found))
+	for alias := range found {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases, nil
+}
+
+// isFleetCommand returns true if ctx is a command run in fleet mode.
+// Fleet mode only starts from the command itself, once the config is
+// initialized, --aliases and --alias-glob given to mc or to a group
+// of commands are rejected.
+func isFleetCommand(ctx *cli.Context) (bool, *probe.Error) {
+	if !ctx.IsSet("aliases") && !ctx.IsSet("alias-glob") {
+		return false, nil
+	}
+	if ctx.Command.Name == "" {
+		return false, probe.NewError(errors.New("--aliases and --alias-glob are only supported after the command name, e.g. `mc admin info --aliases ALIASES`"))
+	}
+	return true, nil
+}
+
+// fleetCommandArgs returns the command path and the flags set in ctx,
+// without the flags of fleet mode.
+func fleetCommandArgs(ctx *cli.Context) (args []string) {
+	args = append(args, strings.Fields(ctx.App.Name+" "+ctx.Command.Name)[1:]...)
+	for _, flag := range ctx.Command.Flags {
+		flagName := strings.TrimSpace(strings.Split(flag.GetName(), ",")[0])
+		if fleetFlagNames[flagName] || !ctx.IsSet(flagName) {
+			continue
+		}
+		switch flag.(type) {
+		case cli.StringSliceFlag:
+			for _, v := range ctx.StringSlice(flagName) {
+				args = append(args, "--"+flagName+"="+v)
+			}
+		case cli.IntSliceFlag:
+			for _, v := range ctx.IntSlice(flagName) {
+				args = append(args, fmt.Sprintf("--%s=%d", flagName, v))
+			}
+		case cli.Int64SliceFlag:
+			for _, v := range ctx.Int64Slice(flagName) {
+				args = append(args, fmt.Sprintf("--%s=%d", flagName, v))
+			}
+		default:
+			args = append(args, "--"+flagName+"="+ctx.String(flagName))
+		}
+	}
+	return args
+}
+
+// fleetTargetArgs returns the arguments of a command for an alias.
+// The alias is the first argument, an argument starting with a slash
+// is appended to it: '/bucket' becomes 'alias/bucket'.
+func fleetTargetArgs(alias string, args []string) []string {
+	if len(args) > 0 && strings.HasPrefix(args[0], "/") {
+		return append([]string{alias + args[0]}, args[1:]...)
+	}
+	return append([]string{alias}, args...)
+}
+
+// parseFleetJSON splits the JSON output of a command into records, and
+// returns the error of the first failed record.
+func parseFleetJSON(output []byte) (records []gojson.RawMessage, e error) {
+	dec := gojson.NewDecoder(bytes.NewReader(output))
+	for {
+		var record gojson.RawMessage
+		if de := dec.Decode(&record); de == io.EOF {
+			return records, e
+		} else if de != nil {
+			return records, fmt.Errorf("unexpected output: %s", strings.TrimSpace(string(output[dec.InputOffset():])))
+		}
+		var status struct {
+			Status string            `json:"status"`
+			Error  gojson.RawMessage `json:"error"`
+		}
+		if gojson.Unmarshal(record, &status) == nil && status.Status == fleetStatusError {
+			if e == nil {
+				e = fleetRecordError(status.Error)
+			}
+			continue
+		}
+		records = append(records, record)
+	}
+}
+
+// fleetRecordError returns the error of a failed record, either a
+// message or the error printed by fatalIf.
+func fleetRecordError(record gojson.RawMessage) error {
+	var msg string
+	if gojson.Unmarshal(record, &msg) == nil {
+		return errors.New(msg)
+	}
+	var fatal struct {
+		Message string `json:"message"`
+		Cause   struct {
+			Message string `json:"message"`
+		} `json:"cause"`
+	}
+	if gojson.Unmarshal(record, &fatal) == nil && fatal.Message != "" {
+		return errors.New(strings.TrimSpace(fatal.Message + " " + fatal.Cause.Message))
+	}
+	return errors.New("unknown error")
+}
+
+// runFleetCommand runs mc with args for an alias.
+func runFleetCommand(ctx context.Context, alias string, args, env []string, timeout time.Duration) fleetMessage {
+	msg := fleetMessage{Alias: alias, Status: fleetStatusSuccess}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	exe, e := os.Executable()
+	if e != nil {
+		msg.Status, msg.Error = fleetStatusError, e.Error()
+		return msg
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, exe, args...)
+	cmd.Env = env
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	start := time.Now()
+	e = cmd.Run()
+	msg.Duration = time.Since(start).Round(time.Millisecond).String()
+
+	if globalJSON {
+		records, err := parseFleetJSON(stdout.Bytes())
+		if err != nil {
+			msg.Status, msg.Error = fleetStatusError, err.Error()
+		}
+		msg.records = records
+	} else {
+		msg.output = stdout.Bytes()
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		msg.Status, msg.Error = fleetStatusTimeout, "timed out after "+timeout.String()
+	case e != nil && msg.Error == "":
+		msg.Status, msg.Error = fleetStatusError, e.Error()
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			lines := strings.Split(s, "\n")
+			msg.Error = strings.TrimPrefix(strings.TrimSpace(lines[len(lines)-1]), "mc: <ERROR> ")
+		}
+	case e != nil:
+		msg.Status = fleetStatusError
+	}
+	return msg
+}
+
+// mainFleet runs the command of ctx on all aliases selected by
+// --aliases and --alias-glob, and exits.
+func mainFleet(ctx *cli.Context) {
+	console.SetColor("FleetAlias", color.New(color.FgCyan, color.Bold))
+	console.SetColor("FleetSuccess", color.New(color.FgGreen))
+	console.SetColor("FleetError", color.New(color.FgRed, color.Bold))
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config version `"+globalMCConfigVersion+"`.")
+	aliases, err := resolveFleetAliases(conf, ctx.String("aliases"), ctx.String("alias-glob"))
+	fatalIf(err, "Unable to select the aliases.")
+	if len(aliases) == 0 {
+		fatalIf(errInvalidArgument().Trace(ctx.String("aliases")), "No alias selected.")
+	}
+
+	// Commands of all aliases share the key of an encrypted config,
+	// the passphrase is asked only once.
+	env := os.Environ()
+	if conf.Encryption != nil {
+		key, err := unlockConfig(conf.Encryption, true)
+		fatalIf(err, "Unable to unlock the config.")
+		env = append(env, mcEnvConfigKey+"="+hex.EncodeToString(key))
+	}
+
+	// The config directory is only read from the flags of mc itself.
+	args := append([]string{"--config-dir=" + mustGetMcConfigDir()}, fleetCommandArgs(ctx)...)
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"json", globalJSON},
+		{"quiet", globalQuiet},
+		{"no-color", globalNoColor},
+		{"insecure", globalInsecure},
+		{"debug", globalDebug},
+	} {
+		if flag.set {
+			args = append(args, "--"+flag.name)
+		}
+	}
+	timeout := ctx.Duration("alias-timeout")
+
+	results := make(chan fleetMessage)
+	sem := make(chan struct{}, fleetMaxParallel)
+	var wg sync.WaitGroup
+	for _, alias := range aliases {
+		wg.Add(1)
+		go func(alias string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			cmdArgs := append(append([]string{}, args...), fleetTargetArgs(alias, ctx.Args())...)
+			results <- runFleetCommand(globalContext, alias, cmdArgs, env, timeout)
+		}(alias)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	summary := fleetSummaryMessage{Status: fleetStatusSuccess, Total: len(aliases)}
+	for msg := range results {
+		if msg.Status == fleetStatusSuccess {
+			summary.Succeeded++
+		} else {
+			summary.Status = fleetStatusError
+			summary.Failed = append(summary.Failed, fleetMessage{Status: msg.Status, Alias: msg.Alias, Duration: msg.Duration, Error: msg.Error})
+		}
+		if !globalJSON {
+			printMsg(msg)
+			continue
+		}
+		if len(msg.records) == 0 {
+			printMsg(msg)
+		}
+		for _, record := range msg.records {
+			msg.Result = record
+			printMsg(msg)
+		}
+	}
+	sort.Slice(summary.Failed, func(i, j int) bool {
+		return summary.Failed[i].Alias < summary.Failed[j].Alias
+	})
+	printMsg(summary)
+	flushOutputFormat()
+
+	if len(summary.Failed) > 0 {
+		os.Exit(globalErrorExitStatus)
+	}
+	os.Exit(0)
+}
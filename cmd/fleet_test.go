@@ -0,0 +1,192 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestResolveFleetAliases(t *testing.T) {
+	conf := newConfigV10()
+	for _, alias := range []string{"prod-us", "prod-eu", "staging", "dev"} {
+		conf.Aliases[alias] = aliasConfigV10{URL: "https://" + alias + ".example.com"}
+	}
+	conf.Groups = map[string][]string{"all-prod": {"prod-eu", "prod-us"}, "stale": {"gone"}}
+
+	testCases := []struct {
+		names, glob string
+		expected    []string
+		wantErr     bool
+	}{
+		{names: "dev,staging", expected: []string{"dev", "staging"}},
+		{names: "all-prod, dev", expected: []string{"dev", "prod-eu", "prod-us"}},
+		{glob: "prod-*", expected: []string{"prod-eu", "prod-us"}},
+		{names: "prod-us", glob: "prod-*", expected: []string{"prod-eu", "prod-us"}},
+		{names: "unknown", wantErr: true},
+		{names: "stale", wantErr: true},
+		{glob: "qa-*", wantErr: true},
+		{glob: "[", wantErr: true},
+	}
+	for i, tc := range testCases {
+		aliases, err := resolveFleetAliases(conf, tc.names, tc.glob)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Test %d: expected an error, got %v", i+1, aliases)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if !reflect.DeepEqual(aliases, tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, tc.expected, aliases)
+		}
+	}
+}
+
+func TestFleetTargetArgs(t *testing.T) {
+	testCases := []struct {
+		args, expected []string
+	}{
+		{nil, []string{"prod"}},
+		{[]string{"/bucket/prefix"}, []string{"prod/bucket/prefix"}},
+		{[]string{"user1"}, []string{"prod", "user1"}},
+	}
+	for i, tc := range testCases {
+		if got := fleetTargetArgs("prod", tc.args); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestFleetCommandArgs(t *testing.T) {
+	var args []string
+	app := cli.NewApp()
+	app.Name = "mc"
+	app.Commands = []cli.Command{{
+		Name: "admin",
+		Subcommands: []cli.Command{{
+			Name: "heal",
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{Name: "recursive, r"},
+				cli.StringFlag{Name: "scan"},
+				cli.StringSliceFlag{Name: "tag"},
+			}, globalFlags...),
+			Before: func(ctx *cli.Context) error {
+				args = fleetCommandArgs(ctx)
+				return nil
+			},
+			Action: func(ctx *cli.Context) error { return nil },
+		}},
+	}}
+	e := app.Run([]string{"mc", "admin", "heal", "-r", "/bucket", "--scan", "deep", "--tag", "a", "--tag", "b", "--aliases", "prod", "--alias-timeout", "1m", "--json"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := []string{"admin", "heal", "--recursive=true", "--scan=deep", "--tag=a", "--tag=b"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+}
+
+func TestIsFleetCommand(t *testing.T) {
+	var fleet []bool
+	var errs []*probe.Error
+	before := func(ctx *cli.Context) error {
+		ok, err := isFleetCommand(ctx)
+		fleet, errs = append(fleet, ok), append(errs, err)
+		return nil
+	}
+	newApp := func() *cli.App {
+		app := cli.NewApp()
+		app.Name = "mc"
+		app.Flags = globalFlags
+		app.Before = before
+		app.Commands = []cli.Command{{
+			Name:   "admin",
+			Flags:  globalFlags,
+			Before: before,
+			Subcommands: []cli.Command{{
+				Name:   "info",
+				Flags:  globalFlags,
+				Before: before,
+				Action: func(ctx *cli.Context) error { return nil },
+			}},
+		}}
+		return app
+	}
+
+	testCases := []struct {
+		args    []string
+		fleet   bool
+		invalid bool
+	}{
+		{[]string{"mc", "admin", "info", "prod"}, false, false},
+		{[]string{"mc", "admin", "info", "--aliases", "a,b"}, true, false},
+		{[]string{"mc", "admin", "info", "--alias-glob", "prod-*"}, true, false},
+		{[]string{"mc", "admin", "--aliases", "a,b", "info"}, false, true},
+		{[]string{"mc", "--aliases", "a,b", "admin", "info"}, false, true},
+	}
+	for i, tc := range testCases {
+		fleet, errs = nil, nil
+		if e := newApp().Run(tc.args); e != nil {
+			t.Fatal(e)
+		}
+		var gotFleet, gotInvalid bool
+		for j := range fleet {
+			gotFleet = gotFleet || fleet[j]
+			gotInvalid = gotInvalid || errs[j] != nil
+		}
+		if gotFleet != tc.fleet || gotInvalid != tc.invalid {
+			t.Errorf("Test %d: expected fleet %v and invalid %v, got %v and %v", i+1, tc.fleet, tc.invalid, gotFleet, gotInvalid)
+		}
+	}
+}
+
+func TestParseFleetJSON(t *testing.T) {
+	output := []byte(`{"status":"success","alias":"prod"}
+{
+ "status": "error",
+ "error": {
+  "message": "Unable to get service status.",
+  "cause": {
+   "message": "connection refused"
+  },
+  "type": "fatal"
+ }
+}
+`)
+	records, e := parseFleetJSON(output)
+	if len(records) != 1 || string(records[0]) != `{"status":"success","alias":"prod"}` {
+		t.Errorf("unexpected records %s", records)
+	}
+	if e == nil || e.Error() != "Unable to get service status. connection refused" {
+		t.Errorf("unexpected error %v", e)
+	}
+
+	if _, e = parseFleetJSON([]byte(`{"status":"error","error":"Access Denied."}`)); e == nil || e.Error() != "Access Denied." {
+		t.Errorf("unexpected error %v", e)
+	}
+	if _, e = parseFleetJSON([]byte(`{"status":"success"} oops`)); e == nil {
+		t.Error("expected an error for unexpected output")
+	}
+}
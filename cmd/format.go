@@ -0,0 +1,468 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+// Output formats of --format, the messages are formatted from their JSON
+// form: the fields of nested objects are named after their path joined
+// with dots, e.g. "metadata.Content-Type".
+const (
+	formatTable    = "table"
+	formatCSV      = "csv"
+	formatTSV      = "tsv"
+	formatJSONL    = "jsonl"
+	formatTemplate = "template"
+)
+
+// Rows of table are aligned in batches, printed when full or after a
+// delay, so that commands streaming messages like watch print them
+// as they come and don't buffer all their output.
+const (
+	formatTableBatchRows  = 1000
+	formatTableBatchDelay = time.Second
+)
+
+// globalFormat formats the messages printed by printMsg, nil for the
+// default output of --json or of the console.
+var globalFormat *outputFormat
+
+// formatField is a field of the JSON form of a message.
+type formatField struct {
+	key   string
+	value string
+}
+
+// outputFormat formats the messages of --format.
+type outputFormat struct {
+	kind     string
+	columns  []string
+	noHeader bool
+	tmpl     *template.Template
+
+	mu sync.Mutex
+	// header are the columns, from the first message of csv and tsv
+	// or the first batch of table if --columns is not set.
+	header []string
+	// rows are buffered by table to align the columns of a batch.
+	rows  [][]formatField
+	timer *time.Timer
+}
+
+// setGlobalFormatFromContext sets the output format of --format.
+func setGlobalFormatFromContext(ctx *cli.Context) {
+	format := ctx.String("format")
+	if format == "" {
+		format = ctx.GlobalString("format")
+	}
+	if format != "" && globalFormat == nil {
+		columns := ctx.String("columns")
+		if columns == "" {
+			columns = ctx.GlobalString("columns")
+		}
+		noHeader := ctx.IsSet("no-header") || ctx.GlobalIsSet("no-header")
+		f, err := parseOutputFormat(format, columns, noHeader)
+		fatalIf(err.Trace(format), "Unable to parse --format.")
+		globalFormat = f
+	}
+	if globalFormat != nil {
+		// Messages are formatted from their JSON form.
+		globalJSON, globalJSONLine, globalNoColor = true, true, true
+	}
+}
+
+// parseOutputFormat parses the values of --format and --columns.
+func parseOutputFormat(format, columns string, noHeader bool) (*outputFormat, *probe.Error) {
+	f := &outputFormat{kind: format, noHeader: noHeader}
+	if columns != "" {
+		for _, c := range strings.Split(columns, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				f.columns = append(f.columns, c)
+			}
+		}
+	}
+	switch {
+	case format == formatTable, format == formatCSV, format == formatTSV, format == formatJSONL:
+	case strings.HasPrefix(format, formatTemplate+"="):
+		f.kind = formatTemplate
+		text := unescapeFormatTemplate(strings.TrimPrefix(format, formatTemplate+"="))
+		tmpl, e := template.New("format").Funcs(formatTemplateFuncs).Option("missingkey=zero").Parse(text)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		f.tmpl = tmpl
+	default:
+		return nil, probe.NewError(fmt.Errorf("unknown format `%s`, expected table, csv, tsv, jsonl or template=TEMPLATE", format))
+	}
+	return f, nil
+}
+
+// unescapeFormatTemplate replaces the \t and \n escape sequences of a
+// template given on the command line.
+func unescapeFormatTemplate(s string) string {
+	return strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\\`, `\`).Replace(s)
+}
+
+// formatTemplateFuncs are the functions available to the templates.
+var formatTemplateFuncs = template.FuncMap{
+	// size formats a number of bytes, e.g. {{size .size}}.
+	"size": func(v interface{}) string {
+		var n json.Number
+		switch v := v.(type) {
+		case json.Number:
+			n = v
+		default:
+			n = json.Number(fmt.Sprint(v))
+		}
+		i, e := n.Int64()
+		if e != nil || i < 0 {
+			return fmt.Sprint(v)
+		}
+		return humanize.IBytes(uint64(i))
+	},
+	// json formats a value as JSON, e.g. {{json .metadata}}.
+	"json": func(v interface{}) string {
+		data, e := json.Marshal(v)
+		if e != nil {
+			return ""
+		}
+		return string(data)
+	},
+}
+
+// printsJSON returns true if errors are printed as JSON on the standard
+// output, like the messages.
+func (f *outputFormat) printsJSON() bool {
+	return f == nil || f.kind == formatJSONL
+}
+
+// print prints a message in the format.
+func (f *outputFormat) print(msg message) {
+	data := []byte(msg.JSON())
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch f.kind {
+	case formatTemplate:
+		f.printTemplate(data)
+		return
+	case formatJSONL:
+		f.printJSONL(data)
+		return
+	}
+	for _, fields := range flattenFormatJSON(data) {
+		if f.kind == formatTable {
+			f.rows = append(f.rows, fields)
+			if len(f.rows) == 1 {
+				f.timer = time.AfterFunc(formatTableBatchDelay, f.flush)
+			}
+			if len(f.rows) >= formatTableBatchRows {
+				f.flushTable()
+			}
+			continue
+		}
+		if f.header == nil {
+			f.header = f.columns
+			if len(f.header) == 0 {
+				for _, field := range fields {
+					f.header = append(f.header, field.key)
+				}
+			}
+			if !f.noHeader {
+				f.printRow(f.header)
+			}
+		}
+		f.printRow(selectFormatColumns(f.header, fields))
+	}
+}
+
+// printRow prints a row of csv or tsv.
+func (f *outputFormat) printRow(row []string) {
+	if f.kind == formatTSV {
+		escaped := make([]string, len(row))
+		for i, v := range row {
+			escaped[i] = escapeFormatValue(v)
+		}
+		console.Println(strings.Join(escaped, "\t"))
+		return
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(row)
+	w.Flush()
+	console.Print(buf.String())
+}
+
+// printJSONL prints a message as a JSON line, with only the selected
+// columns if any.
+func (f *outputFormat) printJSONL(data []byte) {
+	if len(f.columns) == 0 {
+		var dst bytes.Buffer
+		if e := json.Compact(&dst, data); e == nil {
+			data = dst.Bytes()
+		}
+		console.Println(string(data))
+		return
+	}
+	for _, fields := range flattenFormatJSON(data) {
+		values := selectFormatColumns(f.columns, fields)
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for i, c := range f.columns {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(c)
+			v, _ := json.Marshal(values[i])
+			buf.Write(k)
+			buf.WriteByte(':')
+			buf.Write(v)
+		}
+		buf.WriteByte('}')
+		console.Println(buf.String())
+	}
+}
+
+// printTemplate prints a message with the template.
+func (f *outputFormat) printTemplate(data []byte) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if e := dec.Decode(&v); e != nil {
+		console.Println(string(data))
+		return
+	}
+	var buf bytes.Buffer
+	if e := f.tmpl.Execute(&buf, withFieldNames(v)); e != nil {
+		errorIf(probe.NewError(e), "Unable to format the output with the template.")
+		return
+	}
+	console.Println(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// flush prints the rows buffered by table.
+func (f *outputFormat) flush() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flushTable()
+}
+
+// flushTable prints the batch of rows buffered by table, with the
+// header before the first batch.
+func (f *outputFormat) flushTable() {
+	if f.kind != formatTable || len(f.rows) == 0 {
+		return
+	}
+	f.timer.Stop()
+	printHeader := f.header == nil && !f.noHeader
+	if f.header == nil {
+		f.header = f.columns
+	}
+	if len(f.header) == 0 {
+		// Union of the fields of the messages of the first batch, in
+		// order of appearance.
+		seen := make(map[string]bool)
+		for _, fields := range f.rows {
+			for _, field := range fields {
+				if !seen[field.key] {
+					seen[field.key] = true
+					f.header = append(f.header, field.key)
+				}
+			}
+		}
+	}
+	header := f.header
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	writeRow := func(row []string) {
+		for i, v := range row {
+			row[i] = escapeFormatValue(v)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	if printHeader {
+		upper := make([]string, len(header))
+		for i, h := range header {
+			upper[i] = strings.ToUpper(h)
+		}
+		writeRow(upper)
+	}
+	for _, fields := range f.rows {
+		writeRow(selectFormatColumns(header, fields))
+	}
+	w.Flush()
+	console.Print(buf.String())
+	f.rows = nil
+}
+
+// flushOutputFormat prints the output buffered by --format before exiting.
+func flushOutputFormat() {
+	if globalFormat != nil {
+		globalFormat.flush()
+	}
+}
+
+// escapeFormatValue escapes the tabs and new lines of a value of a
+// tab separated row.
+func escapeFormatValue(v string) string {
+	return strings.NewReplacer("\\", `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`).Replace(v)
+}
+
+// selectFormatColumns returns the values of the columns, matched case
+// insensitively, empty for the fields missing in the message.
+func selectFormatColumns(columns []string, fields []formatField) []string {
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		for _, field := range fields {
+			if field.key == c {
+				row[i] = field.value
+				break
+			}
+			if strings.EqualFold(field.key, c) {
+				row[i] = field.value
+			}
+		}
+	}
+	return row
+}
+
+// flattenFormatJSON returns the rows of the JSON form of a message: its
+// fields in order, or the ones of each element of an array.
+func flattenFormatJSON(data []byte) [][]formatField {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var elems []json.RawMessage
+		if e := json.Unmarshal(data, &elems); e == nil {
+			var rows [][]formatField
+			for _, elem := range elems {
+				rows = append(rows, flattenFormatJSON(elem)...)
+			}
+			return rows
+		}
+	}
+	var fields []formatField
+	if e := flattenFormatObject("", data, &fields); e != nil {
+		return [][]formatField{{{key: "value", value: string(data)}}}
+	}
+	return [][]formatField{fields}
+}
+
+// flattenFormatObject appends the fields of a JSON value, named after
+// their path from prefix.
+func flattenFormatObject(prefix string, data []byte, fields *[]formatField) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		if prefix == "" {
+			return fmt.Errorf("not an object")
+		}
+		*fields = append(*fields, formatField{key: prefix, value: formatScalar(data)})
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, e := dec.Token(); e != nil {
+		return e
+	}
+	for dec.More() {
+		t, e := dec.Token()
+		if e != nil {
+			return e
+		}
+		key, _ := t.(string)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		var raw json.RawMessage
+		if e = dec.Decode(&raw); e != nil {
+			return e
+		}
+		if e = flattenFormatObject(key, raw, fields); e != nil {
+			return e
+		}
+	}
+	_, e := dec.Token()
+	if e == io.EOF {
+		e = nil
+	}
+	return e
+}
+
+// formatScalar returns a JSON value as text: strings unquoted, arrays
+// as compact JSON and null as empty.
+func formatScalar(data []byte) string {
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return ""
+	case data[0] == '"':
+		var s string
+		if e := json.Unmarshal(data, &s); e == nil {
+			return s
+		}
+	case data[0] == '[':
+		var dst bytes.Buffer
+		if e := json.Compact(&dst, data); e == nil {
+			return dst.String()
+		}
+	}
+	return string(data)
+}
+
+// withFieldNames returns a decoded JSON value where the fields of objects
+// are also available capitalized like the fields of the messages, e.g.
+// both {{.key}} and {{.Key}}.
+func withFieldNames(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, 2*len(v))
+		for k, e := range v {
+			m[k] = withFieldNames(e)
+		}
+		for k, e := range v {
+			if k == "" {
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(k)
+			name := string(unicode.ToUpper(r)) + k[size:]
+			if _, ok := m[name]; !ok {
+				m[name] = withFieldNames(e)
+			}
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = withFieldNames(e)
+		}
+	}
+	return v
+}
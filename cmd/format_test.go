@@ -0,0 +1,159 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	testCases := []struct {
+		format  string
+		columns string
+		ok      bool
+		kind    string
+		cols    []string
+	}{
+		{"table", "", true, formatTable, nil},
+		{"csv", "key, size,,", true, formatCSV, []string{"key", "size"}},
+		{"tsv", "key", true, formatTSV, []string{"key"}},
+		{"jsonl", "", true, formatJSONL, nil},
+		{`template={{.Key}}\t{{.Size}}`, "", true, formatTemplate, nil},
+		{"template={{.Key", "", false, "", nil},
+		{"yaml", "", false, "", nil},
+		{"template", "", false, "", nil},
+	}
+	for i, tc := range testCases {
+		f, err := parseOutputFormat(tc.format, tc.columns, false)
+		if (err == nil) != tc.ok {
+			t.Fatalf("Test %d: expected ok=%v, got %v", i+1, tc.ok, err)
+		}
+		if err != nil {
+			continue
+		}
+		if f.kind != tc.kind || !reflect.DeepEqual(f.columns, tc.cols) {
+			t.Fatalf("Test %d: unexpected format %+v", i+1, f)
+		}
+	}
+}
+
+func TestFlattenFormatJSON(t *testing.T) {
+	testCases := []struct {
+		data string
+		rows [][]formatField
+	}{
+		{
+			`{"status":"success","size":12,"key":"a b","metadata":{"Content-Type":"text/plain","n":null},"tags":["x","y"],"ok":true}`,
+			[][]formatField{{
+				{"status", "success"}, {"size", "12"}, {"key", "a b"},
+				{"metadata.Content-Type", "text/plain"}, {"metadata.n", ""},
+				{"tags", `["x","y"]`}, {"ok", "true"},
+			}},
+		},
+		{
+			`[{"a":1},{"a":2,"b":{"c":{"d":"e"}}}]`,
+			[][]formatField{{{"a", "1"}}, {{"a", "2"}, {"b.c.d", "e"}}},
+		},
+		{
+			`"plain"`,
+			[][]formatField{{{"value", `"plain"`}}},
+		},
+		{
+			"{\n \"size\": 1234567890123\n}",
+			[][]formatField{{{"size", "1234567890123"}}},
+		},
+	}
+	for i, tc := range testCases {
+		if rows := flattenFormatJSON([]byte(tc.data)); !reflect.DeepEqual(rows, tc.rows) {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, tc.rows, rows)
+		}
+	}
+}
+
+func TestSelectFormatColumns(t *testing.T) {
+	fields := []formatField{{"key", "a"}, {"Key", "b"}, {"size", "1"}, {"metadata.Content-Type", "text/plain"}}
+	row := selectFormatColumns([]string{"Key", "SIZE", "missing", "metadata.content-type"}, fields)
+	if !reflect.DeepEqual(row, []string{"b", "1", "", "text/plain"}) {
+		t.Fatalf("unexpected row %q", row)
+	}
+}
+
+func TestEscapeFormatValue(t *testing.T) {
+	if v := escapeFormatValue("a\tb\nc\\d"); v != `a\tb\nc\\d` {
+		t.Fatalf("unexpected value %q", v)
+	}
+	if v := unescapeFormatTemplate(`{{.key}}\t{{.size}}\n`); v != "{{.key}}\t{{.size}}\n" {
+		t.Fatalf("unexpected template %q", v)
+	}
+}
+
+func TestFormatTemplate(t *testing.T) {
+	f, err := parseOutputFormat(`template={{.Key}}\t{{.size}}\t{{size .Size}}\t{{.Metadata.Type}}\t{{json .tags}}`, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(`{"key":"a","size":2048,"metadata":{"type":"x"},"tags":["t"]}`)))
+	dec.UseNumber()
+	var v interface{}
+	if e := dec.Decode(&v); e != nil {
+		t.Fatal(e)
+	}
+	var buf bytes.Buffer
+	if e := f.tmpl.Execute(&buf, withFieldNames(v)); e != nil {
+		t.Fatal(e)
+	}
+	if got := buf.String(); got != "a\t2048\t2.0 KiB\tx\t[\"t\"]" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
+
+func TestOutputFormatPrintsJSON(t *testing.T) {
+	var f *outputFormat
+	if !f.printsJSON() {
+		t.Fatal("expected JSON errors without --format")
+	}
+	for kind, expected := range map[string]bool{formatJSONL: true, formatCSV: false, formatTable: false} {
+		if (&outputFormat{kind: kind}).printsJSON() != expected {
+			t.Fatalf("%s: expected %v", kind, expected)
+		}
+	}
+}
+
+func TestFormatTableBatches(t *testing.T) {
+	f, err := parseOutputFormat(formatTable, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.print(jsonMessage{map[string]string{"key": "a"}})
+	if len(f.rows) != 1 {
+		t.Fatalf("expected 1 buffered row, got %d", len(f.rows))
+	}
+
+	// A batch is printed after a delay, keeping the columns of the first one.
+	time.Sleep(2 * formatTableBatchDelay)
+	f.mu.Lock()
+	rows, header := len(f.rows), f.header
+	f.mu.Unlock()
+	if rows != 0 || !reflect.DeepEqual(header, []string{"key"}) {
+		t.Fatalf("expected the batch to be printed, got %d rows and header %v", rows, header)
+	}
+}
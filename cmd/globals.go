@@ -96,6 +96,7 @@ func setGlobalsFromContext(ctx *cli.Context) error {
 	globalNoColor = globalNoColor || noColor || globalJSONLine
 	globalInsecure = globalInsecure || insecure
 	globalDevMode = globalDevMode || devMode
+	setGlobalFormatFromContext(ctx)
 
 	// Disable colorified messages if requested.
 	if globalNoColor || globalQuiet {
@@ -105,5 +106,13 @@ func setGlobalsFromContext(ctx *cli.Context) error {
 	globalConnReadDeadline = ctx.Duration("conn-read-deadline")
 	globalConnWriteDeadline = ctx.Duration("conn-write-deadline")
 
+	// Commands for several aliases run in fleet mode, mainFleet
+	// runs them for each alias and exits.
+	fleet, err := isFleetCommand(ctx)
+	fatalIf(err, "Invalid command usage.")
+	if fleet {
+		mainFleet(ctx)
+	}
+
 	return nil
 }
@@ -31,6 +31,7 @@ var ilmSubcommands = []cli.Command{
 	ilmExportCmd,
 	ilmImportCmd,
 	ilmRestoreCmd,
+	ilmSimulateCmd,
 }
 
 var ilmCmd = cli.Command{
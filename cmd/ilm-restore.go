@@ -24,7 +24,6 @@ import (
 	"time"
 
 	"github.com/minio/cli"
-	json "github.com/minio/colorjson"
 	"github.com/minio/mc/pkg/probe"
 )
 
@@ -285,8 +284,7 @@ func showRestoreStatus(restoreReqStatus, restoreFinishedStatus chan *probe.Error
 			Restored int    `json:"restored"`
 		}
 
-		msgBytes, _ := json.Marshal(ilmRestore{Status: "success", Restored: sent})
-		fmt.Println(string(msgBytes))
+		printMsg(jsonMessage{ilmRestore{Status: "success", Restored: sent}})
 	}
 
 	close(doneCh)
@@ -0,0 +1,353 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/cmd/ilm"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/minio/pkg/console"
+	"github.com/olekukonko/tablewriter"
+)
+
+var ilmSimulateFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "rules",
+		Usage: "lifecycle configuration in JSON format to simulate instead of the configuration of the bucket",
+	},
+	cli.StringFlag{
+		Name:  "at",
+		Usage: "date to simulate the lifecycle at, as YYYY-MM-DD or RFC3339 (default: now)",
+	},
+}
+
+var ilmSimulateCmd = cli.Command{
+	Name:         "simulate",
+	Usage:        "show the versions a lifecycle configuration expires or transitions at a date",
+	Action:       mainILMSimulate,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(ilmSimulateFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Walk all versions of the objects of a bucket and evaluate the lifecycle configuration
+  locally, without changing anything. Every version expired, transitioned or delete marker
+  removed when the date is reached is listed with the rule responsible, followed by the
+  number of versions and bytes per rule, and the bytes freed.
+
+  On a versioned bucket, expiring the current version of an object adds a delete marker
+  and frees nothing: the version is kept as noncurrent from then on, until the noncurrent
+  rules expire it.
+
+  Versions are evaluated as they are now: versions written later are not taken into account.
+
+EXAMPLES:
+  1. Show what the lifecycle configuration of 'mybucket' would do by June 1st, 2023.
+     {{.Prompt}} {{.HelpName}} --at 2023-06-01 myminio/mybucket
+
+  2. Try new rules exported with 'mc ilm export' and edited, before importing them.
+     {{.Prompt}} {{.HelpName}} --rules lifecycle.json --at 2023-06-01 myminio/mybucket
+
+  3. Show the totals per rule for the 'logs/' prefix in JSON format.
+     {{.Prompt}} {{.HelpName}} --json --at 2023-06-01 myminio/mybucket/logs/
+`,
+}
+
+// ilmSimulateMessage is a version the lifecycle configuration acts on.
+type ilmSimulateMessage struct {
+	Status    string    `json:"status"`
+	Key       string    `json:"key"`
+	VersionID string    `json:"versionId,omitempty"`
+	Action    string    `json:"action"`
+	Rule      string    `json:"rule"`
+	Tier      string    `json:"tier,omitempty"`
+	Due       time.Time `json:"due"`
+	Size      int64     `json:"size"`
+}
+
+func (m ilmSimulateMessage) String() string {
+	theme := ilmThemeRow
+	if m.Action != ilm.ActionTransition && m.Action != ilm.ActionTransitionNoncurrent {
+		theme = ilmThemeResultFailure
+	}
+	action := m.Action
+	if m.Tier != "" {
+		action += " to " + m.Tier
+	}
+	key := m.Key
+	if m.VersionID != "" {
+		key += " (" + m.VersionID + ")"
+	}
+	return fmt.Sprintf("%s %s %9s %s [%s]", m.Due.Format(printDate), console.Colorize(theme, fmt.Sprintf("%-32s", action)),
+		humanize.IBytes(uint64(m.Size)), key, m.Rule)
+}
+
+func (m ilmSimulateMessage) JSON() string {
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// ilmSimulateTotal is the number of versions and bytes a rule acts on.
+type ilmSimulateTotal struct {
+	Rule     string `json:"rule"`
+	Action   string `json:"action"`
+	Tier     string `json:"tier,omitempty"`
+	Versions int    `json:"versions"`
+	Size     int64  `json:"size"`
+	Freed    int64  `json:"freed"`
+}
+
+// ilmSimulateSummaryMessage reports the totals of a simulation.
+type ilmSimulateSummaryMessage struct {
+	Status string             `json:"status"`
+	Target string             `json:"target"`
+	At     time.Time          `json:"at"`
+	Totals []ilmSimulateTotal `json:"totals"`
+}
+
+func (m ilmSimulateSummaryMessage) String() string {
+	if len(m.Totals) == 0 {
+		return console.Colorize(ilmThemeResultSuccess, "No version of `"+m.Target+"` is affected by "+m.At.Format(printDate)+".")
+	}
+	var s strings.Builder
+	table := tablewriter.NewWriter(&s)
+	table.SetAutoWrapText(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t") // pad with tabs
+	table.SetNoWhiteSpace(true)
+	table.SetHeader([]string{"Rule", "Action", "Tier", "Versions", "Size", "Freed"})
+	for _, t := range m.Totals {
+		table.Append([]string{
+			t.Rule, t.Action, t.Tier, fmt.Sprint(t.Versions),
+			humanize.IBytes(uint64(t.Size)), humanize.IBytes(uint64(t.Freed)),
+		})
+	}
+	table.Render()
+	return "\n" + console.Colorize(ilmMainHeader, "Totals by "+m.At.Format(printDate)+":") + "\n" + strings.TrimSuffix(s.String(), "\n")
+}
+
+func (m ilmSimulateSummaryMessage) JSON() string {
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// parseILMSimulateDate parses the date of a simulation, a day starts
+// at midnight UTC like the lifecycle actions.
+func parseILMSimulateDate(date string) (time.Time, *probe.Error) {
+	if date == "" {
+		return UTCNow(), nil
+	}
+	if t, e := time.Parse("2006-01-02", date); e == nil {
+		return t, nil
+	}
+	t, e := time.Parse(time.RFC3339, date)
+	if e != nil {
+		return time.Time{}, probe.NewError(fmt.Errorf("invalid date `%s`, expected YYYY-MM-DD or RFC3339", date))
+	}
+	return t.UTC(), nil
+}
+
+// readILMSimulateRules reads a lifecycle configuration in the format
+// of 'mc ilm export'.
+func readILMSimulateRules(file string) (*lifecycle.Configuration, *probe.Error) {
+	f, e := os.Open(file)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	defer f.Close()
+
+	cfg := lifecycle.NewConfiguration()
+	if e = json.NewDecoder(f).Decode(cfg); e != nil {
+		return nil, probe.NewError(e)
+	}
+	return cfg, nil
+}
+
+// ilmSimulator accumulates the totals of the versions a lifecycle
+// configuration acts on.
+type ilmSimulator struct {
+	cfg        *lifecycle.Configuration
+	versioning string
+	at         time.Time
+	totals     map[ilmSimulateTotal]*ilmSimulateTotal
+}
+
+// simulate evaluates the versions of an object, newest first.
+func (s *ilmSimulator) simulate(versions []ilm.ObjectVersion) (msgs []ilmSimulateMessage) {
+	for _, ev := range ilm.Simulate(s.cfg, versions, s.versioning, s.at) {
+		key := ilmSimulateTotal{Rule: ev.RuleID, Action: ev.Action, Tier: ev.Tier}
+		total, ok := s.totals[key]
+		if !ok {
+			total = &ilmSimulateTotal{Rule: ev.RuleID, Action: ev.Action, Tier: ev.Tier}
+			s.totals[key] = total
+		}
+		total.Versions++
+		total.Size += ev.Version.Size
+		total.Freed += ev.Freed
+		msgs = append(msgs, ilmSimulateMessage{
+			Status:    "success",
+			Key:       ev.Version.Key,
+			VersionID: ev.Version.VersionID,
+			Action:    ev.Action,
+			Rule:      ev.RuleID,
+			Tier:      ev.Tier,
+			Due:       ev.Due,
+			Size:      ev.Version.Size,
+		})
+	}
+	return msgs
+}
+
+// sortedTotals returns the totals sorted by rule and action.
+func (s *ilmSimulator) sortedTotals() []ilmSimulateTotal {
+	totals := make([]ilmSimulateTotal, 0, len(s.totals))
+	for _, t := range s.totals {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Rule != totals[j].Rule {
+			return totals[i].Rule < totals[j].Rule
+		}
+		if totals[i].Action != totals[j].Action {
+			return totals[i].Action < totals[j].Action
+		}
+		return totals[i].Tier < totals[j].Tier
+	})
+	return totals
+}
+
+func checkILMSimulateSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "simulate", globalErrorExitStatus)
+	}
+}
+
+func mainILMSimulate(cliCtx *cli.Context) error {
+	ctx, cancelILMSimulate := context.WithCancel(globalContext)
+	defer cancelILMSimulate()
+
+	checkILMSimulateSyntax(cliCtx)
+	setILMDisplayColorScheme()
+
+	urlStr := cliCtx.Args().Get(0)
+	alias, _ := url2Alias(urlStr)
+
+	at, err := parseILMSimulateDate(cliCtx.String("at"))
+	fatalIf(err, "Unable to parse the simulation date.")
+
+	client, err := newClient(urlStr)
+	fatalIf(err.Trace(urlStr), "Unable to initialize client for "+urlStr)
+
+	var cfg *lifecycle.Configuration
+	if file := cliCtx.String("rules"); file != "" {
+		cfg, err = readILMSimulateRules(file)
+		fatalIf(err.Trace(file), "Unable to read the lifecycle configuration.")
+	} else {
+		cfg, err = client.GetLifecycle(ctx)
+		fatalIf(err.Trace(urlStr), "Unable to get the lifecycle configuration of "+urlStr)
+	}
+	if len(cfg.Rules) == 0 {
+		fatalIf(errDummy().Trace(urlStr), "The lifecycle configuration does not contain any rule.")
+	}
+	fatalIf(ilm.ValidateILMConfig(cfg), "Invalid lifecycle configuration.")
+	needsTags := ilm.NeedsTags(cfg)
+
+	vcfg, err := client.GetVersion(ctx)
+	fatalIf(err.Trace(urlStr), "Unable to get the versioning configuration of "+urlStr)
+
+	sim := &ilmSimulator{
+		cfg:        cfg,
+		versioning: vcfg.Status,
+		at:         at,
+		totals:     make(map[ilmSimulateTotal]*ilmSimulateTotal),
+	}
+	var versions []ilm.ObjectVersion
+	flush := func() {
+		for _, msg := range sim.simulate(versions) {
+			printMsg(msg)
+		}
+		versions = versions[:0]
+	}
+	for content := range client.List(ctx, ListOptions{
+		Recursive:         true,
+		WithOlderVersions: true,
+		WithDeleteMarkers: true,
+		ShowDir:           DirNone,
+	}) {
+		if content.Err != nil {
+			errorIf(content.Err.Trace(urlStr), "Unable to list folder.")
+			continue
+		}
+		_, key := url2BucketAndObject(&content.URL)
+		if len(versions) > 0 && versions[0].Key != key {
+			flush()
+		}
+		v := ilm.ObjectVersion{
+			Key:            key,
+			VersionID:      content.VersionID,
+			ModTime:        content.Time,
+			Size:           content.Size,
+			StorageClass:   content.StorageClass,
+			IsLatest:       content.IsLatest || content.VersionID == "",
+			IsDeleteMarker: content.IsDeleteMarker,
+		}
+		if needsTags && !v.IsDeleteMarker {
+			clnt, err := newClientFromAlias(alias, content.URL.String())
+			if err == nil {
+				v.Tags, err = clnt.GetTags(ctx, v.VersionID)
+			}
+			errorIf(err.Trace(content.URL.String()), "Unable to get the tags of the object, rules with tags are not applied to it.")
+		}
+		versions = append(versions, v)
+	}
+	flush()
+
+	printMsg(ilmSimulateSummaryMessage{
+		Status: "success",
+		Target: urlStr,
+		At:     at,
+		Totals: sim.sortedTotals(),
+	})
+	return nil
+}
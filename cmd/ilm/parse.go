@@ -173,6 +173,31 @@ func validateILMRule(rule lifecycle.Rule) *probe.Error {
 	return nil
 }
 
+// ValidateILMConfig checks the rules of an existing lifecycle
+// configuration, their dates may be in the past.
+func ValidateILMConfig(cfg *lifecycle.Configuration) *probe.Error {
+	ids := make(map[string]bool, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		if ids[rule.ID] {
+			return probe.NewError(errors.New("duplicate rule ID " + rule.ID))
+		}
+		ids[rule.ID] = true
+		for _, validate := range []func(lifecycle.Rule) error{
+			validateRuleAction,
+			validateExpiration,
+			validateTranExpDate,
+			validateTranDays,
+			validateNoncurrentExpiration,
+			validateNoncurrentTransition,
+		} {
+			if e := validate(rule); e != nil {
+				return probe.NewError(e).Trace(rule.ID)
+			}
+		}
+	}
+	return nil
+}
+
 func parseTransitionDate(transitionDateStr string) (lifecycle.ExpirationDate, *probe.Error) {
 	transitionDate, e := time.Parse(defaultILMDateFormat, transitionDateStr)
 	if e != nil {
@@ -0,0 +1,282 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ilm
+
+import (
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+// Actions of a lifecycle configuration on an object version.
+const (
+	ActionNone                 = ""
+	ActionExpire               = "expire"
+	ActionAddDeleteMarker      = "add-delete-marker"
+	ActionExpireNoncurrent     = "expire-noncurrent"
+	ActionTransition           = "transition"
+	ActionTransitionNoncurrent = "transition-noncurrent"
+	ActionDeleteMarkerCleanup  = "delete-marker-cleanup"
+)
+
+// ObjectVersion is a version of an object as listed in a bucket.
+type ObjectVersion struct {
+	Key            string
+	VersionID      string
+	ModTime        time.Time
+	Size           int64
+	StorageClass   string
+	IsLatest       bool
+	IsDeleteMarker bool
+	Tags           map[string]string
+}
+
+// Event is the action due on an object version at a date.
+type Event struct {
+	Version ObjectVersion
+	Action  string
+	RuleID  string
+	Tier    string
+	Due     time.Time
+	// Freed is the number of bytes the action removes from the bucket.
+	Freed int64
+}
+
+// ExpectedExpiryTime returns the time an action set days after
+// modTime is due, actions run at midnight UTC.
+func ExpectedExpiryTime(modTime time.Time, days int) time.Time {
+	if days == 0 {
+		return modTime
+	}
+	t := modTime.UTC().Add(time.Duration(days+1) * 24 * time.Hour)
+	return t.Truncate(24 * time.Hour)
+}
+
+// RulePrefix returns the prefix a rule applies to.
+func RulePrefix(rule lifecycle.Rule) string {
+	switch {
+	case rule.RuleFilter.Prefix != "":
+		return rule.RuleFilter.Prefix
+	case rule.RuleFilter.And.Prefix != "":
+		return rule.RuleFilter.And.Prefix
+	}
+	return rule.Prefix
+}
+
+// RuleTags returns the tags an object needs for a rule to apply.
+func RuleTags(rule lifecycle.Rule) []lifecycle.Tag {
+	if !rule.RuleFilter.Tag.IsEmpty() {
+		return []lifecycle.Tag{rule.RuleFilter.Tag}
+	}
+	return rule.RuleFilter.And.Tags
+}
+
+// NeedsTags returns whether a rule of the configuration filters on
+// tags, so that object tags are needed to evaluate it.
+func NeedsTags(cfg *lifecycle.Configuration) bool {
+	for _, rule := range cfg.Rules {
+		if len(RuleTags(rule)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleApplies(rule lifecycle.Rule, v ObjectVersion) bool {
+	if rule.Status != "Enabled" || !strings.HasPrefix(v.Key, RulePrefix(rule)) {
+		return false
+	}
+	for _, tag := range RuleTags(rule) {
+		if val, ok := v.Tags[tag.Key]; !ok || val != tag.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// currentEvent returns the action of a rule on the current version.
+func currentEvent(rule lifecycle.Rule, v ObjectVersion, at time.Time) (ev Event) {
+	if v.IsDeleteMarker {
+		return ev
+	}
+	switch {
+	case !rule.Expiration.IsDateNull():
+		ev = Event{Action: ActionExpire, Due: rule.Expiration.Date.Time}
+	case !rule.Expiration.IsDaysNull():
+		ev = Event{Action: ActionExpire, Due: ExpectedExpiryTime(v.ModTime, int(rule.Expiration.Days))}
+	}
+	if ev.Action != ActionNone && !ev.Due.After(at) {
+		return ev
+	}
+	ev = Event{}
+	if tier := rule.Transition.StorageClass; tier != "" && tier != v.StorageClass {
+		due := ExpectedExpiryTime(v.ModTime, int(rule.Transition.Days))
+		if !rule.Transition.IsDateNull() {
+			due = rule.Transition.Date.Time
+		}
+		if !due.After(at) {
+			ev = Event{Action: ActionTransition, Tier: tier, Due: due}
+		}
+	}
+	return ev
+}
+
+// noncurrentEvent returns the action of a rule on a noncurrent version,
+// which became noncurrent at successor and has newer noncurrent
+// versions.
+func noncurrentEvent(rule lifecycle.Rule, v ObjectVersion, successor time.Time, newer int, at time.Time) Event {
+	exp := rule.NoncurrentVersionExpiration
+	if exp.NoncurrentDays > 0 || exp.NewerNoncurrentVersions > 0 {
+		due := ExpectedExpiryTime(successor, int(exp.NoncurrentDays))
+		if newer >= exp.NewerNoncurrentVersions && !due.After(at) {
+			return Event{Action: ActionExpireNoncurrent, Due: due}
+		}
+	}
+	tran := rule.NoncurrentVersionTransition
+	if tier := tran.StorageClass; tier != "" && tier != v.StorageClass && !v.IsDeleteMarker {
+		due := ExpectedExpiryTime(successor, int(tran.NoncurrentDays))
+		if !due.After(at) {
+			return Event{Action: ActionTransitionNoncurrent, Tier: tier, Due: due}
+		}
+	}
+	return Event{}
+}
+
+// better returns whether an event takes precedence over another,
+// expiration over transition, then the earliest.
+func (ev Event) better(other Event) bool {
+	if other.Action == ActionNone {
+		return ev.Action != ActionNone
+	}
+	expires := func(action string) bool {
+		return action == ActionExpire || action == ActionAddDeleteMarker || action == ActionExpireNoncurrent
+	}
+	if expires(ev.Action) != expires(other.Action) {
+		return expires(ev.Action)
+	}
+	return ev.Due.Before(other.Due)
+}
+
+// evaluate returns the action of the rule taking precedence on a
+// version, evaluated as noncurrent since successor unless current.
+func evaluate(cfg *lifecycle.Configuration, v ObjectVersion, current bool, successor time.Time, newer int, at time.Time) (ev Event) {
+	for _, rule := range cfg.Rules {
+		if !ruleApplies(rule, v) {
+			continue
+		}
+		var rev Event
+		if current {
+			rev = currentEvent(rule, v, at)
+		} else {
+			rev = noncurrentEvent(rule, v, successor, newer, at)
+		}
+		if rev.better(ev) {
+			rev.RuleID = rule.ID
+			ev = rev
+		}
+	}
+	ev.Version = v
+	switch ev.Action {
+	case ActionExpire, ActionExpireNoncurrent:
+		ev.Freed = v.Size
+	}
+	return ev
+}
+
+// Simulate returns the actions of a lifecycle configuration due at a
+// date on the versions of an object, sorted newest first. versioning
+// is the versioning status of the bucket, "Enabled", "Suspended" or
+// empty: expiring the current version of a versioned bucket only adds
+// a delete marker, the version stays as noncurrent.
+func Simulate(cfg *lifecycle.Configuration, versions []ObjectVersion, versioning string, at time.Time) (events []Event) {
+	var successor time.Time
+	newer := 0
+	expired := 0
+	for i, v := range versions {
+		ev := evaluate(cfg, v, v.IsLatest, successor, newer, at)
+		if !v.IsLatest {
+			newer++
+		}
+		successor = v.ModTime
+		if ev.Action == ActionNone {
+			continue
+		}
+		if ev.Action != ActionExpire || versioning == "" {
+			events = append(events, ev)
+			if i > 0 && ev.Action == ActionExpireNoncurrent {
+				expired++
+			}
+			continue
+		}
+
+		ev.Action = ActionAddDeleteMarker
+		// A suspended bucket replaces the null version by the delete marker.
+		if versioning == "Suspended" && (v.VersionID == "" || v.VersionID == "null") {
+			events = append(events, ev)
+			continue
+		}
+		ev.Freed = 0
+		events = append(events, ev)
+		// The version is noncurrent from its expiration on.
+		if nev := evaluate(cfg, v, false, ev.Due, 0, at); nev.Action != ActionNone {
+			events = append(events, nev)
+		}
+		newer++
+	}
+
+	// A delete marker left without versions is removed by the rules
+	// expiring delete markers.
+	if len(versions) == 0 || !versions[0].IsLatest || !versions[0].IsDeleteMarker || expired != len(versions)-1 {
+		return events
+	}
+	dm := versions[0]
+	last := dm.ModTime
+	for _, ev := range events {
+		if ev.Due.After(last) {
+			last = ev.Due
+		}
+	}
+	var cleanup Event
+	for _, rule := range cfg.Rules {
+		if !ruleApplies(rule, dm) {
+			continue
+		}
+		due := last
+		switch {
+		case rule.Expiration.IsDeleteMarkerExpirationEnabled():
+		case !rule.Expiration.IsDaysNull():
+			if d := ExpectedExpiryTime(dm.ModTime, int(rule.Expiration.Days)); d.After(due) {
+				due = d
+			}
+		default:
+			continue
+		}
+		if due.After(at) {
+			continue
+		}
+		if ev := (Event{Action: ActionDeleteMarkerCleanup, Due: due, RuleID: rule.ID}); ev.better(cleanup) {
+			cleanup = ev
+		}
+	}
+	if cleanup.Action != ActionNone {
+		cleanup.Version = dm
+		events = append([]Event{cleanup}, events...)
+	}
+	return events
+}
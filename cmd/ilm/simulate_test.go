@@ -0,0 +1,245 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ilm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+func day(d int) time.Time {
+	return time.Date(2023, 1, d, 10, 30, 0, 0, time.UTC)
+}
+
+func TestExpectedExpiryTime(t *testing.T) {
+	if got := ExpectedExpiryTime(day(1), 30); !got.Equal(time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected expiry time %v", got)
+	}
+	if got := ExpectedExpiryTime(day(1), 0); !got.Equal(day(1)) {
+		t.Errorf("unexpected expiry time %v", got)
+	}
+}
+
+func testSimulateConfig() *lifecycle.Configuration {
+	return &lifecycle.Configuration{Rules: []lifecycle.Rule{
+		{
+			ID:         "logs",
+			Status:     "Enabled",
+			RuleFilter: lifecycle.Filter{Prefix: "logs/"},
+			Expiration: lifecycle.Expiration{Days: 30},
+			Transition: lifecycle.Transition{Days: 7, StorageClass: "WARM"},
+			NoncurrentVersionExpiration: lifecycle.NoncurrentVersionExpiration{
+				NoncurrentDays:          10,
+				NewerNoncurrentVersions: 1,
+			},
+		},
+		{
+			ID:                          "tmp",
+			Status:                      "Enabled",
+			RuleFilter:                  lifecycle.Filter{Prefix: "tmp/"},
+			Expiration:                  lifecycle.Expiration{DeleteMarker: true},
+			NoncurrentVersionExpiration: lifecycle.NoncurrentVersionExpiration{NoncurrentDays: 1},
+		},
+		{
+			ID:     "tagged",
+			Status: "Enabled",
+			RuleFilter: lifecycle.Filter{And: lifecycle.And{
+				Prefix: "data/",
+				Tags:   []lifecycle.Tag{{Key: "tmp", Value: "true"}},
+			}},
+			Expiration: lifecycle.Expiration{Days: 1},
+		},
+		{
+			ID:         "disabled",
+			Status:     "Disabled",
+			Expiration: lifecycle.Expiration{Days: 1},
+		},
+	}}
+}
+
+func TestSimulate(t *testing.T) {
+	cfg := testSimulateConfig()
+	testCases := []struct {
+		name     string
+		versions []ObjectVersion
+		at       time.Time
+		expected []Event
+	}{
+		{
+			name:     "too early",
+			versions: []ObjectVersion{{Key: "logs/a", ModTime: day(1), IsLatest: true}},
+			at:       day(5),
+		},
+		{
+			name:     "transition",
+			versions: []ObjectVersion{{Key: "logs/a", ModTime: day(1), IsLatest: true}},
+			at:       day(10),
+			expected: []Event{{Action: ActionTransition, RuleID: "logs", Tier: "WARM", Due: time.Date(2023, 1, 9, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:     "already transitioned",
+			versions: []ObjectVersion{{Key: "logs/a", ModTime: day(1), StorageClass: "WARM", IsLatest: true}},
+			at:       day(10),
+		},
+		{
+			name:     "expiration wins",
+			versions: []ObjectVersion{{Key: "logs/a", ModTime: day(1), IsLatest: true}},
+			at:       time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+			expected: []Event{{Action: ActionExpire, RuleID: "logs", Due: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name: "newer noncurrent versions kept",
+			versions: []ObjectVersion{
+				{Key: "logs/a", ModTime: day(20), IsLatest: true},
+				{Key: "logs/a", VersionID: "v2", ModTime: day(10)},
+				{Key: "logs/a", VersionID: "v1", ModTime: day(5)},
+			},
+			at:       day(25),
+			expected: []Event{{Action: ActionExpireNoncurrent, RuleID: "logs", Due: time.Date(2023, 1, 21, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name: "delete marker cleanup",
+			versions: []ObjectVersion{
+				{Key: "tmp/b", ModTime: day(10), IsLatest: true, IsDeleteMarker: true},
+				{Key: "tmp/b", VersionID: "v1", ModTime: day(5), Size: 10},
+			},
+			at: day(25),
+			expected: []Event{
+				{Action: ActionDeleteMarkerCleanup, RuleID: "tmp", Due: time.Date(2023, 1, 12, 0, 0, 0, 0, time.UTC)},
+				{Action: ActionExpireNoncurrent, RuleID: "tmp", Due: time.Date(2023, 1, 12, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "delete marker with versions left",
+			versions: []ObjectVersion{
+				{Key: "logs/b", ModTime: day(10), IsLatest: true, IsDeleteMarker: true},
+				{Key: "logs/b", VersionID: "v1", ModTime: day(5), Size: 10},
+			},
+			at: day(25),
+		},
+		{
+			name:     "tags",
+			versions: []ObjectVersion{{Key: "data/a", ModTime: day(1), IsLatest: true, Tags: map[string]string{"tmp": "true"}}},
+			at:       day(5),
+			expected: []Event{{Action: ActionExpire, RuleID: "tagged", Due: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:     "missing tag",
+			versions: []ObjectVersion{{Key: "data/a", ModTime: day(1), IsLatest: true}},
+			at:       day(5),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			events := Simulate(cfg, tc.versions, "", tc.at)
+			if len(events) != len(tc.expected) {
+				t.Fatalf("expected %d events, got %+v", len(tc.expected), events)
+			}
+			for i, ev := range events {
+				exp := tc.expected[i]
+				if ev.Action != exp.Action || ev.RuleID != exp.RuleID || ev.Tier != exp.Tier || !ev.Due.Equal(exp.Due) {
+					t.Errorf("event %d: expected %+v, got %+v", i, exp, ev)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulateVersioned(t *testing.T) {
+	cfg := &lifecycle.Configuration{Rules: []lifecycle.Rule{{
+		ID:                          "all",
+		Status:                      "Enabled",
+		Expiration:                  lifecycle.Expiration{Days: 30},
+		NoncurrentVersionExpiration: lifecycle.NoncurrentVersionExpiration{NoncurrentDays: 10},
+	}}}
+	expiry := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	noncurrentExpiry := time.Date(2023, 2, 12, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name       string
+		versioning string
+		versionID  string
+		at         time.Time
+		expected   []Event
+	}{
+		{
+			name:     "unversioned",
+			at:       expiry,
+			expected: []Event{{Action: ActionExpire, Due: expiry, Freed: 10}},
+		},
+		{
+			name:       "delete marker added",
+			versioning: "Enabled",
+			versionID:  "v1",
+			at:         expiry.Add(24 * time.Hour),
+			expected:   []Event{{Action: ActionAddDeleteMarker, Due: expiry}},
+		},
+		{
+			name:       "noncurrent since the expiration",
+			versioning: "Enabled",
+			versionID:  "v1",
+			at:         noncurrentExpiry,
+			expected: []Event{
+				{Action: ActionAddDeleteMarker, Due: expiry},
+				{Action: ActionExpireNoncurrent, Due: noncurrentExpiry, Freed: 10},
+			},
+		},
+		{
+			name:       "null version replaced",
+			versioning: "Suspended",
+			versionID:  "null",
+			at:         noncurrentExpiry,
+			expected:   []Event{{Action: ActionAddDeleteMarker, Due: expiry, Freed: 10}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			versions := []ObjectVersion{{Key: "a", VersionID: tc.versionID, ModTime: day(1), Size: 10, IsLatest: true}}
+			events := Simulate(cfg, versions, tc.versioning, tc.at)
+			if len(events) != len(tc.expected) {
+				t.Fatalf("expected %d events, got %+v", len(tc.expected), events)
+			}
+			for i, ev := range events {
+				exp := tc.expected[i]
+				if ev.Action != exp.Action || !ev.Due.Equal(exp.Due) || ev.Freed != exp.Freed {
+					t.Errorf("event %d: expected %+v, got %+v", i, exp, ev)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateILMConfig(t *testing.T) {
+	if err := ValidateILMConfig(testSimulateConfig()); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	rule := lifecycle.Rule{ID: "a", Status: "Enabled", Expiration: lifecycle.Expiration{Date: lifecycle.ExpirationDate{Time: day(1)}}}
+	if err := ValidateILMConfig(&lifecycle.Configuration{Rules: []lifecycle.Rule{rule}}); err != nil {
+		t.Errorf("rules with past dates should be valid, got %v", err)
+	}
+	if err := ValidateILMConfig(&lifecycle.Configuration{Rules: []lifecycle.Rule{rule, rule}}); err == nil {
+		t.Error("expected an error for duplicate rule IDs")
+	}
+	if err := ValidateILMConfig(&lifecycle.Configuration{Rules: []lifecycle.Rule{{ID: "b", Status: "Enabled"}}}); err == nil {
+		t.Error("expected an error for a rule without action")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var indexBuildFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "out",
+		Usage: "directory to store the index in",
+	},
+	cli.BoolFlag{
+		Name:  "versions",
+		Usage: "index all object versions and delete markers",
+	},
+}
+
+var indexBuildCmd = cli.Command{
+	Name:         "build",
+	Usage:        "build a local index of a bucket listing",
+	Action:       mainIndexBuild,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(indexBuildFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET --out DIR
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Store keys, sizes, ETags, modification times, version IDs, storage classes
+  and tags of all objects under TARGET in a compact sorted index. 'find', 'du'
+  and 'diff' answer queries from the index with --index DIR, without listing
+  the bucket again. Use 'mc index update' to refresh the index.
+
+EXAMPLES:
+  1. Build an index of all objects in "mybucket".
+     {{.Prompt}} {{.HelpName}} myminio/mybucket --out idx/
+
+  2. Build an index of all object versions under the "photos/" prefix.
+     {{.Prompt}} {{.HelpName}} --versions myminio/mybucket/photos/ --out idx/
+`,
+}
+
+func checkIndexBuildSyntax(cliCtx *cli.Context) {
+	if len(cliCtx.Args()) != 1 || cliCtx.String("out") == "" {
+		cli.ShowCommandHelpAndExit(cliCtx, "build", 1) // last argument is exit code
+	}
+}
+
+// mainIndexBuild is the handle for "mc index build" command.
+func mainIndexBuild(cliCtx *cli.Context) error {
+	ctx, cancelIndexBuild := context.WithCancel(globalContext)
+	defer cancelIndexBuild()
+
+	console.SetColor("Index", color.New(color.FgGreen, color.Bold))
+
+	checkIndexBuildSyntax(cliCtx)
+
+	aliasedURL := cliCtx.Args().Get(0)
+	alias, urlStr, hostCfg, err := expandAlias(aliasedURL)
+	fatalIf(err.Trace(aliasedURL), "Unable to expand alias.")
+	if hostCfg == nil {
+		fatalIf(errInvalidArgument().Trace(aliasedURL), "Only object storage targets can be indexed.")
+	}
+
+	clnt, err := newClientFromAlias(alias, urlStr)
+	fatalIf(err.Trace(aliasedURL), "Unable to initialize `"+aliasedURL+"`.")
+
+	targetURL := clnt.GetURL()
+	bucket, prefix := url2BucketAndObject(&targetURL)
+	if bucket == "" {
+		fatalIf(errInvalidArgument().Trace(aliasedURL), "Indexes are built for a single bucket.")
+	}
+
+	dir := cliCtx.String("out")
+	fatalIf(probe.NewError(os.MkdirAll(dir, 0o755)).Trace(dir), "Unable to create index directory.")
+
+	versions := cliCtx.Bool("versions")
+	idx := &bucketIndex{
+		dir: dir,
+		meta: indexMeta{
+			Version:      indexMetaVersion,
+			Alias:        alias,
+			Endpoint:     targetURL.Scheme + "://" + targetURL.Host,
+			Bucket:       bucket,
+			Prefix:       prefix,
+			Versions:     versions,
+			LogicalSizes: !versions,
+			BuiltAt:      UTCNow(),
+		},
+	}
+	err = idx.rewrite(func(w *indexWriter) *probe.Error {
+		return writeListing(ctx, w, clnt, indexListOptions(versions))
+	})
+	fatalIf(err.Trace(aliasedURL, dir), "Unable to build index.")
+
+	printMsg(newIndexMessage(aliasedURL, idx))
+	return nil
+}
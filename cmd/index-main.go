@@ -0,0 +1,105 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var indexSubcommands = []cli.Command{
+	indexBuildCmd,
+	indexUpdateCmd,
+}
+
+var indexCmd = cli.Command{
+	Name:            "index",
+	Usage:           "manage local indexes of bucket listings",
+	Action:          mainIndex,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	Subcommands:     indexSubcommands,
+}
+
+func mainIndex(ctx *cli.Context) error {
+	commandNotFound(ctx, indexSubcommands)
+	return nil
+}
+
+// indexMessage is printed once an index is built or updated.
+type indexMessage struct {
+	Status   string `json:"status"`
+	URL      string `json:"url"`
+	Index    string `json:"index"`
+	Objects  int64  `json:"objects"`
+	Size     int64  `json:"size"`
+	Versions bool   `json:"versions"`
+}
+
+func (i indexMessage) String() string {
+	return console.Colorize("Index", fmt.Sprintf("Indexed `%s` in `%s`: %d objects, %s.",
+		i.URL, i.Index, i.Objects, humanize.IBytes(uint64(i.Size))))
+}
+
+func (i indexMessage) JSON() string {
+	msgBytes, e := json.MarshalIndent(i, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+func newIndexMessage(aliasedURL string, idx *bucketIndex) indexMessage {
+	return indexMessage{
+		Status:   "success",
+		URL:      aliasedURL,
+		Index:    idx.dir,
+		Objects:  idx.meta.Objects,
+		Size:     idx.meta.Size,
+		Versions: idx.meta.Versions,
+	}
+}
+
+// indexListOptions returns the options of the listings saved in
+// an index, tags are only returned by listings with metadata.
+func indexListOptions(versions bool) ListOptions {
+	return ListOptions{
+		Recursive:         true,
+		WithMetadata:      !versions,
+		WithOlderVersions: versions,
+		WithDeleteMarkers: versions,
+	}
+}
+
+// writeListing writes all entries listed by clnt to w.
+func writeListing(ctx context.Context, w *indexWriter, clnt Client, opts ListOptions) *probe.Error {
+	for content := range clnt.List(ctx, opts) {
+		if content.Err != nil {
+			return content.Err.Trace(clnt.GetURL().String())
+		}
+		if err := w.Write(content2IndexEntry(content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var indexUpdateFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "marker",
+		Usage: "only relist keys after this key, indexed keys up to it are kept as is",
+	},
+	cli.BoolFlag{
+		Name:  "watch",
+		Usage: "keep the index up to date with bucket notifications",
+	},
+	cli.DurationFlag{
+		Name:  "interval",
+		Usage: "how often watched changes are saved to the index",
+		Value: time.Minute,
+	},
+}
+
+var indexUpdateCmd = cli.Command{
+	Name:         "update",
+	Usage:        "refresh a local index of a bucket listing",
+	Action:       mainIndexUpdate,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(indexUpdateFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] DIR
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Without --watch, the bucket is listed again in full and replaces the index.
+  With --marker, only keys sorting after the marker are listed again: indexed
+  keys up to the marker are kept as is, so objects added, changed or removed
+  before it are not picked up. Indexes of object versions are always listed
+  again in full.
+
+  With --watch, object creations and removals are received as bucket
+  notifications and saved to the index every --interval.
+
+EXAMPLES:
+  1. Refresh the whole index.
+     {{.Prompt}} {{.HelpName}} idx/
+
+  2. Refresh only the keys after "2022/06/", keeping older keys as indexed.
+     {{.Prompt}} {{.HelpName}} --marker 2022/06/ idx/
+
+  3. Keep the index up to date, saving changes every 5 minutes.
+     {{.Prompt}} {{.HelpName}} --watch --interval 5m idx/
+`,
+}
+
+func checkIndexUpdateSyntax(cliCtx *cli.Context) {
+	if len(cliCtx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(cliCtx, "update", 1) // last argument is exit code
+	}
+	if cliCtx.Bool("watch") && cliCtx.IsSet("marker") {
+		fatalIf(errInvalidArgument(), "--marker cannot be used with --watch.")
+	}
+	if cliCtx.Duration("interval") <= 0 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.String("interval")), "--interval must be positive.")
+	}
+}
+
+// mainIndexUpdate is the handle for "mc index update" command.
+func mainIndexUpdate(cliCtx *cli.Context) error {
+	ctx, cancelIndexUpdate := context.WithCancel(globalContext)
+	defer cancelIndexUpdate()
+
+	console.SetColor("Index", color.New(color.FgGreen, color.Bold))
+
+	checkIndexUpdateSyntax(cliCtx)
+
+	dir := cliCtx.Args().Get(0)
+	idx, err := loadIndex(dir)
+	fatalIf(err.Trace(dir), "Unable to load index.")
+
+	aliasedURL := idx.meta.Alias + "/" + idx.meta.Bucket + "/" + idx.meta.Prefix
+	clnt, err := newClient(aliasedURL)
+	fatalIf(err.Trace(aliasedURL), "Unable to initialize `"+aliasedURL+"`.")
+	if !idx.covers(clnt.GetURL()) {
+		fatalIf(errInvalidArgument().Trace(aliasedURL, idx.meta.Endpoint),
+			"Alias `"+idx.meta.Alias+"` no longer points to the indexed endpoint.")
+	}
+
+	if cliCtx.Bool("watch") {
+		if idx.meta.Versions {
+			fatalIf(errInvalidArgument().Trace(dir), "Indexes of object versions cannot be updated with --watch.")
+		}
+		watchIndex(ctx, idx, clnt, cliCtx.Duration("interval"))
+		return nil
+	}
+
+	err = updateIndex(ctx, idx, clnt, cliCtx.String("marker"))
+	fatalIf(err.Trace(aliasedURL, dir), "Unable to update index.")
+
+	printMsg(newIndexMessage(aliasedURL, idx))
+	return nil
+}
+
+// updateIndex lists all keys again, or with a marker keeps the indexed
+// entries up to it and lists all keys after it again. Object versions
+// can't be listed after a marker, their indexes are listed again in full.
+func updateIndex(ctx context.Context, idx *bucketIndex, clnt Client, marker string) *probe.Error {
+	opts := indexListOptions(idx.meta.Versions)
+	if idx.meta.Versions || marker == "" {
+		return idx.rewrite(func(w *indexWriter) *probe.Error {
+			if err := writeListing(ctx, w, clnt, opts); err != nil {
+				return err
+			}
+			// All entries now record their logical size, even in
+			// indexes built before it was saved.
+			idx.meta.LogicalSizes = !idx.meta.Versions
+			return nil
+		})
+	}
+
+	r, err := newIndexReader(idx.objectsPath())
+	if err != nil {
+		return err.Trace(idx.dir)
+	}
+	defer r.Close()
+
+	opts.StartAfter = marker
+	return idx.rewrite(func(w *indexWriter) *probe.Error {
+		for {
+			entry, err := r.Next()
+			if err != nil {
+				if err.ToGoError() == io.EOF {
+					break
+				}
+				return err.Trace(idx.dir)
+			}
+			if entry.Key > marker {
+				break
+			}
+			if err = w.Write(entry); err != nil {
+				return err
+			}
+		}
+		return writeListing(ctx, w, clnt, opts)
+	})
+}
+
+// watchIndex applies bucket notifications to the index until the
+// watch is canceled, pending changes are merged every interval.
+func watchIndex(ctx context.Context, idx *bucketIndex, clnt Client, interval time.Duration) {
+	wo, err := clnt.Watch(ctx, WatchOptions{
+		Recursive: true,
+		Events:    []string{"put", "delete"},
+	})
+	fatalIf(err.Trace(clnt.GetURL().String()), "Unable to watch with given options.")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	updates := make(map[string]*indexEntry)
+	flush := func() {
+		if len(updates) == 0 {
+			return
+		}
+		r, err := newIndexReader(idx.objectsPath())
+		fatalIf(err.Trace(idx.dir), "Unable to read index.")
+		err = idx.rewrite(func(w *indexWriter) *probe.Error {
+			return mergeIndex(r, w, updates)
+		})
+		r.Close()
+		fatalIf(err.Trace(idx.dir), "Unable to update index.")
+		updates = make(map[string]*indexEntry)
+		printMsg(newIndexMessage(idx.meta.Alias+"/"+idx.meta.Bucket+"/"+idx.meta.Prefix, idx))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			close(wo.DoneChan)
+			flush()
+			return
+		case <-ticker.C:
+			flush()
+		case events, ok := <-wo.Events():
+			if !ok {
+				flush()
+				return
+			}
+			for _, event := range events {
+				key, entry := indexEventEntry(ctx, idx, event)
+				if key != "" {
+					updates[key] = entry
+				}
+			}
+		case err, ok := <-wo.Errors():
+			if !ok {
+				flush()
+				return
+			}
+			flush()
+			fatalIf(err, "Unable to watch for events.")
+		}
+	}
+}
+
+// indexEventEntry returns the index key touched by the event and its
+// new entry, or nil if the object was removed.
+func indexEventEntry(ctx context.Context, idx *bucketIndex, event EventInfo) (string, *indexEntry) {
+	_, key := url2BucketAndObject(newClientURL(event.Path))
+	if !strings.HasPrefix(key, idx.meta.Prefix) {
+		return "", nil
+	}
+	if strings.HasPrefix(string(event.Type), "s3:ObjectRemoved:") {
+		return key, nil
+	}
+
+	clnt, err := newClientFromAlias(idx.meta.Alias, event.Path)
+	if err != nil {
+		errorIf(err.Trace(event.Path), "Unable to initialize `"+event.Path+"`.")
+		return "", nil
+	}
+	content, err := clnt.Stat(ctx, StatOptions{})
+	if err != nil {
+		if _, ok := err.ToGoError().(ObjectMissing); ok {
+			return key, nil
+		}
+		errorIf(err.Trace(event.Path), "Unable to stat `"+event.Path+"`.")
+		return "", nil
+	}
+	entry := content2IndexEntry(content)
+	entry.Key = key
+	entry.IsLatest = true
+	if tags, err := clnt.GetTags(ctx, ""); err == nil && len(tags) > 0 {
+		entry.Tags = tags
+	}
+	return key, &entry
+}
@@ -0,0 +1,485 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/mc/pkg/probe"
+)
+
+const (
+	indexMetaFile    = "index.json"
+	indexObjectsFile = "objects.jsonl.zst"
+
+	indexMetaVersion = "1"
+)
+
+// indexMeta describes what an index covers, it is saved as
+// index.json next to the objects file. LogicalSizes is set when the
+// entries record the uncompressed size of compressed objects, only
+// listings with metadata return it.
+type indexMeta struct {
+	Version      string    `json:"version"`
+	Alias        string    `json:"alias"`
+	Endpoint     string    `json:"endpoint"`
+	Bucket       string    `json:"bucket"`
+	Prefix       string    `json:"prefix"`
+	Versions     bool      `json:"versions"`
+	LogicalSizes bool      `json:"logicalSizes"`
+	Objects      int64     `json:"objects"`
+	Size         int64     `json:"size"`
+	LastKey      string    `json:"lastKey"`
+	BuiltAt      time.Time `json:"builtAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// indexEntry is a single object version in the objects file,
+// field names are kept short as there is one line per version.
+type indexEntry struct {
+	Key            string            `json:"k"`
+	VersionID      string            `json:"v,omitempty"`
+	IsLatest       bool              `json:"l,omitempty"`
+	IsDeleteMarker bool              `json:"d,omitempty"`
+	Size           int64             `json:"s"`
+	ETag           string            `json:"e,omitempty"`
+	ModTime        time.Time         `json:"t"`
+	StorageClass   string            `json:"c,omitempty"`
+	Tags           map[string]string `json:"g,omitempty"`
+	Compression    string            `json:"z,omitempty"`
+	LogicalSize    int64             `json:"u,omitempty"`
+}
+
+func content2IndexEntry(content *ClientContent) indexEntry {
+	_, key := url2BucketAndObject(&content.URL)
+	entry := indexEntry{
+		Key:            key,
+		VersionID:      content.VersionID,
+		IsLatest:       content.IsLatest || content.VersionID == "",
+		IsDeleteMarker: content.IsDeleteMarker,
+		Size:           content.Size,
+		ETag:           content.ETag,
+		ModTime:        content.Time,
+		StorageClass:   content.StorageClass,
+		Tags:           content.Tags,
+	}
+	if codec, size := compressionOf(content.UserMetadata, content.Metadata); codec != "" && size >= 0 {
+		entry.Compression, entry.LogicalSize = codec, size
+	}
+	return entry
+}
+
+// indexWriter writes entries as zstd compressed JSON lines, entries
+// must be written in the listing order, sorted by key.
+type indexWriter struct {
+	f   *os.File
+	zw  *zstd.Encoder
+	enc *json.Encoder
+
+	objects int64
+	size    int64
+	lastKey string
+}
+
+func newIndexWriter(path string) (*indexWriter, *probe.Error) {
+	f, e := os.Create(path)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	zw, e := zstd.NewWriter(f)
+	if e != nil {
+		f.Close()
+		return nil, probe.NewError(e)
+	}
+	return &indexWriter{f: f, zw: zw, enc: json.NewEncoder(zw)}, nil
+}
+
+func (w *indexWriter) Write(entry indexEntry) *probe.Error {
+	if e := w.enc.Encode(entry); e != nil {
+		return probe.NewError(e)
+	}
+	if entry.IsLatest && !entry.IsDeleteMarker {
+		w.objects++
+		w.size += entry.Size
+	}
+	w.lastKey = entry.Key
+	return nil
+}
+
+func (w *indexWriter) Close() *probe.Error {
+	if e := w.zw.Close(); e != nil {
+		w.f.Close()
+		return probe.NewError(e)
+	}
+	return probe.NewError(w.f.Close())
+}
+
+// indexReader reads back the entries written by an indexWriter.
+type indexReader struct {
+	f   *os.File
+	zr  *zstd.Decoder
+	dec *json.Decoder
+}
+
+func newIndexReader(path string) (*indexReader, *probe.Error) {
+	f, e := os.Open(path)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	zr, e := zstd.NewReader(f)
+	if e != nil {
+		f.Close()
+		return nil, probe.NewError(e)
+	}
+	return &indexReader{f: f, zr: zr, dec: json.NewDecoder(zr)}, nil
+}
+
+// Next returns the next entry, io.EOF once all entries are read.
+func (r *indexReader) Next() (entry indexEntry, err *probe.Error) {
+	if e := r.dec.Decode(&entry); e != nil {
+		return entry, probe.NewError(e)
+	}
+	return entry, nil
+}
+
+func (r *indexReader) Close() {
+	r.zr.Close()
+	r.f.Close()
+}
+
+// bucketIndex is an index directory on disk.
+type bucketIndex struct {
+	dir  string
+	meta indexMeta
+}
+
+func loadIndex(dir string) (*bucketIndex, *probe.Error) {
+	buf, e := ioutil.ReadFile(filepath.Join(dir, indexMetaFile))
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	idx := &bucketIndex{dir: dir}
+	if e = json.Unmarshal(buf, &idx.meta); e != nil {
+		return nil, probe.NewError(e)
+	}
+	if idx.meta.Version != indexMetaVersion {
+		return nil, errInvalidIndex(dir)
+	}
+	return idx, nil
+}
+
+func (idx *bucketIndex) objectsPath() string {
+	return filepath.Join(idx.dir, indexObjectsFile)
+}
+
+func (idx *bucketIndex) saveMeta() *probe.Error {
+	buf, e := json.MarshalIndent(idx.meta, "", " ")
+	if e != nil {
+		return probe.NewError(e)
+	}
+	tmpFile := filepath.Join(idx.dir, indexMetaFile+".tmp")
+	if e = ioutil.WriteFile(tmpFile, buf, 0o644); e != nil {
+		return probe.NewError(e)
+	}
+	return probe.NewError(os.Rename(tmpFile, filepath.Join(idx.dir, indexMetaFile)))
+}
+
+// rewrite replaces the objects file with the entries written by fn,
+// the index is left untouched if fn fails.
+func (idx *bucketIndex) rewrite(fn func(w *indexWriter) *probe.Error) *probe.Error {
+	tmpFile := idx.objectsPath() + ".tmp"
+	w, err := newIndexWriter(tmpFile)
+	if err != nil {
+		return err.Trace(tmpFile)
+	}
+	if err = fn(w); err != nil {
+		w.Close()
+		os.Remove(tmpFile)
+		return err
+	}
+	if err = w.Close(); err != nil {
+		os.Remove(tmpFile)
+		return err.Trace(tmpFile)
+	}
+	if e := os.Rename(tmpFile, idx.objectsPath()); e != nil {
+		return probe.NewError(e)
+	}
+	idx.meta.Objects = w.objects
+	idx.meta.Size = w.size
+	idx.meta.LastKey = w.lastKey
+	idx.meta.UpdatedAt = UTCNow()
+	return idx.saveMeta()
+}
+
+// covers returns true if the index answers listings of the given
+// object storage URL.
+func (idx *bucketIndex) covers(u ClientURL) bool {
+	if u.Type != objectStorage || u.Scheme+"://"+u.Host != idx.meta.Endpoint {
+		return false
+	}
+	bucket, object := url2BucketAndObject(&u)
+	return bucket == idx.meta.Bucket && strings.HasPrefix(object, idx.meta.Prefix)
+}
+
+// mergeIndex copies all entries from r to w, replacing the entries of
+// keys found in updates. A nil update removes the key from the index.
+func mergeIndex(r *indexReader, w *indexWriter, updates map[string]*indexEntry) *probe.Error {
+	keys := make([]string, 0, len(updates))
+	for key := range updates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Write all updates sorting before key.
+	flushUntil := func(key string) *probe.Error {
+		for len(keys) > 0 && keys[0] < key {
+			if entry := updates[keys[0]]; entry != nil {
+				if err := w.Write(*entry); err != nil {
+					return err
+				}
+			}
+			keys = keys[1:]
+		}
+		return nil
+	}
+
+	for {
+		entry, err := r.Next()
+		if err != nil {
+			if err.ToGoError() == io.EOF {
+				break
+			}
+			return err
+		}
+		if err = flushUntil(entry.Key); err != nil {
+			return err
+		}
+		if _, ok := updates[entry.Key]; ok {
+			// Replaced or removed, the update is written
+			// by flushUntil with the next key.
+			continue
+		}
+		if err = w.Write(entry); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		if entry := updates[key]; entry != nil {
+			if err := w.Write(*entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// indexSet is the list of indexes passed with --index.
+type indexSet []*bucketIndex
+
+func loadIndexes(dirs []string) (indexSet, *probe.Error) {
+	var indexes indexSet
+	for _, dir := range dirs {
+		idx, err := loadIndex(dir)
+		if err != nil {
+			return nil, err.Trace(dir)
+		}
+		indexes = append(indexes, idx)
+	}
+	return indexes, nil
+}
+
+func (s indexSet) lookup(u ClientURL) *bucketIndex {
+	for _, idx := range s {
+		if idx.covers(u) {
+			return idx
+		}
+	}
+	return nil
+}
+
+// covers returns true if listings of the aliased URL are answered
+// by one of the indexes.
+func (s indexSet) covers(aliasedURL string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	_, urlStr, _, err := expandAlias(aliasedURL)
+	if err != nil {
+		return false
+	}
+	return s.lookup(*newClientURL(urlStr)) != nil
+}
+
+// logicalSizes returns false if listings of the aliased URL are
+// answered by an index without the logical size of the objects.
+func (s indexSet) logicalSizes(aliasedURL string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	_, urlStr, _, err := expandAlias(aliasedURL)
+	if err != nil {
+		return true
+	}
+	idx := s.lookup(*newClientURL(urlStr))
+	return idx == nil || idx.meta.LogicalSizes
+}
+
+// wrap returns a client listing from an index covering clnt, or
+// clnt itself if there is none.
+func (s indexSet) wrap(clnt Client) Client {
+	if idx := s.lookup(clnt.GetURL()); idx != nil {
+		return &indexClient{Client: clnt, index: idx}
+	}
+	return clnt
+}
+
+// indexClient answers listings from a local index, all other
+// operations are passed on to the underlying client.
+type indexClient struct {
+	Client
+	index *bucketIndex
+}
+
+// List - list at delimited path from the index, if not recursive.
+func (c *indexClient) List(ctx context.Context, opts ListOptions) <-chan *ClientContent {
+	contentCh := make(chan *ClientContent)
+	go func() {
+		defer close(contentCh)
+		c.list(ctx, contentCh, opts)
+	}()
+	return contentCh
+}
+
+func (c *indexClient) list(ctx context.Context, contentCh chan *ClientContent, opts ListOptions) {
+	targetURL := c.GetURL()
+	bucket, prefix := url2BucketAndObject(&targetURL)
+	separator := string(targetURL.Separator)
+
+	timeRef := opts.TimeRef
+	if timeRef.IsZero() {
+		timeRef = UTCNow()
+	}
+	versioned := !opts.TimeRef.IsZero() || opts.WithOlderVersions
+
+	send := func(content *ClientContent) bool {
+		select {
+		case contentCh <- content:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	r, err := newIndexReader(c.index.objectsPath())
+	if err != nil {
+		send(&ClientContent{Err: err.Trace(c.index.dir)})
+		return
+	}
+	defer r.Close()
+
+	var skipKey, lastDir string
+	for {
+		entry, err := r.Next()
+		if err != nil {
+			if err.ToGoError() != io.EOF {
+				send(&ClientContent{Err: err.Trace(c.index.dir)})
+			}
+			return
+		}
+		if !strings.HasPrefix(entry.Key, prefix) {
+			if entry.Key > prefix {
+				// Entries are sorted, nothing left under prefix.
+				return
+			}
+			continue
+		}
+		if opts.StartAfter != "" && entry.Key <= opts.StartAfter {
+			continue
+		}
+
+		if !opts.Recursive {
+			if i := strings.Index(entry.Key[len(prefix):], separator); i >= 0 {
+				dir := entry.Key[:len(prefix)+i+1]
+				if dir != lastDir {
+					lastDir = dir
+					if !send(c.indexEntry2ClientContent(bucket, indexEntry{Key: dir})) {
+						return
+					}
+				}
+				continue
+			}
+		}
+
+		if versioned {
+			// Same as listVersionsRoutine, the newest version before
+			// timeRef, or all of them with older versions.
+			if !opts.WithOlderVersions && skipKey == entry.Key {
+				continue
+			}
+			if !entry.ModTime.Before(timeRef) {
+				continue
+			}
+			skipKey = entry.Key
+			if !opts.WithDeleteMarkers && entry.IsDeleteMarker {
+				continue
+			}
+		} else if !entry.IsLatest || entry.IsDeleteMarker {
+			continue
+		}
+
+		if !send(c.indexEntry2ClientContent(bucket, entry)) {
+			return
+		}
+	}
+}
+
+func (c *indexClient) indexEntry2ClientContent(bucket string, entry indexEntry) *ClientContent {
+	url := c.GetURL().Clone()
+	url.Path = string(url.Separator) + bucket + string(url.Separator) + entry.Key
+	content := &ClientContent{
+		URL:            url,
+		BucketName:     bucket,
+		Time:           entry.ModTime,
+		Size:           entry.Size,
+		ETag:           entry.ETag,
+		StorageClass:   entry.StorageClass,
+		VersionID:      entry.VersionID,
+		IsLatest:       entry.IsLatest,
+		IsDeleteMarker: entry.IsDeleteMarker,
+		Tags:           entry.Tags,
+		Metadata:       map[string]string{},
+		UserMetadata:   map[string]string{},
+		Type:           os.FileMode(0o664),
+	}
+	if entry.Compression != "" {
+		setCompressMetadata(content.UserMetadata, entry.Compression, entry.LogicalSize)
+	}
+	if strings.HasSuffix(entry.Key, string(url.Separator)) {
+		content.Type = os.ModeDir
+	}
+	return content
+}
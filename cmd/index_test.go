@@ -0,0 +1,263 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+func testIndex(t *testing.T, entries []indexEntry) *bucketIndex {
+	dir, e := ioutil.TempDir("", "mc-index")
+	if e != nil {
+		t.Fatal(e)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	idx := &bucketIndex{
+		dir: dir,
+		meta: indexMeta{
+			Version:  indexMetaVersion,
+			Endpoint: "http://localhost:9000",
+			Bucket:   "bucket",
+		},
+	}
+	err := idx.rewrite(func(w *indexWriter) *probe.Error {
+		for _, entry := range entries {
+			if err := w.Write(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+func indexTestClient(t *testing.T, idx *bucketIndex, urlStr string) Client {
+	conf := new(Config)
+	conf.HostURL = urlStr
+	conf.AccessKey = "WLGDGYAQYIGI833EV05A"
+	conf.SecretKey = "BYvgJM101sHngl2uzjXS/OBF/aMxAN06JrJ3qJlF"
+	conf.Signature = "S3v4"
+	s3c, err := S3New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clnt := indexSet{idx}.wrap(s3c)
+	if _, ok := clnt.(*indexClient); !ok {
+		t.Fatalf("index does not cover %s", urlStr)
+	}
+	return clnt
+}
+
+func listIndex(t *testing.T, idx *bucketIndex, urlStr string, opts ListOptions) (keys []string) {
+	clnt := indexTestClient(t, idx, urlStr)
+	for content := range clnt.List(context.Background(), opts) {
+		if content.Err != nil {
+			t.Fatal(content.Err)
+		}
+		_, key := url2BucketAndObject(&content.URL)
+		if content.VersionID != "" {
+			key += "@" + content.VersionID
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestIndexList(t *testing.T) {
+	now := UTCNow()
+	idx := testIndex(t, []indexEntry{
+		{Key: "a", IsLatest: true, ModTime: now},
+		{Key: "dir/b", IsLatest: true, ModTime: now},
+		{Key: "dir/c", IsLatest: true, ModTime: now},
+		{Key: "dir/sub/d", IsLatest: true, ModTime: now},
+		{Key: "e", VersionID: "3", IsLatest: true, IsDeleteMarker: true, ModTime: now},
+		{Key: "e", VersionID: "2", ModTime: now.Add(-time.Hour)},
+		{Key: "e", VersionID: "1", ModTime: now.Add(-2 * time.Hour)},
+	})
+
+	testCases := []struct {
+		url  string
+		opts ListOptions
+		keys []string
+	}{
+		{"http://localhost:9000/bucket/", ListOptions{Recursive: true}, []string{"a", "dir/b", "dir/c", "dir/sub/d"}},
+		{"http://localhost:9000/bucket/", ListOptions{}, []string{"a", "dir/"}},
+		{"http://localhost:9000/bucket/dir/", ListOptions{}, []string{"dir/b", "dir/c", "dir/sub/"}},
+		{"http://localhost:9000/bucket/dir/", ListOptions{Recursive: true, StartAfter: "dir/b"}, []string{"dir/c", "dir/sub/d"}},
+		{"http://localhost:9000/bucket/e", ListOptions{Recursive: true, WithOlderVersions: true, WithDeleteMarkers: true}, []string{"e@3", "e@2", "e@1"}},
+		{"http://localhost:9000/bucket/e", ListOptions{Recursive: true, TimeRef: now.Add(-time.Minute)}, []string{"e@2"}},
+		{"http://localhost:9000/bucket/e", ListOptions{Recursive: true, TimeRef: now.Add(-90 * time.Minute), WithOlderVersions: true}, []string{"e@1"}},
+	}
+	for i, testCase := range testCases {
+		keys := listIndex(t, idx, testCase.url, testCase.opts)
+		if !reflect.DeepEqual(keys, testCase.keys) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.keys, keys)
+		}
+	}
+
+	if idx.meta.Objects != 4 || idx.meta.LastKey != "e" {
+		t.Errorf("unexpected index summary %+v", idx.meta)
+	}
+	if (&bucketIndex{meta: idx.meta}).covers(*newClientURL("http://localhost:9000/other/")) {
+		t.Errorf("index should not cover another bucket")
+	}
+}
+
+func TestDUIndex(t *testing.T) {
+	compressed := &ClientContent{Size: 4, UserMetadata: map[string]string{}}
+	setCompressMetadata(compressed.UserMetadata, "zstd", 40)
+	entry := content2IndexEntry(compressed)
+	entry.Key, entry.IsLatest = "dir/sub/z", true
+	idx := testIndex(t, []indexEntry{
+		{Key: "a", IsLatest: true, Size: 1},
+		{Key: "dir/", IsLatest: true},
+		{Key: "dir/b", IsLatest: true, Size: 2},
+		{Key: "dir/sub/d", IsLatest: true, Size: 3},
+		entry,
+		{Key: "dir0", IsLatest: true, Size: 5},
+	})
+
+	testCases := []struct {
+		depth       int
+		logical     bool
+		expected    []string
+		size, count int64
+	}{
+		{1, false, []string{"=15/6"}, 15, 6},
+		{2, false, []string{"dir/=9/3", "=15/5"}, 15, 5},
+		{-1, false, []string{"dir/sub/=7/2", "dir/=9/3", "=15/5"}, 15, 5},
+		{-1, true, []string{"dir/sub/=43/2", "dir/=45/3", "=51/5"}, 51, 5},
+	}
+	for i, testCase := range testCases {
+		var reported []string
+		clnt := indexTestClient(t, idx, "http://localhost:9000/bucket/")
+		size, count, err := duIndex(context.Background(), clnt, ListOptions{}, testCase.logical, testCase.depth, func(folder string, size, objects int64) {
+			reported = append(reported, fmt.Sprintf("%s=%d/%d", folder, size, objects))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(reported, testCase.expected) || size != testCase.size || count != testCase.count {
+			t.Errorf("Test %d: expected %v (%d/%d), got %v (%d/%d)", i+1, testCase.expected, testCase.size, testCase.count, reported, size, count)
+		}
+	}
+}
+
+func TestUpdateIndex(t *testing.T) {
+	// The bucket gained "a" before the last indexed key and lost "b".
+	bucket := testIndex(t, []indexEntry{
+		{Key: "a", IsLatest: true},
+		{Key: "c", IsLatest: true},
+		{Key: "e", IsLatest: true},
+	})
+	conf := new(Config)
+	conf.HostURL = "http://localhost:9000/bucket/"
+	conf.AccessKey = "WLGDGYAQYIGI833EV05A"
+	conf.SecretKey = "BYvgJM101sHngl2uzjXS/OBF/aMxAN06JrJ3qJlF"
+	conf.Signature = "S3v4"
+	s3c, err := S3New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clnt := indexSet{bucket}.wrap(s3c)
+
+	testCases := []struct {
+		marker string
+		keys   []string
+	}{
+		{"", []string{"a", "c", "e"}},
+		{"c", []string{"b", "c", "e"}},
+	}
+	for i, testCase := range testCases {
+		idx := testIndex(t, []indexEntry{
+			{Key: "b", IsLatest: true},
+			{Key: "c", IsLatest: true},
+			{Key: "d", IsLatest: true},
+		})
+		if err = updateIndex(context.Background(), idx, clnt, testCase.marker); err != nil {
+			t.Fatal(err)
+		}
+		keys := listIndex(t, idx, "http://localhost:9000/bucket/", ListOptions{Recursive: true})
+		if !reflect.DeepEqual(keys, testCase.keys) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.keys, keys)
+		}
+	}
+}
+
+func TestMergeIndex(t *testing.T) {
+	idx := testIndex(t, []indexEntry{
+		{Key: "b", IsLatest: true, Size: 1},
+		{Key: "c", IsLatest: true, Size: 1},
+		{Key: "d", IsLatest: true, Size: 1},
+	})
+
+	r, err := newIndexReader(idx.objectsPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = idx.rewrite(func(w *indexWriter) *probe.Error {
+		return mergeIndex(r, w, map[string]*indexEntry{
+			"a": {Key: "a", IsLatest: true, Size: 2},
+			"c": {Key: "c", IsLatest: true, Size: 2},
+			"d": nil,
+			"e": {Key: "e", IsLatest: true, Size: 2},
+		})
+	})
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = newIndexReader(idx.objectsPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var got []indexEntry
+	for {
+		entry, err := r.Next()
+		if err != nil {
+			break
+		}
+		got = append(got, entry)
+	}
+	expected := []indexEntry{
+		{Key: "a", IsLatest: true, Size: 2},
+		{Key: "b", IsLatest: true, Size: 1},
+		{Key: "c", IsLatest: true, Size: 2},
+		{Key: "e", IsLatest: true, Size: 2},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	if idx.meta.Objects != 4 || idx.meta.Size != 7 {
+		t.Fatalf("unexpected index summary %+v", idx.meta)
+	}
+}
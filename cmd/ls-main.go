@@ -60,6 +60,7 @@ var (
 			Name:  "zip",
 			Usage: "list files inside zip archive (MinIO servers only)",
 		},
+		logicalSizeFlag,
 	}
 )
 
@@ -112,6 +113,9 @@ EXAMPLES:
   
   10. List all objects on mybucket, for the GLACIER storage class
      {{.Prompt}} {{.HelpName}} --storage-class 'GLACIER' s3/mybucket 
+
+  11. List all objects on mybucket with the uncompressed size of the objects uploaded with --compress.
+     {{.Prompt}} {{.HelpName}} --recursive --logical-size s3/mybucket
 `,
 }
 
@@ -173,6 +177,7 @@ func checkListSyntax(ctx context.Context, cliCtx *cli.Context) ([]string, doList
 	withOlderVersions := cliCtx.Bool("versions")
 	isSummary := cliCtx.Bool("summarize")
 	listZip := cliCtx.Bool("zip")
+	withLogicalSize := cliCtx.Bool("logical-size")
 
 	timeRef := parseRewindFlag(cliCtx.String("rewind"))
 	if timeRef.IsZero() && withOlderVersions {
@@ -191,6 +196,7 @@ func checkListSyntax(ctx context.Context, cliCtx *cli.Context) ([]string, doList
 		withOlderVersions: withOlderVersions,
 		listZip:           listZip,
 		filter:            storageClasss,
+		withLogicalSize:   withLogicalSize,
 	}
 	return args, opts
 }
@@ -208,6 +208,7 @@ type doListOptions struct {
 	withOlderVersions bool
 	listZip           bool
 	filter            string
+	withLogicalSize   bool
 }
 
 // doList - list all entities inside a folder.
@@ -228,6 +229,7 @@ func doList(ctx context.Context, clnt Client, o doListOptions) error {
 		WithDeleteMarkers: true,
 		ShowDir:           DirNone,
 		ListZip:           o.listZip,
+		WithMetadata:      o.withLogicalSize,
 	}) {
 		if content.Err != nil {
 			switch content.Err.ToGoError().(type) {
@@ -261,6 +263,9 @@ func doList(ctx context.Context, clnt Client, o doListOptions) error {
 			perObjectVersions = []*ClientContent{}
 		}
 
+		if o.withLogicalSize {
+			content.Size = logicalSize(content)
+		}
 		perObjectVersions = append(perObjectVersions, content)
 		totalSize += content.Size
 		totalObjects++
@@ -133,8 +133,16 @@ func Main(args []string) {
 	// Monitor OS exit signals and cancel the global context in such case
 	go trapSignals(os.Interrupt, syscall.SIGTERM, syscall.SIGKILL)
 
+	// Print the output buffered by --format when exiting.
+	cli.OsExiter = func(code int) {
+		flushOutputFormat()
+		os.Exit(code)
+	}
+
 	// Run the app - exit on error.
-	if err := registerApp(appName).Run(args); err != nil {
+	err := registerApp(appName).Run(args)
+	flushOutputFormat()
+	if err != nil {
 		os.Exit(1)
 	}
 }
@@ -423,17 +431,22 @@ var appCmds = []cli.Command{
 	cpCmd,
 	mvCmd,
 	rmCmd,
+	trashCmd,
 	mirrorCmd,
+	agentCmd,
 	catCmd,
 	headCmd,
 	pipeCmd,
 	findCmd,
+	indexCmd,
 	sqlCmd,
 	statCmd,
 	treeCmd,
+	browseCmd,
 	duCmd,
 	retentionCmd,
 	legalHoldCmd,
+	benchCmd,
 	supportCmd,
 	licenseCmd,
 	shareCmd,
@@ -446,6 +459,7 @@ var appCmds = []cli.Command{
 	anonymousCmd,
 	policyCmd,
 	tagCmd,
+	metaCmd,
 	diffCmd,
 	replicateCmd,
 	adminCmd,
@@ -0,0 +1,185 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+	mem "github.com/shirou/gopsutil/v3/mem"
+)
+
+// Number of parts a single multipart upload holds in memory at once.
+const uploadPartsPerTask = 4
+
+// memoryReservation is an amount of memory reserved from a memoryBudget.
+type memoryReservation struct {
+	budget *memoryBudget
+	size   uint64
+}
+
+// Release gives the reserved memory back to the budget, waking up
+// waiting tasks.
+func (r *memoryReservation) Release() {
+	if r == nil {
+		return
+	}
+	r.budget.release(r)
+}
+
+// memoryBudget is a counting semaphore over bytes: it does not own
+// any memory, it only accounts for the memory uploads estimate they
+// buffer and keeps their total under a hard limit. Reserving blocks
+// until enough memory is released.
+type memoryBudget struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	limit uint64
+	used  uint64
+}
+
+func newMemoryBudget(limit uint64) *memoryBudget {
+	mb := &memoryBudget{limit: limit}
+	mb.cond = sync.NewCond(&mb.mu)
+	return mb
+}
+
+// Reserve reserves size bytes. A reservation larger than the whole
+// budget is only granted when no other one is outstanding.
+func (mb *memoryBudget) Reserve(size uint64) *memoryReservation {
+	need := size
+	if need > mb.limit {
+		need = mb.limit
+	}
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	for mb.used+need > mb.limit {
+		mb.cond.Wait()
+	}
+	mb.used += need
+	return &memoryReservation{budget: mb, size: need}
+}
+
+func (mb *memoryBudget) release(r *memoryReservation) {
+	mb.mu.Lock()
+	mb.used -= r.size
+	mb.mu.Unlock()
+	mb.cond.Broadcast()
+}
+
+// Used returns the total size of the outstanding reservations.
+func (mb *memoryBudget) Used() uint64 {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	return mb.used
+}
+
+// copyBufferSize returns the size of the object uploaded by the copy
+// of urls when the upload buffers its parts in memory, 0 otherwise:
+// downloads to a local filesystem and server-side copies within an
+// alias don't buffer anything.
+func copyBufferSize(urls URLs) int64 {
+	if urls.SourceContent == nil || urls.TargetContent == nil || urls.TargetContent.URL.Type != objectStorage {
+		return 0
+	}
+	if urls.SourceAlias == urls.TargetAlias && urls.Compress == "" {
+		return 0
+	}
+	return urls.SourceContent.Size
+}
+
+// uploadMemorySize estimates the memory needed by a single upload
+// of the given size: parallel multipart uploads hold a few parts
+// in memory, smaller uploads the whole object at most.
+func uploadMemorySize(size int64) uint64 {
+	partsCount, partSize, _, e := minio.OptimalPartInfo(size, 0)
+	if e != nil {
+		return uint64(size)
+	}
+	if partsCount >= uploadPartsPerTask {
+		return uploadPartsPerTask * uint64(partSize)
+	}
+	return uint64(size)
+}
+
+const (
+	cgroupV1LimitFile = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+	cgroupV2LimitFile = "/sys/fs/cgroup/memory.max"
+
+	// cgroup v1 reports no limit as the highest positive signed 64-bit
+	// integer (2^63-1), rounded down to multiples of 4096 (2^12), the
+	// most common page size on x86 systems.
+	cgroupV1NoLimit = 9223372036854771712
+)
+
+// cgroupLimit returns the memory limit configured in the given
+// cgroup v1 or v2 file, 0 if there is no limit or it is unreadable.
+func cgroupLimit(limitFile string) (limit uint64) {
+	buf, err := ioutil.ReadFile(limitFile)
+	if err != nil {
+		return 0
+	}
+	// cgroup v2 reports "max" when there is no limit.
+	v := strings.TrimSpace(string(buf))
+	if v == "max" {
+		return 0
+	}
+	limit, err = strconv.ParseUint(v, 10, 64)
+	if err != nil || limit == cgroupV1NoLimit {
+		return 0
+	}
+	return limit
+}
+
+// availableMemory returns the memory limit of the cgroup of mc,
+// or the available memory of the host otherwise.
+func availableMemory() (available uint64) {
+	available = 8 << 30 // Default to 8 GiB when we can't find the limits.
+
+	if runtime.GOOS == "linux" {
+		for _, limitFile := range []string{cgroupV2LimitFile, cgroupV1LimitFile} {
+			if limit := cgroupLimit(limitFile); limit > 0 {
+				return limit
+			}
+		}
+	} // for all other platforms limits are based on virtual memory.
+
+	memStats, err := mem.VirtualMemory()
+	if err != nil {
+		return
+	}
+
+	available = memStats.Available
+	return
+}
+
+// uploadMemoryBudget returns the memory budget of uploads,
+// --max-memory if set, half of the available memory otherwise to
+// leave room for the rest of mc.
+func uploadMemoryBudget(maxMemory uint64) uint64 {
+	if maxMemory > 0 {
+		return maxMemory
+	}
+	return availableMemory() / 2
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	mb := newMemoryBudget(1 << 20)
+	first := mb.Reserve(1000 << 10)
+	if mb.Used() != 1000<<10 {
+		t.Fatalf("expected %d bytes used, got %d", 1000<<10, mb.Used())
+	}
+
+	reserved := make(chan *memoryReservation)
+	go func() {
+		reserved <- mb.Reserve(100 << 10)
+	}()
+	select {
+	case <-reserved:
+		t.Fatal("reserved memory over budget")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	first.Release()
+	second := <-reserved
+	if mb.Used() != 100<<10 {
+		t.Fatalf("expected %d bytes used, got %d", 100<<10, mb.Used())
+	}
+	second.Release()
+
+	// Reservations larger than the budget are granted alone.
+	mb.Reserve(10 << 20).Release()
+	if mb.Used() != 0 {
+		t.Fatalf("expected no bytes used, got %d", mb.Used())
+	}
+}
+
+func TestCopyBufferSize(t *testing.T) {
+	local := func(p string) *ClientContent {
+		return &ClientContent{URL: ClientURL{Type: fileSystem, Path: p}, Size: 10}
+	}
+	remote := func(p string) *ClientContent {
+		return &ClientContent{URL: ClientURL{Type: objectStorage, Path: p}, Size: 10}
+	}
+	testCases := []struct {
+		urls URLs
+		size int64
+	}{
+		{URLs{SourceContent: local("/a"), TargetAlias: "s3", TargetContent: remote("/b/a")}, 10},
+		{URLs{SourceAlias: "s3", SourceContent: remote("/b/a"), TargetContent: local("/a")}, 0},
+		{URLs{SourceAlias: "s3", SourceContent: remote("/b/a"), TargetAlias: "s3", TargetContent: remote("/c/a")}, 0},
+		{URLs{SourceAlias: "s3", SourceContent: remote("/b/a"), TargetAlias: "s3", TargetContent: remote("/c/a"), Compress: "zstd"}, 10},
+		{URLs{SourceAlias: "s3", SourceContent: remote("/b/a"), TargetAlias: "gcs", TargetContent: remote("/c/a")}, 10},
+	}
+	for i, testCase := range testCases {
+		if size := copyBufferSize(testCase.urls); size != testCase.size {
+			t.Errorf("Test %d: expected %d, got %d", i+1, testCase.size, size)
+		}
+	}
+}
+
+func TestCgroupLimit(t *testing.T) {
+	dir, e := ioutil.TempDir("", "mc-cgroup")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		content string
+		limit   uint64
+	}{
+		{"max\n", 0},
+		{"1073741824\n", 1 << 30},
+		{"9223372036854771712\n", 0},
+		{"garbage", 0},
+	}
+	for i, testCase := range testCases {
+		limitFile := filepath.Join(dir, "memory.max")
+		if e = ioutil.WriteFile(limitFile, []byte(testCase.content), 0o644); e != nil {
+			t.Fatal(e)
+		}
+		if limit := cgroupLimit(limitFile); limit != testCase.limit {
+			t.Errorf("Test %d: expected limit %d, got %d", i+1, testCase.limit, limit)
+		}
+	}
+	if limit := cgroupLimit(filepath.Join(dir, "missing")); limit != 0 {
+		t.Errorf("expected no limit for a missing file, got %d", limit)
+	}
+}
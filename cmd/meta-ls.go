@@ -0,0 +1,81 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"path"
+
+	"github.com/minio/cli"
+)
+
+var metaListCmd = cli.Command{
+	Name:         "ls",
+	Usage:        "list metadata of object(s)",
+	Action:       mainMetaList,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(append(append(metaFlags, metaVersionFlags...), ioFlags...), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. List the metadata of an object.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket/report.pdf
+
+  2. List the metadata of all the versions of the objects under a prefix.
+     {{.Prompt}} {{.HelpName}} --recursive --versions myminio/mybucket/assets/
+`,
+}
+
+// mainMetaList is the handle for "mc meta ls" command.
+func mainMetaList(cliCtx *cli.Context) error {
+	ctx, cancelMetaList := context.WithCancel(globalContext)
+	defer cancelMetaList()
+
+	setMetaColors()
+
+	if len(cliCtx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(cliCtx, "ls", 1) // last argument is exit code
+	}
+	targetURL, opts := parseMetaOptions(cliCtx, 1)
+	return walkMeta(ctx, targetURL, opts, func(alias string, versions []*ClientContent) bool {
+		ok := true
+		for _, content := range versions {
+			key := path.Join(alias, content.URL.Path)
+			clnt, err := newClientFromAlias(alias, content.URL.String())
+			if err == nil {
+				sse := getSSE(key, opts.encKeyDB[alias])
+				content, err = clnt.Stat(ctx, StatOptions{versionID: content.VersionID, sse: sse})
+			}
+			if err != nil {
+				errorIf(err.Trace(key), "Unable to get the metadata of `"+key+"`.")
+				ok = false
+				continue
+			}
+			printMsg(metaMessage{op: "list", Key: key, VersionID: content.VersionID, Metadata: editableMetadata(content.Metadata)})
+		}
+		return ok
+	})
+}
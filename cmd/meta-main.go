@@ -0,0 +1,504 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/pkg/console"
+	"github.com/minio/pkg/wildcard"
+)
+
+var metaSubcommands = []cli.Command{
+	metaSetCmd,
+	metaRemoveCmd,
+	metaListCmd,
+}
+
+var metaCmd = cli.Command{
+	Name:            "meta",
+	Usage:           "manage the metadata of objects",
+	HideHelpCommand: true,
+	Action:          mainMeta,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	Subcommands:     metaSubcommands,
+}
+
+// mainMeta is the handle for "mc meta" command.
+func mainMeta(ctx *cli.Context) error {
+	commandNotFound(ctx, metaSubcommands)
+	return nil
+	// Sub-commands like "set", "ls" have their own main.
+}
+
+// metaFlags are the flags selecting the objects of the meta commands.
+var metaFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "apply to all the objects under the prefix",
+	},
+	cli.StringFlag{
+		Name:  "older-than",
+		Usage: "apply to the objects older than value in duration string (e.g. 7d10h31s)",
+	},
+	cli.StringFlag{
+		Name:  "newer-than",
+		Usage: "apply to the objects newer than value in duration string (e.g. 7d10h31s)",
+	},
+	cli.StringSliceFlag{
+		Name:  "exclude",
+		Usage: "exclude the object keys matching the wildcard pattern",
+	},
+	cli.IntFlag{
+		Name:  "max-workers",
+		Value: 16,
+		Usage: "maximum number of objects processed concurrently",
+	},
+}
+
+// metaVersionFlags select the versions of the objects listed by
+// "mc meta ls". The metadata of a version can't be changed, copying an
+// older version onto its object would make it current again, so the
+// commands changing metadata only apply to the current versions.
+var metaVersionFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "versions",
+		Usage: "apply to all the versions of the object(s)",
+	},
+	cli.StringFlag{
+		Name:  "version-id, vid",
+		Usage: "apply to a specific version of the object",
+	},
+	cli.StringFlag{
+		Name:  "rewind",
+		Usage: "apply to the version of the object(s) current at the specified time",
+	},
+}
+
+// metaEditFlags are the flags of the meta commands changing metadata.
+var metaEditFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "show the new metadata without changing anything",
+	},
+}
+
+// metaHeaders are the standard headers stored as metadata of an object,
+// along with its user metadata.
+var metaHeaders = []string{
+	"Cache-Control",
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Type",
+	"Expires",
+}
+
+// normalizeMetaKey returns the canonical form of a metadata key given by
+// the user: a standard header, or user metadata with or without its
+// X-Amz-Meta- prefix.
+func normalizeMetaKey(key string) (string, *probe.Error) {
+	if key == "" || strings.ContainsAny(key, " :=") {
+		return "", errInvalidArgument().Trace(key, "invalid metadata key")
+	}
+	key = http.CanonicalHeaderKey(key)
+	for _, h := range metaHeaders {
+		if key == h {
+			return key, nil
+		}
+	}
+	switch {
+	case strings.HasPrefix(key, "X-Amz-Meta-"):
+		return key, nil
+	case strings.HasPrefix(key, "X-Amz-"):
+		return "", errInvalidArgument().Trace(key, "only standard headers and user metadata can be changed")
+	}
+	return "X-Amz-Meta-" + key, nil
+}
+
+// editableMetadata returns the metadata of an object the meta commands
+// can change, from the headers of the object.
+func editableMetadata(headers map[string]string) map[string]string {
+	metadata := make(map[string]string)
+	for k, v := range headers {
+		k = http.CanonicalHeaderKey(k)
+		if strings.HasPrefix(k, "X-Amz-Meta-") {
+			metadata[k] = v
+			continue
+		}
+		for _, h := range metaHeaders {
+			if k == h {
+				metadata[k] = v
+			}
+		}
+	}
+	return metadata
+}
+
+// objectSSE returns the server-side encryption of an object managed by
+// the server, SSE-S3 or SSE-KMS, from the headers of the object.
+func objectSSE(headers map[string]string) (encrypt.ServerSide, *probe.Error) {
+	switch headers["X-Amz-Server-Side-Encryption"] {
+	case "AES256":
+		return encrypt.NewSSE(), nil
+	case "aws:kms":
+		sse, e := encrypt.NewSSEKMS(headers["X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"], nil)
+		return sse, probe.NewError(e)
+	}
+	return nil, nil
+}
+
+// metaOptions are the options of the meta commands.
+type metaOptions struct {
+	isRecursive  bool
+	withVersions bool
+	versionID    string
+	timeRef      time.Time
+	olderThan    string
+	newerThan    string
+	excludes     []string
+	workers      int
+	isFake       bool
+	encKeyDB     map[string][]prefixSSEPair
+}
+
+// parseMetaOptions returns the options of the meta commands, and the
+// aliased url of their first argument.
+func parseMetaOptions(cliCtx *cli.Context, minArgs int) (string, metaOptions) {
+	if len(cliCtx.Args()) < minArgs {
+		cli.ShowCommandHelpAndExit(cliCtx, cliCtx.Command.Name, 1) // last argument is exit code
+	}
+	targetURL := cliCtx.Args().Get(0)
+	if alias, _ := url2Alias(targetURL); alias == "" {
+		fatalIf(errInvalidArgument().Trace(targetURL), "The metadata can only be managed on object storage.")
+	}
+
+	encKeyDB, err := getEncKeys(cliCtx)
+	fatalIf(err, "Unable to parse encryption keys.")
+
+	opts := metaOptions{
+		isRecursive:  cliCtx.Bool("recursive"),
+		withVersions: cliCtx.Bool("versions"),
+		versionID:    cliCtx.String("version-id"),
+		timeRef:      parseRewindFlag(cliCtx.String("rewind")),
+		olderThan:    cliCtx.String("older-than"),
+		newerThan:    cliCtx.String("newer-than"),
+		excludes:     cliCtx.StringSlice("exclude"),
+		workers:      cliCtx.Int("max-workers"),
+		isFake:       cliCtx.Bool("dry-run"),
+		encKeyDB:     encKeyDB,
+	}
+	if opts.versionID != "" && (opts.isRecursive || opts.withVersions || !opts.timeRef.IsZero()) {
+		fatalIf(errInvalidArgument(), "You cannot pass --version-id with any of --versions, --recursive and --rewind flags.")
+	}
+	if opts.withVersions && opts.timeRef.IsZero() {
+		opts.timeRef = time.Now().UTC()
+	}
+	if opts.workers <= 0 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.String("max-workers")), "--max-workers must be positive.")
+	}
+	return targetURL, opts
+}
+
+// skip returns true if the object is filtered out.
+func (opts metaOptions) skip(content *ClientContent) bool {
+	if content.IsDeleteMarker || content.Type.IsDir() {
+		return true
+	}
+	// Skip objects not old enough, or too old.
+	if opts.olderThan != "" && isOlder(content.Time, opts.olderThan) {
+		return true
+	}
+	if opts.newerThan != "" && isNewer(content.Time, opts.newerThan) {
+		return true
+	}
+	_, key := splitBucketKey(content.URL.Path)
+	for _, pattern := range opts.excludes {
+		if wildcard.Match(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkMeta calls fn concurrently for the objects of the aliased url,
+// fn receives the versions of an object oldest first. It returns an
+// exit error if listing failed or fn returned false.
+func walkMeta(ctx context.Context, targetURL string, opts metaOptions, fn func(alias string, versions []*ClientContent) bool) error {
+	alias, _, _ := mustExpandAlias(targetURL)
+	clnt, err := newClient(targetURL)
+	if err != nil {
+		fatalIf(err.Trace(targetURL), "Unable to parse the provided url.")
+	}
+
+	var failed int32
+	versionsCh := make(chan []*ClientContent)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for versions := range versionsCh {
+				if !fn(alias, versions) {
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+	send := func(versions []*ClientContent) {
+		if len(versions) == 0 {
+			return
+		}
+		// The listing returns the versions newest first.
+		for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+			versions[i], versions[j] = versions[j], versions[i]
+		}
+		select {
+		case versionsCh <- versions:
+		case <-ctx.Done():
+		}
+	}
+
+	found := false
+	if !opts.isRecursive && !opts.withVersions {
+		sse := getSSE(targetURL, opts.encKeyDB[alias])
+		content, err := clnt.Stat(ctx, StatOptions{versionID: opts.versionID, timeRef: opts.timeRef, sse: sse})
+		if err != nil {
+			errorIf(err.Trace(targetURL), "Unable to stat `"+targetURL+"`.")
+			atomic.StoreInt32(&failed, 1)
+		} else if !opts.skip(content) {
+			found = true
+			send([]*ClientContent{content})
+		}
+	} else {
+		listOpts := ListOptions{Recursive: opts.isRecursive, ShowDir: DirNone}
+		if !opts.timeRef.IsZero() {
+			listOpts.WithOlderVersions = opts.withVersions
+			listOpts.TimeRef = opts.timeRef
+		}
+		var versions []*ClientContent
+		for content := range clnt.List(ctx, listOpts) {
+			if content.Err != nil {
+				errorIf(content.Err.Trace(targetURL), "Unable to list `"+targetURL+"`.")
+				atomic.StoreInt32(&failed, 1)
+				continue
+			}
+			if !opts.isRecursive && alias+getKey(content) != getStandardizedURL(targetURL) {
+				break
+			}
+			if opts.skip(content) {
+				continue
+			}
+			found = true
+			if len(versions) > 0 && versions[0].URL.Path != content.URL.Path {
+				send(versions)
+				versions = nil
+			}
+			versions = append(versions, content)
+		}
+		send(versions)
+	}
+	close(versionsCh)
+	wg.Wait()
+
+	if !found && atomic.LoadInt32(&failed) == 0 {
+		console.Infoln("No object found at `" + targetURL + "`.")
+	}
+	if atomic.LoadInt32(&failed) != 0 {
+		return exitStatus(globalErrorExitStatus)
+	}
+	return nil
+}
+
+// rewriteMetadata changes the metadata of an object version with edit,
+// by copying it onto itself. The SSE keys, the storage class, the tags
+// and the retention of the object are kept. It returns the new metadata
+// and whether it changed.
+func rewriteMetadata(ctx context.Context, alias string, content *ClientContent, opts metaOptions, edit func(metadata map[string]string)) (map[string]string, bool, *probe.Error) {
+	objectURL := path.Join(alias, content.URL.Path)
+	clnt, err := newClientFromAlias(alias, content.URL.String())
+	if err != nil {
+		return nil, false, err.Trace(objectURL)
+	}
+	srcSSE := getSSE(objectURL, opts.encKeyDB[alias])
+	st, err := clnt.Stat(ctx, StatOptions{versionID: content.VersionID, sse: srcSSE})
+	if err != nil {
+		return nil, false, err.Trace(objectURL)
+	}
+
+	oldMetadata := editableMetadata(st.Metadata)
+	metadata := make(map[string]string, len(oldMetadata))
+	for k, v := range oldMetadata {
+		metadata[k] = v
+	}
+	edit(metadata)
+	if equalMetadata(metadata, oldMetadata) {
+		return metadata, false, nil
+	}
+	if opts.isFake {
+		return metadata, true, nil
+	}
+
+	// SSE-C objects are copied with the same key, objects encrypted by
+	// the server with the same kind of encryption.
+	tgtSSE := srcSSE
+	if tgtSSE == nil {
+		if tgtSSE, err = objectSSE(st.Metadata); err != nil {
+			return nil, false, err.Trace(objectURL)
+		}
+	}
+
+	var tags map[string]string
+	if count := st.Metadata["X-Amz-Tagging-Count"]; count != "" && count != "0" {
+		if tags, err = clnt.GetTags(ctx, content.VersionID); err != nil {
+			return nil, false, err.Trace(objectURL)
+		}
+	}
+
+	copyMetadata := make(map[string]string, len(metadata)+3)
+	for k, v := range metadata {
+		copyMetadata[k] = v
+	}
+	for _, k := range []string{AmzObjectLockMode, AmzObjectLockRetainUntilDate, AmzObjectLockLegalHold} {
+		if v, ok := st.Metadata[k]; ok {
+			copyMetadata[k] = v
+		}
+	}
+	if len(metadata) == 0 {
+		// An empty metadata is not replaced on copy, use the default content type.
+		copyMetadata["Content-Type"] = "application/octet-stream"
+	}
+
+	copyOpts := CopyOptions{
+		versionID:    content.VersionID,
+		size:         st.Size,
+		srcSSE:       srcSSE,
+		tgtSSE:       tgtSSE,
+		metadata:     copyMetadata,
+		storageClass: st.StorageClass,
+		tags:         tags,
+	}
+	if err = clnt.Copy(ctx, content.URL.Path, copyOpts, nil); err != nil {
+		return nil, false, err.Trace(objectURL)
+	}
+	return metadata, true, nil
+}
+
+// equalMetadata returns true if both metadata are the same.
+func equalMetadata(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+// mainMetaEdit is the main of the meta commands changing metadata.
+func mainMetaEdit(cliCtx *cli.Context, op string, minArgs int, edit func(metadata map[string]string)) error {
+	ctx, cancelMeta := context.WithCancel(globalContext)
+	defer cancelMeta()
+
+	setMetaColors()
+
+	targetURL, opts := parseMetaOptions(cliCtx, minArgs)
+	return walkMeta(ctx, targetURL, opts, func(alias string, versions []*ClientContent) bool {
+		ok := true
+		for _, content := range versions {
+			key := path.Join(alias, content.URL.Path)
+			metadata, changed, err := rewriteMetadata(ctx, alias, content, opts, edit)
+			if err != nil {
+				errorIf(err, "Unable to change the metadata of `"+key+"`.")
+				ok = false
+				continue
+			}
+			if changed {
+				printMsg(metaMessage{op: op, Key: key, VersionID: content.VersionID, Metadata: metadata, DryRun: opts.isFake})
+			}
+		}
+		return ok
+	})
+}
+
+// setMetaColors sets the colors of the meta messages.
+func setMetaColors() {
+	console.SetColor("MetaKey", color.New(color.Bold))
+	console.SetColor("MetaName", color.New(color.FgCyan))
+	console.SetColor("MetaValue", color.New(color.FgYellow))
+}
+
+// metaMessage container for the metadata of an object.
+type metaMessage struct {
+	op        string
+	Status    string            `json:"status"`
+	Key       string            `json:"key"`
+	VersionID string            `json:"versionID,omitempty"`
+	Metadata  map[string]string `json:"metadata"`
+	DryRun    bool              `json:"dryRun,omitempty"`
+}
+
+// String colorized metadata message
+func (m metaMessage) String() string {
+	key := m.Key
+	if m.VersionID != "" {
+		key += " (versionId=" + m.VersionID + ")"
+	}
+	var b strings.Builder
+	switch {
+	case m.op == "list":
+		b.WriteString(console.Colorize("MetaKey", key) + ":")
+	case m.DryRun:
+		fmt.Fprintf(&b, "Would change the metadata of %s to:", console.Colorize("MetaKey", "`"+key+"`"))
+	default:
+		fmt.Fprintf(&b, "Changed the metadata of %s to:", console.Colorize("MetaKey", "`"+key+"`"))
+	}
+	names := make([]string, 0, len(m.Metadata))
+	for k := range m.Metadata {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		fmt.Fprintf(&b, "\n  %s: %s", console.Colorize("MetaName", k), console.Colorize("MetaValue", m.Metadata[k]))
+	}
+	return b.String()
+}
+
+// JSON jsonified metadata message
+func (m metaMessage) JSON() string {
+	m.Status = "success"
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
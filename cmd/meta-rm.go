@@ -0,0 +1,77 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var metaRemoveCmd = cli.Command{
+	Name:         "rm",
+	Usage:        "remove metadata of object(s)",
+	Action:       mainMetaRemove,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(append(append(metaFlags, metaEditFlags...), ioFlags...), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET KEY [KEY...]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  KEY is a standard header or the name of a user metadata, as for 'mc meta set'.
+  Only the current version of the objects is changed.
+
+EXAMPLES:
+  1. Remove a user metadata of an object.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket/report.pdf owner
+
+  2. Remove the content disposition and the expiry of all the objects under a prefix.
+     {{.Prompt}} {{.HelpName}} --recursive myminio/mybucket/downloads/ Content-Disposition Expires
+`,
+}
+
+// parseMetaKeys parses the KEY arguments of "mc meta rm".
+func parseMetaKeys(args []string) ([]string, *probe.Error) {
+	keys := make([]string, 0, len(args))
+	for _, arg := range args {
+		key, err := normalizeMetaKey(arg)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// mainMetaRemove is the handle for "mc meta rm" command.
+func mainMetaRemove(cliCtx *cli.Context) error {
+	keys, err := parseMetaKeys(cliCtx.Args().Tail())
+	fatalIf(err, "Unable to parse the metadata keys.")
+
+	return mainMetaEdit(cliCtx, "rm", 2, func(metadata map[string]string) {
+		for _, k := range keys {
+			delete(metadata, k)
+		}
+	})
+}
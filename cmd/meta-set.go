@@ -0,0 +1,93 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var metaSetCmd = cli.Command{
+	Name:         "set",
+	Usage:        "set metadata of object(s)",
+	Action:       mainMetaSet,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(append(append(metaFlags, metaEditFlags...), ioFlags...), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET KEY=VALUE [KEY=VALUE...]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  KEY is one of Cache-Control, Content-Disposition, Content-Encoding, Content-Language,
+  Content-Type and Expires, or the name of a user metadata, with or without its
+  X-Amz-Meta- prefix. The objects are copied onto themselves with the new metadata,
+  keeping their encryption, storage class, tags, retention and legal hold. Only the
+  current version of the objects is changed: on versioned buckets each change creates
+  a new version and the metadata of the older versions is kept.
+
+EXAMPLES:
+  1. Set the content type of an object.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket/report.pdf Content-Type=application/pdf
+
+  2. Set the cache control and a user metadata of all the objects under a prefix.
+     {{.Prompt}} {{.HelpName}} --recursive myminio/mybucket/assets/ Cache-Control="max-age=3600" owner=web
+
+  3. Show the metadata the objects not modified for 30 days would get, without changing it.
+     {{.Prompt}} {{.HelpName}} --recursive --older-than 30d --dry-run myminio/mybucket/logs/ Cache-Control=no-cache
+
+  4. Set the content type of SSE-C encrypted objects, excluding the '.txt' files.
+     {{.Prompt}} {{.HelpName}} --recursive --exclude "*.txt" --encrypt-key "myminio/mybucket/=32byteslongsecretkeymustbegiven1" myminio/mybucket/ Content-Type=application/json
+`,
+}
+
+// parseMetaSetArgs parses the KEY=VALUE arguments of "mc meta set".
+func parseMetaSetArgs(args []string) (map[string]string, *probe.Error) {
+	metadata := make(map[string]string, len(args))
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return nil, errInvalidArgument().Trace(arg, "expected KEY=VALUE")
+		}
+		key, err := normalizeMetaKey(kv[0])
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = kv[1]
+	}
+	return metadata, nil
+}
+
+// mainMetaSet is the handle for "mc meta set" command.
+func mainMetaSet(cliCtx *cli.Context) error {
+	changes, err := parseMetaSetArgs(cliCtx.Args().Tail())
+	fatalIf(err, "Unable to parse the metadata.")
+
+	return mainMetaEdit(cliCtx, "set", 2, func(metadata map[string]string) {
+		for k, v := range changes {
+			metadata[k] = v
+		}
+	})
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+func TestParseMetaSetArgs(t *testing.T) {
+	metadata, err := parseMetaSetArgs([]string{
+		"content-type=text/html; charset=utf-8",
+		"Cache-Control=max-age=3600",
+		"owner=alice",
+		"x-amz-meta-team=web",
+		"empty=",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"Content-Type":     "text/html; charset=utf-8",
+		"Cache-Control":    "max-age=3600",
+		"X-Amz-Meta-Owner": "alice",
+		"X-Amz-Meta-Team":  "web",
+		"X-Amz-Meta-Empty": "",
+	}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Fatalf("expected %v, got %v", expected, metadata)
+	}
+
+	for i, args := range [][]string{
+		{"owner"},
+		{"=alice"},
+		{"x-amz-storage-class=GLACIER"},
+		{"x-amz-object-lock-mode=COMPLIANCE"},
+		{"my key=value"},
+	} {
+		if _, err := parseMetaSetArgs(args); err == nil {
+			t.Errorf("Test %d: expected an error for %v", i+1, args)
+		}
+	}
+}
+
+func TestEditableMetadata(t *testing.T) {
+	metadata := editableMetadata(map[string]string{
+		"Content-Type":                 "image/png",
+		"Expires":                      "Thu, 01 Dec 2022 16:00:00 GMT",
+		"X-Amz-Meta-Owner":             "alice",
+		"Etag":                         "abc",
+		"Content-Length":               "10",
+		"X-Amz-Server-Side-Encryption": "AES256",
+		"X-Amz-Object-Lock-Mode":       "GOVERNANCE",
+		"X-Amz-Tagging-Count":          "2",
+	})
+	expected := map[string]string{
+		"Content-Type":     "image/png",
+		"Expires":          "Thu, 01 Dec 2022 16:00:00 GMT",
+		"X-Amz-Meta-Owner": "alice",
+	}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Fatalf("expected %v, got %v", expected, metadata)
+	}
+	if !equalMetadata(metadata, expected) || equalMetadata(metadata, map[string]string{"Content-Type": "image/png"}) {
+		t.Fatal("unexpected metadata comparison")
+	}
+}
+
+func TestObjectSSE(t *testing.T) {
+	testCases := []struct {
+		headers map[string]string
+		sseType encrypt.Type
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{"X-Amz-Server-Side-Encryption": "AES256"}, encrypt.S3},
+		{map[string]string{
+			"X-Amz-Server-Side-Encryption":                "aws:kms",
+			"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": "my-key",
+		}, encrypt.KMS},
+	}
+	for i, tc := range testCases {
+		sse, err := objectSSE(tc.headers)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if tc.sseType == "" && sse != nil || tc.sseType != "" && (sse == nil || sse.Type() != tc.sseType) {
+			t.Fatalf("Test %d: expected %q, got %v", i+1, tc.sseType, sse)
+		}
+	}
+}
+
+func TestMetaOptionsSkip(t *testing.T) {
+	now := time.Now()
+	opts := metaOptions{olderThan: "1d", excludes: []string{"*.tmp"}}
+	testCases := []struct {
+		content *ClientContent
+		skip    bool
+	}{
+		{&ClientContent{URL: *newClientURL("/bucket/a.txt"), Time: now.Add(-48 * time.Hour)}, false},
+		{&ClientContent{URL: *newClientURL("/bucket/a.txt"), Time: now}, true},
+		{&ClientContent{URL: *newClientURL("/bucket/dir/a.tmp"), Time: now.Add(-48 * time.Hour)}, true},
+		{&ClientContent{URL: *newClientURL("/bucket/a.txt"), Time: now.Add(-48 * time.Hour), IsDeleteMarker: true}, true},
+	}
+	for i, tc := range testCases {
+		if skip := opts.skip(tc.content); skip != tc.skip {
+			t.Errorf("Test %d: expected %v, got %v", i+1, tc.skip, skip)
+		}
+	}
+}
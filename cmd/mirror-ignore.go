@@ -0,0 +1,165 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+// mirrorIgnoreFile is read from the root of a mirror source.
+const mirrorIgnoreFile = ".mcignore"
+
+// mirrorIgnore holds ignore rules in the .gitignore syntax: one pattern
+// per line, '#' comments, '!' to re-include, a trailing '/' to match
+// directories only, a '/' elsewhere to anchor the pattern to the root
+// and '**' to match any number of directories.
+type mirrorIgnore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func parseMirrorIgnore(r io.Reader) (*mirrorIgnore, error) {
+	m := &mirrorIgnore{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			// Escaped leading '#' or '!'.
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if rule.pattern == "" {
+			continue
+		}
+		if _, e := path.Match(rule.pattern, ""); e != nil {
+			return nil, e
+		}
+		m.rules = append(m.rules, rule)
+	}
+	return m, scanner.Err()
+}
+
+// loadMirrorIgnore reads the ignore file at the root of a source, if any.
+func loadMirrorIgnore(ctx context.Context, sourceURL string, encKeyDB map[string][]prefixSSEPair) (*mirrorIgnore, *probe.Error) {
+	ignorePath := urlJoinPath(sourceURL, mirrorIgnoreFile)
+	clnt, err := newClient(ignorePath)
+	if err != nil {
+		return nil, err.Trace(ignorePath)
+	}
+	alias, _, _ := mustExpandAlias(ignorePath)
+	sse := getSSE(ignorePath, encKeyDB[alias])
+	if _, err = clnt.Stat(ctx, StatOptions{sse: sse}); err != nil {
+		switch err.ToGoError().(type) {
+		case PathNotFound, ObjectMissing, BucketDoesNotExist:
+			return nil, nil
+		}
+		return nil, err.Trace(ignorePath)
+	}
+	reader, err := clnt.Get(ctx, GetOptions{SSE: sse})
+	if err != nil {
+		return nil, err.Trace(ignorePath)
+	}
+	defer reader.Close()
+	m, e := parseMirrorIgnore(reader)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(ignorePath)
+	}
+	return m, nil
+}
+
+// Match returns whether a file, given by its slash separated path
+// relative to the source, is ignored. A nil mirrorIgnore ignores nothing.
+func (m *mirrorIgnore) Match(name string) bool {
+	if m == nil {
+		return false
+	}
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return false
+	}
+	elems := strings.Split(name, "/")
+	ignored := false
+	for _, rule := range m.rules {
+		// A rule matching a parent directory applies to its content.
+		for i := 1; i <= len(elems); i++ {
+			if rule.dirOnly && i == len(elems) {
+				break
+			}
+			if rule.match(elems[:i]) {
+				ignored = !rule.negate
+				break
+			}
+		}
+	}
+	return ignored
+}
+
+func (rule ignoreRule) match(elems []string) bool {
+	if !rule.anchored {
+		ok, _ := path.Match(rule.pattern, elems[len(elems)-1])
+		return ok
+	}
+	return matchGlobElems(strings.Split(rule.pattern, "/"), elems)
+}
+
+// matchGlobElems matches path elements with pattern elements, a "**"
+// element matches zero or more path elements.
+func matchGlobElems(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchGlobElems(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
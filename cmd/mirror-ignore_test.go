@@ -0,0 +1,69 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMirrorIgnore(t *testing.T) {
+	rules := `# build output
+*.tmp
+build/
+/secrets.txt
+docs/**/draft-*
+!important.tmp
+\#literal
+`
+	m, e := parseMirrorIgnore(strings.NewReader(rules))
+	if e != nil {
+		t.Fatal(e)
+	}
+	testCases := []struct {
+		name    string
+		ignored bool
+	}{
+		{"file.tmp", true},
+		{"dir/file.tmp", true},
+		{"important.tmp", false},
+		{"build/out/bin", true},
+		{"src/build/out", true},
+		{"build", false},
+		{"secrets.txt", true},
+		{"dir/secrets.txt", false},
+		{"docs/draft-1.md", true},
+		{"docs/a/b/draft-2.md", true},
+		{"docs/final.md", false},
+		{"#literal", true},
+		{"/dir/file.go", false},
+	}
+	for _, tc := range testCases {
+		if got := m.Match(tc.name); got != tc.ignored {
+			t.Errorf("%q: expected ignored=%v, got %v", tc.name, tc.ignored, got)
+		}
+	}
+
+	var none *mirrorIgnore
+	if none.Match("file.tmp") {
+		t.Fatal("a nil ignore list must not ignore anything")
+	}
+	if _, e = parseMirrorIgnore(strings.NewReader("[a-")); e == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+}
@@ -20,6 +20,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
 	"path"
@@ -29,6 +30,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	"github.com/minio/cli"
 	json "github.com/minio/colorjson"
@@ -67,6 +69,14 @@ var (
 			Name:  "watch, w",
 			Usage: "watch and synchronize changes",
 		},
+		cli.DurationFlag{
+			Name:  "debounce",
+			Usage: "with --watch, mirror a path once it has no new event for this duration, merging its events",
+		},
+		cli.StringFlag{
+			Name:  "watch-state",
+			Usage: "with --watch, file saving the position of the watch, to mirror the changes missed while stopped",
+		},
 		cli.BoolFlag{
 			Name:  "remove",
 			Usage: "remove extraneous object(s) on target",
@@ -97,6 +107,7 @@ var (
 			Name:  "disable-multipart",
 			Usage: "disable multipart upload feature",
 		},
+		compressFlag,
 		cli.StringSliceFlag{
 			Name:  "exclude",
 			Usage: "exclude object(s) that match specified object name pattern",
@@ -125,6 +136,14 @@ var (
 			Name:  "monitoring-address",
 			Usage: "if specified, a new prometheus endpoint will be created to report mirroring activity. (eg: localhost:8081)",
 		},
+		cli.StringFlag{
+			Name:  "limit-bandwidth",
+			Usage: "limit the transfer rate per second, e.g. 10MiB",
+		},
+		cli.StringFlag{
+			Name:  "jobs",
+			Usage: "run the mirror jobs defined in a YAML file, see 'mc agent run'",
+		},
 	}
 )
 
@@ -135,19 +154,34 @@ var mirrorCmd = cli.Command{
 	Action:       mainMirror,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(mirrorFlags, ioFlags...), globalFlags...),
+	Flags:        append(append(append(append(mirrorFlags, deleteGuardFlags...), parallelFlags...), ioFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
 USAGE:
   {{.HelpName}} [FLAGS] SOURCE TARGET
+  {{.HelpName}} [FLAGS] --jobs FILE
 
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
 ENVIRONMENT VARIABLES:
-   MC_ENCRYPT:      list of comma delimited prefixes
-   MC_ENCRYPT_KEY:  list of comma delimited prefix=secret values
+   MC_ENCRYPT:                     list of comma delimited prefixes
+   MC_ENCRYPT_KEY:                 list of comma delimited prefix=secret values
+   MC_DOWNLOAD_MULTIPART_SIZE:     part size of parallel downloads to local filesystem, defaults to 64MiB
+   MC_DOWNLOAD_MULTIPART_THREADS:  number of concurrent ranged GETs per download, 1 disables, defaults to 4
+
+IGNORE FILE:
+   A .mcignore file at the root of SOURCE lists paths to skip, with the .gitignore syntax.
+   It is read when mirroring starts and applies like --exclude.
+
+DELETION SAFEGUARDS:
+   With --remove, --max-delete aborts the removals if more than N objects, or more than P% of
+   the objects found in TARGET, are only found in TARGET, and reports the objects that would have
+   been removed. Objects under the prefixes protected with 'mc alias protect' are never removed.
+   --backup-dir copies each object server-side, under a directory named after the start time of
+   the run, before removing it. Buckets are not removed when a safeguard applies to them. With
+   --watch, the objects removed after the first pass are not counted against --max-delete.
 
 EXAMPLES:
   01. Mirror a bucket recursively from MinIO cloud storage to a bucket on Amazon S3 cloud storage.
@@ -202,36 +236,89 @@ EXAMPLES:
   16. Cross mirror between sites in a active-active deployment.
       Site-A: {{.Prompt}} {{.HelpName}} --active-active siteA siteB
       Site-B: {{.Prompt}} {{.HelpName}} --active-active siteB siteA
+
+  17. Mirror many small files to a busy gateway, using between 2 and 16 concurrent workers.
+      {{.Prompt}} {{.HelpName}} --min-workers 2 --max-workers 16 backup/ s3/archive
+
+  18. Watch a local project, mirroring files once they are unchanged for 2 seconds. Renamed
+      files are copied on the server side instead of being uploaded again.
+      {{.Prompt}} {{.HelpName}} --watch --debounce 2s --remove ~/project s3/project
+
+  19. Mirror a local folder to Amazon S3 cloud storage, transferring at most 20 MiB per second.
+      {{.Prompt}} {{.HelpName}} --limit-bandwidth 20MiB backup/ s3/archive
+
+  20. Run all the mirror jobs defined in jobs.yaml, reporting their activity to prometheus.
+      {{.Prompt}} {{.HelpName}} --jobs jobs.yaml --monitoring-address localhost:8081
+
+  21. Mirror a mounted volume, removing extraneous objects unless that's more than 5% of the bucket.
+      {{.Prompt}} {{.HelpName}} --remove --max-delete 5% /mnt/photos s3/photos
+
+  22. Mirror a local folder, keeping a copy of the removed objects under 's3/trash/'.
+      {{.Prompt}} {{.HelpName}} --remove --backup-dir s3/trash/ backup/ s3/archive
+
+  23. Mirror a local folder of logs compressed with zstd, the objects are compared by their uncompressed size.
+      {{.Prompt}} {{.HelpName}} --compress zstd /var/log/app/ s3/logs/app
+
+  24. Watch a bucket, mirroring on restart the changes made while mc was stopped.
+      {{.Prompt}} {{.HelpName}} --watch --watch-state ~/.mc/mirror-photos.json s3/photos backup/photos
 `,
 }
 
 var (
-	mirrorTotalOps = promauto.NewCounter(prometheus.CounterOpts{
+	mirrorTotalOps = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "mc_mirror_total_s3ops",
 		Help: "The total number of mirror operations",
-	})
-	mirrorTotalUploadedBytes = promauto.NewCounter(prometheus.CounterOpts{
+	}, []string{"mirror_job"})
+	mirrorTotalUploadedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "mc_mirror_total_s3uploaded_bytes",
 		Help: "The total number of bytes uploaded",
-	})
-	mirrorFailedOps = promauto.NewCounter(prometheus.CounterOpts{
+	}, []string{"mirror_job"})
+	mirrorFailedOps = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "mc_mirror_failed_s3ops",
 		Help: "The total number of failed mirror operations",
-	})
-	mirrorRestarts = promauto.NewCounter(prometheus.CounterOpts{
+	}, []string{"mirror_job"})
+	mirrorRestarts = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "mc_mirror_total_restarts",
 		Help: "The number of mirror restarts",
-	})
+	}, []string{"mirror_job"})
+	mirrorWorkers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mc_mirror_workers",
+		Help: "The current number of concurrent mirror workers",
+	}, []string{"mirror_job"})
 	mirrorReplicationDurations = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "mc_mirror_replication_duration",
 			Help:    "Histogram of replication time in ms per object sizes",
 			Buckets: prometheus.ExponentialBuckets(1, 20, 5),
 		},
-		[]string{"object_size"},
+		[]string{"mirror_job", "object_size"},
 	)
 )
 
+// mirrorMetrics holds the prometheus metrics of a mirror, labeled with
+// the name of its job when run by the agent. A plain mirror has an empty
+// label which prometheus treats as no label.
+type mirrorMetrics struct {
+	totalOps      prometheus.Counter
+	uploadedBytes prometheus.Counter
+	failedOps     prometheus.Counter
+	restarts      prometheus.Counter
+	workers       prometheus.Gauge
+	durations     prometheus.ObserverVec
+}
+
+func newMirrorMetrics(job string) *mirrorMetrics {
+	labels := prometheus.Labels{"mirror_job": job}
+	return &mirrorMetrics{
+		totalOps:      mirrorTotalOps.With(labels),
+		uploadedBytes: mirrorTotalUploadedBytes.With(labels),
+		failedOps:     mirrorFailedOps.With(labels),
+		restarts:      mirrorRestarts.With(labels),
+		workers:       mirrorWorkers.With(labels),
+		durations:     mirrorReplicationDurations.MustCurryWith(labels),
+	}
+}
+
 const uaMirrorAppName = "mc-mirror"
 
 type mirrorJob struct {
@@ -255,6 +342,9 @@ type mirrorJob struct {
 	sourceURL string
 	targetURL string
 
+	// abortedRemovals are the removals aborted by --max-delete.
+	abortedRemovals []URLs
+
 	opts mirrorOptions
 }
 
@@ -266,6 +356,7 @@ type mirrorMessage struct {
 	Size       int64  `json:"size"`
 	TotalCount int64  `json:"totalCount"`
 	TotalSize  int64  `json:"totalSize"`
+	Workers    int    `json:"workers,omitempty"`
 }
 
 // String colorized mirror message
@@ -303,6 +394,9 @@ func (mj *mirrorJob) doCreateBucket(ctx context.Context, sURLs URLs) URLs {
 }
 
 func (mj *mirrorJob) doDeleteBucket(ctx context.Context, sURLs URLs) URLs {
+	if err := mj.opts.deleteGuard.checkRemoveBucket(sURLs.TargetAlias, sURLs.TargetContent.URL.Path); err != nil {
+		return sURLs.WithError(err)
+	}
 	if mj.opts.isFake {
 		return sURLs.WithError(nil)
 	}
@@ -329,9 +423,16 @@ func (mj *mirrorJob) doDeleteBucket(ctx context.Context, sURLs URLs) URLs {
 
 // doRemove - removes files on target.
 func (mj *mirrorJob) doRemove(ctx context.Context, sURLs URLs) URLs {
+	guard := mj.opts.deleteGuard
+	if guard.isProtected(sURLs.TargetAlias, sURLs.TargetContent.URL.Path) {
+		return sURLs.WithError(errDeleteProtected(filepath.ToSlash(filepath.Join(sURLs.TargetAlias, sURLs.TargetContent.URL.Path))))
+	}
 	if mj.opts.isFake {
 		return sURLs.WithError(nil)
 	}
+	if err := guard.backup(ctx, sURLs.TargetAlias, sURLs.TargetContent, mj.opts.encKeyDB); err != nil {
+		return sURLs.WithError(err)
+	}
 
 	// Construct proper path with alias.
 	targetWithAlias := filepath.Join(sURLs.TargetAlias, sURLs.TargetContent.URL.Path)
@@ -393,6 +494,56 @@ func (mj *mirrorJob) doMirrorWatch(ctx context.Context, targetPath string, tgtSS
 	return sURLs.WithError(probe.NewError(ObjectAlreadyExists{}))
 }
 
+// doMirrorRename applies a rename of the source to the target with a
+// server-side copy of the object at its previous path, which is removed
+// when mirroring removals. The file is uploaded if the copy isn't possible.
+func (mj *mirrorJob) doMirrorRename(ctx context.Context, renamedTargetPath, targetPath string, tgtSSE encrypt.ServerSide, sURLs URLs) URLs {
+	if mj.opts.isFake {
+		return sURLs.WithError(nil)
+	}
+	renamedClient, err := newClient(renamedTargetPath)
+	if err != nil {
+		return sURLs.WithError(err)
+	}
+	renamedContent, err := renamedClient.Stat(ctx, StatOptions{sse: tgtSSE})
+	if err != nil || renamedContent.Size != sURLs.SourceContent.Size {
+		// The target doesn't have the same content.
+		return mj.doMirrorWatch(ctx, targetPath, tgtSSE, sURLs)
+	}
+	targetClient, err := newClient(targetPath)
+	if err != nil {
+		return sURLs.WithError(err)
+	}
+	if sURLs.SourceAlias != "" {
+		targetClient.AddUserAgent(uaMirrorAppName+":"+sURLs.SourceAlias, ReleaseTag)
+	} else {
+		targetClient.AddUserAgent(uaMirrorAppName, ReleaseTag)
+	}
+	opts := CopyOptions{size: renamedContent.Size, srcSSE: tgtSSE, tgtSSE: tgtSSE, storageClass: mj.opts.storageClass}
+	if err = targetClient.Copy(ctx, renamedClient.GetURL().Path, opts, nil); err != nil {
+		return mj.doMirrorWatch(ctx, targetPath, tgtSSE, sURLs)
+	}
+
+	mj.status.PrintMsg(mirrorMessage{
+		Source:     filepath.ToSlash(renamedTargetPath),
+		Target:     filepath.ToSlash(targetPath),
+		Size:       renamedContent.Size,
+		TotalCount: sURLs.TotalCount,
+		TotalSize:  sURLs.TotalSize,
+	})
+	if mj.opts.isRemove || mj.opts.activeActive {
+		_, expandedPath, _ := mustExpandAlias(renamedTargetPath)
+		removeURLs := mj.doRemove(ctx, URLs{
+			TargetAlias:   sURLs.TargetAlias,
+			TargetContent: &ClientContent{URL: *newClientURL(expandedPath)},
+		})
+		if removeURLs.Error != nil {
+			return removeURLs
+		}
+	}
+	return sURLs.WithError(nil)
+}
+
 func convertSizeToTag(size int64) string {
 	switch {
 	case size < 1024:
@@ -463,15 +614,17 @@ func (mj *mirrorJob) doMirror(ctx context.Context, sURLs URLs) URLs {
 		Size:       length,
 		TotalCount: sURLs.TotalCount,
 		TotalSize:  sURLs.TotalSize,
+		Workers:    mj.parallel.workers(),
 	})
 	sURLs.MD5 = mj.opts.md5
 	sURLs.DisableMultipart = mj.opts.disableMultipart
+	sURLs.Compress = mj.opts.compress
 
 	now := time.Now()
 	ret := uploadSourceToTargetURL(ctx, sURLs, mj.status, mj.opts.encKeyDB, mj.opts.isMetadata, false)
 	if ret.Error == nil {
 		durationMs := time.Since(now) / time.Millisecond
-		mirrorReplicationDurations.With(prometheus.Labels{"object_size": convertSizeToTag(sURLs.SourceContent.Size)}).Observe(float64(durationMs))
+		mj.opts.metrics.durations.With(prometheus.Labels{"object_size": convertSizeToTag(sURLs.SourceContent.Size)}).Observe(float64(durationMs))
 	}
 	return ret
 }
@@ -493,10 +646,10 @@ func (mj *mirrorJob) monitorMirrorStatus(cancel context.CancelFunc) (errDuringMi
 		}
 
 		// Update prometheus fields
-		mirrorTotalOps.Inc()
+		mj.opts.metrics.totalOps.Inc()
 
 		if sURLs.Error != nil {
-			mirrorFailedOps.Inc()
+			mj.opts.metrics.failedOps.Inc()
 			switch {
 			case sURLs.SourceContent != nil:
 				if !isErrIgnored(sURLs.Error) {
@@ -509,6 +662,13 @@ func (mj *mirrorJob) monitorMirrorStatus(cancel context.CancelFunc) (errDuringMi
 				errorIf(sURLs.Error.Trace(sURLs.TargetContent.URL.String()),
 					fmt.Sprintf("Failed to remove `%s`.", sURLs.TargetContent.URL.String()))
 				errDuringMirror = true
+			case mj.abortedRemovals != nil:
+				for _, rURLs := range mj.abortedRemovals {
+					printMsg(deleteAbortedMessage{Key: filepath.ToSlash(filepath.Join(rURLs.TargetAlias, rURLs.TargetContent.URL.Path))})
+				}
+				mj.abortedRemovals = nil
+				errorIf(sURLs.Error.Trace(mj.targetURL), "Aborted without removing anything.")
+				errDuringMirror = true
 			default:
 				if sURLs.ErrorCond == differInUnknown {
 					errorIf(sURLs.Error.Trace(), "Failed to perform mirroring")
@@ -529,7 +689,7 @@ func (mj *mirrorJob) monitorMirrorStatus(cancel context.CancelFunc) (errDuringMi
 		}
 
 		if sURLs.SourceContent != nil {
-			mirrorTotalUploadedBytes.Add(float64(sURLs.SourceContent.Size))
+			mj.opts.metrics.uploadedBytes.Add(float64(sURLs.SourceContent.Size))
 		} else if sURLs.TargetContent != nil {
 			// Construct user facing message and path.
 			targetPath := filepath.ToSlash(filepath.Join(sURLs.TargetAlias, sURLs.TargetContent.URL.Path))
@@ -542,129 +702,183 @@ func (mj *mirrorJob) monitorMirrorStatus(cancel context.CancelFunc) (errDuringMi
 
 func (mj *mirrorJob) watchMirrorEvents(ctx context.Context, events []EventInfo) {
 	for _, event := range events {
-		// It will change the expanded alias back to the alias
-		// again, by replacing the sourceUrlFull with the sourceAlias.
-		// This url will be used to mirror.
-		sourceAlias, sourceURLFull, _ := mustExpandAlias(mj.sourceURL)
-
-		// If the passed source URL points to fs, fetch the absolute src path
-		// to correctly calculate targetPath
-		if sourceAlias == "" {
-			tmpSrcURL, err := filepath.Abs(sourceURLFull)
-			if err == nil {
-				sourceURLFull = tmpSrcURL
-			}
+		if !mj.queueWatchEvent(ctx, event) {
+			// Nothing to mirror, the event is processed.
+			event.Ack()
 		}
-		eventPath := event.Path
-		if runtime.GOOS == "darwin" {
-			// Strip the prefixes in the event path. Happens in darwin OS only
-			eventPath = eventPath[strings.Index(eventPath, sourceURLFull):]
-		} else if runtime.GOOS == "windows" {
-			// Shared folder as source URL and if event path is an absolute path.
-			eventPath = getEventPathURLWin(mj.sourceURL, eventPath)
+	}
+}
+
+// queueWatchEvent queues the task mirroring a watch event, the task
+// acknowledges the event once done. It returns false if the event
+// needs no task.
+func (mj *mirrorJob) queueWatchEvent(ctx context.Context, event EventInfo) bool {
+	// It will change the expanded alias back to the alias
+	// again, by replacing the sourceUrlFull with the sourceAlias.
+	// This url will be used to mirror.
+	sourceAlias, sourceURLFull, _ := mustExpandAlias(mj.sourceURL)
+
+	// If the passed source URL points to fs, fetch the absolute src path
+	// to correctly calculate targetPath
+	if sourceAlias == "" {
+		tmpSrcURL, err := filepath.Abs(sourceURLFull)
+		if err == nil {
+			sourceURLFull = tmpSrcURL
 		}
+	}
+	eventPath := event.Path
+	if runtime.GOOS == "darwin" {
+		// Strip the prefixes in the event path. Happens in darwin OS only
+		eventPath = eventPath[strings.Index(eventPath, sourceURLFull):]
+	} else if runtime.GOOS == "windows" {
+		// Shared folder as source URL and if event path is an absolute path.
+		eventPath = getEventPathURLWin(mj.sourceURL, eventPath)
+	}
 
-		sourceURL := newClientURL(eventPath)
+	sourceURL := newClientURL(eventPath)
 
-		// build target path, it is the relative of the eventPath with the sourceUrl
-		// joined to the targetURL.
-		sourceSuffix := strings.TrimPrefix(eventPath, sourceURLFull)
-		// Skip the object, if it matches the Exclude options provided
-		if matchExcludeOptions(mj.opts.excludeOptions, sourceSuffix) {
-			continue
-		}
+	// build target path, it is the relative of the eventPath with the sourceUrl
+	// joined to the targetURL.
+	sourceSuffix := strings.TrimPrefix(eventPath, sourceURLFull)
+	// Skip the object, if it matches the Exclude options provided
+	if matchExcludeOptions(mj.opts.excludeOptions, sourceSuffix) || mj.opts.ignore.Match(filepath.ToSlash(sourceSuffix)) {
+		return false
+	}
 
-		targetPath := urlJoinPath(mj.targetURL, sourceSuffix)
-
-		// newClient needs the unexpanded  path, newCLientURL needs the expanded path
-		targetAlias, expandedTargetPath, _ := mustExpandAlias(targetPath)
-		targetURL := newClientURL(expandedTargetPath)
-		tgtSSE := getSSE(targetPath, mj.opts.encKeyDB[targetAlias])
-
-		if strings.HasPrefix(string(event.Type), "s3:ObjectCreated:") {
-			sourceModTime, _ := time.Parse(time.RFC3339Nano, event.Time)
-			mirrorURL := URLs{
-				SourceAlias: sourceAlias,
-				SourceContent: &ClientContent{
-					URL:              *sourceURL,
-					RetentionEnabled: event.Type == notification.EventType("s3:ObjectCreated:PutRetention"),
-					LegalHoldEnabled: event.Type == notification.EventType("s3:ObjectCreated:PutLegalHold"),
-					Size:             event.Size,
-					Time:             sourceModTime,
-					Metadata:         event.UserMetadata,
-				},
-				TargetAlias:      targetAlias,
-				TargetContent:    &ClientContent{URL: *targetURL},
-				MD5:              mj.opts.md5,
-				DisableMultipart: mj.opts.disableMultipart,
-				encKeyDB:         mj.opts.encKeyDB,
-			}
-			if mj.opts.activeActive &&
-				(getSourceModTimeKey(mirrorURL.SourceContent.Metadata) != "" ||
-					getSourceModTimeKey(mirrorURL.SourceContent.UserMetadata) != "") {
-				// If source has active-active attributes, it means that the
-				// object was uploaded by "mc mirror", hence ignore the event
-				// to avoid copying it.
-				continue
-			}
-			mj.parallel.queueTask(func() URLs {
-				return mj.doMirrorWatch(ctx, targetPath, tgtSSE, mirrorURL)
-			}, mirrorURL.SourceContent.Size)
-		} else if event.Type == notification.ObjectRemovedDelete {
-			if targetAlias != "" && strings.Contains(event.UserAgent, uaMirrorAppName+":"+targetAlias) {
-				// Ignore delete cascading delete events if cyclical.
-				continue
-			}
-			mirrorURL := URLs{
-				SourceAlias:      sourceAlias,
-				SourceContent:    nil,
-				TargetAlias:      targetAlias,
-				TargetContent:    &ClientContent{URL: *targetURL},
-				MD5:              mj.opts.md5,
-				DisableMultipart: mj.opts.disableMultipart,
-				encKeyDB:         mj.opts.encKeyDB,
-			}
-			mirrorURL.TotalCount = mj.status.GetCounts()
-			mirrorURL.TotalSize = mj.status.Get()
-			if mirrorURL.TargetContent != nil && (mj.opts.isRemove || mj.opts.activeActive) {
+	targetPath := urlJoinPath(mj.targetURL, sourceSuffix)
+
+	// newClient needs the unexpanded  path, newCLientURL needs the expanded path
+	targetAlias, expandedTargetPath, _ := mustExpandAlias(targetPath)
+	targetURL := newClientURL(expandedTargetPath)
+	tgtSSE := getSSE(targetPath, mj.opts.encKeyDB[targetAlias])
+
+	if strings.HasPrefix(string(event.Type), "s3:ObjectCreated:") {
+		sourceModTime, _ := time.Parse(time.RFC3339Nano, event.Time)
+		mirrorURL := URLs{
+			SourceAlias: sourceAlias,
+			SourceContent: &ClientContent{
+				URL:              *sourceURL,
+				RetentionEnabled: event.Type == notification.EventType("s3:ObjectCreated:PutRetention"),
+				LegalHoldEnabled: event.Type == notification.EventType("s3:ObjectCreated:PutLegalHold"),
+				Size:             event.Size,
+				Time:             sourceModTime,
+				Metadata:         event.UserMetadata,
+			},
+			TargetAlias:      targetAlias,
+			TargetContent:    &ClientContent{URL: *targetURL},
+			MD5:              mj.opts.md5,
+			DisableMultipart: mj.opts.disableMultipart,
+			encKeyDB:         mj.opts.encKeyDB,
+		}
+		if mj.opts.activeActive &&
+			(getSourceModTimeKey(mirrorURL.SourceContent.Metadata) != "" ||
+				getSourceModTimeKey(mirrorURL.SourceContent.UserMetadata) != "") {
+			// If source has active-active attributes, it means that the
+			// object was uploaded by "mc mirror", hence ignore the event
+			// to avoid copying it.
+			return false
+		}
+		if event.RenamedFrom != "" {
+			renamedSuffix := strings.TrimPrefix(event.RenamedFrom, sourceURLFull)
+			if !matchExcludeOptions(mj.opts.excludeOptions, renamedSuffix) && !mj.opts.ignore.Match(filepath.ToSlash(renamedSuffix)) {
+				renamedTargetPath := urlJoinPath(mj.targetURL, renamedSuffix)
 				mj.parallel.queueTask(func() URLs {
-					return mj.doRemove(ctx, mirrorURL)
+					defer event.Ack()
+					return mj.doMirrorRename(ctx, renamedTargetPath, targetPath, tgtSSE, mirrorURL)
 				}, 0)
+				return true
 			}
-		} else if event.Type == notification.BucketCreatedAll {
-			mirrorURL := URLs{
-				SourceAlias:   sourceAlias,
-				SourceContent: &ClientContent{URL: *sourceURL},
-				TargetAlias:   targetAlias,
-				TargetContent: &ClientContent{URL: *targetURL},
-			}
-			mj.parallel.queueTaskWithBarrier(func() URLs {
-				return mj.doCreateBucket(ctx, mirrorURL)
-			}, 0)
-		} else if event.Type == notification.BucketRemovedAll && mj.opts.isRemove {
-			mirrorURL := URLs{
-				TargetAlias:   targetAlias,
-				TargetContent: &ClientContent{URL: *targetURL},
-			}
-			mj.parallel.queueTaskWithBarrier(func() URLs {
-				return mj.doDeleteBucket(ctx, mirrorURL)
+		}
+		mj.parallel.queueCopyTask(func() URLs {
+			defer event.Ack()
+			return mj.doMirrorWatch(ctx, targetPath, tgtSSE, mirrorURL)
+		}, mirrorURL)
+		return true
+	} else if event.Type == notification.ObjectRemovedDelete {
+		if targetAlias != "" && strings.Contains(event.UserAgent, uaMirrorAppName+":"+targetAlias) {
+			// Ignore delete cascading delete events if cyclical.
+			return false
+		}
+		mirrorURL := URLs{
+			SourceAlias:      sourceAlias,
+			SourceContent:    nil,
+			TargetAlias:      targetAlias,
+			TargetContent:    &ClientContent{URL: *targetURL},
+			MD5:              mj.opts.md5,
+			DisableMultipart: mj.opts.disableMultipart,
+			encKeyDB:         mj.opts.encKeyDB,
+		}
+		mirrorURL.TotalCount = mj.status.GetCounts()
+		mirrorURL.TotalSize = mj.status.Get()
+		if mirrorURL.TargetContent != nil && (mj.opts.isRemove || mj.opts.activeActive) {
+			mj.parallel.queueTask(func() URLs {
+				defer event.Ack()
+				return mj.doRemove(ctx, mirrorURL)
 			}, 0)
+			return true
 		}
-
+	} else if event.Type == notification.BucketCreatedAll {
+		mirrorURL := URLs{
+			SourceAlias:   sourceAlias,
+			SourceContent: &ClientContent{URL: *sourceURL},
+			TargetAlias:   targetAlias,
+			TargetContent: &ClientContent{URL: *targetURL},
+		}
+		mj.parallel.queueTaskWithBarrier(func() URLs {
+			defer event.Ack()
+			return mj.doCreateBucket(ctx, mirrorURL)
+		}, 0)
+		return true
+	} else if event.Type == notification.BucketRemovedAll && mj.opts.isRemove {
+		mirrorURL := URLs{
+			TargetAlias:   targetAlias,
+			TargetContent: &ClientContent{URL: *targetURL},
+		}
+		mj.parallel.queueTaskWithBarrier(func() URLs {
+			defer event.Ack()
+			return mj.doDeleteBucket(ctx, mirrorURL)
+		}, 0)
+		return true
 	}
+	return false
 }
 
 // this goroutine will watch for notifications, and add modified objects to the queue
 func (mj *mirrorJob) watchMirror(ctx context.Context) {
 	defer mj.watcher.Stop()
 
+	// Events are merged by path when debouncing.
+	var coalescer *watchCoalescer
+	var flushCh <-chan time.Time
+	if mj.opts.debounce > 0 {
+		coalescer = newWatchCoalescer(mj.opts.debounce)
+		if sourceAlias, _, _ := mustExpandAlias(mj.sourceURL); sourceAlias == "" {
+			coalescer.stat = statLocalFile
+		}
+		interval := mj.opts.debounce / 4
+		if interval < 10*time.Millisecond {
+			interval = 10 * time.Millisecond
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		flushCh = ticker.C
+	}
+
 	for {
 		select {
 		case events, ok := <-mj.watcher.Events():
 			if !ok {
 				return
 			}
+			if coalescer != nil {
+				coalescer.Add(events, time.Now())
+				continue
+			}
 			mj.watchMirrorEvents(ctx, events)
+		case now := <-flushCh:
+			if events := coalescer.Flush(now, false); len(events) > 0 {
+				mj.watchMirrorEvents(ctx, events)
+			}
 		case err, ok := <-mj.watcher.Errors():
 			if !ok {
 				return
@@ -687,17 +901,21 @@ func (mj *mirrorJob) watchMirror(ctx context.Context) {
 }
 
 func (mj *mirrorJob) watchURL(ctx context.Context, sourceClient Client) *probe.Error {
-	return mj.watcher.Join(ctx, sourceClient, true)
+	return mj.watcher.Join(ctx, sourceClient, true, mj.opts.watchState)
 }
 
 // Fetch urls that need to be mirrored
 func (mj *mirrorJob) startMirror(ctx context.Context) {
 	URLsCh := prepareMirrorURLs(ctx, mj.sourceURL, mj.targetURL, mj.opts)
 
+	// With --max-delete, removals wait for the end of the comparison.
+	var removals []URLs
+
 	for {
 		select {
 		case sURLs, ok := <-URLsCh:
 			if !ok {
+				mj.queueRemovals(ctx, removals)
 				return
 			}
 			if sURLs.Error != nil {
@@ -727,10 +945,14 @@ func (mj *mirrorJob) startMirror(ctx context.Context) {
 			sURLs.TotalSize = mj.status.Get()
 
 			if sURLs.SourceContent != nil {
-				mj.parallel.queueTask(func() URLs {
+				mj.parallel.queueCopyTask(func() URLs {
 					return mj.doMirror(ctx, sURLs)
-				}, sURLs.SourceContent.Size)
+				}, sURLs)
 			} else if sURLs.TargetContent != nil && mj.opts.isRemove {
+				if mj.opts.deleteGuard.hasLimit() {
+					removals = append(removals, sURLs)
+					continue
+				}
 				mj.parallel.queueTask(func() URLs {
 					return mj.doRemove(ctx, sURLs)
 				}, 0)
@@ -743,6 +965,24 @@ func (mj *mirrorJob) startMirror(ctx context.Context) {
 	}
 }
 
+// queueRemovals removes the objects only found in the target, unless
+// there are more than allowed by --max-delete. Then nothing is removed
+// and the objects are reported instead.
+func (mj *mirrorJob) queueRemovals(ctx context.Context, removals []URLs) {
+	guard := mj.opts.deleteGuard
+	if guard.exceeded(int64(len(removals)), guard.targetTotal()) {
+		mj.abortedRemovals = removals
+		mj.statusCh <- URLs{Error: errDeleteLimitExceeded(int64(len(removals)), guard.targetTotal(), guard.limit())}
+		return
+	}
+	for _, sURLs := range removals {
+		sURLs := sURLs
+		mj.parallel.queueTask(func() URLs {
+			return mj.doRemove(ctx, sURLs)
+		}, 0)
+	}
+}
+
 // when using a struct for copying, we could save a lot of passing of variables
 func (mj *mirrorJob) mirror(ctx context.Context) bool {
 	var wg sync.WaitGroup
@@ -769,6 +1009,9 @@ func (mj *mirrorJob) mirror(ctx context.Context) bool {
 	go func() {
 		wg.Wait()
 		mj.parallel.stopAndWait()
+		if mj.opts.isWatch {
+			errorIf(mj.watcher.SaveState(), "Unable to save the watch state.")
+		}
 		close(mj.statusCh)
 	}()
 
@@ -786,18 +1029,31 @@ func newMirrorJob(srcURL, dstURL string, opts mirrorOptions) *mirrorJob {
 		watcher:   NewWatcher(UTCNow()),
 	}
 
-	mj.parallel = newParallelManager(mj.statusCh)
+	mj.parallel = newParallelManager(mj.statusCh, opts.parallelOpts)
+
+	// Transfers report their progress to the hook, throttle them there.
+	var hook io.Reader = mj.parallel
+	if opts.limitBandwidth > 0 {
+		hook = newBandwidthLimiter(hook, opts.limitBandwidth)
+	}
 
 	// we'll define the status to use here,
 	// do we want the quiet status? or the progressbar
 	if globalQuiet {
-		mj.status = NewQuietStatus(mj.parallel)
+		mj.status = NewQuietStatus(hook)
 	} else if globalJSON {
-		mj.status = NewQuietStatus(mj.parallel)
+		mj.status = NewQuietStatus(hook)
 	} else {
-		mj.status = NewProgressStatus(mj.parallel)
+		mj.status = NewProgressStatus(hook)
 	}
 
+	mj.parallel.setResizeHook(func(workers int) {
+		if ps, ok := mj.status.(*ProgressStatus); ok {
+			ps.SetWorkers(workers)
+		}
+		mj.opts.metrics.workers.Set(float64(workers))
+	})
+
 	return &mj
 }
 
@@ -853,7 +1109,7 @@ func getEventPathURLWin(srcURL, eventPath string) string {
 }
 
 // runMirror - mirrors all buckets to another S3 server
-func runMirror(ctx context.Context, cancelMirror context.CancelFunc, srcURL, dstURL string, cli *cli.Context, encKeyDB map[string][]prefixSSEPair) bool {
+func runMirror(ctx context.Context, cancelMirror context.CancelFunc, srcURL, dstURL string, cli *cli.Context, encKeyDB map[string][]prefixSSEPair, metrics *mirrorMetrics) bool {
 	// Parse metadata.
 	userMetadata := make(map[string]string)
 	if cli.String("attr") != "" {
@@ -862,12 +1118,27 @@ func runMirror(ctx context.Context, cancelMirror context.CancelFunc, srcURL, dst
 		fatalIf(err, "Unable to parse attribute %v", cli.String("attr"))
 	}
 
+	limitBandwidth, err := parseLimitBandwidth(cli)
+	fatalIf(err, "Unable to parse --limit-bandwidth.")
+
+	deleteGuard, err := newDeleteGuard(cli)
+	fatalIf(err, "Unable to parse --max-delete.")
+	if cli.Bool("remove") {
+		fatalIf(deleteGuard.checkBackupDir(dstURL), "Unable to use --backup-dir.")
+	}
+
 	srcClt, err := newClient(srcURL)
 	fatalIf(err, "Unable to initialize `"+srcURL+"`.")
 
 	dstClt, err := newClient(dstURL)
 	fatalIf(err, "Unable to initialize `"+dstURL+"`.")
 
+	var ignore *mirrorIgnore
+	if srcClt.GetURL().Type != objectStorage || srcClt.GetURL().Path != string(srcClt.GetURL().Separator) {
+		ignore, err = loadMirrorIgnore(ctx, srcURL, encKeyDB)
+		fatalIf(err, "Unable to read the ignore rules of `"+srcURL+"`.")
+	}
+
 	// This is kept for backward compatibility, `--force` means --overwrite.
 	isOverwrite := cli.Bool("force")
 	if !isOverwrite {
@@ -890,13 +1161,21 @@ func runMirror(ctx context.Context, cancelMirror context.CancelFunc, srcURL, dst
 		isMetadata:       isMetadata,
 		md5:              cli.Bool("md5"),
 		disableMultipart: cli.Bool("disable-multipart"),
+		compress:         cli.String("compress"),
 		excludeOptions:   cli.StringSlice("exclude"),
+		debounce:         cli.Duration("debounce"),
+		watchState:       cli.String("watch-state"),
+		ignore:           ignore,
 		olderThan:        cli.String("older-than"),
 		newerThan:        cli.String("newer-than"),
 		storageClass:     cli.String("storage-class"),
 		userMetadata:     userMetadata,
 		encKeyDB:         encKeyDB,
 		activeActive:     isWatch,
+		parallelOpts:     parseParallelOptions(cli),
+		limitBandwidth:   limitBandwidth,
+		metrics:          metrics,
+		deleteGuard:      deleteGuard,
 	}
 
 	// Create a new mirror job and execute it
@@ -923,6 +1202,11 @@ func runMirror(ctx context.Context, cancelMirror context.CancelFunc, srcURL, dst
 				diffBucket := strings.TrimPrefix(d.SecondURL, dstClt.GetURL().String())
 				if isRemove {
 					aliasedDstBucket := path.Join(dstURL, diffBucket)
+					dstAlias, _, _ := mustExpandAlias(aliasedDstBucket)
+					if err := deleteGuard.checkRemoveBucket(dstAlias, strings.TrimPrefix(aliasedDstBucket, dstAlias)); err != nil {
+						errorIf(err.Trace(aliasedDstBucket), "Refused to remove `"+aliasedDstBucket+"`.")
+						continue
+					}
 					err := deleteBucket(ctx, aliasedDstBucket, false)
 					mj.status.fatalIf(err, "Failed to start mirroring.")
 				}
@@ -1000,10 +1284,38 @@ func runMirror(ctx context.Context, cancelMirror context.CancelFunc, srcURL, dst
 	return mj.mirror(ctx)
 }
 
+// parseLimitBandwidth returns the --limit-bandwidth rate in bytes per
+// second, zero if unlimited.
+func parseLimitBandwidth(cli *cli.Context) (uint64, *probe.Error) {
+	v := cli.String("limit-bandwidth")
+	if v == "" {
+		return 0, nil
+	}
+	rate, e := humanize.ParseBytes(v)
+	if e != nil {
+		return 0, probe.NewError(e).Trace(v)
+	}
+	return rate, nil
+}
+
+// startMirrorMonitoring serves the prometheus metrics on address, if any.
+func startMirrorMonitoring(address string) {
+	if address == "" {
+		return
+	}
+	http.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if e := http.ListenAndServe(address, nil); e != nil {
+			fatalIf(probe.NewError(e), "Unable to setup monitoring endpoint.")
+		}
+	}()
+}
+
 // Main entry point for mirror command.
 func mainMirror(cliCtx *cli.Context) error {
 	// Additional command specific theme customization.
 	console.SetColor("Mirror", color.New(color.FgGreen, color.Bold))
+	console.SetColor("DeleteAborted", color.New(color.FgYellow, color.Bold))
 
 	ctx, cancelMirror := context.WithCancel(globalContext)
 	defer cancelMirror()
@@ -1012,17 +1324,18 @@ func mainMirror(cliCtx *cli.Context) error {
 	encKeyDB, err := getEncKeys(cliCtx)
 	fatalIf(err, "Unable to parse encryption keys.")
 
+	if jobsFile := cliCtx.String("jobs"); jobsFile != "" {
+		if len(cliCtx.Args()) != 0 {
+			cli.ShowCommandHelpAndExit(cliCtx, "mirror", 1) // last argument is exit code.
+		}
+		return runAgent(ctx, cliCtx, jobsFile, agentSocket(cliCtx))
+	}
+
 	// check 'mirror' cli arguments.
 	srcURL, tgtURL := checkMirrorSyntax(ctx, cliCtx, encKeyDB)
 
-	if prometheusAddress := cliCtx.String("monitoring-address"); prometheusAddress != "" {
-		http.Handle("/metrics", promhttp.Handler())
-		go func() {
-			if e := http.ListenAndServe(prometheusAddress, nil); e != nil {
-				fatalIf(probe.NewError(e), "Unable to setup monitoring endpoint.")
-			}
-		}()
-	}
+	startMirrorMonitoring(cliCtx.String("monitoring-address"))
+	metrics := newMirrorMetrics("")
 
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	for {
@@ -1030,9 +1343,9 @@ func mainMirror(cliCtx *cli.Context) error {
 		case <-ctx.Done():
 			return exitStatus(globalErrorExitStatus)
 		default:
-			errorDetected := runMirror(ctx, cancelMirror, srcURL, tgtURL, cliCtx, encKeyDB)
+			errorDetected := runMirror(ctx, cancelMirror, srcURL, tgtURL, cliCtx, encKeyDB, metrics)
 			if cliCtx.Bool("watch") || cliCtx.Bool("multi-master") || cliCtx.Bool("active-active") {
-				mirrorRestarts.Inc()
+				metrics.restarts.Inc()
 				time.Sleep(time.Duration(r.Float64() * float64(2*time.Second)))
 				continue
 			}
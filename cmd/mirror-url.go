@@ -56,6 +56,13 @@ func checkMirrorSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[st
 	_, expandedTargetPath, _ := mustExpandAlias(tgtURL)
 	destClient := newClientURL(expandedTargetPath)
 
+	if codec := cliCtx.String("compress"); codec != "" {
+		fatalIf(checkCompressTarget(codec, tgtURL), "Unable to compress the objects mirrored to `"+tgtURL+"`.")
+		if cliCtx.Bool("disable-multipart") {
+			fatalIf(errInvalidArgument().Trace(URLs...), "--compress and --disable-multipart cannot be used together.")
+		}
+	}
+
 	// Mirror with preserve option on windows
 	// only works for object storage to object storage
 	if runtime.GOOS == "windows" && cliCtx.Bool("a") {
@@ -127,8 +134,13 @@ func deltaSourceTarget(ctx context.Context, sourceURL, targetURL string, opts mi
 		return
 	}
 
-	// List both source and target, compare and return values through channel.
-	for diffMsg := range objectDifference(ctx, sourceClnt, targetClnt, opts.isMetadata) {
+	// List both source and target, compare and return values through
+	// channel. Identical objects count in the total of the target too.
+	// Objects compressed by mc are uploaded with --compress, and
+	// decompressed when downloaded to a local path.
+	withLogicalSize := opts.compress != "" || targetAlias == "" && sourceAlias != ""
+	diffCh := difference(ctx, sourceClnt, targetClnt, opts.isMetadata, true, opts.deleteGuard.hasLimit(), DirNone, withLogicalSize)
+	for diffMsg := range diffCh {
 		if diffMsg.Error != nil {
 			// Send all errors through the channel
 			URLsCh <- URLs{Error: diffMsg.Error, ErrorCond: differInUnknown}
@@ -137,16 +149,20 @@ func deltaSourceTarget(ctx context.Context, sourceURL, targetURL string, opts mi
 
 		srcSuffix := strings.TrimPrefix(diffMsg.FirstURL, sourceURL)
 		// Skip the source object if it matches the Exclude options provided
-		if matchExcludeOptions(opts.excludeOptions, srcSuffix) {
+		if matchExcludeOptions(opts.excludeOptions, srcSuffix) || opts.ignore.Match(filepath.ToSlash(srcSuffix)) {
 			continue
 		}
 
 		tgtSuffix := strings.TrimPrefix(diffMsg.SecondURL, targetURL)
 		// Skip the target object if it matches the Exclude options provided
-		if matchExcludeOptions(opts.excludeOptions, tgtSuffix) {
+		if matchExcludeOptions(opts.excludeOptions, tgtSuffix) || opts.ignore.Match(filepath.ToSlash(tgtSuffix)) {
 			continue
 		}
 
+		if diffMsg.secondContent != nil {
+			opts.deleteGuard.countTarget()
+		}
+
 		switch diffMsg.Diff {
 		case differInNone:
 			// No difference, continue.
@@ -206,11 +222,19 @@ type mirrorOptions struct {
 	isFake, isOverwrite, activeActive bool
 	isWatch, isRemove, isMetadata     bool
 	excludeOptions                    []string
+	ignore                            *mirrorIgnore
+	debounce                          time.Duration
+	watchState                        string
 	encKeyDB                          map[string][]prefixSSEPair
 	md5, disableMultipart             bool
+	compress                          string
 	olderThan, newerThan              string
 	storageClass                      string
 	userMetadata                      map[string]string
+	parallelOpts                      parallelOptions
+	limitBandwidth                    uint64
+	metrics                           *mirrorMetrics
+	deleteGuard                       *deleteGuard
 }
 
 // Prepares urls that need to be copied or removed based on requested options.
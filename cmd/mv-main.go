@@ -78,7 +78,7 @@ var mvCmd = cli.Command{
 	Action:       mainMove,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(mvFlags, ioFlags...), globalFlags...),
+	Flags:        append(append(append(mvFlags, parallelFlags...), ioFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
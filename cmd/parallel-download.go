@@ -0,0 +1,449 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/mc/pkg/hookreader"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/pkg/console"
+	"github.com/minio/pkg/env"
+)
+
+const (
+	// downloadStateSuffix is appended to the target path to persist
+	// the progress of a parallel download, next to the partial file.
+	downloadStateSuffix = ".download.minio"
+
+	defaultDownloadPartSize = 64 * humanize.MiByte
+	defaultDownloadThreads  = 4
+
+	downloadStateVersion = 1
+)
+
+// parallelDownloadOpts - configuration of parallel ranged downloads.
+type parallelDownloadOpts struct {
+	partSize int64
+	threads  int
+}
+
+// getParallelDownloadOpts reads MC_DOWNLOAD_MULTIPART_SIZE and
+// MC_DOWNLOAD_MULTIPART_THREADS, setting threads to 1 disables
+// parallel downloads altogether.
+func getParallelDownloadOpts() (parallelDownloadOpts, *probe.Error) {
+	opts := parallelDownloadOpts{
+		partSize: defaultDownloadPartSize,
+		threads:  defaultDownloadThreads,
+	}
+	if v := env.Get("MC_DOWNLOAD_MULTIPART_SIZE", ""); v != "" {
+		partSize, e := humanize.ParseBytes(v)
+		if e != nil {
+			return opts, probe.NewError(e)
+		}
+		if partSize == 0 {
+			return opts, probe.NewError(fmt.Errorf("invalid MC_DOWNLOAD_MULTIPART_SIZE `%s`", v))
+		}
+		opts.partSize = int64(partSize)
+	}
+	if v := env.Get("MC_DOWNLOAD_MULTIPART_THREADS", ""); v != "" {
+		threads, e := strconv.Atoi(v)
+		if e != nil {
+			return opts, probe.NewError(e)
+		}
+		opts.threads = threads
+	}
+	return opts, nil
+}
+
+// downloadState is persisted while a parallel download is in progress,
+// so that an interrupted download only fetches the missing ranges.
+type downloadState struct {
+	Version   int    `json:"version"`
+	Size      int64  `json:"size"`
+	ETag      string `json:"etag"`
+	VersionID string `json:"versionId,omitempty"`
+	PartSize  int64  `json:"partSize"`
+	Done      []bool `json:"done"`
+}
+
+func newDownloadState(size, partSize int64, etag, versionID string) *downloadState {
+	return &downloadState{
+		Version:   downloadStateVersion,
+		Size:      size,
+		ETag:      etag,
+		VersionID: versionID,
+		PartSize:  partSize,
+		Done:      make([]bool, (size+partSize-1)/partSize),
+	}
+}
+
+// matches returns true if a previously saved state refers to the
+// same source object and the same part layout.
+func (s *downloadState) matches(o *downloadState) bool {
+	return s.Version == o.Version && s.Size == o.Size && s.ETag == o.ETag &&
+		s.VersionID == o.VersionID && s.PartSize == o.PartSize && len(s.Done) == len(o.Done)
+}
+
+func loadDownloadState(statePath string) (*downloadState, error) {
+	data, e := ioutil.ReadFile(statePath)
+	if e != nil {
+		return nil, e
+	}
+	state := &downloadState{}
+	if e = json.Unmarshal(data, state); e != nil {
+		return nil, e
+	}
+	return state, nil
+}
+
+// save atomically writes the state file.
+func (s *downloadState) save(statePath string) error {
+	data, e := json.Marshal(s)
+	if e != nil {
+		return e
+	}
+	tmpPath := statePath + ".tmp"
+	if e = ioutil.WriteFile(tmpPath, data, 0o666); e != nil {
+		return e
+	}
+	return os.Rename(tmpPath, statePath)
+}
+
+// isParallelDownload returns true if the copy of urls should be done with
+// concurrent ranged GETs: an S3 source larger than one part, downloaded
+// into a regular local file.
+func isParallelDownload(urls URLs, opts parallelDownloadOpts, isZip bool) bool {
+	if isZip || opts.threads <= 1 || urls.SourceContent.Size <= opts.partSize {
+		return false
+	}
+	if urls.SourceAlias == "" || urls.TargetAlias != "" {
+		return false
+	}
+	if fi, e := os.Stat(urls.TargetContent.URL.Path); e == nil && !fi.Mode().IsRegular() {
+		return false
+	}
+	return true
+}
+
+// parallelDownloadSourceToTargetURL downloads a remote object into a
+// local file by splitting it into ranges fetched concurrently and
+// written in place, then verifies the result against the source ETag.
+func parallelDownloadSourceToTargetURL(ctx context.Context, urls URLs, srcSSE encrypt.ServerSide, preserve bool, progress io.Reader, opts parallelDownloadOpts) *probe.Error {
+	sourceURL := urls.SourceContent.URL.String()
+	sourceClnt, err := newClientFromAlias(urls.SourceAlias, sourceURL)
+	if err != nil {
+		return err.Trace(urls.SourceAlias, sourceURL)
+	}
+
+	st, err := sourceClnt.Stat(ctx, StatOptions{preserve: preserve, sse: srcSSE, versionID: urls.SourceContent.VersionID})
+	if err != nil {
+		return err.Trace(sourceURL)
+	}
+
+	objectPath := urls.TargetContent.URL.Path
+	if e := os.MkdirAll(filepath.Dir(objectPath), 0o777); e != nil {
+		return probe.NewError(e).Trace(objectPath)
+	}
+	objectPartPath := objectPath + partSuffix
+	statePath := objectPath + downloadStateSuffix
+
+	state := newDownloadState(st.Size, opts.partSize, st.ETag, st.VersionID)
+	if saved, e := loadDownloadState(statePath); e == nil && saved.matches(state) {
+		if fi, e := os.Stat(objectPartPath); e == nil && fi.Size() == st.Size {
+			state = saved
+		}
+	}
+
+	partFile, e := os.OpenFile(objectPartPath, os.O_CREATE|os.O_WRONLY, 0o666)
+	if e != nil {
+		return probe.NewError(e).Trace(objectPartPath)
+	}
+	if e = partFile.Truncate(st.Size); e != nil {
+		partFile.Close()
+		return probe.NewError(e).Trace(objectPartPath)
+	}
+	if e = state.save(statePath); e != nil {
+		partFile.Close()
+		return probe.NewError(e).Trace(statePath)
+	}
+
+	err = downloadParts(ctx, sourceClnt, partFile, state, statePath, GetOptions{
+		SSE:       srcSSE,
+		VersionID: st.VersionID,
+	}, progress, opts.threads)
+	if err != nil {
+		// Keep the partial file and its state for a later resume.
+		partFile.Close()
+		return err.Trace(sourceURL)
+	}
+	if e = partFile.Close(); e != nil {
+		return probe.NewError(e).Trace(objectPartPath)
+	}
+
+	if srcSSE == nil && st.Metadata["X-Amz-Server-Side-Encryption"] == "" {
+		if err = verifyDownloadETag(ctx, sourceClnt, objectPartPath, st); err != nil {
+			os.Remove(objectPartPath)
+			os.Remove(statePath)
+			return err.Trace(sourceURL)
+		}
+	}
+
+	if codec, _ := compressionOf(st.Metadata); codec != "" {
+		// Objects compressed by mc are downloaded as is, then decompressed.
+		if err = decompressFile(objectPartPath, objectPath, codec); err != nil {
+			return err.Trace(sourceURL)
+		}
+		os.Remove(objectPartPath)
+	} else if e = os.Rename(objectPartPath, objectPath); e != nil {
+		return probe.NewError(e).Trace(objectPartPath, objectPath)
+	}
+	os.Remove(statePath)
+
+	if _, ok := st.Metadata[metadataKey]; ok && preserve {
+		return preserveDownloadAttributes(objectPath, st.Metadata)
+	}
+	return nil
+}
+
+// downloadParts fetches all parts not yet marked done in state, using
+// the given number of concurrent workers.
+func downloadParts(ctx context.Context, clnt Client, partFile *os.File, state *downloadState, statePath string, getOpts GetOptions, progress io.Reader, threads int) *probe.Error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	partsCh := make(chan int)
+	go func() {
+		defer close(partsCh)
+		for i, done := range state.Done {
+			if done {
+				advanceProgress(progress, state.partLength(i))
+				continue
+			}
+			select {
+			case partsCh <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr *probe.Error
+	)
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range partsCh {
+				err := downloadPart(ctx, clnt, partFile, state, part, getOpts, progress)
+				mu.Lock()
+				if err == nil {
+					state.Done[part] = true
+					if e := state.save(statePath); e != nil {
+						err = probe.NewError(e).Trace(statePath)
+					}
+				}
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// partLength returns the length of the given part, the last part
+// may be shorter than the part size.
+func (s *downloadState) partLength(part int) int64 {
+	start := int64(part) * s.PartSize
+	if start+s.PartSize > s.Size {
+		return s.Size - start
+	}
+	return s.PartSize
+}
+
+// downloadPart issues a ranged GET for one part and writes it at its
+// offset in the partial file.
+func downloadPart(ctx context.Context, clnt Client, partFile *os.File, state *downloadState, part int, getOpts GetOptions, progress io.Reader) *probe.Error {
+	start := int64(part) * state.PartSize
+	length := state.partLength(part)
+	getOpts.RangeStart = start
+	getOpts.RangeEnd = start + length - 1
+
+	reader, err := clnt.Get(ctx, getOpts)
+	if err != nil {
+		return err.Trace()
+	}
+	defer reader.Close()
+
+	n, e := copyAt(partFile, start, hookreader.NewHook(io.LimitReader(reader, length), progress))
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if n < length {
+		return probe.NewError(UnexpectedEOF{
+			TotalSize:    length,
+			TotalWritten: n,
+		})
+	}
+	return nil
+}
+
+// copyAt copies from reader into w starting at offset off.
+func copyAt(w io.WriterAt, off int64, reader io.Reader) (int64, error) {
+	buf := make([]byte, 1<<20)
+	var written int64
+	for {
+		n, e := reader.Read(buf)
+		if n > 0 {
+			if _, we := w.WriteAt(buf[:n], off+written); we != nil {
+				return written, we
+			}
+			written += int64(n)
+		}
+		if e == io.EOF {
+			return written, nil
+		}
+		if e != nil {
+			return written, e
+		}
+	}
+}
+
+// advanceProgress accounts for bytes that were already downloaded
+// by a previous, interrupted run.
+func advanceProgress(progress io.Reader, n int64) {
+	switch p := progress.(type) {
+	case interface{ Add64(int64) int64 }:
+		p.Add64(n)
+	case interface{ Add(int64) int64 }:
+		p.Add(n)
+	}
+}
+
+var etagRegex = regexp.MustCompile(`^[0-9a-f]{32}(-[0-9]+)?$`)
+
+// verifyDownloadETag compares the MD5 based ETag of the source object
+// against the downloaded file. ETags which are not MD5 based, such as
+// those of encrypted objects, are not verified.
+func verifyDownloadETag(ctx context.Context, clnt Client, filePath string, st *ClientContent) *probe.Error {
+	etag := strings.ToLower(strings.Trim(st.ETag, "\""))
+	if !etagRegex.MatchString(etag) {
+		return nil
+	}
+
+	partSize := st.Size
+	parts := 1
+	multipart := false
+	if i := strings.IndexByte(etag, '-'); i > 0 {
+		var e error
+		if parts, e = strconv.Atoi(etag[i+1:]); e != nil || parts <= 0 {
+			return nil
+		}
+		s3Clnt, ok := clnt.(*S3Client)
+		if !ok {
+			return nil
+		}
+		var err *probe.Error
+		partSize, err = s3Clnt.getPartSize(ctx, st.VersionID, nil, 1)
+		if err != nil {
+			return err.Trace(filePath)
+		}
+		if partSize <= 0 || (st.Size+partSize-1)/partSize != int64(parts) {
+			// Parts of different sizes, the ETag cannot be recomputed.
+			return nil
+		}
+		multipart = true
+	}
+
+	f, e := os.Open(filePath)
+	if e != nil {
+		return probe.NewError(e).Trace(filePath)
+	}
+	defer f.Close()
+
+	var computed string
+	if !multipart {
+		h := md5.New()
+		if _, e = io.Copy(h, f); e != nil {
+			return probe.NewError(e).Trace(filePath)
+		}
+		computed = hex.EncodeToString(h.Sum(nil))
+	} else {
+		sums := md5.New()
+		for i := 0; i < parts; i++ {
+			h := md5.New()
+			if _, e = io.CopyN(h, f, partSize); e != nil && e != io.EOF {
+				return probe.NewError(e).Trace(filePath)
+			}
+			sums.Write(h.Sum(nil))
+		}
+		computed = fmt.Sprintf("%s-%d", hex.EncodeToString(sums.Sum(nil)), parts)
+	}
+	if computed != etag {
+		return probe.NewError(ChecksumMismatch{Expected: etag, Computed: computed})
+	}
+	return nil
+}
+
+// preserveDownloadAttributes applies preserved file attributes
+// recorded in the object metadata to the downloaded file.
+func preserveDownloadAttributes(objectPath string, metadata map[string]string) *probe.Error {
+	attr, e := parseAttribute(metadata)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	fd, e := os.OpenFile(objectPath, os.O_WRONLY, 0o666)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if err := preserveAttributes(fd, attr); err != nil {
+		console.Println(console.Colorize("Error", fmt.Sprintf("unable to preserve attributes, continuing to copy the content %s\n", err.ToGoError())))
+	}
+	fd.Close()
+	atime, mtime, err := parseAtimeMtime(attr)
+	if err != nil {
+		return err.Trace(objectPath)
+	}
+	if !atime.IsZero() && !mtime.IsZero() {
+		if e := os.Chtimes(objectPath, atime, mtime); e != nil {
+			return probe.NewError(e)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// rangeObjectHandler serves ranged GETs and per part HEADs of a
+// multipart object uploaded with a fixed part size.
+type rangeObjectHandler struct {
+	data     []byte
+	partSize int
+	etag     string
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (h *rangeObjectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("ETag", `"`+h.etag+`"`)
+	if r.URL.Query().Get("partNumber") == "1" {
+		if r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(h.partSize))
+		w.Header().Set("Last-Modified", UTCNow().Format(http.TimeFormat))
+		return
+	}
+	if _, ok := r.URL.Query()["location"]; ok {
+		w.Write([]byte("<LocationConstraint xmlns=\"http://doc.s3.amazonaws.com/2006-03-01\"></LocationConstraint>"))
+		return
+	}
+	h.mu.Lock()
+	h.ranges = append(h.ranges, r.Header.Get("Range"))
+	h.mu.Unlock()
+	http.ServeContent(w, r, "object", UTCNow(), bytes.NewReader(h.data))
+}
+
+func multipartETag(data []byte, partSize int) string {
+	sums := md5.New()
+	parts := 0
+	for off := 0; off < len(data); off += partSize {
+		end := off + partSize
+		if end > len(data) {
+			end = len(data)
+		}
+		sum := md5.Sum(data[off:end])
+		sums.Write(sum[:])
+		parts++
+	}
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(sums.Sum(nil)), parts)
+}
+
+func TestParallelDownload(t *testing.T) {
+	data := make([]byte, 2500)
+	rand.New(rand.NewSource(1)).Read(data)
+	handler := &rangeObjectHandler{data: data, partSize: 1000, etag: multipartETag(data, 1000)}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conf := new(Config)
+	conf.HostURL = server.URL + "/bucket/object"
+	conf.AccessKey = "WLGDGYAQYIGI833EV05A"
+	conf.SecretKey = "BYvgJM101sHngl2uzjXS/OBF/aMxAN06JrJ3qJlF"
+	conf.Signature = "S3v4"
+	s3c, err := S3New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, e := ioutil.TempDir("", "mc-parallel-download")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	partPath := filepath.Join(dir, "object"+partSuffix)
+	statePath := filepath.Join(dir, "object"+downloadStateSuffix)
+
+	// Simulate a previous interrupted run which completed the first part.
+	state := newDownloadState(int64(len(data)), 700, handler.etag, "")
+	state.Done[0] = true
+	partFile, e := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0o666)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e = partFile.Truncate(int64(len(data))); e != nil {
+		t.Fatal(e)
+	}
+	if _, e = partFile.WriteAt(data[:700], 0); e != nil {
+		t.Fatal(e)
+	}
+
+	if err = downloadParts(context.Background(), s3c, partFile, state, statePath, GetOptions{}, nil, 3); err != nil {
+		t.Fatal(err)
+	}
+	partFile.Close()
+
+	if len(handler.ranges) != 3 {
+		t.Fatalf("expected 3 ranged GETs, got %v", handler.ranges)
+	}
+	for _, r := range handler.ranges {
+		if r == "bytes=0-699" {
+			t.Fatalf("completed part was downloaded again")
+		}
+	}
+	got, e := ioutil.ReadFile(partPath)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("downloaded content differs from source")
+	}
+	saved, e := loadDownloadState(statePath)
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i, done := range saved.Done {
+		if !done {
+			t.Fatalf("part %d not recorded as done", i)
+		}
+	}
+
+	st := &ClientContent{Size: int64(len(data)), ETag: handler.etag}
+	if err = verifyDownloadETag(context.Background(), s3c, partPath, st); err != nil {
+		t.Fatal(err)
+	}
+	st.ETag = multipartETag(data[:2499], 1000)
+	if err = verifyDownloadETag(context.Background(), s3c, partPath, st); err == nil {
+		t.Fatal("expected checksum mismatch")
+	}
+}
@@ -18,23 +18,40 @@
 package cmd
 
 import (
-	"io/ioutil"
+	"context"
+	"errors"
+	"net"
+	"net/http"
 	"runtime"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
-	mem "github.com/shirou/gopsutil/v3/mem"
 )
 
 const (
 	// Maximum number of parallel workers
 	maxParallelWorkers = 128
 
-	// Monitor tick to decide to add new workers
+	// Monitor tick to decide to add or remove workers
 	monitorPeriod = 4 * time.Second
+
+	// Average object size from which a workload is considered
+	// bandwidth bound rather than bound by operations per second.
+	largeObjectThreshold = 16 << 20
+
+	// Number of ticks without improvement after which the
+	// number of workers is no longer increased.
+	maxStallTicks = 3
+
+	// Number of ticks after a stall before workers are added
+	// again, to find out whether conditions improved.
+	reprobeTicks = 15
 )
 
 // Number of workers added per bandwidth monitoring.
@@ -49,6 +66,10 @@ type task struct {
 	barrier bool
 	// The total size of the information that we need to upload
 	uploadSize int64
+	// The size of the upload buffered in memory
+	bufferSize int64
+	// Memory reserved for the upload
+	mem *memoryReservation
 }
 
 // ParallelManager - helps manage parallel workers to run tasks
@@ -59,6 +80,10 @@ type ParallelManager struct {
 	// aligned at 64bit. See https://github.com/golang/go/issues/599
 	sentBytes int64
 
+	// Counters of finished tasks observed by the controller,
+	// kept here for the same alignment reason.
+	doneOps, doneBytes, throttledOps, latencyNanos int64
+
 	// Synchronize workers
 	wg          *sync.WaitGroup
 	barrierSync sync.RWMutex
@@ -66,6 +91,16 @@ type ParallelManager struct {
 	// Current threads number
 	workersNum uint32
 
+	// Number of workers wanted by the controller, workers exit
+	// after their current task when there are more than this.
+	targetWorkers uint32
+
+	// Worker bounds
+	minWorkers, maxWorkers uint32
+
+	// Called with the new number of workers whenever it changes
+	resizeHook atomic.Value
+
 	// Channel to receive tasks to run
 	queueCh chan task
 
@@ -73,14 +108,15 @@ type ParallelManager struct {
 	resultCh chan URLs
 
 	stopMonitorCh chan struct{}
+	monitorDoneCh chan struct{}
 
-	// The maximum memory to use
-	maxMem uint64
+	// Budget of memory used by uploads
+	memBudget *memoryBudget
 }
 
 // addWorker creates a new worker to process tasks
 func (p *ParallelManager) addWorker() {
-	if atomic.LoadUint32(&p.workersNum) >= maxParallelWorkers {
+	if atomic.LoadUint32(&p.workersNum) >= p.maxWorkers {
 		// Number of maximum workers is reached, no need to
 		// to create a new one.
 		return
@@ -92,17 +128,26 @@ func (p *ParallelManager) addWorker() {
 	// Start a new worker
 	p.wg.Add(1)
 	go func() {
+		defer p.wg.Done()
 		for {
+			if p.retireWorker() {
+				return
+			}
+
 			// Wait for jobs
 			t, ok := <-p.queueCh
 			if !ok {
 				// No more tasks, quit
-				p.wg.Done()
+				atomic.AddUint32(&p.workersNum, ^uint32(0))
 				return
 			}
 
 			// Execute the task and send the result to channel.
-			p.resultCh <- t.fn()
+			start := time.Now()
+			urls := t.fn()
+			t.mem.Release()
+			p.observe(urls, t.uploadSize, time.Since(start))
+			p.resultCh <- urls
 
 			if t.barrier {
 				p.barrierSync.Unlock()
@@ -113,50 +158,190 @@ func (p *ParallelManager) addWorker() {
 	}()
 }
 
+// retireWorker returns true if the calling worker should exit
+// because there are more workers than the controller wants.
+func (p *ParallelManager) retireWorker() bool {
+	for {
+		n := atomic.LoadUint32(&p.workersNum)
+		if n <= atomic.LoadUint32(&p.targetWorkers) {
+			return false
+		}
+		if atomic.CompareAndSwapUint32(&p.workersNum, n, n-1) {
+			return true
+		}
+	}
+}
+
+// resize sets the wanted number of workers within the configured
+// bounds, starting new workers right away; surplus workers exit
+// once they finish their current task.
+func (p *ParallelManager) resize(n uint32) {
+	if n < p.minWorkers {
+		n = p.minWorkers
+	}
+	if n > p.maxWorkers {
+		n = p.maxWorkers
+	}
+	if atomic.SwapUint32(&p.targetWorkers, n) == n {
+		return
+	}
+	for atomic.LoadUint32(&p.workersNum) < n {
+		p.addWorker()
+	}
+	if hook, ok := p.resizeHook.Load().(func(int)); ok {
+		hook(int(n))
+	}
+}
+
+// workers returns the number of workers wanted by the controller.
+func (p *ParallelManager) workers() int {
+	return int(atomic.LoadUint32(&p.targetWorkers))
+}
+
+// setResizeHook registers a function called every time the number
+// of workers changes, e.g. to update the progress bar.
+func (p *ParallelManager) setResizeHook(hook func(workers int)) {
+	p.resizeHook.Store(hook)
+	hook(p.workers())
+}
+
+// observe accounts a finished task for the concurrency controller.
+func (p *ParallelManager) observe(urls URLs, size int64, latency time.Duration) {
+	atomic.AddInt64(&p.doneOps, 1)
+	atomic.AddInt64(&p.latencyNanos, int64(latency))
+	if urls.Error == nil {
+		atomic.AddInt64(&p.doneBytes, size)
+		return
+	}
+	if isThrottled(urls.Error) {
+		atomic.AddInt64(&p.throttledOps, 1)
+	}
+}
+
+// isThrottled returns true for errors which indicate that the
+// remote end is overloaded: SlowDown, 503 responses and timeouts.
+func isThrottled(err *probe.Error) bool {
+	e := err.ToGoError()
+	switch minio.ToErrorResponse(e).Code {
+	case "SlowDown", "SlowDownRead", "SlowDownWrite", "ServiceUnavailable", "RequestTimeout", "XMinioServerNotInitialized":
+		return true
+	}
+	if minio.ToErrorResponse(e).StatusCode == http.StatusServiceUnavailable {
+		return true
+	}
+	if errors.Is(e, context.DeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(e, &ne) && ne.Timeout()
+}
+
 func (p *ParallelManager) Read(b []byte) (n int, err error) {
 	atomic.AddInt64(&p.sentBytes, int64(len(b)))
 	return len(b), nil
 }
 
-// monitorProgress monitors realtime transfer speed of data
-// and increases threads until it reaches a maximum number of
-// threads or notice there is no apparent enhancement of
-// transfer speed.
+// concurrencyController adjusts the number of workers with an
+// additive-increase/multiplicative-decrease policy. Workers are
+// added while the throughput improves, halved when the remote end
+// throttles or times out and reduced when the latency of small
+// object operations rises well above its observed minimum.
+type concurrencyController struct {
+	prevOps, prevBytes, prevSent, prevThrottled, prevLatency int64
+
+	// best throughput and minimum latency observed since the last decrease
+	bestThroughput float64
+	minLatency     time.Duration
+
+	stall int
+}
+
+// next returns the wanted number of workers given the counters
+// of the manager over the last period.
+func (cc *concurrencyController) next(p *ParallelManager, current uint32) uint32 {
+	doneOps := atomic.LoadInt64(&p.doneOps)
+	doneBytes := atomic.LoadInt64(&p.doneBytes)
+	sentBytes := atomic.LoadInt64(&p.sentBytes)
+	throttled := atomic.LoadInt64(&p.throttledOps)
+	latency := atomic.LoadInt64(&p.latencyNanos)
+
+	ops := doneOps - cc.prevOps
+	bytes := doneBytes - cc.prevBytes
+	sent := sentBytes - cc.prevSent
+	throttledOps := throttled - cc.prevThrottled
+	latencyNanos := latency - cc.prevLatency
+	cc.prevOps, cc.prevBytes, cc.prevSent, cc.prevThrottled, cc.prevLatency = doneOps, doneBytes, sentBytes, throttled, latency
+
+	// Prefer bytes reported while uploading, fall back
+	// to the size of completed tasks otherwise.
+	if sent > 0 {
+		bytes = sent
+	}
+	if ops == 0 && bytes == 0 {
+		// Nothing happened, nothing to learn.
+		return current
+	}
+
+	decrease := func(n uint32) uint32 {
+		cc.bestThroughput, cc.minLatency, cc.stall = 0, 0, 0
+		return n
+	}
+
+	if throttledOps > 0 {
+		return decrease(current / 2)
+	}
+
+	var throughput float64
+	bandwidthBound := ops == 0 || bytes/ops >= largeObjectThreshold
+	if bandwidthBound {
+		throughput = float64(bytes)
+	} else {
+		throughput = float64(ops)
+		avgLatency := time.Duration(latencyNanos / ops)
+		if cc.minLatency == 0 || avgLatency < cc.minLatency {
+			cc.minLatency = avgLatency
+		} else if avgLatency > 2*cc.minLatency {
+			// Always remove at least one worker, the manager
+			// keeps the configured minimum.
+			return decrease(current * 3 / 4)
+		}
+	}
+
+	if throughput > cc.bestThroughput*1.05 {
+		cc.bestThroughput = throughput
+		cc.stall = 0
+	} else {
+		cc.stall++
+	}
+	// Keep adding workers until it is clear that it does
+	// not improve the throughput anymore, probe again
+	// once in a while in case conditions improved.
+	if cc.stall >= maxStallTicks+reprobeTicks {
+		cc.stall = 0
+	}
+	if cc.stall >= maxStallTicks {
+		return current
+	}
+	return current + uint32(defaultWorkerFactor)
+}
+
+// monitorProgress periodically adjusts the number of workers
+// between the configured bounds based on throughput, errors
+// and latency of the finished tasks.
 func (p *ParallelManager) monitorProgress() {
 	go func() {
+		defer close(p.monitorDoneCh)
 		ticker := time.NewTicker(monitorPeriod)
 		defer ticker.Stop()
 
-		var prevSentBytes, maxBandwidth int64
-		var retry int
-
+		cc := &concurrencyController{}
 		for {
 			select {
 			case <-p.stopMonitorCh:
 				// Ordered to quit immediately
 				return
 			case <-ticker.C:
-				// Compute new bandwidth from counted sent bytes
-				sentBytes := atomic.LoadInt64(&p.sentBytes)
-				bandwidth := sentBytes - prevSentBytes
-				prevSentBytes = sentBytes
-
-				if bandwidth <= maxBandwidth {
-					retry++
-					// We still want to add more workers
-					// until we are sure that it is not
-					// useful to add more of them.
-					if retry > 2 {
-						return
-					}
-				} else {
-					retry = 0
-					maxBandwidth = bandwidth
-				}
-
-				for i := 0; i < defaultWorkerFactor; i++ {
-					p.addWorker()
-				}
+				p.resize(cc.next(p, atomic.LoadUint32(&p.targetWorkers)))
 			}
 		}
 	}()
@@ -167,6 +352,11 @@ func (p *ParallelManager) queueTask(fn func() URLs, uploadSize int64) {
 	p.doQueueTask(task{fn: fn, uploadSize: uploadSize})
 }
 
+// Queue the copy of urls in parallel, reserving the memory its upload buffers.
+func (p *ParallelManager) queueCopyTask(fn func() URLs, urls URLs) {
+	p.doQueueTask(task{fn: fn, uploadSize: urls.SourceContent.Size, bufferSize: copyBufferSize(urls)})
+}
+
 // Queue task but ensures that no tasks is running at parallel,
 // which also means wait until all concurrent tasks finish before
 // queueing this and execute it solely.
@@ -174,38 +364,11 @@ func (p *ParallelManager) queueTaskWithBarrier(fn func() URLs, uploadSize int64)
 	p.doQueueTask(task{fn: fn, barrier: true, uploadSize: uploadSize})
 }
 
-func (p *ParallelManager) enoughMemForUpload(uploadSize int64) bool {
-	if uploadSize < 0 {
-		panic("unexpected size")
-	}
-
-	if uploadSize == 0 || p.maxMem == 0 {
-		return true
-	}
-
-	estimateNeededMemoryForUpload := func(size int64) uint64 {
-		partsCount, partSize, _, e := minio.OptimalPartInfo(size, 0)
-		if e != nil {
-			panic(e)
-		}
-		if partsCount >= 4 {
-			return 4 * uint64(partSize)
-		}
-		return uint64(size)
-	}
-
-	smem := runtime.MemStats{}
-	runtime.GC()
-	runtime.ReadMemStats(&smem)
-
-	return estimateNeededMemoryForUpload(uploadSize)+smem.Alloc < p.maxMem
-}
-
 func (p *ParallelManager) doQueueTask(t task) {
-	// Check if we have enough memory to perform next task,
-	// if not, wait to finish all currents tasks to continue
-	if !p.enoughMemForUpload(t.uploadSize) {
-		t.barrier = true
+	// Reserve the memory needed by the upload, this waits
+	// for other tasks to release theirs if over budget.
+	if t.bufferSize > 0 {
+		t.mem = p.memBudget.Reserve(uploadMemorySize(t.bufferSize))
 	}
 	if t.barrier {
 		p.barrierSync.Lock()
@@ -217,65 +380,70 @@ func (p *ParallelManager) doQueueTask(t task) {
 
 // Wait for all workers to finish tasks before shutting down Parallel
 func (p *ParallelManager) stopAndWait() {
+	// Stop the monitor first so no worker is added while waiting.
+	close(p.stopMonitorCh)
+	<-p.monitorDoneCh
 	close(p.queueCh)
 	p.wg.Wait()
-	close(p.stopMonitorCh)
 }
 
-const cgroupLimitFile = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+// parallelOptions - bounds of the number of workers and of the
+// memory used by uploads of a ParallelManager.
+type parallelOptions struct {
+	minWorkers, maxWorkers int
+	maxMemory              uint64
+}
 
-func cgroupLimit(limitFile string) (limit uint64) {
-	buf, err := ioutil.ReadFile(limitFile)
-	if err != nil {
-		return 9223372036854771712
+// parseParallelOptions reads --min-workers, --max-workers and --max-memory.
+func parseParallelOptions(ctx *cli.Context) parallelOptions {
+	opts := parallelOptions{
+		minWorkers: ctx.Int("min-workers"),
+		maxWorkers: ctx.Int("max-workers"),
 	}
-	limit, err = strconv.ParseUint(string(buf), 10, 64)
-	if err != nil {
-		return 9223372036854771712
+	if v := ctx.String("max-memory"); v != "" {
+		maxMemory, e := humanize.ParseBytes(v)
+		fatalIf(probe.NewError(e).Trace(v), "Unable to parse --max-memory.")
+		if maxMemory == 0 {
+			fatalIf(errInvalidArgument().Trace(v), "--max-memory cannot be zero.")
+		}
+		opts.maxMemory = maxMemory
 	}
-	return limit
-}
-
-func availableMemory() (available uint64) {
-	available = 8 << 30 // Default to 8 GiB when we can't find the limits.
-
-	if runtime.GOOS == "linux" {
-		available = cgroupLimit(cgroupLimitFile)
-
-		// No limit set, It's the highest positive signed 64-bit
-		// integer (2^63-1), rounded down to multiples of 4096 (2^12),
-		// the most common page size on x86 systems - for cgroup_limits.
-		if available != 9223372036854771712 {
-			// This means cgroup memory limit is configured.
-			return
-		} // no-limit set proceed to set the limits based on virtual memory.
-
-	} // for all other platforms limits are based on virtual memory.
-
-	memStats, err := mem.VirtualMemory()
-	if err != nil {
-		return
+	if opts.minWorkers < 0 || opts.maxWorkers < 0 {
+		fatalIf(errInvalidArgument().Trace(strconv.Itoa(opts.minWorkers), strconv.Itoa(opts.maxWorkers)),
+			"Number of workers cannot be negative.")
 	}
-
-	available = memStats.Available / 2
-	return
+	if opts.maxWorkers != 0 && opts.minWorkers > opts.maxWorkers {
+		fatalIf(errInvalidArgument().Trace(strconv.Itoa(opts.minWorkers), strconv.Itoa(opts.maxWorkers)),
+			"--min-workers cannot be greater than --max-workers.")
+	}
+	return opts
 }
 
 // newParallelManager starts new workers waiting for executing tasks
-func newParallelManager(resultCh chan URLs) *ParallelManager {
+func newParallelManager(resultCh chan URLs, opts parallelOptions) *ParallelManager {
 	p := &ParallelManager{
 		wg:            &sync.WaitGroup{},
 		workersNum:    0,
+		minWorkers:    1,
+		maxWorkers:    maxParallelWorkers,
 		stopMonitorCh: make(chan struct{}),
+		monitorDoneCh: make(chan struct{}),
 		queueCh:       make(chan task),
 		resultCh:      resultCh,
-		maxMem:        availableMemory(),
+		memBudget:     newMemoryBudget(uploadMemoryBudget(opts.maxMemory)),
+	}
+	if opts.minWorkers > 0 {
+		p.minWorkers = uint32(opts.minWorkers)
+	}
+	if opts.maxWorkers > 0 {
+		p.maxWorkers = uint32(opts.maxWorkers)
+	}
+	if p.minWorkers > p.maxWorkers {
+		p.minWorkers = p.maxWorkers
 	}
 
 	// Start with runtime.NumCPU().
-	for i := 0; i < runtime.NumCPU(); i++ {
-		p.addWorker()
-	}
+	p.resize(uint32(runtime.NumCPU()))
 
 	// Start monitoring tasks progress
 	p.monitorProgress()
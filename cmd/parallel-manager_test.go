@@ -0,0 +1,95 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestConcurrencyController(t *testing.T) {
+	p := &ParallelManager{}
+	cc := &concurrencyController{}
+	step := uint32(defaultWorkerFactor)
+
+	// Small objects with stable latency and rising op/s ramp up.
+	finish := func(ops int, latency time.Duration, err *probe.Error) {
+		for i := 0; i < ops; i++ {
+			p.observe(URLs{Error: err}, 1024, latency)
+		}
+	}
+	finish(100, 10*time.Millisecond, nil)
+	if n := cc.next(p, 8); n != 8+step {
+		t.Fatalf("expected %d workers, got %d", 8+step, n)
+	}
+
+	// Latency rising well above its minimum backs off.
+	finish(200, 50*time.Millisecond, nil)
+	if n := cc.next(p, 16); n != 12 {
+		t.Fatalf("expected 12 workers, got %d", n)
+	}
+
+	// Throttling halves the number of workers.
+	finish(100, 10*time.Millisecond, nil)
+	finish(1, 10*time.Millisecond, probe.NewError(minio.ErrorResponse{Code: "SlowDown", StatusCode: 503}))
+	if n := cc.next(p, 12); n != 6 {
+		t.Fatalf("expected 6 workers, got %d", n)
+	}
+
+	// No activity leaves the number of workers untouched.
+	if n := cc.next(p, 6); n != 6 {
+		t.Fatalf("expected 6 workers, got %d", n)
+	}
+
+	// Large objects stop ramping up once bandwidth plateaus.
+	for i := 0; i < maxStallTicks+1; i++ {
+		p.observe(URLs{}, 64<<20, time.Second)
+		cc.next(p, 6)
+	}
+	p.observe(URLs{}, 64<<20, time.Second)
+	if n := cc.next(p, 6); n != 6 {
+		t.Fatalf("expected 6 workers, got %d", n)
+	}
+
+	// Workers are added again after a while to probe for improvements.
+	probed := false
+	for i := 0; i < reprobeTicks; i++ {
+		p.observe(URLs{}, 64<<20, time.Second)
+		if cc.next(p, 6) > 6 {
+			probed = true
+			break
+		}
+	}
+	if !probed {
+		t.Fatalf("expected workers to be added again after %d ticks", reprobeTicks)
+	}
+
+	// Rising latency removes a worker even at low concurrency.
+	for _, current := range []uint32{3, 2, 1} {
+		p, cc = &ParallelManager{}, &concurrencyController{}
+		finish(100, 10*time.Millisecond, nil)
+		cc.next(p, current)
+		finish(100, 50*time.Millisecond, nil)
+		if n := cc.next(p, current); n >= current {
+			t.Fatalf("expected less than %d workers, got %d", current, n)
+		}
+	}
+}
@@ -18,6 +18,7 @@
 package cmd
 
 import (
+	"io"
 	"os"
 	"syscall"
 
@@ -42,6 +43,7 @@ var pipeFlags = []cli.Flag{
 		Name:  "tags",
 		Usage: "apply one or more tags to the uploaded objects",
 	},
+	compressFlag,
 }
 
 // Display contents of a file.
@@ -86,10 +88,13 @@ EXAMPLES:
 
   7. Set tags to the uploaded objects
       {{.Prompt}} tar cvf - . | {{.HelpName}} --tags "category=prod&type=backup" play/mybucket/backup.tar
+
+  8. Stream a database dump compressed with zstd, 'mc cat' decompresses it.
+      {{.Prompt}} mysqldump -u root -p ******* accountsdb | {{.HelpName}} --compress zstd play/sql-backups/accountsdb.sql
 `,
 }
 
-func pipe(targetURL string, encKeyDB map[string][]prefixSSEPair, storageClass string, meta map[string]string) *probe.Error {
+func pipe(targetURL string, encKeyDB map[string][]prefixSSEPair, storageClass string, meta map[string]string, codec string) *probe.Error {
 	if targetURL == "" {
 		// When no target is specified, pipe cat's stdin to stdout.
 		return catOut(os.Stdin, -1).Trace()
@@ -105,7 +110,19 @@ func pipe(targetURL string, encKeyDB map[string][]prefixSSEPair, storageClass st
 		storageClass: storageClass,
 		metadata:     meta,
 	}
-	_, err := putTargetStreamWithURL(targetURL, os.Stdin, -1, opts)
+	var reader io.Reader = os.Stdin
+	if codec != "" && alias != "" {
+		// The size of stdin is unknown, only the codec is recorded.
+		compressed, err := newCompressReader(os.Stdin, codec)
+		if err != nil {
+			return err.Trace(targetURL)
+		}
+		defer compressed.Close()
+		reader = compressed
+		setCompressMetadata(meta, codec, -1)
+		opts.multipartSize = compressPartSize(-1)
+	}
+	_, err := putTargetStreamWithURL(targetURL, reader, -1, opts)
 	// TODO: See if this check is necessary.
 	switch e := err.ToGoError().(type) {
 	case *os.PathError:
@@ -142,12 +159,14 @@ func mainPipe(ctx *cli.Context) error {
 		meta["X-Amz-Tagging"] = tags
 	}
 	if len(ctx.Args()) == 0 {
-		err = pipe("", nil, ctx.String("storage-class"), meta)
+		err = pipe("", nil, ctx.String("storage-class"), meta, "")
 		fatalIf(err.Trace("stdout"), "Unable to write to one or more targets.")
 	} else {
 		// extract URLs.
 		URLs := ctx.Args()
-		err = pipe(URLs[0], encKeyDB, ctx.String("storage-class"), meta)
+		codec := ctx.String("compress")
+		fatalIf(checkCompressTarget(codec, URLs[0]), "Unable to compress the stream written to `"+URLs[0]+"`.")
+		err = pipe(URLs[0], encKeyDB, ctx.String("storage-class"), meta, codec)
 		fatalIf(err.Trace(URLs[0]), "Unable to write to one or more targets.")
 	}
 
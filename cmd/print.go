@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"strings"
 
+	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/console"
 )
 
@@ -33,6 +34,10 @@ type message interface {
 
 // printMsg prints message string or JSON structure depending on the type of output console.
 func printMsg(msg message) {
+	if globalFormat != nil {
+		globalFormat.print(msg)
+		return
+	}
 	var msgStr string
 	if !globalJSON {
 		msgStr = msg.String()
@@ -49,3 +54,21 @@ func printMsg(msg message) {
 	msgStr = strings.TrimSuffix(msgStr, "\n")
 	console.Println(msgStr)
 }
+
+// jsonMessage prints a value in its JSON form, for the commands which
+// only have a JSON output, e.g. the responses of the server as they are.
+type jsonMessage struct {
+	v interface{}
+}
+
+// String the JSON form of the value.
+func (m jsonMessage) String() string {
+	return m.JSON()
+}
+
+// JSON jsonified value.
+func (m jsonMessage) JSON() string {
+	jsonBytes, e := json.MarshalIndent(m.v, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonBytes)
+}
@@ -18,6 +18,7 @@
 package cmd
 
 import (
+	"fmt"
 	"io"
 	"runtime"
 	"strings"
@@ -156,6 +157,11 @@ func (p *progressBar) Read(buf []byte) (n int, err error) {
 	return p.ProgressBar.Read(buf)
 }
 
+// SetWorkers shows the number of concurrent workers after the bar.
+func (p *progressBar) SetWorkers(workers int) {
+	p.ProgressBar.Postfix(fmt.Sprintf(" %d workers", workers))
+}
+
 func (p *progressBar) SetTotal(total int64) {
 	p.ProgressBar.Total = total
 }
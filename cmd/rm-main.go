@@ -111,7 +111,7 @@ var rmCmd = cli.Command{
 	Action:       mainRm,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(rmFlags, ioFlags...), globalFlags...),
+	Flags:        append(append(append(append(rmFlags, trashFlags...), deleteGuardFlags...), ioFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -121,6 +121,20 @@ USAGE:
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
+TRASH:
+  --trash copies each object server-side to BUCKET/.trash/STAMP/BUCKET/KEY before removing it, with its
+  original path, who removed it and when in its metadata. --trash-bucket uses the trash of another
+  bucket for the objects of all buckets. Objects already in a trash are refused, 'mc trash' lists,
+  restores and empties the trash.
+
+DELETION SAFEGUARDS:
+  --max-delete lists the objects first and aborts without removing anything if more
+  than N objects, or more than P% of the objects found, would be removed; the objects
+  that would have been removed are reported. P% is relative to all the objects listed,
+  including those skipped by --older-than and --newer-than. Objects under the prefixes protected with
+  'mc alias protect' are never removed. --backup-dir copies each object server-side,
+  under a directory named after the start time of the run, before removing it.
+
 ENVIRONMENT VARIABLES:
   MC_ENCRYPT_KEY: list of comma delimited prefix=secret values
 
@@ -167,6 +181,15 @@ EXAMPLES:
   14. Perform a fake removal of object(s) versions that are non-current and older than 10 days. If top-level version is a delete 
   marker, this will also be deleted when --non-current flag is specified.
       {{.Prompt}} {{.HelpName}} s3/docs/ --recursive --force --versions --non-current --older-than 10d --dry-run
+
+  15. Remove the objects older than 30 days, unless that's more than a hundred objects.
+      {{.Prompt}} {{.HelpName}} --recursive --force --older-than 30d --max-delete 100 s3/logs/
+
+  16. Remove a prefix, keeping a copy of its objects under 's3/trash/'.
+      {{.Prompt}} {{.HelpName}} --recursive --force --backup-dir s3/trash/ s3/jazz-songs/louis/
+
+  17. Move a prefix to the trash of its bucket, the trash being emptied after 30 days.
+      {{.Prompt}} {{.HelpName}} --recursive --force --trash --trash-expiry-days 30 s3/jazz-songs/louis/
 `,
 }
 
@@ -289,11 +312,18 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 		modTime time.Time
 	)
 
+	targetAlias, targetURL, _ := mustExpandAlias(url)
+
 	_, content, pErr := url2Stat(ctx, url, versionID, false, opts.encKeyDB, time.Time{}, false)
 	if pErr != nil {
-		switch minio.ToErrorResponse(pErr.ToGoError()).StatusCode {
+		switch statusCode := minio.ToErrorResponse(pErr.ToGoError()).StatusCode; statusCode {
 		case http.StatusBadRequest, http.StatusMethodNotAllowed:
 			ignoreStatError = true
+			content = &ClientContent{
+				URL:            *newClientURL(targetURL),
+				VersionID:      versionID,
+				IsDeleteMarker: statusCode == http.StatusMethodNotAllowed,
+			}
 		default:
 			errorIf(pErr.Trace(url), "Failed to remove `"+url+"`.")
 			return exitStatus(globalErrorExitStatus)
@@ -301,6 +331,9 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 	} else {
 		isDir = content.Type.IsDir()
 		modTime = content.Time
+		if !isDir {
+			opts.candidates.count()
+		}
 	}
 
 	// We should not proceed
@@ -319,14 +352,41 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 		return nil
 	}
 
+	if opts.guard.isProtected(targetAlias, content.URL.Path) {
+		if opts.candidates == nil {
+			errorIf(errDeleteProtected(url).Trace(url), "Refused to remove `"+url+"`.")
+		}
+		return exitStatus(globalErrorExitStatus)
+	}
+
+	if pErr = opts.trash.checkRemove(targetAlias, content); pErr != nil {
+		if opts.candidates == nil {
+			errorIf(pErr.Trace(url), "Refused to remove `"+url+"`.")
+		}
+		return exitStatus(globalErrorExitStatus)
+	}
+
+	if !opts.approved.contains(targetAlias, content) {
+		return nil
+	}
+
 	if !opts.isFake {
-		targetAlias, targetURL, _ := mustExpandAlias(url)
 		clnt, pErr := newClientFromAlias(targetAlias, targetURL)
 		if pErr != nil {
 			errorIf(pErr.Trace(url), "Invalid argument `"+url+"`.")
 			return exitStatus(globalErrorExitStatus) // End of journey.
 		}
 
+		if pErr = opts.guard.backup(ctx, targetAlias, content, opts.encKeyDB); pErr != nil {
+			errorIf(pErr.Trace(url), "Unable to back up `"+url+"`, it was not removed.")
+			return exitStatus(globalErrorExitStatus)
+		}
+
+		if pErr = opts.trash.move(ctx, targetAlias, content, opts.encKeyDB); pErr != nil {
+			errorIf(pErr.Trace(url), "Unable to move `"+url+"` to the trash, it was not removed.")
+			return exitStatus(globalErrorExitStatus)
+		}
+
 		if !strings.HasSuffix(targetURL, string(clnt.GetURL().Separator)) && isDir {
 			targetURL = targetURL + string(clnt.GetURL().Separator)
 		}
@@ -358,7 +418,7 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 			printMsg(msg)
 		}
 	} else {
-		printDryRunMsg(content)
+		opts.dryRun(targetAlias, content)
 	}
 	return nil
 }
@@ -376,6 +436,59 @@ type removeOpts struct {
 	olderThan         string
 	newerThan         string
 	encKeyDB          map[string][]prefixSSEPair
+
+	// guard applies the deletion safeguards.
+	guard *deleteGuard
+	// trash moves objects to the trash before removing them.
+	trash *trashBin
+	// candidates collects the objects a fake remove operation would
+	// remove instead of printing them.
+	candidates *deleteCandidates
+	// approved restricts a remove operation to the objects collected
+	// by the dry run of --max-delete.
+	approved *deleteCandidates
+}
+
+// dryRun reports an object a fake remove operation would remove.
+func (opts removeOpts) dryRun(alias string, content *ClientContent) {
+	if opts.candidates != nil {
+		opts.candidates.add(alias, content)
+		return
+	}
+	printDryRunMsg(content)
+}
+
+// guardRemove applies the protected prefixes and --backup-dir to an
+// object about to be removed, it returns false if the object is kept.
+func (opts removeOpts) guardRemove(ctx context.Context, alias string, content *ClientContent) bool {
+	key := path.Join(alias, content.URL.Path)
+	if opts.guard.isProtected(alias, content.URL.Path) {
+		if opts.candidates == nil {
+			errorIf(errDeleteProtected(key).Trace(key), "Refused to remove `"+key+"`.")
+		}
+		return false
+	}
+	if err := opts.trash.checkRemove(alias, content); err != nil {
+		if opts.candidates == nil {
+			errorIf(err.Trace(key), "Refused to remove `"+key+"`.")
+		}
+		return false
+	}
+	if !opts.approved.contains(alias, content) {
+		return false
+	}
+	if opts.isFake {
+		return true
+	}
+	if err := opts.guard.backup(ctx, alias, content, opts.encKeyDB); err != nil {
+		errorIf(err.Trace(key), "Unable to back up `"+key+"`, it was not removed.")
+		return false
+	}
+	if err := opts.trash.move(ctx, alias, content, opts.encKeyDB); err != nil {
+		errorIf(err.Trace(key), "Unable to move `"+key+"` to the trash, it was not removed.")
+		return false
+	}
+	return true
 }
 
 func printDryRunMsg(content *ClientContent) {
@@ -413,6 +526,7 @@ func listAndRemove(url string, opts removeOpts) error {
 		listOpts.TimeRef = opts.timeRef
 	}
 	atLeastOneObjectFound := false
+	isRefused := false
 
 	resultCh := clnt.Remove(ctx, opts.isIncomplete, isRemoveBucket, opts.isBypass, false, contentCh)
 
@@ -437,6 +551,12 @@ func listAndRemove(url string, opts removeOpts) error {
 			continue
 		}
 
+		// Objects moved to the trash by this run may be listed again,
+		// the other objects of a trash are refused by guardRemove.
+		if opts.trash.movedByRun(content) {
+			continue
+		}
+
 		if !opts.isRecursive {
 			currentObjectURL := targetAlias + getKey(content)
 			standardizedURL := getStandardizedURL(currentObjectURL)
@@ -467,8 +587,13 @@ func listAndRemove(url string, opts removeOpts) error {
 						continue
 					}
 
+					if !opts.guardRemove(ctx, targetAlias, content) {
+						isRefused = true
+						continue
+					}
+
 					if opts.isFake {
-						printDryRunMsg(content)
+						opts.dryRun(targetAlias, content)
 						continue
 					}
 
@@ -505,6 +630,9 @@ func listAndRemove(url string, opts removeOpts) error {
 				perObjectVersions = []*ClientContent{}
 			}
 			atLeastOneObjectFound = true
+			if !content.Time.IsZero() {
+				opts.candidates.count()
+			}
 			perObjectVersions = append(perObjectVersions, content)
 			continue
 		}
@@ -514,21 +642,30 @@ func listAndRemove(url string, opts removeOpts) error {
 		// inform the user that he was searching in an empty area
 		atLeastOneObjectFound = true
 
-		if !content.Time.IsZero() {
-			// Skip objects older than --older-than parameter, if specified
-			if opts.olderThan != "" && isOlder(content.Time, opts.olderThan) {
-				continue
-			}
-
-			// Skip objects newer than --newer-than parameter if specified
-			if opts.newerThan != "" && isNewer(content.Time, opts.newerThan) {
-				continue
-			}
-		} else {
+		if content.Time.IsZero() {
 			// Skip prefix levels.
 			continue
 		}
 
+		// Every listed object counts in the total of --max-delete,
+		// including those filtered out by their age below.
+		opts.candidates.count()
+
+		// Skip objects older than --older-than parameter, if specified
+		if opts.olderThan != "" && isOlder(content.Time, opts.olderThan) {
+			continue
+		}
+
+		// Skip objects newer than --newer-than parameter if specified
+		if opts.newerThan != "" && isNewer(content.Time, opts.newerThan) {
+			continue
+		}
+
+		if !opts.guardRemove(ctx, targetAlias, content) {
+			isRefused = true
+			continue
+		}
+
 		if !opts.isFake {
 			sent := false
 			for !sent {
@@ -564,7 +701,7 @@ func listAndRemove(url string, opts removeOpts) error {
 				}
 			}
 		} else {
-			printDryRunMsg(content)
+			opts.dryRun(targetAlias, content)
 		}
 	}
 
@@ -588,8 +725,13 @@ func listAndRemove(url string, opts removeOpts) error {
 				continue
 			}
 
+			if !opts.guardRemove(ctx, targetAlias, content) {
+				isRefused = true
+				continue
+			}
+
 			if opts.isFake {
-				printDryRunMsg(content)
+				opts.dryRun(targetAlias, content)
 				continue
 			}
 
@@ -627,6 +769,9 @@ func listAndRemove(url string, opts removeOpts) error {
 
 	close(contentCh)
 	if opts.isFake {
+		if isRefused {
+			return exitStatus(globalErrorExitStatus)
+		}
 		return nil
 	}
 	for result := range resultCh {
@@ -661,6 +806,9 @@ func listAndRemove(url string, opts removeOpts) error {
 		return exitStatus(globalErrorExitStatus)
 	}
 
+	if isRefused {
+		return exitStatus(globalErrorExitStatus)
+	}
 	return nil
 }
 
@@ -676,6 +824,12 @@ func mainRm(cliCtx *cli.Context) error {
 	// check 'rm' cli arguments.
 	checkRmSyntax(ctx, cliCtx, encKeyDB)
 
+	guard, err := newDeleteGuard(cliCtx)
+	fatalIf(err, "Unable to parse --max-delete.")
+
+	trash, err := newTrashBin(cliCtx)
+	fatalIf(err, "Unable to parse the --trash flags.")
+
 	// rm specific flags.
 	isIncomplete := cliCtx.Bool("incomplete")
 	isRecursive := cliCtx.Bool("recursive")
@@ -697,76 +851,80 @@ func mainRm(cliCtx *cli.Context) error {
 
 	// Set color.
 	console.SetColor("Removed", color.New(color.FgGreen, color.Bold))
+	console.SetColor("DeleteAborted", color.New(color.FgYellow, color.Bold))
+
+	opts := removeOpts{
+		timeRef:           rewind,
+		withVersions:      withVersions,
+		nonCurrentVersion: withNoncurrentVersion,
+		isForce:           isForce,
+		isRecursive:       isRecursive,
+		isIncomplete:      isIncomplete,
+		isFake:            isFake,
+		isBypass:          isBypass,
+		isForceDel:        isForceDel,
+		olderThan:         olderThan,
+		newerThan:         newerThan,
+		encKeyDB:          encKeyDB,
+		guard:             guard,
+		trash:             trash,
+	}
+	removeURL := func(url string, opts removeOpts) error {
+		if opts.candidates == nil {
+			if err := guard.checkBackupDir(url); err != nil {
+				errorIf(err.Trace(url), "Unable to remove `"+url+"`.")
+				return exitStatus(globalErrorExitStatus)
+			}
+			if err := trash.check(url); err != nil {
+				errorIf(err.Trace(url), "Unable to move `"+url+"` to the trash.")
+				return exitStatus(globalErrorExitStatus)
+			}
+		}
+		if isRecursive || withVersions {
+			return listAndRemove(url, opts)
+		}
+		return removeSingle(url, versionID, opts)
+	}
+
+	urls := []string(cliCtx.Args())
+	var scanner *bufio.Scanner
+	if isStdin {
+		scanner = bufio.NewScanner(os.Stdin)
+	}
+
+	// With --max-delete, find out what would be removed before removing anything.
+	if guard.hasLimit() {
+		for scanner != nil && scanner.Scan() {
+			urls = append(urls, scanner.Text())
+		}
+		scanner = nil
+
+		candidates := &deleteCandidates{}
+		dryRunOpts := opts
+		dryRunOpts.isFake = true
+		dryRunOpts.candidates = candidates
+		for _, url := range urls {
+			removeURL(url, dryRunOpts)
+		}
+		if guard.exceeded(int64(len(candidates.items)), candidates.total) {
+			fatalIf(candidates.abort(guard), "Aborted without removing anything.")
+		}
+		// Objects created since the dry run were not checked against
+		// the limit, only remove those found by the dry run.
+		opts.approved = candidates
+	}
 
 	var rerr error
-	var e error
 	// Support multiple targets.
-	for _, url := range cliCtx.Args() {
-		if isRecursive || withVersions {
-			e = listAndRemove(url, removeOpts{
-				timeRef:           rewind,
-				withVersions:      withVersions,
-				nonCurrentVersion: withNoncurrentVersion,
-				isForce:           isForce,
-				isRecursive:       isRecursive,
-				isIncomplete:      isIncomplete,
-				isFake:            isFake,
-				isBypass:          isBypass,
-				olderThan:         olderThan,
-				newerThan:         newerThan,
-				encKeyDB:          encKeyDB,
-			})
-		} else {
-			e = removeSingle(url, versionID, removeOpts{
-				isIncomplete: isIncomplete,
-				isFake:       isFake,
-				isForce:      isForce,
-				isForceDel:   isForceDel,
-				isBypass:     isBypass,
-				olderThan:    olderThan,
-				newerThan:    newerThan,
-				encKeyDB:     encKeyDB,
-			})
-		}
-		if rerr == nil {
+	for _, url := range urls {
+		if e := removeURL(url, opts); rerr == nil {
 			rerr = e
 		}
 	}
 
-	if !isStdin {
-		return rerr
-	}
-
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
+	for scanner != nil && scanner.Scan() {
 		url := scanner.Text()
-		if isRecursive || withVersions {
-			e = listAndRemove(url, removeOpts{
-				timeRef:           rewind,
-				withVersions:      withVersions,
-				nonCurrentVersion: withNoncurrentVersion,
-				isForce:           isForce,
-				isRecursive:       isRecursive,
-				isIncomplete:      isIncomplete,
-				isFake:            isFake,
-				isBypass:          isBypass,
-				olderThan:         olderThan,
-				newerThan:         newerThan,
-				encKeyDB:          encKeyDB,
-			})
-		} else {
-			e = removeSingle(url, versionID, removeOpts{
-				isIncomplete: isIncomplete,
-				isFake:       isFake,
-				isForce:      isForce,
-				isForceDel:   isForceDel,
-				isBypass:     isBypass,
-				olderThan:    olderThan,
-				newerThan:    newerThan,
-				encKeyDB:     encKeyDB,
-			})
-		}
-		if rerr == nil {
+		if e := removeURL(url, opts); rerr == nil {
 			rerr = e
 		}
 	}
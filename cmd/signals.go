@@ -20,8 +20,21 @@ package cmd
 import (
 	"os"
 	"os/signal"
+	"sync"
 )
 
+// exitHold delays the exit on signals while commands clean up
+// after the global context is canceled.
+var exitHold sync.RWMutex
+
+// holdExit delays the exit on signals until release is called,
+// release may be called more than once.
+func holdExit() (release func()) {
+	exitHold.RLock()
+	var once sync.Once
+	return func() { once.Do(exitHold.RUnlock) }
+}
+
 // trapSignals traps the registered signals and cancel the global context.
 func trapSignals(sig ...os.Signal) {
 	// channel to receive signals.
@@ -45,6 +58,13 @@ func trapSignals(sig ...os.Signal) {
 	// Cancel the global context
 	globalCancel()
 
+	// Wait for the commands cleaning up, another signal exits right
+	// away as the handler is stopped.
+	exitHold.Lock()
+
+	// Print the output buffered by --format.
+	flushOutputFormat()
+
 	var exitCode int
 	switch s.String() {
 	case "interrupt":
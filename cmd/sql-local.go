@@ -0,0 +1,73 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/mc/pkg/s3select"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// selectLocal runs an S3 Select query on the client side, the object is
+// streamed with Get and evaluated by the local SQL engine. The results
+// follow the same serialization as the Select API.
+func selectLocal(ctx context.Context, clnt Client, expression string, sse encrypt.ServerSide, opts SelectObjectOpts) (io.ReadCloser, *probe.Error) {
+	query, e := s3select.Parse(expression)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+
+	object := clnt.GetURL().Path
+	in := selectObjectInputOpts(opts, object)
+	if in.CSV == nil && in.JSON == nil && in.Parquet == nil {
+		return nil, probe.NewError(errors.New("unable to detect the object format, use --csv-input or --json-input"))
+	}
+	out := selectObjectOutputOpts(opts, in)
+
+	reader, err := clnt.Get(ctx, GetOptions{SSE: sse})
+	if err != nil {
+		return nil, err.Trace(object)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		e := query.Run(ctx, pw, reader, in, out)
+		reader.Close()
+		pw.CloseWithError(e)
+	}()
+	return pr, nil
+}
+
+// isSelectNotImplemented returns whether a Select error means the
+// backend doesn't support SelectObjectContent.
+func isSelectNotImplemented(err *probe.Error) bool {
+	if _, ok := err.ToGoError().(APINotImplemented); ok {
+		return true
+	}
+	errResp := minio.ToErrorResponse(err.ToGoError())
+	switch errResp.Code {
+	case "NotImplemented", "XNotImplemented", "MethodNotAllowed":
+		return true
+	}
+	return errResp.StatusCode == http.StatusNotImplemented
+}
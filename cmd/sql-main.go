@@ -72,6 +72,10 @@ var sqlFlags = []cli.Flag{
 		Name:  "json-output",
 		Usage: "json output serialization option",
 	},
+	cli.BoolFlag{
+		Name:  "local",
+		Usage: "evaluate the query on the client, streaming the object",
+	},
 }
 
 // Display contents of a file.
@@ -123,6 +127,12 @@ EXAMPLES:
      {{.Prompt}} {{.HelpName}} --compression GZIP --csv-input "rd=\n,fh=USE,fd=;" \
            --csv-output "rd=\n" --csv-output-header "device_id,uptime,lat,lon" \
            --query "select * from S3Object" myminio/iot-devices/data.csv
+
+  7. Run a query on the client for a backend without S3 Select support, streaming the object.
+     {{.Prompt}} {{.HelpName}} --local --query "select s.name from S3Object s where s.age > 30" s3/mybucket/people.csv
+
+  8. Run a query on a local Parquet file.
+     {{.Prompt}} {{.HelpName}} --query "select count(*) from S3Object" /data/events.parquet
 `,
 }
 
@@ -382,7 +392,7 @@ func isCSVOrJSON(inOpts map[string]map[string]string) bool {
 	return false
 }
 
-func sqlSelect(targetURL, expression string, encKeyDB map[string][]prefixSSEPair, selOpts SelectObjectOpts, csvHdrs []string, writeHdr bool) *probe.Error {
+func sqlSelect(targetURL, expression string, encKeyDB map[string][]prefixSSEPair, selOpts SelectObjectOpts, csvHdrs []string, writeHdr, local bool) *probe.Error {
 	ctx, cancelSelect := context.WithCancel(globalContext)
 	defer cancelSelect()
 
@@ -397,7 +407,14 @@ func sqlSelect(targetURL, expression string, encKeyDB map[string][]prefixSSEPair
 	}
 
 	sseKey := getSSE(targetURL, encKeyDB[alias])
-	outputer, err := targetClnt.Select(ctx, expression, sseKey, selOpts)
+	var outputer io.ReadCloser
+	if !local {
+		outputer, err = targetClnt.Select(ctx, expression, sseKey, selOpts)
+	}
+	// Evaluate the query on the client for backends without Select support.
+	if local || (err != nil && isSelectNotImplemented(err)) {
+		outputer, err = selectLocal(ctx, targetClnt, expression, sseKey, selOpts)
+	}
 	if err != nil {
 		return err.Trace(targetURL, expression)
 	}
@@ -461,7 +478,7 @@ func mainSQL(cliCtx *cli.Context) error {
 			if writeHdr {
 				query, csvHdrs, selOpts = getAndValidateArgs(cliCtx, encKeyDB, url)
 			}
-			errorIf(sqlSelect(url, query, encKeyDB, selOpts, csvHdrs, writeHdr).Trace(url), "Unable to run sql")
+			errorIf(sqlSelect(url, query, encKeyDB, selOpts, csvHdrs, writeHdr, cliCtx.Bool("local")).Trace(url), "Unable to run sql")
 			writeHdr = false
 			continue
 		}
@@ -484,7 +501,7 @@ func mainSQL(cliCtx *cli.Context) error {
 			for _, cTypeSuffix := range supportedContentTypes {
 				if strings.Contains(contentType, cTypeSuffix) {
 					errorIf(sqlSelect(targetAlias+content.URL.Path, query,
-						encKeyDB, selOpts, csvHdrs, writeHdr).Trace(content.URL.String()), "Unable to run sql")
+						encKeyDB, selOpts, csvHdrs, writeHdr, cliCtx.Bool("local")).Trace(content.URL.String()), "Unable to run sql")
 				}
 				writeHdr = false
 			}
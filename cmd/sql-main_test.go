@@ -18,8 +18,16 @@
 package cmd
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
 )
 
 var testParseKVArgsCases = []struct {
@@ -147,3 +155,49 @@ func TestParseSerializationOpts(t *testing.T) {
 		}
 	}
 }
+
+func TestSelectLocal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "people.csv")
+	if e := os.WriteFile(path, []byte("name,age\nalice,31\nbob,25\n"), 0o600); e != nil {
+		t.Fatal(e)
+	}
+	clnt, err := fsNew(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := SelectObjectOpts{OutputSerOpts: map[string]map[string]string{"json": {}}}
+	reader, err := clnt.Select(context.Background(), "select s.name from S3Object s where s.age > 30", nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	out, e := ioutil.ReadAll(reader)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if string(out) != "{\"name\":\"alice\"}\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	if _, err = clnt.Select(context.Background(), "select from", nil, opts); err == nil {
+		t.Fatal("expected an error for an invalid query")
+	}
+}
+
+func TestIsSelectNotImplemented(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{APINotImplemented{API: "Select", APIType: "filesystem"}, true},
+		{minio.ErrorResponse{Code: "NotImplemented", StatusCode: http.StatusNotImplemented}, true},
+		{minio.ErrorResponse{Code: "MethodNotAllowed", StatusCode: http.StatusMethodNotAllowed}, true},
+		{minio.ErrorResponse{StatusCode: http.StatusNotImplemented}, true},
+		{minio.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}, false},
+	}
+	for i, tc := range testCases {
+		if got := isSelectNotImplemented(probe.NewError(tc.err)); got != tc.expected {
+			t.Errorf("Test %d: expected %v, got %v", i+1, tc.expected, got)
+		}
+	}
+}
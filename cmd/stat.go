@@ -43,6 +43,8 @@ type statMessage struct {
 	Key               string            `json:"name"`
 	Date              time.Time         `json:"lastModified"`
 	Size              int64             `json:"size"`
+	LogicalSize       int64             `json:"logicalSize,omitempty"`
+	Compression       string            `json:"compression,omitempty"`
 	ETag              string            `json:"etag"`
 	Type              string            `json:"type,omitempty"`
 	Expires           *time.Time        `json:"expires,omitempty"`
@@ -61,7 +63,15 @@ func (stat statMessage) String() (msg string) {
 	stat.Key = fmt.Sprintf("%-10s: %s", "Name", stat.Key)
 	msgBuilder.WriteString(console.Colorize("Name", stat.Key) + "\n")
 	msgBuilder.WriteString(fmt.Sprintf("%-10s: %s ", "Date", stat.Date.Format(printDate)) + "\n")
-	msgBuilder.WriteString(fmt.Sprintf("%-10s: %-6s ", "Size", humanize.IBytes(uint64(stat.Size))) + "\n")
+	switch {
+	case stat.Compression != "" && stat.LogicalSize > 0:
+		msgBuilder.WriteString(fmt.Sprintf("%-10s: %-6s (%s, %s uncompressed) ", "Size", humanize.IBytes(uint64(stat.Size)),
+			stat.Compression, humanize.IBytes(uint64(stat.LogicalSize))) + "\n")
+	case stat.Compression != "":
+		msgBuilder.WriteString(fmt.Sprintf("%-10s: %-6s (%s) ", "Size", humanize.IBytes(uint64(stat.Size)), stat.Compression) + "\n")
+	default:
+		msgBuilder.WriteString(fmt.Sprintf("%-10s: %-6s ", "Size", humanize.IBytes(uint64(stat.Size))) + "\n")
+	}
 	if stat.ETag != "" {
 		msgBuilder.WriteString(fmt.Sprintf("%-10s: %s ", "ETag", stat.ETag) + "\n")
 	}
@@ -140,6 +150,12 @@ func parseStat(c *ClientContent) statMessage {
 		return "file"
 	}()
 	content.Size = c.Size
+	if codec, size := compressionOf(c.UserMetadata, c.Metadata); codec != "" {
+		content.Compression = codec
+		if size >= 0 {
+			content.LogicalSize = size
+		}
+	}
 	content.VersionID = c.VersionID
 	content.Key = getKey(c)
 	content.Metadata = c.Metadata
@@ -0,0 +1,750 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	gojson "encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/klauspost/compress/gzip"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var supportDiagAnalyzeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "severity",
+		Usage: "show only findings of at least this severity (values: `info`, `warning`, `critical`)",
+		Value: diagSeverityInfo,
+	},
+}
+
+var supportDiagAnalyzeCmd = cli.Command{
+	Name:         "analyze",
+	Usage:        "analyze a diagnostics report offline",
+	Action:       mainSupportDiagAnalyze,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(supportDiagAnalyzeFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] FILE
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Run the built-in rules over a report saved with 'mc support diag --airgap',
+  without uploading it to SUBNET. The rules look for offline servers and
+  drives, drive latency and throughput outliers, clock skew between servers,
+  mismatched server versions, kernel and system settings, uneven pool usage
+  and network errors. Findings are printed by decreasing severity along with
+  their evidence.
+
+EXAMPLES:
+  1. Analyze a diagnostics report.
+     {{.Prompt}} {{.HelpName}} myminio-health_20220901103000.json.gz
+
+  2. Print only the warnings and critical findings as JSON.
+     {{.Prompt}} {{.HelpName}} --severity warning --json myminio-health_20220901103000.json.gz
+`,
+}
+
+// Severities of the findings, by increasing priority.
+const (
+	diagSeverityInfo     = "info"
+	diagSeverityWarning  = "warning"
+	diagSeverityCritical = "critical"
+)
+
+var diagSeverities = map[string]int{
+	diagSeverityInfo:     0,
+	diagSeverityWarning:  1,
+	diagSeverityCritical: 2,
+}
+
+var diagSeverityColors = map[string]string{
+	diagSeverityInfo:     "DiagInfo",
+	diagSeverityWarning:  "DiagWarning",
+	diagSeverityCritical: "DiagCritical",
+}
+
+// Thresholds of the rules.
+const (
+	diagClockSkewWarning    = 2 * time.Second
+	diagClockSkewCritical   = 15 * time.Minute
+	diagDriveLatencyFactor  = 3
+	diagDriveLatencyMin     = 10 * time.Millisecond
+	diagThroughputRatio     = 0.5
+	diagPoolUsageSpread     = 0.2
+	diagPoolUsageCritical   = 0.9
+	diagMinOpenFiles        = 65536
+	diagMinKernelMajorMinor = 4.0
+)
+
+// diagFinding is the result of a rule.
+type diagFinding struct {
+	Severity string   `json:"severity"`
+	Rule     string   `json:"rule"`
+	Summary  string   `json:"summary"`
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// diagRule checks a health report and returns its findings.
+type diagRule struct {
+	name  string
+	check func(info madmin.HealthInfo) []diagFinding
+}
+
+var diagRules = []diagRule{
+	{"offline", diagCheckOffline},
+	{"drive-latency", diagCheckDriveLatency},
+	{"drive-throughput", diagCheckDriveThroughput},
+	{"clock-skew", diagCheckClockSkew},
+	{"versions", diagCheckVersions},
+	{"kernel", diagCheckKernel},
+	{"system", diagCheckSystem},
+	{"pool-usage", diagCheckPoolUsage},
+	{"network", diagCheckNetwork},
+	{"data-collection", diagCheckCollectionErrors},
+}
+
+// analyzeHealthInfo runs all rules, findings are sorted by decreasing
+// severity and then by rule.
+func analyzeHealthInfo(info madmin.HealthInfo) []diagFinding {
+	findings := []diagFinding{}
+	for _, rule := range diagRules {
+		for _, f := range rule.check(info) {
+			f.Rule = rule.name
+			findings = append(findings, f)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return diagSeverities[findings[i].Severity] > diagSeverities[findings[j].Severity]
+	})
+	return findings
+}
+
+func diagCheckOffline(info madmin.HealthInfo) (findings []diagFinding) {
+	var servers, drives []string
+	for _, srv := range info.Minio.Info.Servers {
+		if srv.State != "" && srv.State != string(madmin.ItemOnline) {
+			servers = append(servers, fmt.Sprintf("%s is %s", srv.Endpoint, srv.State))
+		}
+		for _, d := range srv.Drives {
+			if d.State != "" && d.State != madmin.DriveStateOk {
+				drives = append(drives, fmt.Sprintf("%s%s on %s is %s", d.Endpoint, d.DrivePath, srv.Endpoint, d.State))
+			}
+		}
+	}
+	if len(servers) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityCritical,
+			Summary:  fmt.Sprintf("%d server(s) offline", len(servers)),
+			Evidence: servers,
+		})
+	}
+	if len(drives) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityCritical,
+			Summary:  fmt.Sprintf("%d drive(s) offline or faulty", len(drives)),
+			Evidence: drives,
+		})
+	}
+	return findings
+}
+
+// medianFloat returns the median of values, which are sorted in place.
+func medianFloat(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+func diagCheckDriveLatency(info madmin.HealthInfo) []diagFinding {
+	type driveLatency struct {
+		name string
+		avg  time.Duration
+	}
+	var drives []driveLatency
+	var avgs []float64
+	for _, srv := range info.Minio.Info.Servers {
+		for _, d := range srv.Drives {
+			if d.Metrics == nil {
+				continue
+			}
+			var count, acc uint64
+			for _, action := range d.Metrics.LastMinute {
+				count += action.Count
+				acc += action.AccTime
+			}
+			if count == 0 {
+				continue
+			}
+			avg := time.Duration(acc / count)
+			drives = append(drives, driveLatency{d.Endpoint + d.DrivePath, avg})
+			avgs = append(avgs, float64(avg))
+		}
+	}
+	if len(drives) < 2 {
+		return nil
+	}
+	median := time.Duration(medianFloat(avgs))
+	var evidence []string
+	for _, d := range drives {
+		if d.avg > diagDriveLatencyMin && d.avg > diagDriveLatencyFactor*median {
+			evidence = append(evidence, fmt.Sprintf("%s averages %s per call, the median drive %s", d.name, d.avg.Round(time.Microsecond), median.Round(time.Microsecond)))
+		}
+	}
+	if len(evidence) == 0 {
+		return nil
+	}
+	return []diagFinding{{
+		Severity: diagSeverityWarning,
+		Summary:  fmt.Sprintf("%d drive(s) are more than %dx slower than the median drive", len(evidence), diagDriveLatencyFactor),
+		Evidence: evidence,
+	}}
+}
+
+func diagCheckDriveThroughput(info madmin.HealthInfo) (findings []diagFinding) {
+	type drivePerf struct {
+		name        string
+		read, write uint64
+	}
+	var drives []drivePerf
+	var reads, writes []float64
+	var failed []string
+	for _, node := range info.Perf.DrivePerf {
+		if node.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s: %s", node.Endpoint, node.Error))
+			continue
+		}
+		for _, d := range node.DrivePerf {
+			if d.Error != "" {
+				failed = append(failed, fmt.Sprintf("%s%s: %s", node.Endpoint, d.Path, d.Error))
+				continue
+			}
+			drives = append(drives, drivePerf{node.Endpoint + d.Path, d.ReadThroughput, d.WriteThroughput})
+			reads = append(reads, float64(d.ReadThroughput))
+			writes = append(writes, float64(d.WriteThroughput))
+		}
+	}
+	if len(failed) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityCritical,
+			Summary:  fmt.Sprintf("drive speed test failed on %d drive(s)", len(failed)),
+			Evidence: failed,
+		})
+	}
+	if len(drives) < 2 {
+		return findings
+	}
+	medianRead, medianWrite := medianFloat(reads), medianFloat(writes)
+	var evidence []string
+	for _, d := range drives {
+		if float64(d.read) < diagThroughputRatio*medianRead || float64(d.write) < diagThroughputRatio*medianWrite {
+			evidence = append(evidence, fmt.Sprintf("%s reads %s/s and writes %s/s, the median drive %s/s and %s/s",
+				d.name, humanize.IBytes(d.read), humanize.IBytes(d.write),
+				humanize.IBytes(uint64(medianRead)), humanize.IBytes(uint64(medianWrite))))
+		}
+	}
+	if len(evidence) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityWarning,
+			Summary:  fmt.Sprintf("%d drive(s) have less than half the throughput of the median drive", len(evidence)),
+			Evidence: evidence,
+		})
+	}
+	return findings
+}
+
+// diagConfigValue decodes a value of the system config of a node.
+func diagConfigValue(sc madmin.SysConfig, key string, v interface{}) bool {
+	value, ok := sc.Config[key]
+	if !ok {
+		return false
+	}
+	buf, e := gojson.Marshal(value)
+	if e != nil {
+		return false
+	}
+	return gojson.Unmarshal(buf, v) == nil
+}
+
+func diagCheckClockSkew(info madmin.HealthInfo) []diagFinding {
+	type nodeTime struct {
+		addr string
+		t    time.Time
+	}
+	var times []nodeTime
+	for _, sc := range info.Sys.SysConfig {
+		var ti madmin.TimeInfo
+		if diagConfigValue(sc, "time-info", &ti) && !ti.CurrentTime.IsZero() {
+			times = append(times, nodeTime{sc.Addr, ti.CurrentTime})
+		}
+	}
+	if len(times) < 2 {
+		return nil
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].t.Before(times[j].t) })
+	skew := times[len(times)-1].t.Sub(times[0].t)
+	if skew < diagClockSkewWarning {
+		return nil
+	}
+	severity := diagSeverityWarning
+	if skew >= diagClockSkewCritical {
+		severity = diagSeverityCritical
+	}
+	var evidence []string
+	for _, nt := range times[1:] {
+		evidence = append(evidence, fmt.Sprintf("%s is %s ahead of %s", nt.addr, nt.t.Sub(times[0].t).Round(time.Millisecond), times[0].addr))
+	}
+	return []diagFinding{{
+		Severity: severity,
+		Summary:  fmt.Sprintf("clocks of the servers are %s apart", skew.Round(time.Millisecond)),
+		Evidence: evidence,
+	}}
+}
+
+// groupByValue returns the keys sharing each value, sorted by value.
+func groupByValue(values map[string]string) []string {
+	groups := make(map[string][]string)
+	for k, v := range values {
+		groups[v] = append(groups[v], k)
+	}
+	var evidence []string
+	for _, v := range sortedGroupKeys(groups) {
+		evidence = append(evidence, fmt.Sprintf("%s: %s", v, strings.Join(groups[v], ", ")))
+	}
+	return evidence
+}
+
+// sortedGroupKeys sorts the keys of groups and the members of each group.
+func sortedGroupKeys(groups map[string][]string) []string {
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		sort.Strings(groups[k])
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func diagCheckVersions(info madmin.HealthInfo) []diagFinding {
+	versions := make(map[string]string)
+	for _, srv := range info.Minio.Info.Servers {
+		if srv.Version != "" {
+			versions[srv.Endpoint] = srv.Version
+		}
+	}
+	evidence := groupByValue(versions)
+	if len(evidence) < 2 {
+		return nil
+	}
+	return []diagFinding{{
+		Severity: diagSeverityCritical,
+		Summary:  fmt.Sprintf("servers run %d different MinIO versions", len(evidence)),
+		Evidence: evidence,
+	}}
+}
+
+func diagCheckKernel(info madmin.HealthInfo) (findings []diagFinding) {
+	kernels := make(map[string]string)
+	var old []string
+	for _, osInfo := range info.Sys.OSInfo {
+		kernel := osInfo.Info.KernelVersion
+		if kernel == "" {
+			continue
+		}
+		kernels[osInfo.Addr] = kernel
+		var major, minor int
+		if n, _ := fmt.Sscanf(kernel, "%d.%d", &major, &minor); n == 2 && float64(major)+float64(minor)/100 < diagMinKernelMajorMinor {
+			old = append(old, fmt.Sprintf("%s runs %s", osInfo.Addr, kernel))
+		}
+	}
+	if len(old) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityWarning,
+			Summary:  fmt.Sprintf("%d server(s) run a kernel older than 4.0", len(old)),
+			Evidence: old,
+		})
+	}
+	if evidence := groupByValue(kernels); len(evidence) > 1 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityInfo,
+			Summary:  fmt.Sprintf("servers run %d different kernel versions", len(evidence)),
+			Evidence: evidence,
+		})
+	}
+	return findings
+}
+
+func diagCheckSystem(info madmin.HealthInfo) (findings []diagFinding) {
+	var openFiles []string
+	for _, sc := range info.Sys.SysConfig {
+		var limit uint64
+		if diagConfigValue(sc, "rlimit-max", &limit) && limit > 0 && limit < diagMinOpenFiles {
+			openFiles = append(openFiles, fmt.Sprintf("%s allows %d open files", sc.Addr, limit))
+		}
+	}
+	if len(openFiles) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityWarning,
+			Summary:  fmt.Sprintf("open files limit is below %d", diagMinOpenFiles),
+			Evidence: openFiles,
+		})
+	}
+
+	var governor []string
+	for _, cpus := range info.Sys.CPUInfo {
+		if cpus.IsFreqGovPerf != nil && !*cpus.IsFreqGovPerf {
+			governor = append(governor, cpus.Addr)
+		}
+	}
+	if len(governor) > 0 {
+		sort.Strings(governor)
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityWarning,
+			Summary:  "CPU frequency governor is not set to performance",
+			Evidence: governor,
+		})
+	}
+
+	var swap []string
+	for _, mem := range info.Sys.MemInfo {
+		if mem.SwapSpaceTotal > 0 {
+			swap = append(swap, fmt.Sprintf("%s has %s of swap", mem.Addr, humanize.IBytes(mem.SwapSpaceTotal)))
+		}
+	}
+	if len(swap) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityInfo,
+			Summary:  "swap is enabled, it may slow down servers under memory pressure",
+			Evidence: swap,
+		})
+	}
+
+	// System errors are the known misconfigurations found by the servers.
+	sysErrs := make(map[string][]string)
+	for _, se := range info.Sys.SysErrs {
+		for _, e := range se.Errors {
+			sysErrs[e] = append(sysErrs[e], se.Addr)
+		}
+	}
+	for _, e := range sortedGroupKeys(sysErrs) {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityWarning,
+			Summary:  e,
+			Evidence: sysErrs[e],
+		})
+	}
+	return findings
+}
+
+func diagCheckPoolUsage(info madmin.HealthInfo) (findings []diagFinding) {
+	type poolUsage struct{ used, total uint64 }
+	pools := make(map[int]*poolUsage)
+	for _, srv := range info.Minio.Info.Servers {
+		for _, d := range srv.Drives {
+			if d.State != madmin.DriveStateOk || d.TotalSpace == 0 {
+				continue
+			}
+			p, ok := pools[d.PoolIndex]
+			if !ok {
+				p = &poolUsage{}
+				pools[d.PoolIndex] = p
+			}
+			p.used += d.UsedSpace
+			p.total += d.TotalSpace
+		}
+	}
+	var indexes []int
+	for i := range pools {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	var evidence, full []string
+	min, max := 1.0, 0.0
+	for _, i := range indexes {
+		p := pools[i]
+		usage := float64(p.used) / float64(p.total)
+		if usage < min {
+			min = usage
+		}
+		if usage > max {
+			max = usage
+		}
+		line := fmt.Sprintf("pool %d uses %s of %s (%.1f%%)", i+1, humanize.IBytes(p.used), humanize.IBytes(p.total), usage*100)
+		evidence = append(evidence, line)
+		if usage >= diagPoolUsageCritical {
+			full = append(full, line)
+		}
+	}
+	if len(full) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityCritical,
+			Summary:  fmt.Sprintf("%d pool(s) are more than %.0f%% full", len(full), diagPoolUsageCritical*100),
+			Evidence: full,
+		})
+	}
+	if len(pools) > 1 && max-min > diagPoolUsageSpread {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityWarning,
+			Summary:  fmt.Sprintf("pool usage is uneven, from %.1f%% to %.1f%%", min*100, max*100),
+			Evidence: evidence,
+		})
+	}
+	return findings
+}
+
+func diagCheckNetwork(info madmin.HealthInfo) (findings []diagFinding) {
+	var unreachable []string
+	for _, srv := range info.Minio.Info.Servers {
+		for _, peer := range sortedKeys(srv.Network) {
+			if state := srv.Network[peer]; state != string(madmin.ItemOnline) {
+				unreachable = append(unreachable, fmt.Sprintf("%s sees %s %s", srv.Endpoint, peer, state))
+			}
+		}
+	}
+	if len(unreachable) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityCritical,
+			Summary:  "servers can't reach each other",
+			Evidence: unreachable,
+		})
+	}
+
+	var failed []string
+	var rx, tx []float64
+	for _, n := range info.Perf.NetPerf {
+		if n.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s: %s", n.Endpoint, n.Error))
+			continue
+		}
+		rx = append(rx, float64(n.RX))
+		tx = append(tx, float64(n.TX))
+	}
+	if len(failed) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityCritical,
+			Summary:  fmt.Sprintf("network test failed on %d server(s)", len(failed)),
+			Evidence: failed,
+		})
+	}
+	if len(rx) < 2 {
+		return findings
+	}
+	medianRX, medianTX := medianFloat(rx), medianFloat(tx)
+	var slow []string
+	for _, n := range info.Perf.NetPerf {
+		if n.Error == "" && (float64(n.RX) < diagThroughputRatio*medianRX || float64(n.TX) < diagThroughputRatio*medianTX) {
+			slow = append(slow, fmt.Sprintf("%s receives %s/s and sends %s/s, the median server %s/s and %s/s",
+				n.Endpoint, humanize.IBytes(n.RX), humanize.IBytes(n.TX),
+				humanize.IBytes(uint64(medianRX)), humanize.IBytes(uint64(medianTX))))
+		}
+	}
+	if len(slow) > 0 {
+		findings = append(findings, diagFinding{
+			Severity: diagSeverityWarning,
+			Summary:  fmt.Sprintf("%d server(s) have less than half the network throughput of the median server", len(slow)),
+			Evidence: slow,
+		})
+	}
+	return findings
+}
+
+// diagCheckCollectionErrors reports the data which couldn't be collected,
+// the other rules can't check it.
+func diagCheckCollectionErrors(info madmin.HealthInfo) []diagFinding {
+	var evidence []string
+	add := func(what, addr, e string) {
+		if e != "" {
+			evidence = append(evidence, fmt.Sprintf("%s of %s: %s", what, addr, e))
+		}
+	}
+	add("report", "cluster", info.Error)
+	add("performance tests", "cluster", info.Perf.Error)
+	add("server info", "cluster", info.Minio.Error)
+	for _, c := range info.Sys.CPUInfo {
+		add("cpu info", c.Addr, c.Error)
+	}
+	for _, p := range info.Sys.Partitions {
+		add("partitions", p.Addr, p.Error)
+	}
+	for _, o := range info.Sys.OSInfo {
+		add("os info", o.Addr, o.Error)
+	}
+	for _, m := range info.Sys.MemInfo {
+		add("memory info", m.Addr, m.Error)
+	}
+	for _, sc := range info.Sys.SysConfig {
+		add("system config", sc.Addr, sc.Error)
+	}
+	if len(evidence) == 0 {
+		return nil
+	}
+	return []diagFinding{{
+		Severity: diagSeverityInfo,
+		Summary:  "some diagnostics could not be collected",
+		Evidence: evidence,
+	}}
+}
+
+// readDiagReport reads a report saved by tarGZ, made of the report
+// version followed by the health info.
+func readDiagReport(path string) (info madmin.HealthInfo, version string, err *probe.Error) {
+	f, e := os.Open(path)
+	if e != nil {
+		return info, "", probe.NewError(e)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gr, e := gzip.NewReader(br)
+		if e != nil {
+			return info, "", probe.NewError(e)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	dec := gojson.NewDecoder(r)
+	var header struct {
+		Version string `json:"version"`
+	}
+	if e = dec.Decode(&header); e != nil {
+		return info, "", probe.NewError(e)
+	}
+	switch header.Version {
+	case madmin.HealthInfoVersion:
+		e = dec.Decode(&info)
+	case madmin.HealthInfoVersion2:
+		// Performance tests of version 2 have another format, the
+		// rules checking them don't apply.
+		var v2 madmin.HealthInfoV2
+		e = dec.Decode(&v2)
+		info = madmin.HealthInfo{Version: v2.Version, Error: v2.Error, TimeStamp: v2.TimeStamp, Sys: v2.Sys, Minio: v2.Minio}
+	default:
+		return info, header.Version, probe.NewError(fmt.Errorf("unsupported report version %q", header.Version))
+	}
+	if e != nil {
+		return info, header.Version, probe.NewError(e)
+	}
+	return info, header.Version, nil
+}
+
+// diagAnalysisMessage is the analysis of a diagnostics report.
+type diagAnalysisMessage struct {
+	Status    string        `json:"status"`
+	File      string        `json:"file"`
+	Version   string        `json:"version"`
+	TimeStamp time.Time     `json:"timestamp,omitempty"`
+	Servers   int           `json:"servers"`
+	Drives    int           `json:"drives"`
+	Findings  []diagFinding `json:"findings"`
+}
+
+func (d diagAnalysisMessage) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d server(s), %d drive(s)", console.Colorize("DiagFile", d.File), d.Servers, d.Drives)
+	if !d.TimeStamp.IsZero() {
+		fmt.Fprintf(&b, ", collected %s", d.TimeStamp.Local().Format(printDate))
+	}
+	b.WriteString("\n")
+	if len(d.Findings) == 0 {
+		b.WriteString("No issues found.")
+		return b.String()
+	}
+	for _, f := range d.Findings {
+		severity := console.Colorize(diagSeverityColors[f.Severity], fmt.Sprintf("%-8s", strings.ToUpper(f.Severity)))
+		fmt.Fprintf(&b, "\n%s [%s] %s\n", severity, f.Rule, f.Summary)
+		for _, e := range f.Evidence {
+			fmt.Fprintf(&b, "         - %s\n", e)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (d diagAnalysisMessage) JSON() string {
+	d.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(d, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func checkSupportDiagAnalyzeSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(ctx, "analyze", 1) // last argument is exit code
+	}
+	if _, ok := diagSeverities[ctx.String("severity")]; !ok {
+		fatalIf(errInvalidArgument().Trace(ctx.String("severity")), "Invalid severity.")
+	}
+}
+
+// mainSupportDiagAnalyze is the handler for "mc support diag analyze" command.
+func mainSupportDiagAnalyze(ctx *cli.Context) error {
+	checkSupportDiagAnalyzeSyntax(ctx)
+
+	console.SetColor("DiagFile", color.New(color.Bold))
+	console.SetColor(diagSeverityColors[diagSeverityCritical], color.New(color.FgRed, color.Bold))
+	console.SetColor(diagSeverityColors[diagSeverityWarning], color.New(color.FgYellow, color.Bold))
+	console.SetColor(diagSeverityColors[diagSeverityInfo], color.New(color.FgCyan))
+
+	file := ctx.Args().Get(0)
+	info, version, err := readDiagReport(file)
+	fatalIf(err.Trace(file), "Unable to read the diagnostics report.")
+
+	msg := diagAnalysisMessage{
+		File:      file,
+		Version:   version,
+		TimeStamp: info.TimeStamp,
+		Servers:   len(info.Minio.Info.Servers),
+		Findings:  []diagFinding{},
+	}
+	for _, srv := range info.Minio.Info.Servers {
+		msg.Drives += len(srv.Drives)
+	}
+	minSeverity := diagSeverities[ctx.String("severity")]
+	for _, f := range analyzeHealthInfo(info) {
+		if diagSeverities[f.Severity] >= minSeverity {
+			msg.Findings = append(msg.Findings, f)
+		}
+	}
+	printMsg(msg)
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go"
+)
+
+func testHealthInfo() madmin.HealthInfo {
+	now := time.Date(2022, 9, 1, 10, 30, 0, 0, time.UTC)
+	drive := func(endpoint string, pool int, state string, used uint64, latency time.Duration) madmin.Disk {
+		return madmin.Disk{
+			Endpoint:   endpoint,
+			DrivePath:  "/data1",
+			State:      state,
+			PoolIndex:  pool,
+			TotalSpace: 100 << 30,
+			UsedSpace:  used << 30,
+			Metrics: &madmin.DiskMetrics{LastMinute: map[string]madmin.TimedAction{
+				"ReadAll": {Count: 10, AccTime: uint64(10 * latency)},
+			}},
+		}
+	}
+	info := madmin.HealthInfo{Version: madmin.HealthInfoVersion, TimeStamp: now}
+	info.Minio.Info.Servers = []madmin.ServerInfo{
+		{
+			Endpoint: "node1:9000", State: "online", Version: "2022-08-25T07:17:05Z",
+			Network: map[string]string{"node2:9000": "online", "node3:9000": "online"},
+			Drives:  []madmin.Disk{drive("http://node1:9000", 0, "ok", 40, time.Millisecond), drive("http://node1:9000", 0, "ok", 40, 2*time.Millisecond)},
+		},
+		{
+			Endpoint: "node2:9000", State: "online", Version: "2022-08-25T07:17:05Z",
+			Network: map[string]string{"node1:9000": "online", "node3:9000": "offline"},
+			Drives:  []madmin.Disk{drive("http://node2:9000", 1, "ok", 95, 40*time.Millisecond), drive("http://node2:9000", 1, "faulty", 0, 0)},
+		},
+		{
+			Endpoint: "node3:9000", State: "offline", Version: "2022-08-02T23:59:16Z",
+		},
+	}
+	for i, addr := range []string{"node1:9000", "node2:9000", "node3:9000"} {
+		info.Sys.SysConfig = append(info.Sys.SysConfig, madmin.SysConfig{
+			NodeCommon: madmin.NodeCommon{Addr: addr},
+			Config: map[string]interface{}{
+				"rlimit-max": 1024 * (i*64 + 1),
+				"time-info":  madmin.TimeInfo{CurrentTime: now.Add(time.Duration(i*i) * time.Second)},
+			},
+		})
+		info.Sys.OSInfo = append(info.Sys.OSInfo, madmin.OSInfo{NodeCommon: madmin.NodeCommon{Addr: addr}})
+		info.Sys.OSInfo[i].Info.KernelVersion = "5.15.0-46-generic"
+	}
+	info.Sys.OSInfo[2].Info.KernelVersion = "3.10.0-1160.el7.x86_64"
+	info.Sys.SysErrs = []madmin.SysErrors{{NodeCommon: madmin.NodeCommon{Addr: "node1:9000"}, Errors: []string{madmin.SysErrUpdatedbInstalled}}}
+	info.Perf.NetPerf = []madmin.NetperfNodeResult{
+		{Endpoint: "node1:9000", RX: 1 << 30, TX: 1 << 30},
+		{Endpoint: "node2:9000", RX: 1 << 30, TX: 1 << 30},
+		{Endpoint: "node3:9000", RX: 100 << 20, TX: 1 << 30},
+	}
+	return info
+}
+
+func TestAnalyzeHealthInfo(t *testing.T) {
+	dir, e := ioutil.TempDir("", "mc-diag")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "myminio-health.json.gz")
+	if e = tarGZ(testHealthInfo(), madmin.HealthInfoVersion, file, false); e != nil {
+		t.Fatal(e)
+	}
+	info, version, err := readDiagReport(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != madmin.HealthInfoVersion || len(info.Minio.Info.Servers) != 3 {
+		t.Fatalf("unexpected report version %s: %+v", version, info)
+	}
+
+	type result struct{ severity, rule string }
+	var results []result
+	findings := analyzeHealthInfo(info)
+	for _, f := range findings {
+		results = append(results, result{f.Severity, f.Rule})
+	}
+	expected := []result{
+		{diagSeverityCritical, "offline"},
+		{diagSeverityCritical, "offline"},
+		{diagSeverityCritical, "versions"},
+		{diagSeverityCritical, "pool-usage"},
+		{diagSeverityCritical, "network"},
+		{diagSeverityWarning, "drive-latency"},
+		{diagSeverityWarning, "clock-skew"},
+		{diagSeverityWarning, "kernel"},
+		{diagSeverityWarning, "system"},
+		{diagSeverityWarning, "system"},
+		{diagSeverityWarning, "pool-usage"},
+		{diagSeverityWarning, "network"},
+		{diagSeverityInfo, "kernel"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("expected %v, got %v", expected, results)
+	}
+	if evidence := findings[6].Evidence; len(evidence) != 2 || evidence[1] != "node3:9000 is 4s ahead of node1:9000" {
+		t.Fatalf("unexpected clock skew evidence %v", evidence)
+	}
+	if evidence := findings[8].Evidence; !reflect.DeepEqual(evidence, []string{"node1:9000 allows 1024 open files"}) {
+		t.Fatalf("unexpected open files evidence %v", evidence)
+	}
+
+	// A healthy cluster has no findings.
+	healthy := testHealthInfo()
+	healthy.Minio.Info.Servers = healthy.Minio.Info.Servers[:1]
+	healthy.Sys = madmin.SysInfo{}
+	healthy.Perf = madmin.SpeedTestResults{}
+	if findings = analyzeHealthInfo(healthy); len(findings) != 0 {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+}
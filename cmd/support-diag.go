@@ -77,11 +77,13 @@ var supportDiagCmd = cli.Command{
 	Action:       mainSupportDiag,
 	Before:       setGlobalsFromContext,
 	Flags:        append(supportDiagFlags, globalFlags...),
+	Subcommands:  []cli.Command{supportDiagAnalyzeCmd},
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
 USAGE:
   {{.HelpName}} TARGET
+  {{.HelpName}} analyze FILE
 
 FLAGS:
   {{range .VisibleFlags}}{{.}}
@@ -92,13 +94,16 @@ EXAMPLES:
 
   2. Generate MinIO diagnostics report for alias 'play' (https://play.min.io by default) save and upload to SUBNET manually
      {{.Prompt}} {{.HelpName}} play --airgap
+
+  3. Analyze a saved MinIO diagnostics report offline
+     {{.Prompt}} {{.HelpName}} analyze play-health_20220901103000.json.gz
 `,
 }
 
 // checkSupportDiagSyntax - validate arguments passed by a user
 func checkSupportDiagSyntax(ctx *cli.Context) {
 	if len(ctx.Args()) == 0 || len(ctx.Args()) > 1 {
-		cli.ShowCommandHelpAndExit(ctx, "diag", 1) // last argument is exit code
+		cli.ShowAppHelpAndExit(ctx, 1) // last argument is exit code
 	}
 }
 
@@ -0,0 +1,687 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	gojson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zip"
+	"github.com/klauspost/reedsolomon"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/highwayhash"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+	"github.com/tinylib/msgp/msgp"
+)
+
+var supportInspectReconstructFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "out",
+		Usage: "file to write the reconstructed object to",
+	},
+	cli.StringFlag{
+		Name:  "object",
+		Usage: "BUCKET/OBJECT to reconstruct when the export has several objects",
+	},
+	cli.StringFlag{
+		Name:  "version-id, vid",
+		Usage: "version of the object to reconstruct, the latest by default",
+	},
+	cli.StringFlag{
+		Name:  "key",
+		Usage: "decryption key of an export downloaded with --encrypt",
+	},
+	cli.BoolFlag{
+		Name:  "raw",
+		Usage: "write the data as stored, without decompressing it",
+	},
+}
+
+var supportInspectReconstructCmd = cli.Command{
+	Name:         "reconstruct",
+	Usage:        "reconstruct an object from the shards of an inspect export",
+	Action:       mainSupportInspectReconstruct,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(supportInspectReconstructFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} --out FILE [FLAGS] EXPORT
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Decode the erasure coded shards of an object downloaded with 'mc support inspect',
+  using the layout recorded in the 'xl.meta' of each drive. Every shard block is
+  verified against its bitrot hash, missing and corrupted shards are rebuilt from
+  parity as long as no more than parity shards are unusable.
+
+  Compressed objects are decompressed unless --raw is set. Encrypted objects are
+  written as stored, they can't be decrypted without the server keys.
+
+EXAMPLES:
+  1. Download the shards of an object and reconstruct it.
+     {{.Prompt}} mc support inspect myminio/bucket/photo.jpg/**
+     {{.Prompt}} {{.HelpName}} --out photo.jpg inspect.7a3d02c1.zip
+
+  2. Reconstruct a previous version of an object from an encrypted export.
+     {{.Prompt}} {{.HelpName}} --key 7a3d02c1f3... --version-id 2f6c1b0e-8c1e-4bb4-9c6a-8d8e0a1e3f21 \
+                 --out photo.jpg inspect.7a3d02c1.enc
+`,
+}
+
+// magicHighwayHash256Key is the key of the bitrot hashes written by
+// the servers, the HighwayHash-256 of the first 100 decimals of π
+// with a zero key.
+var magicHighwayHash256Key = []byte("\x4b\xe7\x34\xfa\x8e\x23\x8a\xcd\x26\x3e\x83\xe6\xbb\x96\x85\x52\x04\x0f\x93\x5d\xa3\x9f\x44\x14\x97\xe0\x9d\x13\x22\xde\x36\xa0")
+
+// Values of xl.meta used to reconstruct objects.
+const (
+	xlMetaObjectType      = 1
+	xlMetaReedSolomon     = 1
+	xlMetaHighwayHash     = 1
+	xlMetaCompressionKey  = "x-minio-internal-compression"
+	xlMetaEncryptionKey   = "x-minio-internal-server-side-encryption-"
+	xlMetaNullVersionID   = "null"
+	shardStatusOK         = "ok"
+	shardStatusMissing    = "missing"
+	shardStatusCorrupted  = "corrupted"
+	shardStatusUnreadable = "unreadable"
+)
+
+// xlMetaObject is the erasure layout of an object version in xl.meta.
+type xlMetaObject struct {
+	ID        []byte            `json:"ID"`
+	DDir      []byte            `json:"DDir"`
+	EcAlgo    int               `json:"EcAlgo"`
+	EcM       int               `json:"EcM"`
+	EcN       int               `json:"EcN"`
+	EcBSize   int64             `json:"EcBSize"`
+	EcIndex   int               `json:"EcIndex"`
+	CSumAlgo  int               `json:"CSumAlgo"`
+	PartNums  []int             `json:"PartNums"`
+	PartSizes []int64           `json:"PartSizes"`
+	Size      int64             `json:"Size"`
+	MTime     int64             `json:"MTime"`
+	MetaSys   map[string][]byte `json:"MetaSys"`
+	MetaUsr   map[string]string `json:"MetaUsr"`
+}
+
+type xlMetaObjectVersion struct {
+	Type  int           `json:"Type"`
+	V2Obj *xlMetaObject `json:"V2Obj"`
+}
+
+// sortXLVersions sorts versions by modification time, newest first.
+// Versions without an object, e.g. delete markers, sort last.
+func sortXLVersions(versions []xlMetaObjectVersion) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		vi, vj := versions[i].V2Obj, versions[j].V2Obj
+		if vi == nil || vj == nil {
+			return vi != nil && vj == nil
+		}
+		return vi.MTime > vj.MTime
+	})
+}
+
+func formatUUID(id []byte) string {
+	if len(id) != 16 || bytes.Equal(id, make([]byte, 16)) {
+		return xlMetaNullVersionID
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+// VersionID returns the version ID, "null" for unversioned objects.
+func (o xlMetaObject) VersionID() string {
+	return formatUUID(o.ID)
+}
+
+// hasMeta returns whether a metadata key with the prefix exists.
+func (o xlMetaObject) hasMeta(prefix string) bool {
+	for k := range o.MetaSys {
+		if strings.HasPrefix(strings.ToLower(k), prefix) {
+			return true
+		}
+	}
+	for k := range o.MetaUsr {
+		if strings.HasPrefix(strings.ToLower(k), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseXLMeta returns the versions of xl.meta, newest first, and its
+// inline data.
+func parseXLMeta(buf []byte) (versions []xlMetaObjectVersion, inline xlMetaInlineData, e error) {
+	buf, _, minor, e := checkXL2V1(buf)
+	if e != nil {
+		return nil, nil, e
+	}
+	var meta []byte
+	switch minor {
+	case 0:
+		meta = buf
+	case 1, 2, 3:
+		meta, buf, e = msgp.ReadBytesZC(buf)
+		if e != nil {
+			return nil, nil, e
+		}
+		if _, nbuf, e := msgp.ReadUint32Bytes(buf); e == nil {
+			// Read metadata CRC (added in v2, ignore if not found)
+			buf = nbuf
+		}
+		inline = buf
+	default:
+		return nil, nil, fmt.Errorf("unknown metadata version %d", minor)
+	}
+
+	if minor < 3 {
+		var js bytes.Buffer
+		if _, e = msgp.UnmarshalAsJSON(&js, meta); e != nil {
+			return nil, nil, e
+		}
+		var xl struct {
+			Versions []xlMetaObjectVersion `json:"Versions"`
+		}
+		if e = gojson.Unmarshal(js.Bytes(), &xl); e != nil {
+			return nil, nil, e
+		}
+		// Versions were stored by modification time, oldest first.
+		sortXLVersions(xl.Versions)
+		return xl.Versions, inline, nil
+	}
+
+	nVers, meta, e := decodeXLHeaders(meta)
+	if e != nil {
+		return nil, nil, e
+	}
+	versions = make([]xlMetaObjectVersion, nVers)
+	e = decodeVersions(meta, nVers, func(idx int, hdr, meta []byte) error {
+		var js bytes.Buffer
+		if _, e := msgp.UnmarshalAsJSON(&js, meta); e != nil {
+			return e
+		}
+		return gojson.Unmarshal(js.Bytes(), &versions[idx])
+	})
+	return versions, inline, e
+}
+
+// find returns the inline data stored under a key.
+func (x xlMetaInlineData) find(key string) ([]byte, error) {
+	if len(x) == 0 {
+		return nil, nil
+	}
+	if !x.versionOK() {
+		return nil, errors.New("xlMetaInlineData: unknown version")
+	}
+	sz, buf, e := msgp.ReadMapHeaderBytes(x.afterVersion())
+	if e != nil {
+		return nil, e
+	}
+	for i := uint32(0); i < sz; i++ {
+		var k, v []byte
+		if k, buf, e = msgp.ReadMapKeyZC(buf); e != nil {
+			return nil, e
+		}
+		if v, buf, e = msgp.ReadBytesZC(buf); e != nil {
+			return nil, e
+		}
+		if string(k) == key {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+// inspectDrive is the copy of an object on a drive of an export.
+type inspectDrive struct {
+	dir    string
+	object xlMetaObject
+	inline []byte
+}
+
+// shardStatus reports the state of a shard after reconstruction.
+type shardStatus struct {
+	Index  int      `json:"index"`
+	Drive  string   `json:"drive,omitempty"`
+	Status string   `json:"status"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// inspectReconstructMessage reports a reconstructed object.
+type inspectReconstructMessage struct {
+	Status        string        `json:"status"`
+	Object        string        `json:"object"`
+	VersionID     string        `json:"versionId"`
+	Out           string        `json:"out"`
+	Size          int64         `json:"size"`
+	Written       int64         `json:"written"`
+	Parts         int           `json:"parts"`
+	DataShards    int           `json:"dataShards"`
+	ParityShards  int           `json:"parityShards"`
+	Reconstructed int           `json:"reconstructedBlocks"`
+	Compressed    bool          `json:"compressed,omitempty"`
+	Encrypted     bool          `json:"encrypted,omitempty"`
+	Shards        []shardStatus `json:"shards"`
+	Ignored       []string      `json:"ignored,omitempty"`
+}
+
+func (m inspectReconstructMessage) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Reconstructed %s (version %s) to %s\n", console.Colorize("File", m.Object), m.VersionID, console.Colorize("File", m.Out))
+	fmt.Fprintf(&b, "Size: %d bytes in %d part(s), EC:%d+%d, %d block(s) rebuilt from parity\n", m.Written, m.Parts, m.DataShards, m.ParityShards, m.Reconstructed)
+	if m.Encrypted {
+		b.WriteString(console.Colorize("ShardBad", "The object is encrypted, the output is the encrypted data.") + "\n")
+	} else if m.Compressed && m.Written != m.Size {
+		b.WriteString("The object is compressed, the output is the compressed data.\n")
+	}
+	for _, s := range m.Shards {
+		status := console.Colorize("ShardOK", s.Status)
+		if s.Status != shardStatusOK {
+			status = console.Colorize("ShardBad", s.Status)
+		}
+		drive := s.Drive
+		if drive == "" {
+			drive = "-"
+		}
+		fmt.Fprintf(&b, "  shard %2d  %-10s %s\n", s.Index, status, drive)
+		for _, e := range s.Errors {
+			fmt.Fprintf(&b, "            %s\n", e)
+		}
+	}
+	for _, dir := range m.Ignored {
+		fmt.Fprintf(&b, "  ignored %s, it has another version of the object\n", dir)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (m inspectReconstructMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// loadInspectDrives reads the xl.meta of an object on all drives of
+// an export, with the version to reconstruct.
+func loadInspectDrives(zr *zip.Reader, object, versionID string) (drives []inspectDrive, ignored []string, e error) {
+	objects := make(map[string]bool)
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() || path.Base(file.Name) != "xl.meta" {
+			continue
+		}
+		dir := path.Dir(file.Name)
+		if object != "" && !strings.HasSuffix(dir, "/"+strings.Trim(object, "/")) && dir != strings.Trim(object, "/") {
+			continue
+		}
+		r, e := file.Open()
+		if e != nil {
+			return nil, nil, e
+		}
+		buf, e := ioutil.ReadAll(r)
+		r.Close()
+		if e != nil {
+			return nil, nil, e
+		}
+		versions, inline, e := parseXLMeta(buf)
+		if e != nil {
+			return nil, nil, fmt.Errorf("%s: %w", file.Name, e)
+		}
+		var found *xlMetaObject
+		for _, v := range versions {
+			if v.Type != xlMetaObjectType || v.V2Obj == nil {
+				if versionID == "" {
+					break
+				}
+				continue
+			}
+			if versionID == "" || v.V2Obj.VersionID() == versionID {
+				found = v.V2Obj
+				break
+			}
+		}
+		if found == nil {
+			ignored = append(ignored, dir)
+			continue
+		}
+		d := inspectDrive{dir: dir, object: *found}
+		if d.inline, e = inline.find(found.VersionID()); e != nil {
+			return nil, nil, fmt.Errorf("%s: %w", file.Name, e)
+		}
+		drives = append(drives, d)
+		objects[path.Base(dir)] = true
+	}
+	if len(drives) == 0 {
+		return nil, nil, errors.New("no drive has the object version, it may be a delete marker")
+	}
+
+	// Drives with other versions of the object are ignored, they are
+	// outdated or ahead of the quorum.
+	latest := drives[0].object
+	for _, d := range drives {
+		if d.object.MTime > latest.MTime {
+			latest = d.object
+		}
+	}
+	var matching []inspectDrive
+	for _, d := range drives {
+		if d.object.VersionID() == latest.VersionID() && d.object.MTime == latest.MTime && bytes.Equal(d.object.DDir, latest.DDir) {
+			matching = append(matching, d)
+			continue
+		}
+		ignored = append(ignored, d.dir)
+	}
+	if len(objects) > 1 && object == "" {
+		return nil, nil, errors.New("the export has several objects, select one with --object")
+	}
+	return matching, ignored, nil
+}
+
+// shardSource reads the blocks of a shard and checks their bitrot hashes.
+type shardSource struct {
+	status *shardStatus
+	r      io.Reader
+	closer io.Closer
+	hash   [highwayhash.Size]byte
+}
+
+// readBlock returns the next block of the shard, nil if the block is
+// unusable.
+func (s *shardSource) readBlock(part, block int, size int) []byte {
+	if s.r == nil {
+		return nil
+	}
+	buf := make([]byte, size)
+	if _, e := io.ReadFull(s.r, s.hash[:]); e != nil {
+		s.fail(fmt.Sprintf("part.%d: truncated at block %d", part, block))
+		return nil
+	}
+	if _, e := io.ReadFull(s.r, buf); e != nil {
+		s.fail(fmt.Sprintf("part.%d: truncated at block %d", part, block))
+		return nil
+	}
+	if sum := highwayhash.Sum(buf, magicHighwayHash256Key); !bytes.Equal(sum[:], s.hash[:]) {
+		s.status.Status = shardStatusCorrupted
+		s.status.Errors = append(s.status.Errors, fmt.Sprintf("part.%d: bitrot in block %d", part, block))
+		return nil
+	}
+	return buf
+}
+
+// fail marks the rest of the shard as unusable.
+func (s *shardSource) fail(msg string) {
+	if s.status.Status == shardStatusOK {
+		s.status.Status = shardStatusUnreadable
+	}
+	s.status.Errors = append(s.status.Errors, msg)
+	s.close()
+}
+
+func (s *shardSource) close() {
+	if s.closer != nil {
+		s.closer.Close()
+	}
+	s.r, s.closer = nil, nil
+}
+
+// reconstructObject writes the object stored by drives to w.
+func reconstructObject(zr *zip.Reader, drives []inspectDrive, w io.Writer) (msg inspectReconstructMessage, e error) {
+	obj := drives[0].object
+	if obj.EcAlgo != xlMetaReedSolomon {
+		return msg, fmt.Errorf("unsupported erasure algorithm %d", obj.EcAlgo)
+	}
+	if obj.CSumAlgo != xlMetaHighwayHash {
+		return msg, fmt.Errorf("unsupported bitrot algorithm %d", obj.CSumAlgo)
+	}
+	dataShards, parityShards := obj.EcM, obj.EcN
+	total := dataShards + parityShards
+	enc, e := reedsolomon.New(dataShards, parityShards)
+	if e != nil {
+		return msg, e
+	}
+
+	msg.VersionID = obj.VersionID()
+	msg.Size = obj.Size
+	msg.Parts = len(obj.PartNums)
+	msg.DataShards, msg.ParityShards = dataShards, parityShards
+	msg.Compressed = obj.hasMeta(xlMetaCompressionKey)
+	msg.Encrypted = obj.hasMeta(xlMetaEncryptionKey)
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+	byIndex := make([]*inspectDrive, total)
+	for i := range drives {
+		idx := drives[i].object.EcIndex - 1
+		if idx >= 0 && idx < total && byIndex[idx] == nil {
+			byIndex[idx] = &drives[i]
+		}
+	}
+	msg.Shards = make([]shardStatus, total)
+	for i := range msg.Shards {
+		msg.Shards[i] = shardStatus{Index: i + 1, Status: shardStatusOK}
+		if byIndex[i] == nil {
+			msg.Shards[i].Status = shardStatusMissing
+		} else {
+			msg.Shards[i].Drive = byIndex[i].dir
+		}
+	}
+
+	shardSize := ceilFrac(obj.EcBSize, int64(dataShards))
+	for p, partNum := range obj.PartNums {
+		partSize := obj.PartSizes[p]
+		sources := make([]*shardSource, total)
+		for i, d := range byIndex {
+			src := &shardSource{status: &msg.Shards[i]}
+			sources[i] = src
+			if d == nil {
+				continue
+			}
+			name := fmt.Sprintf("%s/%s/part.%d", d.dir, formatUUID(d.object.DDir), partNum)
+			if file, ok := files[name]; ok {
+				rc, e := file.Open()
+				if e != nil {
+					src.fail(fmt.Sprintf("part.%d: %v", partNum, e))
+					continue
+				}
+				src.r, src.closer = rc, rc
+			} else if d.inline != nil && len(obj.PartNums) == 1 {
+				src.r = bytes.NewReader(d.inline)
+			} else {
+				src.fail(fmt.Sprintf("part.%d: missing", partNum))
+			}
+		}
+
+		for block, off := 0, int64(0); off < partSize; block, off = block+1, off+obj.EcBSize {
+			blockSize := obj.EcBSize
+			if partSize-off < blockSize {
+				blockSize = partSize - off
+			}
+			size := int(shardSize)
+			if blockSize < obj.EcBSize {
+				size = int(ceilFrac(blockSize, int64(dataShards)))
+			}
+			shards := make([][]byte, total)
+			available, dataAvailable := 0, 0
+			for i, src := range sources {
+				if shards[i] = src.readBlock(partNum, block, size); shards[i] != nil {
+					available++
+					if i < dataShards {
+						dataAvailable++
+					}
+				}
+			}
+			if available < dataShards {
+				for _, src := range sources {
+					src.close()
+				}
+				return msg, fmt.Errorf("part.%d block %d: only %d of %d required shards are usable", partNum, block, available, dataShards)
+			}
+			if dataAvailable < dataShards {
+				if e = enc.ReconstructData(shards); e != nil {
+					return msg, e
+				}
+				msg.Reconstructed++
+			}
+			if e = enc.Join(w, shards, int(blockSize)); e != nil {
+				return msg, e
+			}
+			msg.Written += blockSize
+		}
+		for _, src := range sources {
+			src.close()
+		}
+	}
+	return msg, nil
+}
+
+// ceilFrac returns the smallest integer not less than numerator/denominator.
+func ceilFrac(numerator, denominator int64) int64 {
+	if denominator == 0 {
+		return 0
+	}
+	return (numerator + denominator - 1) / denominator
+}
+
+// openInspectExport opens an inspect export, decrypting it with the
+// key printed by 'mc support inspect --encrypt' if given.
+func openInspectExport(file, hexKey string) (*zip.Reader, func(), *probe.Error) {
+	f, e := os.Open(file)
+	if e != nil {
+		return nil, nil, probe.NewError(e)
+	}
+	if hexKey != "" {
+		key, e := hex.DecodeString(hexKey)
+		if e != nil || len(key) != 36 {
+			f.Close()
+			return nil, nil, errInvalidArgument().Trace(hexKey)
+		}
+		var k [32]byte
+		copy(k[:], key[4:])
+		tmp, e := ioutil.TempFile("", "mc-inspect-")
+		if e != nil {
+			f.Close()
+			return nil, nil, probe.NewError(e)
+		}
+		os.Remove(tmp.Name())
+		_, e = io.Copy(tmp, decryptInspect(k, f))
+		f.Close()
+		if e != nil {
+			tmp.Close()
+			return nil, nil, probe.NewError(e)
+		}
+		f = tmp
+	}
+	st, e := f.Stat()
+	if e != nil {
+		f.Close()
+		return nil, nil, probe.NewError(e)
+	}
+	zr, e := zip.NewReader(f, st.Size())
+	if e != nil {
+		f.Close()
+		return nil, nil, probe.NewError(e)
+	}
+	return zr, func() { f.Close() }, nil
+}
+
+func checkSupportInspectReconstructSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 || ctx.String("out") == "" {
+		cli.ShowCommandHelpAndExit(ctx, "reconstruct", 1) // last argument is exit code
+	}
+}
+
+// mainSupportInspectReconstruct is the handler for "mc support inspect reconstruct" command.
+func mainSupportInspectReconstruct(ctx *cli.Context) error {
+	checkSupportInspectReconstructSyntax(ctx)
+
+	console.SetColor("File", color.New(color.FgWhite, color.Bold))
+	console.SetColor("ShardOK", color.New(color.FgGreen))
+	console.SetColor("ShardBad", color.New(color.FgRed, color.Bold))
+
+	export, out := ctx.Args().Get(0), ctx.String("out")
+	zr, closer, err := openInspectExport(export, ctx.String("key"))
+	fatalIf(err.Trace(export), "Unable to open the inspect export.")
+	defer closer()
+
+	drives, ignored, e := loadInspectDrives(zr, ctx.String("object"), ctx.String("version-id"))
+	fatalIf(probe.NewError(e).Trace(export), "Unable to read the object layout.")
+
+	f, e := os.Create(out)
+	fatalIf(probe.NewError(e).Trace(out), "Unable to create the output file.")
+
+	// Compressed objects are decompressed while they are written.
+	var w io.Writer = f
+	var pw *io.PipeWriter
+	done := make(chan error, 1)
+	obj := drives[0].object
+	if obj.hasMeta(xlMetaCompressionKey) && !obj.hasMeta(xlMetaEncryptionKey) && !ctx.Bool("raw") {
+		var pr *io.PipeReader
+		pr, pw = io.Pipe()
+		w = pw
+		go func() {
+			_, e := io.Copy(f, s2.NewReader(pr))
+			pr.CloseWithError(e)
+			done <- e
+		}()
+	}
+
+	msg, e := reconstructObject(zr, drives, w)
+	if pw != nil {
+		pw.CloseWithError(e)
+		if de := <-done; e == nil {
+			e = de
+		}
+	}
+	if ce := f.Close(); e == nil {
+		e = ce
+	}
+	if e != nil {
+		os.Remove(out)
+	}
+	fatalIf(probe.NewError(e).Trace(export), "Unable to reconstruct the object.")
+
+	if pw != nil {
+		if st, e := os.Stat(out); e == nil {
+			msg.Written = st.Size()
+		}
+	}
+	msg.Object = path.Base(drives[0].dir)
+	msg.Out = out
+	msg.Ignored = ignored
+	printMsg(msg)
+	return nil
+}
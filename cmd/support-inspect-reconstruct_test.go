@@ -0,0 +1,288 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/klauspost/compress/zip"
+	"github.com/klauspost/reedsolomon"
+	"github.com/minio/highwayhash"
+	"github.com/tinylib/msgp/msgp"
+)
+
+const (
+	testECData   = 2
+	testECParity = 2
+	testECBlock  = 64
+)
+
+var (
+	testVersionID = []byte{0x2f, 0x6c, 0x1b, 0x0e, 0x8c, 0x1e, 0x4b, 0xb4, 0x9c, 0x6a, 0x8d, 0x8e, 0x0a, 0x1e, 0x3f, 0x21}
+	testDataDir   = []byte{0x7a, 0x3d, 0x02, 0xc1, 0x1b, 0x0e, 0x4b, 0xb4, 0x9c, 0x6a, 0x8d, 0x8e, 0x0a, 0x1e, 0x3f, 0x21}
+)
+
+// testEncodeShards erasure codes data the way the servers store it,
+// every block of a shard is prefixed by its bitrot hash.
+func testEncodeShards(t *testing.T, data []byte) [][]byte {
+	enc, e := reedsolomon.New(testECData, testECParity)
+	if e != nil {
+		t.Fatal(e)
+	}
+	shards := make([][]byte, testECData+testECParity)
+	for off := 0; off < len(data); off += testECBlock {
+		end := off + testECBlock
+		if end > len(data) {
+			end = len(data)
+		}
+		blocks, e := enc.Split(append([]byte{}, data[off:end]...))
+		if e != nil {
+			t.Fatal(e)
+		}
+		if e = enc.Encode(blocks); e != nil {
+			t.Fatal(e)
+		}
+		for i, block := range blocks {
+			sum := highwayhash.Sum(block, magicHighwayHash256Key)
+			shards[i] = append(shards[i], sum[:]...)
+			shards[i] = append(shards[i], block...)
+		}
+	}
+	return shards
+}
+
+// testXLMeta returns a v1.3 xl.meta of an object stored on the drive
+// holding shard index, with inline data if set.
+func testXLMeta(index int, size int64, inline []byte) []byte {
+	var obj []byte
+	obj = msgp.AppendMapHeader(obj, 13)
+	obj = msgp.AppendString(obj, "ID")
+	obj = msgp.AppendBytes(obj, testVersionID)
+	obj = msgp.AppendString(obj, "DDir")
+	obj = msgp.AppendBytes(obj, testDataDir)
+	obj = msgp.AppendString(obj, "EcAlgo")
+	obj = msgp.AppendUint8(obj, xlMetaReedSolomon)
+	obj = msgp.AppendString(obj, "EcM")
+	obj = msgp.AppendInt(obj, testECData)
+	obj = msgp.AppendString(obj, "EcN")
+	obj = msgp.AppendInt(obj, testECParity)
+	obj = msgp.AppendString(obj, "EcBSize")
+	obj = msgp.AppendInt64(obj, testECBlock)
+	obj = msgp.AppendString(obj, "EcIndex")
+	obj = msgp.AppendInt(obj, index)
+	obj = msgp.AppendString(obj, "CSumAlgo")
+	obj = msgp.AppendUint8(obj, xlMetaHighwayHash)
+	obj = msgp.AppendString(obj, "PartNums")
+	obj = msgp.AppendArrayHeader(obj, 1)
+	obj = msgp.AppendInt(obj, 1)
+	obj = msgp.AppendString(obj, "PartSizes")
+	obj = msgp.AppendArrayHeader(obj, 1)
+	obj = msgp.AppendInt64(obj, size)
+	obj = msgp.AppendString(obj, "Size")
+	obj = msgp.AppendInt64(obj, size)
+	obj = msgp.AppendString(obj, "MTime")
+	obj = msgp.AppendInt64(obj, 1662028200000000000)
+	obj = msgp.AppendString(obj, "MetaSys")
+	obj = msgp.AppendMapHeader(obj, 0)
+
+	var version []byte
+	version = msgp.AppendMapHeader(version, 2)
+	version = msgp.AppendString(version, "Type")
+	version = msgp.AppendUint8(version, xlMetaObjectType)
+	version = msgp.AppendString(version, "V2Obj")
+	version = append(version, obj...)
+
+	var meta []byte
+	meta = msgp.AppendUint(meta, xlHeaderVersion)
+	meta = msgp.AppendUint(meta, xlMetaVersion)
+	meta = msgp.AppendInt(meta, 1)
+	meta = msgp.AppendBytes(meta, nil)
+	meta = msgp.AppendBytes(meta, version)
+
+	buf := append([]byte("XL2 "), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint16(buf[4:6], 1)
+	binary.LittleEndian.PutUint16(buf[6:8], 3)
+	buf = msgp.AppendBytes(buf, meta)
+	buf = msgp.AppendUint32(buf, 0)
+	if inline != nil {
+		buf = append(buf, xlMetaInlineDataVer)
+		buf = msgp.AppendMapHeader(buf, 1)
+		buf = msgp.AppendString(buf, formatUUID(testVersionID))
+		buf = msgp.AppendBytes(buf, inline)
+	}
+	return buf
+}
+
+// testInspectExport returns an export of the object with the shards
+// of the given drives, stored inline or as part files.
+func testInspectExport(t *testing.T, shards [][]byte, size int64, inline bool, drives ...int) *zip.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, i := range drives {
+		dir := fmt.Sprintf("server%d/data/bucket/object", i+1)
+		var inlineData []byte
+		if inline {
+			inlineData = shards[i]
+		}
+		w, e := zw.Create(dir + "/xl.meta")
+		if e != nil {
+			t.Fatal(e)
+		}
+		w.Write(testXLMeta(i+1, size, inlineData))
+		if inline {
+			continue
+		}
+		if w, e = zw.Create(dir + "/" + formatUUID(testDataDir) + "/part.1"); e != nil {
+			t.Fatal(e)
+		}
+		w.Write(shards[i])
+	}
+	if e := zw.Close(); e != nil {
+		t.Fatal(e)
+	}
+	zr, e := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if e != nil {
+		t.Fatal(e)
+	}
+	return zr
+}
+
+func TestSupportInspectReconstruct(t *testing.T) {
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	size := int64(len(data))
+
+	testCases := []struct {
+		name     string
+		drives   []int
+		inline   bool
+		corrupt  func(shards [][]byte)
+		statuses []string
+		rebuilt  int
+		wantErr  bool
+	}{
+		{
+			name:     "all shards",
+			drives:   []int{0, 1, 2, 3},
+			statuses: []string{shardStatusOK, shardStatusOK, shardStatusOK, shardStatusOK},
+		},
+		{
+			name:     "inline",
+			drives:   []int{0, 1, 2, 3},
+			inline:   true,
+			statuses: []string{shardStatusOK, shardStatusOK, shardStatusOK, shardStatusOK},
+		},
+		{
+			name:     "missing and corrupted shard",
+			drives:   []int{1, 2, 3},
+			corrupt:  func(shards [][]byte) { shards[1][highwayhash.Size+testECBlock/testECData+highwayhash.Size] ^= 0xff },
+			statuses: []string{shardStatusMissing, shardStatusCorrupted, shardStatusOK, shardStatusOK},
+			rebuilt:  4,
+		},
+		{
+			name:     "truncated shard",
+			drives:   []int{0, 1, 2, 3},
+			corrupt:  func(shards [][]byte) { shards[0] = shards[0][:highwayhash.Size+10] },
+			statuses: []string{shardStatusUnreadable, shardStatusOK, shardStatusOK, shardStatusOK},
+			rebuilt:  4,
+		},
+		{
+			name:    "too many missing shards",
+			drives:  []int{1, 2},
+			corrupt: func(shards [][]byte) { shards[2][highwayhash.Size] ^= 0xff },
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shards := testEncodeShards(t, data)
+			if tc.corrupt != nil {
+				tc.corrupt(shards)
+			}
+			zr := testInspectExport(t, shards, size, tc.inline, tc.drives...)
+			drives, _, e := loadInspectDrives(zr, "", "")
+			if e != nil {
+				t.Fatal(e)
+			}
+			var out bytes.Buffer
+			msg, e := reconstructObject(zr, drives, &out)
+			if tc.wantErr {
+				if e == nil {
+					t.Fatal("expected an error with too many unusable shards")
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			if !bytes.Equal(out.Bytes(), data) {
+				t.Fatalf("reconstructed data differs from the object")
+			}
+			if msg.VersionID != formatUUID(testVersionID) || msg.Written != size {
+				t.Errorf("unexpected version %s or size %d", msg.VersionID, msg.Written)
+			}
+			if msg.Reconstructed != tc.rebuilt {
+				t.Errorf("expected %d rebuilt blocks, got %d", tc.rebuilt, msg.Reconstructed)
+			}
+			for i, status := range tc.statuses {
+				if msg.Shards[i].Status != status {
+					t.Errorf("shard %d: expected %s, got %s", i+1, status, msg.Shards[i].Status)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadInspectDrivesVersion(t *testing.T) {
+	shards := testEncodeShards(t, []byte("hello"))
+	zr := testInspectExport(t, shards, 5, true, 0, 1)
+	if _, _, e := loadInspectDrives(zr, "bucket/object", "2f6c1b0e-8c1e-4bb4-9c6a-8d8e0a1e3f21"); e != nil {
+		t.Fatal(e)
+	}
+	if _, _, e := loadInspectDrives(zr, "", "00000000-8c1e-4bb4-9c6a-8d8e0a1e3f21"); e == nil {
+		t.Fatal("expected an error for an unknown version")
+	}
+}
+
+func TestSortXLVersions(t *testing.T) {
+	versions := []xlMetaObjectVersion{
+		{Type: 2},
+		{Type: 1, V2Obj: &xlMetaObject{MTime: 1}},
+		{Type: 2},
+		{Type: 1, V2Obj: &xlMetaObject{MTime: 3}},
+		{Type: 1, V2Obj: &xlMetaObject{MTime: 2}},
+	}
+	sortXLVersions(versions)
+	var mtimes []int64
+	for _, v := range versions {
+		if v.V2Obj == nil {
+			mtimes = append(mtimes, 0)
+			continue
+		}
+		mtimes = append(mtimes, v.V2Obj.MTime)
+	}
+	if fmt.Sprint(mtimes) != "[3 2 1 0 0]" {
+		t.Fatalf("unexpected order of versions %v", mtimes)
+	}
+}
@@ -33,7 +33,6 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/minio/cli"
-	json "github.com/minio/colorjson"
 	"github.com/minio/madmin-go"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/console"
@@ -60,11 +59,15 @@ var supportInspectCmd = cli.Command{
 	Before:          setGlobalsFromContext,
 	Flags:           append(supportInspectFlags, globalFlags...),
 	HideHelpCommand: true,
+	Subcommands: []cli.Command{
+		supportInspectReconstructCmd,
+	},
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
 USAGE:
   {{.HelpName}} [FLAGS] TARGET
+  {{.HelpName}} reconstruct --out FILE EXPORT
 
 FLAGS:
   {{range .VisibleFlags}}{{.}}
@@ -78,12 +81,15 @@ EXAMPLES:
 
   3. Download recursively all objects at a prefix. NOTE: This can be an expensive operation use it with caution.
      {{.Prompt}} {{.HelpName}} myminio/bucket/test/**
+
+  4. Reconstruct an object from the downloaded parts, verifying and repairing its shards.
+     {{.Prompt}} {{.HelpName}} reconstruct --out test.bin inspect.7a3d02c1.zip
 `,
 }
 
 func checkSupportInspectSyntax(ctx *cli.Context) {
 	if len(ctx.Args()) != 1 {
-		cli.ShowCommandHelpAndExit(ctx, "inspect", 1) // last argument is exit code
+		cli.ShowAppHelpAndExit(ctx, 1) // last argument is exit code
 	}
 
 	if ctx.IsSet("export") && globalJSON {
@@ -201,9 +207,7 @@ func mainSupportInspect(ctx *cli.Context) error {
 	if !encrypt {
 		v.Key = ""
 	}
-	b, e := json.Marshal(v)
-	fatalIf(probe.NewError(e), "Unable to serialize data")
-	console.Println(string(b))
+	printMsg(jsonMessage{v})
 	return nil
 }
 
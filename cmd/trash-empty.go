@@ -0,0 +1,127 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"path"
+
+	"github.com/minio/cli"
+)
+
+var trashEmptyFlags = []cli.Flag{
+	trashBucketFlag,
+	cli.StringFlag{
+		Name:  "older-than",
+		Usage: "remove the objects moved to the trash longer than L days ago, L in duration string (e.g. 7d10h31s)",
+	},
+	cli.BoolFlag{
+		Name:  "force",
+		Usage: "allow removing the objects of the trash for good",
+	},
+}
+
+var trashEmptyCmd = cli.Command{
+	Name:         "empty",
+	Usage:        "remove the objects of the trash for good",
+	Action:       mainTrashEmpty,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(trashEmptyFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Empty the trash of the bucket 'mybucket'.
+     {{.Prompt}} {{.HelpName}} --force myminio/mybucket
+
+  2. Remove the objects moved to the trash of 'mybucket' more than 30 days ago.
+     {{.Prompt}} {{.HelpName}} --force --older-than 30d myminio/mybucket
+
+  3. Remove the objects removed under the prefix 'tmp/' of 'mybucket' from the trash shared in the bucket 'trash'.
+     {{.Prompt}} {{.HelpName}} --force --trash-bucket trash myminio/mybucket/tmp/
+`,
+}
+
+// mainTrashEmpty is the handle for "mc trash empty" command.
+func mainTrashEmpty(cliCtx *cli.Context) error {
+	if len(cliCtx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(cliCtx, "empty", 1) // last argument is exit code
+	}
+	ctx, cancelTrashEmpty := context.WithCancel(globalContext)
+	defer cancelTrashEmpty()
+
+	setTrashColors()
+
+	aliasedURL := cliCtx.Args().Get(0)
+	if !cliCtx.Bool("force") {
+		fatalIf(errInvalidArgument().Trace(aliasedURL), "Emptying the trash requires --force.")
+	}
+	olderThan := cliCtx.String("older-than")
+
+	sharedBucket := cliCtx.String("trash-bucket")
+	alias, entries, err := listTrash(ctx, aliasedURL, sharedBucket)
+	fatalIf(err, "Unable to list the trash of `"+aliasedURL+"`.")
+
+	_, p := url2Alias(aliasedURL)
+	bucket, _ := splitBucketKey(p)
+	clnt, err := newClient(path.Join(alias, trashBucket(sharedBucket, bucket)))
+	fatalIf(err, "Unable to empty the trash of `"+aliasedURL+"`.")
+
+	var expired []trashEntry
+	removed := make(map[string]trashEntry)
+	for _, entry := range entries {
+		// Skip the objects not in the trash for long enough.
+		if olderThan != "" && isOlder(entry.DeletedAt, olderThan) {
+			continue
+		}
+		expired = append(expired, entry)
+		removed[entry.content.URL.Path] = entry
+	}
+
+	contentCh := make(chan *ClientContent)
+	go func() {
+		defer close(contentCh)
+		for _, entry := range expired {
+			select {
+			case contentCh <- &ClientContent{URL: entry.content.URL}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var cErr error
+	for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+		if result.Err != nil {
+			errorIf(result.Err.Trace(aliasedURL), "Unable to remove from the trash of `"+aliasedURL+"`.")
+			cErr = exitStatus(globalErrorExitStatus)
+			continue
+		}
+		if entry, ok := removed["/"+path.Join(result.BucketName, result.ObjectName)]; ok {
+			printMsg(newTrashMessage("empty", alias, entry))
+		}
+	}
+	return cErr
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/minio/cli"
+)
+
+var trashListCmd = cli.Command{
+	Name:         "ls",
+	Usage:        "list the objects in the trash",
+	Action:       mainTrashList,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append([]cli.Flag{trashBucketFlag}, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. List the objects removed from the bucket 'mybucket' with 'rm --trash'.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket
+
+  2. List the objects removed under the prefix 'photos/2022/' of 'mybucket'.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket/photos/2022/
+
+  3. List the objects removed from all the buckets of 'myminio' into the trash of the bucket 'trash'.
+     {{.Prompt}} {{.HelpName}} --trash-bucket trash myminio
+`,
+}
+
+// mainTrashList is the handle for "mc trash ls" command.
+func mainTrashList(cliCtx *cli.Context) error {
+	if len(cliCtx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(cliCtx, "ls", 1) // last argument is exit code
+	}
+	ctx, cancelTrashList := context.WithCancel(globalContext)
+	defer cancelTrashList()
+
+	setTrashColors()
+
+	aliasedURL := cliCtx.Args().Get(0)
+	alias, entries, err := listTrash(ctx, aliasedURL, cliCtx.String("trash-bucket"))
+	fatalIf(err, "Unable to list the trash of `"+aliasedURL+"`.")
+	for _, entry := range entries {
+		printMsg(newTrashMessage("list", alias, entry))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var trashSubcommands = []cli.Command{
+	trashListCmd,
+	trashRestoreCmd,
+	trashEmptyCmd,
+}
+
+var trashCmd = cli.Command{
+	Name:            "trash",
+	Usage:           "manage the objects removed with 'rm --trash'",
+	HideHelpCommand: true,
+	Action:          mainTrash,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	Subcommands:     trashSubcommands,
+}
+
+// mainTrash is the handle for "mc trash" command.
+func mainTrash(ctx *cli.Context) error {
+	commandNotFound(ctx, trashSubcommands)
+	return nil
+	// Sub-commands like "ls", "restore" have their own main.
+}
+
+// setTrashColors sets the colors of the trash messages.
+func setTrashColors() {
+	console.SetColor("TrashTime", color.New(color.FgGreen))
+	console.SetColor("TrashSize", color.New(color.FgYellow))
+	console.SetColor("TrashKey", color.New(color.Bold))
+	console.SetColor("TrashBy", color.New(color.FgCyan))
+}
+
+// trashMessage container for the objects of the trash.
+type trashMessage struct {
+	op        string
+	Status    string    `json:"status"`
+	Key       string    `json:"key"`
+	TrashKey  string    `json:"trashKey"`
+	Size      int64     `json:"size"`
+	DeletedAt time.Time `json:"deletedAt"`
+	DeletedBy string    `json:"deletedBy,omitempty"`
+}
+
+func newTrashMessage(op, alias string, entry trashEntry) trashMessage {
+	return trashMessage{
+		op:        op,
+		Key:       entry.originalPath(alias),
+		TrashKey:  entry.trashPath(alias),
+		Size:      entry.content.Size,
+		DeletedAt: entry.DeletedAt,
+		DeletedBy: entry.deletedBy(),
+	}
+}
+
+// String colorized trash message
+func (m trashMessage) String() string {
+	switch m.op {
+	case "restore":
+		return fmt.Sprintf("Restored %s from %s.", console.Colorize("TrashKey", "`"+m.Key+"`"), "`"+m.TrashKey+"`")
+	case "empty":
+		return fmt.Sprintf("Removed %s from the trash.", console.Colorize("TrashKey", "`"+m.TrashKey+"`"))
+	}
+	msg := console.Colorize("TrashTime", "["+m.DeletedAt.Local().Format(printDate)+"] ") +
+		console.Colorize("TrashSize", fmt.Sprintf("%7s ", humanize.IBytes(uint64(m.Size)))) +
+		console.Colorize("TrashKey", m.Key)
+	if m.DeletedBy != "" {
+		msg += console.Colorize("TrashBy", " (removed by "+m.DeletedBy+")")
+	}
+	return msg
+}
+
+// JSON jsonified trash message
+func (m trashMessage) JSON() string {
+	m.Status = "success"
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
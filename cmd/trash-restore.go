@@ -0,0 +1,188 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var trashRestoreFlags = []cli.Flag{
+	trashBucketFlag,
+	cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "restore all the objects removed under the prefix",
+	},
+	cli.BoolFlag{
+		Name:  "overwrite",
+		Usage: "overwrite the objects created since their removal",
+	},
+}
+
+var trashRestoreCmd = cli.Command{
+	Name:         "restore",
+	Usage:        "put objects of the trash back where they were removed from",
+	Action:       mainTrashRestore,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(append(trashRestoreFlags, ioFlags...), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  An object removed several times is restored from its latest removal. The
+  restored object is a new object: its versions, tags and retention are not
+  restored.
+
+EXAMPLES:
+  1. Restore the object 'report.pdf' removed from the bucket 'mybucket'.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket/report.pdf
+
+  2. Restore all the objects removed under the prefix 'photos/2022/' of 'mybucket'.
+     {{.Prompt}} {{.HelpName}} --recursive myminio/mybucket/photos/2022/
+
+  3. Restore an object from the trash shared in the bucket 'trash', overwriting the object created since.
+     {{.Prompt}} {{.HelpName}} --trash-bucket trash --overwrite myminio/mybucket/report.pdf
+`,
+}
+
+// latestTrashEntries returns the latest removal of each object among the
+// entries, sorted by original path and removal time.
+func latestTrashEntries(entries []trashEntry) (latest []trashEntry) {
+	for i, entry := range entries {
+		if i+1 < len(entries) && entries[i+1].Bucket == entry.Bucket && entries[i+1].Key == entry.Key {
+			continue
+		}
+		latest = append(latest, entry)
+	}
+	return latest
+}
+
+// restoreTrashEntry copies the object of the trash back to its original
+// path, then removes it from the trash.
+func restoreTrashEntry(ctx context.Context, alias string, entry trashEntry, overwrite bool, encKeyDB map[string][]prefixSSEPair) *probe.Error {
+	trashPath, targetPath := entry.trashPath(alias), entry.originalPath(alias)
+	srcSSE := getSSE(trashPath, encKeyDB[alias])
+	tgtSSE := getSSE(targetPath, encKeyDB[alias])
+
+	tgtClnt, err := newClient(targetPath)
+	if err != nil {
+		return err.Trace(targetPath)
+	}
+	if !overwrite {
+		_, err = tgtClnt.Stat(ctx, StatOptions{sse: tgtSSE})
+		if err == nil {
+			return errOverWriteNotAllowed(targetPath)
+		}
+		if _, ok := err.ToGoError().(ObjectMissing); !ok {
+			return err.Trace(targetPath)
+		}
+	}
+
+	srcClnt, err := newClient(trashPath)
+	if err != nil {
+		return err.Trace(trashPath)
+	}
+	st, err := srcClnt.Stat(ctx, StatOptions{sse: srcSSE})
+	if err != nil {
+		return err.Trace(trashPath)
+	}
+	// Always replace the metadata, to drop the trash metadata.
+	metadata := trashMetadata(st.Metadata)
+	if metadata["Content-Type"] == "" {
+		metadata["Content-Type"] = "application/octet-stream"
+	}
+	opts := CopyOptions{
+		size:     st.Size,
+		srcSSE:   srcSSE,
+		tgtSSE:   tgtSSE,
+		metadata: metadata,
+	}
+	if err = tgtClnt.Copy(ctx, entry.content.URL.Path, opts, nil); err != nil {
+		return err.Trace(trashPath, targetPath)
+	}
+
+	contentCh := make(chan *ClientContent, 1)
+	contentCh <- &ClientContent{URL: entry.content.URL}
+	close(contentCh)
+	for result := range srcClnt.Remove(ctx, false, false, false, false, contentCh) {
+		if result.Err != nil {
+			return result.Err.Trace(trashPath)
+		}
+	}
+	return nil
+}
+
+// mainTrashRestore is the handle for "mc trash restore" command.
+func mainTrashRestore(cliCtx *cli.Context) error {
+	if len(cliCtx.Args()) != 1 {
+		cli.ShowCommandHelpAndExit(cliCtx, "restore", 1) // last argument is exit code
+	}
+	ctx, cancelTrashRestore := context.WithCancel(globalContext)
+	defer cancelTrashRestore()
+
+	setTrashColors()
+
+	encKeyDB, err := getEncKeys(cliCtx)
+	fatalIf(err, "Unable to parse encryption keys.")
+
+	aliasedURL := cliCtx.Args().Get(0)
+	isRecursive := cliCtx.Bool("recursive")
+	_, p := url2Alias(aliasedURL)
+	bucket, key := splitBucketKey(p)
+	if !isRecursive && (key == "" || strings.HasSuffix(aliasedURL, "/")) {
+		fatalIf(errInvalidArgument().Trace(aliasedURL), "Restoring a prefix requires --recursive.")
+	}
+
+	alias, entries, err := listTrash(ctx, aliasedURL, cliCtx.String("trash-bucket"))
+	fatalIf(err, "Unable to list the trash of `"+aliasedURL+"`.")
+	if !isRecursive {
+		var found []trashEntry
+		for _, entry := range entries {
+			if entry.Bucket == bucket && entry.Key == key {
+				found = append(found, entry)
+			}
+		}
+		entries = found
+	}
+	entries = latestTrashEntries(entries)
+	if len(entries) == 0 {
+		fatalIf(errDummy().Trace(aliasedURL), "No object of the trash was removed from `"+aliasedURL+"`.")
+	}
+
+	var cErr error
+	for _, entry := range entries {
+		if err := restoreTrashEntry(ctx, alias, entry, cliCtx.Bool("overwrite"), encKeyDB); err != nil {
+			errorIf(err, "Unable to restore `"+path.Join(alias, entry.Bucket, entry.Key)+"`.")
+			cErr = exitStatus(globalErrorExitStatus)
+			continue
+		}
+		printMsg(newTrashMessage("restore", alias, entry))
+	}
+	return cErr
+}
@@ -0,0 +1,370 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+// The trash of a bucket holds the objects removed with 'rm --trash' under
+//
+//	TRASH-BUCKET/.trash/STAMP/BUCKET/KEY
+//
+// where TRASH-BUCKET is the bucket itself, or the bucket given with
+// --trash-bucket for a trash shared by all the buckets of an alias, and
+// STAMP is the time of the removal.
+const (
+	trashPrefix        = ".trash/"
+	trashStampFormat   = "20060102T150405.000Z"
+	trashExpiryRuleID  = "mc-trash-expiry"
+	trashMetaPath      = "X-Amz-Meta-Mc-Trash-Path"
+	trashMetaVersionID = "X-Amz-Meta-Mc-Trash-Version-Id"
+	trashMetaDeletedBy = "X-Amz-Meta-Mc-Trash-Deleted-By"
+	trashMetaDeletedAt = "X-Amz-Meta-Mc-Trash-Deleted-At"
+)
+
+// trashBucketFlag selects a trash shared by the buckets of an alias.
+var trashBucketFlag = cli.StringFlag{
+	Name:  "trash-bucket",
+	Usage: "use the trash in this bucket for the objects of all buckets, instead of the trash of each bucket",
+}
+
+// trashFlags are the flags of 'rm' moving objects to the trash.
+var trashFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "trash",
+		Usage: "move object(s) to the trash of their bucket instead of removing them, see 'mc trash'",
+	},
+	trashBucketFlag,
+	cli.IntFlag{
+		Name:  "trash-expiry-days",
+		Usage: "with --trash, add a lifecycle rule removing objects from the trash after this many days",
+	},
+}
+
+// trashBucket returns the bucket holding the trash of the objects of
+// bucket, sharedBucket is the value of --trash-bucket.
+func trashBucket(sharedBucket, bucket string) string {
+	if sharedBucket != "" {
+		return sharedBucket
+	}
+	return bucket
+}
+
+// trashBin moves objects to the trash before 'rm' removes them.
+// A nil trash bin moves nothing.
+type trashBin struct {
+	sharedBucket string
+	expiryDays   int
+	stamp        string
+
+	mu sync.Mutex
+	// expirySet records the trash buckets with an expiry rule.
+	expirySet map[string]bool
+}
+
+// newTrashBin returns the trash bin of the --trash flags, nil without them.
+func newTrashBin(cliCtx *cli.Context) (*trashBin, *probe.Error) {
+	sharedBucket := cliCtx.String("trash-bucket")
+	expiryDays := cliCtx.Int("trash-expiry-days")
+	if !cliCtx.Bool("trash") && sharedBucket == "" {
+		if expiryDays != 0 {
+			return nil, errInvalidArgument().Trace("--trash-expiry-days requires --trash")
+		}
+		return nil, nil
+	}
+	if expiryDays < 0 {
+		return nil, errInvalidArgument().Trace("--trash-expiry-days")
+	}
+	return &trashBin{
+		sharedBucket: sharedBucket,
+		expiryDays:   expiryDays,
+		stamp:        UTCNow().Format(trashStampFormat),
+		expirySet:    make(map[string]bool),
+	}, nil
+}
+
+// check verifies that the objects of the aliased url can be moved to
+// the trash.
+func (t *trashBin) check(aliasedURL string) *probe.Error {
+	if t == nil {
+		return nil
+	}
+	alias, p := url2Alias(aliasedURL)
+	if alias == "" {
+		return errTrashUnsupported(aliasedURL)
+	}
+	bucket, _ := splitBucketKey(p)
+	if t.sharedBucket != "" && bucket == t.sharedBucket {
+		return errInvalidArgument().Trace(aliasedURL, "the trash can't be moved to itself, see `mc trash empty`")
+	}
+	return nil
+}
+
+// splitBucketKey splits the path of an object in its bucket and key.
+func splitBucketKey(p string) (bucket, key string) {
+	tokens := splitStr(strings.TrimLeft(p, "/"), "/", 2)
+	return tokens[0], tokens[1]
+}
+
+// contains returns true if the object is in a trash, the objects of a
+// trash are not moved to the trash again.
+func (t *trashBin) contains(content *ClientContent) bool {
+	if t == nil {
+		return false
+	}
+	bucket, key := splitBucketKey(content.URL.Path)
+	if t.sharedBucket != "" {
+		return bucket == t.sharedBucket
+	}
+	return strings.HasPrefix(key, trashPrefix)
+}
+
+// movedByRun returns true if the object was moved to the trash by this
+// run, listings of a bucket may find the objects just moved there.
+func (t *trashBin) movedByRun(content *ClientContent) bool {
+	if !t.contains(content) {
+		return false
+	}
+	_, key := splitBucketKey(content.URL.Path)
+	return strings.HasPrefix(key, trashPrefix+t.stamp+"/")
+}
+
+// checkRemove refuses to remove the objects already in a trash, they
+// are neither moved to the trash again nor removed for good, which is
+// left to `mc trash empty`.
+func (t *trashBin) checkRemove(alias string, content *ClientContent) *probe.Error {
+	if t == nil || content.IsDeleteMarker || content.Type.IsDir() || !t.contains(content) {
+		return nil
+	}
+	return errInTrash(path.Join(alias, content.URL.Path))
+}
+
+// trashMetadata returns the metadata of an object to keep across the trash.
+func trashMetadata(metadata map[string]string) map[string]string {
+	kept := editableMetadata(metadata)
+	for k := range kept {
+		if strings.HasPrefix(k, "X-Amz-Meta-Mc-Trash-") {
+			delete(kept, k)
+		}
+	}
+	return kept
+}
+
+// move copies the object server-side to the trash of its bucket, the
+// objects already in a trash are refused, see checkRemove.
+func (t *trashBin) move(ctx context.Context, alias string, content *ClientContent, encKeyDB map[string][]prefixSSEPair) *probe.Error {
+	if t == nil || content.IsDeleteMarker || content.Type.IsDir() {
+		return nil
+	}
+	if err := t.checkRemove(alias, content); err != nil {
+		return err
+	}
+	srcPath := path.Join(alias, content.URL.Path)
+	bucket, key := splitBucketKey(content.URL.Path)
+	srcSSE := getSSE(srcPath, encKeyDB[alias])
+
+	srcClnt, err := newClient(srcPath)
+	if err != nil {
+		return err.Trace(srcPath)
+	}
+	st, err := srcClnt.Stat(ctx, StatOptions{versionID: content.VersionID, sse: srcSSE})
+	if err != nil {
+		return err.Trace(srcPath)
+	}
+
+	_, _, aliasCfg := mustExpandAlias(srcPath)
+	metadata := trashMetadata(st.Metadata)
+	metadata[trashMetaPath] = url.PathEscape(path.Join(bucket, key))
+	metadata[trashMetaDeletedAt] = UTCNow().Format(time.RFC3339)
+	if aliasCfg != nil && aliasCfg.AccessKey != "" {
+		metadata[trashMetaDeletedBy] = aliasCfg.AccessKey
+	}
+	if content.VersionID != "" {
+		metadata[trashMetaVersionID] = content.VersionID
+	}
+
+	tBucket := trashBucket(t.sharedBucket, bucket)
+	dstPath := path.Join(alias, tBucket, trashPrefix, t.stamp, bucket, key)
+	dstClnt, err := newClient(dstPath)
+	if err != nil {
+		return err.Trace(dstPath)
+	}
+	opts := CopyOptions{
+		versionID: content.VersionID,
+		size:      st.Size,
+		srcSSE:    srcSSE,
+		tgtSSE:    getSSE(dstPath, encKeyDB[alias]),
+		metadata:  metadata,
+	}
+	if err = dstClnt.Copy(ctx, content.URL.Path, opts, nil); err != nil {
+		return err.Trace(srcPath, dstPath)
+	}
+	return t.setExpiry(ctx, alias, tBucket)
+}
+
+// setExpiry adds the lifecycle rule expiring the objects of the trash of
+// the bucket, once per run.
+func (t *trashBin) setExpiry(ctx context.Context, alias, bucket string) *probe.Error {
+	if t.expiryDays == 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.expirySet[bucket] {
+		return nil
+	}
+	clnt, err := newClient(path.Join(alias, bucket))
+	if err != nil {
+		return err.Trace(alias, bucket)
+	}
+	config, err := clnt.GetLifecycle(ctx)
+	if err != nil {
+		if minio.ToErrorResponse(err.ToGoError()).Code != "NoSuchLifecycleConfiguration" {
+			return err.Trace(alias, bucket)
+		}
+		config = lifecycle.NewConfiguration()
+	}
+	setTrashExpiryRule(config, t.expiryDays)
+	if err = clnt.SetLifecycle(ctx, config); err != nil {
+		return err.Trace(alias, bucket)
+	}
+	t.expirySet[bucket] = true
+	return nil
+}
+
+// setTrashExpiryRule adds or updates the rule expiring the objects of the
+// trash after days.
+func setTrashExpiryRule(config *lifecycle.Configuration, days int) {
+	rule := lifecycle.Rule{
+		ID:         trashExpiryRuleID,
+		Status:     "Enabled",
+		RuleFilter: lifecycle.Filter{Prefix: trashPrefix},
+		Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(days)},
+	}
+	for i := range config.Rules {
+		if config.Rules[i].ID == trashExpiryRuleID {
+			config.Rules[i] = rule
+			return
+		}
+	}
+	config.Rules = append(config.Rules, rule)
+}
+
+// trashEntry is an object in the trash.
+type trashEntry struct {
+	// Bucket and Key are the original location of the object.
+	Bucket    string
+	Key       string
+	DeletedAt time.Time
+	// content is the object in the trash.
+	content *ClientContent
+}
+
+// parseTrashEntry returns the entry of an object of the trash in tBucket.
+func parseTrashEntry(tBucket string, content *ClientContent) (trashEntry, bool) {
+	bucket, key := splitBucketKey(content.URL.Path)
+	if bucket != tBucket || !strings.HasPrefix(key, trashPrefix) {
+		return trashEntry{}, false
+	}
+	tokens := strings.SplitN(strings.TrimPrefix(key, trashPrefix), "/", 3)
+	if len(tokens) != 3 || tokens[1] == "" || tokens[2] == "" {
+		return trashEntry{}, false
+	}
+	deletedAt, e := time.Parse(trashStampFormat, tokens[0])
+	if e != nil {
+		return trashEntry{}, false
+	}
+	return trashEntry{Bucket: tokens[1], Key: tokens[2], DeletedAt: deletedAt, content: content}, true
+}
+
+// originalPath returns the aliased path the object was removed from.
+func (e trashEntry) originalPath(alias string) string {
+	return path.Join(alias, e.Bucket, e.Key)
+}
+
+// trashPath returns the aliased path of the object in the trash.
+func (e trashEntry) trashPath(alias string) string {
+	return path.Join(alias, e.content.URL.Path)
+}
+
+// deletedBy returns who removed the object, if known.
+func (e trashEntry) deletedBy() string {
+	for k, v := range e.content.UserMetadata {
+		if http.CanonicalHeaderKey(k) == trashMetaDeletedBy || http.CanonicalHeaderKey("X-Amz-Meta-"+k) == trashMetaDeletedBy {
+			return v
+		}
+	}
+	return ""
+}
+
+// listTrash returns the entries of the trash holding the objects of the
+// aliased url, sorted by original path and removal time. The objects
+// removed from the path of url are listed, or the whole trash if url
+// is an alias with --trash-bucket.
+func listTrash(ctx context.Context, aliasedURL, sharedBucket string) (alias string, entries []trashEntry, err *probe.Error) {
+	alias, p := url2Alias(aliasedURL)
+	if alias == "" {
+		return "", nil, errTrashUnsupported(aliasedURL)
+	}
+	bucket, key := splitBucketKey(p)
+	if bucket == "" && sharedBucket == "" {
+		return "", nil, errInvalidArgument().Trace(aliasedURL, "a bucket is required without --trash-bucket")
+	}
+	tBucket := trashBucket(sharedBucket, bucket)
+	clnt, err := newClient(path.Join(alias, tBucket, trashPrefix) + "/")
+	if err != nil {
+		return "", nil, err.Trace(aliasedURL)
+	}
+
+	for content := range clnt.List(ctx, ListOptions{Recursive: true, WithMetadata: true, ShowDir: DirNone}) {
+		if content.Err != nil {
+			return "", nil, content.Err.Trace(aliasedURL)
+		}
+		entry, ok := parseTrashEntry(tBucket, content)
+		if !ok {
+			continue
+		}
+		if bucket != "" && (entry.Bucket != bucket || !strings.HasPrefix(entry.Key, key)) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Bucket != entries[j].Bucket {
+			return entries[i].Bucket < entries[j].Bucket
+		}
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].DeletedAt.Before(entries[j].DeletedAt)
+	})
+	return alias, entries, nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+func TestParseTrashEntry(t *testing.T) {
+	testCases := []struct {
+		tBucket string
+		path    string
+		ok      bool
+		entry   trashEntry
+	}{
+		{"photos", "/photos/.trash/20220301T101112.123Z/photos/2022/a.jpg", true, trashEntry{
+			Bucket:    "photos",
+			Key:       "2022/a.jpg",
+			DeletedAt: time.Date(2022, 3, 1, 10, 11, 12, 123000000, time.UTC),
+		}},
+		{"trash", "/trash/.trash/20220301T101112.000Z/docs/a/b/", true, trashEntry{
+			Bucket:    "docs",
+			Key:       "a/b/",
+			DeletedAt: time.Date(2022, 3, 1, 10, 11, 12, 0, time.UTC),
+		}},
+		{"photos", "/photos/2022/a.jpg", false, trashEntry{}},
+		{"photos", "/other/.trash/20220301T101112.123Z/photos/a.jpg", false, trashEntry{}},
+		{"photos", "/photos/.trash/yesterday/photos/a.jpg", false, trashEntry{}},
+		{"photos", "/photos/.trash/20220301T101112.123Z/photos", false, trashEntry{}},
+	}
+	for i, tc := range testCases {
+		content := &ClientContent{URL: *newClientURL(tc.path)}
+		entry, ok := parseTrashEntry(tc.tBucket, content)
+		if ok != tc.ok {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, tc.ok, ok)
+		}
+		if !ok {
+			continue
+		}
+		if entry.Bucket != tc.entry.Bucket || entry.Key != tc.entry.Key || !entry.DeletedAt.Equal(tc.entry.DeletedAt) {
+			t.Fatalf("Test %d: expected %+v, got %+v", i+1, tc.entry, entry)
+		}
+		if p := entry.trashPath("s3"); p != "s3"+tc.path && p+"/" != "s3"+tc.path {
+			t.Fatalf("Test %d: unexpected trash path %s", i+1, p)
+		}
+	}
+}
+
+func TestTrashBinContains(t *testing.T) {
+	testCases := []struct {
+		sharedBucket string
+		path         string
+		contains     bool
+	}{
+		{"", "/photos/.trash/20220301T101112.123Z/photos/a.jpg", true},
+		{"", "/photos/a.jpg", false},
+		{"", "/photos/dir/.trash/a.jpg", false},
+		{"trash", "/trash/.trash/20220301T101112.123Z/photos/a.jpg", true},
+		{"trash", "/photos/.trash/20220301T101112.123Z/photos/a.jpg", false},
+	}
+	for i, tc := range testCases {
+		bin := &trashBin{sharedBucket: tc.sharedBucket}
+		if contains := bin.contains(&ClientContent{URL: *newClientURL(tc.path)}); contains != tc.contains {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, tc.contains, contains)
+		}
+	}
+}
+
+func TestTrashBinCheckRemove(t *testing.T) {
+	bin := &trashBin{stamp: "20220301T101112.123Z"}
+	testCases := []struct {
+		path       string
+		movedByRun bool
+		refused    bool
+	}{
+		{"/photos/a.jpg", false, false},
+		{"/photos/.trash/20220301T101112.123Z/photos/a.jpg", true, true},
+		{"/photos/.trash/20220201T101112.123Z/photos/a.jpg", false, true},
+	}
+	for i, tc := range testCases {
+		content := &ClientContent{URL: *newClientURL(tc.path)}
+		if moved := bin.movedByRun(content); moved != tc.movedByRun {
+			t.Errorf("Test %d: expected moved by run %v, got %v", i+1, tc.movedByRun, moved)
+		}
+		err := bin.checkRemove("s3", content)
+		if refused := err != nil; refused != tc.refused {
+			t.Errorf("Test %d: expected refused %v, got %v", i+1, tc.refused, err)
+		}
+		if err != nil && !strings.Contains(err.ToGoError().Error(), "already in the trash") {
+			t.Errorf("Test %d: unexpected error %v", i+1, err)
+		}
+	}
+	if err := (*trashBin)(nil).checkRemove("s3", &ClientContent{URL: *newClientURL("/photos/.trash/x")}); err != nil {
+		t.Errorf("objects are not refused without --trash, got %v", err)
+	}
+}
+
+func TestTrashMetadata(t *testing.T) {
+	metadata := trashMetadata(map[string]string{
+		"content-type":             "image/jpeg",
+		"X-Amz-Meta-Owner":         "alice",
+		"X-Amz-Meta-Mc-Trash-Path": "photos%2Fa.jpg",
+		"Etag":                     "abc",
+		"Last-Modified":            "yesterday",
+	})
+	expected := map[string]string{
+		"Content-Type":     "image/jpeg",
+		"X-Amz-Meta-Owner": "alice",
+	}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Fatalf("expected %v, got %v", expected, metadata)
+	}
+}
+
+func TestLatestTrashEntries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2022, 3, d, 0, 0, 0, 0, time.UTC) }
+	entries := []trashEntry{
+		{Bucket: "a", Key: "x", DeletedAt: day(1)},
+		{Bucket: "a", Key: "x", DeletedAt: day(2)},
+		{Bucket: "a", Key: "y", DeletedAt: day(1)},
+		{Bucket: "b", Key: "x", DeletedAt: day(1)},
+		{Bucket: "b", Key: "x", DeletedAt: day(3)},
+	}
+	latest := latestTrashEntries(entries)
+	expected := []trashEntry{entries[1], entries[2], entries[4]}
+	if !reflect.DeepEqual(latest, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, latest)
+	}
+}
+
+func TestSetTrashExpiryRule(t *testing.T) {
+	config := lifecycle.NewConfiguration()
+	config.Rules = append(config.Rules, lifecycle.Rule{ID: "other", Status: "Enabled"})
+	setTrashExpiryRule(config, 30)
+	setTrashExpiryRule(config, 7)
+	if len(config.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(config.Rules))
+	}
+	rule := config.Rules[1]
+	if rule.ID != trashExpiryRuleID || rule.RuleFilter.Prefix != trashPrefix || rule.Expiration.Days != 7 {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+}
+
+func TestSplitBucketKey(t *testing.T) {
+	testCases := []struct {
+		path, bucket, key string
+	}{
+		{"", "", ""},
+		{"/photos", "photos", ""},
+		{"/photos/", "photos", ""},
+		{"photos/2022/a.jpg", "photos", "2022/a.jpg"},
+		{"/photos/2022/", "photos", "2022/"},
+	}
+	for i, tc := range testCases {
+		if bucket, key := splitBucketKey(tc.path); bucket != tc.bucket || key != tc.key {
+			t.Fatalf("Test %d: expected %s %s, got %s %s", i+1, tc.bucket, tc.key, bucket, key)
+		}
+	}
+}
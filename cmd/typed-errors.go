@@ -83,6 +83,17 @@ var errNoMatchingHost = func(URL string) *probe.Error {
 	return probe.NewError(noMatchingHostErr(errors.New(msg))).Untrace()
 }
 
+// secretUnavailableErr is a struct, unlike the error types above, so
+// that other errors don't satisfy it in type assertions.
+type secretUnavailableErr struct {
+	error
+}
+
+var errSecretUnavailable = func(alias string, e error) *probe.Error {
+	msg := "Credentials of alias `" + alias + "` are unavailable: " + e.Error()
+	return probe.NewError(secretUnavailableErr{errors.New(msg)}).Untrace()
+}
+
 type invalidSourceErr error
 
 var errInvalidSource = func(URL string) *probe.Error {
@@ -126,3 +137,52 @@ var errConflictSSE = func(sseServer, sseKeys string) *probe.Error {
 	err := fmt.Errorf("SSE alias '%s' overlaps with SSE-C aliases '%s'", sseServer, sseKeys)
 	return probe.NewError(conflictSSEErr(err)).Untrace()
 }
+
+type invalidIndexErr error
+
+var errInvalidIndex = func(dir string) *probe.Error {
+	msg := "`" + dir + "` is not an index built by this version of `mc index build`."
+	return probe.NewError(invalidIndexErr(errors.New(msg))).Untrace()
+}
+
+type deleteProtectedErr error
+
+var errDeleteProtected = func(path string) *probe.Error {
+	msg := "`" + path + "` is protected from removal, see `mc alias protect list`."
+	return probe.NewError(deleteProtectedErr(errors.New(msg))).Untrace()
+}
+
+type deleteLimitExceededErr error
+
+var errDeleteLimitExceeded = func(deletes, total int64, limit string) *probe.Error {
+	msg := fmt.Sprintf("%d of %d objects would be removed, above the --max-delete limit of %s.", deletes, total, limit)
+	return probe.NewError(deleteLimitExceededErr(errors.New(msg))).Untrace()
+}
+
+type invalidBackupDirErr error
+
+var errInvalidBackupDir = func(dir, reason string) *probe.Error {
+	msg := "Invalid backup directory `" + dir + "`, " + reason + "."
+	return probe.NewError(invalidBackupDirErr(errors.New(msg))).Untrace()
+}
+
+type deleteBucketGuardedErr error
+
+var errDeleteBucketGuarded = func(bucket string) *probe.Error {
+	msg := "Bucket `" + bucket + "` is not removed with --max-delete or --backup-dir, remove it with `mc rb`."
+	return probe.NewError(deleteBucketGuardedErr(errors.New(msg))).Untrace()
+}
+
+type inTrashErr error
+
+var errInTrash = func(path string) *probe.Error {
+	msg := "`" + path + "` is already in the trash, see `mc trash empty`."
+	return probe.NewError(inTrashErr(errors.New(msg))).Untrace()
+}
+
+type trashUnsupportedErr error
+
+var errTrashUnsupported = func(URL string) *probe.Error {
+	msg := "The trash is only supported on object storage, `" + URL + "` is a local path."
+	return probe.NewError(trashUnsupportedErr(errors.New(msg))).Untrace()
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+// watchCoalescer merges the events of a path until it stays quiet for a
+// window: successive writes become one upload, a file written then
+// removed is never uploaded, and both halves of a rename become a single
+// event with RenamedFrom set. A merged event carries the acks of the
+// events it replaces, so the watch position only moves past them once
+// it is processed.
+type watchCoalescer struct {
+	window time.Duration
+	// stat returns the size of a source file and whether it exists, when
+	// set the flushed events are checked against the source since local
+	// events can be received out of order.
+	stat func(path string) (int64, bool)
+
+	pending map[string]*coalescedEvent
+	// Rename sources waiting for their destination, by cookie.
+	renames map[uint32]*coalescedEvent
+	// Other events, they are not delayed.
+	passthrough []EventInfo
+}
+
+type coalescedEvent struct {
+	event   EventInfo
+	updated time.Time
+	// The path has an upload pending, the target copy is outdated.
+	dirty bool
+	// Rename cookie of a destination received before its source.
+	cookie uint32
+}
+
+func newWatchCoalescer(window time.Duration) *watchCoalescer {
+	return &watchCoalescer{
+		window:  window,
+		pending: make(map[string]*coalescedEvent),
+		renames: make(map[uint32]*coalescedEvent),
+	}
+}
+
+func isCreatedEvent(event EventInfo) bool {
+	return strings.HasPrefix(string(event.Type), "s3:ObjectCreated:")
+}
+
+// Add merges events received at now.
+func (c *watchCoalescer) Add(events []EventInfo, now time.Time) {
+	for _, event := range events {
+		switch {
+		case isCreatedEvent(event):
+			c.addCreated(event, now)
+		case event.Type == notification.ObjectRemovedDelete:
+			c.addRemoved(event, now)
+		default:
+			c.passthrough = append(c.passthrough, event)
+		}
+	}
+}
+
+func (c *watchCoalescer) addCreated(event EventInfo, now time.Time) {
+	cookie := event.RenameCookie
+	event.RenameCookie = 0
+	if from, ok := c.renames[cookie]; ok && cookie != 0 {
+		delete(c.renames, cookie)
+		c.rename(from, event, now)
+		return
+	}
+	// A rename from this path can't copy its content anymore.
+	for _, from := range c.renames {
+		if from.event.Path == event.Path {
+			from.dirty = true
+		}
+	}
+	for _, p := range c.pending {
+		if p.event.RenamedFrom == event.Path {
+			c.unrename(p, now)
+		}
+	}
+	if p, ok := c.pending[event.Path]; ok {
+		if p.event.RenamedFrom != "" {
+			c.unrename(p, now)
+		}
+		event.mergeAck(p.event)
+	}
+	c.pending[event.Path] = &coalescedEvent{event: event, updated: now, dirty: true, cookie: cookie}
+}
+
+func (c *watchCoalescer) addRemoved(event EventInfo, now time.Time) {
+	cookie := event.RenameCookie
+	event.RenameCookie = 0
+	p, ok := c.pending[event.Path]
+	delete(c.pending, event.Path)
+	if ok {
+		event.mergeAck(p.event)
+	}
+	if cookie != 0 {
+		from := &coalescedEvent{event: event, updated: now}
+		if ok {
+			from.dirty = p.dirty
+			from.event.RenamedFrom = p.event.RenamedFrom
+		}
+		for _, to := range c.pending {
+			if to.cookie == cookie {
+				c.rename(from, to.event, now)
+				return
+			}
+		}
+		// Wait for the destination of the rename.
+		c.renames[cookie] = from
+		return
+	}
+	if ok && p.event.RenamedFrom != "" {
+		c.removed(p.event.RenamedFrom, now)
+	}
+	c.pending[event.Path] = &coalescedEvent{event: event, updated: now}
+}
+
+// removed records that path doesn't exist anymore.
+func (c *watchCoalescer) removed(path string, now time.Time) {
+	if _, ok := c.pending[path]; ok {
+		return
+	}
+	c.pending[path] = &coalescedEvent{
+		event:   EventInfo{Time: UTCNow().Format(watchEventTimeFormat), Path: path, Type: notification.ObjectRemovedDelete},
+		updated: now,
+	}
+}
+
+// unrename turns a pending rename into an upload.
+func (c *watchCoalescer) unrename(p *coalescedEvent, now time.Time) {
+	c.removed(p.event.RenamedFrom, now)
+	p.event.RenamedFrom = ""
+	p.dirty = true
+}
+
+func (c *watchCoalescer) rename(from *coalescedEvent, to EventInfo, now time.Time) {
+	source := from.event.Path
+	if from.event.RenamedFrom != "" {
+		// Renamed again within the window.
+		source = from.event.RenamedFrom
+	}
+	to.mergeAck(from.event)
+	if prev, ok := c.pending[to.Path]; ok {
+		if prev.event.RenamedFrom != "" {
+			c.removed(prev.event.RenamedFrom, now)
+		}
+		to.mergeAck(prev.event)
+	}
+	p := &coalescedEvent{event: to, updated: now}
+	if from.dirty {
+		// The target doesn't have the content yet, upload it.
+		p.dirty = true
+		c.removed(source, now)
+	} else {
+		p.event.RenamedFrom = source
+	}
+	c.pending[to.Path] = p
+}
+
+// Flush returns the events of the paths quiet for the window, or all of
+// them when force is set. Unpaired rename sources become removals.
+func (c *watchCoalescer) Flush(now time.Time, force bool) []EventInfo {
+	due := func(updated time.Time) bool {
+		return force || now.Sub(updated) >= c.window
+	}
+	for cookie, from := range c.renames {
+		if !due(from.updated) {
+			continue
+		}
+		delete(c.renames, cookie)
+		// Moved out of the watched tree.
+		c.removed(from.event.Path, from.updated)
+		c.pending[from.event.Path].event.mergeAck(from.event)
+		if from.event.RenamedFrom != "" {
+			c.removed(from.event.RenamedFrom, from.updated)
+		}
+	}
+
+	var ready []*coalescedEvent
+	for path, p := range c.pending {
+		if due(p.updated) {
+			ready = append(ready, p)
+			delete(c.pending, path)
+		}
+	}
+	sort.Slice(ready, func(i, j int) bool {
+		if !ready[i].updated.Equal(ready[j].updated) {
+			return ready[i].updated.Before(ready[j].updated)
+		}
+		return ready[i].event.Path < ready[j].event.Path
+	})
+
+	events := c.passthrough
+	c.passthrough = nil
+	for _, p := range ready {
+		if c.stat != nil {
+			reconciled := c.reconcile(p.event)
+			reconciled[0].ack = p.event.ack
+			events = append(events, reconciled...)
+		} else {
+			events = append(events, p.event)
+		}
+	}
+	return events
+}
+
+// reconcile updates an event to the current state of the source.
+func (c *watchCoalescer) reconcile(event EventInfo) []EventInfo {
+	size, exists := c.stat(event.Path)
+	at := func(path string, exists bool, size int64) EventInfo {
+		e := EventInfo{Time: UTCNow().Format(watchEventTimeFormat), Path: path, Type: notification.ObjectRemovedDelete}
+		if exists {
+			e.Type, e.Size = notification.ObjectCreatedPut, size
+		}
+		return e
+	}
+	if event.RenamedFrom != "" {
+		_, fromExists := c.stat(event.RenamedFrom)
+		if exists && !fromExists {
+			event.Size = size
+			return []EventInfo{event}
+		}
+		// The rename was undone or overwritten meanwhile.
+		events := []EventInfo{at(event.Path, exists, size)}
+		if !fromExists {
+			events = append(events, at(event.RenamedFrom, false, 0))
+		}
+		return events
+	}
+	if exists == isCreatedEvent(event) {
+		if exists {
+			event.Size = size
+		}
+		return []EventInfo{event}
+	}
+	return []EventInfo{at(event.Path, exists, size)}
+}
+
+// statLocalFile returns the size of a file and whether it exists.
+func statLocalFile(path string) (int64, bool) {
+	fi, e := os.Stat(path)
+	if e != nil || fi.IsDir() {
+		return 0, false
+	}
+	return fi.Size(), true
+}
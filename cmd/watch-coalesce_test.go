@@ -0,0 +1,135 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+func TestWatchCoalescer(t *testing.T) {
+	put := func(path string, cookie uint32) EventInfo {
+		return EventInfo{Path: path, Type: notification.ObjectCreatedPut, RenameCookie: cookie}
+	}
+	del := func(path string, cookie uint32) EventInfo {
+		return EventInfo{Path: path, Type: notification.ObjectRemovedDelete, RenameCookie: cookie}
+	}
+	summary := func(events []EventInfo) string {
+		var s []string
+		for _, event := range events {
+			switch {
+			case event.RenamedFrom != "":
+				s = append(s, fmt.Sprintf("mv %s %s", event.RenamedFrom, event.Path))
+			case isCreatedEvent(event):
+				s = append(s, "put "+event.Path)
+			case event.Type == notification.ObjectRemovedDelete:
+				s = append(s, "rm "+event.Path)
+			default:
+				s = append(s, string(event.Type)+" "+event.Path)
+			}
+		}
+		return strings.Join(s, ", ")
+	}
+
+	testCases := []struct {
+		events   []EventInfo
+		expected string
+	}{
+		// Repeated writes are uploaded once.
+		{[]EventInfo{put("a", 0), put("a", 0), put("a", 0)}, "put a"},
+		// A file removed before the window ends is never uploaded.
+		{[]EventInfo{put("a", 0), del("a", 0)}, "rm a"},
+		{[]EventInfo{del("a", 0), put("a", 0)}, "put a"},
+		// Renames of files mirrored already.
+		{[]EventInfo{del("a", 1), put("b", 1)}, "mv a b"},
+		{[]EventInfo{del("a", 1), put("b", 1), del("b", 2), put("c", 2)}, "mv a c"},
+		// Renames of files not uploaded yet.
+		{[]EventInfo{put("a", 0), del("a", 1), put("b", 1)}, "rm a, put b"},
+		// A rename followed by a write is uploaded.
+		{[]EventInfo{del("a", 1), put("b", 1), put("b", 0)}, "rm a, put b"},
+		// The source of a rename is written again.
+		{[]EventInfo{del("a", 1), put("b", 1), put("a", 0)}, "put a, put b"},
+		// Renamed then removed.
+		{[]EventInfo{del("a", 1), put("b", 1), del("b", 0)}, "rm a, rm b"},
+		// Moved out of or into the watched tree.
+		{[]EventInfo{del("a", 1)}, "rm a"},
+		{[]EventInfo{put("b", 1)}, "put b"},
+		// Other events aren't delayed.
+		{[]EventInfo{{Path: "bucket", Type: notification.BucketCreatedAll}, put("a", 0)}, "s3:BucketCreated:* bucket, put a"},
+	}
+
+	start := time.Now()
+	for i, tc := range testCases {
+		c := newWatchCoalescer(time.Second)
+		c.Add(tc.events, start)
+		if got := summary(c.Flush(start.Add(time.Second), false)); got != tc.expected {
+			t.Errorf("Test %d: expected %q, got %q", i+1, tc.expected, got)
+		}
+		if got := c.Flush(start.Add(time.Hour), true); len(got) != 0 {
+			t.Errorf("Test %d: unexpected events left %v", i+1, got)
+		}
+	}
+
+	// Events are held until the path is quiet for the window.
+	c := newWatchCoalescer(time.Second)
+	c.Add([]EventInfo{put("a", 0), put("b", 0)}, start)
+	c.Add([]EventInfo{put("a", 0)}, start.Add(800*time.Millisecond))
+	if got := summary(c.Flush(start.Add(time.Second), false)); got != "put b" {
+		t.Fatalf("expected only b to be ready, got %q", got)
+	}
+	if got := summary(c.Flush(start.Add(1500*time.Millisecond), false)); got != "" {
+		t.Fatalf("expected a to be held, got %q", got)
+	}
+	if got := summary(c.Flush(start.Add(1800*time.Millisecond), false)); got != "put a" {
+		t.Fatalf("expected a to be ready, got %q", got)
+	}
+
+	// Destinations of renames received before their sources.
+	c = newWatchCoalescer(time.Second)
+	c.Add([]EventInfo{put("b", 1), del("a", 1)}, start)
+	if got := summary(c.Flush(start, true)); got != "mv a b" {
+		t.Fatalf("expected a rename, got %q", got)
+	}
+}
+
+func TestWatchCoalescerReconcile(t *testing.T) {
+	exists := map[string]int64{"a": 1}
+	c := newWatchCoalescer(time.Second)
+	c.stat = func(path string) (int64, bool) {
+		size, ok := exists[path]
+		return size, ok
+	}
+	// a was renamed to b and back, the events of b are lost and the
+	// others are received out of order.
+	c.Add([]EventInfo{
+		{Path: "a", Type: notification.ObjectCreatedPut, RenameCookie: 2},
+		{Path: "b", Type: notification.ObjectRemovedDelete, RenameCookie: 2},
+		{Path: "a", Type: notification.ObjectRemovedDelete, RenameCookie: 1},
+	}, time.Now())
+	var got []string
+	for _, event := range c.Flush(time.Now(), true) {
+		got = append(got, string(event.Type)+" "+event.Path)
+	}
+	if expected := "s3:ObjectCreated:Put a,s3:ObjectRemoved:Delete b"; strings.Join(got, ",") != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,488 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+const (
+	// watchCatchUpMargin is subtracted from the last event time when
+	// listing missed events, events of the same instant are sent twice
+	// rather than lost.
+	watchCatchUpMargin = 5 * time.Second
+
+	// watchCatchUpLimit bounds the number of objects listed to catch up
+	// a gap.
+	watchCatchUpLimit = 100000
+
+	// watchStateSaveInterval throttles the writes of the state file.
+	watchStateSaveInterval = time.Second
+
+	watchEventTimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// watchState is the position of a durable watch, it is persisted to a
+// file when a path is set so that a restarted watch resumes from it.
+// The position only moves past an event once the consumer acknowledged
+// it and all the older events delivered.
+type watchState struct {
+	path string
+
+	mu      sync.Mutex
+	saved   time.Time
+	dirty   bool
+	content watchStateContent
+
+	// Times of the events delivered and not acknowledged yet, and of
+	// the latest acknowledged one.
+	pending map[uint64]time.Time
+	nextID  uint64
+	acked   time.Time
+}
+
+type watchStateContent struct {
+	Version       int       `json:"version"`
+	URL           string    `json:"url"`
+	LastEventTime time.Time `json:"lastEventTime"`
+	Sequence      uint64    `json:"sequence"`
+}
+
+// loadWatchState reads the state of a watch on targetURL, an empty path
+// keeps the state in memory only.
+func loadWatchState(path, targetURL string) (*watchState, *probe.Error) {
+	s := &watchState{path: path, content: watchStateContent{Version: 1, URL: targetURL}}
+	if path == "" {
+		return s, nil
+	}
+	data, e := os.ReadFile(path)
+	if os.IsNotExist(e) {
+		return s, nil
+	}
+	if e != nil {
+		return nil, probe.NewError(e).Trace(path)
+	}
+	var content watchStateContent
+	if e = json.Unmarshal(data, &content); e != nil {
+		return nil, probe.NewError(e).Trace(path)
+	}
+	if content.URL != targetURL {
+		return nil, probe.NewError(fmt.Errorf("state file `%s` belongs to a watch on `%s`", path, content.URL))
+	}
+	s.content = content
+	return s, nil
+}
+
+// Last returns the time of the last event processed, zero if none.
+func (s *watchState) Last() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance()
+	return s.content.LastEventTime
+}
+
+// hold records a delivered event, it returns the id acknowledging it.
+func (s *watchState) hold(eventTime time.Time) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil {
+		s.pending = make(map[uint64]time.Time)
+	}
+	s.nextID++
+	s.pending[s.nextID] = eventTime
+	return s.nextID
+}
+
+// ack records that the consumer processed a delivered event.
+func (s *watchState) ack(id uint64) {
+	s.mu.Lock()
+	eventTime, ok := s.pending[id]
+	if ok {
+		delete(s.pending, id)
+		if eventTime.After(s.acked) {
+			s.acked = eventTime
+		}
+		s.content.Sequence++
+		s.dirty = true
+	}
+	s.mu.Unlock()
+	if ok {
+		s.saveIfDue()
+	}
+}
+
+// advance moves the position to the latest acknowledged event, but
+// before the oldest event still processed.
+func (s *watchState) advance() {
+	last := s.acked
+	for _, eventTime := range s.pending {
+		if !eventTime.After(last) {
+			last = eventTime.Add(-time.Nanosecond)
+		}
+	}
+	if last.After(s.content.LastEventTime) {
+		s.content.LastEventTime = last
+	}
+}
+
+// Save writes the state file if it changed.
+func (s *watchState) Save() *probe.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" || !s.dirty {
+		return nil
+	}
+	s.advance()
+	data, e := json.Marshal(s.content)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if e = os.MkdirAll(filepath.Dir(s.path), 0o700); e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	tmp := s.path + ".tmp"
+	if e = os.WriteFile(tmp, data, 0o600); e != nil {
+		return probe.NewError(e).Trace(tmp)
+	}
+	if e = os.Rename(tmp, s.path); e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	s.dirty = false
+	s.saved = time.Now()
+	return nil
+}
+
+func (s *watchState) saveIfDue() {
+	s.mu.Lock()
+	due := s.dirty && time.Since(s.saved) >= watchStateSaveInterval
+	s.mu.Unlock()
+	if due {
+		errorIf(s.Save(), "Unable to save the watch state.")
+	}
+}
+
+// isWatchErrorFatal returns whether a watch error can't be fixed by
+// reconnecting.
+func isWatchErrorFatal(err *probe.Error) bool {
+	if _, ok := err.ToGoError().(APINotImplemented); ok {
+		return true
+	}
+	switch minio.ToErrorResponse(err.ToGoError()).Code {
+	case "AccessDenied", "NoSuchBucket", "InvalidBucketName", "InvalidAccessKeyId",
+		"SignatureDoesNotMatch", "NotImplemented":
+		return true
+	}
+	return false
+}
+
+func parseWatchEventTime(t string) time.Time {
+	eventTime, e := time.Parse(time.RFC3339Nano, t)
+	if e != nil {
+		return UTCNow()
+	}
+	return eventTime
+}
+
+// durableWatch watches clnt like Client.Watch and survives connection
+// losses: it reconnects with backoff and lists the objects changed
+// during the gap to send the missed events. Events are delivered at
+// least once, duplicates are possible around a gap.
+func durableWatch(ctx context.Context, clnt Client, options WatchOptions, state *watchState) (*WatchObject, *probe.Error) {
+	wo, err := clnt.Watch(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	dw := &durableWatcher{
+		clnt:    clnt,
+		options: options,
+		state:   state,
+		started: UTCNow(),
+		remote:  clnt.GetURL().Type == objectStorage,
+		out: &WatchObject{
+			EventInfoChan: make(chan []EventInfo),
+			ErrorChan:     make(chan *probe.Error),
+			DoneChan:      make(chan struct{}),
+		},
+	}
+	go dw.run(ctx, wo)
+	return dw.out, nil
+}
+
+type durableWatcher struct {
+	clnt    Client
+	options WatchOptions
+	state   *watchState
+	started time.Time
+	// Time of the last event sent, where a reconnected watch catches
+	// up from.
+	delivered time.Time
+	// Only remote watches lose their connection, local ones are
+	// caught up on start only.
+	remote bool
+	out    *WatchObject
+}
+
+var errWatchStopped = errors.New("watch stopped")
+
+// send delivers events to the consumer, the state advances as the
+// consumer acknowledges them with EventInfo.Ack.
+func (dw *durableWatcher) send(events []EventInfo) error {
+	for i := range events {
+		eventTime := parseWatchEventTime(events[i].Time)
+		if eventTime.After(dw.delivered) {
+			dw.delivered = eventTime
+		}
+		id := dw.state.hold(eventTime)
+		events[i].ack = func() { dw.state.ack(id) }
+	}
+	select {
+	case dw.out.EventInfoChan <- events:
+	case <-dw.out.DoneChan:
+		return errWatchStopped
+	}
+	return nil
+}
+
+func (dw *durableWatcher) sendError(err *probe.Error) {
+	select {
+	case dw.out.ErrorChan <- err:
+	case <-dw.out.DoneChan:
+	}
+}
+
+// abandonWatch stops an underlying watch, its pending messages are discarded.
+func abandonWatch(wo *WatchObject) {
+	close(wo.DoneChan)
+	go func() {
+		for range wo.Events() {
+		}
+	}()
+	go func() {
+		for range wo.Errors() {
+		}
+	}()
+}
+
+func (dw *durableWatcher) run(ctx context.Context, wo *WatchObject) {
+	defer close(dw.out.ErrorChan)
+	defer close(dw.out.EventInfoChan)
+	defer func() {
+		errorIf(dw.state.Save(), "Unable to save the watch state.")
+	}()
+
+	// Resume from a previous run.
+	if last := dw.state.Last(); !last.IsZero() {
+		if dw.catchUp(ctx, last) != nil {
+			abandonWatch(wo)
+			return
+		}
+	}
+
+	for {
+		lost := false
+		select {
+		case events, ok := <-wo.Events():
+			if !ok {
+				lost = true
+				break
+			}
+			if dw.send(events) != nil {
+				abandonWatch(wo)
+				return
+			}
+		case err, ok := <-wo.Errors():
+			if !ok {
+				lost = true
+				break
+			}
+			if !dw.remote || isWatchErrorFatal(err) {
+				dw.sendError(err)
+				if dw.remote {
+					abandonWatch(wo)
+					return
+				}
+				continue
+			}
+			errorIf(err.Trace(dw.clnt.GetURL().String()), "Watch connection lost, reconnecting.")
+			lost = true
+		case <-dw.out.DoneChan:
+			abandonWatch(wo)
+			return
+		case <-ctx.Done():
+			abandonWatch(wo)
+			return
+		}
+		if !lost {
+			continue
+		}
+		if !dw.remote {
+			// A local watch never ends by itself.
+			return
+		}
+		abandonWatch(wo)
+		if wo = dw.reconnect(ctx); wo == nil {
+			return
+		}
+	}
+}
+
+// reconnect watches again with backoff and sends the events missed in
+// between, it returns nil once the watch is stopped.
+func (dw *durableWatcher) reconnect(ctx context.Context) *WatchObject {
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-time.After(watchBackoff(attempt)):
+		case <-dw.out.DoneChan:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+		wo, err := dw.clnt.Watch(ctx, dw.options)
+		if err != nil {
+			if isWatchErrorFatal(err) {
+				dw.sendError(err)
+				return nil
+			}
+			errorIf(err.Trace(dw.clnt.GetURL().String()), "Unable to reconnect the watch, retrying.")
+			continue
+		}
+		since := dw.delivered
+		if since.IsZero() {
+			since = dw.state.Last()
+		}
+		if since.IsZero() {
+			since = dw.started
+		}
+		if dw.catchUp(ctx, since) != nil {
+			abandonWatch(wo)
+			return nil
+		}
+		return wo
+	}
+}
+
+func (dw *durableWatcher) wants(event string) bool {
+	for _, e := range dw.options.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// watchKey returns the path of content relative to the watched URL,
+// which prefix and suffix filters apply to.
+func (dw *durableWatcher) watchKey(content *ClientContent) string {
+	root := dw.clnt.GetURL()
+	key := strings.TrimPrefix(content.URL.Path, root.Path)
+	return strings.TrimLeft(key, string(root.Separator))
+}
+
+// catchUp lists the objects to send the events missed since a time:
+// objects modified after it are reported as created and, on versioned
+// buckets, objects which existed then but not anymore as removed.
+func (dw *durableWatcher) catchUp(ctx context.Context, since time.Time) error {
+	wantPut, wantDelete := dw.wants("put"), dw.wants("delete")
+	if !wantPut && !wantDelete {
+		return nil
+	}
+	since = since.Add(-watchCatchUpMargin)
+	// A local watch which isn't recursive only sees its direct entries.
+	shallow := !dw.remote && !dw.options.Recursive
+	matches := func(content *ClientContent) bool {
+		key := dw.watchKey(content)
+		if shallow && strings.ContainsRune(key, rune(dw.clnt.GetURL().Separator)) {
+			return false
+		}
+		return !content.Type.IsDir() &&
+			strings.HasPrefix(key, dw.options.Prefix) &&
+			strings.HasSuffix(key, dw.options.Suffix)
+	}
+
+	current := make(map[string]struct{})
+	listed := 0
+	for content := range dw.clnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirNone}) {
+		if content.Err != nil {
+			errorIf(content.Err.Trace(dw.clnt.GetURL().String()), "Unable to list the objects missed by the watch.")
+			return nil
+		}
+		if !matches(content) {
+			continue
+		}
+		if listed++; listed > watchCatchUpLimit {
+			errorIf(errDummy().Trace(dw.clnt.GetURL().String()),
+				"Too many objects to catch up the watch, only the first %d were checked.", watchCatchUpLimit)
+			return nil
+		}
+		current[content.URL.String()] = struct{}{}
+		if !wantPut || !content.Time.After(since) {
+			continue
+		}
+		event := EventInfo{
+			Time: content.Time.UTC().Format(watchEventTimeFormat),
+			Size: content.Size,
+			Path: content.URL.String(),
+			Type: notification.ObjectCreatedPut,
+		}
+		if e := dw.send([]EventInfo{event}); e != nil {
+			return e
+		}
+	}
+
+	if !wantDelete || !dw.remote {
+		return nil
+	}
+	// Listing as of the gap start returns the versions current then.
+	listed = 0
+	for content := range dw.clnt.List(ctx, ListOptions{Recursive: true, TimeRef: since, ShowDir: DirNone}) {
+		if content.Err != nil {
+			// Unversioned buckets and older servers don't support it.
+			return nil
+		}
+		if !matches(content) {
+			continue
+		}
+		if listed++; listed > watchCatchUpLimit {
+			return nil
+		}
+		if _, ok := current[content.URL.String()]; ok {
+			continue
+		}
+		event := EventInfo{
+			Time: UTCNow().Format(watchEventTimeFormat),
+			Path: content.URL.String(),
+			Type: notification.ObjectRemovedDelete,
+		}
+		if e := dw.send([]EventInfo{event}); e != nil {
+			return e
+		}
+	}
+	return nil
+}
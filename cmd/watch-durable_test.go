@@ -0,0 +1,135 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestWatchState(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state", "watch.json")
+	state, err := loadWatchState(statePath, "play/bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Last().IsZero() {
+		t.Fatalf("expected no position, got %v", state.Last())
+	}
+	last := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	state.ack(state.hold(last))
+	state.ack(state.hold(last.Add(-time.Hour)))
+	if err = state.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = loadWatchState(statePath, "play/bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Last().Equal(last) || state.content.Sequence != 2 {
+		t.Fatalf("unexpected state %+v", state.content)
+	}
+	if _, err = loadWatchState(statePath, "play/other"); err == nil {
+		t.Fatal("expected an error for a state file of another target")
+	}
+}
+
+func TestWatchStateAck(t *testing.T) {
+	state, _ := loadWatchState("", "play/bucket")
+	first := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+	firstID, secondID := state.hold(first), state.hold(second)
+	if !state.Last().IsZero() {
+		t.Fatalf("expected no position before any ack, got %v", state.Last())
+	}
+
+	// The position must not pass an event still processed.
+	state.ack(secondID)
+	if !state.Last().Before(first) {
+		t.Fatalf("expected a position before %v, got %v", first, state.Last())
+	}
+	state.ack(firstID)
+	if !state.Last().Equal(second) {
+		t.Fatalf("expected position %v, got %v", second, state.Last())
+	}
+}
+
+func TestDurableWatchCatchUp(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"old.txt", "new.txt", "new.log", "sub/nested.txt"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o700)
+		if e := os.WriteFile(path, []byte(name), 0o600); e != nil {
+			t.Fatal(e)
+		}
+	}
+	os.Chtimes(filepath.Join(dir, "old.txt"), old, old)
+
+	clnt, err := fsNew(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _ := loadWatchState("", dir)
+	state.ack(state.hold(time.Now().Add(-10 * time.Minute)))
+
+	wo, err := durableWatch(context.Background(), clnt, WatchOptions{Events: []string{"put"}, Suffix: ".txt"}, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(wo.DoneChan)
+
+	select {
+	case events := <-wo.Events():
+		if len(events) != 1 || events[0].Path != filepath.Join(dir, "new.txt") || events[0].Size != 7 {
+			t.Fatalf("unexpected events %+v", events)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("missed events were not sent")
+	}
+	select {
+	case events := <-wo.Events():
+		t.Fatalf("unexpected events %+v", events)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestIsWatchErrorFatal(t *testing.T) {
+	testCases := []struct {
+		err   error
+		fatal bool
+	}{
+		{APINotImplemented{API: "Watch"}, true},
+		{minio.ErrorResponse{Code: "NoSuchBucket"}, true},
+		{minio.ErrorResponse{Code: "SlowDown"}, false},
+		{errors.New("unexpected EOF"), false},
+	}
+	for i, tc := range testCases {
+		if got := isWatchErrorFatal(probe.NewError(tc.err)); got != tc.fatal {
+			t.Errorf("Test %d: expected %v, got %v", i+1, tc.fatal, got)
+		}
+	}
+}
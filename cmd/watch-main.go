@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/fatih/color"
@@ -30,6 +31,7 @@ import (
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7/pkg/notification"
 	"github.com/minio/pkg/console"
+	"github.com/minio/pkg/env"
 )
 
 var watchFlags = []cli.Flag{
@@ -50,6 +52,58 @@ var watchFlags = []cli.Flag{
 		Name:  "recursive",
 		Usage: "recursively watch for events",
 	},
+	cli.StringFlag{
+		Name:  "webhook",
+		Usage: "forward events to an HTTP endpoint as JSON arrays",
+	},
+	cli.StringFlag{
+		Name:  "webhook-secret",
+		Usage: "sign webhook payloads with HMAC-SHA256 in the " + watchSinkHMACHeader + " header",
+	},
+	cli.StringFlag{
+		Name:  "exec",
+		Usage: "run a command for each event, {} is replaced by the object path",
+	},
+	cli.StringFlag{
+		Name:  "redis",
+		Usage: "forward events to a Redis stream, list or channel",
+	},
+	cli.StringFlag{
+		Name:  "nats",
+		Usage: "publish events on a NATS subject",
+	},
+	cli.StringFlag{
+		Name:  "jsonl",
+		Usage: "append events to a JSON lines file",
+	},
+	cli.StringFlag{
+		Name:  "jsonl-max-size",
+		Value: "100MiB",
+		Usage: "rotate the JSON lines file once it reaches this size",
+	},
+	cli.IntFlag{
+		Name:  "batch-size",
+		Value: 1,
+		Usage: "number of events delivered to sinks at once",
+	},
+	cli.DurationFlag{
+		Name:  "batch-interval",
+		Value: time.Second,
+		Usage: "deliver incomplete batches after this delay",
+	},
+	cli.IntFlag{
+		Name:  "retries",
+		Value: 5,
+		Usage: "delivery retries before events are dropped, spooled events are retried forever",
+	},
+	cli.StringFlag{
+		Name:  "spool",
+		Usage: "keep events in a directory until sinks acknowledge them",
+	},
+	cli.StringFlag{
+		Name:  "state",
+		Usage: "save the watch position in a file to resume from it on restart",
+	},
 }
 
 var watchCmd = cli.Command{
@@ -68,6 +122,22 @@ USAGE:
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
+ENVIRONMENT VARIABLES:
+  MC_WATCH_WEBHOOK_SECRET: secret to sign webhook payloads, if --webhook-secret is not set
+
+SINKS:
+  Events are printed and forwarded to every configured sink. Delivery is at least once.
+  --webhook   http(s)://host/path, the signature is "sha256=" followed by the hex HMAC
+  --exec      placeholders are {}, {base}, {dir}, {event}, {size} and {time}
+  --redis     redis(s)://[:password@]host:port[/db][?stream=KEY|list=KEY|channel=KEY]
+  --nats      nats://[user:password@|token@]host:port/subject
+  --jsonl     path of the file, rotated files get a timestamp suffix
+
+DURABILITY:
+  A lost connection is retried with backoff, then objects changed meanwhile are listed
+  to send the missed events. With --state, a restarted watch also catches up the events
+  since the last one it printed or queued. Events may be sent more than once around a gap.
+
 EXAMPLES:
   1. Watch new S3 operations on a MinIO server
      {{.Prompt}} {{.HelpName}} play/testbucket
@@ -86,6 +156,20 @@ EXAMPLES:
 
   6. Watch for events on local directory.
      {{.Prompt}} {{.HelpName}} /usr/share
+
+  7. Forward events to a signed webhook in batches of 100, spooling them while the endpoint is down.
+     {{.Prompt}} {{.HelpName}} --webhook https://hooks.example.com/minio --webhook-secret mysecret \
+           --batch-size 100 --spool ~/.mc/spool play/testbucket
+
+  8. Generate a thumbnail for each new image.
+     {{.Prompt}} {{.HelpName}} --events put --suffix ".jpg" --exec "mc cp {} /tmp/thumbnails/{base}" play/photos
+
+  9. Bridge events to a Redis stream and to JSON lines files rotated at 64MiB.
+     {{.Prompt}} {{.HelpName}} --redis "redis://localhost:6379?stream=minio-events" \
+           --jsonl /var/log/mc/events.jsonl --jsonl-max-size 64MiB --quiet play/testbucket
+
+  10. Resume watching where the previous run stopped, events missed while it was down are sent first.
+      {{.Prompt}} {{.HelpName}} --state ~/.mc/watch-testbucket.json --webhook https://hooks.example.com/minio play/testbucket
 `,
 }
 
@@ -162,8 +246,26 @@ func mainWatch(cliCtx *cli.Context) error {
 	ctx, cancelWatch := context.WithCancel(globalContext)
 	defer cancelWatch()
 
+	sinks, err := watchSinksFromContext(cliCtx)
+	fatalIf(err, "Unable to initialize the event sinks.")
+	// With sinks configured, --quiet only forwards the events.
+	printEvents := !cliCtx.Bool("quiet") && !cliCtx.GlobalBool("quiet")
+	var forwarder *watchForwarder
+	if len(sinks) > 0 {
+		forwarder, err = newWatchForwarder(sinks, cliCtx.String("spool"), cliCtx.Int("batch-size"),
+			cliCtx.Duration("batch-interval"), cliCtx.Int("retries"))
+		fatalIf(err, "Unable to initialize the event spool.")
+		defer forwarder.Close()
+	}
+
+	state, err := loadWatchState(cliCtx.String("state"), s3Client.GetURL().String())
+	fatalIf(err, "Unable to load the watch state.")
+	defer func() {
+		errorIf(state.Save(), "Unable to save the watch state.")
+	}()
+
 	// Start watching on events
-	wo, err := s3Client.Watch(ctx, options)
+	wo, err := durableWatch(ctx, s3Client, options, state)
 	fatalIf(err, "Unable to watch on the specified bucket.")
 
 	// Initialize.. waitgroup to track the go-routine.
@@ -196,7 +298,13 @@ func mainWatch(cliCtx *cli.Context) error {
 					msg.Source.Host = event.Host
 					msg.Source.Port = event.Port
 					msg.Source.UserAgent = event.UserAgent
-					printMsg(msg)
+					if forwarder == nil || printEvents {
+						printMsg(msg)
+					}
+					if forwarder != nil {
+						forwarder.Forward(msg)
+					}
+					event.Ack()
 				}
 			case err, ok := <-wo.Errors():
 				if !ok {
@@ -215,3 +323,45 @@ func mainWatch(cliCtx *cli.Context) error {
 
 	return nil
 }
+
+// watchSinksFromContext returns the sinks configured by flags.
+func watchSinksFromContext(ctx *cli.Context) (sinks []watchSink, err *probe.Error) {
+	add := func(sink watchSink, e error) {
+		if e != nil && err == nil {
+			err = probe.NewError(e)
+		}
+		if e == nil {
+			sinks = append(sinks, sink)
+		}
+	}
+	if endpoint := ctx.String("webhook"); endpoint != "" {
+		secret := ctx.String("webhook-secret")
+		if secret == "" {
+			secret = env.Get("MC_WATCH_WEBHOOK_SECRET", "")
+		}
+		add(newWebhookSink(endpoint, secret))
+	}
+	if command := ctx.String("exec"); command != "" {
+		add(newExecSink(command))
+	}
+	if endpoint := ctx.String("redis"); endpoint != "" {
+		add(newRedisSink(endpoint))
+	}
+	if endpoint := ctx.String("nats"); endpoint != "" {
+		add(newNATSSink(endpoint))
+	}
+	if filePath := ctx.String("jsonl"); filePath != "" {
+		maxSize, e := humanize.ParseBytes(ctx.String("jsonl-max-size"))
+		if e != nil {
+			return nil, probe.NewError(e).Trace(ctx.String("jsonl-max-size"))
+		}
+		add(newJSONLSink(filePath, int64(maxSize)))
+	}
+	if err != nil {
+		for _, sink := range sinks {
+			sink.Close()
+		}
+		return nil, err
+	}
+	return sinks, nil
+}
@@ -0,0 +1,544 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/shlex"
+	"github.com/minio/pkg/console"
+)
+
+// watchSinkHMACHeader carries the HMAC-SHA256 signature of webhook payloads.
+const watchSinkHMACHeader = "X-Mc-Signature"
+
+// watchSink delivers batches of events to a destination.
+type watchSink interface {
+	// Name identifies the sink in messages and spool directories.
+	Name() string
+	// Send delivers events, an error means none or only some of them
+	// were delivered and the batch is sent again.
+	Send(ctx context.Context, events []watchMessage) error
+	Close() error
+}
+
+// marshalWatchEvent encodes an event as a single line of JSON.
+func marshalWatchEvent(event watchMessage) ([]byte, error) {
+	event.Status = "success"
+	return json.Marshal(event)
+}
+
+// webhookSink posts events as a JSON array to an HTTP endpoint.
+type webhookSink struct {
+	endpoint string
+	secret   string
+	client   *http.Client
+}
+
+func newWebhookSink(endpoint, secret string) (*webhookSink, error) {
+	u, e := url.Parse(endpoint)
+	if e != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook endpoint `%s`", endpoint)
+	}
+	return &webhookSink{endpoint: endpoint, secret: secret, client: httpClient(30 * time.Second)}, nil
+}
+
+func (s *webhookSink) Name() string {
+	return "webhook"
+}
+
+// signWatchPayload returns the value of the signature header of a payload.
+func signWatchPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *webhookSink) Send(ctx context.Context, events []watchMessage) error {
+	for i := range events {
+		events[i].Status = "success"
+	}
+	payload, e := json.Marshal(events)
+	if e != nil {
+		return e
+	}
+	req, e := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if e != nil {
+		return e
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mc/"+ReleaseTag)
+	if s.secret != "" {
+		req.Header.Set(watchSinkHMACHeader, signWatchPayload(s.secret, payload))
+	}
+	resp, e := s.client.Do(req)
+	if e != nil {
+		return e
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook replied %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// execSink runs a command for each event, `{}` and the other
+// placeholders are replaced by the event fields.
+type execSink struct {
+	args []string
+}
+
+func newExecSink(command string) (*execSink, error) {
+	args, e := shlex.Split(command)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse --exec: %w", e)
+	}
+	if len(args) == 0 {
+		return nil, errors.New("--exec command is empty")
+	}
+	return &execSink{args: args}, nil
+}
+
+func (s *execSink) Name() string {
+	return "exec"
+}
+
+// watchExecReplace substitutes the placeholders of an exec argument.
+func watchExecReplace(arg string, event watchMessage) string {
+	return strings.NewReplacer(
+		"{}", event.Event.Path,
+		"{base}", path.Base(filepath.ToSlash(event.Event.Path)),
+		"{dir}", path.Dir(filepath.ToSlash(event.Event.Path)),
+		"{event}", string(event.Event.Type),
+		"{size}", strconv.FormatInt(event.Event.Size, 10),
+		"{time}", event.Event.Time,
+	).Replace(arg)
+}
+
+func (s *execSink) Send(ctx context.Context, events []watchMessage) error {
+	for i, event := range events {
+		args := make([]string, len(s.args))
+		for j, arg := range s.args {
+			args[j] = watchExecReplace(arg, event)
+		}
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if e := cmd.Run(); e != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				e = fmt.Errorf("%w: %s", e, msg)
+			}
+			// Events before the failing one were handled.
+			return &partialSendError{sent: i, err: e}
+		}
+		console.PrintC(stdout.String())
+	}
+	return nil
+}
+
+func (s *execSink) Close() error {
+	return nil
+}
+
+// partialSendError reports how many events of a batch were delivered
+// before a failure, they aren't sent again.
+type partialSendError struct {
+	sent int
+	err  error
+}
+
+func (e *partialSendError) Error() string {
+	return e.err.Error()
+}
+
+func (e *partialSendError) Unwrap() error {
+	return e.err
+}
+
+// streamSink holds the connection of a sink speaking a line protocol
+// over TCP, it reconnects after errors.
+type streamSink struct {
+	address string
+	useTLS  bool
+	conn    net.Conn
+	reader  *bufio.Reader
+}
+
+func (s *streamSink) dial(ctx context.Context) error {
+	if s.conn != nil {
+		return nil
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var e error
+	if s.useTLS {
+		conn, e = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{RootCAs: globalRootCAs, MinVersion: tls.VersionTLS12}}).DialContext(ctx, "tcp", s.address)
+	} else {
+		conn, e = dialer.DialContext(ctx, "tcp", s.address)
+	}
+	if e != nil {
+		return e
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+	return nil
+}
+
+func (s *streamSink) reset() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func (s *streamSink) Close() error {
+	s.reset()
+	return nil
+}
+
+func (s *streamSink) readLine() (string, error) {
+	line, e := s.reader.ReadString('\n')
+	if e != nil {
+		return "", e
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// redisSink appends events to a Redis stream, list or channel.
+type redisSink struct {
+	streamSink
+	password string
+	db       int
+	command  string
+	key      string
+}
+
+// newRedisSink parses redis://[:password@]host:port[/db][?stream|list|channel=key],
+// events go to the stream `mc:events` by default.
+func newRedisSink(endpoint string) (*redisSink, error) {
+	u, e := url.Parse(endpoint)
+	if e != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+		return nil, fmt.Errorf("invalid redis endpoint `%s`", endpoint)
+	}
+	s := &redisSink{streamSink: streamSink{address: u.Host, useTLS: u.Scheme == "rediss"}, command: "XADD", key: "mc:events"}
+	if u.Port() == "" {
+		s.address = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		s.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if s.db, e = strconv.Atoi(db); e != nil {
+			return nil, fmt.Errorf("invalid redis database `%s`", db)
+		}
+	}
+	q := u.Query()
+	for param, command := range map[string]string{"stream": "XADD", "list": "RPUSH", "channel": "PUBLISH"} {
+		if key := q.Get(param); key != "" {
+			s.command, s.key = command, key
+		}
+	}
+	return s, nil
+}
+
+func (s *redisSink) Name() string {
+	return "redis"
+}
+
+// writeRESP writes a command in the Redis serialization protocol.
+func writeRESP(w io.Writer, args ...string) {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
+
+// readRESP reads a reply, error replies are returned as errors.
+func (s *redisSink) readRESP() error {
+	line, e := s.readLine()
+	if e != nil {
+		return e
+	}
+	if line == "" {
+		return errors.New("empty reply from redis")
+	}
+	switch line[0] {
+	case '-':
+		return errors.New(line[1:])
+	case '$':
+		n, e := strconv.Atoi(line[1:])
+		if e != nil {
+			return e
+		}
+		if n >= 0 {
+			_, e = io.CopyN(io.Discard, s.reader, int64(n)+2)
+		}
+		return e
+	case '*':
+		n, e := strconv.Atoi(line[1:])
+		if e != nil {
+			return e
+		}
+		for i := 0; i < n; i++ {
+			if e = s.readRESP(); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func (s *redisSink) Send(ctx context.Context, events []watchMessage) (err error) {
+	connected := s.conn != nil
+	if err = s.dial(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			s.reset()
+		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetDeadline(deadline)
+	} else {
+		s.conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	// Commands are pipelined, replies are read after the whole batch.
+	var buf bytes.Buffer
+	replies := len(events)
+	if !connected {
+		if s.password != "" {
+			writeRESP(&buf, "AUTH", s.password)
+			replies++
+		}
+		if s.db != 0 {
+			writeRESP(&buf, "SELECT", strconv.Itoa(s.db))
+			replies++
+		}
+	}
+	for _, event := range events {
+		payload, e := marshalWatchEvent(event)
+		if e != nil {
+			return e
+		}
+		switch s.command {
+		case "XADD":
+			writeRESP(&buf, "XADD", s.key, "*", "event", string(payload))
+		default:
+			writeRESP(&buf, s.command, s.key, string(payload))
+		}
+	}
+	if _, err = s.conn.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	for i := 0; i < replies; i++ {
+		if e := s.readRESP(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// natsSink publishes events on a NATS subject.
+type natsSink struct {
+	streamSink
+	connect []byte
+	subject string
+}
+
+// newNATSSink parses nats://[user:password@|token@]host:port/subject,
+// events are published on `mc.events` by default.
+func newNATSSink(endpoint string) (*natsSink, error) {
+	u, e := url.Parse(endpoint)
+	if e != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Host == "" {
+		return nil, fmt.Errorf("invalid nats endpoint `%s`", endpoint)
+	}
+	s := &natsSink{streamSink: streamSink{address: u.Host, useTLS: u.Scheme == "tls"}, subject: "mc.events"}
+	if u.Port() == "" {
+		s.address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if subject := strings.Trim(u.Path, "/"); subject != "" {
+		s.subject = strings.ReplaceAll(subject, "/", ".")
+	}
+	opts := map[string]interface{}{"verbose": false, "pedantic": false, "name": "mc", "lang": "go"}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			opts["user"], opts["pass"] = u.User.Username(), password
+		} else {
+			opts["auth_token"] = u.User.Username()
+		}
+	}
+	if s.connect, e = json.Marshal(opts); e != nil {
+		return nil, e
+	}
+	return s, nil
+}
+
+func (s *natsSink) Name() string {
+	return "nats"
+}
+
+func (s *natsSink) Send(ctx context.Context, events []watchMessage) (err error) {
+	connected := s.conn != nil
+	if err = s.dial(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			s.reset()
+		}
+	}()
+	s.conn.SetDeadline(time.Now().Add(time.Minute))
+
+	var buf bytes.Buffer
+	if !connected {
+		// The server greets new connections with INFO.
+		if _, err = s.readLine(); err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "CONNECT %s\r\n", s.connect)
+	}
+	for _, event := range events {
+		payload, e := marshalWatchEvent(event)
+		if e != nil {
+			return e
+		}
+		fmt.Fprintf(&buf, "PUB %s %d\r\n%s\r\n", s.subject, len(payload), payload)
+	}
+	// A PONG confirms the server processed the messages.
+	buf.WriteString("PING\r\n")
+	if _, err = s.conn.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	for {
+		line, e := s.readLine()
+		if e != nil {
+			return e
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err = io.WriteString(s.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// jsonlSink appends events to a JSON lines file, rotated once it
+// reaches the maximum size.
+type jsonlSink struct {
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+}
+
+func newJSONLSink(filePath string, maxSize int64) (*jsonlSink, error) {
+	if e := os.MkdirAll(filepath.Dir(filePath), 0o700); e != nil {
+		return nil, e
+	}
+	s := &jsonlSink{path: filePath, maxSize: maxSize}
+	return s, s.open()
+}
+
+func (s *jsonlSink) Name() string {
+	return "jsonl"
+}
+
+func (s *jsonlSink) open() error {
+	f, e := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if e != nil {
+		return e
+	}
+	st, e := f.Stat()
+	if e != nil {
+		f.Close()
+		return e
+	}
+	s.file, s.size = f, st.Size()
+	return nil
+}
+
+// rotate renames the current file with a timestamp and starts a new one.
+func (s *jsonlSink) rotate() error {
+	if e := s.file.Close(); e != nil {
+		return e
+	}
+	ext := filepath.Ext(s.path)
+	rotated := strings.TrimSuffix(s.path, ext) + "-" + UTCNow().Format("20060102T150405.000000000Z") + ext
+	if e := os.Rename(s.path, rotated); e != nil {
+		return e
+	}
+	return s.open()
+}
+
+func (s *jsonlSink) Send(ctx context.Context, events []watchMessage) error {
+	var buf bytes.Buffer
+	for _, event := range events {
+		payload, e := marshalWatchEvent(event)
+		if e != nil {
+			return e
+		}
+		buf.Write(payload)
+		buf.WriteByte('\n')
+	}
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(buf.Len()) > s.maxSize {
+		if e := s.rotate(); e != nil {
+			return e
+		}
+	}
+	n, e := s.file.Write(buf.Bytes())
+	s.size += int64(n)
+	return e
+}
+
+func (s *jsonlSink) Close() error {
+	return s.file.Close()
+}
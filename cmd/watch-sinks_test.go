@@ -0,0 +1,263 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testWatchEvent(path string) watchMessage {
+	var msg watchMessage
+	msg.Event.Path = path
+	msg.Event.Size = 10
+	msg.Event.Type = "s3:ObjectCreated:Put"
+	msg.Event.Time = "2022-01-01T00:00:00.000Z"
+	return msg
+}
+
+func TestWatchWebhookForwarder(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+		calls    int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			// The first delivery fails and is retried.
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get(watchSinkHMACHeader) != signWatchPayload("secret", payload) {
+			t.Errorf("invalid signature %q", r.Header.Get(watchSinkHMACHeader))
+		}
+		var events []watchMessage
+		if e := json.Unmarshal(payload, &events); e != nil {
+			t.Errorf("invalid payload %s: %v", payload, e)
+		}
+		for _, event := range events {
+			received = append(received, event.Event.Path)
+		}
+	}))
+	defer srv.Close()
+
+	sink, e := newWebhookSink(srv.URL, "secret")
+	if e != nil {
+		t.Fatal(e)
+	}
+	f, err := newWatchForwarder([]watchSink{sink}, "", 2, 10*time.Millisecond, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Forward(testWatchEvent("bucket/a"))
+	f.Forward(testWatchEvent("bucket/b"))
+	f.Forward(testWatchEvent("bucket/c"))
+	f.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(received, ",") != "bucket/a,bucket/b,bucket/c" {
+		t.Fatalf("unexpected events delivered %v after %d calls", received, calls)
+	}
+
+	if _, e = newWebhookSink("ftp://host", ""); e == nil {
+		t.Fatal("expected an error for an invalid endpoint")
+	}
+}
+
+func TestWatchExecReplace(t *testing.T) {
+	event := testWatchEvent("bucket/dir/photo.jpg")
+	got := watchExecReplace("{event} {dir} {base} {size} {}", event)
+	if expected := "s3:ObjectCreated:Put bucket/dir photo.jpg 10 bucket/dir/photo.jpg"; got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestWatchJSONLSink(t *testing.T) {
+	dir := t.TempDir()
+	sink, e := newJSONLSink(filepath.Join(dir, "events.jsonl"), 200)
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i := 0; i < 3; i++ {
+		if e = sink.Send(context.Background(), []watchMessage{testWatchEvent("bucket/object")}); e != nil {
+			t.Fatal(e)
+		}
+	}
+	sink.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "events*.jsonl"))
+	if len(files) != 3 {
+		t.Fatalf("expected the file to be rotated twice, got %v", files)
+	}
+	data, e := os.ReadFile(filepath.Join(dir, "events.jsonl"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	var event watchMessage
+	if e = json.Unmarshal(data, &event); e != nil || event.Event.Path != "bucket/object" {
+		t.Fatalf("unexpected content %q: %v", data, e)
+	}
+}
+
+func TestWatchQueueSpool(t *testing.T) {
+	dir := t.TempDir()
+	q, e := newWatchQueue(dir)
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if e = q.Put(testWatchEvent(name)); e != nil {
+			t.Fatal(e)
+		}
+	}
+	batch, e := q.Get(context.Background(), 2, time.Second)
+	if e != nil || len(batch) != 2 || batch[0].Event.Path != "a" {
+		t.Fatalf("unexpected batch %v: %v", batch, e)
+	}
+	q.Ack(1)
+	q.Close()
+
+	// Events not acknowledged are read again after a restart.
+	q, e = newWatchQueue(dir)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if q.Len() != 2 {
+		t.Fatalf("expected 2 spooled events, got %d", q.Len())
+	}
+	if e = q.Put(testWatchEvent("d")); e != nil {
+		t.Fatal(e)
+	}
+	q.Close()
+	batch, e = q.Get(context.Background(), 10, time.Hour)
+	if e != nil || len(batch) != 3 || batch[0].Event.Path != "b" || batch[2].Event.Path != "d" {
+		t.Fatalf("unexpected batch %v: %v", batch, e)
+	}
+	q.Ack(3)
+	if _, e = q.Get(context.Background(), 10, time.Hour); e != io.EOF {
+		t.Fatalf("expected EOF, got %v", e)
+	}
+}
+
+// serveOnce accepts one connection and runs handle on it.
+func serveOnce(t *testing.T, handle func(conn net.Conn, r *bufio.Reader)) (string, chan struct{}) {
+	l, e := net.Listen("tcp", "127.0.0.1:0")
+	if e != nil {
+		t.Fatal(e)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer l.Close()
+		conn, e := l.Accept()
+		if e != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn, bufio.NewReader(conn))
+	}()
+	return l.Addr().String(), done
+}
+
+func TestWatchRedisSink(t *testing.T) {
+	var commands []string
+	addr, done := serveOnce(t, func(conn net.Conn, r *bufio.Reader) {
+		for i := 0; i < 3; i++ {
+			line, e := r.ReadString('\n')
+			if e != nil {
+				return
+			}
+			var args []string
+			n := 0
+			json.Unmarshal([]byte(strings.TrimSpace(line[1:])), &n)
+			for j := 0; j < n; j++ {
+				r.ReadString('\n')
+				arg, _ := r.ReadString('\n')
+				args = append(args, strings.TrimSpace(arg))
+			}
+			commands = append(commands, strings.Join(args[:len(args)-1], " "))
+			if args[0] == "AUTH" {
+				io.WriteString(conn, "+OK\r\n")
+			} else {
+				io.WriteString(conn, "$3\r\n1-0\r\n")
+			}
+		}
+	})
+	sink, e := newRedisSink("redis://:pass@" + addr + "?stream=events")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer sink.Close()
+	if e = sink.Send(context.Background(), []watchMessage{testWatchEvent("a"), testWatchEvent("b")}); e != nil {
+		t.Fatal(e)
+	}
+	<-done
+	if strings.Join(commands, ";") != "AUTH;XADD events * event;XADD events * event" {
+		t.Fatalf("unexpected commands %q", commands)
+	}
+}
+
+func TestWatchNATSSink(t *testing.T) {
+	var lines []string
+	addr, done := serveOnce(t, func(conn net.Conn, r *bufio.Reader) {
+		io.WriteString(conn, "INFO {}\r\n")
+		for {
+			line, e := r.ReadString('\n')
+			if e != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "PUB ") {
+				r.ReadString('\n')
+			}
+			lines = append(lines, strings.SplitN(line, " ", 2)[0]+" "+strings.SplitN(line+" ", " ", 3)[1])
+			if line == "PING" {
+				io.WriteString(conn, "PONG\r\n")
+				return
+			}
+		}
+	})
+	sink, e := newNATSSink("nats://" + addr + "/minio/events")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer sink.Close()
+	if e = sink.Send(context.Background(), []watchMessage{testWatchEvent("a")}); e != nil {
+		t.Fatal(e)
+	}
+	<-done
+	if strings.Join(lines, ";") != "CONNECT {\"lang\":\"go\",\"name\":\"mc\",\"pedantic\":false,\"verbose\":false};PUB minio.events;PING " {
+		t.Fatalf("unexpected protocol lines %q", lines)
+	}
+}
@@ -0,0 +1,387 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+const (
+	// watchQueueCapacity is the number of events buffered in memory
+	// per sink, watching blocks once it is reached.
+	watchQueueCapacity = 10000
+
+	// watchDrainTimeout bounds the delivery of buffered events on exit.
+	watchDrainTimeout = 5 * time.Second
+
+	watchMaxBackoff = time.Minute
+)
+
+var errWatchQueueClosed = errors.New("watch queue closed")
+
+// watchQueue buffers the events of a sink until they are delivered. A
+// spooled queue keeps them in a directory, one file per event, so they
+// survive restarts and sink outages.
+type watchQueue struct {
+	dir string
+
+	mu     sync.Mutex
+	events []watchMessage
+	names  []string
+	seq    uint64
+	closed bool
+
+	notify chan struct{}
+	space  chan struct{}
+	done   chan struct{}
+}
+
+func newWatchQueue(dir string) (*watchQueue, error) {
+	q := &watchQueue{
+		dir:    dir,
+		notify: make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if dir == "" {
+		return q, nil
+	}
+	if e := os.MkdirAll(dir, 0o700); e != nil {
+		return nil, e
+	}
+	entries, e := os.ReadDir(dir)
+	if e != nil {
+		return nil, e
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			// Leftover of an interrupted write.
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		seq, e := strconv.ParseUint(strings.TrimSuffix(name, ".json"), 10, 64)
+		if e != nil || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		q.names = append(q.names, name)
+		if seq > q.seq {
+			q.seq = seq
+		}
+	}
+	sort.Strings(q.names)
+	return q, nil
+}
+
+func wakeUp(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns the number of pending events.
+func (q *watchQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.dir != "" {
+		return len(q.names)
+	}
+	return len(q.events)
+}
+
+// Put appends an event, blocking while an in-memory queue is full.
+func (q *watchQueue) Put(event watchMessage) error {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return errWatchQueueClosed
+		}
+		if q.dir != "" {
+			err := q.spool(event)
+			q.mu.Unlock()
+			wakeUp(q.notify)
+			return err
+		}
+		if len(q.events) < watchQueueCapacity {
+			q.events = append(q.events, event)
+			q.mu.Unlock()
+			wakeUp(q.notify)
+			return nil
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.space:
+		case <-q.done:
+		}
+	}
+}
+
+// spool writes an event file, callers hold the lock.
+func (q *watchQueue) spool(event watchMessage) error {
+	payload, e := marshalWatchEvent(event)
+	if e != nil {
+		return e
+	}
+	name := fmt.Sprintf("%020d.json", q.seq+1)
+	tmp := filepath.Join(q.dir, name+".tmp")
+	if e = os.WriteFile(tmp, payload, 0o600); e != nil {
+		return e
+	}
+	if e = os.Rename(tmp, filepath.Join(q.dir, name)); e != nil {
+		os.Remove(tmp)
+		return e
+	}
+	q.seq++
+	q.names = append(q.names, name)
+	return nil
+}
+
+// peek returns up to max events from the head of the queue.
+func (q *watchQueue) peek(max int) []watchMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.dir == "" {
+		if max > len(q.events) {
+			max = len(q.events)
+		}
+		return append([]watchMessage(nil), q.events[:max]...)
+	}
+	var events []watchMessage
+	for len(events) < max && len(events) < len(q.names) {
+		name := q.names[len(events)]
+		var event watchMessage
+		data, e := os.ReadFile(filepath.Join(q.dir, name))
+		if e == nil {
+			e = json.Unmarshal(data, &event)
+		}
+		if e != nil {
+			// Drop unreadable spool files instead of blocking the queue.
+			errorIf(probe.NewError(e).Trace(name), "Unable to read spooled event, skipping it.")
+			os.Remove(filepath.Join(q.dir, name))
+			q.names = append(q.names[:len(events)], q.names[len(events)+1:]...)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// Get waits for a batch of up to max events, a partial batch is returned
+// once wait elapsed after the first event. It returns io.EOF when the
+// queue is closed and empty.
+func (q *watchQueue) Get(ctx context.Context, max int, wait time.Duration) ([]watchMessage, error) {
+	var timer *time.Timer
+	var expired <-chan time.Time
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		n := q.Len()
+		q.mu.Lock()
+		closed := q.closed
+		q.mu.Unlock()
+		if n >= max || (n > 0 && (closed || wait <= 0)) {
+			return q.peek(max), nil
+		}
+		if n == 0 && closed {
+			return nil, io.EOF
+		}
+		if n > 0 && timer == nil {
+			timer = time.NewTimer(wait)
+			expired = timer.C
+		}
+		select {
+		case <-q.notify:
+		case <-expired:
+			return q.peek(max), nil
+		case <-q.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Ack removes the first n events, they were delivered.
+func (q *watchQueue) Ack(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.dir == "" {
+		if n > len(q.events) {
+			n = len(q.events)
+		}
+		q.events = q.events[n:]
+	} else {
+		if n > len(q.names) {
+			n = len(q.names)
+		}
+		for _, name := range q.names[:n] {
+			os.Remove(filepath.Join(q.dir, name))
+		}
+		q.names = q.names[n:]
+	}
+	wakeUp(q.space)
+}
+
+// Close stops accepting events, pending ones can still be read.
+func (q *watchQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+}
+
+// watchForwarder delivers events to sinks, each sink has its own queue
+// so that a failing sink doesn't hold the others back.
+type watchForwarder struct {
+	sinks     []watchSink
+	queues    []*watchQueue
+	batchSize int
+	batchWait time.Duration
+	// retries before dropping a batch, negative retries forever.
+	retries int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newWatchForwarder(sinks []watchSink, spoolDir string, batchSize int, batchWait time.Duration, retries int) (*watchForwarder, *probe.Error) {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if spoolDir != "" {
+		// Spooled events are never dropped.
+		retries = -1
+	}
+	f := &watchForwarder{sinks: sinks, batchSize: batchSize, batchWait: batchWait, retries: retries}
+	for _, sink := range sinks {
+		dir := ""
+		if spoolDir != "" {
+			dir = filepath.Join(spoolDir, sink.Name())
+		}
+		q, e := newWatchQueue(dir)
+		if e != nil {
+			return nil, probe.NewError(e).Trace(dir)
+		}
+		f.queues = append(f.queues, q)
+	}
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+	for i := range sinks {
+		f.wg.Add(1)
+		go f.run(sinks[i], f.queues[i])
+	}
+	return f, nil
+}
+
+// Forward queues an event for all sinks.
+func (f *watchForwarder) Forward(event watchMessage) {
+	for i, q := range f.queues {
+		errorIf(probe.NewError(q.Put(event)), "Unable to queue event for the %s sink.", f.sinks[i].Name())
+	}
+}
+
+// watchBackoff returns the delay before the retry following attempt.
+func watchBackoff(attempt int) time.Duration {
+	if attempt > 6 {
+		return watchMaxBackoff
+	}
+	d := time.Second << uint(attempt)
+	if d > watchMaxBackoff {
+		d = watchMaxBackoff
+	}
+	return d
+}
+
+func (f *watchForwarder) run(sink watchSink, q *watchQueue) {
+	defer f.wg.Done()
+	for {
+		batch, e := q.Get(f.ctx, f.batchSize, f.batchWait)
+		if e != nil {
+			return
+		}
+		for attempt := 0; len(batch) > 0; attempt++ {
+			e = sink.Send(f.ctx, batch)
+			if e == nil {
+				q.Ack(len(batch))
+				break
+			}
+			var partial *partialSendError
+			if errors.As(e, &partial) && partial.sent > 0 {
+				q.Ack(partial.sent)
+				batch = batch[partial.sent:]
+				attempt = 0
+			}
+			if f.ctx.Err() != nil {
+				return
+			}
+			errorIf(probe.NewError(e), "Unable to deliver %d event(s) to the %s sink.", len(batch), sink.Name())
+			if f.retries >= 0 && attempt >= f.retries {
+				errorIf(probe.NewError(e), "Dropping %d event(s) after %d retries to the %s sink.", len(batch), f.retries, sink.Name())
+				q.Ack(len(batch))
+				break
+			}
+			select {
+			case <-time.After(watchBackoff(attempt)):
+			case <-f.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Close delivers the buffered events for a short while and stops the
+// sinks, spooled events left are sent on the next run.
+func (f *watchForwarder) Close() {
+	for _, q := range f.queues {
+		q.Close()
+	}
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(watchDrainTimeout):
+		f.cancel()
+		<-done
+	}
+	f.cancel()
+	for _, sink := range f.sinks {
+		sink.Close()
+	}
+}
@@ -37,6 +37,34 @@ type EventInfo struct {
 	Port         string
 	UserAgent    string
 	Type         notification.EventType
+	// RenameCookie pairs the two events of a local rename, zero if unknown.
+	RenameCookie uint32
+	// RenamedFrom is the previous path of a renamed object.
+	RenamedFrom string
+
+	// ack acknowledges the event to a durable watch.
+	ack func()
+}
+
+// Ack tells a durable watch that the event was processed, its position
+// only advances past acknowledged events.
+func (e EventInfo) Ack() {
+	if e.ack != nil {
+		e.ack()
+	}
+}
+
+// mergeAck makes e acknowledge other too, when other is merged into e.
+func (e *EventInfo) mergeAck(other EventInfo) {
+	switch a, b := e.ack, other.ack; {
+	case a == nil:
+		e.ack = b
+	case b != nil:
+		e.ack = func() {
+			a()
+			b()
+		}
+	}
 }
 
 // WatchOptions contains watch configuration options
@@ -78,6 +106,8 @@ type Watcher struct {
 
 	// array of watchers joined
 	o []*WatchObject
+	// their positions
+	states []*watchState
 
 	// all watchers joining will enter this waitgroup
 	wg sync.WaitGroup
@@ -111,17 +141,34 @@ func (w *Watcher) Stop() {
 	w.wg.Wait()
 }
 
-// Join the watcher with client
-func (w *Watcher) Join(ctx context.Context, client Client, recursive bool) *probe.Error {
-	wo, err := client.Watch(ctx, WatchOptions{
+// SaveState saves the positions of the watchers, acknowledged events
+// may still arrive after they stopped.
+func (w *Watcher) SaveState() *probe.Error {
+	for _, state := range w.states {
+		if err := state.Save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Join the watcher with client, its position is saved to statePath
+// if set.
+func (w *Watcher) Join(ctx context.Context, client Client, recursive bool, statePath string) *probe.Error {
+	state, err := loadWatchState(statePath, client.GetURL().String())
+	if err != nil {
+		return err
+	}
+	wo, err := durableWatch(ctx, client, WatchOptions{
 		Recursive: recursive,
 		Events:    []string{"put", "delete", "bucket-creation", "bucket-removal"},
-	})
+	}, state)
 	if err != nil {
 		return err
 	}
 
 	w.o = append(w.o, wo)
+	w.states = append(w.states, state)
 
 	// join monitoring waitgroup
 	w.wg.Add(1)
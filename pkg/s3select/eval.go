@@ -0,0 +1,977 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// value is the result of an expression: nil for NULL, missing, bool,
+// int64, float64, string, time.Time, *object or []value.
+type value interface{}
+
+type missingType struct{}
+
+// missing is the value of fields absent from a record.
+var missing value = missingType{}
+
+// object is a record or a nested document with ordered fields.
+type object struct {
+	keys   []string
+	values []value
+}
+
+func (o *object) set(key string, v value) {
+	for i, k := range o.keys {
+		if k == key {
+			o.values[i] = v
+			return
+		}
+	}
+	o.keys = append(o.keys, key)
+	o.values = append(o.values, v)
+}
+
+// get looks a field up by name, case insensitively unless exact.
+// Positional names _1, _2... address the fields of CSV records.
+func (o *object) get(name string, exact bool) value {
+	for i, k := range o.keys {
+		if k == name {
+			return o.values[i]
+		}
+	}
+	if !exact {
+		for i, k := range o.keys {
+			if strings.EqualFold(k, name) {
+				return o.values[i]
+			}
+		}
+	}
+	if strings.HasPrefix(name, "_") {
+		if n, err := strconv.Atoi(name[1:]); err == nil && n > 0 && n <= len(o.values) {
+			return o.values[n-1]
+		}
+	}
+	return missing
+}
+
+// row is the evaluation context of a record.
+type row struct {
+	record value
+	alias  string
+	aggs   []value
+}
+
+type expr interface {
+	eval(r *row) (value, error)
+}
+
+type literalExpr struct {
+	v value
+}
+
+func (e *literalExpr) eval(*row) (value, error) {
+	return e.v, nil
+}
+
+type pathExpr struct {
+	steps []pathStep
+}
+
+func (e *pathExpr) eval(r *row) (value, error) {
+	steps := e.steps
+	first := steps[0]
+	if !first.quoted && (strings.EqualFold(first.name, r.alias) || strings.EqualFold(first.name, "S3Object")) {
+		steps = steps[1:]
+	}
+	return walkPath(r.record, steps), nil
+}
+
+// name returns the output name of a projected path.
+func (e *pathExpr) name() string {
+	last := e.steps[len(e.steps)-1]
+	if last.name == "" && !last.quoted {
+		return ""
+	}
+	return last.name
+}
+
+func walkPath(v value, steps []pathStep) value {
+	for _, step := range steps {
+		switch {
+		case step.wildcard:
+		case step.name == "" && !step.quoted:
+			arr, ok := v.([]value)
+			if !ok || step.index >= len(arr) {
+				return missing
+			}
+			v = arr[step.index]
+		default:
+			obj, ok := v.(*object)
+			if !ok {
+				return missing
+			}
+			v = obj.get(step.name, step.quoted)
+		}
+	}
+	return v
+}
+
+type unaryExpr struct {
+	op string
+	x  expr
+}
+
+func (e *unaryExpr) eval(r *row) (value, error) {
+	v, err := e.x.eval(r)
+	if err != nil || isNull(v) {
+		return v, err
+	}
+	if e.op == "NOT" {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("NOT applied to a non boolean value")
+		}
+		return !b, nil
+	}
+	switch n := toNumber(v).(type) {
+	case int64:
+		return -n, nil
+	case float64:
+		return -n, nil
+	}
+	return nil, fmt.Errorf("unary minus applied to a non numeric value")
+}
+
+type binaryExpr struct {
+	op   string
+	l, r expr
+}
+
+func (e *binaryExpr) eval(r *row) (value, error) {
+	l, err := e.l.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "AND", "OR":
+		return e.evalLogical(r, l)
+	}
+	rv, err := e.r.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	if l == missing || rv == missing {
+		return missing, nil
+	}
+	if l == nil || rv == nil {
+		return nil, nil
+	}
+	switch e.op {
+	case "||":
+		return toString(l) + toString(rv), nil
+	case "+", "-", "*", "/", "%":
+		return arithmetic(e.op, l, rv)
+	}
+	c, ok := compare(l, rv)
+	if !ok {
+		return e.op == "!=", nil
+	}
+	switch e.op {
+	case "=":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", e.op)
+}
+
+// evalLogical implements the three valued AND and OR.
+func (e *binaryExpr) evalLogical(r *row, l value) (value, error) {
+	lb, lok := l.(bool)
+	if lok && lb == (e.op == "OR") {
+		return lb, nil
+	}
+	rv, err := e.r.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	rb, rok := rv.(bool)
+	if rok && rb == (e.op == "OR") {
+		return rb, nil
+	}
+	if lok && rok {
+		return rb, nil
+	}
+	if (!lok && !isNull(l)) || (!rok && !isNull(rv)) {
+		return nil, fmt.Errorf("%s applied to a non boolean value", e.op)
+	}
+	return nil, nil
+}
+
+type isExpr struct {
+	x       expr
+	missing bool
+	not     bool
+}
+
+func (e *isExpr) eval(r *row) (value, error) {
+	v, err := e.x.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	res := v == missing
+	if !e.missing {
+		res = isNull(v)
+	}
+	return res != e.not, nil
+}
+
+type likeExpr struct {
+	x, pattern, escape expr
+	not                bool
+
+	cached string
+	re     *regexp.Regexp
+}
+
+func (e *likeExpr) eval(r *row) (value, error) {
+	v, err := e.x.eval(r)
+	if err != nil || isNull(v) {
+		return v, err
+	}
+	p, err := e.pattern.eval(r)
+	if err != nil || isNull(p) {
+		return p, err
+	}
+	esc := ""
+	if e.escape != nil {
+		ev, err := e.escape.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		esc = toString(ev)
+	}
+	pattern := toString(p)
+	if e.re == nil || e.cached != esc+pattern {
+		if e.re, err = likeToRegexp(pattern, esc); err != nil {
+			return nil, err
+		}
+		e.cached = esc + pattern
+	}
+	return e.re.MatchString(toString(v)) != e.not, nil
+}
+
+// likeToRegexp converts a LIKE pattern to an anchored regular expression.
+func likeToRegexp(pattern, escape string) (*regexp.Regexp, error) {
+	if len([]rune(escape)) > 1 {
+		return nil, fmt.Errorf("LIKE escape must be a single character")
+	}
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case escape != "" && string(c) == escape:
+			escaped = true
+		case c == '%':
+			sb.WriteString(".*")
+		case c == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("LIKE pattern ends with the escape character")
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+type betweenExpr struct {
+	x, lo, hi expr
+	not       bool
+}
+
+func (e *betweenExpr) eval(r *row) (value, error) {
+	vals := make([]value, 3)
+	for i, x := range []expr{e.x, e.lo, e.hi} {
+		v, err := x.eval(r)
+		if err != nil || isNull(v) {
+			return v, err
+		}
+		vals[i] = v
+	}
+	lo, ok1 := compare(vals[0], vals[1])
+	hi, ok2 := compare(vals[0], vals[2])
+	if !ok1 || !ok2 {
+		return e.not, nil
+	}
+	return (lo >= 0 && hi <= 0) != e.not, nil
+}
+
+type inExpr struct {
+	x    expr
+	list []expr
+	not  bool
+}
+
+func (e *inExpr) eval(r *row) (value, error) {
+	v, err := e.x.eval(r)
+	if err != nil || isNull(v) {
+		return v, err
+	}
+	for _, x := range e.list {
+		item, err := x.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := compare(v, item); ok && c == 0 {
+			return !e.not, nil
+		}
+	}
+	return e.not, nil
+}
+
+type whenClause struct {
+	cond, result expr
+}
+
+type caseExpr struct {
+	operand expr
+	whens   []whenClause
+	els     expr
+}
+
+func (e *caseExpr) eval(r *row) (value, error) {
+	var operand value
+	if e.operand != nil {
+		v, err := e.operand.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		operand = v
+	}
+	for _, w := range e.whens {
+		cond, err := w.cond.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		matched := cond == true
+		if e.operand != nil {
+			c, ok := compare(operand, cond)
+			matched = ok && c == 0 && !isNull(operand)
+		}
+		if matched {
+			return w.result.eval(r)
+		}
+	}
+	if e.els == nil {
+		return nil, nil
+	}
+	return e.els.eval(r)
+}
+
+var validCastTypes = map[string]bool{
+	"INT": true, "INTEGER": true, "FLOAT": true, "DECIMAL": true, "NUMERIC": true,
+	"STRING": true, "VARCHAR": true, "CHAR": true, "BOOL": true, "BOOLEAN": true, "TIMESTAMP": true,
+}
+
+type castExpr struct {
+	x   expr
+	typ string
+}
+
+func (e *castExpr) eval(r *row) (value, error) {
+	v, err := e.x.eval(r)
+	if err != nil || isNull(v) {
+		return v, err
+	}
+	switch e.typ {
+	case "INT", "INTEGER":
+		switch n := toNumber(v).(type) {
+		case int64:
+			return n, nil
+		case float64:
+			return int64(n), nil
+		}
+		if b, ok := v.(bool); ok {
+			if b {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+	case "FLOAT", "DECIMAL", "NUMERIC":
+		switch n := toNumber(v).(type) {
+		case int64:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case "STRING", "VARCHAR", "CHAR":
+		return toString(v), nil
+	case "BOOL", "BOOLEAN":
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(x)); err == nil {
+				return b, nil
+			}
+		case int64:
+			return x != 0, nil
+		}
+	case "TIMESTAMP":
+		switch x := v.(type) {
+		case time.Time:
+			return x, nil
+		case string:
+			return parseTimestamp(x)
+		}
+	}
+	return nil, fmt.Errorf("cannot CAST %s AS %s", toString(v), e.typ)
+}
+
+type funcExpr struct {
+	name string
+	args []expr
+}
+
+type scalarFunc func(args []value) (value, error)
+
+var scalarFuncs map[string]scalarFunc
+
+func init() {
+	scalarFuncs = map[string]scalarFunc{
+		"LOWER":            stringFunc(strings.ToLower),
+		"UPPER":            stringFunc(strings.ToUpper),
+		"CHAR_LENGTH":      charLength,
+		"CHARACTER_LENGTH": charLength,
+		"COALESCE":         coalesce,
+		"NULLIF":           nullIf,
+		"UTCNOW":           utcNow,
+		"TO_STRING":        toStringFunc,
+		"TO_TIMESTAMP":     toTimestamp,
+		"SUBSTRING":        substring,
+		"TRIM":             trim,
+		"EXTRACT":          extract,
+	}
+}
+
+func (e *funcExpr) eval(r *row) (value, error) {
+	args := make([]value, len(e.args))
+	for i, x := range e.args {
+		v, err := x.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return scalarFuncs[e.name](args)
+}
+
+func stringFunc(fn func(string) string) scalarFunc {
+	return func(args []value) (value, error) {
+		if len(args) != 1 {
+			return nil, errors.New("expected one argument")
+		}
+		if isNull(args[0]) {
+			return args[0], nil
+		}
+		return fn(toString(args[0])), nil
+	}
+}
+
+func charLength(args []value) (value, error) {
+	if len(args) != 1 {
+		return nil, errors.New("CHAR_LENGTH expects one argument")
+	}
+	if isNull(args[0]) {
+		return args[0], nil
+	}
+	return int64(len([]rune(toString(args[0])))), nil
+}
+
+func coalesce(args []value) (value, error) {
+	for _, v := range args {
+		if !isNull(v) {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+func nullIf(args []value) (value, error) {
+	if len(args) != 2 {
+		return nil, errors.New("NULLIF expects two arguments")
+	}
+	if c, ok := compare(args[0], args[1]); ok && c == 0 {
+		return nil, nil
+	}
+	return args[0], nil
+}
+
+func utcNow(args []value) (value, error) {
+	if len(args) != 0 {
+		return nil, errors.New("UTCNOW expects no arguments")
+	}
+	return time.Now().UTC(), nil
+}
+
+func toStringFunc(args []value) (value, error) {
+	if len(args) < 1 {
+		return nil, errors.New("TO_STRING expects an argument")
+	}
+	if isNull(args[0]) {
+		return args[0], nil
+	}
+	return toString(args[0]), nil
+}
+
+func toTimestamp(args []value) (value, error) {
+	if len(args) != 1 {
+		return nil, errors.New("TO_TIMESTAMP expects one argument")
+	}
+	if isNull(args[0]) {
+		return args[0], nil
+	}
+	return parseTimestamp(toString(args[0]))
+}
+
+func substring(args []value) (value, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, errors.New("SUBSTRING expects a string and a start position")
+	}
+	for _, v := range args {
+		if isNull(v) {
+			return v, nil
+		}
+	}
+	s := []rune(toString(args[0]))
+	start, ok := toNumber(args[1]).(int64)
+	if !ok {
+		return nil, errors.New("SUBSTRING start position must be an integer")
+	}
+	// Positions are 1 based, a start before the string shortens the length.
+	end := int64(len(s)) + 1
+	if len(args) == 3 {
+		n, ok := toNumber(args[2]).(int64)
+		if !ok || n < 0 {
+			return nil, errors.New("SUBSTRING length must be a positive integer")
+		}
+		end = start + n
+	}
+	if start < 1 {
+		start = 1
+	}
+	if end > int64(len(s))+1 {
+		end = int64(len(s)) + 1
+	}
+	if start >= end {
+		return "", nil
+	}
+	return string(s[start-1 : end-1]), nil
+}
+
+func trim(args []value) (value, error) {
+	if isNull(args[1]) || isNull(args[2]) {
+		return nil, nil
+	}
+	s, chars := toString(args[2]), toString(args[1])
+	switch args[0] {
+	case "LEADING":
+		return strings.TrimLeft(s, chars), nil
+	case "TRAILING":
+		return strings.TrimRight(s, chars), nil
+	}
+	return strings.Trim(s, chars), nil
+}
+
+func extract(args []value) (value, error) {
+	if isNull(args[1]) {
+		return args[1], nil
+	}
+	t, ok := args[1].(time.Time)
+	if !ok {
+		var err error
+		if t, err = parseTimestamp(toString(args[1])); err != nil {
+			return nil, err
+		}
+	}
+	switch args[0] {
+	case "YEAR":
+		return int64(t.Year()), nil
+	case "MONTH":
+		return int64(t.Month()), nil
+	case "DAY":
+		return int64(t.Day()), nil
+	case "HOUR":
+		return int64(t.Hour()), nil
+	case "MINUTE":
+		return int64(t.Minute()), nil
+	case "SECOND":
+		return int64(t.Second()), nil
+	}
+	return nil, fmt.Errorf("unsupported EXTRACT part %s", args[0])
+}
+
+// aggregateExpr is an aggregate function, its value is computed over
+// all the matching records.
+type aggregateExpr struct {
+	name string
+	arg  expr
+	idx  int
+}
+
+func (e *aggregateExpr) eval(r *row) (value, error) {
+	return r.aggs[e.idx], nil
+}
+
+// aggregator accumulates the value of an aggregate function.
+type aggregator struct {
+	expr  *aggregateExpr
+	count int64
+	sum   value
+	best  value
+}
+
+func (a *aggregator) update(r *row) error {
+	if a.expr.arg == nil {
+		a.count++
+		return nil
+	}
+	v, err := a.expr.arg.eval(r)
+	if err != nil || isNull(v) {
+		return err
+	}
+	a.count++
+	switch a.expr.name {
+	case "SUM", "AVG":
+		n := toNumber(v)
+		if n == nil {
+			return fmt.Errorf("%s applied to a non numeric value", a.expr.name)
+		}
+		if a.sum == nil {
+			a.sum = n
+			return nil
+		}
+		a.sum, err = arithmetic("+", a.sum, n)
+		return err
+	case "MIN", "MAX":
+		if a.best == nil {
+			a.best = v
+			return nil
+		}
+		c, ok := compare(v, a.best)
+		if ok && ((a.expr.name == "MIN" && c < 0) || (a.expr.name == "MAX" && c > 0)) {
+			a.best = v
+		}
+	}
+	return nil
+}
+
+func (a *aggregator) result() value {
+	switch a.expr.name {
+	case "COUNT":
+		return a.count
+	case "SUM":
+		return a.sum
+	case "AVG":
+		if a.count == 0 {
+			return nil
+		}
+		switch s := a.sum.(type) {
+		case int64:
+			return float64(s) / float64(a.count)
+		case float64:
+			return s / float64(a.count)
+		}
+		return nil
+	}
+	return a.best
+}
+
+// hasFieldRef returns whether an expression reads the record outside
+// of aggregate functions.
+func hasFieldRef(e expr) bool {
+	switch x := e.(type) {
+	case *pathExpr:
+		return true
+	case *unaryExpr:
+		return hasFieldRef(x.x)
+	case *binaryExpr:
+		return hasFieldRef(x.l) || hasFieldRef(x.r)
+	case *isExpr:
+		return hasFieldRef(x.x)
+	case *likeExpr:
+		return hasFieldRef(x.x) || hasFieldRef(x.pattern) || (x.escape != nil && hasFieldRef(x.escape))
+	case *betweenExpr:
+		return hasFieldRef(x.x) || hasFieldRef(x.lo) || hasFieldRef(x.hi)
+	case *inExpr:
+		return hasFieldRef(x.x) || anyFieldRef(x.list)
+	case *caseExpr:
+		if (x.operand != nil && hasFieldRef(x.operand)) || (x.els != nil && hasFieldRef(x.els)) {
+			return true
+		}
+		for _, w := range x.whens {
+			if hasFieldRef(w.cond) || hasFieldRef(w.result) {
+				return true
+			}
+		}
+	case *castExpr:
+		return hasFieldRef(x.x)
+	case *funcExpr:
+		return anyFieldRef(x.args)
+	}
+	return false
+}
+
+func anyFieldRef(list []expr) bool {
+	for _, e := range list {
+		if hasFieldRef(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNull(v value) bool {
+	return v == nil || v == missing
+}
+
+// toNumber converts a value to int64 or float64, strings read from
+// CSV records are parsed. It returns nil if the value isn't numeric.
+func toNumber(v value) value {
+	switch x := v.(type) {
+	case int64, float64:
+		return x
+	case string:
+		s := strings.TrimSpace(x)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func toFloat(v value) float64 {
+	switch x := v.(type) {
+	case int64:
+		return float64(x)
+	case float64:
+		return x
+	}
+	return math.NaN()
+}
+
+func arithmetic(op string, l, r value) (value, error) {
+	ln, rn := toNumber(l), toNumber(r)
+	if ln == nil || rn == nil {
+		return nil, fmt.Errorf("operator %s applied to a non numeric value", op)
+	}
+	li, lint := ln.(int64)
+	ri, rint := rn.(int64)
+	if lint && rint {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		}
+		if ri == 0 {
+			return nil, errors.New("division by zero")
+		}
+		if op == "%" {
+			return li % ri, nil
+		}
+		return li / ri, nil
+	}
+	lf, rf := toFloat(ln), toFloat(rn)
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	}
+	if rf == 0 {
+		return nil, errors.New("division by zero")
+	}
+	if op == "%" {
+		return math.Mod(lf, rf), nil
+	}
+	return lf / rf, nil
+}
+
+// compare orders two values, a number and a numeric string compare as
+// numbers. It returns false if the values aren't comparable.
+func compare(l, r value) (int, bool) {
+	if isNull(l) || isNull(r) {
+		return 0, false
+	}
+	switch lv := l.(type) {
+	case string:
+		if rs, ok := r.(string); ok {
+			return strings.Compare(lv, rs), true
+		}
+		if rt, ok := r.(time.Time); ok {
+			lt, err := parseTimestamp(lv)
+			if err != nil {
+				return 0, false
+			}
+			return compareTime(lt, rt), true
+		}
+	case bool:
+		rb, ok := r.(bool)
+		if !ok {
+			if rs, isStr := r.(string); isStr {
+				var err error
+				if rb, err = strconv.ParseBool(rs); err == nil {
+					ok = true
+				}
+			}
+		}
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case lv == rb:
+			return 0, true
+		case !lv:
+			return -1, true
+		}
+		return 1, true
+	case time.Time:
+		rt, ok := r.(time.Time)
+		if !ok {
+			var err error
+			if rt, err = parseTimestamp(toString(r)); err != nil {
+				return 0, false
+			}
+		}
+		return compareTime(lv, rt), true
+	}
+	if _, ok := r.(bool); ok {
+		c, ok := compare(r, l)
+		return -c, ok
+	}
+	if _, ok := r.(time.Time); ok {
+		c, ok := compare(r, l)
+		return -c, ok
+	}
+	ln, rn := toNumber(l), toNumber(r)
+	if ln == nil || rn == nil {
+		return 0, false
+	}
+	li, lint := ln.(int64)
+	ri, rint := rn.(int64)
+	if lint && rint {
+		switch {
+		case li < ri:
+			return -1, true
+		case li > ri:
+			return 1, true
+		}
+		return 0, true
+	}
+	lf, rf := toFloat(ln), toFloat(rn)
+	switch {
+	case lf < rf:
+		return -1, true
+	case lf > rf:
+		return 1, true
+	}
+	return 0, true
+}
+
+func compareTime(l, r time.Time) int {
+	switch {
+	case l.Before(r):
+		return -1
+	case l.After(r):
+		return 1
+	}
+	return 0
+}
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// toString formats a value as written to CSV output.
+func toString(v value) string {
+	switch x := v.(type) {
+	case nil, missingType:
+		return ""
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	}
+	b, err := marshalJSON(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
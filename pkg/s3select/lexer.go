@@ -0,0 +1,145 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokQuotedIdent
+	tokString
+	tokNumber
+	tokTimestamp
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// is returns whether the token is the keyword or operator s.
+func (t token) is(s string) bool {
+	switch t.kind {
+	case tokIdent:
+		return strings.EqualFold(t.text, s)
+	case tokOp:
+		return t.text == s
+	}
+	return false
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+	return fmt.Sprintf("`%s`", t.text)
+}
+
+var twoCharOps = []string{"<=", ">=", "<>", "!=", "||"}
+
+// lex splits a query into tokens.
+func lex(query string) ([]token, error) {
+	var tokens []token
+	r := []rune(query)
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '-' && i+1 < len(r) && r[i+1] == '-':
+			for i < len(r) && r[i] != '\n' {
+				i++
+			}
+		case c == '\'' || c == '"' || c == '`':
+			var sb strings.Builder
+			start := i
+			i++
+			for {
+				if i >= len(r) {
+					return nil, fmt.Errorf("unterminated %c at position %d", c, start)
+				}
+				if r[i] == c {
+					// A doubled quote escapes itself.
+					if i+1 < len(r) && r[i+1] == c {
+						sb.WriteRune(c)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteRune(r[i])
+				i++
+			}
+			kind := tokString
+			switch c {
+			case '"':
+				kind = tokQuotedIdent
+			case '`':
+				kind = tokTimestamp
+			}
+			tokens = append(tokens, token{kind: kind, text: sb.String(), pos: start})
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(r) && unicode.IsDigit(r[i+1])):
+			start := i
+			for i < len(r) && (unicode.IsDigit(r[i]) || r[i] == '.') {
+				i++
+			}
+			if i < len(r) && (r[i] == 'e' || r[i] == 'E') {
+				i++
+				if i < len(r) && (r[i] == '+' || r[i] == '-') {
+					i++
+				}
+				for i < len(r) && unicode.IsDigit(r[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(r[start:i]), pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(r) && (unicode.IsLetter(r[i]) || unicode.IsDigit(r[i]) || r[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(r[start:i]), pos: start})
+		default:
+			op := string(c)
+			if i+1 < len(r) {
+				for _, two := range twoCharOps {
+					if string(r[i:i+2]) == two {
+						op = two
+						break
+					}
+				}
+			}
+			if len(op) == 1 && !strings.ContainsRune("()[],.*+-/%=<>;", c) {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len([]rune(op))
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(r)}), nil
+}
@@ -0,0 +1,640 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathStep is a field name or an array index of a path expression.
+type pathStep struct {
+	name     string
+	quoted   bool
+	index    int
+	wildcard bool
+}
+
+type selectItem struct {
+	expr  expr
+	alias string
+}
+
+// Query is a parsed S3 Select SQL statement.
+type Query struct {
+	star      bool
+	items     []selectItem
+	fromPath  []pathStep
+	fromAlias string
+	where     expr
+	limit     int64
+	aggs      []*aggregateExpr
+}
+
+// reserved words that can't be used as a table alias.
+var reservedWords = map[string]bool{
+	"WHERE": true, "LIMIT": true, "AS": true, "FROM": true, "AND": true, "OR": true,
+}
+
+var aggregateFuncs = map[string]bool{
+	"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true,
+}
+
+type parser struct {
+	tokens  []token
+	pos     int
+	aggs    []*aggregateExpr
+	inAgg   bool
+	inWhere bool
+}
+
+// Parse parses an S3 Select SQL statement.
+func Parse(query string) (*Query, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	q, err := p.parseSelect()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return q, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(s string) bool {
+	if p.peek().is(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) error {
+	if !p.accept(s) {
+		return fmt.Errorf("expected %s, found %s", s, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) parseSelect() (*Query, error) {
+	q := &Query{limit: -1}
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	if p.accept("*") {
+		q.star = true
+	} else {
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			item := selectItem{expr: e}
+			if p.accept("AS") {
+				t := p.next()
+				if t.kind != tokIdent && t.kind != tokQuotedIdent {
+					return nil, fmt.Errorf("expected alias, found %s", t)
+				}
+				item.alias = t.text
+			} else if t := p.peek(); t.kind == tokQuotedIdent || (t.kind == tokIdent && !t.is("FROM")) {
+				item.alias = p.next().text
+			}
+			q.items = append(q.items, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	q.aggs = p.aggs
+
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if !t.is("S3Object") {
+		return nil, fmt.Errorf("expected S3Object, found %s", t)
+	}
+	for {
+		if p.accept("[") {
+			if err := p.expect("*"); err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			q.fromPath = append(q.fromPath, pathStep{wildcard: true})
+			continue
+		}
+		if p.peek().is(".") {
+			p.next()
+			t := p.next()
+			if t.kind != tokIdent && t.kind != tokQuotedIdent {
+				return nil, fmt.Errorf("expected field name, found %s", t)
+			}
+			q.fromPath = append(q.fromPath, pathStep{name: t.text, quoted: t.kind == tokQuotedIdent})
+			continue
+		}
+		break
+	}
+	p.accept("AS")
+	if t := p.peek(); (t.kind == tokIdent && !reservedWords[strings.ToUpper(t.text)]) || t.kind == tokQuotedIdent {
+		q.fromAlias = p.next().text
+	}
+
+	p.inWhere = true
+	if p.accept("WHERE") {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		q.where = e
+	}
+	if p.accept("LIMIT") {
+		t := p.next()
+		n, err := strconv.ParseInt(t.text, 10, 64)
+		if t.kind != tokNumber || err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid LIMIT %s", t)
+		}
+		q.limit = n
+	}
+	p.accept(";")
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s", t)
+	}
+
+	if len(q.aggs) > 0 {
+		for _, item := range q.items {
+			if hasFieldRef(item.expr) {
+				return nil, fmt.Errorf("cannot mix aggregate and non-aggregate projections")
+			}
+		}
+	}
+	return q, nil
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (expr, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: "OR", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: "AND", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.accept("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "NOT", x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	l, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.is("=") || t.is("!=") || t.is("<>") || t.is("<") || t.is("<=") || t.is(">") || t.is(">="):
+			p.next()
+			r, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			op := t.text
+			if op == "<>" {
+				op = "!="
+			}
+			l = &binaryExpr{op: op, l: l, r: r}
+		case t.is("IS"):
+			p.next()
+			not := p.accept("NOT")
+			what := strings.ToUpper(p.next().text)
+			if what != "NULL" && what != "MISSING" {
+				return nil, fmt.Errorf("expected NULL or MISSING after IS")
+			}
+			l = &isExpr{x: l, missing: what == "MISSING", not: not}
+		case t.is("NOT") || t.is("LIKE") || t.is("BETWEEN") || t.is("IN"):
+			not := false
+			if t.is("NOT") {
+				nt := p.peekAt(1)
+				if !nt.is("LIKE") && !nt.is("BETWEEN") && !nt.is("IN") {
+					return l, nil
+				}
+				p.next()
+				not = true
+			}
+			switch op := strings.ToUpper(p.next().text); op {
+			case "LIKE":
+				pattern, err := p.parseAdditive()
+				if err != nil {
+					return nil, err
+				}
+				like := &likeExpr{x: l, pattern: pattern, not: not}
+				if p.accept("ESCAPE") {
+					if like.escape, err = p.parseAdditive(); err != nil {
+						return nil, err
+					}
+				}
+				l = like
+			case "BETWEEN":
+				lo, err := p.parseAdditive()
+				if err != nil {
+					return nil, err
+				}
+				if err = p.expect("AND"); err != nil {
+					return nil, err
+				}
+				hi, err := p.parseAdditive()
+				if err != nil {
+					return nil, err
+				}
+				l = &betweenExpr{x: l, lo: lo, hi: hi, not: not}
+			case "IN":
+				if err := p.expect("("); err != nil {
+					return nil, err
+				}
+				list, err := p.parseExprList(")")
+				if err != nil {
+					return nil, err
+				}
+				l = &inExpr{x: l, list: list, not: not}
+			}
+		default:
+			return l, nil
+		}
+	}
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	l, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !t.is("+") && !t.is("-") && !t.is("||") {
+			return l, nil
+		}
+		p.next()
+		r, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !t.is("*") && !t.is("/") && !t.is("%") {
+			return l, nil
+		}
+		p.next()
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.accept("-") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "-", x: x}, nil
+	}
+	p.accept("+")
+	return p.parsePrimary()
+}
+
+// parseExprList parses comma separated expressions up to the closing token.
+func (p *parser) parseExprList(closing string) ([]expr, error) {
+	var list []expr
+	if p.accept(closing) {
+		return list, nil
+	}
+	for {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if p.accept(closing) {
+			return list, nil
+		}
+		if err = p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return &literalExpr{v: n}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", t)
+		}
+		return &literalExpr{v: f}, nil
+	case tokString:
+		return &literalExpr{v: t.text}, nil
+	case tokTimestamp:
+		ts, err := parseTimestamp(t.text)
+		if err != nil {
+			return nil, err
+		}
+		return &literalExpr{v: ts}, nil
+	case tokQuotedIdent:
+		return p.parsePath(pathStep{name: t.text, quoted: true})
+	case tokOp:
+		if t.is("(") {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expect(")")
+		}
+		return nil, fmt.Errorf("unexpected %s", t)
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+
+	upper := strings.ToUpper(t.text)
+	switch upper {
+	case "NULL":
+		return &literalExpr{v: nil}, nil
+	case "MISSING":
+		return &literalExpr{v: missing}, nil
+	case "TRUE", "FALSE":
+		return &literalExpr{v: upper == "TRUE"}, nil
+	case "CASE":
+		return p.parseCase()
+	}
+	if !p.peek().is("(") {
+		return p.parsePath(pathStep{name: t.text})
+	}
+	p.next()
+
+	if aggregateFuncs[upper] {
+		return p.parseAggregate(upper)
+	}
+	switch upper {
+	case "CAST":
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect("AS"); err != nil {
+			return nil, err
+		}
+		typ := strings.ToUpper(p.next().text)
+		if !validCastTypes[typ] {
+			return nil, fmt.Errorf("unsupported CAST type %s", typ)
+		}
+		return &castExpr{x: x, typ: typ}, p.expect(")")
+	case "EXTRACT":
+		part := strings.ToUpper(p.next().text)
+		if err := p.expect("FROM"); err != nil {
+			return nil, err
+		}
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &funcExpr{name: upper, args: []expr{&literalExpr{v: part}, x}}, p.expect(")")
+	case "TRIM":
+		return p.parseTrim()
+	case "SUBSTRING":
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args := []expr{x}
+		if p.accept("FROM") || p.accept(",") {
+			from, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, from)
+			if p.accept("FOR") || p.accept(",") {
+				n, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, n)
+			}
+		}
+		return &funcExpr{name: upper, args: args}, p.expect(")")
+	}
+	if _, ok := scalarFuncs[upper]; !ok {
+		return nil, fmt.Errorf("unsupported function %s", t.text)
+	}
+	args, err := p.parseExprList(")")
+	if err != nil {
+		return nil, err
+	}
+	return &funcExpr{name: upper, args: args}, nil
+}
+
+func (p *parser) parseAggregate(name string) (expr, error) {
+	if p.inAgg {
+		return nil, fmt.Errorf("nested aggregate %s", name)
+	}
+	if p.inWhere {
+		return nil, fmt.Errorf("aggregate %s not allowed in WHERE", name)
+	}
+	agg := &aggregateExpr{name: name, idx: len(p.aggs)}
+	if name == "COUNT" && p.peek().is("*") {
+		p.next()
+	} else {
+		p.inAgg = true
+		arg, err := p.parseExpr()
+		p.inAgg = false
+		if err != nil {
+			return nil, err
+		}
+		agg.arg = arg
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	p.aggs = append(p.aggs, agg)
+	return agg, nil
+}
+
+func (p *parser) parseTrim() (expr, error) {
+	spec := "BOTH"
+	if t := p.peek(); t.is("LEADING") || t.is("TRAILING") || t.is("BOTH") {
+		spec = strings.ToUpper(p.next().text)
+	}
+	var chars expr = &literalExpr{v: " "}
+	if p.accept("FROM") {
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &funcExpr{name: "TRIM", args: []expr{&literalExpr{v: spec}, chars, x}}, p.expect(")")
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.accept("FROM") {
+		chars = x
+		if x, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	return &funcExpr{name: "TRIM", args: []expr{&literalExpr{v: spec}, chars, x}}, p.expect(")")
+}
+
+func (p *parser) parseCase() (expr, error) {
+	c := &caseExpr{}
+	if !p.peek().is("WHEN") {
+		operand, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.operand = operand
+	}
+	for p.accept("WHEN") {
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect("THEN"); err != nil {
+			return nil, err
+		}
+		res, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.whens = append(c.whens, whenClause{cond: cond, result: res})
+	}
+	if len(c.whens) == 0 {
+		return nil, fmt.Errorf("CASE without WHEN")
+	}
+	if p.accept("ELSE") {
+		els, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.els = els
+	}
+	return c, p.expect("END")
+}
+
+// parsePath parses the rest of a path expression starting at first.
+func (p *parser) parsePath(first pathStep) (expr, error) {
+	path := &pathExpr{steps: []pathStep{first}}
+	for {
+		switch {
+		case p.peek().is("."):
+			p.next()
+			t := p.next()
+			if t.kind != tokIdent && t.kind != tokQuotedIdent {
+				return nil, fmt.Errorf("expected field name, found %s", t)
+			}
+			path.steps = append(path.steps, pathStep{name: t.text, quoted: t.kind == tokQuotedIdent})
+		case p.peek().is("["):
+			p.next()
+			t := p.next()
+			switch t.kind {
+			case tokNumber:
+				n, err := strconv.Atoi(t.text)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid index %s", t)
+				}
+				path.steps = append(path.steps, pathStep{index: n})
+			case tokString:
+				path.steps = append(path.steps, pathStep{name: t.text, quoted: true})
+			default:
+				return nil, fmt.Errorf("invalid index %s", t)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		default:
+			return path, nil
+		}
+	}
+}
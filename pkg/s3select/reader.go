@@ -0,0 +1,325 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/minio-go/v7"
+)
+
+// recordReader reads the records of an object.
+type recordReader interface {
+	// Read returns the next record, io.EOF at the end of the object.
+	Read() (value, error)
+}
+
+// decompress wraps r to decompress the content per the compression type.
+func decompress(r io.Reader, typ minio.SelectCompressionType) (io.ReadCloser, error) {
+	switch strings.ToUpper(string(typ)) {
+	case "", string(minio.SelectCompressionNONE):
+		return ioutil.NopCloser(r), nil
+	case minio.SelectCompressionGZIP:
+		return gzip.NewReader(r)
+	case minio.SelectCompressionBZIP:
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	case minio.SelectCompressionZSTD:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	case minio.SelectCompressionS2, minio.SelectCompressionSNAPPY:
+		return ioutil.NopCloser(s2.NewReader(r)), nil
+	}
+	return nil, fmt.Errorf("unsupported compression type %s", typ)
+}
+
+// singleRune returns the only rune of s, def if s is empty.
+func singleRune(s string, def rune, what string) (rune, error) {
+	if s == "" {
+		return def, nil
+	}
+	if utf8.RuneCountInString(s) != 1 {
+		return 0, fmt.Errorf("%s must be a single character, found %q", what, s)
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r, nil
+}
+
+type csvRecordReader struct {
+	r       *csv.Reader
+	lines   *bufio.Scanner
+	comma   rune
+	comment rune
+	header  []string
+}
+
+func newCSVReader(r io.Reader, opts *minio.CSVInputOptions) (recordReader, error) {
+	cr := &csvRecordReader{}
+	var err error
+	if cr.comma, err = singleRune(opts.FieldDelimiter, ',', "FieldDelimiter"); err != nil {
+		return nil, err
+	}
+	if cr.comment, err = singleRune(opts.Comments, 0, "Comments"); err != nil {
+		return nil, err
+	}
+	if opts.QuoteCharacter != "" && opts.QuoteCharacter != `"` {
+		return nil, fmt.Errorf("unsupported QuoteCharacter %q", opts.QuoteCharacter)
+	}
+	if opts.QuoteEscapeCharacter != "" && opts.QuoteEscapeCharacter != `"` {
+		return nil, fmt.Errorf("unsupported QuoteEscapeCharacter %q", opts.QuoteEscapeCharacter)
+	}
+
+	switch opts.RecordDelimiter {
+	case "", "\n", "\r\n":
+		cr.r = cr.newCSV(r)
+	default:
+		// Records are split on the custom delimiter first, quoted
+		// fields can't contain it.
+		delim := []byte(opts.RecordDelimiter)
+		cr.lines = bufio.NewScanner(r)
+		cr.lines.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		cr.lines.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			if i := bytes.Index(data, delim); i >= 0 {
+				return i + len(delim), data[:i], nil
+			}
+			if atEOF && len(data) > 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		})
+	}
+
+	switch strings.ToUpper(string(opts.FileHeaderInfo)) {
+	case minio.CSVFileHeaderInfoUse:
+		hdr, err := cr.readFields()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		cr.header = append([]string(nil), hdr...)
+	case minio.CSVFileHeaderInfoIgnore:
+		if _, err := cr.readFields(); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+	return cr, nil
+}
+
+func (cr *csvRecordReader) newCSV(r io.Reader) *csv.Reader {
+	c := csv.NewReader(r)
+	c.FieldsPerRecord = -1
+	c.LazyQuotes = true
+	c.Comma = cr.comma
+	c.Comment = cr.comment
+	return c
+}
+
+func (cr *csvRecordReader) readFields() ([]string, error) {
+	if cr.lines == nil {
+		return cr.r.Read()
+	}
+	for cr.lines.Scan() {
+		fields, err := cr.newCSV(strings.NewReader(cr.lines.Text())).Read()
+		if err == io.EOF {
+			// Empty or comment line.
+			continue
+		}
+		return fields, err
+	}
+	if err := cr.lines.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (cr *csvRecordReader) Read() (value, error) {
+	fields, err := cr.readFields()
+	if err != nil {
+		return nil, err
+	}
+	obj := &object{}
+	for i, f := range fields {
+		name := "_" + strconv.Itoa(i+1)
+		if i < len(cr.header) {
+			name = cr.header[i]
+		}
+		obj.keys = append(obj.keys, name)
+		obj.values = append(obj.values, f)
+	}
+	return obj, nil
+}
+
+type jsonRecordReader struct {
+	dec *json.Decoder
+}
+
+func newJSONReader(r io.Reader) recordReader {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &jsonRecordReader{dec: dec}
+}
+
+func (jr *jsonRecordReader) Read() (value, error) {
+	return decodeJSON(jr.dec)
+}
+
+// decodeJSON decodes the next JSON value keeping the order of object keys.
+func decodeJSON(dec *json.Decoder) (value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := &object{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := decodeJSON(dec)
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				obj.set(key.(string), v)
+			}
+			_, err = dec.Token()
+			return obj, unexpectedEOF(err)
+		case '[':
+			arr := []value{}
+			for dec.More() {
+				v, err := decodeJSON(dec)
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				arr = append(arr, v)
+			}
+			_, err = dec.Token()
+			return arr, unexpectedEOF(err)
+		}
+		return nil, fmt.Errorf("unexpected %v in JSON input", t)
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n, nil
+		}
+		return t.Float64()
+	}
+	return tok, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+type parquetRecordReader struct {
+	r       *goparquet.FileReader
+	columns []string
+}
+
+func newParquetReader(r io.ReadSeeker) (recordReader, error) {
+	fr, err := goparquet.NewFileReader(r)
+	if err != nil {
+		return nil, err
+	}
+	pr := &parquetRecordReader{r: fr}
+	for _, col := range fr.Columns() {
+		pr.columns = append(pr.columns, col.Name())
+	}
+	return pr, nil
+}
+
+func (pr *parquetRecordReader) Read() (value, error) {
+	data, err := pr.r.NextRow()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	obj := &object{}
+	for _, col := range pr.columns {
+		v, ok := data[col]
+		if !ok {
+			// Optional columns without value are NULL.
+			obj.set(col, nil)
+			continue
+		}
+		obj.set(col, parquetValue(v))
+	}
+	return obj, nil
+}
+
+// parquetValue converts a value read from a Parquet file.
+func parquetValue(v interface{}) value {
+	switch x := v.(type) {
+	case []byte:
+		return string(x)
+	case int32:
+		return int64(x)
+	case int64:
+		return x
+	case float32:
+		return float64(x)
+	case float64, bool, string:
+		return x
+	case [12]byte:
+		return goparquet.Int96ToTime(x)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		obj := &object{}
+		for _, k := range keys {
+			obj.set(k, parquetValue(x[k]))
+		}
+		return obj
+	case []interface{}:
+		arr := make([]value, len(x))
+		for i, e := range x {
+			arr[i] = parquetValue(e)
+		}
+		return arr
+	case nil:
+		return nil
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package s3select runs S3 Select SQL queries locally on CSV, JSON and
+// Parquet content, for backends without SelectObjectContent support.
+package s3select
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Select runs an S3 Select SQL query on the content read from r, encoded
+// per the input serialization, and writes the matching records to w per
+// the output serialization.
+func Select(ctx context.Context, w io.Writer, r io.Reader, query string, in minio.SelectObjectInputSerialization, out minio.SelectObjectOutputSerialization) error {
+	q, err := Parse(query)
+	if err != nil {
+		return err
+	}
+	return q.Run(ctx, w, r, in, out)
+}
+
+// Run runs the query on the content read from r.
+func (q *Query) Run(ctx context.Context, w io.Writer, r io.Reader, in minio.SelectObjectInputSerialization, out minio.SelectObjectOutputSerialization) error {
+	rr, cleanup, err := newRecordReader(r, in)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	var rw recordWriter
+	switch {
+	case out.JSON != nil:
+		rw = newJSONWriter(w, out.JSON)
+	case out.CSV != nil:
+		rw = newCSVWriter(w, out.CSV)
+	default:
+		rw = newCSVWriter(w, &minio.CSVOutputOptions{})
+	}
+
+	if err = q.run(ctx, rr, rw); err != nil {
+		rw.Flush()
+		return err
+	}
+	return rw.Flush()
+}
+
+func (q *Query) run(ctx context.Context, rr recordReader, rw recordWriter) error {
+	var aggs []*aggregator
+	for _, agg := range q.aggs {
+		aggs = append(aggs, &aggregator{expr: agg})
+	}
+
+	var emitted, read int64
+	for q.limit < 0 || emitted < q.limit || len(aggs) > 0 {
+		rec, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if read++; read%1000 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		for _, v := range applyFrom(rec, q.fromPath) {
+			r := &row{record: v, alias: q.fromAlias}
+			if q.where != nil {
+				cond, err := q.where.eval(r)
+				if err != nil {
+					return err
+				}
+				if cond != true {
+					continue
+				}
+			}
+			if len(aggs) > 0 {
+				for _, agg := range aggs {
+					if err = agg.update(r); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if q.limit >= 0 && emitted >= q.limit {
+				break
+			}
+			res, err := q.project(r)
+			if err != nil {
+				return err
+			}
+			if err = rw.Write(res); err != nil {
+				return err
+			}
+			emitted++
+		}
+	}
+
+	if len(aggs) == 0 || q.limit == 0 {
+		return nil
+	}
+	r := &row{}
+	for _, agg := range aggs {
+		r.aggs = append(r.aggs, agg.result())
+	}
+	res, err := q.project(r)
+	if err != nil {
+		return err
+	}
+	return rw.Write(res)
+}
+
+// project computes the output record of a row.
+func (q *Query) project(r *row) (*object, error) {
+	if q.star {
+		if obj, ok := r.record.(*object); ok {
+			return obj, nil
+		}
+		return &object{keys: []string{"_1"}, values: []value{r.record}}, nil
+	}
+	res := &object{}
+	for i, item := range q.items {
+		v, err := item.expr.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		name := item.alias
+		if p, ok := item.expr.(*pathExpr); ok && name == "" {
+			name = p.name()
+		}
+		if name == "" {
+			name = "_" + strconv.Itoa(i+1)
+		}
+		res.keys = append(res.keys, name)
+		res.values = append(res.values, v)
+	}
+	return res, nil
+}
+
+// applyFrom returns the records selected by the FROM clause path.
+func applyFrom(v value, steps []pathStep) []value {
+	vals := []value{v}
+	for _, step := range steps {
+		var next []value
+		for _, x := range vals {
+			if !step.wildcard {
+				if y := walkPath(x, []pathStep{step}); y != missing {
+					next = append(next, y)
+				}
+				continue
+			}
+			if arr, ok := x.([]value); ok {
+				next = append(next, arr...)
+			} else {
+				next = append(next, x)
+			}
+		}
+		vals = next
+	}
+	return vals
+}
+
+func newRecordReader(r io.Reader, in minio.SelectObjectInputSerialization) (recordReader, func(), error) {
+	cleanup := func() {}
+	if in.Parquet != nil {
+		rs, ok := r.(io.ReadSeeker)
+		if !ok {
+			// Parquet footers are at the end of the file, spool
+			// streamed content to a temporary file.
+			f, err := ioutil.TempFile("", "s3select-")
+			if err != nil {
+				return nil, cleanup, err
+			}
+			cleanup = func() {
+				f.Close()
+				os.Remove(f.Name())
+			}
+			if _, err = io.Copy(f, r); err == nil {
+				_, err = f.Seek(0, io.SeekStart)
+			}
+			if err != nil {
+				return nil, cleanup, err
+			}
+			rs = f
+		}
+		rr, err := newParquetReader(rs)
+		return rr, cleanup, err
+	}
+
+	dr, err := decompress(r, in.CompressionType)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	cleanup = func() { dr.Close() }
+	switch {
+	case in.CSV != nil:
+		rr, err := newCSVReader(dr, in.CSV)
+		return rr, cleanup, err
+	case in.JSON != nil:
+		return newJSONReader(dr), cleanup, nil
+	}
+	return nil, cleanup, errors.New("unknown input serialization, expected CSV, JSON or Parquet")
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/minio/minio-go/v7"
+)
+
+const testCSV = `name,city,age
+alice,Paris,31
+bob,"New York, NY",25
+carol,Berlin,
+dave,paris,40
+`
+
+const testJSON = `{"name":"alice","age":31,"tags":["a","b"],"address":{"city":"Paris"}}
+{"name":"bob","age":25.5,"tags":[],"address":{"city":"Berlin"}}
+{"name":"carol","active":true}
+`
+
+func csvIn() minio.SelectObjectInputSerialization {
+	return minio.SelectObjectInputSerialization{CSV: &minio.CSVInputOptions{FileHeaderInfo: minio.CSVFileHeaderInfoUse}}
+}
+
+func jsonIn() minio.SelectObjectInputSerialization {
+	return minio.SelectObjectInputSerialization{JSON: &minio.JSONInputOptions{Type: minio.JSONLinesType}}
+}
+
+var (
+	csvOut  = minio.SelectObjectOutputSerialization{CSV: &minio.CSVOutputOptions{}}
+	jsonOut = minio.SelectObjectOutputSerialization{JSON: &minio.JSONOutputOptions{}}
+)
+
+func TestSelect(t *testing.T) {
+	testCases := []struct {
+		input    string
+		in       minio.SelectObjectInputSerialization
+		out      minio.SelectObjectOutputSerialization
+		query    string
+		expected string
+	}{
+		{testCSV, csvIn(), csvOut, "select * from s3object", "alice,Paris,31\nbob,\"New York, NY\",25\ncarol,Berlin,\ndave,paris,40\n"},
+		{testCSV, csvIn(), csvOut, "SELECT s.name FROM S3Object s WHERE s.age > 30", "alice\ndave\n"},
+		{testCSV, csvIn(), csvOut, "select name from s3object where lower(city) = 'paris' limit 1", "alice\n"},
+		{testCSV, csvIn(), csvOut, "select _1, _3 from s3object where age = ''", "carol,\n"},
+		{testCSV, csvIn(), jsonOut, "select name as n, cast(age as int) + 1 from s3object s where s.city like 'P%'", "{\"n\":\"alice\",\"_2\":32}\n"},
+		{testCSV, csvIn(), csvOut, "select count(*), sum(age), max(age), min(name) from s3object where age <> ''", "3,96,40,alice\n"},
+		{testCSV, csvIn(), csvOut, "select avg(cast(age as float)) from s3object where age between 25 and 31", "28\n"},
+		{testCSV, csvIn(), csvOut, "select name from s3object where city in ('Berlin', 'Paris') and not name = 'carol'", "alice\n"},
+		{testCSV, csvIn(), csvOut, "select upper(substring(name from 1 for 2)) || '-' || char_length(city) from s3object limit 2", "AL-5\nBO-12\n"},
+		{testCSV, csvIn(), csvOut, "select case when age is null or age = '' then 'unknown' when cast(age as int) < 30 then 'young' else 'old' end from s3object", "old\nyoung\nunknown\nold\n"},
+		{testCSV, minio.SelectObjectInputSerialization{CSV: &minio.CSVInputOptions{FileHeaderInfo: minio.CSVFileHeaderInfoIgnore}}, jsonOut, "select * from s3object limit 1", "{\"_1\":\"alice\",\"_2\":\"Paris\",\"_3\":\"31\"}\n"},
+		{"a;1|b;2|", minio.SelectObjectInputSerialization{CSV: &minio.CSVInputOptions{FieldDelimiter: ";", RecordDelimiter: "|"}}, minio.SelectObjectOutputSerialization{CSV: &minio.CSVOutputOptions{FieldDelimiter: "\t", QuoteFields: minio.CSVQuoteFieldsAlways}}, "select _2, _1 from s3object", "\"1\"\t\"a\"\n\"2\"\t\"b\"\n"},
+		{testJSON, jsonIn(), jsonOut, "select * from s3object s where s.age >= 25.5", "{\"name\":\"alice\",\"age\":31,\"tags\":[\"a\",\"b\"],\"address\":{\"city\":\"Paris\"}}\n{\"name\":\"bob\",\"age\":25.5,\"tags\":[],\"address\":{\"city\":\"Berlin\"}}\n"},
+		{testJSON, jsonIn(), jsonOut, "select s.name, s.address.city, s.tags[1] from s3object s", "{\"name\":\"alice\",\"city\":\"Paris\",\"_3\":\"b\"}\n{\"name\":\"bob\",\"city\":\"Berlin\"}\n{\"name\":\"carol\"}\n"},
+		{testJSON, jsonIn(), csvOut, "select name from s3object where address is missing", "carol\n"},
+		{testJSON, jsonIn(), csvOut, "select name from s3object where active = true", "carol\n"},
+		{testJSON, jsonIn(), csvOut, "select count(age), sum(age) from s3object", "2,56.5\n"},
+		{`{"items":[{"id":1},{"id":2}]}`, minio.SelectObjectInputSerialization{JSON: &minio.JSONInputOptions{Type: minio.JSONDocumentType}}, csvOut, "select d.id from s3object[*].items[*] d where d.id > 1", "2\n"},
+		{testCSV, csvIn(), csvOut, "select name from s3object where name like 'a\\%%' escape '\\' or name like '_ob'", "bob\n"},
+	}
+
+	for i, tc := range testCases {
+		var buf bytes.Buffer
+		if err := Select(context.Background(), &buf, strings.NewReader(tc.input), tc.query, tc.in, tc.out); err != nil {
+			t.Errorf("Test %d: %q unexpected error: %v", i+1, tc.query, err)
+			continue
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("Test %d: %q expected %q, got %q", i+1, tc.query, tc.expected, buf.String())
+		}
+	}
+}
+
+func TestSelectGzip(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(testCSV))
+	zw.Close()
+
+	in := csvIn()
+	in.CompressionType = minio.SelectCompressionGZIP
+	var buf bytes.Buffer
+	if err := Select(context.Background(), &buf, &gz, "select count(*) from s3object", in, csvOut); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "4\n" {
+		t.Errorf("expected 4 records, got %q", buf.String())
+	}
+}
+
+func TestSelectParquet(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required binary name (STRING);
+		required int64 age;
+		optional double score;
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file bytes.Buffer
+	fw := goparquet.NewFileWriter(&file, goparquet.WithSchemaDefinition(sd))
+	rows := []map[string]interface{}{
+		{"name": []byte("alice"), "age": int64(31), "score": 1.5},
+		{"name": []byte("bob"), "age": int64(25)},
+	}
+	for _, r := range rows {
+		if err = fw.AddData(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	in := minio.SelectObjectInputSerialization{Parquet: &minio.ParquetInputOptions{}}
+	var buf bytes.Buffer
+	// A plain reader isn't seekable and gets spooled to a temporary file.
+	r := struct{ io.Reader }{bytes.NewReader(file.Bytes())}
+	if err = Select(context.Background(), &buf, r, "select * from s3object where age > 20", in, jsonOut); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"name\":\"alice\",\"age\":31,\"score\":1.5}\n{\"name\":\"bob\",\"age\":25,\"score\":null}\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	queries := []string{
+		"",
+		"select",
+		"select * from table",
+		"select * from s3object where",
+		"select count(*), name from s3object",
+		"select * from s3object where count(*) > 1",
+		"select * from s3object limit -1",
+		"select 'abc from s3object",
+		"select foo(name) from s3object",
+		"select cast(name as blob) from s3object",
+		"select sum(max(age)) from s3object",
+	}
+	for _, q := range queries {
+		if _, err := Parse(q); err == nil {
+			t.Errorf("%q: expected an error", q)
+		}
+	}
+}
+
+func TestSelectEvalErrors(t *testing.T) {
+	for _, q := range []string{
+		"select name / 2 from s3object",
+		"select age / 0 from s3object where age <> ''",
+		"select sum(name) from s3object",
+	} {
+		var buf bytes.Buffer
+		if err := Select(context.Background(), &buf, strings.NewReader(testCSV), q, csvIn(), csvOut); err == nil {
+			t.Errorf("%q: expected an error", q)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// recordWriter writes the projected records.
+type recordWriter interface {
+	Write(rec *object) error
+	Flush() error
+}
+
+type csvRecordWriter struct {
+	w           *bufio.Writer
+	fieldDelim  string
+	recordDelim string
+	quote       string
+	quoteEscape string
+	always      bool
+}
+
+func newCSVWriter(w io.Writer, opts *minio.CSVOutputOptions) recordWriter {
+	cw := &csvRecordWriter{
+		w:           bufio.NewWriter(w),
+		fieldDelim:  opts.FieldDelimiter,
+		recordDelim: opts.RecordDelimiter,
+		quote:       opts.QuoteCharacter,
+		quoteEscape: opts.QuoteEscapeCharacter,
+		always:      strings.EqualFold(string(opts.QuoteFields), string(minio.CSVQuoteFieldsAlways)),
+	}
+	if cw.fieldDelim == "" {
+		cw.fieldDelim = ","
+	}
+	if cw.recordDelim == "" {
+		cw.recordDelim = "\n"
+	}
+	if cw.quote == "" {
+		cw.quote = `"`
+	}
+	if cw.quoteEscape == "" {
+		cw.quoteEscape = cw.quote
+	}
+	return cw
+}
+
+func (cw *csvRecordWriter) needsQuotes(s string) bool {
+	return cw.always || strings.Contains(s, cw.fieldDelim) || strings.Contains(s, cw.recordDelim) ||
+		strings.Contains(s, cw.quote) || strings.ContainsAny(s, "\r\n")
+}
+
+func (cw *csvRecordWriter) Write(rec *object) error {
+	for i, v := range rec.values {
+		if i > 0 {
+			cw.w.WriteString(cw.fieldDelim)
+		}
+		s := toString(v)
+		if !cw.needsQuotes(s) {
+			cw.w.WriteString(s)
+			continue
+		}
+		cw.w.WriteString(cw.quote)
+		cw.w.WriteString(strings.ReplaceAll(s, cw.quote, cw.quoteEscape+cw.quote))
+		cw.w.WriteString(cw.quote)
+	}
+	_, err := cw.w.WriteString(cw.recordDelim)
+	return err
+}
+
+func (cw *csvRecordWriter) Flush() error {
+	return cw.w.Flush()
+}
+
+type jsonRecordWriter struct {
+	w           *bufio.Writer
+	recordDelim string
+}
+
+func newJSONWriter(w io.Writer, opts *minio.JSONOutputOptions) recordWriter {
+	jw := &jsonRecordWriter{w: bufio.NewWriter(w), recordDelim: opts.RecordDelimiter}
+	if jw.recordDelim == "" {
+		jw.recordDelim = "\n"
+	}
+	return jw
+}
+
+func (jw *jsonRecordWriter) Write(rec *object) error {
+	b, err := marshalJSON(rec)
+	if err != nil {
+		return err
+	}
+	jw.w.Write(b)
+	_, err = jw.w.WriteString(jw.recordDelim)
+	return err
+}
+
+func (jw *jsonRecordWriter) Flush() error {
+	return jw.w.Flush()
+}
+
+// marshalJSON encodes a value as JSON, missing object fields are left out.
+func marshalJSON(v value) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := appendJSON(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func appendJSONString(buf *bytes.Buffer, s string) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline.
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+func appendJSON(buf *bytes.Buffer, v value) error {
+	switch x := v.(type) {
+	case nil, missingType:
+		buf.WriteString("null")
+	case string:
+		return appendJSONString(buf, x)
+	case int64:
+		buf.WriteString(strconv.FormatInt(x, 10))
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			buf.WriteString("null")
+		} else {
+			buf.WriteString(strconv.FormatFloat(x, 'f', -1, 64))
+		}
+	case bool:
+		buf.WriteString(strconv.FormatBool(x))
+	case time.Time:
+		return appendJSONString(buf, x.Format(time.RFC3339Nano))
+	case *object:
+		buf.WriteByte('{')
+		first := true
+		for i, k := range x.keys {
+			if x.values[i] == missing {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			if err := appendJSONString(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := appendJSON(buf, x.values[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []value:
+		buf.WriteByte('[')
+		for i, e := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := appendJSON(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		b, err := json.Marshal(x)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	return nil
+}